@@ -0,0 +1,349 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	// Bench flags
+	benchTarget      string
+	benchConcurrency int
+	benchDuration    time.Duration
+	benchPayloadSize int
+)
+
+// benchTargets maps bench targets to the service type they exercise
+var benchTargets = map[string]string{
+	"cache": "redis",
+	"db":    "postgres",
+	"queue": "kafka",
+}
+
+var benchCmd = &cobra.Command{
+	Use:   "bench [cluster-id]",
+	Short: "Run a load test against a cluster through the gateway",
+	Long: `Drive synthetic load through the gateway API and report throughput and latency
+percentiles measured by the client and by the gateway.
+
+Targets:
+  cache  SET followed by GET of a key per request pair
+  db     SELECT 1
+  queue  PUBLISH to the throome-bench topic`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		clusterID := args[0]
+
+		serviceType, ok := benchTargets[benchTarget]
+		if !ok {
+			fmt.Printf("Error: unknown target '%s' (use cache, db or queue)\n", benchTarget)
+			os.Exit(1)
+		}
+		if benchConcurrency < 1 {
+			fmt.Println("Error: concurrency must be at least 1")
+			os.Exit(1)
+		}
+
+		httpClient := &http.Client{
+			Timeout: 10 * time.Second,
+			Transport: &http.Transport{
+				MaxIdleConns:        benchConcurrency,
+				MaxIdleConnsPerHost: benchConcurrency,
+			},
+		}
+
+		before, err := fetchServiceMetrics(httpClient, clusterID, serviceType)
+		if err != nil {
+			fmt.Printf("Error: cannot reach gateway at %s: %v\n", gatewayURL, err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("Benchmarking %s on cluster %s (%d workers, %s)...\n",
+			benchTarget, clusterID, benchConcurrency, benchDuration)
+
+		result := runBench(httpClient, clusterID, benchTarget, benchConcurrency, benchDuration, benchPayloadSize)
+
+		after, err := fetchServiceMetrics(httpClient, clusterID, serviceType)
+		if err != nil {
+			fmt.Printf("Warning: failed to fetch gateway metrics: %v\n", err)
+		}
+
+		printBenchResult(result, before, after)
+	},
+}
+
+// benchResult holds the client-side measurements of a run
+type benchResult struct {
+	latencies []time.Duration
+	errors    int
+	elapsed   time.Duration
+	lastError string
+}
+
+// runBench runs workers until the duration elapses and merges their measurements
+func runBench(httpClient *http.Client, clusterID, target string, concurrency int, duration time.Duration, payloadSize int) *benchResult {
+	ctx, cancel := context.WithTimeout(context.Background(), duration)
+	defer cancel()
+
+	payload := bytes.Repeat([]byte("x"), payloadSize)
+	results := make([]*benchResult, concurrency)
+
+	var wg sync.WaitGroup
+	start := time.Now()
+	for worker := 0; worker < concurrency; worker++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+
+			result := &benchResult{}
+			for i := 0; ctx.Err() == nil; i++ {
+				requestStart := time.Now()
+				err := benchRequest(ctx, httpClient, clusterID, target, worker, i, payload)
+				if ctx.Err() != nil {
+					break // Don't count requests cut off by the deadline
+				}
+				if err != nil {
+					result.errors++
+					result.lastError = err.Error()
+					continue
+				}
+				result.latencies = append(result.latencies, time.Since(requestStart))
+			}
+			results[worker] = result
+		}(worker)
+	}
+	wg.Wait()
+
+	merged := &benchResult{elapsed: time.Since(start)}
+	for _, result := range results {
+		merged.latencies = append(merged.latencies, result.latencies...)
+		merged.errors += result.errors
+		if result.lastError != "" {
+			merged.lastError = result.lastError
+		}
+	}
+	sort.Slice(merged.latencies, func(i, j int) bool { return merged.latencies[i] < merged.latencies[j] })
+
+	return merged
+}
+
+// benchRequest performs one unit of work against the target
+func benchRequest(ctx context.Context, httpClient *http.Client, clusterID, target string, worker, i int, payload []byte) error {
+	base := fmt.Sprintf("/api/v1/clusters/%s", clusterID)
+
+	switch target {
+	case "cache":
+		key := fmt.Sprintf("throome:bench:%d:%d", worker, i%100)
+		if err := postJSON(ctx, httpClient, base+"/cache/set", map[string]interface{}{
+			"key": key, "value": string(payload), "ttl": 60,
+		}); err != nil {
+			return err
+		}
+		return postJSON(ctx, httpClient, base+"/cache/get", map[string]interface{}{"key": key})
+	case "db":
+		return postJSON(ctx, httpClient, base+"/db/query", map[string]interface{}{"query": "SELECT 1"})
+	default:
+		return postJSON(ctx, httpClient, base+"/queue/publish", map[string]interface{}{
+			"topic": "throome-bench", "message": payload,
+		})
+	}
+}
+
+// postJSON posts a JSON body to a gateway path and fails on non-2xx responses
+func postJSON(ctx context.Context, httpClient *http.Client, path string, body interface{}) error {
+	if err := callGateway(ctx, httpClient, "POST", path, body, nil); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// gatewayServiceMetrics is the request total and latency sum of the benchmarked services
+type gatewayServiceMetrics struct {
+	totalRequests  int64
+	failedRequests int64
+	latencySum     time.Duration
+}
+
+// serviceMetrics is the part of a service's gateway metrics the benchmark reads
+type serviceMetrics struct {
+	ServiceType    string
+	TotalRequests  int64
+	FailedRequests int64
+	AverageLatency time.Duration
+}
+
+// fetchServiceMetrics sums the gateway metrics of all services of a type in a cluster
+func fetchServiceMetrics(httpClient *http.Client, clusterID, serviceType string) (*gatewayServiceMetrics, error) {
+	req, err := gatewayRequest(context.Background(), "GET", fmt.Sprintf("/api/v1/clusters/%s/metrics", clusterID), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return &gatewayServiceMetrics{}, nil // No requests recorded yet
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError(resp)
+	}
+
+	var body struct {
+		ServiceMetrics map[string]serviceMetrics
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+
+	return sumServiceMetrics(body.ServiceMetrics, serviceType), nil
+}
+
+// sumServiceMetrics sums the metrics of the services of a type
+func sumServiceMetrics(services map[string]serviceMetrics, serviceType string) *gatewayServiceMetrics {
+	metrics := &gatewayServiceMetrics{}
+	for _, svc := range services {
+		if svc.ServiceType != serviceType {
+			continue
+		}
+		metrics.totalRequests += svc.TotalRequests
+		metrics.failedRequests += svc.FailedRequests
+		metrics.latencySum += svc.AverageLatency * time.Duration(svc.TotalRequests)
+	}
+	return metrics
+}
+
+// gatewayDelta is what the gateway recorded for the benchmarked services during a run
+type gatewayDelta struct {
+	requests int64
+	failed   int64
+	average  time.Duration
+}
+
+// diffServiceMetrics returns what the gateway recorded between two fetches of its
+// metrics, or false if it recorded no requests
+func diffServiceMetrics(before, after *gatewayServiceMetrics) (gatewayDelta, bool) {
+	requests := after.totalRequests - before.totalRequests
+	if requests <= 0 {
+		return gatewayDelta{}, false
+	}
+	return gatewayDelta{
+		requests: requests,
+		failed:   after.failedRequests - before.failedRequests,
+		average:  (after.latencySum - before.latencySum) / time.Duration(requests),
+	}, true
+}
+
+// gatewayOverhead estimates the average time a client request spent in the gateway
+// rather than in the backend. Each client request may issue several backend
+// operations (e.g. SET + GET), so the backend time is spread over the client requests.
+func gatewayOverhead(clientAverage time.Duration, clientRequests int, delta gatewayDelta) time.Duration {
+	if clientRequests <= 0 {
+		return 0
+	}
+	perRequest := delta.average * time.Duration(delta.requests) / time.Duration(clientRequests)
+	return clientAverage - perRequest
+}
+
+// latencySummary is the average and percentiles of the latencies of a run
+type latencySummary struct {
+	average time.Duration
+	p50     time.Duration
+	p90     time.Duration
+	p95     time.Duration
+	p99     time.Duration
+	max     time.Duration
+}
+
+// summarizeLatencies summarizes sorted latencies
+func summarizeLatencies(sorted []time.Duration) latencySummary {
+	var sum time.Duration
+	for _, latency := range sorted {
+		sum += latency
+	}
+	var average time.Duration
+	if len(sorted) > 0 {
+		average = sum / time.Duration(len(sorted))
+	}
+
+	return latencySummary{
+		average: average,
+		p50:     percentile(sorted, 50),
+		p90:     percentile(sorted, 90),
+		p95:     percentile(sorted, 95),
+		p99:     percentile(sorted, 99),
+		max:     percentile(sorted, 100),
+	}
+}
+
+// percentile returns the p-th percentile of sorted latencies
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	index := int(float64(len(sorted)-1) * p / 100)
+	return sorted[index]
+}
+
+// printBenchResult prints client-side and gateway-side results
+func printBenchResult(result *benchResult, before, after *gatewayServiceMetrics) {
+	succeeded := len(result.latencies)
+	total := succeeded + result.errors
+	latencies := summarizeLatencies(result.latencies)
+
+	fmt.Printf("\nClient:\n")
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "  Requests\t%d (%d failed)\n", total, result.errors)
+	fmt.Fprintf(w, "  Throughput\t%.1f req/s\n", float64(succeeded)/result.elapsed.Seconds())
+	fmt.Fprintf(w, "  Latency avg\t%s\n", latencies.average.Round(time.Microsecond))
+	fmt.Fprintf(w, "  Latency p50\t%s\n", latencies.p50.Round(time.Microsecond))
+	fmt.Fprintf(w, "  Latency p90\t%s\n", latencies.p90.Round(time.Microsecond))
+	fmt.Fprintf(w, "  Latency p95\t%s\n", latencies.p95.Round(time.Microsecond))
+	fmt.Fprintf(w, "  Latency p99\t%s\n", latencies.p99.Round(time.Microsecond))
+	fmt.Fprintf(w, "  Latency max\t%s\n", latencies.max.Round(time.Microsecond))
+	w.Flush()
+
+	if result.lastError != "" {
+		fmt.Printf("  Last error: %s\n", result.lastError)
+	}
+
+	if before == nil || after == nil {
+		return
+	}
+
+	delta, ok := diffServiceMetrics(before, after)
+	if !ok {
+		fmt.Printf("\nGateway: no backend requests recorded\n")
+		return
+	}
+
+	fmt.Printf("\nGateway:\n")
+	w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "  Backend requests\t%d (%d failed)\n", delta.requests, delta.failed)
+	fmt.Fprintf(w, "  Backend latency avg\t%s\n", delta.average.Round(time.Microsecond))
+	if succeeded > 0 {
+		fmt.Fprintf(w, "  Gateway overhead avg\t%s\n", gatewayOverhead(latencies.average, total, delta).Round(time.Microsecond))
+	}
+	w.Flush()
+}
+
+func init() {
+	benchCmd.Flags().StringVar(&benchTarget, "target", "cache", "Service to load: cache, db or queue")
+	benchCmd.Flags().IntVar(&benchConcurrency, "concurrency", 10, "Number of concurrent workers")
+	benchCmd.Flags().DurationVar(&benchDuration, "duration", 30*time.Second, "How long to run the benchmark")
+	benchCmd.Flags().IntVar(&benchPayloadSize, "payload-size", 128, "Size in bytes of cache values and queue messages")
+
+	rootCmd.AddCommand(benchCmd)
+}
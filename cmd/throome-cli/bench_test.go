@@ -0,0 +1,85 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPercentile(t *testing.T) {
+	latencies := make([]time.Duration, 100)
+	for i := range latencies {
+		latencies[i] = time.Duration(i+1) * time.Millisecond
+	}
+
+	tests := []struct {
+		sorted   []time.Duration
+		p        float64
+		expected time.Duration
+	}{
+		{nil, 50, 0},
+		{[]time.Duration{7 * time.Millisecond}, 99, 7 * time.Millisecond},
+		{latencies, 0, time.Millisecond},
+		{latencies, 50, 50 * time.Millisecond},
+		{latencies, 90, 90 * time.Millisecond},
+		{latencies, 99, 99 * time.Millisecond},
+		{latencies, 100, 100 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		if got := percentile(tt.sorted, tt.p); got != tt.expected {
+			t.Errorf("percentile of %d latencies at p%.0f = %s, expected %s", len(tt.sorted), tt.p, got, tt.expected)
+		}
+	}
+}
+
+func TestSummarizeLatencies(t *testing.T) {
+	summary := summarizeLatencies([]time.Duration{time.Millisecond, 2 * time.Millisecond, 3 * time.Millisecond, 10 * time.Millisecond})
+	if summary.average != 4*time.Millisecond || summary.p50 != 2*time.Millisecond || summary.max != 10*time.Millisecond {
+		t.Errorf("Unexpected summary: %+v", summary)
+	}
+
+	if summary := summarizeLatencies(nil); summary != (latencySummary{}) {
+		t.Errorf("Expected an empty summary without latencies, got %+v", summary)
+	}
+}
+
+func TestSumServiceMetrics(t *testing.T) {
+	metrics := sumServiceMetrics(map[string]serviceMetrics{
+		"cache":   {ServiceType: "redis", TotalRequests: 10, FailedRequests: 1, AverageLatency: 2 * time.Millisecond},
+		"replica": {ServiceType: "redis", TotalRequests: 30, AverageLatency: 4 * time.Millisecond},
+		"db":      {ServiceType: "postgres", TotalRequests: 100, AverageLatency: time.Second},
+	}, "redis")
+
+	if metrics.totalRequests != 40 || metrics.failedRequests != 1 || metrics.latencySum != 140*time.Millisecond {
+		t.Errorf("Expected the redis services only, got %+v", metrics)
+	}
+}
+
+func TestDiffServiceMetrics(t *testing.T) {
+	before := &gatewayServiceMetrics{totalRequests: 100, failedRequests: 2, latencySum: time.Second}
+	after := &gatewayServiceMetrics{totalRequests: 300, failedRequests: 5, latencySum: 2 * time.Second}
+
+	delta, ok := diffServiceMetrics(before, after)
+	if !ok {
+		t.Fatal("Expected the requests of the run to be recorded")
+	}
+	if delta.requests != 200 || delta.failed != 3 || delta.average != 5*time.Millisecond {
+		t.Errorf("Unexpected delta: %+v", delta)
+	}
+
+	// Two backend operations of 5ms per client request of 15ms leave 5ms in the gateway
+	if overhead := gatewayOverhead(15*time.Millisecond, 100, delta); overhead != 5*time.Millisecond {
+		t.Errorf("Expected an overhead of 5ms, got %s", overhead)
+	}
+	if overhead := gatewayOverhead(15*time.Millisecond, 0, delta); overhead != 0 {
+		t.Errorf("Expected no overhead without client requests, got %s", overhead)
+	}
+
+	// Counters that did not move, or went back after a gateway restart, record nothing
+	if _, ok := diffServiceMetrics(after, after); ok {
+		t.Error("Expected no requests to be recorded without new requests")
+	}
+	if _, ok := diffServiceMetrics(after, before); ok {
+		t.Error("Expected no requests to be recorded after the counters were reset")
+	}
+}
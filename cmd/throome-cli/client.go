@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// defaultHTTPClient sends the requests of commands that make a few of them
+var defaultHTTPClient = &http.Client{Timeout: 10 * time.Second}
+
+// gatewayRequest creates a request to the gateway API authenticated with the --api-key
+// flag. A non-nil body is sent as JSON.
+func gatewayRequest(ctx context.Context, method, path string, body interface{}) (*http.Request, error) {
+	var content io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		content = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, gatewayURL+path, content)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if apiKey != "" {
+		req.Header.Set("X-API-Key", apiKey)
+	}
+	return req, nil
+}
+
+// callGateway sends a request to the gateway API and decodes its JSON response into
+// out, unless out is nil. Responses other than 2xx are returned as errors.
+func callGateway(ctx context.Context, httpClient *http.Client, method, path string, body, out interface{}) error {
+	req, err := gatewayRequest(ctx, method, path, body)
+	if err != nil {
+		return err
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return responseError(resp)
+	}
+	if out == nil {
+		_, _ = io.Copy(io.Discard, resp.Body) //nolint:errcheck // Drain body so the connection is reused
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// responseError returns the error of a failed gateway response, with its details
+func responseError(resp *http.Response) error {
+	var body struct {
+		Error   string `json:"error"`
+		Details string `json:"details"`
+	}
+	content, _ := io.ReadAll(resp.Body) //nolint:errcheck // The status alone is reported when the body cannot be read
+	if json.Unmarshal(content, &body) != nil || body.Error == "" {
+		return fmt.Errorf("gateway returned %d", resp.StatusCode)
+	}
+	if body.Details != "" {
+		return fmt.Errorf("%s: %s", body.Error, body.Details)
+	}
+	return fmt.Errorf("%s", body.Error)
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCallGatewaySendsAPIKey(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-API-Key") != "thr_secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "Invalid API key", "details": "unknown API key"})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"path": r.URL.Path, "content_type": r.Header.Get("Content-Type")})
+	}))
+	defer server.Close()
+
+	defer func(url, key string) { gatewayURL, apiKey = url, key }(gatewayURL, apiKey)
+	gatewayURL = server.URL
+
+	apiKey = ""
+	err := callGateway(context.Background(), server.Client(), "GET", "/api/v1/clusters", nil, nil)
+	if err == nil || err.Error() != "Invalid API key: unknown API key" {
+		t.Errorf("Expected the gateway's error without a key, got %v", err)
+	}
+
+	apiKey = "thr_secret"
+	var body map[string]string
+	if err := callGateway(context.Background(), server.Client(), "POST", "/api/v1/clusters/c1/purge", map[string]bool{}, &body); err != nil {
+		t.Fatalf("Expected the key to be accepted, got %v", err)
+	}
+	if body["path"] != "/api/v1/clusters/c1/purge" || body["content_type"] != "application/json" {
+		t.Errorf("Expected a JSON request to the path, got %v", body)
+	}
+}
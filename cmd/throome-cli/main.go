@@ -1,7 +1,10 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"net/http"
 	"os"
 	"path/filepath"
 	"text/tabwriter"
@@ -21,10 +24,16 @@ var (
 
 	// Global flags
 	clustersDir string
+	gatewayURL  string
+	apiKey      string
 	verbose     bool
 
 	// Command-specific flags
-	clusterName string
+	clusterName   string
+	listArchived  bool
+	purgeCluster  bool
+	composeOutput string
+	composeReveal bool
 )
 
 func main() {
@@ -99,43 +108,43 @@ var createClusterCmd = &cobra.Command{
 
 var listClustersCmd = &cobra.Command{
 	Use:   "list-clusters",
-	Short: "List all clusters",
+	Short: "List the clusters of the gateway",
 	Run: func(cmd *cobra.Command, args []string) {
-		manager := cluster.NewManager(clustersDir)
-
-		clusterIDs, err := manager.List()
-		if err != nil {
+		var clusters []struct {
+			ID         string        `json:"id"`
+			Namespace  string        `json:"namespace"`
+			Name       string        `json:"name"`
+			Services   []interface{} `json:"services"`
+			ArchivedAt string        `json:"archived_at"`
+		}
+		path := fmt.Sprintf("/api/v1/clusters?archived=%t", listArchived)
+		if err := callGateway(context.Background(), defaultHTTPClient, "GET", path, nil, &clusters); err != nil {
 			fmt.Printf("Error listing clusters: %v\n", err)
 			os.Exit(1)
 		}
 
-		if len(clusterIDs) == 0 {
+		if len(clusters) == 0 {
 			fmt.Println("No clusters found.")
 			return
 		}
 
-		// Load cluster details
-		if err := manager.LoadAll(); err != nil {
-			logger.Error("Failed to load clusters", zap.Error(err))
-		}
-
-		configs := manager.GetAllConfigs()
-
 		// Print table
 		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-		fmt.Fprintln(w, "CLUSTER ID\tNAME\tSERVICES\tCREATED")
-		fmt.Fprintln(w, "----------\t----\t--------\t-------")
-
-		for _, id := range clusterIDs {
-			config := configs[id]
-			if config != nil {
-				fmt.Fprintf(w, "%s\t%s\t%d\t%s\n",
-					config.ClusterID,
-					config.Name,
-					len(config.Services),
-					config.CreatedAt.Format("2006-01-02"),
-				)
+		fmt.Fprintln(w, "CLUSTER ID\tNAME\tNAMESPACE\tSERVICES\tARCHIVED")
+		fmt.Fprintln(w, "----------\t----\t---------\t--------\t--------")
+
+		for _, c := range clusters {
+			archivedAt := "-"
+			if t, err := time.Parse(time.RFC3339, c.ArchivedAt); err == nil {
+				archivedAt = t.Format("2006-01-02")
 			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\n",
+				c.ID,
+				c.Name,
+				c.Namespace,
+				len(c.Services),
+				archivedAt,
+			)
 		}
 
 		w.Flush()
@@ -178,20 +187,79 @@ var getClusterCmd = &cobra.Command{
 		fmt.Printf("\nRouting Strategy: %s\n", config.Routing.Strategy)
 		fmt.Printf("Health Checks: %v\n", config.Health.Enabled)
 		fmt.Printf("AI Optimization: %v\n", config.AI.Enabled)
+
+		printRecommendations(clusterID)
 	},
 }
 
+// printRecommendations prints pool recommendations from a running gateway, if reachable
+func printRecommendations(clusterID string) {
+	var body struct {
+		Recommendations []struct {
+			ServiceName string `json:"service_name"`
+			Severity    string `json:"severity"`
+			Reason      string `json:"reason"`
+			Current     struct {
+				MaxConnections int `json:"max_connections"`
+			} `json:"current"`
+			Recommended struct {
+				MaxConnections int `json:"max_connections"`
+			} `json:"recommended"`
+		} `json:"recommendations"`
+	}
+	httpClient := &http.Client{Timeout: 3 * time.Second}
+	path := fmt.Sprintf("/api/v1/clusters/%s/recommendations", clusterID)
+	if err := callGateway(context.Background(), httpClient, "GET", path, nil, &body); err != nil {
+		logger.Debug("Gateway not reachable, skipping recommendations", zap.Error(err))
+		return
+	}
+
+	fmt.Printf("\nRecommendations (%d):\n", len(body.Recommendations))
+	if len(body.Recommendations) == 0 {
+		fmt.Println("  No changes recommended.")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SERVICE\tSEVERITY\tMAX POOL\tREASON")
+	fmt.Fprintln(w, "-------\t--------\t--------\t------")
+	for _, rec := range body.Recommendations {
+		fmt.Fprintf(w, "%s\t%s\t%d -> %d\t%s\n",
+			rec.ServiceName,
+			rec.Severity,
+			rec.Current.MaxConnections,
+			rec.Recommended.MaxConnections,
+			rec.Reason,
+		)
+	}
+	w.Flush()
+}
+
 var deleteClusterCmd = &cobra.Command{
 	Use:   "delete-cluster [cluster-id]",
-	Short: "Delete a cluster",
-	Args:  cobra.ExactArgs(1),
+	Short: "Archive a cluster, or delete it permanently with --purge",
+	Long: `Archive a cluster through the gateway, stopping its containers but keeping its
+configuration and volumes so it can be restored. Archived clusters are purged
+automatically after the gateway's retention window. Use --purge to delete the
+cluster and its containers permanently instead.`,
+	Args: cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		clusterID := args[0]
+		ctx := context.Background()
 
-		manager := cluster.NewManager(clustersDir)
+		if !purgeCluster {
+			path := fmt.Sprintf("/api/v1/clusters/%s", clusterID)
+			if err := callGateway(ctx, defaultHTTPClient, "DELETE", path, nil, nil); err != nil {
+				fmt.Printf("Error archiving cluster: %v\n", err)
+				os.Exit(1)
+			}
+
+			fmt.Printf("✓ Cluster '%s' archived. Use --purge to delete it permanently.\n", clusterID)
+			return
+		}
 
 		// Confirm deletion
-		fmt.Printf("Are you sure you want to delete cluster '%s'? (yes/no): ", clusterID)
+		fmt.Printf("Are you sure you want to permanently delete cluster '%s'? (yes/no): ", clusterID)
 		var confirm string
 		_, _ = fmt.Scanln(&confirm) //nolint:errcheck // User input errors are handled by empty string default
 
@@ -200,7 +268,10 @@ var deleteClusterCmd = &cobra.Command{
 			return
 		}
 
-		if err := manager.Delete(clusterID); err != nil {
+		// Removing containers and volumes can take longer than other requests
+		httpClient := &http.Client{Timeout: 2 * time.Minute}
+		path := fmt.Sprintf("/api/v1/clusters/%s/purge", clusterID)
+		if err := callGateway(ctx, httpClient, "POST", path, nil, nil); err != nil {
 			fmt.Printf("Error deleting cluster: %v\n", err)
 			os.Exit(1)
 		}
@@ -209,6 +280,76 @@ var deleteClusterCmd = &cobra.Command{
 	},
 }
 
+var restoreClusterCmd = &cobra.Command{
+	Use:   "restore-cluster [cluster-id]",
+	Short: "Restore an archived cluster",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		clusterID := args[0]
+
+		httpClient := &http.Client{Timeout: 2 * time.Minute} // Containers are started again
+		path := fmt.Sprintf("/api/v1/clusters/%s/restore", clusterID)
+		if err := callGateway(context.Background(), httpClient, "POST", path, nil, nil); err != nil {
+			fmt.Printf("Error restoring cluster: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("✓ Cluster '%s' restored successfully!\n", clusterID)
+	},
+}
+
+var exportComposeCmd = &cobra.Command{
+	Use:   "export-compose [cluster-id]",
+	Short: "Render a cluster's services as a docker-compose.yaml",
+	Long: `Render a cluster's services into a docker-compose.yaml with the images, environment,
+ports, volumes and health checks Throome provisions them with, so the same topology
+can run outside Throome. Credentials are rendered as variables unless revealed with
+--reveal-secrets, which needs an admin API key.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		clusterID := args[0]
+
+		content, err := fetchCompose(clusterID, composeReveal)
+		if err != nil {
+			fmt.Printf("Error rendering compose file: %v\n", err)
+			os.Exit(1)
+		}
+
+		if composeOutput == "" {
+			fmt.Print(string(content))
+			return
+		}
+		if err := os.WriteFile(composeOutput, content, 0600); err != nil {
+			fmt.Printf("Error writing compose file: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("✓ Compose file written to %s\n", composeOutput)
+	},
+}
+
+// fetchCompose gets the docker-compose.yaml of a cluster from the gateway
+func fetchCompose(clusterID string, reveal bool) ([]byte, error) {
+	path := fmt.Sprintf("/api/v1/clusters/%s/compose", clusterID)
+	if reveal {
+		path += "?reveal_secrets=true"
+	}
+	req, err := gatewayRequest(context.Background(), "GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := defaultHTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError(resp)
+	}
+	return io.ReadAll(resp.Body)
+}
+
 var validateConfigCmd = &cobra.Command{
 	Use:   "validate-config [config-file]",
 	Short: "Validate a cluster configuration file",
@@ -235,17 +376,29 @@ var validateConfigCmd = &cobra.Command{
 func init() {
 	// Global flags
 	rootCmd.PersistentFlags().StringVar(&clustersDir, "clusters-dir", "./clusters", "Path to clusters directory")
+	rootCmd.PersistentFlags().StringVar(&gatewayURL, "gateway", "http://localhost:9000", "Throome gateway URL")
+	rootCmd.PersistentFlags().StringVar(&apiKey, "api-key", os.Getenv("THROOME_API_KEY"), "API key for gateways with authentication enabled")
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "Verbose output")
 
 	// Create cluster flags
 	createClusterCmd.Flags().StringVar(&clusterName, "name", "", "Cluster name (required)")
 	_ = createClusterCmd.MarkFlagRequired("name") //nolint:errcheck // Flag is defined in same function, error impossible
 
+	// List and delete cluster flags
+	listClustersCmd.Flags().BoolVar(&listArchived, "archived", false, "List archived clusters instead of active ones")
+	deleteClusterCmd.Flags().BoolVar(&purgeCluster, "purge", false, "Delete the cluster permanently instead of archiving it")
+
+	// Export compose flags
+	exportComposeCmd.Flags().StringVarP(&composeOutput, "output", "o", "", "File to write, standard output by default")
+	exportComposeCmd.Flags().BoolVar(&composeReveal, "reveal-secrets", false, "Render credentials instead of variables")
+
 	// Add commands
 	rootCmd.AddCommand(versionCmd)
 	rootCmd.AddCommand(createClusterCmd)
 	rootCmd.AddCommand(listClustersCmd)
 	rootCmd.AddCommand(getClusterCmd)
 	rootCmd.AddCommand(deleteClusterCmd)
+	rootCmd.AddCommand(restoreClusterCmd)
+	rootCmd.AddCommand(exportComposeCmd)
 	rootCmd.AddCommand(validateConfigCmd)
 }
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/akmadan/throome/pkg/cluster"
+)
+
+var (
+	// Set routing flags
+	routingStrategy      string
+	routingFailover      bool
+	routingTimeoutMS     int
+	routingRetryAttempts int
+)
+
+var setRoutingCmd = &cobra.Command{
+	Use:   "set-routing [cluster-id]",
+	Short: "Change the routing settings of a running cluster",
+	Long: `Change the routing strategy, timeout, retries or failover of a cluster through the
+gateway. The change applies to the cluster's next requests without restarting the
+gateway, and is saved to the cluster's config.yaml. Settings without a flag are kept.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		clusterID := args[0]
+
+		changes := make(map[string]interface{})
+		if cmd.Flags().Changed("strategy") {
+			changes["strategy"] = routingStrategy
+		}
+		if cmd.Flags().Changed("failover") {
+			changes["failover_enabled"] = routingFailover
+		}
+		if cmd.Flags().Changed("timeout-ms") {
+			changes["timeout_ms"] = routingTimeoutMS
+		}
+		if cmd.Flags().Changed("retry-attempts") {
+			changes["retry_attempts"] = routingRetryAttempts
+		}
+		if len(changes) == 0 {
+			fmt.Println("Error: nothing to change (use --strategy, --failover, --timeout-ms or --retry-attempts)")
+			os.Exit(1)
+		}
+
+		routing, err := patchRouting(clusterID, changes)
+		if err != nil {
+			fmt.Printf("Error updating routing: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("✓ Routing of cluster '%s' updated\n", clusterID)
+		fmt.Printf("  Strategy: %s\n", routing.Strategy)
+		fmt.Printf("  Failover: %t\n", routing.FailoverEnabled)
+		fmt.Printf("  Timeout: %dms\n", routing.TimeoutMS)
+		fmt.Printf("  Retry attempts: %d\n", routing.RetryAttempts)
+	},
+}
+
+// patchRouting sends routing changes of a cluster to the gateway and returns the
+// cluster's new routing settings
+func patchRouting(clusterID string, changes map[string]interface{}) (*cluster.RoutingConfig, error) {
+	var routing cluster.RoutingConfig
+	path := fmt.Sprintf("/api/v1/clusters/%s/routing", clusterID)
+	if err := callGateway(context.Background(), defaultHTTPClient, "PATCH", path, changes, &routing); err != nil {
+		return nil, err
+	}
+	return &routing, nil
+}
+
+func init() {
+	setRoutingCmd.Flags().StringVar(&routingStrategy, "strategy", "", "Routing strategy: round_robin, weighted, least_connections, ai, latency or a registered one")
+	setRoutingCmd.Flags().BoolVar(&routingFailover, "failover", true, "Whether to fail over to other services")
+	setRoutingCmd.Flags().IntVar(&routingTimeoutMS, "timeout-ms", 0, "Limit of each attempt of a data operation in milliseconds, none when 0")
+	setRoutingCmd.Flags().IntVar(&routingRetryAttempts, "retry-attempts", 0, "Retries of data operations after their first attempt")
+
+	rootCmd.AddCommand(setRoutingCmd)
+}
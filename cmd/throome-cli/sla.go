@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/akmadan/throome/pkg/monitor"
+)
+
+var (
+	// SLA flags
+	slaIncidents bool
+)
+
+// clusterSLA is the availability report of a cluster from the gateway
+type clusterSLA struct {
+	ClusterID   string    `json:"cluster_id"`
+	GeneratedAt time.Time `json:"generated_at"`
+	Services    []struct {
+		ServiceName string             `json:"service_name"`
+		ServiceType string             `json:"service_type"`
+		Healthy     bool               `json:"healthy"`
+		Weekly      monitor.SLAReport  `json:"weekly"`
+		Monthly     monitor.SLAReport  `json:"monthly"`
+		Incidents   []monitor.Incident `json:"incidents"`
+	} `json:"services"`
+}
+
+var slaCmd = &cobra.Command{
+	Use:   "sla [cluster-id]",
+	Short: "Report the uptime and incidents of a cluster's services",
+	Long: `Print the weekly and monthly uptime, downtime and mean time to recovery of each
+service of a cluster, computed by the gateway from its health checks. Health checks
+are kept in memory, so the report starts over when the gateway restarts.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		clusterID := args[0]
+
+		sla, err := fetchSLA(clusterID)
+		if err != nil {
+			fmt.Printf("Error fetching SLA: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("SLA of cluster '%s' at %s\n\n", sla.ClusterID, sla.GeneratedAt.Format(time.RFC3339))
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "SERVICE\tTYPE\tSTATUS\tWEEK\tMONTH\tINCIDENTS\tDOWNTIME\tMTTR")
+		fmt.Fprintln(w, "-------\t----\t------\t----\t-----\t---------\t--------\t----")
+		for _, svc := range sla.Services {
+			status := "healthy"
+			if !svc.Healthy {
+				status = "unhealthy"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%.3f%%\t%.3f%%\t%d\t%s\t%s\n",
+				svc.ServiceName,
+				svc.ServiceType,
+				status,
+				svc.Weekly.UptimePercentage,
+				svc.Monthly.UptimePercentage,
+				svc.Monthly.Incidents,
+				svc.Monthly.Downtime.Round(time.Second),
+				svc.Monthly.MTTR.Round(time.Second),
+			)
+		}
+		w.Flush()
+
+		if !slaIncidents {
+			return
+		}
+
+		fmt.Printf("\nIncidents:\n")
+		w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "SERVICE\tSTART\tEND\tDURATION\tERROR")
+		fmt.Fprintln(w, "-------\t-----\t---\t--------\t-----")
+		for _, svc := range sla.Services {
+			for _, incident := range svc.Incidents {
+				end := "ongoing"
+				if incident.End != nil {
+					end = incident.End.Format(time.RFC3339)
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+					svc.ServiceName,
+					incident.Start.Format(time.RFC3339),
+					end,
+					incident.DurationAt(sla.GeneratedAt).Round(time.Second),
+					incident.Error,
+				)
+			}
+		}
+		w.Flush()
+	},
+}
+
+// fetchSLA gets the availability report of a cluster from the gateway
+func fetchSLA(clusterID string) (*clusterSLA, error) {
+	var sla clusterSLA
+	path := fmt.Sprintf("/api/v1/clusters/%s/sla", clusterID)
+	if err := callGateway(context.Background(), defaultHTTPClient, "GET", path, nil, &sla); err != nil {
+		return nil, err
+	}
+	return &sla, nil
+}
+
+func init() {
+	slaCmd.Flags().BoolVar(&slaIncidents, "incidents", false, "Also list the incidents of the last month")
+
+	rootCmd.AddCommand(slaCmd)
+}
@@ -56,24 +56,25 @@ func main() {
 		logger.Fatal("Failed to load configuration", zap.Error(err))
 	}
 
-	// Override with command-line flags
-	if *port != 9000 {
-		cfg.Server.Port = *port
-	}
-	if *clustersDir != "./clusters" {
-		cfg.Gateway.ClustersDir = *clustersDir
-	}
-
 	// Validate configuration
 	if err := cfg.Validate(); err != nil {
 		logger.Fatal("Invalid configuration", zap.Error(err))
 	}
+	if err := logger.SetLevel(cfg.Logging.Level); err != nil {
+		logger.Fatal("Invalid log level", zap.Error(err))
+	}
 
 	// Create gateway
 	gw, err := gateway.NewGateway(cfg.Gateway.ClustersDir)
 	if err != nil {
 		logger.Fatal("Failed to create gateway", zap.Error(err))
 	}
+	gw.SetCollectionInterval(time.Duration(cfg.Monitoring.CollectionInterval) * time.Second)
+	gw.SetActivityBufferSize(cfg.Monitoring.ActivityBufferSize)
+	gw.SetArchiveRetention(time.Duration(cfg.Gateway.ArchiveRetention) * time.Hour)
+	if cfg.Alerting.Enabled {
+		gw.SetAlertEngine(gateway.NewAlertEngine(cfg.Alerting), time.Duration(cfg.Alerting.Interval)*time.Second)
+	}
 
 	// Initialize gateway
 	ctx := context.Background()
@@ -83,6 +84,20 @@ func main() {
 
 	// Create HTTP server
 	server := gateway.NewServer(cfg, gw)
+	if *configFile != "" {
+		server.SetConfigLoader(loadConfig)
+	}
+
+	// Reload the configuration on SIGHUP
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+	go func() {
+		for range reload {
+			if err := server.ReloadConfig(); err != nil {
+				logger.Error("Failed to reload configuration", zap.Error(err))
+			}
+		}
+	}()
 
 	// Setup graceful shutdown
 	shutdown := make(chan os.Signal, 1)
@@ -122,12 +137,26 @@ func main() {
 	logger.Info("Throome Gateway stopped")
 }
 
-// loadConfig loads the application configuration
+// loadConfig loads the application configuration, overridden by command-line flags
 func loadConfig() (*config.AppConfig, error) {
+	cfg := config.DefaultConfig()
 	if *configFile != "" {
-		return config.LoadConfig(*configFile)
+		var err error
+		if cfg, err = config.LoadConfig(*configFile); err != nil {
+			return nil, err
+		}
+	}
+
+	// Override with command-line flags
+	if *port != 9000 {
+		cfg.Server.Port = *port
+	}
+	if *clustersDir != "./clusters" {
+		cfg.Gateway.ClustersDir = *clustersDir
+	}
+	if *logLevel != "info" {
+		cfg.Logging.Level = *logLevel
 	}
 
-	// Return default configuration
-	return config.DefaultConfig(), nil
+	return cfg, nil
 }
@@ -15,22 +15,94 @@ type AppConfig struct {
 	Dashboard  DashboardConfig  `yaml:"dashboard"`
 	Monitoring MonitoringConfig `yaml:"monitoring"`
 	Logging    LoggingConfig    `yaml:"logging"`
+	Auth       AuthConfig       `yaml:"auth"`
+	Alerting   AlertingConfig   `yaml:"alerting"`
 }
 
 // ServerConfig holds HTTP server configuration
 type ServerConfig struct {
-	Host         string `yaml:"host"`
-	Port         int    `yaml:"port"`
-	ReadTimeout  int    `yaml:"read_timeout"`  // seconds
-	WriteTimeout int    `yaml:"write_timeout"` // seconds
+	Host         string            `yaml:"host"`
+	Port         int               `yaml:"port"`
+	ReadTimeout  int               `yaml:"read_timeout"`  // seconds
+	WriteTimeout int               `yaml:"write_timeout"` // seconds
+	TLS          ServerTLSConfig   `yaml:"tls"`
+	GRPC         GRPCConfig        `yaml:"grpc"`
+	Compression  CompressionConfig `yaml:"compression"`
+	CORS         CORSConfig        `yaml:"cors"`
+}
+
+// CORSConfig holds the cross-origin requests the API allows
+type CORSConfig struct {
+	AllowedOrigins []string `yaml:"allowed_origins"` // Origins allowed to call the API, "*" for any
+}
+
+// ServerTLSConfig holds HTTPS settings of the server
+type ServerTLSConfig struct {
+	Enabled      bool   `yaml:"enabled"`
+	CertFile     string `yaml:"cert_file"`
+	KeyFile      string `yaml:"key_file"`
+	MinVersion   string `yaml:"min_version"`    // 1.2 or 1.3
+	ClientCAFile string `yaml:"client_ca_file"` // Require client certificates signed by this CA, when set
+	RedirectPort int    `yaml:"redirect_port"`  // Port redirecting plain HTTP to HTTPS, 0 disables it
+}
+
+// GRPCConfig holds settings of the gRPC API. It uses the TLS settings of the server.
+type GRPCConfig struct {
+	Enabled bool `yaml:"enabled"`
+	Port    int  `yaml:"port"`
+}
+
+// CompressionConfig holds settings of response compression. Responses are compressed
+// with gzip or deflate, as the client accepts, when their content type is listed and
+// their body reaches the minimum size.
+type CompressionConfig struct {
+	Enabled      bool     `yaml:"enabled"`
+	Level        int      `yaml:"level"`         // 1 (fastest) to 9 (smallest)
+	MinSize      int      `yaml:"min_size"`      // bytes a body needs to be compressed
+	ContentTypes []string `yaml:"content_types"` // Media types to compress, "text/*" for all of a type
 }
 
 // GatewayConfig holds gateway-specific configuration
 type GatewayConfig struct {
-	ClustersDir       string `yaml:"clusters_dir"`
-	MaxConnections    int    `yaml:"max_connections"`
-	ConnectionTimeout int    `yaml:"connection_timeout"` // seconds
-	EnableAI          bool   `yaml:"enable_ai"`
+	ClustersDir       string            `yaml:"clusters_dir"`
+	SnapshotsDir      string            `yaml:"snapshots_dir"`
+	MaxConnections    int               `yaml:"max_connections"`
+	ConnectionTimeout int               `yaml:"connection_timeout"` // seconds
+	EnableAI          bool              `yaml:"enable_ai"`
+	ArchiveRetention  int               `yaml:"archive_retention"` // hours archived clusters are kept, 0 keeps them until purged
+	MaxStreamRows     int               `yaml:"max_stream_rows"`   // rows a streamed query returns at most, 0 for no limit
+	Provisioner       ProvisionerConfig `yaml:"provisioner"`
+}
+
+// ProvisionerConfig selects where provisioned services run
+type ProvisionerConfig struct {
+	Type          string           `yaml:"type"`           // docker, podman or kubernetes
+	RemoveOrphans bool             `yaml:"remove_orphans"` // remove provisioned containers of no stored cluster at startup
+	Docker        DockerConfig     `yaml:"docker"`
+	Podman        PodmanConfig     `yaml:"podman"`
+	Kubernetes    KubernetesConfig `yaml:"kubernetes"`
+}
+
+// DockerConfig holds the settings of the Docker provisioner
+type DockerConfig struct {
+	Host     string `yaml:"host"`    // empty uses $DOCKER_HOST, then the local daemon
+	CAFile   string `yaml:"ca_file"` // TLS files for daemons listening on tcp://host:2376
+	CertFile string `yaml:"cert_file"`
+	KeyFile  string `yaml:"key_file"`
+}
+
+// PodmanConfig holds the settings of the Podman provisioner
+type PodmanConfig struct {
+	Socket string `yaml:"socket"` // empty uses $CONTAINER_HOST, then the rootless socket, then /run/podman/podman.sock
+}
+
+// KubernetesConfig holds the settings of the Kubernetes provisioner
+type KubernetesConfig struct {
+	Kubeconfig      string `yaml:"kubeconfig"`       // empty uses the in-cluster configuration
+	Context         string `yaml:"context"`          // empty uses the kubeconfig's current context
+	NamespacePrefix string `yaml:"namespace_prefix"` // each cluster gets the namespace <prefix><cluster name>
+	StorageClass    string `yaml:"storage_class"`    // empty uses the default storage class
+	StorageSize     string `yaml:"storage_size"`     // size of each service's data volume
 }
 
 // DashboardConfig holds dashboard configuration
@@ -44,7 +116,44 @@ type DashboardConfig struct {
 type MonitoringConfig struct {
 	Enabled            bool   `yaml:"enabled"`
 	MetricsPath        string `yaml:"metrics_path"`
-	CollectionInterval int    `yaml:"collection_interval"` // seconds
+	CollectionInterval int    `yaml:"collection_interval"`  // seconds
+	ActivityBufferSize int    `yaml:"activity_buffer_size"` // operations kept for the activity API and export
+}
+
+// AlertingConfig holds the alert rules evaluated on service metrics and health, and
+// the channels their alerts are sent to
+type AlertingConfig struct {
+	Enabled  bool                 `yaml:"enabled"`
+	Interval int                  `yaml:"interval"` // seconds between evaluations of the rules
+	Channels []AlertChannelConfig `yaml:"channels"`
+	Rules    []AlertRuleConfig    `yaml:"rules"`
+}
+
+// AlertChannelConfig is where alerts are sent: a Slack incoming webhook, a generic
+// webhook receiving alerts as JSON, or email recipients
+type AlertChannelConfig struct {
+	Name     string            `yaml:"name"`
+	Type     string            `yaml:"type"`    // slack, webhook or email
+	URL      string            `yaml:"url"`     // URL of slack and webhook channels
+	Headers  map[string]string `yaml:"headers"` // Headers of webhook notifications
+	SMTPHost string            `yaml:"smtp_host"`
+	SMTPPort int               `yaml:"smtp_port"`
+	Username string            `yaml:"username"` // SMTP credentials, when the server needs them
+	Password string            `yaml:"password"`
+	From     string            `yaml:"from"`
+	To       []string          `yaml:"to"`
+}
+
+// AlertRuleConfig fires an alert for each service whose metric crosses a threshold
+// for a duration
+type AlertRuleConfig struct {
+	Name      string   `yaml:"name"`
+	Metric    string   `yaml:"metric"`     // error_rate, p99_latency, unhealthy or anomaly
+	Threshold float64  `yaml:"threshold"`  // percent for error_rate, milliseconds for p99_latency, standard deviations for anomaly
+	For       int      `yaml:"for"`        // seconds the condition must hold before the alert fires
+	ClusterID string   `yaml:"cluster_id"` // all clusters when empty
+	Service   string   `yaml:"service"`    // all services when empty
+	Channels  []string `yaml:"channels"`
 }
 
 // LoggingConfig holds logging configuration
@@ -54,6 +163,40 @@ type LoggingConfig struct {
 	OutputPath  string `yaml:"output_path"`
 }
 
+// AuthConfig holds API authentication configuration
+type AuthConfig struct {
+	Enabled  bool           `yaml:"enabled"`
+	KeysFile string         `yaml:"keys_file"` // Where issued API keys are stored, hashed
+	APIKeys  []StaticAPIKey `yaml:"api_keys"`  // Keys accepted in addition to the issued ones
+	JWT      JWTConfig      `yaml:"jwt"`
+}
+
+// JWTConfig holds bearer token validation settings, for gateways behind an identity
+// provider
+type JWTConfig struct {
+	Enabled        bool   `yaml:"enabled"`
+	Secret         string `yaml:"secret"`          // HMAC secret for HS256/HS384/HS512 tokens
+	JWKSURL        string `yaml:"jwks_url"`        // Key set for RSA and ECDSA tokens
+	JWKSRefresh    int    `yaml:"jwks_refresh"`    // seconds fetched keys are cached
+	Issuer         string `yaml:"issuer"`          // Required "iss" claim, when set
+	Audience       string `yaml:"audience"`        // Required "aud" claim, when set
+	SubjectClaim   string `yaml:"subject_claim"`   // Claim identifying the caller
+	RoleClaim      string `yaml:"role_claim"`      // Claim holding the caller's role or roles
+	DefaultRole    string `yaml:"default_role"`    // Role of tokens naming no valid role
+	NamespaceClaim string `yaml:"namespace_claim"` // Claim limiting the caller to one namespace, when set
+	Leeway         int    `yaml:"leeway"`          // seconds of tolerated clock skew
+}
+
+// StaticAPIKey is an API key defined in the configuration. Prefer the hash, so the
+// key itself is not kept in the file.
+type StaticAPIKey struct {
+	Name      string `yaml:"name"`
+	Key       string `yaml:"key,omitempty"`
+	Hash      string `yaml:"hash,omitempty"`      // Hex-encoded SHA-256 of the key
+	Role      string `yaml:"role,omitempty"`      // admin, operator or read-only; admin by default
+	Namespace string `yaml:"namespace,omitempty"` // Limits the key to the clusters of one namespace
+}
+
 // DefaultConfig returns the default application configuration
 func DefaultConfig() *AppConfig {
 	return &AppConfig{
@@ -62,12 +205,41 @@ func DefaultConfig() *AppConfig {
 			Port:         9000,
 			ReadTimeout:  30,
 			WriteTimeout: 30,
+			TLS: ServerTLSConfig{
+				MinVersion: "1.2",
+			},
+			GRPC: GRPCConfig{
+				Port: 9090,
+			},
+			Compression: CompressionConfig{
+				Level:   6,
+				MinSize: 1024,
+				ContentTypes: []string{
+					"application/json",
+					"application/x-ndjson",
+					"application/javascript",
+					"text/*",
+				},
+			},
+			CORS: CORSConfig{
+				AllowedOrigins: []string{"*"},
+			},
 		},
 		Gateway: GatewayConfig{
 			ClustersDir:       "./clusters",
+			SnapshotsDir:      "./snapshots",
 			MaxConnections:    1000,
 			ConnectionTimeout: 10,
 			EnableAI:          false,
+			ArchiveRetention:  168, // 7 days
+			MaxStreamRows:     1000000,
+			Provisioner: ProvisionerConfig{
+				Type: "docker",
+				Kubernetes: KubernetesConfig{
+					NamespacePrefix: "throome-",
+					StorageSize:     "1Gi",
+				},
+			},
 		},
 		Dashboard: DashboardConfig{
 			Enabled: true,
@@ -78,12 +250,28 @@ func DefaultConfig() *AppConfig {
 			Enabled:            true,
 			MetricsPath:        "/metrics",
 			CollectionInterval: 10,
+			ActivityBufferSize: 1000,
+		},
+		Alerting: AlertingConfig{
+			Enabled:  false,
+			Interval: 30,
 		},
 		Logging: LoggingConfig{
 			Level:       "info",
 			Development: false,
 			OutputPath:  "stdout",
 		},
+		Auth: AuthConfig{
+			Enabled:  false,
+			KeysFile: "./auth/api_keys.json",
+			JWT: JWTConfig{
+				JWKSRefresh:  3600,
+				SubjectClaim: "sub",
+				RoleClaim:    "role",
+				DefaultRole:  "read-only",
+				Leeway:       30,
+			},
+		},
 	}
 }
 
@@ -121,6 +309,20 @@ func LoadConfig(configPath string) (*AppConfig, error) {
 		}
 		config.Gateway.ClustersDir = absPath
 	}
+	if !filepath.IsAbs(config.Gateway.SnapshotsDir) {
+		absPath, err := filepath.Abs(config.Gateway.SnapshotsDir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve snapshots directory: %w", err)
+		}
+		config.Gateway.SnapshotsDir = absPath
+	}
+	if config.Auth.KeysFile != "" && !filepath.IsAbs(config.Auth.KeysFile) {
+		absPath, err := filepath.Abs(config.Auth.KeysFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve API keys file: %w", err)
+		}
+		config.Auth.KeysFile = absPath
+	}
 
 	return config, nil
 }
@@ -131,6 +333,33 @@ func (c *AppConfig) Validate() error {
 		return fmt.Errorf("invalid server port: %d", c.Server.Port)
 	}
 
+	if tlsConfig := c.Server.TLS; tlsConfig.Enabled {
+		if tlsConfig.CertFile == "" || tlsConfig.KeyFile == "" {
+			return fmt.Errorf("TLS requires a certificate and a key file")
+		}
+		if tlsConfig.MinVersion != "" && tlsConfig.MinVersion != "1.2" && tlsConfig.MinVersion != "1.3" {
+			return fmt.Errorf("invalid TLS min version: %s, expected 1.2 or 1.3", tlsConfig.MinVersion)
+		}
+		if tlsConfig.RedirectPort < 0 || tlsConfig.RedirectPort > 65535 || tlsConfig.RedirectPort == c.Server.Port {
+			return fmt.Errorf("invalid TLS redirect port: %d", tlsConfig.RedirectPort)
+		}
+	}
+
+	if grpcConfig := c.Server.GRPC; grpcConfig.Enabled {
+		if grpcConfig.Port < 1 || grpcConfig.Port > 65535 || grpcConfig.Port == c.Server.Port {
+			return fmt.Errorf("invalid gRPC port: %d", grpcConfig.Port)
+		}
+	}
+
+	if compression := c.Server.Compression; compression.Enabled {
+		if compression.Level < 1 || compression.Level > 9 {
+			return fmt.Errorf("invalid compression level: %d, expected 1 to 9", compression.Level)
+		}
+		if compression.MinSize < 0 {
+			return fmt.Errorf("invalid compression min size: %d", compression.MinSize)
+		}
+	}
+
 	if c.Dashboard.Enabled && (c.Dashboard.Port < 1 || c.Dashboard.Port > 65535) {
 		return fmt.Errorf("invalid dashboard port: %d", c.Dashboard.Port)
 	}
@@ -139,6 +368,29 @@ func (c *AppConfig) Validate() error {
 		return fmt.Errorf("clusters directory cannot be empty")
 	}
 
+	if c.Gateway.MaxStreamRows < 0 {
+		return fmt.Errorf("invalid max stream rows: %d", c.Gateway.MaxStreamRows)
+	}
+
+	if c.Monitoring.ActivityBufferSize < 1 {
+		return fmt.Errorf("invalid activity buffer size: %d", c.Monitoring.ActivityBufferSize)
+	}
+
+	if c.Alerting.Enabled {
+		if err := c.Alerting.validate(); err != nil {
+			return err
+		}
+	}
+
+	validProvisioners := map[string]bool{
+		"docker":     true,
+		"podman":     true,
+		"kubernetes": true,
+	}
+	if !validProvisioners[c.Gateway.Provisioner.Type] {
+		return fmt.Errorf("invalid provisioner: %s, expected docker, podman or kubernetes", c.Gateway.Provisioner.Type)
+	}
+
 	validLogLevels := map[string]bool{
 		"debug": true,
 		"info":  true,
@@ -149,5 +401,70 @@ func (c *AppConfig) Validate() error {
 		return fmt.Errorf("invalid log level: %s", c.Logging.Level)
 	}
 
+	for _, key := range c.Auth.APIKeys {
+		if key.Name == "" {
+			return fmt.Errorf("API key name cannot be empty")
+		}
+		if key.Key == "" && key.Hash == "" {
+			return fmt.Errorf("API key %s: key or hash is required", key.Name)
+		}
+	}
+
+	if c.Auth.JWT.Enabled && c.Auth.JWT.Secret == "" && c.Auth.JWT.JWKSURL == "" {
+		return fmt.Errorf("JWT authentication requires a secret or a JWKS URL")
+	}
+
+	return nil
+}
+
+// validate checks that the rules name known metrics and channels, and that channels
+// have where to send alerts
+func (a *AlertingConfig) validate() error {
+	if a.Interval < 1 {
+		return fmt.Errorf("invalid alerting interval: %d", a.Interval)
+	}
+
+	channels := make(map[string]bool, len(a.Channels))
+	for _, channel := range a.Channels {
+		if channel.Name == "" || channels[channel.Name] {
+			return fmt.Errorf("alert channel names must be unique and not empty: %q", channel.Name)
+		}
+		channels[channel.Name] = true
+
+		switch channel.Type {
+		case "slack", "webhook":
+			if channel.URL == "" {
+				return fmt.Errorf("alert channel %s needs a url", channel.Name)
+			}
+		case "email":
+			if channel.SMTPHost == "" || channel.SMTPPort < 1 || channel.SMTPPort > 65535 || channel.From == "" || len(channel.To) == 0 {
+				return fmt.Errorf("alert channel %s needs an smtp_host, smtp_port, from and to", channel.Name)
+			}
+		default:
+			return fmt.Errorf("invalid type of alert channel %s: %s, expected slack, webhook or email", channel.Name, channel.Type)
+		}
+	}
+
+	rules := make(map[string]bool, len(a.Rules))
+	for _, rule := range a.Rules {
+		if rule.Name == "" || rules[rule.Name] {
+			return fmt.Errorf("alert rule names must be unique and not empty: %q", rule.Name)
+		}
+		rules[rule.Name] = true
+
+		switch rule.Metric {
+		case "error_rate", "p99_latency", "unhealthy", "anomaly":
+		default:
+			return fmt.Errorf("invalid metric of alert rule %s: %s, expected error_rate, p99_latency, unhealthy or anomaly", rule.Name, rule.Metric)
+		}
+		if rule.Threshold < 0 || rule.For < 0 {
+			return fmt.Errorf("alert rule %s has a negative threshold or duration", rule.Name)
+		}
+		for _, channel := range rule.Channels {
+			if !channels[channel] {
+				return fmt.Errorf("alert rule %s uses unknown channel: %s", rule.Name, channel)
+			}
+		}
+	}
 	return nil
 }
@@ -9,6 +9,9 @@ import (
 
 var Log *zap.Logger
 
+// level is the minimum level of the global logger, changeable while it runs
+var level = zap.NewAtomicLevel()
+
 // InitLogger initializes the global logger
 func InitLogger(development bool) error {
 	var config zap.Config
@@ -20,6 +23,8 @@ func InitLogger(development bool) error {
 		config = zap.NewProductionConfig()
 	}
 
+	level.SetLevel(config.Level.Level())
+	config.Level = level
 	config.OutputPaths = []string{"stdout"}
 	config.ErrorOutputPaths = []string{"stderr"}
 
@@ -32,6 +37,16 @@ func InitLogger(development bool) error {
 	return nil
 }
 
+// SetLevel changes the minimum level of the global logger: debug, info, warn or error
+func SetLevel(name string) error {
+	parsed, err := zapcore.ParseLevel(name)
+	if err != nil {
+		return err
+	}
+	level.SetLevel(parsed)
+	return nil
+}
+
 // Sync flushes any buffered log entries
 func Sync() {
 	if Log != nil {
@@ -0,0 +1,27 @@
+package adapters
+
+import (
+	"fmt"
+	"strings"
+)
+
+// argsMarker separates a query from its arguments in activity log commands
+const argsMarker = " [args: "
+
+// CommandWithArgs describes a query and its arguments in activity logs
+func CommandWithArgs(query string, args []interface{}) string {
+	if len(args) == 0 {
+		return query
+	}
+	return fmt.Sprintf("%s%s%v]", query, argsMarker, args)
+}
+
+// SplitCommandArgs splits an activity log command written by CommandWithArgs into its
+// query and its formatted arguments, which are empty when it has none
+func SplitCommandArgs(command string) (query, args string) {
+	query, args, found := strings.Cut(command, argsMarker)
+	if !found {
+		return command, ""
+	}
+	return query, strings.TrimSuffix(args, "]")
+}
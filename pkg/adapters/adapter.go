@@ -2,6 +2,10 @@ package adapters
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync/atomic"
 	"time"
 
 	"github.com/akmadan/throome/pkg/cluster"
@@ -74,6 +78,29 @@ type CacheAdapter interface {
 	Expire(ctx context.Context, key string, expiration time.Duration) error
 }
 
+// WatchAdapter extends CacheAdapter for key-value stores that can stream changes
+type WatchAdapter interface {
+	CacheAdapter
+
+	// Watch streams changes to the keys starting with prefix until ctx is cancelled,
+	// after which the returned channel is closed
+	Watch(ctx context.Context, prefix string) (<-chan WatchEvent, error)
+}
+
+// WatchEvent types
+const (
+	WatchEventPut    = "PUT"
+	WatchEventDelete = "DELETE"
+)
+
+// WatchEvent describes a change to a key
+type WatchEvent struct {
+	Type     string `json:"type"`
+	Key      string `json:"key"`
+	Value    string `json:"value,omitempty"`
+	Revision int64  `json:"revision"`
+}
+
 // QueueAdapter extends Adapter for message queue operations
 type QueueAdapter interface {
 	Adapter
@@ -97,6 +124,207 @@ type QueueAdapter interface {
 	ListTopics(ctx context.Context) ([]string, error)
 }
 
+// GroupSubscriber is a QueueAdapter whose subscriptions can join a named consumer
+// group, so several subscribers share the messages of a topic
+type GroupSubscriber interface {
+	// SubscribeGroup subscribes to a topic as a new member of a consumer group and
+	// returns the ID of the subscription
+	SubscribeGroup(ctx context.Context, topic, group string, handler MessageHandler) (string, error)
+
+	// UnsubscribeGroup ends a subscription by its ID
+	UnsubscribeGroup(ctx context.Context, id string) error
+}
+
+// DocumentAdapter extends Adapter for document store operations
+type DocumentAdapter interface {
+	Adapter
+
+	// Insert inserts documents into a collection and returns their IDs
+	Insert(ctx context.Context, collection string, documents []Document) ([]interface{}, error)
+
+	// Find returns the documents of a collection matching a filter
+	Find(ctx context.Context, collection string, filter Document, opts FindOptions) ([]Document, error)
+
+	// Update applies an update to all documents matching a filter and returns the
+	// number of documents modified
+	Update(ctx context.Context, collection string, filter, update Document) (int64, error)
+
+	// Delete deletes all documents matching a filter and returns the number deleted
+	Delete(ctx context.Context, collection string, filter Document) (int64, error)
+
+	// Aggregate runs an aggregation pipeline on a collection
+	Aggregate(ctx context.Context, collection string, pipeline []Document) ([]Document, error)
+}
+
+// Document is a document in a document store
+type Document map[string]interface{}
+
+// FindOptions controls which documents a find returns
+type FindOptions struct {
+	Limit      int64       `json:"limit,omitempty"`
+	Skip       int64       `json:"skip,omitempty"`
+	Sort       []SortField `json:"sort,omitempty"` // Applied in order
+	Projection Document    `json:"projection,omitempty"`
+}
+
+// SortField sorts documents by a field
+type SortField struct {
+	Field string `json:"field"`
+	Order int    `json:"order"` // 1 for ascending, -1 for descending
+}
+
+// KeyDocumentAdapter extends Adapter for key-value document stores such as DynamoDB,
+// where items are addressed by a partition key and an optional sort key
+type KeyDocumentAdapter interface {
+	Adapter
+
+	// PutItem creates or replaces an item
+	PutItem(ctx context.Context, table string, item Document) error
+
+	// GetItem returns the item with the given primary key
+	GetItem(ctx context.Context, table string, key Document) (Document, error)
+
+	// Query returns the items of a partition, optionally narrowed by a sort key condition
+	Query(ctx context.Context, table string, query KeyQuery) ([]Document, error)
+}
+
+// ErrItemNotFound is returned when no item has the requested key
+var ErrItemNotFound = errors.New("item not found")
+
+// KeyQuery selects the items of one partition
+type KeyQuery struct {
+	PartitionKey   string      `json:"partition_key"`
+	PartitionValue interface{} `json:"partition_value"`
+	SortKey        string      `json:"sort_key,omitempty"`
+	SortOperator   string      `json:"sort_operator,omitempty"` // =, <, <=, >, >=, begins_with or between
+	SortValue      interface{} `json:"sort_value,omitempty"`
+	SortValueEnd   interface{} `json:"sort_value_end,omitempty"` // Upper bound for between
+	Index          string      `json:"index,omitempty"`          // Secondary index to query
+	Limit          int         `json:"limit,omitempty"`
+	Descending     bool        `json:"descending,omitempty"` // Sort key order
+}
+
+// ObjectStoreAdapter extends Adapter for object storage operations
+type ObjectStoreAdapter interface {
+	Adapter
+
+	// PutObject uploads an object, creating the bucket if it does not exist
+	PutObject(ctx context.Context, bucket, key string, data io.Reader, size int64, contentType string) (*ObjectInfo, error)
+
+	// GetObject downloads an object. The caller must close the returned reader.
+	GetObject(ctx context.Context, bucket, key string) (io.ReadCloser, *ObjectInfo, error)
+
+	// ListObjects lists the objects of a bucket whose keys start with prefix
+	ListObjects(ctx context.Context, bucket, prefix string, limit int) ([]*ObjectInfo, error)
+
+	// Presign returns a URL that grants GET or PUT access to an object until it expires
+	Presign(ctx context.Context, method, bucket, key string, expiry time.Duration) (string, error)
+}
+
+// ErrObjectNotFound is returned when a bucket or object does not exist
+var ErrObjectNotFound = errors.New("object not found")
+
+// ObjectInfo describes a stored object
+type ObjectInfo struct {
+	Bucket       string    `json:"bucket"`
+	Key          string    `json:"key"`
+	Size         int64     `json:"size"`
+	ContentType  string    `json:"content_type,omitempty"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified time.Time `json:"last_modified"`
+}
+
+// TimeSeriesAdapter extends Adapter for time series databases
+type TimeSeriesAdapter interface {
+	Adapter
+
+	// WritePoints writes points to the store. Points without a timestamp are written at
+	// the current time.
+	WritePoints(ctx context.Context, points []*Point) error
+
+	// QueryRange returns the points of a measurement within a time range
+	QueryRange(ctx context.Context, query RangeQuery) ([]*Point, error)
+}
+
+// Point is a time series sample: a set of fields of a measurement, identified by tags
+type Point struct {
+	Measurement string                 `json:"measurement"`
+	Tags        map[string]string      `json:"tags,omitempty"`
+	Fields      map[string]interface{} `json:"fields"`
+	Timestamp   time.Time              `json:"timestamp"`
+}
+
+// RangeQuery selects points of a measurement within [Start, End)
+type RangeQuery struct {
+	Measurement string
+	Start       time.Time
+	End         time.Time         // Defaults to now
+	Tags        map[string]string // Only points with these tag values
+	Fields      []string          // Only these fields, all by default
+	Window      time.Duration     // Downsample into windows of this size
+	Aggregate   string            // Aggregate applied per window, e.g. mean, max
+	Limit       int               // Maximum points per series
+}
+
+// SecretAdapter extends Adapter for secret stores
+type SecretAdapter interface {
+	Adapter
+
+	// ReadSecret reads the latest version of the secret at path
+	ReadSecret(ctx context.Context, path string) (*Secret, error)
+
+	// WriteSecret stores data as a new version of the secret at path
+	WriteSecret(ctx context.Context, path string, data map[string]interface{}) (*Secret, error)
+}
+
+// ErrSecretNotFound is returned when a secret does not exist
+var ErrSecretNotFound = errors.New("secret not found")
+
+// Secret is a version of a secret
+type Secret struct {
+	Path        string                 `json:"path"`
+	Data        map[string]interface{} `json:"data,omitempty"`
+	Version     int                    `json:"version"`
+	CreatedTime time.Time              `json:"created_time"`
+}
+
+// VectorAdapter extends Adapter for vector databases that store embeddings
+type VectorAdapter interface {
+	Adapter
+
+	// CreateCollection creates a collection of vectors with the given dimension and
+	// distance metric (cosine, euclid or dot)
+	CreateCollection(ctx context.Context, name string, dimension int, distance string) error
+
+	// Upsert inserts or replaces points by ID
+	Upsert(ctx context.Context, collection string, points []*VectorPoint) error
+
+	// SearchNearest returns the points closest to the query vector, best match first
+	SearchNearest(ctx context.Context, collection string, query VectorQuery) ([]*VectorMatch, error)
+}
+
+// VectorPoint is an embedding with its payload
+type VectorPoint struct {
+	ID      string                 `json:"id"`
+	Vector  []float32              `json:"vector"`
+	Payload map[string]interface{} `json:"payload,omitempty"`
+}
+
+// VectorQuery selects the nearest neighbours of a vector
+type VectorQuery struct {
+	Vector         []float32              `json:"vector"`
+	Limit          int                    `json:"limit,omitempty"`
+	Filter         map[string]interface{} `json:"filter,omitempty"`          // Payload fields that must match exactly
+	ScoreThreshold *float64               `json:"score_threshold,omitempty"` // Drop matches scoring worse than this
+}
+
+// VectorMatch is a search result
+type VectorMatch struct {
+	ID      string                 `json:"id"`
+	Score   float64                `json:"score"`
+	Payload map[string]interface{} `json:"payload,omitempty"`
+}
+
 // Result represents the result of a database operation
 type Result interface {
 	RowsAffected() int64
@@ -134,29 +362,85 @@ type Message struct {
 	Value     []byte
 	Headers   map[string]string
 	Timestamp time.Time
+	Partition int
 	Offset    int64
 }
 
 // HealthStatus represents the health status of an adapter
 type HealthStatus struct {
-	Healthy          bool
+	Healthy          bool // Reachable and passing all probes
+	Reachable        bool // Connectivity check only
 	ResponseTime     time.Duration
 	ErrorMessage     string
 	LastChecked      time.Time
 	ConsecutiveFails int
+	Probes           []ProbeResult
 }
 
-// Metrics holds adapter performance metrics
+// Metrics holds adapter performance metrics. The success rate and average latency
+// cover the requests of the last MetricsWindow, the other counts every request.
 type Metrics struct {
-	TotalRequests     int64
-	FailedRequests    int64
-	SuccessRate       float64
-	AverageLatency    time.Duration
-	MinLatency        time.Duration
-	MaxLatency        time.Duration
-	ActiveConnections int
-	TotalConnections  int64
-	LastRequestTime   time.Time
+	TotalRequests        int64
+	FailedRequests       int64
+	SuccessRate          float64       // 100 without requests in the window
+	AverageLatency       time.Duration // Zero without requests in the window
+	MinLatency           time.Duration
+	MaxLatency           time.Duration
+	ActiveConnections    int
+	TotalConnections     int64
+	LastRequestTime      time.Time
+	WindowRequests       int64 // Requests of the last MetricsWindow
+	WindowFailedRequests int64
+}
+
+// PoolStats holds a point-in-time view of an adapter's connection pool
+type PoolStats struct {
+	AcquiredConns        int           `json:"acquired_conns"`
+	IdleConns            int           `json:"idle_conns"`
+	TotalConns           int           `json:"total_conns"`
+	MaxConns             int           `json:"max_conns"`
+	AcquireCount         int64         `json:"acquire_count"`
+	EmptyAcquireCount    int64         `json:"empty_acquire_count"` // Acquires that had to wait for a connection
+	CanceledAcquireCount int64         `json:"canceled_acquire_count"`
+	AcquireDuration      time.Duration `json:"acquire_duration"` // Total time spent waiting to acquire
+	NewConnsCount        int64         `json:"new_conns_count"`
+	Timeouts             int64         `json:"timeouts"`
+}
+
+// PoolStatsProvider is implemented by adapters that maintain a connection pool
+type PoolStatsProvider interface {
+	PoolStats() *PoolStats
+}
+
+// CacheStats holds server-side statistics of a cache
+type CacheStats struct {
+	Hits             int64                    `json:"hits"`
+	Misses           int64                    `json:"misses"`
+	HitRatio         float64                  `json:"hit_ratio"` // Percentage of lookups that found a key
+	UsedMemory       int64                    `json:"used_memory"`
+	PeakMemory       int64                    `json:"peak_memory"`
+	MaxMemory        int64                    `json:"max_memory"` // Zero when unlimited
+	Evictions        int64                    `json:"evictions"`
+	ExpiredKeys      int64                    `json:"expired_keys"`
+	ConnectedClients int                      `json:"connected_clients"`
+	BlockedClients   int                      `json:"blocked_clients"`
+	OpsPerSecond     int64                    `json:"ops_per_second"`
+	Uptime           time.Duration            `json:"uptime"`
+	Version          string                   `json:"version,omitempty"`
+	Keyspace         map[string]KeyspaceStats `json:"keyspace"` // Database name -> key counts
+	CollectedAt      time.Time                `json:"collected_at"`
+}
+
+// KeyspaceStats holds the key counts of a single cache database
+type KeyspaceStats struct {
+	Keys    int64 `json:"keys"`
+	Expires int64 `json:"expires"` // Keys with a TTL set
+	AvgTTL  int64 `json:"avg_ttl"` // milliseconds
+}
+
+// CacheStatsProvider is implemented by adapters that can report cache statistics
+type CacheStatsProvider interface {
+	CacheStats(ctx context.Context) (*CacheStats, error)
 }
 
 // Factory creates adapters based on service configuration
@@ -202,32 +486,35 @@ func (e ErrAdapterNotFound) Error() string {
 type BaseAdapter struct {
 	config         *cluster.ServiceConfig
 	connected      bool
-	metrics        *Metrics
+	metrics        adapterMetrics
 	activityLogger ActivityLogger
+	classifier     ErrorClassifier
 	clusterID      string
 	serviceName    string
+	observer       atomic.Pointer[RequestObserver]
+	latencies      latencyWindow
 }
 
-// ActivityLogger interface for logging service activities
+// RequestObserver is told about the outcome of every request recorded by an adapter
+type RequestObserver func(success bool)
+
+// ActivityLogger interface for logging service activities. The context is the one of
+// the operation, which carries the ID of the API request that caused it.
 type ActivityLogger interface {
-	LogOperation(clusterID, serviceName, serviceType, operation, command string, duration time.Duration, err error, response string)
+	LogOperation(ctx context.Context, clusterID, serviceName, serviceType, operation, command string, duration time.Duration, err error, response string)
+}
+
+// QueryActivityLogger is an ActivityLogger that also records the rows and bytes
+// returned by queries
+type QueryActivityLogger interface {
+	LogQuery(ctx context.Context, clusterID, serviceName, serviceType, operation, command string, duration time.Duration, err error, rows, bytes int64)
 }
 
 // NewBaseAdapter creates a new base adapter
 func NewBaseAdapter(config *cluster.ServiceConfig) *BaseAdapter {
 	return &BaseAdapter{
-		config:    config,
-		connected: false,
-		metrics: &Metrics{
-			TotalRequests:     0,
-			FailedRequests:    0,
-			SuccessRate:       100.0,
-			AverageLatency:    0,
-			MinLatency:        time.Duration(0),
-			MaxLatency:        time.Duration(0),
-			ActiveConnections: 0,
-			TotalConnections:  0,
-		},
+		config:         config,
+		connected:      false,
 		activityLogger: nil, // Set later by SetActivityLogger
 	}
 }
@@ -240,29 +527,54 @@ func (b *BaseAdapter) SetActivityLogger(logger ActivityLogger, clusterID, servic
 }
 
 // LogActivity logs an activity if logger is configured
-func (b *BaseAdapter) LogActivity(operation, command string, duration time.Duration, err error, response string) {
+func (b *BaseAdapter) LogActivity(ctx context.Context, operation, command string, duration time.Duration, err error, response string) {
 	if b.activityLogger != nil {
 		b.activityLogger.LogOperation(
+			ctx,
 			b.clusterID,
 			b.serviceName,
 			b.config.Type,
 			operation,
 			command,
 			duration,
-			err,
+			b.classify(err),
 			response,
 		)
 	}
 }
 
+// LogQuery logs a query along with the rows and bytes it returned. Loggers that do not
+// record result sizes get the row count in the response of the operation.
+func (b *BaseAdapter) LogQuery(ctx context.Context, operation, command string, duration time.Duration, err error, rows, bytes int64) {
+	if b.activityLogger == nil {
+		return
+	}
+	if logger, ok := b.activityLogger.(QueryActivityLogger); ok {
+		logger.LogQuery(ctx, b.clusterID, b.serviceName, b.config.Type, operation, command, duration, b.classify(err), rows, bytes)
+		return
+	}
+
+	response := ""
+	if err == nil {
+		response = fmt.Sprintf("%d rows returned", rows)
+	}
+	b.activityLogger.LogOperation(ctx, b.clusterID, b.serviceName, b.config.Type, operation, command, duration, b.classify(err), response)
+}
+
+// ServiceName returns the name of the service the adapter was registered for, empty
+// before SetActivityLogger
+func (b *BaseAdapter) ServiceName() string {
+	return b.serviceName
+}
+
 // GetType returns the adapter type
 func (b *BaseAdapter) GetType() string {
 	return b.config.Type
 }
 
-// GetMetrics returns the adapter metrics
+// GetMetrics returns a snapshot of the adapter metrics
 func (b *BaseAdapter) GetMetrics() *Metrics {
-	return b.metrics
+	return b.metrics.snapshot(time.Now())
 }
 
 // IsConnected returns the connection status
@@ -275,26 +587,28 @@ func (b *BaseAdapter) SetConnected(connected bool) {
 	b.connected = connected
 }
 
-// RecordRequest records a request in metrics
-func (b *BaseAdapter) RecordRequest(latency time.Duration, success bool) {
-	b.metrics.TotalRequests++
-	b.metrics.LastRequestTime = time.Now()
-
-	if !success {
-		b.metrics.FailedRequests++
+// SetRequestObserver sets the observer of the adapter's requests, or removes it when
+// nil
+func (b *BaseAdapter) SetRequestObserver(observer RequestObserver) {
+	if observer == nil {
+		b.observer.Store(nil)
+		return
 	}
+	b.observer.Store(&observer)
+}
 
-	// Update success rate
-	b.metrics.SuccessRate = float64(b.metrics.TotalRequests-b.metrics.FailedRequests) / float64(b.metrics.TotalRequests) * 100
+// RecentLatency returns the latency under which the given percentage of the adapter's
+// requests of the last minute completed, and false when it had none
+func (b *BaseAdapter) RecentLatency(percent float64) (time.Duration, bool) {
+	return b.latencies.percentile(percent)
+}
 
-	// Update latency metrics
-	if b.metrics.MinLatency == 0 || latency < b.metrics.MinLatency {
-		b.metrics.MinLatency = latency
-	}
-	if latency > b.metrics.MaxLatency {
-		b.metrics.MaxLatency = latency
+// RecordRequest records a request in metrics. It is safe to call from concurrent
+// requests.
+func (b *BaseAdapter) RecordRequest(latency time.Duration, success bool) {
+	if observer := b.observer.Load(); observer != nil {
+		(*observer)(success)
 	}
-
-	// Calculate rolling average
-	b.metrics.AverageLatency = (b.metrics.AverageLatency*time.Duration(b.metrics.TotalRequests-1) + latency) / time.Duration(b.metrics.TotalRequests)
+	b.latencies.add(latency)
+	b.metrics.record(time.Now(), latency, success)
 }
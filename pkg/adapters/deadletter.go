@@ -0,0 +1,108 @@
+package adapters
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultDeadLetterSuffix is appended to a topic name to form its dead-letter topic
+const DefaultDeadLetterSuffix = ".dlq"
+
+// Publisher publishes raw messages to a topic
+type Publisher interface {
+	Publish(ctx context.Context, topic string, message []byte) error
+}
+
+// DeadLetterPolicy controls retries and dead-lettering of failing messages
+type DeadLetterPolicy struct {
+	MaxRetries   int           // Retries after the first delivery attempt
+	RetryBackoff time.Duration // Delay before the first retry, doubled for each one after
+	TopicSuffix  string        // Defaults to DefaultDeadLetterSuffix
+}
+
+// DeadLetterTopic returns the dead-letter topic for a topic
+func (p DeadLetterPolicy) DeadLetterTopic(topic string) string {
+	if p.TopicSuffix == "" {
+		return topic + DefaultDeadLetterSuffix
+	}
+	return topic + p.TopicSuffix
+}
+
+// DeadLetter is the envelope published to a dead-letter topic. It carries the
+// original message along with the reason it could not be handled.
+type DeadLetter struct {
+	ID                string            `json:"id"`
+	OriginalTopic     string            `json:"original_topic"`
+	OriginalPartition int               `json:"original_partition"`
+	OriginalOffset    int64             `json:"original_offset"`
+	Key               []byte            `json:"key,omitempty"`
+	Value             []byte            `json:"value"`
+	Headers           map[string]string `json:"headers,omitempty"`
+	Error             string            `json:"error"`
+	Attempts          int               `json:"attempts"`
+	FailedAt          time.Time         `json:"failed_at"`
+}
+
+// WithDeadLetter wraps a message handler so that failing messages are retried per
+// the policy and then published to the topic's dead-letter topic. The wrapped handler
+// only returns an error when the message could not be dead-lettered either.
+func WithDeadLetter(publisher Publisher, policy DeadLetterPolicy, handler MessageHandler) MessageHandler {
+	return func(ctx context.Context, message *Message) error {
+		var err error
+		backoff := policy.RetryBackoff
+		attempts := 0
+
+		for attempts <= policy.MaxRetries {
+			if attempts > 0 && backoff > 0 {
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case <-time.After(backoff):
+				}
+				backoff *= 2
+			}
+
+			attempts++
+			if err = handler(ctx, message); err == nil {
+				return nil
+			}
+		}
+
+		letter := &DeadLetter{
+			ID:                uuid.New().String(),
+			OriginalTopic:     message.Topic,
+			OriginalPartition: message.Partition,
+			OriginalOffset:    message.Offset,
+			Key:               message.Key,
+			Value:             message.Value,
+			Headers:           message.Headers,
+			Error:             err.Error(),
+			Attempts:          attempts,
+			FailedAt:          time.Now(),
+		}
+
+		payload, marshalErr := json.Marshal(letter)
+		if marshalErr != nil {
+			return fmt.Errorf("failed to encode dead letter: %w", marshalErr)
+		}
+
+		if pubErr := publisher.Publish(ctx, policy.DeadLetterTopic(message.Topic), payload); pubErr != nil {
+			return fmt.Errorf("failed to publish to dead-letter topic after %d attempts (%v): %w", attempts, err, pubErr)
+		}
+
+		return nil
+	}
+}
+
+// DecodeDeadLetter decodes a dead-letter envelope
+func DecodeDeadLetter(payload []byte) (*DeadLetter, error) {
+	var letter DeadLetter
+	if err := json.Unmarshal(payload, &letter); err != nil {
+		return nil, fmt.Errorf("failed to decode dead letter: %w", err)
+	}
+	return &letter, nil
+}
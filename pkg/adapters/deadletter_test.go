@@ -0,0 +1,78 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type recordingPublisher struct {
+	topics   []string
+	payloads [][]byte
+}
+
+func (p *recordingPublisher) Publish(ctx context.Context, topic string, message []byte) error {
+	p.topics = append(p.topics, topic)
+	p.payloads = append(p.payloads, message)
+	return nil
+}
+
+func TestWithDeadLetterPublishesAfterRetries(t *testing.T) {
+	publisher := &recordingPublisher{}
+	calls := 0
+	handler := WithDeadLetter(publisher, DeadLetterPolicy{MaxRetries: 2}, func(ctx context.Context, message *Message) error {
+		calls++
+		return errors.New("cannot process")
+	})
+
+	message := &Message{Topic: "orders", Key: []byte("k1"), Value: []byte("payload"), Partition: 1, Offset: 42}
+	if err := handler(context.Background(), message); err != nil {
+		t.Fatalf("Expected dead-lettered message to be acknowledged, got %v", err)
+	}
+
+	if calls != 3 {
+		t.Errorf("Expected 3 delivery attempts, got %d", calls)
+	}
+	if len(publisher.topics) != 1 || publisher.topics[0] != "orders.dlq" {
+		t.Fatalf("Expected one message on 'orders.dlq', got %v", publisher.topics)
+	}
+
+	letter, err := DecodeDeadLetter(publisher.payloads[0])
+	if err != nil {
+		t.Fatalf("Failed to decode dead letter: %v", err)
+	}
+	if letter.OriginalTopic != "orders" || letter.OriginalOffset != 42 || letter.OriginalPartition != 1 {
+		t.Errorf("Unexpected origin in dead letter: %+v", letter)
+	}
+	if string(letter.Value) != "payload" || string(letter.Key) != "k1" {
+		t.Errorf("Expected original key and value, got key '%s' value '%s'", letter.Key, letter.Value)
+	}
+	if letter.Error != "cannot process" || letter.Attempts != 3 {
+		t.Errorf("Expected failure metadata, got error '%s' after %d attempts", letter.Error, letter.Attempts)
+	}
+}
+
+func TestWithDeadLetterSucceedsOnRetry(t *testing.T) {
+	publisher := &recordingPublisher{}
+	calls := 0
+	handler := WithDeadLetter(publisher, DeadLetterPolicy{MaxRetries: 3, TopicSuffix: "-failed"}, func(ctx context.Context, message *Message) error {
+		calls++
+		if calls < 2 {
+			return errors.New("transient")
+		}
+		return nil
+	})
+
+	if err := handler(context.Background(), &Message{Topic: "orders"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("Expected 2 delivery attempts, got %d", calls)
+	}
+	if len(publisher.topics) != 0 {
+		t.Errorf("Expected nothing dead-lettered, got %v", publisher.topics)
+	}
+	if topic := (DeadLetterPolicy{TopicSuffix: "-failed"}).DeadLetterTopic("orders"); topic != "orders-failed" {
+		t.Errorf("Expected custom suffix topic 'orders-failed', got '%s'", topic)
+	}
+}
@@ -0,0 +1,388 @@
+package dynamodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/akmadan/throome/pkg/adapters"
+	"github.com/akmadan/throome/pkg/cluster"
+)
+
+const (
+	defaultRegion = "us-east-1"
+	// DynamoDB Local accepts any credentials, but requests must still be signed
+	defaultAccessKey = "local"
+	defaultSecretKey = "local"
+)
+
+// DynamoDBAdapter implements the KeyDocumentAdapter interface for DynamoDB and
+// DynamoDB Local. The access key and secret key are configured as the service
+// username and password, and the region with the "region" option.
+type DynamoDBAdapter struct {
+	*adapters.BaseAdapter
+	config *cluster.ServiceConfig
+	client *dynamodb.Client
+}
+
+// NewDynamoDBAdapter creates a new DynamoDB adapter
+func NewDynamoDBAdapter(config *cluster.ServiceConfig) (adapters.Adapter, error) {
+	adapter := &DynamoDBAdapter{
+		BaseAdapter: adapters.NewBaseAdapter(config),
+		config:      config,
+	}
+	adapter.SetErrorClassifier(classifyError)
+	return adapter, nil
+}
+
+// classifyError returns the type of a DynamoDB error by its code
+func classifyError(err error) string {
+	var apiErr interface{ ErrorCode() string }
+	if !errors.As(err, &apiErr) {
+		return ""
+	}
+	switch apiErr.ErrorCode() {
+	case "ConditionalCheckFailedException", "TransactionCanceledException":
+		return adapters.ErrorTypeConstraintViolation
+	case "ResourceNotFoundException":
+		return adapters.ErrorTypeNotFound
+	case "UnrecognizedClientException", "InvalidSignatureException", "AccessDeniedException", "MissingAuthenticationTokenException":
+		return adapters.ErrorTypeAuthFailure
+	case "RequestTimeout", "RequestTimeoutException":
+		return adapters.ErrorTypeTimeout
+	}
+	return ""
+}
+
+// Connect creates the DynamoDB client and verifies the endpoint is reachable
+func (d *DynamoDBAdapter) Connect(ctx context.Context) error {
+	tlsConfig, err := adapters.BuildTLSConfig(&d.config.TLS)
+	if err != nil {
+		return err
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+
+	accessKey, secretKey := credentialsFor(d.config)
+	d.client = dynamodb.New(dynamodb.Options{
+		Region:       region(d.config),
+		BaseEndpoint: aws.String(endpoint(d.config)),
+		Credentials:  aws.NewCredentialsCache(credentials.NewStaticCredentialsProvider(accessKey, secretKey, "")),
+		HTTPClient:   &http.Client{Transport: transport, Timeout: 30 * time.Second},
+	})
+
+	// Test connection
+	if err := d.Ping(ctx); err != nil {
+		return fmt.Errorf("failed to connect to DynamoDB: %w", err)
+	}
+
+	d.SetConnected(true)
+	return nil
+}
+
+// Disconnect releases the client. DynamoDB is stateless HTTP, so there is nothing to close.
+func (d *DynamoDBAdapter) Disconnect(ctx context.Context) error {
+	d.SetConnected(false)
+	return nil
+}
+
+// Ping checks if DynamoDB is reachable and the credentials are accepted
+func (d *DynamoDBAdapter) Ping(ctx context.Context) error {
+	start := time.Now()
+	_, err := d.client.ListTables(ctx, &dynamodb.ListTablesInput{Limit: aws.Int32(1)})
+	d.RecordRequest(time.Since(start), err == nil)
+	return err
+}
+
+// HealthCheck performs a health check
+func (d *DynamoDBAdapter) HealthCheck(ctx context.Context) (*adapters.HealthStatus, error) {
+	start := time.Now()
+	err := d.Ping(ctx)
+	responseTime := time.Since(start)
+
+	status := &adapters.HealthStatus{
+		Healthy:      err == nil,
+		Reachable:    err == nil,
+		ResponseTime: responseTime,
+		LastChecked:  time.Now(),
+	}
+
+	if err != nil {
+		status.ErrorMessage = err.Error()
+	}
+
+	return status, nil
+}
+
+// PutItem creates or replaces an item
+func (d *DynamoDBAdapter) PutItem(ctx context.Context, table string, item adapters.Document) error {
+	start := time.Now()
+	err := d.putItem(ctx, table, item)
+	duration := time.Since(start)
+	d.RecordRequest(duration, err == nil)
+
+	// Log activity
+	response := ""
+	if err == nil {
+		response = "Item stored"
+	}
+	d.LogActivity(ctx, "PUT_ITEM", fmt.Sprintf("PutItem %s (%d attributes)", table, len(item)), duration, err, response)
+
+	return err
+}
+
+func (d *DynamoDBAdapter) putItem(ctx context.Context, table string, item adapters.Document) error {
+	attributes, err := attributevalue.MarshalMap(map[string]interface{}(item))
+	if err != nil {
+		return fmt.Errorf("invalid item: %w", err)
+	}
+	_, err = d.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(table),
+		Item:      attributes,
+	})
+	return err
+}
+
+// GetItem returns the item with the given primary key, or ErrItemNotFound
+func (d *DynamoDBAdapter) GetItem(ctx context.Context, table string, key adapters.Document) (adapters.Document, error) {
+	start := time.Now()
+	item, err := d.getItem(ctx, table, key)
+	duration := time.Since(start)
+	d.RecordRequest(duration, err == nil || errors.Is(err, adapters.ErrItemNotFound))
+
+	// Log activity
+	response := ""
+	switch {
+	case err == nil:
+		response = fmt.Sprintf("%d attributes", len(item))
+	case errors.Is(err, adapters.ErrItemNotFound):
+		response = "(nil)"
+	}
+	logErr := err
+	if errors.Is(err, adapters.ErrItemNotFound) {
+		logErr = nil // A missing item is not a failure
+	}
+	d.LogActivity(ctx, "GET_ITEM", fmt.Sprintf("GetItem %s %v", table, map[string]interface{}(key)), duration, logErr, response)
+
+	return item, err
+}
+
+func (d *DynamoDBAdapter) getItem(ctx context.Context, table string, key adapters.Document) (adapters.Document, error) {
+	attributes, err := attributevalue.MarshalMap(map[string]interface{}(key))
+	if err != nil {
+		return nil, fmt.Errorf("invalid key: %w", err)
+	}
+
+	out, err := d.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(table),
+		Key:       attributes,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(out.Item) == 0 {
+		return nil, adapters.ErrItemNotFound
+	}
+	return toDocument(out.Item)
+}
+
+// Query returns the items of a partition in sort key order. Pages are followed until
+// the limit is reached or the partition is exhausted.
+func (d *DynamoDBAdapter) Query(ctx context.Context, table string, query adapters.KeyQuery) ([]adapters.Document, error) {
+	start := time.Now()
+	items, expression, err := d.query(ctx, table, query)
+	duration := time.Since(start)
+	d.RecordRequest(duration, err == nil)
+
+	// Log activity
+	response := ""
+	if err == nil {
+		response = fmt.Sprintf("%d items", len(items))
+	}
+	d.LogActivity(ctx, "QUERY", fmt.Sprintf("Query %s WHERE %s", table, expression), duration, err, response)
+
+	return items, err
+}
+
+func (d *DynamoDBAdapter) query(ctx context.Context, table string, query adapters.KeyQuery) ([]adapters.Document, string, error) {
+	condition, err := buildKeyCondition(query)
+	if err != nil {
+		return nil, "", err
+	}
+
+	values, err := attributevalue.MarshalMap(condition.values)
+	if err != nil {
+		return nil, condition.expression, fmt.Errorf("invalid key values: %w", err)
+	}
+
+	input := &dynamodb.QueryInput{
+		TableName:                 aws.String(table),
+		KeyConditionExpression:    aws.String(condition.expression),
+		ExpressionAttributeNames:  condition.names,
+		ExpressionAttributeValues: values,
+		ScanIndexForward:          aws.Bool(!query.Descending),
+	}
+	if query.Index != "" {
+		input.IndexName = aws.String(query.Index)
+	}
+	if query.Limit > 0 {
+		input.Limit = aws.Int32(int32(query.Limit))
+	}
+
+	items := make([]adapters.Document, 0)
+	for {
+		out, err := d.client.Query(ctx, input)
+		if err != nil {
+			return nil, condition.expression, err
+		}
+		for _, attributes := range out.Items {
+			item, err := toDocument(attributes)
+			if err != nil {
+				return nil, condition.expression, err
+			}
+			items = append(items, item)
+		}
+
+		if len(out.LastEvaluatedKey) == 0 || (query.Limit > 0 && len(items) >= query.Limit) {
+			break
+		}
+		input.ExclusiveStartKey = out.LastEvaluatedKey
+	}
+
+	if query.Limit > 0 && len(items) > query.Limit {
+		items = items[:query.Limit]
+	}
+	return items, condition.expression, nil
+}
+
+// CreateTable creates an on-demand table keyed by a string partition key and an
+// optional string sort key
+func (d *DynamoDBAdapter) CreateTable(ctx context.Context, table, partitionKey, sortKey string) error {
+	start := time.Now()
+
+	definitions := []types.AttributeDefinition{{AttributeName: aws.String(partitionKey), AttributeType: types.ScalarAttributeTypeS}}
+	schema := []types.KeySchemaElement{{AttributeName: aws.String(partitionKey), KeyType: types.KeyTypeHash}}
+	if sortKey != "" {
+		definitions = append(definitions, types.AttributeDefinition{AttributeName: aws.String(sortKey), AttributeType: types.ScalarAttributeTypeS})
+		schema = append(schema, types.KeySchemaElement{AttributeName: aws.String(sortKey), KeyType: types.KeyTypeRange})
+	}
+
+	_, err := d.client.CreateTable(ctx, &dynamodb.CreateTableInput{
+		TableName:            aws.String(table),
+		AttributeDefinitions: definitions,
+		KeySchema:            schema,
+		BillingMode:          types.BillingModePayPerRequest,
+	})
+	duration := time.Since(start)
+	d.RecordRequest(duration, err == nil)
+
+	// Log activity
+	command := fmt.Sprintf("CreateTable %s (partition key: %s)", table, partitionKey)
+	if sortKey != "" {
+		command = fmt.Sprintf("CreateTable %s (partition key: %s, sort key: %s)", table, partitionKey, sortKey)
+	}
+	response := ""
+	if err == nil {
+		response = fmt.Sprintf("Table '%s' created successfully", table)
+	}
+	d.LogActivity(ctx, "CREATE_TABLE", command, duration, err, response)
+
+	return err
+}
+
+// GetClient returns the underlying DynamoDB client for advanced operations
+func (d *DynamoDBAdapter) GetClient() *dynamodb.Client {
+	return d.client
+}
+
+// keyCondition is a key condition expression with its placeholders
+type keyCondition struct {
+	expression string
+	names      map[string]string
+	values     map[string]interface{}
+}
+
+// buildKeyCondition builds the key condition expression of a query. Attribute names
+// go through placeholders so reserved words such as "name" can be used as keys.
+func buildKeyCondition(query adapters.KeyQuery) (*keyCondition, error) {
+	if query.PartitionKey == "" || query.PartitionValue == nil {
+		return nil, fmt.Errorf("partition key and value are required")
+	}
+
+	condition := &keyCondition{
+		expression: "#pk = :pk",
+		names:      map[string]string{"#pk": query.PartitionKey},
+		values:     map[string]interface{}{":pk": query.PartitionValue},
+	}
+
+	if query.SortKey == "" {
+		if query.SortOperator != "" {
+			return nil, fmt.Errorf("sort operator requires a sort key")
+		}
+		return condition, nil
+	}
+	if query.SortValue == nil {
+		return nil, fmt.Errorf("sort value is required")
+	}
+
+	condition.names["#sk"] = query.SortKey
+	condition.values[":sk"] = query.SortValue
+
+	switch operator := query.SortOperator; operator {
+	case "", "=", "<", "<=", ">", ">=":
+		if operator == "" {
+			operator = "="
+		}
+		condition.expression += fmt.Sprintf(" AND #sk %s :sk", operator)
+	case "begins_with":
+		condition.expression += " AND begins_with(#sk, :sk)"
+	case "between":
+		if query.SortValueEnd == nil {
+			return nil, fmt.Errorf("between requires sort_value_end")
+		}
+		condition.values[":sk_end"] = query.SortValueEnd
+		condition.expression += " AND #sk BETWEEN :sk AND :sk_end"
+	default:
+		return nil, fmt.Errorf("unsupported sort operator: %s", operator)
+	}
+
+	return condition, nil
+}
+
+func toDocument(attributes map[string]types.AttributeValue) (adapters.Document, error) {
+	var item map[string]interface{}
+	if err := attributevalue.UnmarshalMap(attributes, &item); err != nil {
+		return nil, fmt.Errorf("failed to decode item: %w", err)
+	}
+	return adapters.Document(item), nil
+}
+
+func endpoint(config *cluster.ServiceConfig) string {
+	scheme := "http"
+	if config.TLS.Enabled {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s:%d", scheme, config.Host, config.Port)
+}
+
+func credentialsFor(config *cluster.ServiceConfig) (string, string) {
+	if config.Username == "" {
+		return defaultAccessKey, defaultSecretKey
+	}
+	return config.Username, config.Password
+}
+
+func region(config *cluster.ServiceConfig) string {
+	if value, ok := config.Options["region"].(string); ok && value != "" {
+		return value
+	}
+	return defaultRegion
+}
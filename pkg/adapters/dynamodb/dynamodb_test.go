@@ -0,0 +1,81 @@
+package dynamodb
+
+import (
+	"testing"
+
+	"github.com/akmadan/throome/pkg/adapters"
+)
+
+func TestBuildKeyCondition(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      adapters.KeyQuery
+		expression string
+		values     int
+	}{
+		{
+			name:       "partition only",
+			query:      adapters.KeyQuery{PartitionKey: "user_id", PartitionValue: "u1"},
+			expression: "#pk = :pk",
+			values:     1,
+		},
+		{
+			name:       "default sort operator",
+			query:      adapters.KeyQuery{PartitionKey: "user_id", PartitionValue: "u1", SortKey: "created_at", SortValue: "2024"},
+			expression: "#pk = :pk AND #sk = :sk",
+			values:     2,
+		},
+		{
+			name:       "comparison",
+			query:      adapters.KeyQuery{PartitionKey: "user_id", PartitionValue: "u1", SortKey: "created_at", SortOperator: ">=", SortValue: "2024"},
+			expression: "#pk = :pk AND #sk >= :sk",
+			values:     2,
+		},
+		{
+			name:       "begins_with",
+			query:      adapters.KeyQuery{PartitionKey: "user_id", PartitionValue: "u1", SortKey: "sk", SortOperator: "begins_with", SortValue: "order#"},
+			expression: "#pk = :pk AND begins_with(#sk, :sk)",
+			values:     2,
+		},
+		{
+			name:       "between",
+			query:      adapters.KeyQuery{PartitionKey: "user_id", PartitionValue: "u1", SortKey: "created_at", SortOperator: "between", SortValue: "2024-01", SortValueEnd: "2024-12"},
+			expression: "#pk = :pk AND #sk BETWEEN :sk AND :sk_end",
+			values:     3,
+		},
+	}
+
+	for _, tt := range tests {
+		condition, err := buildKeyCondition(tt.query)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.name, err)
+			continue
+		}
+		if condition.expression != tt.expression {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.expression, condition.expression)
+		}
+		if len(condition.values) != tt.values {
+			t.Errorf("%s: expected %d values, got %d", tt.name, tt.values, len(condition.values))
+		}
+		if condition.names["#pk"] != tt.query.PartitionKey {
+			t.Errorf("%s: expected #pk to name %q", tt.name, tt.query.PartitionKey)
+		}
+	}
+}
+
+func TestBuildKeyConditionInvalid(t *testing.T) {
+	tests := map[string]adapters.KeyQuery{
+		"missing partition key":   {PartitionValue: "u1"},
+		"missing partition value": {PartitionKey: "user_id"},
+		"operator without key":    {PartitionKey: "user_id", PartitionValue: "u1", SortOperator: ">"},
+		"missing sort value":      {PartitionKey: "user_id", PartitionValue: "u1", SortKey: "sk"},
+		"between without end":     {PartitionKey: "user_id", PartitionValue: "u1", SortKey: "sk", SortOperator: "between", SortValue: 1},
+		"unsupported operator":    {PartitionKey: "user_id", PartitionValue: "u1", SortKey: "sk", SortOperator: "contains", SortValue: 1},
+	}
+
+	for name, query := range tests {
+		if _, err := buildKeyCondition(query); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"strings"
+	"syscall"
+)
+
+// Types of the errors of failed operations, as counted in the error metrics
+const (
+	ErrorTypeTimeout             = "timeout"
+	ErrorTypeConnectionRefused   = "connection_refused"
+	ErrorTypeAuthFailure         = "auth_failure"
+	ErrorTypeConstraintViolation = "constraint_violation"
+	ErrorTypeNotFound            = "not_found"
+	ErrorTypeUnknown             = "unknown"
+)
+
+// ErrorClassifier returns the type of an error of a service's driver, or an empty
+// string when it does not recognize the error
+type ErrorClassifier func(err error) string
+
+// authFailureMessages are parts of the messages of authentication failures, for
+// drivers that do not return typed errors for them
+var authFailureMessages = []string{
+	"authentication failed",
+	"auth failed",
+	"access denied",
+	"permission denied",
+	"unauthorized",
+	"not authorized",
+}
+
+// ClassifyError returns the type of an error: the one its adapter classified it as, or
+// one recognized in the errors of the standard library and the adapters or in its
+// message. It returns ErrorTypeUnknown for other errors, and an empty string for nil.
+func ClassifyError(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	var classified *ClassifiedError
+	if errors.As(err, &classified) {
+		return classified.Type
+	}
+
+	var netErr net.Error
+	switch {
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, os.ErrDeadlineExceeded),
+		errors.As(err, &netErr) && netErr.Timeout():
+		return ErrorTypeTimeout
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return ErrorTypeConnectionRefused
+	case errors.Is(err, ErrItemNotFound), errors.Is(err, ErrObjectNotFound), errors.Is(err, ErrSecretNotFound),
+		errors.Is(err, os.ErrNotExist):
+		return ErrorTypeNotFound
+	}
+
+	message := strings.ToLower(err.Error())
+	for _, part := range authFailureMessages {
+		if strings.Contains(message, part) {
+			return ErrorTypeAuthFailure
+		}
+	}
+	if strings.Contains(message, "connection refused") {
+		return ErrorTypeConnectionRefused
+	}
+	return ErrorTypeUnknown
+}
+
+// ClassifiedError is an error whose type is known, which ClassifyError returns
+type ClassifiedError struct {
+	Err  error
+	Type string
+}
+
+// Error returns the message of the classified error
+func (e *ClassifiedError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the classified error
+func (e *ClassifiedError) Unwrap() error {
+	return e.Err
+}
+
+// classify attaches the type the adapter's error classifier gives an error to it, so
+// the activity logger can count it without knowing the service's driver
+func (b *BaseAdapter) classify(err error) error {
+	if err == nil || b.classifier == nil {
+		return err
+	}
+	if errorType := b.classifier(err); errorType != "" {
+		return &ClassifiedError{Err: err, Type: errorType}
+	}
+	return err
+}
+
+// SetErrorClassifier sets how the adapter recognizes the errors of its driver. Errors
+// it does not recognize are classified by ClassifyError.
+func (b *BaseAdapter) SetErrorClassifier(classifier ErrorClassifier) {
+	b.classifier = classifier
+}
@@ -0,0 +1,57 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"syscall"
+	"testing"
+
+	"github.com/akmadan/throome/pkg/cluster"
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected string
+	}{
+		{nil, ""},
+		{fmt.Errorf("query: %w", context.DeadlineExceeded), ErrorTypeTimeout},
+		{&net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}, ErrorTypeConnectionRefused},
+		{errors.New("dial tcp 127.0.0.1:5432: connect: connection refused"), ErrorTypeConnectionRefused},
+		{fmt.Errorf("get: %w", ErrItemNotFound), ErrorTypeNotFound},
+		{errors.New("pq: password authentication failed for user \"app\""), ErrorTypeAuthFailure},
+		{&ClassifiedError{Err: errors.New("duplicate key"), Type: ErrorTypeConstraintViolation}, ErrorTypeConstraintViolation},
+		{errors.New("syntax error"), ErrorTypeUnknown},
+	}
+	for _, test := range tests {
+		if errorType := ClassifyError(test.err); errorType != test.expected {
+			t.Errorf("Expected %v to be classified as %q, got %q", test.err, test.expected, errorType)
+		}
+	}
+}
+
+func TestBaseAdapterClassify(t *testing.T) {
+	adapter := NewBaseAdapter(&cluster.ServiceConfig{Type: "test"})
+	duplicate := errors.New("duplicate key")
+	if err := adapter.classify(duplicate); err != duplicate {
+		t.Errorf("Expected errors to be kept without a classifier, got %v", err)
+	}
+
+	adapter.SetErrorClassifier(func(err error) string {
+		if err == duplicate {
+			return ErrorTypeConstraintViolation
+		}
+		return ""
+	})
+	err := adapter.classify(duplicate)
+	if !errors.Is(err, duplicate) || err.Error() != duplicate.Error() || ClassifyError(err) != ErrorTypeConstraintViolation {
+		t.Errorf("Expected the error to be classified as a constraint violation, got %v", ClassifyError(err))
+	}
+
+	// Errors the classifier does not know fall back to ClassifyError
+	if errorType := ClassifyError(adapter.classify(context.DeadlineExceeded)); errorType != ErrorTypeTimeout {
+		t.Errorf("Expected a timeout, got %q", errorType)
+	}
+}
@@ -0,0 +1,403 @@
+package etcd
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+
+	"github.com/akmadan/throome/pkg/adapters"
+	"github.com/akmadan/throome/pkg/cluster"
+)
+
+// TTL results for keys without an expiry and missing keys, matching Redis
+const (
+	ttlNoExpiry   = time.Duration(-1)
+	ttlKeyMissing = time.Duration(-2)
+)
+
+// EtcdAdapter implements the WatchAdapter interface for etcd. Expirations are backed
+// by leases: each key written with a TTL is attached to its own lease.
+type EtcdAdapter struct {
+	*adapters.BaseAdapter
+	config *cluster.ServiceConfig
+	client *clientv3.Client
+}
+
+// NewEtcdAdapter creates a new etcd adapter
+func NewEtcdAdapter(config *cluster.ServiceConfig) (adapters.Adapter, error) {
+	adapter := &EtcdAdapter{
+		BaseAdapter: adapters.NewBaseAdapter(config),
+		config:      config,
+	}
+	return adapter, nil
+}
+
+// Connect establishes a connection to etcd
+func (e *EtcdAdapter) Connect(ctx context.Context) error {
+	tlsConfig, err := adapters.BuildTLSConfig(&e.config.TLS)
+	if err != nil {
+		return err
+	}
+
+	client, err := clientv3.New(clientv3.Config{
+		Endpoints:   []string{fmt.Sprintf("%s:%d", e.config.Host, e.config.Port)},
+		Username:    e.config.Username,
+		Password:    e.config.Password,
+		TLS:         tlsConfig,
+		DialTimeout: 5 * time.Second,
+		Context:     context.Background(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create etcd client: %w", err)
+	}
+
+	e.client = client
+
+	// Test connection
+	if err := e.Ping(ctx); err != nil {
+		_ = client.Close()
+		return fmt.Errorf("failed to connect to etcd: %w", err)
+	}
+
+	e.SetConnected(true)
+	return nil
+}
+
+// Disconnect closes the etcd connection
+func (e *EtcdAdapter) Disconnect(ctx context.Context) error {
+	if e.client != nil {
+		err := e.client.Close()
+		e.SetConnected(false)
+		return err
+	}
+	return nil
+}
+
+// Ping checks if the etcd connection is alive
+func (e *EtcdAdapter) Ping(ctx context.Context) error {
+	start := time.Now()
+	// Status requires a live connection to the endpoint, unlike the lazily dialled KV calls
+	_, err := e.client.Status(ctx, e.client.Endpoints()[0])
+	e.RecordRequest(time.Since(start), err == nil)
+	return err
+}
+
+// HealthCheck performs a health check
+func (e *EtcdAdapter) HealthCheck(ctx context.Context) (*adapters.HealthStatus, error) {
+	start := time.Now()
+	err := e.Ping(ctx)
+	responseTime := time.Since(start)
+
+	status := &adapters.HealthStatus{
+		Healthy:      err == nil,
+		Reachable:    err == nil,
+		ResponseTime: responseTime,
+		LastChecked:  time.Now(),
+	}
+
+	if err != nil {
+		status.ErrorMessage = err.Error()
+	}
+
+	return status, nil
+}
+
+// Get retrieves a value from etcd. A missing key returns an empty value.
+func (e *EtcdAdapter) Get(ctx context.Context, key string) (string, error) {
+	start := time.Now()
+	resp, err := e.client.Get(ctx, key)
+	duration := time.Since(start)
+	e.RecordRequest(duration, err == nil)
+
+	var value string
+	response := ""
+	if err == nil {
+		if len(resp.Kvs) == 0 {
+			response = "(nil)"
+		} else {
+			value = string(resp.Kvs[0].Value)
+			response = value
+		}
+	}
+
+	// Log activity
+	e.LogActivity(ctx, "GET", fmt.Sprintf("GET %s", key), duration, err, response)
+
+	return value, err
+}
+
+// Set sets a value in etcd. A positive expiration attaches the key to a new lease;
+// otherwise the key is stored without one, clearing any previous expiry.
+func (e *EtcdAdapter) Set(ctx context.Context, key, value string, expiration time.Duration) error {
+	start := time.Now()
+	err := e.put(ctx, key, value, expiration)
+	duration := time.Since(start)
+	e.RecordRequest(duration, err == nil)
+
+	// Log activity
+	command := fmt.Sprintf("PUT %s %s", key, value)
+	if expiration > 0 {
+		command += fmt.Sprintf(" --lease-ttl=%d", leaseSeconds(expiration))
+	}
+	response := "OK"
+	if err != nil {
+		response = ""
+	}
+	e.LogActivity(ctx, "SET", command, duration, err, response)
+
+	return err
+}
+
+func (e *EtcdAdapter) put(ctx context.Context, key, value string, expiration time.Duration) error {
+	opts, err := e.leaseOptions(ctx, expiration)
+	if err != nil {
+		return err
+	}
+	_, err = e.client.Put(ctx, key, value, opts...)
+	return err
+}
+
+// leaseOptions grants a lease for a positive expiration
+func (e *EtcdAdapter) leaseOptions(ctx context.Context, expiration time.Duration) ([]clientv3.OpOption, error) {
+	if expiration <= 0 {
+		return nil, nil
+	}
+	lease, err := e.client.Grant(ctx, leaseSeconds(expiration))
+	if err != nil {
+		return nil, fmt.Errorf("failed to grant lease: %w", err)
+	}
+	return []clientv3.OpOption{clientv3.WithLease(lease.ID)}, nil
+}
+
+// Delete deletes a key from etcd
+func (e *EtcdAdapter) Delete(ctx context.Context, key string) error {
+	start := time.Now()
+	resp, err := e.client.Delete(ctx, key)
+	duration := time.Since(start)
+	e.RecordRequest(duration, err == nil)
+
+	// Log activity
+	response := ""
+	if err == nil {
+		response = fmt.Sprintf("%d keys deleted", resp.Deleted)
+	}
+	e.LogActivity(ctx, "DELETE", fmt.Sprintf("DEL %s", key), duration, err, response)
+
+	return err
+}
+
+// Exists checks if a key exists in etcd
+func (e *EtcdAdapter) Exists(ctx context.Context, key string) (bool, error) {
+	start := time.Now()
+	resp, err := e.client.Get(ctx, key, clientv3.WithCountOnly())
+	e.RecordRequest(time.Since(start), err == nil)
+	if err != nil {
+		return false, err
+	}
+	return resp.Count > 0, nil
+}
+
+// Keys returns keys matching a Redis-style glob pattern. Only the literal prefix of
+// the pattern is used as an etcd range; the rest is matched client-side.
+func (e *EtcdAdapter) Keys(ctx context.Context, pattern string) ([]string, error) {
+	start := time.Now()
+	keys, err := e.keys(ctx, pattern)
+	e.RecordRequest(time.Since(start), err == nil)
+	return keys, err
+}
+
+func (e *EtcdAdapter) keys(ctx context.Context, pattern string) ([]string, error) {
+	matcher, err := globToRegexp(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+
+	// An empty prefix ranges over the whole keyspace
+	resp, err := e.client.Get(ctx, literalPrefix(pattern), clientv3.WithPrefix(), clientv3.WithKeysOnly())
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		if key := string(kv.Key); matcher.MatchString(key) {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+// TTL returns the remaining time-to-live of a key's lease. Like Redis, it returns -1
+// for keys without an expiry and -2 for missing keys.
+func (e *EtcdAdapter) TTL(ctx context.Context, key string) (time.Duration, error) {
+	start := time.Now()
+	ttl, err := e.ttl(ctx, key)
+	e.RecordRequest(time.Since(start), err == nil)
+	return ttl, err
+}
+
+func (e *EtcdAdapter) ttl(ctx context.Context, key string) (time.Duration, error) {
+	resp, err := e.client.Get(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	if len(resp.Kvs) == 0 {
+		return ttlKeyMissing, nil
+	}
+
+	leaseID := clientv3.LeaseID(resp.Kvs[0].Lease)
+	if leaseID == clientv3.NoLease {
+		return ttlNoExpiry, nil
+	}
+
+	lease, err := e.client.TimeToLive(ctx, leaseID)
+	if err != nil {
+		return 0, err
+	}
+	if lease.TTL < 0 {
+		// The lease expired between the two reads, taking the key with it
+		return ttlKeyMissing, nil
+	}
+	return time.Duration(lease.TTL) * time.Second, nil
+}
+
+// Expire sets expiration on a key by moving it onto a new lease. The key is rewritten
+// only if it has not changed since it was read. A non-positive expiration deletes the
+// key, as in Redis.
+func (e *EtcdAdapter) Expire(ctx context.Context, key string, expiration time.Duration) error {
+	start := time.Now()
+	err := e.expire(ctx, key, expiration)
+	e.RecordRequest(time.Since(start), err == nil)
+	return err
+}
+
+func (e *EtcdAdapter) expire(ctx context.Context, key string, expiration time.Duration) error {
+	if expiration <= 0 {
+		_, err := e.client.Delete(ctx, key)
+		return err
+	}
+
+	resp, err := e.client.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+	if len(resp.Kvs) == 0 {
+		return nil
+	}
+	kv := resp.Kvs[0]
+
+	opts, err := e.leaseOptions(ctx, expiration)
+	if err != nil {
+		return err
+	}
+
+	txn, err := e.client.Txn(ctx).
+		If(clientv3.Compare(clientv3.ModRevision(key), "=", kv.ModRevision)).
+		Then(clientv3.OpPut(key, string(kv.Value), opts...)).
+		Commit()
+	if err != nil {
+		return err
+	}
+	if !txn.Succeeded {
+		return fmt.Errorf("key %s was modified while setting its expiration", key)
+	}
+	return nil
+}
+
+// Watch streams changes to the keys starting with prefix until ctx is cancelled
+func (e *EtcdAdapter) Watch(ctx context.Context, prefix string) (<-chan adapters.WatchEvent, error) {
+	// Require a leader so watches on a partitioned member fail instead of hanging
+	watchChan := e.client.Watch(clientv3.WithRequireLeader(ctx), prefix, clientv3.WithPrefix())
+	e.LogActivity(ctx, "WATCH", fmt.Sprintf("WATCH %s --prefix", prefix), 0, nil, "watching")
+
+	events := make(chan adapters.WatchEvent)
+	go func() {
+		defer close(events)
+		for resp := range watchChan {
+			if err := resp.Err(); err != nil {
+				e.LogActivity(ctx, "WATCH", fmt.Sprintf("WATCH %s --prefix", prefix), 0, err, "")
+				return
+			}
+			for _, event := range resp.Events {
+				watchEvent := adapters.WatchEvent{
+					Type:     adapters.WatchEventPut,
+					Key:      string(event.Kv.Key),
+					Value:    string(event.Kv.Value),
+					Revision: event.Kv.ModRevision,
+				}
+				if event.Type == clientv3.EventTypeDelete {
+					watchEvent.Type = adapters.WatchEventDelete
+					watchEvent.Value = ""
+				}
+
+				select {
+				case events <- watchEvent:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return events, nil
+}
+
+// GetClient returns the underlying etcd client for advanced operations
+func (e *EtcdAdapter) GetClient() *clientv3.Client {
+	return e.client
+}
+
+// leaseSeconds rounds an expiration up to whole seconds, the granularity of leases
+func leaseSeconds(expiration time.Duration) int64 {
+	return int64(math.Ceil(expiration.Seconds()))
+}
+
+// literalPrefix returns the part of a glob pattern before its first wildcard
+func literalPrefix(pattern string) string {
+	if i := strings.IndexAny(pattern, `*?[\`); i >= 0 {
+		return pattern[:i]
+	}
+	return pattern
+}
+
+// globToRegexp converts a Redis-style glob pattern into an anchored regular
+// expression. Unlike path.Match, * also matches across '/' separators.
+func globToRegexp(pattern string) (*regexp.Regexp, error) {
+	var expr strings.Builder
+	expr.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			expr.WriteString(".*")
+		case '?':
+			expr.WriteString(".")
+		case '[':
+			end := strings.IndexByte(pattern[i+1:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated character class")
+			}
+			class := pattern[i+1 : i+1+end]
+			if strings.HasPrefix(class, "^") {
+				class = "^" + regexp.QuoteMeta(class[1:])
+			} else {
+				class = regexp.QuoteMeta(class)
+			}
+			expr.WriteString("[" + class + "]")
+			i += end + 1
+		case '\\':
+			if i+1 < len(pattern) {
+				i++
+				expr.WriteString(regexp.QuoteMeta(string(pattern[i])))
+			}
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	expr.WriteString("$")
+	return regexp.Compile(expr.String())
+}
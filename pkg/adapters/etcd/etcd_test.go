@@ -0,0 +1,65 @@
+package etcd
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGlobToRegexp(t *testing.T) {
+	tests := []struct {
+		pattern string
+		key     string
+		want    bool
+	}{
+		{"*", "config/app/port", true},
+		{"config/*", "config/app/port", true},
+		{"config/*", "other/app", false},
+		{"user:?", "user:1", true},
+		{"user:?", "user:10", false},
+		{"user:[12]", "user:2", true},
+		{"user:[^12]", "user:2", false},
+		{"user:[a-c]", "user:b", true},
+		{"a.b", "axb", false},
+		{`literal\*`, "literal*", true},
+		{`literal\*`, "literalx", false},
+	}
+
+	for _, tt := range tests {
+		matcher, err := globToRegexp(tt.pattern)
+		if err != nil {
+			t.Fatalf("Unexpected error for %q: %v", tt.pattern, err)
+		}
+		if got := matcher.MatchString(tt.key); got != tt.want {
+			t.Errorf("Pattern %q on %q: expected %v, got %v", tt.pattern, tt.key, tt.want, got)
+		}
+	}
+
+	if _, err := globToRegexp("user:[12"); err == nil {
+		t.Error("Expected an error for an unterminated character class")
+	}
+}
+
+func TestLiteralPrefix(t *testing.T) {
+	tests := map[string]string{
+		"*":             "",
+		"config/*":      "config/",
+		"user:?":        "user:",
+		"session:[ab]*": "session:",
+		"exact":         "exact",
+	}
+
+	for pattern, want := range tests {
+		if got := literalPrefix(pattern); got != want {
+			t.Errorf("Pattern %q: expected prefix %q, got %q", pattern, want, got)
+		}
+	}
+}
+
+func TestLeaseSeconds(t *testing.T) {
+	if got := leaseSeconds(1500 * time.Millisecond); got != 2 {
+		t.Errorf("Expected partial seconds to round up to 2, got %d", got)
+	}
+	if got := leaseSeconds(time.Minute); got != 60 {
+		t.Errorf("Expected 60, got %d", got)
+	}
+}
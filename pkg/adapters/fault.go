@@ -0,0 +1,589 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrInjectedFault is returned by operations failed by fault injection
+	ErrInjectedFault = errors.New("injected fault")
+
+	// ErrInjectedDrop is returned by operations whose connection was dropped by fault injection
+	ErrInjectedDrop = fmt.Errorf("%w: connection dropped", ErrInjectedFault)
+)
+
+// FaultConfig describes the faults injected into a service's operations
+type FaultConfig struct {
+	Latency   time.Duration `json:"latency"`    // Added before every operation
+	Jitter    time.Duration `json:"jitter"`     // Random extra latency up to this value
+	ErrorRate float64       `json:"error_rate"` // Percentage of operations that fail
+	DropRate  float64       `json:"drop_rate"`  // Percentage of operations that fail as dropped connections
+	Unhealthy bool          `json:"unhealthy"`  // Fail pings and health checks
+	ExpiresAt time.Time     `json:"expires_at,omitempty"`
+}
+
+// Validate validates the fault configuration
+func (c *FaultConfig) Validate() error {
+	if c.Latency < 0 || c.Jitter < 0 {
+		return fmt.Errorf("latency and jitter cannot be negative")
+	}
+	if c.ErrorRate < 0 || c.ErrorRate > 100 {
+		return fmt.Errorf("error rate must be between 0 and 100")
+	}
+	if c.DropRate < 0 || c.DropRate > 100 {
+		return fmt.Errorf("drop rate must be between 0 and 100")
+	}
+	return nil
+}
+
+// FaultInjector holds the faults currently injected into a service. The zero
+// configuration injects nothing.
+type FaultInjector struct {
+	config *FaultConfig
+	mu     sync.RWMutex
+}
+
+// NewFaultInjector creates a fault injector with no faults
+func NewFaultInjector() *FaultInjector {
+	return &FaultInjector{}
+}
+
+// Set replaces the injected faults
+func (f *FaultInjector) Set(config FaultConfig) error {
+	if err := config.Validate(); err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.config = &config
+	return nil
+}
+
+// Clear removes all injected faults
+func (f *FaultInjector) Clear() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.config = nil
+}
+
+// Config returns a copy of the active faults, or nil when none are injected
+func (f *FaultInjector) Config() *FaultConfig {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	if f.config == nil || (!f.config.ExpiresAt.IsZero() && time.Now().After(f.config.ExpiresAt)) {
+		return nil
+	}
+	config := *f.config
+	return &config
+}
+
+// Inject applies the active faults to an operation: it waits for the injected
+// latency, then fails the operation at the configured rates
+func (f *FaultInjector) Inject(ctx context.Context) error {
+	config := f.Config()
+	if config == nil {
+		return nil
+	}
+
+	delay := config.Latency
+	if config.Jitter > 0 {
+		delay += time.Duration(rand.Int63n(int64(config.Jitter))) // #nosec G404 -- Not security sensitive
+	}
+	if delay > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+
+	if config.DropRate > 0 && rand.Float64()*100 < config.DropRate { // #nosec G404 -- Not security sensitive
+		return ErrInjectedDrop
+	}
+	if config.ErrorRate > 0 && rand.Float64()*100 < config.ErrorRate { // #nosec G404 -- Not security sensitive
+		return ErrInjectedFault
+	}
+
+	return nil
+}
+
+// Unhealthy reports whether the service is currently marked unhealthy
+func (f *FaultInjector) Unhealthy() bool {
+	config := f.Config()
+	return config != nil && config.Unhealthy
+}
+
+// faultAdapter injects faults into the base Adapter operations
+type faultAdapter struct {
+	Adapter
+	injector *FaultInjector
+}
+
+// WithFaults wraps an adapter so its operations go through the fault injector. The
+// returned adapter implements the same database, cache or queue interface as the
+// wrapped one; use Unwrap to reach the underlying adapter.
+func WithFaults(adapter Adapter, injector *FaultInjector) Adapter {
+	base := &faultAdapter{Adapter: adapter, injector: injector}
+
+	switch inner := adapter.(type) {
+	case DatabaseAdapter:
+		return &faultDatabase{faultAdapter: base, db: inner}
+	case WatchAdapter:
+		return &faultWatch{faultCache: &faultCache{faultAdapter: base, cache: inner}, watcher: inner}
+	case cacheQueue:
+		return &faultCacheQueue{faultCache: &faultCache{faultAdapter: base, cache: inner}, queue: &faultQueue{faultAdapter: base, queue: inner}}
+	case CacheAdapter:
+		return &faultCache{faultAdapter: base, cache: inner}
+	case QueueAdapter:
+		return &faultQueue{faultAdapter: base, queue: inner}
+	case DocumentAdapter:
+		return &faultDocument{faultAdapter: base, documents: inner}
+	case TimeSeriesAdapter:
+		return &faultTimeSeries{faultAdapter: base, series: inner}
+	case KeyDocumentAdapter:
+		return &faultKeyDocument{faultAdapter: base, items: inner}
+	case VectorAdapter:
+		return &faultVector{faultAdapter: base, vectors: inner}
+	case SecretAdapter:
+		return &faultSecret{faultAdapter: base, secrets: inner}
+	case ObjectStoreAdapter:
+		return &faultObjectStore{faultAdapter: base, objects: inner}
+	default:
+		return base
+	}
+}
+
+// Unwrap returns the adapter beneath any fault injection wrapper
+func Unwrap(adapter Adapter) Adapter {
+	if wrapped, ok := adapter.(interface{ Unwrap() Adapter }); ok {
+		return wrapped.Unwrap()
+	}
+	return adapter
+}
+
+// InjectFault applies the faults injected into an adapter, if it is wrapped. It is
+// used by callers that operate on the underlying adapter directly.
+func InjectFault(ctx context.Context, adapter Adapter) error {
+	if wrapped, ok := adapter.(interface{ Faults() *FaultInjector }); ok {
+		return wrapped.Faults().Inject(ctx)
+	}
+	return nil
+}
+
+// Unwrap returns the wrapped adapter
+func (a *faultAdapter) Unwrap() Adapter {
+	return a.Adapter
+}
+
+// Faults returns the adapter's fault injector
+func (a *faultAdapter) Faults() *FaultInjector {
+	return a.injector
+}
+
+// Ping fails while the service is marked unhealthy
+func (a *faultAdapter) Ping(ctx context.Context) error {
+	if a.injector.Unhealthy() {
+		return fmt.Errorf("%w: service marked unhealthy", ErrInjectedFault)
+	}
+	if err := a.injector.Inject(ctx); err != nil {
+		return err
+	}
+	return a.Adapter.Ping(ctx)
+}
+
+// HealthCheck reports the service as unreachable while it is marked unhealthy or
+// its connection is dropped, and includes injected latency in the response time
+func (a *faultAdapter) HealthCheck(ctx context.Context) (*HealthStatus, error) {
+	if a.injector.Unhealthy() {
+		return &HealthStatus{
+			ErrorMessage: "injected fault: service marked unhealthy",
+			LastChecked:  time.Now(),
+		}, nil
+	}
+
+	start := time.Now()
+	if err := a.injector.Inject(ctx); errors.Is(err, ErrInjectedDrop) {
+		return &HealthStatus{
+			ResponseTime: time.Since(start),
+			ErrorMessage: err.Error(),
+			LastChecked:  time.Now(),
+		}, nil
+	}
+	injected := time.Since(start)
+
+	status, err := a.Adapter.HealthCheck(ctx)
+	if err != nil {
+		return nil, err
+	}
+	status.ResponseTime += injected
+	return status, nil
+}
+
+// faultDatabase injects faults into database operations
+type faultDatabase struct {
+	*faultAdapter
+	db DatabaseAdapter
+}
+
+func (d *faultDatabase) Execute(ctx context.Context, query string, args ...interface{}) (Result, error) {
+	if err := d.injector.Inject(ctx); err != nil {
+		return nil, err
+	}
+	return d.db.Execute(ctx, query, args...)
+}
+
+func (d *faultDatabase) Query(ctx context.Context, query string, args ...interface{}) (Rows, error) {
+	if err := d.injector.Inject(ctx); err != nil {
+		return nil, err
+	}
+	return d.db.Query(ctx, query, args...)
+}
+
+func (d *faultDatabase) QueryRow(ctx context.Context, query string, args ...interface{}) Row {
+	if err := d.injector.Inject(ctx); err != nil {
+		return faultRow{err: err}
+	}
+	return d.db.QueryRow(ctx, query, args...)
+}
+
+func (d *faultDatabase) Begin(ctx context.Context) (Transaction, error) {
+	if err := d.injector.Inject(ctx); err != nil {
+		return nil, err
+	}
+	return d.db.Begin(ctx)
+}
+
+// faultRow is returned by QueryRow when a fault was injected
+type faultRow struct {
+	err error
+}
+
+func (r faultRow) Scan(dest ...interface{}) error {
+	return r.err
+}
+
+// faultCache injects faults into cache operations
+type faultCache struct {
+	*faultAdapter
+	cache CacheAdapter
+}
+
+func (c *faultCache) Get(ctx context.Context, key string) (string, error) {
+	if err := c.injector.Inject(ctx); err != nil {
+		return "", err
+	}
+	return c.cache.Get(ctx, key)
+}
+
+func (c *faultCache) Set(ctx context.Context, key string, value string, expiration time.Duration) error {
+	if err := c.injector.Inject(ctx); err != nil {
+		return err
+	}
+	return c.cache.Set(ctx, key, value, expiration)
+}
+
+func (c *faultCache) Delete(ctx context.Context, key string) error {
+	if err := c.injector.Inject(ctx); err != nil {
+		return err
+	}
+	return c.cache.Delete(ctx, key)
+}
+
+func (c *faultCache) Exists(ctx context.Context, key string) (bool, error) {
+	if err := c.injector.Inject(ctx); err != nil {
+		return false, err
+	}
+	return c.cache.Exists(ctx, key)
+}
+
+func (c *faultCache) Keys(ctx context.Context, pattern string) ([]string, error) {
+	if err := c.injector.Inject(ctx); err != nil {
+		return nil, err
+	}
+	return c.cache.Keys(ctx, pattern)
+}
+
+func (c *faultCache) TTL(ctx context.Context, key string) (time.Duration, error) {
+	if err := c.injector.Inject(ctx); err != nil {
+		return 0, err
+	}
+	return c.cache.TTL(ctx, key)
+}
+
+func (c *faultCache) Expire(ctx context.Context, key string, expiration time.Duration) error {
+	if err := c.injector.Inject(ctx); err != nil {
+		return err
+	}
+	return c.cache.Expire(ctx, key, expiration)
+}
+
+// faultWatch injects faults into watchable key-value stores
+type faultWatch struct {
+	*faultCache
+	watcher WatchAdapter
+}
+
+func (w *faultWatch) Watch(ctx context.Context, prefix string) (<-chan WatchEvent, error) {
+	if err := w.injector.Inject(ctx); err != nil {
+		return nil, err
+	}
+	return w.watcher.Watch(ctx, prefix)
+}
+
+// faultQueue injects faults into queue operations
+type faultQueue struct {
+	*faultAdapter
+	queue QueueAdapter
+}
+
+func (q *faultQueue) Publish(ctx context.Context, topic string, message []byte) error {
+	if err := q.injector.Inject(ctx); err != nil {
+		return err
+	}
+	return q.queue.Publish(ctx, topic, message)
+}
+
+func (q *faultQueue) Subscribe(ctx context.Context, topic string, handler MessageHandler) error {
+	if err := q.injector.Inject(ctx); err != nil {
+		return err
+	}
+	return q.queue.Subscribe(ctx, topic, handler)
+}
+
+func (q *faultQueue) Unsubscribe(ctx context.Context, topic string) error {
+	return q.queue.Unsubscribe(ctx, topic)
+}
+
+func (q *faultQueue) CreateTopic(ctx context.Context, topic string, config map[string]interface{}) error {
+	if err := q.injector.Inject(ctx); err != nil {
+		return err
+	}
+	return q.queue.CreateTopic(ctx, topic, config)
+}
+
+func (q *faultQueue) DeleteTopic(ctx context.Context, topic string) error {
+	if err := q.injector.Inject(ctx); err != nil {
+		return err
+	}
+	return q.queue.DeleteTopic(ctx, topic)
+}
+
+func (q *faultQueue) ListTopics(ctx context.Context) ([]string, error) {
+	if err := q.injector.Inject(ctx); err != nil {
+		return nil, err
+	}
+	return q.queue.ListTopics(ctx)
+}
+
+// cacheQueue is implemented by caches that double as queues, such as Redis with Streams
+type cacheQueue interface {
+	CacheAdapter
+	QueueAdapter
+}
+
+// faultCacheQueue injects faults into both the cache and queue operations of an adapter
+type faultCacheQueue struct {
+	*faultCache
+	queue *faultQueue
+}
+
+func (c *faultCacheQueue) Publish(ctx context.Context, topic string, message []byte) error {
+	return c.queue.Publish(ctx, topic, message)
+}
+
+func (c *faultCacheQueue) Subscribe(ctx context.Context, topic string, handler MessageHandler) error {
+	return c.queue.Subscribe(ctx, topic, handler)
+}
+
+func (c *faultCacheQueue) Unsubscribe(ctx context.Context, topic string) error {
+	return c.queue.Unsubscribe(ctx, topic)
+}
+
+func (c *faultCacheQueue) CreateTopic(ctx context.Context, topic string, config map[string]interface{}) error {
+	return c.queue.CreateTopic(ctx, topic, config)
+}
+
+func (c *faultCacheQueue) DeleteTopic(ctx context.Context, topic string) error {
+	return c.queue.DeleteTopic(ctx, topic)
+}
+
+func (c *faultCacheQueue) ListTopics(ctx context.Context) ([]string, error) {
+	return c.queue.ListTopics(ctx)
+}
+
+// faultDocument injects faults into document store operations
+type faultDocument struct {
+	*faultAdapter
+	documents DocumentAdapter
+}
+
+func (d *faultDocument) Insert(ctx context.Context, collection string, documents []Document) ([]interface{}, error) {
+	if err := d.injector.Inject(ctx); err != nil {
+		return nil, err
+	}
+	return d.documents.Insert(ctx, collection, documents)
+}
+
+func (d *faultDocument) Find(ctx context.Context, collection string, filter Document, opts FindOptions) ([]Document, error) {
+	if err := d.injector.Inject(ctx); err != nil {
+		return nil, err
+	}
+	return d.documents.Find(ctx, collection, filter, opts)
+}
+
+func (d *faultDocument) Update(ctx context.Context, collection string, filter, update Document) (int64, error) {
+	if err := d.injector.Inject(ctx); err != nil {
+		return 0, err
+	}
+	return d.documents.Update(ctx, collection, filter, update)
+}
+
+func (d *faultDocument) Delete(ctx context.Context, collection string, filter Document) (int64, error) {
+	if err := d.injector.Inject(ctx); err != nil {
+		return 0, err
+	}
+	return d.documents.Delete(ctx, collection, filter)
+}
+
+func (d *faultDocument) Aggregate(ctx context.Context, collection string, pipeline []Document) ([]Document, error) {
+	if err := d.injector.Inject(ctx); err != nil {
+		return nil, err
+	}
+	return d.documents.Aggregate(ctx, collection, pipeline)
+}
+
+// faultObjectStore injects faults into object storage operations
+type faultObjectStore struct {
+	*faultAdapter
+	objects ObjectStoreAdapter
+}
+
+func (o *faultObjectStore) PutObject(ctx context.Context, bucket, key string, data io.Reader, size int64, contentType string) (*ObjectInfo, error) {
+	if err := o.injector.Inject(ctx); err != nil {
+		return nil, err
+	}
+	return o.objects.PutObject(ctx, bucket, key, data, size, contentType)
+}
+
+func (o *faultObjectStore) GetObject(ctx context.Context, bucket, key string) (io.ReadCloser, *ObjectInfo, error) {
+	if err := o.injector.Inject(ctx); err != nil {
+		return nil, nil, err
+	}
+	return o.objects.GetObject(ctx, bucket, key)
+}
+
+func (o *faultObjectStore) ListObjects(ctx context.Context, bucket, prefix string, limit int) ([]*ObjectInfo, error) {
+	if err := o.injector.Inject(ctx); err != nil {
+		return nil, err
+	}
+	return o.objects.ListObjects(ctx, bucket, prefix, limit)
+}
+
+func (o *faultObjectStore) Presign(ctx context.Context, method, bucket, key string, expiry time.Duration) (string, error) {
+	if err := o.injector.Inject(ctx); err != nil {
+		return "", err
+	}
+	return o.objects.Presign(ctx, method, bucket, key, expiry)
+}
+
+// faultTimeSeries injects faults into time series operations
+type faultTimeSeries struct {
+	*faultAdapter
+	series TimeSeriesAdapter
+}
+
+func (t *faultTimeSeries) WritePoints(ctx context.Context, points []*Point) error {
+	if err := t.injector.Inject(ctx); err != nil {
+		return err
+	}
+	return t.series.WritePoints(ctx, points)
+}
+
+func (t *faultTimeSeries) QueryRange(ctx context.Context, query RangeQuery) ([]*Point, error) {
+	if err := t.injector.Inject(ctx); err != nil {
+		return nil, err
+	}
+	return t.series.QueryRange(ctx, query)
+}
+
+// faultSecret injects faults into secret store operations
+type faultSecret struct {
+	*faultAdapter
+	secrets SecretAdapter
+}
+
+func (s *faultSecret) ReadSecret(ctx context.Context, path string) (*Secret, error) {
+	if err := s.injector.Inject(ctx); err != nil {
+		return nil, err
+	}
+	return s.secrets.ReadSecret(ctx, path)
+}
+
+func (s *faultSecret) WriteSecret(ctx context.Context, path string, data map[string]interface{}) (*Secret, error) {
+	if err := s.injector.Inject(ctx); err != nil {
+		return nil, err
+	}
+	return s.secrets.WriteSecret(ctx, path, data)
+}
+
+// faultKeyDocument injects faults into key-value document store operations
+type faultKeyDocument struct {
+	*faultAdapter
+	items KeyDocumentAdapter
+}
+
+func (k *faultKeyDocument) PutItem(ctx context.Context, table string, item Document) error {
+	if err := k.injector.Inject(ctx); err != nil {
+		return err
+	}
+	return k.items.PutItem(ctx, table, item)
+}
+
+func (k *faultKeyDocument) GetItem(ctx context.Context, table string, key Document) (Document, error) {
+	if err := k.injector.Inject(ctx); err != nil {
+		return nil, err
+	}
+	return k.items.GetItem(ctx, table, key)
+}
+
+func (k *faultKeyDocument) Query(ctx context.Context, table string, query KeyQuery) ([]Document, error) {
+	if err := k.injector.Inject(ctx); err != nil {
+		return nil, err
+	}
+	return k.items.Query(ctx, table, query)
+}
+
+// faultVector injects faults into vector database operations
+type faultVector struct {
+	*faultAdapter
+	vectors VectorAdapter
+}
+
+func (v *faultVector) CreateCollection(ctx context.Context, name string, dimension int, distance string) error {
+	if err := v.injector.Inject(ctx); err != nil {
+		return err
+	}
+	return v.vectors.CreateCollection(ctx, name, dimension, distance)
+}
+
+func (v *faultVector) Upsert(ctx context.Context, collection string, points []*VectorPoint) error {
+	if err := v.injector.Inject(ctx); err != nil {
+		return err
+	}
+	return v.vectors.Upsert(ctx, collection, points)
+}
+
+func (v *faultVector) SearchNearest(ctx context.Context, collection string, query VectorQuery) ([]*VectorMatch, error) {
+	if err := v.injector.Inject(ctx); err != nil {
+		return nil, err
+	}
+	return v.vectors.SearchNearest(ctx, collection, query)
+}
@@ -0,0 +1,153 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWithFaults(t *testing.T) {
+	cache := &fakeCache{values: map[string]string{"greeting": "hello"}}
+	injector := NewFaultInjector()
+
+	wrapped, ok := WithFaults(cache, injector).(CacheAdapter)
+	if !ok {
+		t.Fatal("Expected wrapped cache to implement CacheAdapter")
+	}
+	if Unwrap(wrapped) != cache {
+		t.Error("Expected Unwrap to return the wrapped adapter")
+	}
+
+	if value, err := wrapped.Get(context.Background(), "greeting"); err != nil || value != "hello" {
+		t.Errorf("Expected pass-through without faults, got %q, %v", value, err)
+	}
+
+	if err := injector.Set(FaultConfig{ErrorRate: 100}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := wrapped.Get(context.Background(), "greeting"); !errors.Is(err, ErrInjectedFault) {
+		t.Errorf("Expected injected fault, got %v", err)
+	}
+	if err := InjectFault(context.Background(), wrapped); !errors.Is(err, ErrInjectedFault) {
+		t.Errorf("Expected InjectFault to apply the injected fault, got %v", err)
+	}
+
+	if err := injector.Set(FaultConfig{DropRate: 100}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := wrapped.Get(context.Background(), "greeting"); !errors.Is(err, ErrInjectedDrop) {
+		t.Errorf("Expected dropped connection, got %v", err)
+	}
+
+	injector.Clear()
+	if _, err := wrapped.Get(context.Background(), "greeting"); err != nil {
+		t.Errorf("Expected no error after clearing faults, got %v", err)
+	}
+}
+
+// fakeCacheQueue is a cache that doubles as a queue
+type fakeCacheQueue struct {
+	cacheQueue
+	published []string
+}
+
+func (c *fakeCacheQueue) Publish(ctx context.Context, topic string, message []byte) error {
+	c.published = append(c.published, topic)
+	return nil
+}
+
+func TestWithFaultsCacheQueue(t *testing.T) {
+	adapter := &fakeCacheQueue{}
+	injector := NewFaultInjector()
+	wrapped := WithFaults(adapter, injector)
+
+	if _, ok := wrapped.(CacheAdapter); !ok {
+		t.Fatal("Expected wrapped adapter to implement CacheAdapter")
+	}
+	queue, ok := wrapped.(QueueAdapter)
+	if !ok {
+		t.Fatal("Expected wrapped adapter to implement QueueAdapter")
+	}
+
+	if err := queue.Publish(context.Background(), "events", []byte("hello")); err != nil || len(adapter.published) != 1 {
+		t.Errorf("Expected pass-through without faults, got %v", err)
+	}
+
+	if err := injector.Set(FaultConfig{ErrorRate: 100}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := queue.Publish(context.Background(), "events", []byte("hello")); !errors.Is(err, ErrInjectedFault) {
+		t.Errorf("Expected injected fault, got %v", err)
+	}
+}
+
+func TestFaultInjectorLatency(t *testing.T) {
+	injector := NewFaultInjector()
+	if err := injector.Set(FaultConfig{Latency: 20 * time.Millisecond}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	start := time.Now()
+	if err := injector.Inject(context.Background()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Errorf("Expected at least 20ms of injected latency, got %s", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := injector.Inject(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected injected latency to respect cancellation, got %v", err)
+	}
+}
+
+func TestFaultInjectorUnhealthy(t *testing.T) {
+	injector := NewFaultInjector()
+	wrapped := WithFaults(&fakeCache{}, injector)
+
+	if err := injector.Set(FaultConfig{Unhealthy: true}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	status, err := wrapped.HealthCheck(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if status.Healthy || status.Reachable {
+		t.Errorf("Expected unhealthy and unreachable status, got %+v", status)
+	}
+
+	// Expired faults are no longer applied
+	if err := injector.Set(FaultConfig{Unhealthy: true, ExpiresAt: time.Now().Add(-time.Second)}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if injector.Config() != nil {
+		t.Error("Expected expired faults to be inactive")
+	}
+	status, err = wrapped.HealthCheck(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !status.Healthy {
+		t.Errorf("Expected healthy status once faults expire, got %+v", status)
+	}
+}
+
+func TestFaultConfigValidate(t *testing.T) {
+	invalid := []FaultConfig{
+		{Latency: -time.Second},
+		{ErrorRate: 101},
+		{DropRate: -1},
+	}
+	for _, config := range invalid {
+		if err := config.Validate(); err == nil {
+			t.Errorf("Expected validation error for %+v", config)
+		}
+	}
+
+	valid := FaultConfig{Latency: time.Second, ErrorRate: 50, DropRate: 10}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("Unexpected validation error: %v", err)
+	}
+}
@@ -0,0 +1,341 @@
+package influxdb
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	influxdb2 "github.com/influxdata/influxdb-client-go/v2"
+	"github.com/influxdata/influxdb-client-go/v2/api/query"
+	"github.com/influxdata/influxdb-client-go/v2/api/write"
+
+	"github.com/akmadan/throome/pkg/adapters"
+	"github.com/akmadan/throome/pkg/cluster"
+)
+
+// Defaults matching the organization, bucket and token the provisioner sets up
+const (
+	defaultOrg    = "throome"
+	defaultBucket = "throome"
+	defaultToken  = "throome-token"
+)
+
+// aggregates are the Flux functions allowed as RangeQuery.Aggregate
+var aggregates = map[string]bool{
+	"mean": true, "median": true, "sum": true, "count": true,
+	"min": true, "max": true, "first": true, "last": true,
+}
+
+// Columns of Flux records that are not tags
+var reservedColumns = map[string]bool{
+	"result": true, "table": true,
+	"_start": true, "_stop": true, "_time": true,
+	"_measurement": true, "_field": true, "_value": true,
+}
+
+// InfluxDBAdapter implements the TimeSeriesAdapter interface for InfluxDB 2.x
+type InfluxDBAdapter struct {
+	*adapters.BaseAdapter
+	config *cluster.ServiceConfig
+	client influxdb2.Client
+}
+
+// NewInfluxDBAdapter creates a new InfluxDB adapter
+func NewInfluxDBAdapter(config *cluster.ServiceConfig) (adapters.Adapter, error) {
+	adapter := &InfluxDBAdapter{
+		BaseAdapter: adapters.NewBaseAdapter(config),
+		config:      config,
+	}
+	return adapter, nil
+}
+
+// Connect establishes a connection to InfluxDB
+func (i *InfluxDBAdapter) Connect(ctx context.Context) error {
+	tlsConfig, err := adapters.BuildTLSConfig(&i.config.TLS)
+	if err != nil {
+		return err
+	}
+
+	options := influxdb2.DefaultOptions().SetTLSConfig(tlsConfig)
+	i.client = influxdb2.NewClientWithOptions(serverURL(i.config), token(i.config), options)
+
+	// Test connection
+	if err := i.Ping(ctx); err != nil {
+		i.client.Close()
+		return fmt.Errorf("failed to connect to InfluxDB: %w", err)
+	}
+
+	i.SetConnected(true)
+	return nil
+}
+
+// Disconnect closes the InfluxDB client
+func (i *InfluxDBAdapter) Disconnect(ctx context.Context) error {
+	if i.client != nil {
+		i.client.Close()
+		i.SetConnected(false)
+	}
+	return nil
+}
+
+// Ping checks if InfluxDB is reachable
+func (i *InfluxDBAdapter) Ping(ctx context.Context) error {
+	start := time.Now()
+	ok, err := i.client.Ping(ctx)
+	if err == nil && !ok {
+		err = fmt.Errorf("InfluxDB is not ready")
+	}
+	i.RecordRequest(time.Since(start), err == nil)
+	return err
+}
+
+// HealthCheck performs a health check
+func (i *InfluxDBAdapter) HealthCheck(ctx context.Context) (*adapters.HealthStatus, error) {
+	start := time.Now()
+	err := i.Ping(ctx)
+	responseTime := time.Since(start)
+
+	status := &adapters.HealthStatus{
+		Healthy:      err == nil,
+		Reachable:    err == nil,
+		ResponseTime: responseTime,
+		LastChecked:  time.Now(),
+	}
+
+	if err != nil {
+		status.ErrorMessage = err.Error()
+	}
+
+	return status, nil
+}
+
+// WritePoints writes points to the service's bucket
+func (i *InfluxDBAdapter) WritePoints(ctx context.Context, points []*adapters.Point) error {
+	start := time.Now()
+	err := i.writePoints(ctx, points)
+	duration := time.Since(start)
+	i.RecordRequest(duration, err == nil)
+
+	// Log activity
+	response := ""
+	if err == nil {
+		response = fmt.Sprintf("%d points written", len(points))
+	}
+	i.LogActivity(ctx, "WRITE", fmt.Sprintf("WRITE %s (%d points)", bucket(i.config), len(points)), duration, err, response)
+
+	return err
+}
+
+func (i *InfluxDBAdapter) writePoints(ctx context.Context, points []*adapters.Point) error {
+	now := time.Now()
+	converted := make([]*write.Point, len(points))
+	for n, point := range points {
+		if point.Measurement == "" {
+			return fmt.Errorf("point %d has no measurement", n)
+		}
+		if len(point.Fields) == 0 {
+			return fmt.Errorf("point %d has no fields", n)
+		}
+		timestamp := point.Timestamp
+		if timestamp.IsZero() {
+			timestamp = now
+		}
+		converted[n] = influxdb2.NewPoint(point.Measurement, point.Tags, point.Fields, timestamp)
+	}
+
+	return i.client.WriteAPIBlocking(org(i.config), bucket(i.config)).WritePoint(ctx, converted...)
+}
+
+// QueryRange returns the points of a measurement within a time range. Fields of the
+// same series and timestamp are merged into one point.
+func (i *InfluxDBAdapter) QueryRange(ctx context.Context, rangeQuery adapters.RangeQuery) ([]*adapters.Point, error) {
+	start := time.Now()
+	flux, err := buildFlux(bucket(i.config), rangeQuery, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	points, err := i.query(ctx, flux)
+	duration := time.Since(start)
+	i.RecordRequest(duration, err == nil)
+
+	// Log activity
+	response := ""
+	if err == nil {
+		response = fmt.Sprintf("%d points", len(points))
+	}
+	i.LogActivity(ctx, "QUERY", flux, duration, err, response)
+
+	return points, err
+}
+
+func (i *InfluxDBAdapter) query(ctx context.Context, flux string) ([]*adapters.Point, error) {
+	result, err := i.client.QueryAPI(org(i.config)).Query(ctx, flux)
+	if err != nil {
+		return nil, err
+	}
+	defer result.Close()
+
+	var records []*query.FluxRecord
+	for result.Next() {
+		records = append(records, result.Record())
+	}
+	if err := result.Err(); err != nil {
+		return nil, err
+	}
+
+	return mergeRecords(records), nil
+}
+
+// GetClient returns the underlying InfluxDB client for advanced operations
+func (i *InfluxDBAdapter) GetClient() influxdb2.Client {
+	return i.client
+}
+
+// buildFlux builds the Flux query of a range query
+func buildFlux(bucket string, rangeQuery adapters.RangeQuery, now time.Time) (string, error) {
+	if rangeQuery.Measurement == "" {
+		return "", fmt.Errorf("measurement is required")
+	}
+	if rangeQuery.Start.IsZero() {
+		return "", fmt.Errorf("start is required")
+	}
+	end := rangeQuery.End
+	if end.IsZero() {
+		end = now
+	}
+	if !end.After(rangeQuery.Start) {
+		return "", fmt.Errorf("end must be after start")
+	}
+
+	var flux strings.Builder
+	fmt.Fprintf(&flux, "from(bucket: %s)", strconv.Quote(bucket))
+	fmt.Fprintf(&flux, "\n  |> range(start: %s, stop: %s)",
+		rangeQuery.Start.UTC().Format(time.RFC3339Nano), end.UTC().Format(time.RFC3339Nano))
+	fmt.Fprintf(&flux, "\n  |> filter(fn: (r) => r._measurement == %s)", strconv.Quote(rangeQuery.Measurement))
+
+	// Sort tags so the same query always produces the same Flux
+	tags := make([]string, 0, len(rangeQuery.Tags))
+	for tag := range rangeQuery.Tags {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	for _, tag := range tags {
+		fmt.Fprintf(&flux, "\n  |> filter(fn: (r) => r[%s] == %s)", strconv.Quote(tag), strconv.Quote(rangeQuery.Tags[tag]))
+	}
+
+	if len(rangeQuery.Fields) > 0 {
+		conditions := make([]string, len(rangeQuery.Fields))
+		for n, field := range rangeQuery.Fields {
+			conditions[n] = fmt.Sprintf("r._field == %s", strconv.Quote(field))
+		}
+		fmt.Fprintf(&flux, "\n  |> filter(fn: (r) => %s)", strings.Join(conditions, " or "))
+	}
+
+	if rangeQuery.Window > 0 {
+		aggregate := rangeQuery.Aggregate
+		if aggregate == "" {
+			aggregate = "mean"
+		}
+		if !aggregates[aggregate] {
+			return "", fmt.Errorf("unsupported aggregate: %s", aggregate)
+		}
+		fmt.Fprintf(&flux, "\n  |> aggregateWindow(every: %dms, fn: %s, createEmpty: false)", rangeQuery.Window.Milliseconds(), aggregate)
+	} else if rangeQuery.Aggregate != "" {
+		return "", fmt.Errorf("aggregate requires a window")
+	}
+
+	if rangeQuery.Limit > 0 {
+		fmt.Fprintf(&flux, "\n  |> limit(n: %d)", rangeQuery.Limit)
+	}
+
+	return flux.String(), nil
+}
+
+// mergeRecords groups Flux records, which carry a single field each, into points
+// keyed by measurement, tags and time
+func mergeRecords(records []*query.FluxRecord) []*adapters.Point {
+	points := make([]*adapters.Point, 0)
+	index := make(map[string]*adapters.Point)
+
+	for _, record := range records {
+		tags := make(map[string]string)
+		for column, value := range record.Values() {
+			if reservedColumns[column] {
+				continue
+			}
+			if tag, ok := value.(string); ok {
+				tags[column] = tag
+			}
+		}
+
+		key := seriesKey(record.Measurement(), tags, record.Time())
+		point, ok := index[key]
+		if !ok {
+			point = &adapters.Point{
+				Measurement: record.Measurement(),
+				Tags:        tags,
+				Fields:      make(map[string]interface{}),
+				Timestamp:   record.Time(),
+			}
+			index[key] = point
+			points = append(points, point)
+		}
+		point.Fields[record.Field()] = record.Value()
+	}
+
+	sort.SliceStable(points, func(a, b int) bool {
+		return points[a].Timestamp.Before(points[b].Timestamp)
+	})
+	return points
+}
+
+func seriesKey(measurement string, tags map[string]string, timestamp time.Time) string {
+	names := make([]string, 0, len(tags))
+	for name := range tags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var key strings.Builder
+	key.WriteString(measurement)
+	for _, name := range names {
+		fmt.Fprintf(&key, ",%s=%s", name, tags[name])
+	}
+	fmt.Fprintf(&key, " %d", timestamp.UnixNano())
+	return key.String()
+}
+
+func serverURL(config *cluster.ServiceConfig) string {
+	scheme := "http"
+	if config.TLS.Enabled {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s:%d", scheme, config.Host, config.Port)
+}
+
+// token returns the API token of a service, set with the token option
+func token(config *cluster.ServiceConfig) string {
+	if value, ok := config.Options["token"].(string); ok && value != "" {
+		return value
+	}
+	return defaultToken
+}
+
+func org(config *cluster.ServiceConfig) string {
+	if value, ok := config.Options["org"].(string); ok && value != "" {
+		return value
+	}
+	return defaultOrg
+}
+
+// bucket returns the bucket of a service, configured as its database
+func bucket(config *cluster.ServiceConfig) string {
+	if config.Database != "" {
+		return config.Database
+	}
+	return defaultBucket
+}
@@ -0,0 +1,117 @@
+package influxdb
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb-client-go/v2/api/query"
+
+	"github.com/akmadan/throome/pkg/adapters"
+)
+
+func TestBuildFlux(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(time.Hour)
+
+	flux, err := buildFlux("metrics", adapters.RangeQuery{
+		Measurement: "cpu",
+		Start:       start,
+		End:         end,
+		Tags:        map[string]string{"region": "eu", "host": "a"},
+		Fields:      []string{"usage", "idle"},
+		Window:      time.Minute,
+		Aggregate:   "max",
+		Limit:       100,
+	}, time.Now())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	want := `from(bucket: "metrics")
+  |> range(start: 2024-01-01T00:00:00Z, stop: 2024-01-01T01:00:00Z)
+  |> filter(fn: (r) => r._measurement == "cpu")
+  |> filter(fn: (r) => r["host"] == "a")
+  |> filter(fn: (r) => r["region"] == "eu")
+  |> filter(fn: (r) => r._field == "usage" or r._field == "idle")
+  |> aggregateWindow(every: 60000ms, fn: max, createEmpty: false)
+  |> limit(n: 100)`
+	if flux != want {
+		t.Errorf("Unexpected Flux:\n%s\nwant:\n%s", flux, want)
+	}
+}
+
+func TestBuildFluxDefaults(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	flux, err := buildFlux("metrics", adapters.RangeQuery{
+		Measurement: `cpu") |> drop(`,
+		Start:       now.Add(-time.Hour),
+		Window:      time.Minute,
+	}, now)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if !strings.Contains(flux, "stop: 2024-01-01T12:00:00Z") {
+		t.Errorf("Expected the range to stop now, got %s", flux)
+	}
+	if !strings.Contains(flux, "fn: mean") {
+		t.Errorf("Expected windows to default to mean, got %s", flux)
+	}
+	if !strings.Contains(flux, `r._measurement == "cpu\") |> drop("`) {
+		t.Errorf("Expected the measurement to be quoted, got %s", flux)
+	}
+}
+
+func TestBuildFluxErrors(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := map[string]adapters.RangeQuery{
+		"no measurement":        {Start: start},
+		"no start":              {Measurement: "cpu"},
+		"end before start":      {Measurement: "cpu", Start: start, End: start.Add(-time.Second)},
+		"unknown aggregate":     {Measurement: "cpu", Start: start, Window: time.Minute, Aggregate: "drop"},
+		"aggregate sans window": {Measurement: "cpu", Start: start, Aggregate: "max"},
+	}
+
+	for name, rangeQuery := range tests {
+		if _, err := buildFlux("metrics", rangeQuery, start.Add(time.Hour)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestMergeRecords(t *testing.T) {
+	at := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	record := func(field string, value interface{}, host string, timestamp time.Time) *query.FluxRecord {
+		return query.NewFluxRecord(0, map[string]interface{}{
+			"result": "_result", "table": int64(0),
+			"_measurement": "cpu", "_field": field, "_value": value, "_time": timestamp,
+			"host": host,
+		})
+	}
+
+	points := mergeRecords([]*query.FluxRecord{
+		record("usage", 0.5, "a", at.Add(time.Second)),
+		record("usage", 0.7, "a", at),
+		record("idle", 0.3, "a", at),
+		record("usage", 0.9, "b", at),
+	})
+
+	if len(points) != 3 {
+		t.Fatalf("Expected 3 points, got %d", len(points))
+	}
+	if !points[2].Timestamp.Equal(at.Add(time.Second)) {
+		t.Errorf("Expected points sorted by time, got %v last", points[2].Timestamp)
+	}
+
+	for _, point := range points {
+		if point.Tags["host"] == "a" && point.Timestamp.Equal(at) {
+			if point.Fields["usage"] != 0.7 || point.Fields["idle"] != 0.3 {
+				t.Errorf("Expected fields of the same series and time to be merged, got %v", point.Fields)
+			}
+			if _, ok := point.Tags["result"]; ok {
+				t.Errorf("Expected reserved columns to be dropped from tags, got %v", point.Tags)
+			}
+		}
+	}
+}
@@ -0,0 +1,392 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// ErrGroupNotFound is returned for consumer groups the broker does not know
+var ErrGroupNotFound = errors.New("consumer group not found")
+
+// ErrGroupActive is returned when resetting the offsets of a group that has members
+var ErrGroupActive = errors.New("consumer group has active members")
+
+// Offset reset targets
+const (
+	ResetEarliest  = "earliest"
+	ResetLatest    = "latest"
+	ResetTimestamp = "timestamp"
+)
+
+// Group states reported by the broker for groups without members
+const (
+	groupStateEmpty = "Empty"
+	groupStateDead  = "Dead"
+)
+
+// ConsumerGroup summarizes a consumer group
+type ConsumerGroup struct {
+	GroupID string `json:"group_id"`
+	State   string `json:"state"`
+	Members int    `json:"members"`
+}
+
+// GroupMember is a member of a consumer group with the partitions assigned to it
+type GroupMember struct {
+	MemberID    string           `json:"member_id"`
+	ClientID    string           `json:"client_id"`
+	ClientHost  string           `json:"client_host"`
+	Assignments map[string][]int `json:"assignments"` // Partitions by topic
+}
+
+// PartitionLag is how far a consumer group is behind on a partition
+type PartitionLag struct {
+	Topic           string `json:"topic"`
+	Partition       int    `json:"partition"`
+	CommittedOffset int64  `json:"committed_offset"` // -1 when the group never committed
+	LogEndOffset    int64  `json:"log_end_offset"`
+	Lag             int64  `json:"lag"` // -1 when the group never committed
+}
+
+// ConsumerGroupDetail describes a consumer group with its members and lag
+type ConsumerGroupDetail struct {
+	GroupID  string          `json:"group_id"`
+	State    string          `json:"state"`
+	Members  []*GroupMember  `json:"members"`
+	Lag      []*PartitionLag `json:"lag"`
+	TotalLag int64           `json:"total_lag"`
+}
+
+// ListConsumerGroups returns the consumer groups of the broker, sorted by ID
+func (k *KafkaAdapter) ListConsumerGroups(ctx context.Context) ([]*ConsumerGroup, error) {
+	start := time.Now()
+
+	groups, err := k.listConsumerGroups(ctx)
+	duration := time.Since(start)
+	k.RecordRequest(duration, err == nil)
+
+	response := ""
+	if err == nil {
+		response = fmt.Sprintf("%d consumer groups", len(groups))
+	}
+	k.LogActivity(ctx, "LIST_GROUPS", "LIST consumer groups", duration, err, response)
+
+	return groups, err
+}
+
+func (k *KafkaAdapter) listConsumerGroups(ctx context.Context) ([]*ConsumerGroup, error) {
+	client := k.adminClient()
+
+	listed, err := client.ListGroups(ctx, &kafka.ListGroupsRequest{})
+	if err != nil {
+		return nil, err
+	}
+	if listed.Error != nil {
+		return nil, listed.Error
+	}
+
+	groups := make([]*ConsumerGroup, 0, len(listed.Groups))
+	if len(listed.Groups) == 0 {
+		return groups, nil
+	}
+
+	groupIDs := make([]string, 0, len(listed.Groups))
+	for _, group := range listed.Groups {
+		groupIDs = append(groupIDs, group.GroupID)
+	}
+	described, err := client.DescribeGroups(ctx, &kafka.DescribeGroupsRequest{GroupIDs: groupIDs})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, group := range described.Groups {
+		groups = append(groups, &ConsumerGroup{
+			GroupID: group.GroupID,
+			State:   group.GroupState,
+			Members: len(group.Members),
+		})
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].GroupID < groups[j].GroupID })
+	return groups, nil
+}
+
+// DescribeConsumerGroup returns the members of a consumer group and its lag on every
+// partition it is assigned or has committed offsets for
+func (k *KafkaAdapter) DescribeConsumerGroup(ctx context.Context, groupID string) (*ConsumerGroupDetail, error) {
+	start := time.Now()
+	command := fmt.Sprintf("DESCRIBE group '%s'", groupID)
+
+	detail, err := k.describeConsumerGroup(ctx, groupID)
+	duration := time.Since(start)
+	k.RecordRequest(duration, err == nil)
+
+	response := ""
+	if err == nil {
+		response = fmt.Sprintf("Group '%s' is %s with a lag of %d", groupID, detail.State, detail.TotalLag)
+	}
+	k.LogActivity(ctx, "DESCRIBE_GROUP", command, duration, err, response)
+
+	return detail, err
+}
+
+func (k *KafkaAdapter) describeConsumerGroup(ctx context.Context, groupID string) (*ConsumerGroupDetail, error) {
+	client := k.adminClient()
+
+	group, err := k.describeGroup(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	fetched, err := client.OffsetFetch(ctx, &kafka.OffsetFetchRequest{GroupID: groupID})
+	if err != nil {
+		return nil, err
+	}
+	if fetched.Error != nil {
+		return nil, fetched.Error
+	}
+
+	if group.GroupState == groupStateDead && len(fetched.Topics) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrGroupNotFound, groupID)
+	}
+
+	detail := &ConsumerGroupDetail{
+		GroupID: groupID,
+		State:   group.GroupState,
+		Members: make([]*GroupMember, 0, len(group.Members)),
+		Lag:     make([]*PartitionLag, 0),
+	}
+
+	// Partitions with a committed offset, then the assigned ones that have none yet
+	committed := make(map[string]map[int]int64)
+	for topic, partitions := range fetched.Topics {
+		committed[topic] = make(map[int]int64, len(partitions))
+		for _, partition := range partitions {
+			committed[topic][partition.Partition] = partition.CommittedOffset
+		}
+	}
+	for _, member := range group.Members {
+		groupMember := &GroupMember{
+			MemberID:    member.MemberID,
+			ClientID:    member.ClientID,
+			ClientHost:  member.ClientHost,
+			Assignments: make(map[string][]int),
+		}
+		for _, assignment := range member.MemberAssignments.Topics {
+			groupMember.Assignments[assignment.Topic] = assignment.Partitions
+			if committed[assignment.Topic] == nil {
+				committed[assignment.Topic] = make(map[int]int64)
+			}
+			for _, partition := range assignment.Partitions {
+				if _, exists := committed[assignment.Topic][partition]; !exists {
+					committed[assignment.Topic][partition] = -1
+				}
+			}
+		}
+		detail.Members = append(detail.Members, groupMember)
+	}
+
+	if len(committed) == 0 {
+		return detail, nil
+	}
+
+	requests := make(map[string][]kafka.OffsetRequest, len(committed))
+	for topic, partitions := range committed {
+		for partition := range partitions {
+			requests[topic] = append(requests[topic], kafka.LastOffsetOf(partition))
+		}
+	}
+	listed, err := client.ListOffsets(ctx, &kafka.ListOffsetsRequest{Topics: requests})
+	if err != nil {
+		return nil, err
+	}
+
+	for topic, partitions := range listed.Topics {
+		for _, partition := range partitions {
+			if partition.Error != nil {
+				return nil, fmt.Errorf("failed to read end offset of %s/%d: %w", topic, partition.Partition, partition.Error)
+			}
+			detail.Lag = append(detail.Lag, partitionLag(topic, partition.Partition, committed[topic][partition.Partition], partition.LastOffset))
+		}
+	}
+	sort.Slice(detail.Lag, func(i, j int) bool {
+		if detail.Lag[i].Topic != detail.Lag[j].Topic {
+			return detail.Lag[i].Topic < detail.Lag[j].Topic
+		}
+		return detail.Lag[i].Partition < detail.Lag[j].Partition
+	})
+	for _, lag := range detail.Lag {
+		if lag.Lag > 0 {
+			detail.TotalLag += lag.Lag
+		}
+	}
+
+	return detail, nil
+}
+
+// ResetGroupOffsets moves a consumer group's committed offsets on a topic to the
+// earliest or latest retained message, or to the first message produced at or after
+// a timestamp. The gateway's own pull consumers of the group leave it first; other
+// members must be stopped, as the broker only accepts the commit for an empty group.
+// Returns the committed offset per partition.
+func (k *KafkaAdapter) ResetGroupOffsets(ctx context.Context, groupID, topic, to string, at time.Time, partitions []int) (map[int]int64, error) {
+	start := time.Now()
+	command := fmt.Sprintf("RESET OFFSETS of group '%s' on topic '%s' to %s", groupID, topic, to)
+
+	offsets, err := k.resetGroupOffsets(ctx, groupID, topic, to, at, partitions)
+	duration := time.Since(start)
+	k.RecordRequest(duration, err == nil)
+
+	response := ""
+	if err == nil {
+		response = fmt.Sprintf("Offsets reset on %d partitions", len(offsets))
+	}
+	k.LogActivity(ctx, "RESET_OFFSETS", command, duration, err, response)
+
+	return offsets, err
+}
+
+func (k *KafkaAdapter) resetGroupOffsets(ctx context.Context, groupID, topic, to string, at time.Time, requested []int) (map[int]int64, error) {
+	if to != ResetEarliest && to != ResetLatest && to != ResetTimestamp {
+		return nil, fmt.Errorf("unknown reset target %q: use %s, %s or %s", to, ResetEarliest, ResetLatest, ResetTimestamp)
+	}
+
+	k.pulls.mu.Lock()
+	consumer, exists := k.pulls.consumers[pullKey(groupID, topic)]
+	k.pulls.mu.Unlock()
+	if exists {
+		consumer.timer.Stop()
+		k.closePullConsumer(pullKey(groupID, topic), consumer)
+	}
+
+	group, err := k.describeGroup(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+	if group.GroupState != groupStateEmpty && group.GroupState != groupStateDead {
+		return nil, fmt.Errorf("%w: %s is %s with %d members", ErrGroupActive, groupID, group.GroupState, len(group.Members))
+	}
+
+	partitions, err := k.selectPartitions(ctx, topic, requested)
+	if err != nil {
+		return nil, err
+	}
+
+	address := fmt.Sprintf("%s:%d", k.config.Host, k.config.Port)
+	offsets := make(map[int]int64, len(partitions))
+	for _, partition := range partitions {
+		conn, err := kafka.DialLeader(ctx, "tcp", address, topic, partition)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to partition %d: %w", partition, err)
+		}
+		offset, err := resetOffset(conn, to, at)
+		conn.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve offsets of partition %d: %w", partition, err)
+		}
+		offsets[partition] = offset
+	}
+
+	if err := k.commitGroupOffsets(ctx, groupID, topic, offsets); err != nil {
+		return nil, err
+	}
+	return offsets, nil
+}
+
+// resetOffset resolves the offset a reset moves to on the partition the connection
+// is attached to
+func resetOffset(conn *kafka.Conn, to string, at time.Time) (int64, error) {
+	first, last, err := conn.ReadOffsets()
+	if err != nil {
+		return 0, err
+	}
+
+	switch to {
+	case ResetEarliest:
+		return first, nil
+	case ResetLatest:
+		return last, nil
+	default:
+		offset, err := conn.ReadOffset(at)
+		if err != nil {
+			return 0, err
+		}
+		// No message was produced at or after the timestamp
+		if offset < 0 || offset > last {
+			return last, nil
+		}
+		return offset, nil
+	}
+}
+
+// describeGroup returns the broker's description of a consumer group
+func (k *KafkaAdapter) describeGroup(ctx context.Context, groupID string) (*kafka.DescribeGroupsResponseGroup, error) {
+	described, err := k.adminClient().DescribeGroups(ctx, &kafka.DescribeGroupsRequest{GroupIDs: []string{groupID}})
+	if err != nil {
+		return nil, err
+	}
+	if len(described.Groups) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrGroupNotFound, groupID)
+	}
+
+	group := described.Groups[0]
+	if group.Error != nil {
+		return nil, group.Error
+	}
+	return &group, nil
+}
+
+// commitGroupOffsets commits offsets of a topic for a consumer group, outside of any
+// group generation
+func (k *KafkaAdapter) commitGroupOffsets(ctx context.Context, groupID, topic string, offsets map[int]int64) error {
+	commits := make([]kafka.OffsetCommit, 0, len(offsets))
+	for partition, offset := range offsets {
+		commits = append(commits, kafka.OffsetCommit{Partition: partition, Offset: offset})
+	}
+
+	resp, err := k.adminClient().OffsetCommit(ctx, &kafka.OffsetCommitRequest{
+		GroupID:      groupID,
+		GenerationID: -1, // Commit outside of a group generation
+		Topics:       map[string][]kafka.OffsetCommit{topic: commits},
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, partition := range resp.Topics[topic] {
+		if partition.Error != nil {
+			return fmt.Errorf("failed to commit offset for partition %d: %w", partition.Partition, partition.Error)
+		}
+	}
+	return nil
+}
+
+// adminClient returns a client for the broker's group and offset APIs
+func (k *KafkaAdapter) adminClient() *kafka.Client {
+	return &kafka.Client{
+		Addr:    kafka.TCP(fmt.Sprintf("%s:%d", k.config.Host, k.config.Port)),
+		Timeout: 10 * time.Second,
+	}
+}
+
+// partitionLag computes the lag of a group on a partition
+func partitionLag(topic string, partition int, committed, end int64) *PartitionLag {
+	lag := &PartitionLag{
+		Topic:           topic,
+		Partition:       partition,
+		CommittedOffset: committed,
+		LogEndOffset:    end,
+		Lag:             -1,
+	}
+	if committed >= 0 {
+		lag.Lag = end - committed
+		if lag.Lag < 0 {
+			lag.Lag = 0
+		}
+	}
+	return lag
+}
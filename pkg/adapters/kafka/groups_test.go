@@ -0,0 +1,38 @@
+package kafka
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/akmadan/throome/pkg/cluster"
+)
+
+func TestPartitionLag(t *testing.T) {
+	tests := []struct {
+		committed int64
+		end       int64
+		lag       int64
+	}{
+		{10, 42, 32},
+		{42, 42, 0},
+		{50, 42, 0}, // Committed past the end after the topic was recreated
+		{-1, 42, -1},
+	}
+
+	for _, tt := range tests {
+		lag := partitionLag("orders", 0, tt.committed, tt.end)
+		if lag.Lag != tt.lag || lag.CommittedOffset != tt.committed || lag.LogEndOffset != tt.end {
+			t.Errorf("partitionLag(%d, %d) = %+v, expected a lag of %d", tt.committed, tt.end, lag, tt.lag)
+		}
+	}
+}
+
+func TestResetGroupOffsetsRejectsUnknownTarget(t *testing.T) {
+	adapter, _ := NewKafkaAdapter(&cluster.ServiceConfig{Type: "kafka", Host: "localhost", Port: 9092})
+	k := adapter.(*KafkaAdapter)
+
+	if _, err := k.ResetGroupOffsets(context.Background(), "billing", "orders", "yesterday", time.Time{}, nil); err == nil {
+		t.Error("Expected an unknown reset target to be rejected")
+	}
+}
@@ -14,11 +14,12 @@ import (
 // KafkaAdapter implements the QueueAdapter interface for Kafka
 type KafkaAdapter struct {
 	*adapters.BaseAdapter
-	config    *cluster.ServiceConfig
-	writer    *kafka.Writer
-	readers   map[string]*kafka.Reader
-	handlers  map[string]adapters.MessageHandler
-	stopChans map[string]chan struct{}
+	config        *cluster.ServiceConfig
+	writer        *kafka.Writer
+	replayWriter  *kafka.Writer // Partitions by key, for replays
+	subscriptions subscriptionRegistry
+	newReader     func(config kafka.ReaderConfig) messageReader // Readers of subscriptions, replaced in tests
+	pulls         pullRegistry
 }
 
 // NewKafkaAdapter creates a new Kafka adapter
@@ -26,9 +27,9 @@ func NewKafkaAdapter(config *cluster.ServiceConfig) (adapters.Adapter, error) {
 	adapter := &KafkaAdapter{
 		BaseAdapter: adapters.NewBaseAdapter(config),
 		config:      config,
-		readers:     make(map[string]*kafka.Reader),
-		handlers:    make(map[string]adapters.MessageHandler),
-		stopChans:   make(map[string]chan struct{}),
+		newReader: func(config kafka.ReaderConfig) messageReader {
+			return kafka.NewReader(config)
+		},
 	}
 	return adapter, nil
 }
@@ -45,6 +46,15 @@ func (k *KafkaAdapter) Connect(ctx context.Context) error {
 		MaxAttempts:  3,
 	}
 
+	// Replays hash keys to partitions, so the replayed messages of a key land on one
+	// partition in their original order
+	k.replayWriter = &kafka.Writer{
+		Addr:         kafka.TCP(brokers...),
+		Balancer:     &kafka.Hash{},
+		BatchTimeout: 10 * time.Millisecond,
+		MaxAttempts:  3,
+	}
+
 	// Test connection by listing topics
 	conn, err := kafka.Dial("tcp", fmt.Sprintf("%s:%d", k.config.Host, k.config.Port))
 	if err != nil {
@@ -58,19 +68,15 @@ func (k *KafkaAdapter) Connect(ctx context.Context) error {
 
 // Disconnect closes all Kafka connections
 func (k *KafkaAdapter) Disconnect(ctx context.Context) error {
-	// Stop all consumers
-	for topic, stopChan := range k.stopChans {
-		close(stopChan)
-		delete(k.stopChans, topic)
-	}
+	k.closeSubscriptions()
+	k.closePullConsumers()
 
-	// Close all readers
-	for topic, reader := range k.readers {
-		_ = reader.Close() // Ignore errors during cleanup
-		delete(k.readers, topic)
+	// Close writers
+	if k.replayWriter != nil {
+		if err := k.replayWriter.Close(); err != nil {
+			return err
+		}
 	}
-
-	// Close writer
 	if k.writer != nil {
 		if err := k.writer.Close(); err != nil {
 			return err
@@ -90,13 +96,13 @@ func (k *KafkaAdapter) Ping(ctx context.Context) error {
 
 	if err != nil {
 		k.RecordRequest(duration, false)
-		k.LogActivity("PING", "PING", duration, err, "")
+		k.LogActivity(ctx, "PING", "PING", duration, err, "")
 		return err
 	}
 	defer conn.Close()
 
 	k.RecordRequest(duration, true)
-	k.LogActivity("PING", "PING", duration, nil, "PONG")
+	k.LogActivity(ctx, "PING", "PING", duration, nil, "PONG")
 	return nil
 }
 
@@ -108,6 +114,7 @@ func (k *KafkaAdapter) HealthCheck(ctx context.Context) (*adapters.HealthStatus,
 
 	status := &adapters.HealthStatus{
 		Healthy:      err == nil,
+		Reachable:    err == nil,
 		ResponseTime: responseTime,
 		LastChecked:  time.Now(),
 	}
@@ -138,7 +145,7 @@ func (k *KafkaAdapter) Publish(ctx context.Context, topic string, message []byte
 	if err == nil {
 		response = fmt.Sprintf("Message published successfully to topic '%s'", topic)
 	}
-	k.LogActivity("PUBLISH", command, duration, err, response)
+	k.LogActivity(ctx, "PUBLISH", command, duration, err, response)
 
 	return err
 }
@@ -163,121 +170,48 @@ func (k *KafkaAdapter) PublishWithKey(ctx context.Context, topic string, key, me
 	if err == nil {
 		response = fmt.Sprintf("Message published successfully to topic '%s' with key", topic)
 	}
-	k.LogActivity("PUBLISH_WITH_KEY", command, duration, err, response)
+	k.LogActivity(ctx, "PUBLISH_WITH_KEY", command, duration, err, response)
 
 	return err
 }
 
-// Subscribe subscribes to a topic
-func (k *KafkaAdapter) Subscribe(ctx context.Context, topic string, handler adapters.MessageHandler) error {
+// PublishBatch publishes several messages to a topic in a single write
+func (k *KafkaAdapter) PublishBatch(ctx context.Context, topic string, messages [][]byte) error {
 	start := time.Now()
 
-	if _, exists := k.readers[topic]; exists {
-		err := fmt.Errorf("already subscribed to topic: %s", topic)
-		k.LogActivity("SUBSCRIBE", fmt.Sprintf("SUBSCRIBE to topic '%s'", topic), time.Since(start), err, "")
-		return err
+	batch := make([]kafka.Message, 0, len(messages))
+	for _, message := range messages {
+		batch = append(batch, kafka.Message{
+			Topic: topic,
+			Value: message,
+			Time:  time.Now(),
+		})
 	}
 
-	brokers := []string{fmt.Sprintf("%s:%d", k.config.Host, k.config.Port)}
-
-	// Create a reader for this topic
-	reader := kafka.NewReader(kafka.ReaderConfig{
-		Brokers:        brokers,
-		Topic:          topic,
-		GroupID:        "throome-gateway", // Default group ID
-		MinBytes:       10e3,              // 10KB
-		MaxBytes:       10e6,              // 10MB
-		CommitInterval: time.Second,
-	})
-
-	k.readers[topic] = reader
-	k.handlers[topic] = handler
-
-	// Start consuming messages in a goroutine
-	stopChan := make(chan struct{})
-	k.stopChans[topic] = stopChan
-
-	go k.consumeMessages(ctx, topic, reader, handler, stopChan)
+	err := k.writer.WriteMessages(ctx, batch...)
 
 	duration := time.Since(start)
-	command := fmt.Sprintf("SUBSCRIBE to topic '%s' with group 'throome-gateway'", topic)
-	response := fmt.Sprintf("Successfully subscribed to topic '%s'", topic)
-	k.LogActivity("SUBSCRIBE", command, duration, nil, response)
-
-	return nil
-}
-
-// consumeMessages consumes messages from a topic
-func (k *KafkaAdapter) consumeMessages(ctx context.Context, topic string, reader *kafka.Reader, handler adapters.MessageHandler, stopChan chan struct{}) {
-	for {
-		select {
-		case <-stopChan:
-			return
-		default:
-			msg, err := reader.ReadMessage(ctx)
-			if err != nil {
-				// Handle error (log it)
-				continue
-			}
-
-			// Convert to our Message type
-			message := &adapters.Message{
-				Topic:     msg.Topic,
-				Key:       msg.Key,
-				Value:     msg.Value,
-				Timestamp: msg.Time,
-				Offset:    msg.Offset,
-				Headers:   make(map[string]string),
-			}
-
-			// Copy headers
-			for _, header := range msg.Headers {
-				message.Headers[header.Key] = string(header.Value)
-			}
-
-			// Call handler, ignore errors to continue processing
-			_ = handler(ctx, message)
-		}
-	}
-}
-
-// Unsubscribe unsubscribes from a topic
-func (k *KafkaAdapter) Unsubscribe(ctx context.Context, topic string) error {
-	start := time.Now()
-
-	// Stop the consumer
-	if stopChan, exists := k.stopChans[topic]; exists {
-		close(stopChan)
-		delete(k.stopChans, topic)
-	}
+	k.RecordRequest(duration, err == nil)
 
-	// Close the reader
-	if reader, exists := k.readers[topic]; exists {
-		if err := reader.Close(); err != nil {
-			k.LogActivity("UNSUBSCRIBE", fmt.Sprintf("UNSUBSCRIBE from topic '%s'", topic), time.Since(start), err, "")
-			return err
-		}
-		delete(k.readers, topic)
+	// Log activity
+	command := fmt.Sprintf("PUBLISH %d messages to topic '%s'", len(messages), topic)
+	response := ""
+	if err == nil {
+		response = fmt.Sprintf("%d messages published successfully to topic '%s'", len(messages), topic)
 	}
+	k.LogActivity(ctx, "PUBLISH_BATCH", command, duration, err, response)
 
-	// Remove handler
-	delete(k.handlers, topic)
-
-	duration := time.Since(start)
-	command := fmt.Sprintf("UNSUBSCRIBE from topic '%s'", topic)
-	response := fmt.Sprintf("Successfully unsubscribed from topic '%s'", topic)
-	k.LogActivity("UNSUBSCRIBE", command, duration, nil, response)
-
-	return nil
+	return err
 }
 
-// CreateTopic creates a new topic
+// CreateTopic creates a new topic, with one partition and one replica unless
+// num_partitions and replication_factor are set
 func (k *KafkaAdapter) CreateTopic(ctx context.Context, topic string, config map[string]interface{}) error {
 	start := time.Now()
 
 	conn, err := kafka.DialContext(ctx, "tcp", fmt.Sprintf("%s:%d", k.config.Host, k.config.Port))
 	if err != nil {
-		k.LogActivity("CREATE_TOPIC", fmt.Sprintf("CREATE TOPIC '%s'", topic), time.Since(start), err, "")
+		k.LogActivity(ctx, "CREATE_TOPIC", fmt.Sprintf("CREATE TOPIC '%s'", topic), time.Since(start), err, "")
 		return err
 	}
 	defer conn.Close()
@@ -285,10 +219,10 @@ func (k *KafkaAdapter) CreateTopic(ctx context.Context, topic string, config map
 	numPartitions := 1
 	replicationFactor := 1
 
-	if np, ok := config["num_partitions"].(int); ok {
+	if np, ok := config["num_partitions"].(int); ok && np > 0 {
 		numPartitions = np
 	}
-	if rf, ok := config["replication_factor"].(int); ok {
+	if rf, ok := config["replication_factor"].(int); ok && rf > 0 {
 		replicationFactor = rf
 	}
 
@@ -307,7 +241,7 @@ func (k *KafkaAdapter) CreateTopic(ctx context.Context, topic string, config map
 	if err == nil {
 		response = fmt.Sprintf("Topic '%s' created successfully", topic)
 	}
-	k.LogActivity("CREATE_TOPIC", command, duration, err, response)
+	k.LogActivity(ctx, "CREATE_TOPIC", command, duration, err, response)
 
 	return err
 }
@@ -318,7 +252,7 @@ func (k *KafkaAdapter) DeleteTopic(ctx context.Context, topic string) error {
 
 	conn, err := kafka.DialContext(ctx, "tcp", fmt.Sprintf("%s:%d", k.config.Host, k.config.Port))
 	if err != nil {
-		k.LogActivity("DELETE_TOPIC", fmt.Sprintf("DELETE TOPIC '%s'", topic), time.Since(start), err, "")
+		k.LogActivity(ctx, "DELETE_TOPIC", fmt.Sprintf("DELETE TOPIC '%s'", topic), time.Since(start), err, "")
 		return err
 	}
 	defer conn.Close()
@@ -332,7 +266,7 @@ func (k *KafkaAdapter) DeleteTopic(ctx context.Context, topic string) error {
 	if err == nil {
 		response = fmt.Sprintf("Topic '%s' deleted successfully", topic)
 	}
-	k.LogActivity("DELETE_TOPIC", command, duration, err, response)
+	k.LogActivity(ctx, "DELETE_TOPIC", command, duration, err, response)
 
 	return err
 }
@@ -343,14 +277,14 @@ func (k *KafkaAdapter) ListTopics(ctx context.Context) ([]string, error) {
 
 	conn, err := kafka.DialContext(ctx, "tcp", fmt.Sprintf("%s:%d", k.config.Host, k.config.Port))
 	if err != nil {
-		k.LogActivity("LIST_TOPICS", "LIST TOPICS", time.Since(start), err, "")
+		k.LogActivity(ctx, "LIST_TOPICS", "LIST TOPICS", time.Since(start), err, "")
 		return nil, err
 	}
 	defer conn.Close()
 
 	partitions, err := conn.ReadPartitions()
 	if err != nil {
-		k.LogActivity("LIST_TOPICS", "LIST TOPICS", time.Since(start), err, "")
+		k.LogActivity(ctx, "LIST_TOPICS", "LIST TOPICS", time.Since(start), err, "")
 		return nil, err
 	}
 
@@ -369,7 +303,7 @@ func (k *KafkaAdapter) ListTopics(ctx context.Context) ([]string, error) {
 	duration := time.Since(start)
 	command := "LIST TOPICS"
 	response := fmt.Sprintf("Found %d topics", len(topics))
-	k.LogActivity("LIST_TOPICS", command, duration, nil, response)
+	k.LogActivity(ctx, "LIST_TOPICS", command, duration, nil, response)
 
 	return topics, nil
 }
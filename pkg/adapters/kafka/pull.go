@@ -0,0 +1,349 @@
+package kafka
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+
+	"github.com/akmadan/throome/pkg/adapters"
+)
+
+// DefaultGroupID is the consumer group of gateway-managed consumers that name none
+const DefaultGroupID = "throome-gateway"
+
+// fetchedWait is how long a pull waits for each message after the first
+const fetchedWait = 50 * time.Millisecond
+
+// pullIdleTimeout is how long a pull consumer stays in its group without a pull or
+// commit. Its uncommitted messages are delivered again to the group once it leaves.
+const pullIdleTimeout = 5 * time.Minute
+
+// PullOptions configures a pull of messages from a topic
+type PullOptions struct {
+	GroupID           string        // Consumer group, DefaultGroupID when empty
+	MaxMessages       int           // Messages to return at most
+	Wait              time.Duration // How long to wait for the first new message
+	VisibilityTimeout time.Duration // How long pulled messages are hidden from other pulls
+}
+
+// Lease is a pulled message, hidden from other pulls of its group until it is
+// committed with its receipt or its visibility timeout passes
+type Lease struct {
+	Receipt      string
+	Message      *adapters.Message
+	Deliveries   int // How often the message has been pulled
+	VisibleUntil time.Time
+}
+
+// pulledMessage is a message a pull consumer fetched but has not committed yet
+type pulledMessage struct {
+	msg          kafka.Message
+	receipt      string // Receipt of the current lease
+	visibleUntil time.Time
+	deliveries   int
+	settled      bool
+}
+
+// pullConsumer reads a topic as a member of a consumer group on behalf of pulls.
+// Offsets are only committed up to the first unsettled message of each partition,
+// so a message is never committed before it is settled.
+type pullConsumer struct {
+	reader     *kafka.Reader
+	partitions map[int][]*pulledMessage // By partition, in offset order
+	receipts   map[string]*pulledMessage
+	timer      *time.Timer // Closes the consumer once it has been idle
+	fetchMu    sync.Mutex  // Held while fetching, so concurrent pulls do not interleave
+	mu         sync.Mutex
+}
+
+// pullRegistry holds the pull consumers of an adapter, by group and topic
+type pullRegistry struct {
+	consumers map[string]*pullConsumer
+	mu        sync.Mutex
+}
+
+// Pull returns up to opts.MaxMessages messages of a topic for the consumer group.
+// Messages whose visibility timeout passed without a commit are returned again before
+// new ones are fetched; new ones are waited for up to opts.Wait. The first pull of a
+// group may return nothing while the group is being joined.
+func (k *KafkaAdapter) Pull(ctx context.Context, topic string, opts PullOptions) ([]*Lease, error) {
+	start := time.Now()
+	groupID := pullGroupID(opts.GroupID)
+	command := fmt.Sprintf("PULL %d messages from topic '%s' with group '%s'", opts.MaxMessages, topic, groupID)
+
+	consumer := k.pullConsumer(groupID, topic)
+	leases, err := consumer.pull(ctx, opts)
+	duration := time.Since(start)
+	k.RecordRequest(duration, err == nil)
+
+	response := ""
+	if err == nil {
+		response = fmt.Sprintf("Pulled %d messages from topic '%s'", len(leases), topic)
+	}
+	k.LogActivity(ctx, "PULL", command, duration, err, response)
+
+	return leases, err
+}
+
+// CommitPulled settles pulled messages by their receipts and commits the group's
+// offsets past them. Receipts of leases that expired, were pulled again or were
+// already committed are returned as unknown.
+func (k *KafkaAdapter) CommitPulled(ctx context.Context, topic, groupID string, receipts []string) (int, []string, error) {
+	start := time.Now()
+	groupID = pullGroupID(groupID)
+	command := fmt.Sprintf("COMMIT %d messages of topic '%s' with group '%s'", len(receipts), topic, groupID)
+
+	k.pulls.mu.Lock()
+	consumer, exists := k.pulls.consumers[pullKey(groupID, topic)]
+	k.pulls.mu.Unlock()
+
+	settled, unknown := 0, receipts
+	var err error
+	if exists {
+		settled, unknown, err = consumer.commit(ctx, receipts)
+	}
+	duration := time.Since(start)
+	k.RecordRequest(duration, err == nil)
+
+	response := ""
+	if err == nil {
+		response = fmt.Sprintf("Committed %d messages of topic '%s'", settled, topic)
+	}
+	k.LogActivity(ctx, "COMMIT", command, duration, err, response)
+
+	return settled, unknown, err
+}
+
+// pullConsumer returns the pull consumer of a group on a topic, joining the group
+// when there is none
+func (k *KafkaAdapter) pullConsumer(groupID, topic string) *pullConsumer {
+	k.pulls.mu.Lock()
+	defer k.pulls.mu.Unlock()
+
+	key := pullKey(groupID, topic)
+	if consumer, exists := k.pulls.consumers[key]; exists {
+		consumer.touch()
+		return consumer
+	}
+
+	consumer := &pullConsumer{
+		reader: kafka.NewReader(kafka.ReaderConfig{
+			Brokers:  []string{fmt.Sprintf("%s:%d", k.config.Host, k.config.Port)},
+			Topic:    topic,
+			GroupID:  groupID,
+			MinBytes: 1, // Return what is there rather than waiting for a full batch
+			MaxBytes: 10e6,
+			MaxWait:  500 * time.Millisecond,
+		}),
+		partitions: make(map[int][]*pulledMessage),
+		receipts:   make(map[string]*pulledMessage),
+	}
+	consumer.timer = time.AfterFunc(pullIdleTimeout, func() { k.closePullConsumer(key, consumer) })
+
+	if k.pulls.consumers == nil {
+		k.pulls.consumers = make(map[string]*pullConsumer)
+	}
+	k.pulls.consumers[key] = consumer
+	return consumer
+}
+
+// closePullConsumer leaves the group of an idle pull consumer
+func (k *KafkaAdapter) closePullConsumer(key string, consumer *pullConsumer) {
+	k.pulls.mu.Lock()
+	if k.pulls.consumers[key] == consumer {
+		delete(k.pulls.consumers, key)
+	}
+	k.pulls.mu.Unlock()
+
+	_ = consumer.reader.Close()
+}
+
+// closePullConsumers closes every pull consumer
+func (k *KafkaAdapter) closePullConsumers() {
+	k.pulls.mu.Lock()
+	consumers := k.pulls.consumers
+	k.pulls.consumers = nil
+	k.pulls.mu.Unlock()
+
+	for _, consumer := range consumers {
+		consumer.timer.Stop()
+		_ = consumer.reader.Close()
+	}
+}
+
+// pull leases expired messages again, then fetches new ones
+func (c *pullConsumer) pull(ctx context.Context, opts PullOptions) ([]*Lease, error) {
+	c.fetchMu.Lock()
+	defer c.fetchMu.Unlock()
+
+	leases, err := c.leaseExpired(opts)
+	if err != nil || len(leases) >= opts.MaxMessages {
+		return leases, err
+	}
+
+	// Wait for the first new message, then only as long as more keep coming
+	wait := opts.Wait
+	if wait < fetchedWait {
+		wait = fetchedWait
+	}
+	for len(leases) < opts.MaxMessages {
+		msg, err := fetchWithin(ctx, c.reader, wait)
+		if err != nil {
+			if errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil {
+				break // Waited long enough
+			}
+			return leases, err
+		}
+
+		lease, err := c.lease(msg, opts.VisibilityTimeout)
+		if err != nil {
+			return leases, err
+		}
+		leases = append(leases, lease)
+		wait = fetchedWait
+	}
+
+	c.touch()
+	return leases, nil
+}
+
+// fetchWithin fetches the next message of a reader, waiting for it at most wait
+func fetchWithin(ctx context.Context, reader *kafka.Reader, wait time.Duration) (kafka.Message, error) {
+	ctx, cancel := context.WithTimeout(ctx, wait)
+	defer cancel()
+	return reader.FetchMessage(ctx)
+}
+
+// leaseExpired leases messages whose visibility timeout passed again
+func (c *pullConsumer) leaseExpired(opts PullOptions) ([]*Lease, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	leases := make([]*Lease, 0)
+	for _, pulled := range c.partitions {
+		for _, message := range pulled {
+			if len(leases) >= opts.MaxMessages {
+				return leases, nil
+			}
+			if message.settled || now.Before(message.visibleUntil) {
+				continue
+			}
+
+			delete(c.receipts, message.receipt)
+			lease, err := c.renew(message, opts.VisibilityTimeout)
+			if err != nil {
+				return leases, err
+			}
+			leases = append(leases, lease)
+		}
+	}
+	return leases, nil
+}
+
+// lease records a newly fetched message and leases it
+func (c *pullConsumer) lease(msg kafka.Message, timeout time.Duration) (*Lease, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	message := &pulledMessage{msg: msg}
+	c.partitions[msg.Partition] = append(c.partitions[msg.Partition], message)
+	return c.renew(message, timeout)
+}
+
+// renew gives a message a new receipt and visibility deadline. The caller holds c.mu.
+func (c *pullConsumer) renew(message *pulledMessage, timeout time.Duration) (*Lease, error) {
+	receipt, err := receiptHandle()
+	if err != nil {
+		return nil, err
+	}
+
+	message.receipt = receipt
+	message.visibleUntil = time.Now().Add(timeout)
+	message.deliveries++
+	c.receipts[receipt] = message
+
+	return &Lease{
+		Receipt:      receipt,
+		Message:      toMessage(message.msg),
+		Deliveries:   message.deliveries,
+		VisibleUntil: message.visibleUntil,
+	}, nil
+}
+
+// commit settles messages by their receipts and commits each partition up to its
+// first unsettled message
+func (c *pullConsumer) commit(ctx context.Context, receipts []string) (int, []string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	settled := 0
+	unknown := make([]string, 0)
+	for _, receipt := range receipts {
+		message, exists := c.receipts[receipt]
+		if !exists {
+			unknown = append(unknown, receipt)
+			continue
+		}
+		delete(c.receipts, receipt)
+		message.settled = true
+		settled++
+	}
+
+	commits := make([]kafka.Message, 0, len(c.partitions))
+	for partition, pulled := range c.partitions {
+		n := 0
+		for n < len(pulled) && pulled[n].settled {
+			n++
+		}
+		if n == 0 {
+			continue
+		}
+		commits = append(commits, pulled[n-1].msg)
+		c.partitions[partition] = pulled[n:]
+	}
+
+	if len(commits) > 0 {
+		if err := c.reader.CommitMessages(ctx, commits...); err != nil {
+			return settled, unknown, err
+		}
+	}
+
+	c.touch()
+	return settled, unknown, nil
+}
+
+// touch restarts the idle timer of the consumer
+func (c *pullConsumer) touch() {
+	if c.timer != nil {
+		c.timer.Reset(pullIdleTimeout)
+	}
+}
+
+// pullGroupID returns the consumer group of a pull
+func pullGroupID(groupID string) string {
+	if groupID == "" {
+		return DefaultGroupID
+	}
+	return groupID
+}
+
+// pullKey identifies the pull consumer of a group on a topic
+func pullKey(groupID, topic string) string {
+	return groupID + "/" + topic
+}
+
+// receiptHandle returns a random receipt, so leases cannot be settled by guessing
+func receiptHandle() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate receipt: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
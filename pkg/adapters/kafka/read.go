@@ -0,0 +1,209 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+
+	"github.com/akmadan/throome/pkg/adapters"
+)
+
+// ReadOptions selects a range of messages to read from a topic
+type ReadOptions struct {
+	Partitions  []int     // Partitions to read, all when empty
+	StartOffset int64     // First offset to read (inclusive), from the beginning when zero
+	EndOffset   int64     // Offset to stop at (exclusive), up to the latest message when zero
+	Since       time.Time // Only messages produced at or after this time
+	Until       time.Time // Only messages produced before this time
+	Limit       int       // Maximum number of messages, unlimited when zero
+}
+
+// ReadMessages reads a range of messages from a topic without joining a consumer group,
+// so committed offsets are left untouched
+func (k *KafkaAdapter) ReadMessages(ctx context.Context, topic string, opts ReadOptions) ([]*adapters.Message, error) {
+	start := time.Now()
+	command := fmt.Sprintf("READ from topic '%s'", topic)
+
+	messages, err := k.readMessages(ctx, topic, opts)
+	duration := time.Since(start)
+	k.RecordRequest(duration, err == nil)
+
+	response := ""
+	if err == nil {
+		response = fmt.Sprintf("Read %d messages from topic '%s'", len(messages), topic)
+	}
+	k.LogActivity(ctx, "READ", command, duration, err, response)
+
+	return messages, err
+}
+
+func (k *KafkaAdapter) readMessages(ctx context.Context, topic string, opts ReadOptions) ([]*adapters.Message, error) {
+	address := fmt.Sprintf("%s:%d", k.config.Host, k.config.Port)
+
+	partitions, err := k.selectPartitions(ctx, topic, opts.Partitions)
+	if err != nil {
+		return nil, err
+	}
+
+	messages := make([]*adapters.Message, 0)
+	for _, partition := range partitions {
+		remaining := 0
+		if opts.Limit > 0 {
+			remaining = opts.Limit - len(messages)
+			if remaining <= 0 {
+				break
+			}
+		}
+
+		read, err := readPartition(ctx, address, topic, partition, opts, remaining)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read partition %d: %w", partition, err)
+		}
+		messages = append(messages, read...)
+	}
+
+	return messages, nil
+}
+
+// selectPartitions returns the requested partitions, or all partitions of the topic
+// when none are requested
+func (k *KafkaAdapter) selectPartitions(ctx context.Context, topic string, requested []int) ([]int, error) {
+	if len(requested) > 0 {
+		return requested, nil
+	}
+
+	conn, err := kafka.DialContext(ctx, "tcp", fmt.Sprintf("%s:%d", k.config.Host, k.config.Port))
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	infos, err := conn.ReadPartitions(topic)
+	if err != nil {
+		return nil, err
+	}
+
+	partitions := make([]int, 0, len(infos))
+	for _, info := range infos {
+		partitions = append(partitions, info.ID)
+	}
+	return partitions, nil
+}
+
+// readPartition reads up to limit messages (unlimited when zero) from a single partition
+func readPartition(ctx context.Context, address, topic string, partition int, opts ReadOptions, limit int) ([]*adapters.Message, error) {
+	conn, err := kafka.DialLeader(ctx, "tcp", address, topic, partition)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	offset, end, err := partitionRange(conn, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	messages := make([]*adapters.Message, 0)
+	if offset >= end {
+		return messages, nil
+	}
+
+	if _, err := conn.Seek(offset, kafka.SeekAbsolute); err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(10 * time.Second)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	if err := conn.SetReadDeadline(deadline); err != nil {
+		return nil, err
+	}
+
+	for offset < end {
+		batch := conn.ReadBatch(1, 10e6)
+		for {
+			msg, err := batch.ReadMessage()
+			if err != nil {
+				batch.Close()
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				return nil, err
+			}
+
+			offset = msg.Offset + 1
+			if msg.Offset >= end || (!opts.Until.IsZero() && !msg.Time.Before(opts.Until)) {
+				batch.Close()
+				return messages, nil
+			}
+
+			messages = append(messages, toMessage(msg))
+			if limit > 0 && len(messages) >= limit {
+				batch.Close()
+				return messages, nil
+			}
+		}
+	}
+
+	return messages, nil
+}
+
+// partitionRange resolves the offsets [start, end) selected by the options on the
+// partition the connection is attached to
+func partitionRange(conn *kafka.Conn, opts ReadOptions) (int64, int64, error) {
+	first, last, err := conn.ReadOffsets()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	sinceOffset := int64(-1)
+	if !opts.Since.IsZero() {
+		sinceOffset, err = conn.ReadOffset(opts.Since)
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+
+	start, end := offsetRange(first, last, sinceOffset, opts)
+	return start, end, nil
+}
+
+// offsetRange narrows the offsets [first, last) of a partition to those selected by
+// the options. sinceOffset is the first offset produced at or after opts.Since, or -1
+// without one.
+func offsetRange(first, last, sinceOffset int64, opts ReadOptions) (int64, int64) {
+	start := max(first, opts.StartOffset, sinceOffset)
+
+	end := last
+	if opts.EndOffset > 0 && opts.EndOffset < end {
+		end = opts.EndOffset
+	}
+	return start, end
+}
+
+// toMessage converts a kafka-go message to the adapter message type
+func toMessage(msg kafka.Message) *adapters.Message {
+	message := &adapters.Message{
+		Topic:     msg.Topic,
+		Key:       msg.Key,
+		Value:     msg.Value,
+		Timestamp: msg.Time,
+		Partition: msg.Partition,
+		Offset:    msg.Offset,
+		Headers:   make(map[string]string),
+	}
+	for _, header := range msg.Headers {
+		message.Headers[header.Key] = string(header.Value)
+	}
+	return message
+}
+
+// Partitions returns the partition IDs of a topic
+func (k *KafkaAdapter) Partitions(ctx context.Context, topic string) ([]int, error) {
+	return k.selectPartitions(ctx, topic, nil)
+}
@@ -0,0 +1,35 @@
+package kafka
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOffsetRange(t *testing.T) {
+	since := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		sinceOffset int64
+		opts        ReadOptions
+		start       int64
+		end         int64
+	}{
+		{"whole partition", -1, ReadOptions{}, 100, 200},
+		{"start and end offsets", -1, ReadOptions{StartOffset: 120, EndOffset: 150}, 120, 150},
+		{"start before the first offset", -1, ReadOptions{StartOffset: 50}, 100, 200},
+		{"end past the last offset", -1, ReadOptions{EndOffset: 500}, 100, 200},
+		{"since after the start offset", 170, ReadOptions{Since: since, StartOffset: 120}, 170, 200},
+		{"since before the start offset", 110, ReadOptions{Since: since, StartOffset: 120}, 120, 200},
+		{"nothing produced since", 200, ReadOptions{Since: since}, 200, 200},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end := offsetRange(100, 200, tt.sinceOffset, tt.opts)
+			if start != tt.start || end != tt.end {
+				t.Errorf("Expected [%d, %d), got [%d, %d)", tt.start, tt.end, start, end)
+			}
+		})
+	}
+}
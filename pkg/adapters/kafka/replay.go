@@ -0,0 +1,121 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+
+	"github.com/akmadan/throome/pkg/adapters"
+)
+
+// Headers added to replayed messages so consumers can tell them apart from new events
+const (
+	ReplayedFromTopicHeader     = "x-throome-replayed-from-topic"
+	ReplayedFromPartitionHeader = "x-throome-replayed-from-partition"
+	ReplayedFromOffsetHeader    = "x-throome-replayed-from-offset"
+)
+
+// Replay republishes previously read messages to a destination topic, which may be
+// the topic they were read from. Keys and headers are preserved and keys are hashed to
+// partitions, so messages with a key keep their relative order; messages without one
+// are spread across partitions. The origin of each message is recorded in replay
+// headers.
+func (k *KafkaAdapter) Replay(ctx context.Context, destination string, messages []*adapters.Message) (int, error) {
+	start := time.Now()
+
+	batch := make([]kafka.Message, 0, len(messages))
+	for _, message := range messages {
+		headers := make([]kafka.Header, 0, len(message.Headers)+3)
+		for key, value := range message.Headers {
+			headers = append(headers, kafka.Header{Key: key, Value: []byte(value)})
+		}
+		headers = append(headers,
+			kafka.Header{Key: ReplayedFromTopicHeader, Value: []byte(message.Topic)},
+			kafka.Header{Key: ReplayedFromPartitionHeader, Value: []byte(strconv.Itoa(message.Partition))},
+			kafka.Header{Key: ReplayedFromOffsetHeader, Value: []byte(strconv.FormatInt(message.Offset, 10))},
+		)
+
+		batch = append(batch, kafka.Message{
+			Topic:   destination,
+			Key:     message.Key,
+			Value:   message.Value,
+			Headers: headers,
+			Time:    time.Now(),
+		})
+	}
+
+	var err error
+	if len(batch) > 0 {
+		err = k.replayWriter.WriteMessages(ctx, batch...)
+	}
+
+	duration := time.Since(start)
+	k.RecordRequest(duration, err == nil)
+
+	// Log activity
+	command := fmt.Sprintf("REPLAY %d messages to topic '%s'", len(batch), destination)
+	response := ""
+	if err == nil {
+		response = fmt.Sprintf("%d messages replayed to topic '%s'", len(batch), destination)
+	}
+	k.LogActivity(ctx, "REPLAY", command, duration, err, response)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return len(batch), nil
+}
+
+// ResetConsumerGroup moves a consumer group's committed offsets on a topic back to the
+// start of the selected range, so the group's consumers reprocess the messages after it.
+// The group must have no active members, otherwise the broker rejects the commit.
+// Returns the committed offset per partition.
+func (k *KafkaAdapter) ResetConsumerGroup(ctx context.Context, groupID, topic string, opts ReadOptions) (map[int]int64, error) {
+	start := time.Now()
+	command := fmt.Sprintf("RESET OFFSETS of group '%s' on topic '%s'", groupID, topic)
+
+	offsets, err := k.resetConsumerGroup(ctx, groupID, topic, opts)
+	duration := time.Since(start)
+	k.RecordRequest(duration, err == nil)
+
+	response := ""
+	if err == nil {
+		response = fmt.Sprintf("Offsets reset on %d partitions", len(offsets))
+	}
+	k.LogActivity(ctx, "RESET_OFFSETS", command, duration, err, response)
+
+	return offsets, err
+}
+
+func (k *KafkaAdapter) resetConsumerGroup(ctx context.Context, groupID, topic string, opts ReadOptions) (map[int]int64, error) {
+	address := fmt.Sprintf("%s:%d", k.config.Host, k.config.Port)
+
+	partitions, err := k.selectPartitions(ctx, topic, opts.Partitions)
+	if err != nil {
+		return nil, err
+	}
+
+	offsets := make(map[int]int64, len(partitions))
+	for _, partition := range partitions {
+		conn, err := kafka.DialLeader(ctx, "tcp", address, topic, partition)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to partition %d: %w", partition, err)
+		}
+		offset, _, err := partitionRange(conn, opts)
+		conn.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve offsets of partition %d: %w", partition, err)
+		}
+
+		offsets[partition] = offset
+	}
+
+	if err := k.commitGroupOffsets(ctx, groupID, topic, offsets); err != nil {
+		return nil, err
+	}
+	return offsets, nil
+}
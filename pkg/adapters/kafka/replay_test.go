@@ -0,0 +1,21 @@
+package kafka
+
+import (
+	"context"
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+
+	"github.com/akmadan/throome/pkg/cluster"
+)
+
+func TestReplayWriterPartitionsByKey(t *testing.T) {
+	adapter, _ := NewKafkaAdapter(&cluster.ServiceConfig{Type: "kafka", Host: "127.0.0.1", Port: 1})
+	k := adapter.(*KafkaAdapter)
+
+	// The writers are set up before the broker is reached
+	_ = k.Connect(context.Background())
+	if _, ok := k.replayWriter.Balancer.(*kafka.Hash); !ok {
+		t.Errorf("Expected replays to hash keys to partitions, got %T", k.replayWriter.Balancer)
+	}
+}
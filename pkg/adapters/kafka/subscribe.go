@@ -0,0 +1,224 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/segmentio/kafka-go"
+
+	"github.com/akmadan/throome/pkg/adapters"
+)
+
+// Delays before a message whose handler failed is delivered again, doubled after each
+// failure
+const (
+	redeliveryBackoff    = 100 * time.Millisecond
+	maxRedeliveryBackoff = 10 * time.Second
+)
+
+// messageReader reads a topic as a member of a consumer group
+type messageReader interface {
+	FetchMessage(ctx context.Context) (kafka.Message, error)
+	CommitMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
+// subscription is a member of a consumer group that passes the messages of its
+// partitions to a handler
+type subscription struct {
+	topic  string
+	group  string
+	reader messageReader
+	stop   chan struct{}
+}
+
+// subscriptionRegistry holds the subscriptions of an adapter
+type subscriptionRegistry struct {
+	subscriptions map[string]*subscription // By ID
+	byTopic       map[string]string        // IDs of the subscriptions made with Subscribe, by topic
+	mu            sync.Mutex
+}
+
+// Subscribe subscribes to a topic as a member of the default consumer group. A topic
+// can be subscribed to once this way; use SubscribeGroup for more members.
+func (k *KafkaAdapter) Subscribe(ctx context.Context, topic string, handler adapters.MessageHandler) error {
+	start := time.Now()
+
+	k.subscriptions.mu.Lock()
+	defer k.subscriptions.mu.Unlock()
+
+	if _, exists := k.subscriptions.byTopic[topic]; exists {
+		err := fmt.Errorf("already subscribed to topic: %s", topic)
+		k.LogActivity(ctx, "SUBSCRIBE", fmt.Sprintf("SUBSCRIBE to topic '%s'", topic), time.Since(start), err, "")
+		return err
+	}
+
+	id := k.subscribeLocked(ctx, topic, DefaultGroupID, handler)
+	if k.subscriptions.byTopic == nil {
+		k.subscriptions.byTopic = make(map[string]string)
+	}
+	k.subscriptions.byTopic[topic] = id
+	return nil
+}
+
+// SubscribeGroup subscribes to a topic as a new member of a consumer group, which
+// shares the topic's partitions with the group's other members and resumes from its
+// commits. It returns the ID that ends the subscription with UnsubscribeGroup.
+func (k *KafkaAdapter) SubscribeGroup(ctx context.Context, topic, group string, handler adapters.MessageHandler) (string, error) {
+	if group == "" {
+		group = DefaultGroupID
+	}
+
+	k.subscriptions.mu.Lock()
+	defer k.subscriptions.mu.Unlock()
+	return k.subscribeLocked(ctx, topic, group, handler), nil
+}
+
+// subscribeLocked starts a subscription and returns its ID. The caller holds
+// k.subscriptions.mu.
+func (k *KafkaAdapter) subscribeLocked(ctx context.Context, topic, group string, handler adapters.MessageHandler) string {
+	start := time.Now()
+	brokers := []string{fmt.Sprintf("%s:%d", k.config.Host, k.config.Port)}
+
+	// Each subscription reads with a reader of its own, a member of the group, so the
+	// group balances the topic's partitions across subscribers
+	sub := &subscription{
+		topic: topic,
+		group: group,
+		reader: k.newReader(kafka.ReaderConfig{
+			Brokers:        brokers,
+			Topic:          topic,
+			GroupID:        group,
+			MinBytes:       10e3, // 10KB
+			MaxBytes:       10e6, // 10MB
+			CommitInterval: time.Second,
+		}),
+		stop: make(chan struct{}),
+	}
+
+	id := uuid.New().String()
+	if k.subscriptions.subscriptions == nil {
+		k.subscriptions.subscriptions = make(map[string]*subscription)
+	}
+	k.subscriptions.subscriptions[id] = sub
+
+	go k.consume(ctx, sub, handler)
+
+	duration := time.Since(start)
+	command := fmt.Sprintf("SUBSCRIBE to topic '%s' with group '%s'", topic, group)
+	response := fmt.Sprintf("Successfully subscribed to topic '%s'", topic)
+	k.LogActivity(ctx, "SUBSCRIBE", command, duration, nil, response)
+
+	return id
+}
+
+// consume passes the messages of a subscription to its handler. A message's offset is
+// only committed once the handler succeeds, and a message whose handler failed is
+// delivered again before any later message is fetched, so a later commit never skips
+// it. Messages left uncommitted when the subscription ends are delivered to the
+// group's remaining members.
+func (k *KafkaAdapter) consume(ctx context.Context, sub *subscription, handler adapters.MessageHandler) {
+	for {
+		msg, err := sub.reader.FetchMessage(ctx)
+		if err != nil {
+			// The reader fails once it is closed; wait before retrying other failures
+			if !sub.wait(ctx, redeliveryBackoff) {
+				return
+			}
+			continue
+		}
+
+		if !sub.deliver(ctx, msg, handler) {
+			return
+		}
+		_ = sub.reader.CommitMessages(ctx, msg) //nolint:errcheck // uncommitted messages are delivered again
+	}
+}
+
+// deliver passes a message to the handler until it succeeds, backing off between
+// attempts. It returns false if the subscription ended first.
+func (s *subscription) deliver(ctx context.Context, msg kafka.Message, handler adapters.MessageHandler) bool {
+	backoff := redeliveryBackoff
+	for {
+		if err := handler(ctx, toMessage(msg)); err == nil {
+			return true
+		}
+		if !s.wait(ctx, backoff) {
+			return false
+		}
+		backoff = min(backoff*2, maxRedeliveryBackoff)
+	}
+}
+
+// wait sleeps for d, returning false if the subscription ends or ctx is done first
+func (s *subscription) wait(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-s.stop:
+		return false
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// Unsubscribe ends the subscription to a topic made with Subscribe
+func (k *KafkaAdapter) Unsubscribe(ctx context.Context, topic string) error {
+	k.subscriptions.mu.Lock()
+	id, exists := k.subscriptions.byTopic[topic]
+	delete(k.subscriptions.byTopic, topic)
+	k.subscriptions.mu.Unlock()
+
+	if !exists {
+		return nil
+	}
+	return k.UnsubscribeGroup(ctx, id)
+}
+
+// UnsubscribeGroup ends a subscription made with SubscribeGroup, leaving its consumer
+// group. Ending an unknown subscription does nothing.
+func (k *KafkaAdapter) UnsubscribeGroup(ctx context.Context, id string) error {
+	start := time.Now()
+
+	k.subscriptions.mu.Lock()
+	sub, exists := k.subscriptions.subscriptions[id]
+	delete(k.subscriptions.subscriptions, id)
+	k.subscriptions.mu.Unlock()
+
+	if !exists {
+		return nil
+	}
+
+	command := fmt.Sprintf("UNSUBSCRIBE from topic '%s' with group '%s'", sub.topic, sub.group)
+	close(sub.stop)
+	if err := sub.reader.Close(); err != nil {
+		k.LogActivity(ctx, "UNSUBSCRIBE", command, time.Since(start), err, "")
+		return err
+	}
+
+	duration := time.Since(start)
+	response := fmt.Sprintf("Successfully unsubscribed from topic '%s'", sub.topic)
+	k.LogActivity(ctx, "UNSUBSCRIBE", command, duration, nil, response)
+
+	return nil
+}
+
+// closeSubscriptions ends every subscription
+func (k *KafkaAdapter) closeSubscriptions() {
+	k.subscriptions.mu.Lock()
+	subscriptions := k.subscriptions.subscriptions
+	k.subscriptions.subscriptions = nil
+	k.subscriptions.byTopic = nil
+	k.subscriptions.mu.Unlock()
+
+	for _, sub := range subscriptions {
+		close(sub.stop)
+		_ = sub.reader.Close() // Ignore errors during cleanup
+	}
+}
@@ -0,0 +1,203 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+
+	"github.com/akmadan/throome/pkg/adapters"
+	"github.com/akmadan/throome/pkg/cluster"
+)
+
+// fakeReader hands out queued messages and records commits
+type fakeReader struct {
+	messages  chan kafka.Message
+	closed    chan struct{}
+	closeOnce sync.Once
+	mu        sync.Mutex
+	committed []int64
+}
+
+func newFakeReader() *fakeReader {
+	return &fakeReader{messages: make(chan kafka.Message, 10), closed: make(chan struct{})}
+}
+
+func (r *fakeReader) FetchMessage(ctx context.Context) (kafka.Message, error) {
+	select {
+	case msg := <-r.messages:
+		return msg, nil
+	case <-r.closed:
+		return kafka.Message{}, io.EOF
+	case <-ctx.Done():
+		return kafka.Message{}, ctx.Err()
+	}
+}
+
+func (r *fakeReader) CommitMessages(ctx context.Context, msgs ...kafka.Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, msg := range msgs {
+		r.committed = append(r.committed, msg.Offset)
+	}
+	return nil
+}
+
+func (r *fakeReader) Close() error {
+	r.closeOnce.Do(func() { close(r.closed) })
+	return nil
+}
+
+func (r *fakeReader) commits() []int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]int64{}, r.committed...)
+}
+
+// newSubscribeAdapter returns an adapter whose subscriptions read with fake readers
+func newSubscribeAdapter(t *testing.T) (*KafkaAdapter, chan *fakeReader) {
+	adapter, err := NewKafkaAdapter(&cluster.ServiceConfig{Type: "kafka", Host: "localhost", Port: 9092})
+	if err != nil {
+		t.Fatalf("Failed to create adapter: %v", err)
+	}
+	k := adapter.(*KafkaAdapter)
+
+	readers := make(chan *fakeReader, 100)
+	k.newReader = func(config kafka.ReaderConfig) messageReader {
+		reader := newFakeReader()
+		readers <- reader
+		return reader
+	}
+	t.Cleanup(k.closeSubscriptions)
+	return k, readers
+}
+
+func TestSubscriptionRedeliversFailedMessages(t *testing.T) {
+	k, readers := newSubscribeAdapter(t)
+
+	var mu sync.Mutex
+	var delivered []int64
+	failed := false
+	handler := func(ctx context.Context, message *adapters.Message) error {
+		mu.Lock()
+		defer mu.Unlock()
+		delivered = append(delivered, message.Offset)
+		if message.Offset == 1 && !failed {
+			failed = true
+			return errors.New("rejected by subscriber")
+		}
+		return nil
+	}
+
+	if _, err := k.SubscribeGroup(context.Background(), "orders", "billing", handler); err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+	reader := <-readers
+	reader.messages <- kafka.Message{Topic: "orders", Offset: 1}
+	reader.messages <- kafka.Message{Topic: "orders", Offset: 2}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(reader.commits()) < 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected both messages to be committed, got %v", reader.commits())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// The nacked message is delivered again before the next one, so the commit of the
+	// next one cannot skip it
+	mu.Lock()
+	defer mu.Unlock()
+	if len(delivered) != 3 || delivered[0] != 1 || delivered[1] != 1 || delivered[2] != 2 {
+		t.Errorf("Expected deliveries [1 1 2], got %v", delivered)
+	}
+	if commits := reader.commits(); commits[0] != 1 || commits[1] != 2 {
+		t.Errorf("Expected commits [1 2], got %v", commits)
+	}
+}
+
+func TestSubscribeGroupMembers(t *testing.T) {
+	k, readers := newSubscribeAdapter(t)
+	handler := func(ctx context.Context, message *adapters.Message) error { return nil }
+	ctx := context.Background()
+
+	// Subscribers of the same group are members of their own
+	first, err := k.SubscribeGroup(ctx, "orders", "billing", handler)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+	second, err := k.SubscribeGroup(ctx, "orders", "billing", handler)
+	if err != nil {
+		t.Fatalf("Expected a second member of the group to subscribe, got %v", err)
+	}
+	if first == second {
+		t.Fatalf("Expected members to have distinct IDs, got %s twice", first)
+	}
+	firstReader, secondReader := <-readers, <-readers
+
+	if err := k.UnsubscribeGroup(ctx, first); err != nil {
+		t.Fatalf("Failed to unsubscribe: %v", err)
+	}
+	select {
+	case <-firstReader.closed:
+	default:
+		t.Error("Expected the reader of the ended member to be closed")
+	}
+	select {
+	case <-secondReader.closed:
+		t.Error("Expected the other member to keep reading")
+	default:
+	}
+
+	// Unknown and ended subscriptions are ignored
+	if err := k.UnsubscribeGroup(ctx, first); err != nil {
+		t.Errorf("Expected ending an ended subscription to succeed, got %v", err)
+	}
+
+	// Subscribe still subscribes to a topic once
+	if err := k.Subscribe(ctx, "orders", handler); err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+	if err := k.Subscribe(ctx, "orders", handler); err == nil {
+		t.Error("Expected a second Subscribe to the topic to fail")
+	}
+	if err := k.Unsubscribe(ctx, "orders"); err != nil {
+		t.Fatalf("Failed to unsubscribe: %v", err)
+	}
+	if err := k.Subscribe(ctx, "orders", handler); err != nil {
+		t.Errorf("Expected Subscribe to succeed after Unsubscribe, got %v", err)
+	}
+}
+
+func TestSubscribeGroupConcurrently(t *testing.T) {
+	k, _ := newSubscribeAdapter(t)
+	handler := func(ctx context.Context, message *adapters.Message) error { return nil }
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			id, err := k.SubscribeGroup(ctx, "orders", "billing", handler)
+			if err != nil {
+				t.Errorf("Failed to subscribe: %v", err)
+				return
+			}
+			if err := k.UnsubscribeGroup(ctx, id); err != nil {
+				t.Errorf("Failed to unsubscribe: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	k.subscriptions.mu.Lock()
+	defer k.subscriptions.mu.Unlock()
+	if len(k.subscriptions.subscriptions) != 0 {
+		t.Errorf("Expected every subscription to have ended, got %d", len(k.subscriptions.subscriptions))
+	}
+}
@@ -0,0 +1,130 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// ErrTopicNotFound is returned for topics the broker does not know
+var ErrTopicNotFound = errors.New("topic not found")
+
+// TopicDetail describes a topic and its partitions
+type TopicDetail struct {
+	Topic             string             `json:"topic"`
+	PartitionCount    int                `json:"partition_count"`
+	ReplicationFactor int                `json:"replication_factor"`
+	Messages          int64              `json:"messages"` // Approximate, summed over partitions
+	Partitions        []*PartitionDetail `json:"partitions"`
+}
+
+// PartitionDetail describes a partition of a topic. Messages is the distance between
+// its watermarks, which overcounts on compacted topics and with transaction markers.
+type PartitionDetail struct {
+	Partition     int   `json:"partition"`
+	Leader        int   `json:"leader"`
+	Replicas      []int `json:"replicas"`
+	ISR           []int `json:"isr"` // In-sync replicas
+	LowWatermark  int64 `json:"low_watermark"`
+	HighWatermark int64 `json:"high_watermark"`
+	Messages      int64 `json:"messages"`
+}
+
+// DescribeTopic returns the partitions of a topic with their replicas and watermarks
+func (k *KafkaAdapter) DescribeTopic(ctx context.Context, topic string) (*TopicDetail, error) {
+	start := time.Now()
+	command := fmt.Sprintf("DESCRIBE TOPIC '%s'", topic)
+
+	detail, err := k.describeTopic(ctx, topic)
+	duration := time.Since(start)
+	k.RecordRequest(duration, err == nil)
+
+	response := ""
+	if err == nil {
+		response = fmt.Sprintf("Topic '%s' has %d partitions and about %d messages", topic, detail.PartitionCount, detail.Messages)
+	}
+	k.LogActivity(ctx, "DESCRIBE_TOPIC", command, duration, err, response)
+
+	return detail, err
+}
+
+func (k *KafkaAdapter) describeTopic(ctx context.Context, topic string) (*TopicDetail, error) {
+	conn, err := kafka.DialContext(ctx, "tcp", fmt.Sprintf("%s:%d", k.config.Host, k.config.Port))
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	partitions, err := conn.ReadPartitions(topic)
+	if err != nil {
+		if errors.Is(err, kafka.UnknownTopicOrPartition) {
+			return nil, fmt.Errorf("%w: %s", ErrTopicNotFound, topic)
+		}
+		return nil, err
+	}
+	if len(partitions) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrTopicNotFound, topic)
+	}
+
+	requests := make([]kafka.OffsetRequest, 0, 2*len(partitions))
+	for _, partition := range partitions {
+		requests = append(requests, kafka.FirstOffsetOf(partition.ID), kafka.LastOffsetOf(partition.ID))
+	}
+	listed, err := k.adminClient().ListOffsets(ctx, &kafka.ListOffsetsRequest{
+		Topics: map[string][]kafka.OffsetRequest{topic: requests},
+	})
+	if err != nil {
+		return nil, err
+	}
+	watermarks := make(map[int]kafka.PartitionOffsets, len(partitions))
+	for _, offsets := range listed.Topics[topic] {
+		if offsets.Error != nil {
+			return nil, fmt.Errorf("failed to read watermarks of partition %d: %w", offsets.Partition, offsets.Error)
+		}
+		watermarks[offsets.Partition] = offsets
+	}
+
+	detail := &TopicDetail{
+		Topic:          topic,
+		PartitionCount: len(partitions),
+		Partitions:     make([]*PartitionDetail, 0, len(partitions)),
+	}
+	for _, partition := range partitions {
+		offsets := watermarks[partition.ID]
+		partitionDetail := &PartitionDetail{
+			Partition:     partition.ID,
+			Leader:        partition.Leader.ID,
+			Replicas:      brokerIDs(partition.Replicas),
+			ISR:           brokerIDs(partition.Isr),
+			LowWatermark:  offsets.FirstOffset,
+			HighWatermark: offsets.LastOffset,
+		}
+		if partitionDetail.HighWatermark > partitionDetail.LowWatermark {
+			partitionDetail.Messages = partitionDetail.HighWatermark - partitionDetail.LowWatermark
+		}
+
+		detail.Messages += partitionDetail.Messages
+		if len(partition.Replicas) > detail.ReplicationFactor {
+			detail.ReplicationFactor = len(partition.Replicas)
+		}
+		detail.Partitions = append(detail.Partitions, partitionDetail)
+	}
+	sort.Slice(detail.Partitions, func(i, j int) bool {
+		return detail.Partitions[i].Partition < detail.Partitions[j].Partition
+	})
+
+	return detail, nil
+}
+
+// brokerIDs returns the IDs of brokers
+func brokerIDs(brokers []kafka.Broker) []int {
+	ids := make([]int, 0, len(brokers))
+	for _, broker := range brokers {
+		ids = append(ids, broker.ID)
+	}
+	return ids
+}
@@ -0,0 +1,63 @@
+package adapters
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Bounds of the latency window of an adapter
+const (
+	latencyWindowAge  = time.Minute // Age of the oldest latency kept
+	latencyWindowSize = 512         // Latencies kept, the oldest are dropped first
+)
+
+// latencySample is the latency of a request and when it completed
+type latencySample struct {
+	at      time.Time
+	latency time.Duration
+}
+
+// latencyWindow keeps the latencies of an adapter's recent requests
+type latencyWindow struct {
+	samples [latencyWindowSize]latencySample
+	next    int // Index the next sample is written to
+	count   int
+	mu      sync.Mutex
+}
+
+// add adds the latency of a request that just completed
+func (w *latencyWindow) add(latency time.Duration) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.samples[w.next] = latencySample{at: time.Now(), latency: latency}
+	w.next = (w.next + 1) % latencyWindowSize
+	if w.count < latencyWindowSize {
+		w.count++
+	}
+}
+
+// percentile returns the latency under which the given percentage of the requests of
+// the window completed, and false when the window holds no request
+func (w *latencyWindow) percentile(percent float64) (time.Duration, bool) {
+	w.mu.Lock()
+	cutoff := time.Now().Add(-latencyWindowAge)
+	latencies := make([]time.Duration, 0, w.count)
+	for i := 0; i < w.count; i++ {
+		if sample := w.samples[i]; sample.at.After(cutoff) {
+			latencies = append(latencies, sample.latency)
+		}
+	}
+	w.mu.Unlock()
+
+	if len(latencies) == 0 {
+		return 0, false
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+
+	index := int(math.Ceil(float64(len(latencies))*percent/100)) - 1
+	index = min(max(index, 0), len(latencies)-1)
+	return latencies[index], true
+}
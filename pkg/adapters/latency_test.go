@@ -0,0 +1,40 @@
+package adapters
+
+import (
+	"testing"
+	"time"
+
+	"github.com/akmadan/throome/pkg/cluster"
+)
+
+func TestRecentLatency(t *testing.T) {
+	adapter := NewBaseAdapter(&cluster.ServiceConfig{Type: "redis"})
+	if _, ok := adapter.RecentLatency(95); ok {
+		t.Fatal("Expected no latency before any request")
+	}
+
+	for i := 1; i <= 100; i++ {
+		adapter.RecordRequest(time.Duration(i)*time.Millisecond, true)
+	}
+	if p95, _ := adapter.RecentLatency(95); p95 != 95*time.Millisecond {
+		t.Errorf("Expected a p95 of 95ms, got %v", p95)
+	}
+
+	// Old requests leave the window
+	adapter.latencies.mu.Lock()
+	for i := 0; i < 90; i++ {
+		adapter.latencies.samples[i].at = time.Now().Add(-2 * latencyWindowAge)
+	}
+	adapter.latencies.mu.Unlock()
+	if p95, _ := adapter.RecentLatency(95); p95 != 100*time.Millisecond {
+		t.Errorf("Expected the p95 of the recent requests, got %v", p95)
+	}
+
+	// The oldest requests are dropped once the window is full
+	for i := 0; i < latencyWindowSize; i++ {
+		adapter.RecordRequest(time.Millisecond, true)
+	}
+	if p95, _ := adapter.RecentLatency(95); p95 != time.Millisecond {
+		t.Errorf("Expected the slow requests to be dropped, got %v", p95)
+	}
+}
@@ -0,0 +1,124 @@
+package adapters
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Layout of the window of recent requests of an adapter
+const (
+	MetricsWindow      = 5 * time.Minute // Age of the oldest request in the success rate and average latency
+	metricsBucketWidth = time.Second     // Time each bucket of the window covers
+	metricsBuckets     = int64(MetricsWindow / metricsBucketWidth)
+)
+
+// adapterMetrics are the request metrics of an adapter. Lifetime counters are atomic
+// and recent requests are counted in a sliding window, so adapters can record their
+// requests from many goroutines at once.
+type adapterMetrics struct {
+	total       atomic.Int64
+	failed      atomic.Int64
+	minLatency  atomic.Int64 // Nanoseconds, zero before the first request
+	maxLatency  atomic.Int64
+	lastRequest atomic.Int64 // Unix nanoseconds, zero before the first request
+	window      requestWindow
+}
+
+// record adds a request that completed at a time
+func (m *adapterMetrics) record(at time.Time, latency time.Duration, success bool) {
+	m.total.Add(1)
+	if !success {
+		m.failed.Add(1)
+	}
+	m.lastRequest.Store(at.UnixNano())
+
+	for {
+		current := m.minLatency.Load()
+		if current != 0 && current <= int64(latency) || m.minLatency.CompareAndSwap(current, int64(latency)) {
+			break
+		}
+	}
+	for {
+		current := m.maxLatency.Load()
+		if current >= int64(latency) || m.maxLatency.CompareAndSwap(current, int64(latency)) {
+			break
+		}
+	}
+
+	m.window.add(at, latency, success)
+}
+
+// snapshot returns the metrics as of a time. The success rate and average latency
+// are those of the window, so they follow the current load.
+func (m *adapterMetrics) snapshot(now time.Time) *Metrics {
+	metrics := &Metrics{
+		TotalRequests:  m.total.Load(),
+		FailedRequests: m.failed.Load(),
+		SuccessRate:    100,
+		MinLatency:     time.Duration(m.minLatency.Load()),
+		MaxLatency:     time.Duration(m.maxLatency.Load()),
+	}
+	if last := m.lastRequest.Load(); last != 0 {
+		metrics.LastRequestTime = time.Unix(0, last)
+	}
+
+	requests, failed, latency := m.window.totals(now)
+	metrics.WindowRequests = requests
+	metrics.WindowFailedRequests = failed
+	if requests > 0 {
+		metrics.SuccessRate = float64(requests-failed) / float64(requests) * 100
+		metrics.AverageLatency = latency / time.Duration(requests)
+	}
+	return metrics
+}
+
+// metricsBucket counts the requests of one second of the window
+type metricsBucket struct {
+	second   int64 // Unix second the bucket counts, older buckets are stale
+	requests int64
+	failed   int64
+	latency  time.Duration // Sum of the latencies
+}
+
+// requestWindow counts the requests of the last MetricsWindow in a ring of buckets
+type requestWindow struct {
+	mu      sync.Mutex
+	buckets [metricsBuckets]metricsBucket
+}
+
+// add counts a request that completed at a time
+func (w *requestWindow) add(at time.Time, latency time.Duration, success bool) {
+	second := at.Unix()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	bucket := &w.buckets[second%metricsBuckets]
+	if bucket.second != second {
+		*bucket = metricsBucket{second: second}
+	}
+	bucket.requests++
+	if !success {
+		bucket.failed++
+	}
+	bucket.latency += latency
+}
+
+// totals returns the requests, failures and summed latencies of the window ending at
+// a time
+func (w *requestWindow) totals(now time.Time) (requests, failed int64, latency time.Duration) {
+	oldest := now.Unix() - metricsBuckets + 1
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for i := range w.buckets {
+		if bucket := &w.buckets[i]; bucket.second >= oldest && bucket.second <= now.Unix() {
+			requests += bucket.requests
+			failed += bucket.failed
+			latency += bucket.latency
+		}
+	}
+	return requests, failed, latency
+}
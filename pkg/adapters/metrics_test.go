@@ -0,0 +1,67 @@
+package adapters
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/akmadan/throome/pkg/cluster"
+)
+
+func TestMetricsConcurrent(t *testing.T) {
+	adapter := NewBaseAdapter(&cluster.ServiceConfig{Type: "redis"})
+	if metrics := adapter.GetMetrics(); metrics.SuccessRate != 100 || !metrics.LastRequestTime.IsZero() {
+		t.Fatalf("Expected full success before any request, got %+v", metrics)
+	}
+
+	var wg sync.WaitGroup
+	for worker := 0; worker < 8; worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 1; i <= 100; i++ {
+				adapter.RecordRequest(time.Duration(i)*time.Millisecond, i%4 != 0)
+			}
+		}()
+	}
+	wg.Wait()
+
+	metrics := adapter.GetMetrics()
+	if metrics.TotalRequests != 800 || metrics.FailedRequests != 200 || metrics.WindowRequests != 800 {
+		t.Errorf("Expected 800 requests with 200 failures, got %+v", metrics)
+	}
+	if metrics.SuccessRate != 75 {
+		t.Errorf("Expected a 75%% success rate, got %v", metrics.SuccessRate)
+	}
+	if metrics.MinLatency != time.Millisecond || metrics.MaxLatency != 100*time.Millisecond {
+		t.Errorf("Expected latencies from 1ms to 100ms, got %v to %v", metrics.MinLatency, metrics.MaxLatency)
+	}
+	if metrics.AverageLatency != 50500*time.Microsecond {
+		t.Errorf("Expected a 50.5ms average, got %v", metrics.AverageLatency)
+	}
+}
+
+func TestMetricsWindow(t *testing.T) {
+	var metrics adapterMetrics
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	// A slow failing burst, then fast successes once it has left the window
+	for i := 0; i < 10; i++ {
+		metrics.record(start, time.Second, false)
+	}
+	later := start.Add(MetricsWindow + time.Minute)
+	metrics.record(later, 10*time.Millisecond, true)
+	metrics.record(later.Add(time.Second), 30*time.Millisecond, true)
+
+	snapshot := metrics.snapshot(later.Add(time.Second))
+	if snapshot.TotalRequests != 12 || snapshot.FailedRequests != 10 {
+		t.Errorf("Expected the lifetime counts of every request, got %+v", snapshot)
+	}
+	if snapshot.WindowRequests != 2 || snapshot.SuccessRate != 100 || snapshot.AverageLatency != 20*time.Millisecond {
+		t.Errorf("Expected only the recent requests in the window, got %+v", snapshot)
+	}
+
+	if snapshot := metrics.snapshot(later.Add(2 * MetricsWindow)); snapshot.WindowRequests != 0 || snapshot.AverageLatency != 0 || snapshot.SuccessRate != 100 {
+		t.Errorf("Expected an empty window once idle, got %+v", snapshot)
+	}
+}
@@ -0,0 +1,318 @@
+package minio
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+
+	"github.com/akmadan/throome/pkg/adapters"
+	"github.com/akmadan/throome/pkg/cluster"
+)
+
+// Default root credentials of the official MinIO image
+const (
+	defaultAccessKey = "minioadmin"
+	defaultSecretKey = "minioadmin"
+)
+
+// MinIOAdapter implements the ObjectStoreAdapter interface for MinIO and S3
+type MinIOAdapter struct {
+	*adapters.BaseAdapter
+	config *cluster.ServiceConfig
+	client *minio.Client
+}
+
+// NewMinIOAdapter creates a new MinIO/S3 adapter
+func NewMinIOAdapter(config *cluster.ServiceConfig) (adapters.Adapter, error) {
+	adapter := &MinIOAdapter{
+		BaseAdapter: adapters.NewBaseAdapter(config),
+		config:      config,
+	}
+	return adapter, nil
+}
+
+// Connect creates the S3 client and verifies the endpoint is reachable
+func (m *MinIOAdapter) Connect(ctx context.Context) error {
+	accessKey, secretKey := credentialsFor(m.config)
+	client, err := minio.New(endpoint(m.config), &minio.Options{
+		Creds:  credentials.NewStaticV4(accessKey, secretKey, ""),
+		Secure: m.config.TLS.Enabled,
+		Region: region(m.config),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create MinIO client: %w", err)
+	}
+
+	m.client = client
+
+	// Test connection
+	if err := m.Ping(ctx); err != nil {
+		return fmt.Errorf("failed to connect to MinIO: %w", err)
+	}
+
+	m.SetConnected(true)
+	return nil
+}
+
+// Disconnect releases the client. S3 is stateless HTTP, so there is nothing to close.
+func (m *MinIOAdapter) Disconnect(ctx context.Context) error {
+	m.SetConnected(false)
+	return nil
+}
+
+// Ping checks if the object store is reachable and the credentials are valid
+func (m *MinIOAdapter) Ping(ctx context.Context) error {
+	start := time.Now()
+	_, err := m.client.ListBuckets(ctx)
+	m.RecordRequest(time.Since(start), err == nil)
+	return err
+}
+
+// HealthCheck performs a health check
+func (m *MinIOAdapter) HealthCheck(ctx context.Context) (*adapters.HealthStatus, error) {
+	start := time.Now()
+	err := m.Ping(ctx)
+	responseTime := time.Since(start)
+
+	status := &adapters.HealthStatus{
+		Healthy:      err == nil,
+		Reachable:    err == nil,
+		ResponseTime: responseTime,
+		LastChecked:  time.Now(),
+	}
+
+	if err != nil {
+		status.ErrorMessage = err.Error()
+	}
+
+	return status, nil
+}
+
+// PutObject uploads an object, creating the bucket if it does not exist
+func (m *MinIOAdapter) PutObject(ctx context.Context, bucket, key string, data io.Reader, size int64, contentType string) (*adapters.ObjectInfo, error) {
+	start := time.Now()
+	info, err := m.putObject(ctx, bucket, key, data, size, contentType)
+	duration := time.Since(start)
+	m.RecordRequest(duration, err == nil)
+
+	// Log activity
+	response := ""
+	if err == nil {
+		response = fmt.Sprintf("%d bytes stored", info.Size)
+	}
+	m.LogActivity(ctx, "PUT_OBJECT", fmt.Sprintf("PUT s3://%s/%s", bucket, key), duration, err, response)
+
+	return info, err
+}
+
+func (m *MinIOAdapter) putObject(ctx context.Context, bucket, key string, data io.Reader, size int64, contentType string) (*adapters.ObjectInfo, error) {
+	if err := m.ensureBucket(ctx, bucket); err != nil {
+		return nil, err
+	}
+
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	upload, err := m.client.PutObject(ctx, bucket, key, data, size, minio.PutObjectOptions{ContentType: contentType})
+	if err != nil {
+		return nil, err
+	}
+
+	lastModified := upload.LastModified
+	if lastModified.IsZero() {
+		lastModified = time.Now()
+	}
+	return &adapters.ObjectInfo{
+		Bucket:       bucket,
+		Key:          key,
+		Size:         upload.Size,
+		ContentType:  contentType,
+		ETag:         upload.ETag,
+		LastModified: lastModified,
+	}, nil
+}
+
+// ensureBucket creates a bucket unless it already exists
+func (m *MinIOAdapter) ensureBucket(ctx context.Context, bucket string) error {
+	exists, err := m.client.BucketExists(ctx, bucket)
+	if err != nil {
+		return fmt.Errorf("failed to check bucket %s: %w", bucket, err)
+	}
+	if exists {
+		return nil
+	}
+
+	err = m.client.MakeBucket(ctx, bucket, minio.MakeBucketOptions{Region: region(m.config)})
+	if err != nil {
+		// Another writer may have created it in the meantime
+		code := minio.ToErrorResponse(err).Code
+		if code == "BucketAlreadyOwnedByYou" || code == "BucketAlreadyExists" {
+			return nil
+		}
+		return fmt.Errorf("failed to create bucket %s: %w", bucket, err)
+	}
+	return nil
+}
+
+// GetObject downloads an object. The caller must close the returned reader.
+func (m *MinIOAdapter) GetObject(ctx context.Context, bucket, key string) (io.ReadCloser, *adapters.ObjectInfo, error) {
+	start := time.Now()
+	object, info, err := m.getObject(ctx, bucket, key)
+	duration := time.Since(start)
+	m.RecordRequest(duration, err == nil)
+
+	// Log activity
+	response := ""
+	if err == nil {
+		response = fmt.Sprintf("%d bytes", info.Size)
+	}
+	m.LogActivity(ctx, "GET_OBJECT", fmt.Sprintf("GET s3://%s/%s", bucket, key), duration, err, response)
+
+	return object, info, err
+}
+
+func (m *MinIOAdapter) getObject(ctx context.Context, bucket, key string) (io.ReadCloser, *adapters.ObjectInfo, error) {
+	object, err := m.client.GetObject(ctx, bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, nil, translateError(err)
+	}
+
+	// GetObject is lazy; Stat issues the request and surfaces missing objects
+	stat, err := object.Stat()
+	if err != nil {
+		_ = object.Close()
+		return nil, nil, translateError(err)
+	}
+
+	return object, toObjectInfo(bucket, stat), nil
+}
+
+// ListObjects lists the objects of a bucket whose keys start with prefix
+func (m *MinIOAdapter) ListObjects(ctx context.Context, bucket, prefix string, limit int) ([]*adapters.ObjectInfo, error) {
+	start := time.Now()
+	objects, err := m.listObjects(ctx, bucket, prefix, limit)
+	duration := time.Since(start)
+	m.RecordRequest(duration, err == nil)
+
+	// Log activity
+	response := ""
+	if err == nil {
+		response = fmt.Sprintf("%d objects", len(objects))
+	}
+	m.LogActivity(ctx, "LIST_OBJECTS", fmt.Sprintf("LIST s3://%s/%s", bucket, prefix), duration, err, response)
+
+	return objects, err
+}
+
+func (m *MinIOAdapter) listObjects(ctx context.Context, bucket, prefix string, limit int) ([]*adapters.ObjectInfo, error) {
+	// Cancelling the context stops the listing goroutine once the limit is reached
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	objects := make([]*adapters.ObjectInfo, 0)
+	for object := range m.client.ListObjects(ctx, bucket, minio.ListObjectsOptions{Prefix: prefix, Recursive: true}) {
+		if object.Err != nil {
+			return nil, translateError(object.Err)
+		}
+		objects = append(objects, toObjectInfo(bucket, object))
+		if limit > 0 && len(objects) >= limit {
+			break
+		}
+	}
+	return objects, nil
+}
+
+// Presign returns a URL that grants GET or PUT access to an object until it expires
+func (m *MinIOAdapter) Presign(ctx context.Context, method, bucket, key string, expiry time.Duration) (string, error) {
+	start := time.Now()
+	presigned, err := m.presign(ctx, method, bucket, key, expiry)
+	duration := time.Since(start)
+	m.RecordRequest(duration, err == nil)
+
+	// Log activity
+	response := ""
+	if err == nil {
+		response = fmt.Sprintf("expires in %s", expiry)
+	}
+	m.LogActivity(ctx, "PRESIGN", fmt.Sprintf("PRESIGN %s s3://%s/%s", strings.ToUpper(method), bucket, key), duration, err, response)
+
+	return presigned, err
+}
+
+func (m *MinIOAdapter) presign(ctx context.Context, method, bucket, key string, expiry time.Duration) (string, error) {
+	switch strings.ToUpper(method) {
+	case http.MethodGet:
+		u, err := m.client.PresignedGetObject(ctx, bucket, key, expiry, nil)
+		if err != nil {
+			return "", err
+		}
+		return u.String(), nil
+	case http.MethodPut:
+		// Uploads through the URL fail if the bucket does not exist yet
+		if err := m.ensureBucket(ctx, bucket); err != nil {
+			return "", err
+		}
+		u, err := m.client.PresignedPutObject(ctx, bucket, key, expiry)
+		if err != nil {
+			return "", err
+		}
+		return u.String(), nil
+	default:
+		return "", fmt.Errorf("unsupported presign method: %s", method)
+	}
+}
+
+// GetClient returns the underlying MinIO client for advanced operations
+func (m *MinIOAdapter) GetClient() *minio.Client {
+	return m.client
+}
+
+func endpoint(config *cluster.ServiceConfig) string {
+	return fmt.Sprintf("%s:%d", config.Host, config.Port)
+}
+
+// credentialsFor returns the access and secret keys of a service, which are configured
+// as its username and password
+func credentialsFor(config *cluster.ServiceConfig) (string, string) {
+	accessKey, secretKey := config.Username, config.Password
+	if accessKey == "" {
+		accessKey = defaultAccessKey
+	}
+	if secretKey == "" {
+		secretKey = defaultSecretKey
+	}
+	return accessKey, secretKey
+}
+
+func region(config *cluster.ServiceConfig) string {
+	if value, ok := config.Options["region"].(string); ok {
+		return value
+	}
+	return ""
+}
+
+// translateError maps missing buckets and objects to adapters.ErrObjectNotFound
+func translateError(err error) error {
+	switch minio.ToErrorResponse(err).Code {
+	case "NoSuchKey", "NoSuchBucket":
+		return fmt.Errorf("%w: %s", adapters.ErrObjectNotFound, err)
+	}
+	return err
+}
+
+func toObjectInfo(bucket string, object minio.ObjectInfo) *adapters.ObjectInfo {
+	return &adapters.ObjectInfo{
+		Bucket:       bucket,
+		Key:          object.Key,
+		Size:         object.Size,
+		ContentType:  object.ContentType,
+		ETag:         object.ETag,
+		LastModified: object.LastModified,
+	}
+}
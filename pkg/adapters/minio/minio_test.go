@@ -0,0 +1,38 @@
+package minio
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/minio/minio-go/v7"
+
+	"github.com/akmadan/throome/pkg/adapters"
+	"github.com/akmadan/throome/pkg/cluster"
+)
+
+func TestCredentialsFor(t *testing.T) {
+	accessKey, secretKey := credentialsFor(&cluster.ServiceConfig{})
+	if accessKey != defaultAccessKey || secretKey != defaultSecretKey {
+		t.Errorf("Expected default credentials, got %s/%s", accessKey, secretKey)
+	}
+
+	accessKey, secretKey = credentialsFor(&cluster.ServiceConfig{Username: "AKIA", Password: "secret"})
+	if accessKey != "AKIA" || secretKey != "secret" {
+		t.Errorf("Expected configured credentials, got %s/%s", accessKey, secretKey)
+	}
+}
+
+func TestTranslateError(t *testing.T) {
+	for _, code := range []string{"NoSuchKey", "NoSuchBucket"} {
+		err := translateError(minio.ErrorResponse{Code: code, StatusCode: http.StatusNotFound})
+		if !errors.Is(err, adapters.ErrObjectNotFound) {
+			t.Errorf("Expected %s to map to ErrObjectNotFound, got %v", code, err)
+		}
+	}
+
+	denied := minio.ErrorResponse{Code: "AccessDenied", StatusCode: http.StatusForbidden}
+	if err := translateError(denied); errors.Is(err, adapters.ErrObjectNotFound) {
+		t.Errorf("Expected AccessDenied to be left alone, got %v", err)
+	}
+}
@@ -0,0 +1,393 @@
+package mongodb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+	"go.mongodb.org/mongo-driver/v2/mongo/readpref"
+
+	"github.com/akmadan/throome/pkg/adapters"
+	"github.com/akmadan/throome/pkg/cluster"
+)
+
+// defaultDatabase is used when the service config does not name a database
+const defaultDatabase = "throome"
+
+// MongoDBAdapter implements the DocumentAdapter interface for MongoDB
+type MongoDBAdapter struct {
+	*adapters.BaseAdapter
+	config *cluster.ServiceConfig
+	client *mongo.Client
+	db     *mongo.Database
+}
+
+// NewMongoDBAdapter creates a new MongoDB adapter
+func NewMongoDBAdapter(config *cluster.ServiceConfig) (adapters.Adapter, error) {
+	adapter := &MongoDBAdapter{
+		BaseAdapter: adapters.NewBaseAdapter(config),
+		config:      config,
+	}
+	adapter.SetErrorClassifier(classifyError)
+	return adapter, nil
+}
+
+// Codes of the MongoDB command errors of failed authentication and authorization
+const (
+	unauthorizedCode         = 13
+	authenticationFailedCode = 18
+)
+
+// classifyError returns the type of a MongoDB driver error
+func classifyError(err error) string {
+	var cmdErr mongo.CommandError
+	switch {
+	case errors.Is(err, mongo.ErrNoDocuments):
+		return adapters.ErrorTypeNotFound
+	case mongo.IsDuplicateKeyError(err):
+		return adapters.ErrorTypeConstraintViolation
+	case mongo.IsTimeout(err):
+		return adapters.ErrorTypeTimeout
+	case errors.As(err, &cmdErr) && (cmdErr.Code == unauthorizedCode || cmdErr.Code == authenticationFailedCode):
+		return adapters.ErrorTypeAuthFailure
+	}
+	return ""
+}
+
+// Connect establishes a connection to MongoDB
+func (m *MongoDBAdapter) Connect(ctx context.Context) error {
+	clientOptions := options.Client().
+		ApplyURI(connectionURI(m.config)).
+		// Decode nested documents as maps so results serialize as plain JSON objects
+		SetBSONOptions(&options.BSONOptions{DefaultDocumentM: true})
+
+	// Configure pool
+	if m.config.Pool.MaxConnections > 0 {
+		clientOptions.SetMaxPoolSize(uint64(m.config.Pool.MaxConnections))
+	}
+	if m.config.Pool.MinConnections > 0 {
+		clientOptions.SetMinPoolSize(uint64(m.config.Pool.MinConnections))
+	}
+	if m.config.Pool.MaxIdleTime > 0 {
+		clientOptions.SetMaxConnIdleTime(time.Duration(m.config.Pool.MaxIdleTime) * time.Second)
+	}
+
+	client, err := mongo.Connect(clientOptions)
+	if err != nil {
+		return fmt.Errorf("failed to create MongoDB client: %w", err)
+	}
+
+	m.client = client
+	m.db = client.Database(databaseName(m.config))
+
+	// Test connection
+	if err := m.Ping(ctx); err != nil {
+		_ = client.Disconnect(ctx)
+		return fmt.Errorf("failed to connect to MongoDB: %w", err)
+	}
+
+	m.SetConnected(true)
+	return nil
+}
+
+// Disconnect closes the MongoDB connection
+func (m *MongoDBAdapter) Disconnect(ctx context.Context) error {
+	if m.client != nil {
+		err := m.client.Disconnect(ctx)
+		m.SetConnected(false)
+		return err
+	}
+	return nil
+}
+
+// Ping checks if the MongoDB connection is alive
+func (m *MongoDBAdapter) Ping(ctx context.Context) error {
+	start := time.Now()
+	err := m.client.Ping(ctx, readpref.Primary())
+	m.RecordRequest(time.Since(start), err == nil)
+	return err
+}
+
+// HealthCheck performs a health check
+func (m *MongoDBAdapter) HealthCheck(ctx context.Context) (*adapters.HealthStatus, error) {
+	start := time.Now()
+	err := m.Ping(ctx)
+	responseTime := time.Since(start)
+
+	status := &adapters.HealthStatus{
+		Healthy:      err == nil,
+		Reachable:    err == nil,
+		ResponseTime: responseTime,
+		LastChecked:  time.Now(),
+	}
+
+	if err != nil {
+		status.ErrorMessage = err.Error()
+	}
+
+	return status, nil
+}
+
+// Insert inserts documents into a collection
+func (m *MongoDBAdapter) Insert(ctx context.Context, collection string, documents []adapters.Document) ([]interface{}, error) {
+	start := time.Now()
+	docs := make([]interface{}, len(documents))
+	for i, document := range documents {
+		docs[i] = bson.M(normalizeIDs(document))
+	}
+
+	var ids []interface{}
+	result, err := m.db.Collection(collection).InsertMany(ctx, docs)
+	if err == nil {
+		ids = result.InsertedIDs
+	}
+	duration := time.Since(start)
+	m.RecordRequest(duration, err == nil)
+
+	// Log activity
+	response := ""
+	if err == nil {
+		response = fmt.Sprintf("%d documents inserted", len(ids))
+	}
+	m.LogActivity(ctx, "INSERT", fmt.Sprintf("db.%s.insertMany(%d documents)", collection, len(documents)), duration, err, response)
+
+	return ids, err
+}
+
+// Find returns the documents of a collection matching a filter
+func (m *MongoDBAdapter) Find(ctx context.Context, collection string, filter adapters.Document, opts adapters.FindOptions) ([]adapters.Document, error) {
+	start := time.Now()
+	findOptions := options.Find()
+	if opts.Limit > 0 {
+		findOptions.SetLimit(opts.Limit)
+	}
+	if opts.Skip > 0 {
+		findOptions.SetSkip(opts.Skip)
+	}
+	if len(opts.Sort) > 0 {
+		sort := bson.D{}
+		for _, field := range opts.Sort {
+			sort = append(sort, bson.E{Key: field.Field, Value: field.Order})
+		}
+		findOptions.SetSort(sort)
+	}
+	if len(opts.Projection) > 0 {
+		findOptions.SetProjection(bson.M(opts.Projection))
+	}
+
+	documents, err := m.find(ctx, collection, filter, findOptions)
+	duration := time.Since(start)
+	m.RecordRequest(duration, err == nil)
+
+	// Log activity
+	response := ""
+	if err == nil {
+		response = fmt.Sprintf("%d documents", len(documents))
+	}
+	m.LogActivity(ctx, "FIND", fmt.Sprintf("db.%s.find(%s)", collection, formatDocument(filter)), duration, err, response)
+
+	return documents, err
+}
+
+func (m *MongoDBAdapter) find(ctx context.Context, collection string, filter adapters.Document, findOptions *options.FindOptionsBuilder) ([]adapters.Document, error) {
+	cursor, err := m.db.Collection(collection).Find(ctx, filterOrEmpty(filter), findOptions)
+	if err != nil {
+		return nil, err
+	}
+	return decodeAll(ctx, cursor)
+}
+
+// Update applies an update to all documents matching a filter
+func (m *MongoDBAdapter) Update(ctx context.Context, collection string, filter, update adapters.Document) (int64, error) {
+	start := time.Now()
+	var modified int64
+	result, err := m.db.Collection(collection).UpdateMany(ctx, filterOrEmpty(filter), bson.M(update))
+	if err == nil {
+		modified = result.ModifiedCount
+	}
+	duration := time.Since(start)
+	m.RecordRequest(duration, err == nil)
+
+	// Log activity
+	response := ""
+	if err == nil {
+		response = fmt.Sprintf("%d documents modified", modified)
+	}
+	m.LogActivity(ctx, "UPDATE", fmt.Sprintf("db.%s.updateMany(%s, %s)", collection, formatDocument(filter), formatDocument(update)), duration, err, response)
+
+	return modified, err
+}
+
+// Delete deletes all documents matching a filter
+func (m *MongoDBAdapter) Delete(ctx context.Context, collection string, filter adapters.Document) (int64, error) {
+	start := time.Now()
+	var deleted int64
+	result, err := m.db.Collection(collection).DeleteMany(ctx, filterOrEmpty(filter))
+	if err == nil {
+		deleted = result.DeletedCount
+	}
+	duration := time.Since(start)
+	m.RecordRequest(duration, err == nil)
+
+	// Log activity
+	response := ""
+	if err == nil {
+		response = fmt.Sprintf("%d documents deleted", deleted)
+	}
+	m.LogActivity(ctx, "DELETE", fmt.Sprintf("db.%s.deleteMany(%s)", collection, formatDocument(filter)), duration, err, response)
+
+	return deleted, err
+}
+
+// Aggregate runs an aggregation pipeline on a collection
+func (m *MongoDBAdapter) Aggregate(ctx context.Context, collection string, pipeline []adapters.Document) ([]adapters.Document, error) {
+	start := time.Now()
+	documents, err := m.aggregate(ctx, collection, pipeline)
+	duration := time.Since(start)
+	m.RecordRequest(duration, err == nil)
+
+	// Log activity
+	response := ""
+	if err == nil {
+		response = fmt.Sprintf("%d documents", len(documents))
+	}
+	m.LogActivity(ctx, "AGGREGATE", fmt.Sprintf("db.%s.aggregate(%d stages)", collection, len(pipeline)), duration, err, response)
+
+	return documents, err
+}
+
+func (m *MongoDBAdapter) aggregate(ctx context.Context, collection string, pipeline []adapters.Document) ([]adapters.Document, error) {
+	stages := make([]bson.M, len(pipeline))
+	for i, stage := range pipeline {
+		stages[i] = bson.M(stage)
+	}
+
+	cursor, err := m.db.Collection(collection).Aggregate(ctx, stages)
+	if err != nil {
+		return nil, err
+	}
+	return decodeAll(ctx, cursor)
+}
+
+// ListCollections lists the collections of the database
+func (m *MongoDBAdapter) ListCollections(ctx context.Context) ([]string, error) {
+	start := time.Now()
+	names, err := m.db.ListCollectionNames(ctx, bson.D{})
+	duration := time.Since(start)
+	m.RecordRequest(duration, err == nil)
+
+	// Log activity
+	response := ""
+	if err == nil {
+		response = fmt.Sprintf("%d collections", len(names))
+	}
+	m.LogActivity(ctx, "LIST_COLLECTIONS", "db.getCollectionNames()", duration, err, response)
+
+	return names, err
+}
+
+// GetClient returns the underlying MongoDB client for advanced operations
+func (m *MongoDBAdapter) GetClient() *mongo.Client {
+	return m.client
+}
+
+// connectionURI builds the MongoDB connection URI for a service
+func connectionURI(config *cluster.ServiceConfig) string {
+	uri := url.URL{
+		Scheme: "mongodb",
+		Host:   fmt.Sprintf("%s:%d", config.Host, config.Port),
+		Path:   "/",
+	}
+	if config.Username != "" {
+		uri.User = url.UserPassword(config.Username, config.Password)
+	}
+
+	query := url.Values{}
+	if config.Username != "" {
+		// Root users created by the official image live in the admin database
+		authSource := "admin"
+		if source, ok := config.Options["auth_source"].(string); ok && source != "" {
+			authSource = source
+		}
+		query.Set("authSource", authSource)
+	}
+	if replicaSet, ok := config.Options["replica_set"].(string); ok && replicaSet != "" {
+		query.Set("replicaSet", replicaSet)
+	}
+	if config.TLS.Enabled {
+		query.Set("tls", "true")
+		if config.TLS.CAFile != "" {
+			query.Set("tlsCAFile", config.TLS.CAFile)
+		}
+		if config.TLS.InsecureSkipVerify {
+			query.Set("tlsInsecure", "true")
+		}
+	}
+	uri.RawQuery = query.Encode()
+
+	return uri.String()
+}
+
+func databaseName(config *cluster.ServiceConfig) string {
+	if config.Database == "" {
+		return defaultDatabase
+	}
+	return config.Database
+}
+
+// normalizeIDs converts a hex string _id into an ObjectID so filters written in JSON
+// match documents whose IDs were generated by MongoDB
+func normalizeIDs(document adapters.Document) adapters.Document {
+	id, ok := document["_id"].(string)
+	if !ok {
+		return document
+	}
+	objectID, err := bson.ObjectIDFromHex(id)
+	if err != nil {
+		return document
+	}
+
+	normalized := make(adapters.Document, len(document))
+	for key, value := range document {
+		normalized[key] = value
+	}
+	normalized["_id"] = objectID
+	return normalized
+}
+
+func filterOrEmpty(filter adapters.Document) bson.M {
+	if filter == nil {
+		return bson.M{}
+	}
+	return bson.M(normalizeIDs(filter))
+}
+
+func decodeAll(ctx context.Context, cursor *mongo.Cursor) ([]adapters.Document, error) {
+	var results []bson.M
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+
+	documents := make([]adapters.Document, len(results))
+	for i, result := range results {
+		documents[i] = adapters.Document(result)
+	}
+	return documents, nil
+}
+
+func formatDocument(document adapters.Document) string {
+	if len(document) == 0 {
+		return "{}"
+	}
+	data, err := json.Marshal(document)
+	if err != nil {
+		return fmt.Sprintf("%v", map[string]interface{}(document))
+	}
+	return string(data)
+}
@@ -0,0 +1,394 @@
+package mqtt
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+
+	"github.com/akmadan/throome/pkg/adapters"
+	"github.com/akmadan/throome/pkg/cluster"
+)
+
+const (
+	defaultQoS       = 1
+	defaultKeepAlive = 30 * time.Second
+	connectTimeout   = 10 * time.Second
+)
+
+// topicSettings are the delivery settings of a topic
+type topicSettings struct {
+	qos    byte
+	retain bool
+}
+
+// MQTTAdapter implements the QueueAdapter interface for MQTT brokers such as
+// Mosquitto and EMQX. MQTT topics exist implicitly, so the adapter tracks the topics
+// it has created, published to or subscribed to.
+//
+// Delivery settings come from the service options: "qos" (0, 1 or 2, default 1) and
+// "retain" apply to every topic unless CreateTopic overrides them.
+type MQTTAdapter struct {
+	*adapters.BaseAdapter
+	config *cluster.ServiceConfig
+	client paho.Client
+
+	mu            sync.Mutex
+	topics        map[string]topicSettings
+	subscriptions map[string]paho.MessageHandler // Restored when the client reconnects
+}
+
+// NewMQTTAdapter creates a new MQTT adapter
+func NewMQTTAdapter(config *cluster.ServiceConfig) (adapters.Adapter, error) {
+	if _, err := qosOption(config.Options, defaultQoS); err != nil {
+		return nil, err
+	}
+
+	adapter := &MQTTAdapter{
+		BaseAdapter:   adapters.NewBaseAdapter(config),
+		config:        config,
+		topics:        make(map[string]topicSettings),
+		subscriptions: make(map[string]paho.MessageHandler),
+	}
+	return adapter, nil
+}
+
+// Connect establishes a connection to the MQTT broker
+func (m *MQTTAdapter) Connect(ctx context.Context) error {
+	tlsConfig, err := adapters.BuildTLSConfig(&m.config.TLS)
+	if err != nil {
+		return err
+	}
+
+	options := paho.NewClientOptions().
+		AddBroker(brokerURL(m.config)).
+		SetClientID(clientID(m.config)).
+		SetUsername(m.config.Username).
+		SetPassword(m.config.Password).
+		SetCleanSession(boolOption(m.config.Options, "clean_session", true)).
+		SetKeepAlive(defaultKeepAlive).
+		SetConnectTimeout(connectTimeout).
+		SetAutoReconnect(true).
+		// Handlers run concurrently so a slow handler does not stall other topics
+		SetOrderMatters(false).
+		SetOnConnectHandler(m.restoreSubscriptions)
+	if tlsConfig != nil {
+		options.SetTLSConfig(tlsConfig)
+	}
+
+	client := paho.NewClient(options)
+	if err := wait(ctx, client.Connect()); err != nil {
+		return fmt.Errorf("failed to connect to MQTT broker: %w", err)
+	}
+
+	m.client = client
+	m.SetConnected(true)
+	return nil
+}
+
+// restoreSubscriptions resubscribes after a reconnect, since a clean session drops
+// the broker-side subscriptions
+func (m *MQTTAdapter) restoreSubscriptions(client paho.Client) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for topic, handler := range m.subscriptions {
+		client.Subscribe(topic, m.settingsLocked(topic).qos, handler)
+	}
+}
+
+// Disconnect closes the connection to the broker
+func (m *MQTTAdapter) Disconnect(ctx context.Context) error {
+	m.mu.Lock()
+	m.subscriptions = make(map[string]paho.MessageHandler)
+	m.mu.Unlock()
+
+	if m.client != nil {
+		// Give in-flight messages up to a second to complete
+		m.client.Disconnect(1000)
+		m.SetConnected(false)
+	}
+	return nil
+}
+
+// Ping checks if the connection to the broker is open
+func (m *MQTTAdapter) Ping(ctx context.Context) error {
+	start := time.Now()
+	var err error
+	if m.client == nil || !m.client.IsConnectionOpen() {
+		err = fmt.Errorf("connection to MQTT broker is closed")
+	}
+	m.RecordRequest(time.Since(start), err == nil)
+	return err
+}
+
+// HealthCheck performs a health check
+func (m *MQTTAdapter) HealthCheck(ctx context.Context) (*adapters.HealthStatus, error) {
+	start := time.Now()
+	err := m.Ping(ctx)
+	responseTime := time.Since(start)
+
+	status := &adapters.HealthStatus{
+		Healthy:      err == nil,
+		Reachable:    err == nil,
+		ResponseTime: responseTime,
+		LastChecked:  time.Now(),
+	}
+
+	if err != nil {
+		status.ErrorMessage = err.Error()
+	}
+
+	return status, nil
+}
+
+// Publish publishes a message to a topic with the topic's QoS and retain settings
+func (m *MQTTAdapter) Publish(ctx context.Context, topic string, message []byte) error {
+	start := time.Now()
+
+	m.mu.Lock()
+	settings := m.settingsLocked(topic)
+	m.topics[topic] = settings
+	m.mu.Unlock()
+
+	err := wait(ctx, m.client.Publish(topic, settings.qos, settings.retain, message))
+	duration := time.Since(start)
+	m.RecordRequest(duration, err == nil)
+
+	// Log activity
+	command := fmt.Sprintf("PUBLISH to topic '%s' (qos: %d, %d bytes)", topic, settings.qos, len(message))
+	response := ""
+	if err == nil {
+		response = "Message published successfully"
+	}
+	m.LogActivity(ctx, "PUBLISH", command, duration, err, response)
+
+	return err
+}
+
+// Subscribe subscribes to a topic filter, which may contain the + and # wildcards.
+// MQTT has no negative acknowledgement, so messages are acknowledged whether or not
+// the handler succeeds.
+func (m *MQTTAdapter) Subscribe(ctx context.Context, topic string, handler adapters.MessageHandler) error {
+	start := time.Now()
+	command := fmt.Sprintf("SUBSCRIBE to topic '%s'", topic)
+
+	m.mu.Lock()
+	if _, exists := m.subscriptions[topic]; exists {
+		m.mu.Unlock()
+		err := fmt.Errorf("already subscribed to topic: %s", topic)
+		m.LogActivity(ctx, "SUBSCRIBE", command, time.Since(start), err, "")
+		return err
+	}
+	settings := m.settingsLocked(topic)
+	m.mu.Unlock()
+
+	callback := func(_ paho.Client, msg paho.Message) {
+		_ = handler(ctx, toMessage(msg))
+	}
+
+	err := wait(ctx, m.client.Subscribe(topic, settings.qos, callback))
+	if err == nil {
+		m.mu.Lock()
+		m.subscriptions[topic] = callback
+		m.topics[topic] = settings
+		m.mu.Unlock()
+	}
+
+	response := ""
+	if err == nil {
+		response = fmt.Sprintf("Successfully subscribed to topic '%s'", topic)
+	}
+	m.LogActivity(ctx, "SUBSCRIBE", command, time.Since(start), err, response)
+
+	return err
+}
+
+// Unsubscribe unsubscribes from a topic filter
+func (m *MQTTAdapter) Unsubscribe(ctx context.Context, topic string) error {
+	start := time.Now()
+
+	m.mu.Lock()
+	_, exists := m.subscriptions[topic]
+	delete(m.subscriptions, topic)
+	m.mu.Unlock()
+
+	var err error
+	if exists {
+		err = wait(ctx, m.client.Unsubscribe(topic))
+	}
+
+	duration := time.Since(start)
+	command := fmt.Sprintf("UNSUBSCRIBE from topic '%s'", topic)
+	response := ""
+	if err == nil {
+		response = fmt.Sprintf("Successfully unsubscribed from topic '%s'", topic)
+	}
+	m.LogActivity(ctx, "UNSUBSCRIBE", command, duration, err, response)
+
+	return err
+}
+
+// CreateTopic registers a topic with its own delivery settings. The "qos" and
+// "retain" keys override the service options for this topic.
+func (m *MQTTAdapter) CreateTopic(ctx context.Context, topic string, config map[string]interface{}) error {
+	start := time.Now()
+
+	m.mu.Lock()
+	settings := m.settingsLocked(topic)
+	qos, err := qosOption(config, int(settings.qos))
+	if err == nil {
+		settings.qos = qos
+		settings.retain = boolOption(config, "retain", settings.retain)
+		m.topics[topic] = settings
+	}
+	m.mu.Unlock()
+
+	duration := time.Since(start)
+	m.RecordRequest(duration, err == nil)
+
+	// Log activity
+	command := fmt.Sprintf("CREATE TOPIC '%s' (qos: %d, retain: %t)", topic, settings.qos, settings.retain)
+	response := ""
+	if err == nil {
+		response = fmt.Sprintf("Topic '%s' created successfully", topic)
+	}
+	m.LogActivity(ctx, "CREATE_TOPIC", command, duration, err, response)
+
+	return err
+}
+
+// DeleteTopic clears the topic's retained message and forgets the topic
+func (m *MQTTAdapter) DeleteTopic(ctx context.Context, topic string) error {
+	start := time.Now()
+
+	m.mu.Lock()
+	settings := m.settingsLocked(topic)
+	m.mu.Unlock()
+
+	// An empty retained message removes the one stored by the broker
+	err := wait(ctx, m.client.Publish(topic, settings.qos, true, []byte{}))
+	if err == nil {
+		m.mu.Lock()
+		delete(m.topics, topic)
+		m.mu.Unlock()
+	}
+
+	duration := time.Since(start)
+	m.RecordRequest(duration, err == nil)
+
+	// Log activity
+	command := fmt.Sprintf("CLEAR RETAINED on topic '%s'", topic)
+	response := ""
+	if err == nil {
+		response = fmt.Sprintf("Topic '%s' deleted successfully", topic)
+	}
+	m.LogActivity(ctx, "DELETE_TOPIC", command, duration, err, response)
+
+	return err
+}
+
+// ListTopics lists the topics known to this adapter. MQTT brokers do not expose
+// their topics, so topics used only by other clients are not included.
+func (m *MQTTAdapter) ListTopics(ctx context.Context) ([]string, error) {
+	start := time.Now()
+
+	m.mu.Lock()
+	topics := make([]string, 0, len(m.topics))
+	for topic := range m.topics {
+		topics = append(topics, topic)
+	}
+	m.mu.Unlock()
+	sort.Strings(topics)
+
+	duration := time.Since(start)
+	m.RecordRequest(duration, true)
+	m.LogActivity(ctx, "LIST_TOPICS", "LIST TOPICS", duration, nil, fmt.Sprintf("Found %d topics", len(topics)))
+
+	return topics, nil
+}
+
+// GetClient returns the underlying MQTT client for advanced operations
+func (m *MQTTAdapter) GetClient() paho.Client {
+	return m.client
+}
+
+// settingsLocked returns the delivery settings of a topic. The caller must hold mu.
+func (m *MQTTAdapter) settingsLocked(topic string) topicSettings {
+	if settings, ok := m.topics[topic]; ok {
+		return settings
+	}
+	// Validated by NewMQTTAdapter
+	qos, _ := qosOption(m.config.Options, defaultQoS)
+	return topicSettings{qos: qos, retain: boolOption(m.config.Options, "retain", false)}
+}
+
+// wait waits for an MQTT operation to complete or the context to be done
+func wait(ctx context.Context, token paho.Token) error {
+	select {
+	case <-token.Done():
+		return token.Error()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func toMessage(msg paho.Message) *adapters.Message {
+	return &adapters.Message{
+		Topic: msg.Topic(),
+		Value: msg.Payload(),
+		Headers: map[string]string{
+			"qos":      strconv.Itoa(int(msg.Qos())),
+			"retained": strconv.FormatBool(msg.Retained()),
+		},
+		Timestamp: time.Now(),
+		Offset:    int64(msg.MessageID()),
+	}
+}
+
+func brokerURL(config *cluster.ServiceConfig) string {
+	scheme := "tcp"
+	if config.TLS.Enabled {
+		scheme = "ssl"
+	}
+	return fmt.Sprintf("%s://%s:%d", scheme, config.Host, config.Port)
+}
+
+// clientID returns the configured client ID, or a unique one. Brokers disconnect a
+// client when another connects with the same ID.
+func clientID(config *cluster.ServiceConfig) string {
+	if id, ok := config.Options["client_id"].(string); ok && id != "" {
+		return id
+	}
+	return fmt.Sprintf("throome-%d", time.Now().UnixNano())
+}
+
+// qosOption reads a QoS level, which is a float64 when the config was decoded from JSON
+func qosOption(options map[string]interface{}, defaultValue int) (byte, error) {
+	qos := defaultValue
+	switch value := options["qos"].(type) {
+	case nil:
+	case int:
+		qos = value
+	case float64:
+		qos = int(value)
+	default:
+		return 0, fmt.Errorf("invalid MQTT qos: %v", value)
+	}
+
+	if qos < 0 || qos > 2 {
+		return 0, fmt.Errorf("invalid MQTT qos %d: must be 0, 1 or 2", qos)
+	}
+	return byte(qos), nil
+}
+
+func boolOption(options map[string]interface{}, key string, defaultValue bool) bool {
+	if value, ok := options[key].(bool); ok {
+		return value
+	}
+	return defaultValue
+}
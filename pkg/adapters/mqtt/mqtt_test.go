@@ -0,0 +1,87 @@
+package mqtt
+
+import (
+	"context"
+	"testing"
+
+	"github.com/akmadan/throome/pkg/cluster"
+)
+
+func TestQoSOption(t *testing.T) {
+	tests := []struct {
+		name    string
+		options map[string]interface{}
+		want    byte
+		wantErr bool
+	}{
+		{name: "default", options: nil, want: defaultQoS},
+		{name: "yaml int", options: map[string]interface{}{"qos": 2}, want: 2},
+		{name: "json number", options: map[string]interface{}{"qos": float64(0)}, want: 0},
+		{name: "out of range", options: map[string]interface{}{"qos": 3}, wantErr: true},
+		{name: "wrong type", options: map[string]interface{}{"qos": "high"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := qosOption(tt.options, defaultQoS)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("Expected qos %d, got %d", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestNewMQTTAdapterRejectsInvalidQoS(t *testing.T) {
+	config := &cluster.ServiceConfig{Type: "mqtt", Host: "localhost", Port: 1883, Options: map[string]interface{}{"qos": 5}}
+	if _, err := NewMQTTAdapter(config); err == nil {
+		t.Error("Expected an error for an invalid qos option")
+	}
+}
+
+func TestBrokerURL(t *testing.T) {
+	config := &cluster.ServiceConfig{Host: "broker", Port: 1883}
+	if got := brokerURL(config); got != "tcp://broker:1883" {
+		t.Errorf("Expected tcp://broker:1883, got %s", got)
+	}
+
+	config.TLS.Enabled = true
+	config.Port = 8883
+	if got := brokerURL(config); got != "ssl://broker:8883" {
+		t.Errorf("Expected ssl://broker:8883, got %s", got)
+	}
+}
+
+func TestCreateTopicOverridesSettings(t *testing.T) {
+	config := &cluster.ServiceConfig{
+		Type: "mqtt", Host: "localhost", Port: 1883,
+		Options: map[string]interface{}{"qos": 0, "retain": true},
+	}
+	adapter, err := NewMQTTAdapter(config)
+	if err != nil {
+		t.Fatalf("Failed to create adapter: %v", err)
+	}
+	m := adapter.(*MQTTAdapter)
+
+	if err := m.CreateTopic(context.Background(), "sensors/temp", map[string]interface{}{"qos": float64(2)}); err != nil {
+		t.Fatalf("Failed to create topic: %v", err)
+	}
+	if err := m.CreateTopic(context.Background(), "sensors/bad", map[string]interface{}{"qos": 7}); err == nil {
+		t.Error("Expected an error for an invalid topic qos")
+	}
+
+	settings := m.settingsLocked("sensors/temp")
+	if settings.qos != 2 || !settings.retain {
+		t.Errorf("Expected qos 2 with the service retain setting, got %+v", settings)
+	}
+	if defaults := m.settingsLocked("other"); defaults.qos != 0 || !defaults.retain {
+		t.Errorf("Expected service defaults for unknown topics, got %+v", defaults)
+	}
+
+	topics, _ := m.ListTopics(context.Background())
+	if len(topics) != 1 || topics[0] != "sensors/temp" {
+		t.Errorf("Expected only the created topic to be listed, got %v", topics)
+	}
+}
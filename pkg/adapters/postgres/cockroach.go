@@ -0,0 +1,113 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/akmadan/throome/pkg/adapters"
+	"github.com/akmadan/throome/pkg/cluster"
+)
+
+const (
+	// serializationFailure is the SQLSTATE of transactions aborted by a conflict
+	// that are safe to retry
+	serializationFailure = "40001"
+	// defaultCockroachRetries is how often CockroachDB statements are retried, since
+	// every transaction there runs under serializable isolation
+	defaultCockroachRetries = 5
+	maxRetryBackoff         = time.Second
+)
+
+// NewCockroachDBAdapter creates an adapter for CockroachDB, which speaks the
+// PostgreSQL wire protocol. It behaves like the PostgreSQL adapter, but retries
+// statements and transactions that fail with serialization errors. The number of
+// retries is set with the "max_retries" option.
+func NewCockroachDBAdapter(config *cluster.ServiceConfig) (adapters.Adapter, error) {
+	// Single-node containers run insecure with the root user and defaultdb
+	crdbConfig := *config
+	if crdbConfig.Username == "" {
+		crdbConfig.Username = "root"
+	}
+	if crdbConfig.Database == "" {
+		crdbConfig.Database = "defaultdb"
+	}
+
+	adapter := &PostgresAdapter{
+		BaseAdapter: adapters.NewBaseAdapter(&crdbConfig),
+		config:      &crdbConfig,
+		maxRetries:  maxRetries(config, defaultCockroachRetries),
+	}
+	adapter.slowQueries.threshold = slowQueryThreshold(config)
+	adapter.sessions.idleTimeout = transactionIdleTimeout(config)
+	adapter.sessions.max = maxTransactions(config)
+	adapter.SetErrorClassifier(classifyError)
+	return adapter, nil
+}
+
+// RunInTransaction runs fn in a transaction and commits it, or rolls it back when fn
+// fails. Transactions aborted by serialization errors are run again from the start,
+// so fn must be safe to repeat.
+func (p *PostgresAdapter) RunInTransaction(ctx context.Context, fn func(tx adapters.Transaction) error) error {
+	return p.retry(ctx, func() error {
+		tx, err := p.Begin(ctx)
+		if err != nil {
+			return err
+		}
+		if err := fn(tx); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+		return tx.Commit()
+	})
+}
+
+// retry runs fn until it succeeds, fails with an error that is not retryable, or
+// runs out of retries
+func (p *PostgresAdapter) retry(ctx context.Context, fn func() error) error {
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		if err == nil || !isRetryable(err) || attempt >= p.maxRetries {
+			return err
+		}
+
+		backoff := retryBackoff(attempt)
+		p.LogActivity(ctx, "RETRY", fmt.Sprintf("Retry %d/%d after serialization failure", attempt+1, p.maxRetries), backoff, nil, err.Error())
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+	}
+}
+
+// isRetryable reports whether err aborted a transaction that can be run again
+func isRetryable(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == serializationFailure
+}
+
+// retryBackoff doubles the wait after every attempt, starting at 10ms
+func retryBackoff(attempt int) time.Duration {
+	backoff := 10 * time.Millisecond << attempt
+	if backoff <= 0 || backoff > maxRetryBackoff {
+		return maxRetryBackoff
+	}
+	return backoff
+}
+
+// maxRetries reads the "max_retries" option
+func maxRetries(config *cluster.ServiceConfig, defaultValue int) int {
+	switch value := config.Options["max_retries"].(type) {
+	case int:
+		return value
+	case float64:
+		return int(value)
+	default:
+		return defaultValue
+	}
+}
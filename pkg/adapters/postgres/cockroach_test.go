@@ -0,0 +1,97 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/akmadan/throome/pkg/cluster"
+)
+
+func TestIsRetryable(t *testing.T) {
+	serialization := &pgconn.PgError{Code: serializationFailure}
+	if !isRetryable(serialization) {
+		t.Error("Expected serialization failures to be retryable")
+	}
+	if !isRetryable(fmt.Errorf("exec: %w", serialization)) {
+		t.Error("Expected wrapped serialization failures to be retryable")
+	}
+	if isRetryable(&pgconn.PgError{Code: "23505"}) {
+		t.Error("Expected unique violations not to be retryable")
+	}
+	if isRetryable(errors.New("connection refused")) {
+		t.Error("Expected other errors not to be retryable")
+	}
+}
+
+func TestRetry(t *testing.T) {
+	adapter, _ := NewCockroachDBAdapter(&cluster.ServiceConfig{
+		Type:    "cockroachdb",
+		Options: map[string]interface{}{"max_retries": 2},
+	})
+	p := adapter.(*PostgresAdapter)
+
+	attempts := 0
+	err := p.retry(context.Background(), func() error {
+		attempts++
+		if attempts < 3 {
+			return &pgconn.PgError{Code: serializationFailure}
+		}
+		return nil
+	})
+	if err != nil || attempts != 3 {
+		t.Errorf("Expected success on the third attempt, got %v after %d attempts", err, attempts)
+	}
+
+	attempts = 0
+	err = p.retry(context.Background(), func() error {
+		attempts++
+		return &pgconn.PgError{Code: serializationFailure}
+	})
+	if !isRetryable(err) || attempts != 3 {
+		t.Errorf("Expected to give up after 2 retries, got %v after %d attempts", err, attempts)
+	}
+
+	attempts = 0
+	_ = p.retry(context.Background(), func() error {
+		attempts++
+		return errors.New("syntax error")
+	})
+	if attempts != 1 {
+		t.Errorf("Expected errors that are not retryable to be returned at once, got %d attempts", attempts)
+	}
+}
+
+func TestCockroachDBDefaults(t *testing.T) {
+	config := &cluster.ServiceConfig{Type: "cockroachdb"}
+	adapter, _ := NewCockroachDBAdapter(config)
+	p := adapter.(*PostgresAdapter)
+
+	if p.config.Username != "root" || p.config.Database != "defaultdb" {
+		t.Errorf("Unexpected defaults: %s@%s", p.config.Username, p.config.Database)
+	}
+	if config.Username != "" {
+		t.Error("Expected the service config to be left unchanged")
+	}
+	if p.maxRetries != defaultCockroachRetries {
+		t.Errorf("Expected %d retries, got %d", defaultCockroachRetries, p.maxRetries)
+	}
+
+	postgresAdapter, _ := NewPostgresAdapter(&cluster.ServiceConfig{Type: "postgres"})
+	if postgresAdapter.(*PostgresAdapter).maxRetries != 0 {
+		t.Error("Expected PostgreSQL statements not to be retried by default")
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	if retryBackoff(0) != 10*time.Millisecond || retryBackoff(1) != 20*time.Millisecond {
+		t.Errorf("Unexpected backoff: %v, %v", retryBackoff(0), retryBackoff(1))
+	}
+	if retryBackoff(20) != maxRetryBackoff || retryBackoff(100) != maxRetryBackoff {
+		t.Error("Expected the backoff to be capped")
+	}
+}
@@ -0,0 +1,291 @@
+package postgres
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/akmadan/throome/pkg/cluster"
+)
+
+// ErrCursorNotFound is returned for cursor tokens that were never issued, have been
+// read to the end, closed or have expired
+var ErrCursorNotFound = errors.New("cursor not found")
+
+// ErrTooManyCursors is returned when opening a cursor while max_cursors are open
+var ErrTooManyCursors = errors.New("too many open cursors")
+
+const (
+	// defaultCursorIdleTimeout is how long a cursor stays open without a fetch when
+	// the service sets no cursor_idle_seconds option
+	defaultCursorIdleTimeout = 5 * time.Minute
+
+	// defaultMaxCursors bounds the open cursors when the service sets no
+	// max_cursors option. Each one holds a pooled connection.
+	defaultMaxCursors = 10
+
+	// cursorName names the cursor in its transaction, which only ever holds one
+	cursorName = "throome_cursor"
+)
+
+// cursor is a server-side cursor, open in a read-only transaction on a connection
+// of its own until it is read to the end, closed or left idle too long
+type cursor struct {
+	tx     pgx.Tx
+	query  string
+	timer  *time.Timer // Closes the cursor once it has been idle for the timeout
+	closed bool
+	mu     sync.Mutex
+}
+
+// cursorRegistry holds the open cursors of an adapter, by token
+type cursorRegistry struct {
+	cursors     map[string]*cursor
+	idleTimeout time.Duration
+	max         int
+	mu          sync.Mutex
+}
+
+// OpenCursor declares a cursor for a query and fetches its first fetchSize rows. The
+// returned token fetches the next rows with FetchCursor; it is empty when the first
+// rows were all there is, in which case the cursor is already closed. A cursor left
+// idle for the service's cursor_idle_seconds is closed.
+func (p *PostgresAdapter) OpenCursor(ctx context.Context, query string, fetchSize int, args ...interface{}) ([]map[string]interface{}, string, error) {
+	p.cursors.mu.Lock()
+	if p.cursors.max > 0 && len(p.cursors.cursors) >= p.cursors.max {
+		p.cursors.mu.Unlock()
+		return nil, "", fmt.Errorf("%w: at most %d can be open", ErrTooManyCursors, p.cursors.max)
+	}
+	p.cursors.mu.Unlock()
+
+	start := time.Now()
+	c, err := p.declareCursor(ctx, query, args)
+	if err != nil {
+		duration := time.Since(start)
+		p.RecordRequest(duration, false)
+		p.LogActivity(ctx, "DECLARE_CURSOR", query, duration, err, "")
+		return nil, "", err
+	}
+
+	c.mu.Lock()
+	rows, done, err := p.fetch(ctx, "", c, fetchSize, start)
+	c.mu.Unlock()
+	if err != nil || done {
+		return rows, "", err
+	}
+
+	token, err := cursorToken()
+	if err != nil {
+		p.closeUnregistered(ctx, c)
+		return nil, "", err
+	}
+
+	// Cursors opened concurrently may have used up the limit meanwhile
+	p.cursors.mu.Lock()
+	if p.cursors.max > 0 && len(p.cursors.cursors) >= p.cursors.max {
+		p.cursors.mu.Unlock()
+		p.closeUnregistered(ctx, c)
+		return nil, "", fmt.Errorf("%w: at most %d can be open", ErrTooManyCursors, p.cursors.max)
+	}
+	if p.cursors.cursors == nil {
+		p.cursors.cursors = make(map[string]*cursor)
+	}
+	p.cursors.cursors[token] = c
+	p.cursors.mu.Unlock()
+
+	c.mu.Lock()
+	c.timer = time.AfterFunc(p.cursors.idleTimeout, func() { p.expireCursor(token) })
+	c.mu.Unlock()
+
+	return rows, token, nil
+}
+
+// FetchCursor fetches the next fetchSize rows of a cursor. done reports that the
+// cursor has been read to the end and closed.
+func (p *PostgresAdapter) FetchCursor(ctx context.Context, token string, fetchSize int) ([]map[string]interface{}, bool, error) {
+	p.cursors.mu.Lock()
+	c, exists := p.cursors.cursors[token]
+	p.cursors.mu.Unlock()
+	if !exists {
+		return nil, false, ErrCursorNotFound
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// A timer that already fired is closing the cursor
+	if c.closed || !c.timer.Stop() {
+		return nil, false, ErrCursorNotFound
+	}
+
+	return p.fetch(ctx, token, c, fetchSize, time.Now())
+}
+
+// CloseCursor closes a cursor before it has been read to the end
+func (p *PostgresAdapter) CloseCursor(ctx context.Context, token string) error {
+	c := p.removeCursor(token)
+	if c == nil {
+		return ErrCursorNotFound
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.close(ctx)
+
+	p.LogActivity(ctx, "CLOSE_CURSOR", c.query, 0, nil, "Cursor closed")
+	return nil
+}
+
+// OpenCursors returns how many cursors are open
+func (p *PostgresAdapter) OpenCursors() int {
+	p.cursors.mu.Lock()
+	defer p.cursors.mu.Unlock()
+	return len(p.cursors.cursors)
+}
+
+// declareCursor begins the transaction a cursor lives in and declares it there
+func (p *PostgresAdapter) declareCursor(ctx context.Context, query string, args []interface{}) (*cursor, error) {
+	tx, err := p.pool.BeginTx(ctx, pgx.TxOptions{AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.Exec(ctx, fmt.Sprintf("DECLARE %s NO SCROLL CURSOR FOR %s", cursorName, query), args...); err != nil {
+		_ = tx.Rollback(ctx)
+		return nil, err
+	}
+
+	return &cursor{tx: tx, query: query}, nil
+}
+
+// fetch reads the next rows of a cursor whose idle timer is stopped or not started
+// yet. It closes the cursor once it is read to the end or fails, and restarts its
+// idle timer otherwise. The caller holds c.mu.
+func (p *PostgresAdapter) fetch(ctx context.Context, token string, c *cursor, fetchSize int, start time.Time) ([]map[string]interface{}, bool, error) {
+	var rows []map[string]interface{}
+	var bytes int64
+	pgxRows, err := c.tx.Query(ctx, fmt.Sprintf("FETCH FORWARD %d FROM %s", fetchSize, cursorName))
+	if err == nil {
+		rows, bytes, err = CollectRows(pgxRows)
+	}
+	duration := time.Since(start)
+	p.RecordRequest(duration, err == nil)
+	p.LogQuery(ctx, "FETCH_CURSOR", c.query, duration, err, int64(len(rows)), bytes)
+
+	done := err != nil || len(rows) < fetchSize
+	if done {
+		p.removeCursor(token)
+		c.close(ctx)
+	} else if c.timer != nil {
+		c.timer.Reset(p.cursors.idleTimeout)
+	}
+
+	if err != nil {
+		return nil, false, err
+	}
+	return rows, done, nil
+}
+
+// expireCursor closes a cursor that has been idle for the idle timeout
+func (p *PostgresAdapter) expireCursor(token string) {
+	c := p.removeCursor(token)
+	if c == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.close(ctx)
+}
+
+// closeUnregistered closes a cursor that was never registered
+func (p *PostgresAdapter) closeUnregistered(ctx context.Context, c *cursor) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.close(ctx)
+}
+
+// closeCursors closes every open cursor, releasing their connections to the pool
+func (p *PostgresAdapter) closeCursors(ctx context.Context) {
+	p.cursors.mu.Lock()
+	cursors := p.cursors.cursors
+	p.cursors.cursors = nil
+	p.cursors.mu.Unlock()
+
+	for _, c := range cursors {
+		c.mu.Lock()
+		c.close(ctx)
+		c.mu.Unlock()
+	}
+}
+
+// removeCursor unregisters a cursor, returning nil if it was not registered
+func (p *PostgresAdapter) removeCursor(token string) *cursor {
+	p.cursors.mu.Lock()
+	defer p.cursors.mu.Unlock()
+
+	c, exists := p.cursors.cursors[token]
+	if !exists {
+		return nil
+	}
+	delete(p.cursors.cursors, token)
+	return c
+}
+
+// close ends the cursor's transaction, which releases its connection. The caller
+// holds c.mu.
+func (c *cursor) close(ctx context.Context) {
+	if c.closed {
+		return
+	}
+	c.closed = true
+	if c.timer != nil {
+		c.timer.Stop()
+	}
+	_ = c.tx.Rollback(ctx)
+}
+
+// cursorToken returns a random token, so cursors cannot be guessed by other clients
+func cursorToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate cursor token: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// cursorIdleTimeout reads the cursor_idle_seconds option of a service
+func cursorIdleTimeout(config *cluster.ServiceConfig) time.Duration {
+	var timeout time.Duration
+	switch seconds := config.Options["cursor_idle_seconds"].(type) {
+	case int:
+		timeout = time.Duration(seconds) * time.Second
+	case float64:
+		timeout = time.Duration(seconds * float64(time.Second))
+	}
+	if timeout <= 0 {
+		return defaultCursorIdleTimeout
+	}
+	return timeout
+}
+
+// maxCursors reads the max_cursors option of a service
+func maxCursors(config *cluster.ServiceConfig) int {
+	switch value := config.Options["max_cursors"].(type) {
+	case int:
+		return value
+	case float64:
+		return int(value)
+	default:
+		return defaultMaxCursors
+	}
+}
@@ -0,0 +1,48 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/akmadan/throome/pkg/cluster"
+)
+
+func TestCursorOptions(t *testing.T) {
+	tests := []struct {
+		options     map[string]interface{}
+		idleTimeout time.Duration
+		max         int
+	}{
+		{nil, defaultCursorIdleTimeout, defaultMaxCursors},
+		{map[string]interface{}{"cursor_idle_seconds": 30, "max_cursors": 4}, 30 * time.Second, 4},
+		{map[string]interface{}{"cursor_idle_seconds": 1.5, "max_cursors": 4.0}, 1500 * time.Millisecond, 4},
+		{map[string]interface{}{"cursor_idle_seconds": 0, "max_cursors": 0}, defaultCursorIdleTimeout, 0},
+	}
+
+	for _, tt := range tests {
+		config := &cluster.ServiceConfig{Options: tt.options}
+		if got := cursorIdleTimeout(config); got != tt.idleTimeout {
+			t.Errorf("cursorIdleTimeout(%v) = %s, expected %s", tt.options, got, tt.idleTimeout)
+		}
+		if got := maxCursors(config); got != tt.max {
+			t.Errorf("maxCursors(%v) = %d, expected %d", tt.options, got, tt.max)
+		}
+	}
+}
+
+func TestUnknownCursor(t *testing.T) {
+	adapter, _ := NewPostgresAdapter(&cluster.ServiceConfig{Type: "postgres"})
+	p := adapter.(*PostgresAdapter)
+
+	if _, _, err := p.FetchCursor(context.Background(), "missing", 10); !errors.Is(err, ErrCursorNotFound) {
+		t.Errorf("Expected an unknown cursor to be reported, got %v", err)
+	}
+	if err := p.CloseCursor(context.Background(), "missing"); !errors.Is(err, ErrCursorNotFound) {
+		t.Errorf("Expected an unknown cursor to be reported, got %v", err)
+	}
+	if open := p.OpenCursors(); open != 0 {
+		t.Errorf("Expected no open cursors, got %d", open)
+	}
+}
@@ -0,0 +1,50 @@
+package postgres
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/akmadan/throome/pkg/adapters"
+)
+
+// SQLSTATEs of the errors classified individually rather than by their class
+const (
+	queryCanceled      = "57014" // Also raised by statement_timeout
+	undefinedTable     = "42P01"
+	undefinedColumn    = "42703"
+	undefinedFunction  = "42883"
+	undefinedObject    = "42704"
+	invalidCatalogName = "3D000" // The database does not exist
+	insufficientGrant  = "42501"
+)
+
+// classifyError returns the type of an error of pgx by its SQLSTATE
+func classifyError(err error) string {
+	switch {
+	case errors.Is(err, pgx.ErrNoRows), errors.Is(err, ErrCursorNotFound), errors.Is(err, ErrStatementNotFound),
+		errors.Is(err, ErrTransactionNotFound):
+		return adapters.ErrorTypeNotFound
+	case pgconn.Timeout(err):
+		return adapters.ErrorTypeTimeout
+	}
+
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return ""
+	}
+	switch {
+	case strings.HasPrefix(pgErr.Code, "23"): // Integrity constraint violation
+		return adapters.ErrorTypeConstraintViolation
+	case strings.HasPrefix(pgErr.Code, "28"), pgErr.Code == insufficientGrant: // Invalid authorization
+		return adapters.ErrorTypeAuthFailure
+	case pgErr.Code == queryCanceled:
+		return adapters.ErrorTypeTimeout
+	case pgErr.Code == undefinedTable, pgErr.Code == undefinedColumn, pgErr.Code == undefinedFunction,
+		pgErr.Code == undefinedObject, pgErr.Code == invalidCatalogName:
+		return adapters.ErrorTypeNotFound
+	}
+	return ""
+}
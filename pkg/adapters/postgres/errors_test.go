@@ -0,0 +1,34 @@
+package postgres
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/akmadan/throome/pkg/adapters"
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected string
+	}{
+		{&pgconn.PgError{Code: "23505"}, adapters.ErrorTypeConstraintViolation},
+		{fmt.Errorf("exec: %w", &pgconn.PgError{Code: "23503"}), adapters.ErrorTypeConstraintViolation},
+		{&pgconn.PgError{Code: "28P01"}, adapters.ErrorTypeAuthFailure},
+		{&pgconn.PgError{Code: insufficientGrant}, adapters.ErrorTypeAuthFailure},
+		{&pgconn.PgError{Code: queryCanceled}, adapters.ErrorTypeTimeout},
+		{&pgconn.PgError{Code: undefinedTable}, adapters.ErrorTypeNotFound},
+		{pgx.ErrNoRows, adapters.ErrorTypeNotFound},
+		{ErrStatementNotFound, adapters.ErrorTypeNotFound},
+		{&pgconn.PgError{Code: "42601"}, ""}, // Syntax errors are left to adapters.ClassifyError
+		{fmt.Errorf("other"), ""},
+	}
+	for _, test := range tests {
+		if errorType := classifyError(test.err); errorType != test.expected {
+			t.Errorf("Expected %v to be classified as %q, got %q", test.err, test.expected, errorType)
+		}
+	}
+}
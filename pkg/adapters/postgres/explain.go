@@ -0,0 +1,243 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/akmadan/throome/pkg/cluster"
+)
+
+// defaultSlowQueryThreshold is used when the service options don't set slow_query_ms
+const defaultSlowQueryThreshold = 1000 * time.Millisecond
+
+// maxSlowQueries is the number of slow queries kept per adapter
+const maxSlowQueries = 100
+
+// PlanNode is a single node of a parsed query plan
+type PlanNode struct {
+	NodeType          string      `json:"node_type"`
+	RelationName      string      `json:"relation_name,omitempty"`
+	Alias             string      `json:"alias,omitempty"`
+	IndexName         string      `json:"index_name,omitempty"`
+	JoinType          string      `json:"join_type,omitempty"`
+	Filter            string      `json:"filter,omitempty"`
+	IndexCond         string      `json:"index_cond,omitempty"`
+	StartupCost       float64     `json:"startup_cost"`
+	TotalCost         float64     `json:"total_cost"`
+	PlanRows          float64     `json:"plan_rows"`
+	PlanWidth         int         `json:"plan_width"`
+	ActualStartupTime *float64    `json:"actual_startup_time,omitempty"` // milliseconds, ANALYZE only
+	ActualTotalTime   *float64    `json:"actual_total_time,omitempty"`   // milliseconds, ANALYZE only
+	ActualRows        *float64    `json:"actual_rows,omitempty"`         // ANALYZE only
+	ActualLoops       *float64    `json:"actual_loops,omitempty"`        // ANALYZE only
+	Plans             []*PlanNode `json:"plans,omitempty"`
+}
+
+// ExplainResult is the parsed output of EXPLAIN (FORMAT JSON)
+type ExplainResult struct {
+	Plan          *PlanNode       `json:"plan"`
+	PlanningTime  *float64        `json:"planning_time,omitempty"`  // milliseconds, ANALYZE only
+	ExecutionTime *float64        `json:"execution_time,omitempty"` // milliseconds, ANALYZE only
+	Analyzed      bool            `json:"analyzed"`
+	Raw           json.RawMessage `json:"raw"`
+}
+
+// SlowQuery is a statement that exceeded the slow query threshold
+type SlowQuery struct {
+	Query     string         `json:"query"`
+	Args      []interface{}  `json:"args,omitempty"`
+	Duration  int64          `json:"duration"` // milliseconds
+	Timestamp time.Time      `json:"timestamp"`
+	Plan      *ExplainResult `json:"plan,omitempty"`
+	PlanError string         `json:"plan_error,omitempty"`
+}
+
+// pgPlanNode mirrors the key names PostgreSQL uses in EXPLAIN JSON output
+type pgPlanNode struct {
+	NodeType          string        `json:"Node Type"`
+	RelationName      string        `json:"Relation Name"`
+	Alias             string        `json:"Alias"`
+	IndexName         string        `json:"Index Name"`
+	JoinType          string        `json:"Join Type"`
+	Filter            string        `json:"Filter"`
+	IndexCond         string        `json:"Index Cond"`
+	StartupCost       float64       `json:"Startup Cost"`
+	TotalCost         float64       `json:"Total Cost"`
+	PlanRows          float64       `json:"Plan Rows"`
+	PlanWidth         int           `json:"Plan Width"`
+	ActualStartupTime *float64      `json:"Actual Startup Time"`
+	ActualTotalTime   *float64      `json:"Actual Total Time"`
+	ActualRows        *float64      `json:"Actual Rows"`
+	ActualLoops       *float64      `json:"Actual Loops"`
+	Plans             []*pgPlanNode `json:"Plans"`
+}
+
+type pgExplain struct {
+	Plan          *pgPlanNode `json:"Plan"`
+	PlanningTime  *float64    `json:"Planning Time"`
+	ExecutionTime *float64    `json:"Execution Time"`
+}
+
+func (n *pgPlanNode) toPlanNode() *PlanNode {
+	if n == nil {
+		return nil
+	}
+	node := &PlanNode{
+		NodeType:          n.NodeType,
+		RelationName:      n.RelationName,
+		Alias:             n.Alias,
+		IndexName:         n.IndexName,
+		JoinType:          n.JoinType,
+		Filter:            n.Filter,
+		IndexCond:         n.IndexCond,
+		StartupCost:       n.StartupCost,
+		TotalCost:         n.TotalCost,
+		PlanRows:          n.PlanRows,
+		PlanWidth:         n.PlanWidth,
+		ActualStartupTime: n.ActualStartupTime,
+		ActualTotalTime:   n.ActualTotalTime,
+		ActualRows:        n.ActualRows,
+		ActualLoops:       n.ActualLoops,
+	}
+	for _, child := range n.Plans {
+		node.Plans = append(node.Plans, child.toPlanNode())
+	}
+	return node
+}
+
+// parseExplain parses the JSON document returned by EXPLAIN (FORMAT JSON)
+func parseExplain(raw []byte, analyzed bool) (*ExplainResult, error) {
+	var explains []pgExplain
+	if err := json.Unmarshal(raw, &explains); err != nil {
+		return nil, fmt.Errorf("failed to parse plan: %w", err)
+	}
+	if len(explains) == 0 {
+		return nil, fmt.Errorf("empty plan")
+	}
+
+	return &ExplainResult{
+		Plan:          explains[0].Plan.toPlanNode(),
+		PlanningTime:  explains[0].PlanningTime,
+		ExecutionTime: explains[0].ExecutionTime,
+		Analyzed:      analyzed,
+		Raw:           json.RawMessage(raw),
+	}, nil
+}
+
+// Explain returns the query plan for a statement. With analyze the statement is
+// executed inside a transaction that is always rolled back, so data-modifying
+// statements can be analyzed without side effects.
+func (p *PostgresAdapter) Explain(ctx context.Context, query string, analyze bool, args ...interface{}) (*ExplainResult, error) {
+	start := time.Now()
+
+	explainQuery := "EXPLAIN (FORMAT JSON) " + query
+	if analyze {
+		explainQuery = "EXPLAIN (ANALYZE, BUFFERS, FORMAT JSON) " + query
+	}
+
+	tx, err := p.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(context.Background()) //nolint:errcheck // Rollback is the intended outcome, errors are irrelevant
+
+	var raw []byte
+	err = tx.QueryRow(ctx, explainQuery, args...).Scan(&raw)
+	duration := time.Since(start)
+	p.RecordRequest(duration, err == nil)
+
+	// Log activity
+	operation := "EXPLAIN"
+	if analyze {
+		operation = "EXPLAIN_ANALYZE"
+	}
+	response := ""
+	if err == nil {
+		response = "Plan captured"
+	}
+	p.LogActivity(ctx, operation, query, duration, err, response)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return parseExplain(raw, analyze)
+}
+
+// slowQueryLog holds the most recent slow queries of an adapter
+type slowQueryLog struct {
+	threshold time.Duration
+	queries   []*SlowQuery
+	mu        sync.RWMutex
+}
+
+// slowQueryThreshold reads the slow_query_ms service option. Zero or a negative value
+// disables slow query capture.
+func slowQueryThreshold(config *cluster.ServiceConfig) time.Duration {
+	switch ms := config.Options["slow_query_ms"].(type) {
+	case int:
+		return time.Duration(ms) * time.Millisecond
+	case float64:
+		return time.Duration(ms * float64(time.Millisecond))
+	default:
+		return defaultSlowQueryThreshold
+	}
+}
+
+// ObserveQuery records the statement in the slow query log if it exceeded the slow
+// query threshold and captures its plan in the background. Queries that bypass the
+// adapter (e.g. run directly on the pool) can report their duration here, with the
+// context they ran in.
+func (p *PostgresAdapter) ObserveQuery(ctx context.Context, query string, args []interface{}, duration time.Duration) {
+	if p.slowQueries.threshold <= 0 || duration < p.slowQueries.threshold {
+		return
+	}
+
+	slow := &SlowQuery{
+		Query:     query,
+		Args:      args,
+		Duration:  duration.Milliseconds(),
+		Timestamp: time.Now(),
+	}
+
+	p.slowQueries.mu.Lock()
+	if len(p.slowQueries.queries) >= maxSlowQueries {
+		p.slowQueries.queries = p.slowQueries.queries[1:]
+	}
+	p.slowQueries.queries = append(p.slowQueries.queries, slow)
+	p.slowQueries.mu.Unlock()
+
+	p.LogActivity(ctx, "SLOW_QUERY", query, duration, nil,
+		fmt.Sprintf("Query exceeded slow query threshold of %s", p.slowQueries.threshold))
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		plan, err := p.Explain(ctx, query, false, args...)
+
+		p.slowQueries.mu.Lock()
+		defer p.slowQueries.mu.Unlock()
+		slow.Plan = plan
+		if err != nil {
+			slow.PlanError = err.Error()
+		}
+	}()
+}
+
+// SlowQueries returns the recorded slow queries, newest first
+func (p *PostgresAdapter) SlowQueries() []*SlowQuery {
+	p.slowQueries.mu.RLock()
+	defer p.slowQueries.mu.RUnlock()
+
+	result := make([]*SlowQuery, 0, len(p.slowQueries.queries))
+	for i := len(p.slowQueries.queries) - 1; i >= 0; i-- {
+		queryCopy := *p.slowQueries.queries[i]
+		result = append(result, &queryCopy)
+	}
+
+	return result
+}
@@ -0,0 +1,67 @@
+package postgres
+
+import (
+	"testing"
+
+	"github.com/akmadan/throome/pkg/cluster"
+)
+
+func TestParseExplain(t *testing.T) {
+	raw := []byte(`[{"Plan": {"Node Type": "Hash Join", "Join Type": "Inner", "Startup Cost": 1.5, "Total Cost": 42.25,
+		"Plan Rows": 100, "Plan Width": 16, "Actual Total Time": 3.2, "Actual Rows": 97, "Actual Loops": 1,
+		"Plans": [{"Node Type": "Seq Scan", "Relation Name": "users", "Alias": "u", "Filter": "(active = true)",
+		"Startup Cost": 0, "Total Cost": 20, "Plan Rows": 1000, "Plan Width": 8}]},
+		"Planning Time": 0.12, "Execution Time": 3.5}]`)
+
+	result, err := parseExplain(raw, true)
+	if err != nil {
+		t.Fatalf("Failed to parse plan: %v", err)
+	}
+
+	if result.Plan.NodeType != "Hash Join" {
+		t.Errorf("Expected root node 'Hash Join', got '%s'", result.Plan.NodeType)
+	}
+	if result.Plan.TotalCost != 42.25 {
+		t.Errorf("Expected total cost 42.25, got %v", result.Plan.TotalCost)
+	}
+	if result.Plan.ActualRows == nil || *result.Plan.ActualRows != 97 {
+		t.Errorf("Expected 97 actual rows, got %v", result.Plan.ActualRows)
+	}
+	if result.ExecutionTime == nil || *result.ExecutionTime != 3.5 {
+		t.Errorf("Expected execution time 3.5, got %v", result.ExecutionTime)
+	}
+	if len(result.Plan.Plans) != 1 {
+		t.Fatalf("Expected 1 child plan, got %d", len(result.Plan.Plans))
+	}
+
+	child := result.Plan.Plans[0]
+	if child.RelationName != "users" || child.Filter != "(active = true)" {
+		t.Errorf("Unexpected child plan: %+v", child)
+	}
+	if child.ActualRows != nil {
+		t.Error("Expected no actual rows on a node without ANALYZE data")
+	}
+
+	if _, err := parseExplain([]byte(`[]`), false); err == nil {
+		t.Error("Expected error for empty plan")
+	}
+}
+
+func TestSlowQueryThreshold(t *testing.T) {
+	tests := []struct {
+		options  map[string]interface{}
+		expected int64 // milliseconds
+	}{
+		{nil, defaultSlowQueryThreshold.Milliseconds()},
+		{map[string]interface{}{"slow_query_ms": 250}, 250},
+		{map[string]interface{}{"slow_query_ms": 250.0}, 250},
+		{map[string]interface{}{"slow_query_ms": 0}, 0},
+	}
+
+	for _, tt := range tests {
+		got := slowQueryThreshold(&cluster.ServiceConfig{Options: tt.options})
+		if got.Milliseconds() != tt.expected {
+			t.Errorf("slowQueryThreshold(%v) = %dms, expected %dms", tt.options, got.Milliseconds(), tt.expected)
+		}
+	}
+}
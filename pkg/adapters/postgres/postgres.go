@@ -16,8 +16,13 @@ import (
 // PostgresAdapter implements the DatabaseAdapter interface for PostgreSQL
 type PostgresAdapter struct {
 	*adapters.BaseAdapter
-	config *cluster.ServiceConfig
-	pool   *pgxpool.Pool
+	config      *cluster.ServiceConfig
+	pool        *pgxpool.Pool
+	slowQueries slowQueryLog
+	statements  statementRegistry
+	cursors     cursorRegistry
+	sessions    sessionRegistry
+	maxRetries  int // Retries of statements aborted by serialization errors
 }
 
 // NewPostgresAdapter creates a new PostgreSQL adapter
@@ -25,7 +30,14 @@ func NewPostgresAdapter(config *cluster.ServiceConfig) (adapters.Adapter, error)
 	adapter := &PostgresAdapter{
 		BaseAdapter: adapters.NewBaseAdapter(config),
 		config:      config,
+		maxRetries:  maxRetries(config, 0),
 	}
+	adapter.slowQueries.threshold = slowQueryThreshold(config)
+	adapter.cursors.idleTimeout = cursorIdleTimeout(config)
+	adapter.cursors.max = maxCursors(config)
+	adapter.sessions.idleTimeout = transactionIdleTimeout(config)
+	adapter.sessions.max = maxTransactions(config)
+	adapter.SetErrorClassifier(classifyError)
 	return adapter, nil
 }
 
@@ -82,6 +94,9 @@ func (p *PostgresAdapter) Connect(ctx context.Context) error {
 // Disconnect closes the PostgreSQL connection pool
 func (p *PostgresAdapter) Disconnect(ctx context.Context) error {
 	if p.pool != nil {
+		// Closing the pool waits for the connections the cursors and transactions hold
+		p.closeCursors(ctx)
+		p.closeSessions()
 		p.pool.Close()
 		p.SetConnected(false)
 	}
@@ -104,6 +119,7 @@ func (p *PostgresAdapter) HealthCheck(ctx context.Context) (*adapters.HealthStat
 
 	status := &adapters.HealthStatus{
 		Healthy:      err == nil,
+		Reachable:    err == nil,
 		ResponseTime: responseTime,
 		LastChecked:  time.Now(),
 	}
@@ -118,20 +134,23 @@ func (p *PostgresAdapter) HealthCheck(ctx context.Context) (*adapters.HealthStat
 // Execute executes a query/command
 func (p *PostgresAdapter) Execute(ctx context.Context, query string, args ...interface{}) (adapters.Result, error) {
 	start := time.Now()
-	tag, err := p.pool.Exec(ctx, query, args...)
+	var tag pgconn.CommandTag
+	err := p.retry(ctx, func() error {
+		var execErr error
+		tag, execErr = p.pool.Exec(ctx, query, args...)
+		return execErr
+	})
 	duration := time.Since(start)
 	p.RecordRequest(duration, err == nil)
 
 	// Log activity
-	command := query
-	if len(args) > 0 {
-		command = fmt.Sprintf("%s [args: %v]", query, args)
-	}
+	command := adapters.CommandWithArgs(query, args)
 	response := ""
 	if err == nil {
 		response = fmt.Sprintf("Rows affected: %d", tag.RowsAffected())
 	}
-	p.LogActivity("EXECUTE", command, duration, err, response)
+	p.LogActivity(ctx, "EXECUTE", command, duration, err, response)
+	p.ObserveQuery(ctx, query, args, duration)
 
 	if err != nil {
 		return nil, err
@@ -143,28 +162,27 @@ func (p *PostgresAdapter) Execute(ctx context.Context, query string, args ...int
 // Query performs a query and returns rows
 func (p *PostgresAdapter) Query(ctx context.Context, query string, args ...interface{}) (adapters.Rows, error) {
 	start := time.Now()
-	rows, err := p.pool.Query(ctx, query, args...)
+	var rows pgx.Rows
+	err := p.retry(ctx, func() error {
+		var queryErr error
+		rows, queryErr = p.pool.Query(ctx, query, args...)
+		return queryErr
+	})
 	duration := time.Since(start)
 	p.RecordRequest(duration, err == nil)
+	p.ObserveQuery(ctx, query, args, duration)
 
-	// Log activity
-	command := query
-	if len(args) > 0 {
-		command = fmt.Sprintf("%s [args: %v]", query, args)
-	}
-	response := "Query executed successfully"
-	if err == nil {
-		// Note: We can't count rows without consuming the result set
-		// So we just log that the query was successful
-		response = "Query executed, rows available"
-	}
-	p.LogActivity("QUERY", command, duration, err, response)
-
+	// The rows are only counted as they are read, so the query is logged once they
+	// are closed
+	command := adapters.CommandWithArgs(query, args)
 	if err != nil {
+		p.LogQuery(ctx, "QUERY", command, duration, err, 0, 0)
 		return nil, err
 	}
 
-	return &postgresRows{rows: rows}, nil
+	return &postgresRows{rows: rows, log: func(err error, count, bytes int64) {
+		p.LogQuery(ctx, "QUERY", command, duration, err, count, bytes)
+	}}, nil
 }
 
 // QueryRow performs a query that returns a single row
@@ -175,20 +193,26 @@ func (p *PostgresAdapter) QueryRow(ctx context.Context, query string, args ...in
 	p.RecordRequest(duration, true) // Record as success since error is deferred
 
 	// Log activity
-	command := query
-	if len(args) > 0 {
-		command = fmt.Sprintf("%s [args: %v]", query, args)
-	}
+	command := adapters.CommandWithArgs(query, args)
 	response := "Single row query executed"
-	p.LogActivity("QUERY_ROW", command, duration, nil, response)
+	p.LogActivity(ctx, "QUERY_ROW", command, duration, nil, response)
 
 	return &postgresRow{row: row}
 }
 
 // Begin starts a transaction
 func (p *PostgresAdapter) Begin(ctx context.Context) (adapters.Transaction, error) {
+	tx, err := p.beginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// beginTx starts a transaction with options
+func (p *PostgresAdapter) beginTx(ctx context.Context, options pgx.TxOptions) (*postgresTransaction, error) {
 	start := time.Now()
-	tx, err := p.pool.Begin(ctx)
+	tx, err := p.pool.BeginTx(ctx, options)
 	duration := time.Since(start)
 	p.RecordRequest(duration, err == nil)
 
@@ -197,13 +221,13 @@ func (p *PostgresAdapter) Begin(ctx context.Context) (adapters.Transaction, erro
 	if err == nil {
 		response = "Transaction started successfully"
 	}
-	p.LogActivity("BEGIN", "BEGIN TRANSACTION", duration, err, response)
+	p.LogActivity(ctx, "BEGIN", "BEGIN TRANSACTION", duration, err, response)
 
 	if err != nil {
 		return nil, err
 	}
 
-	return &postgresTransaction{tx: tx, adapter: p}, nil
+	return &postgresTransaction{tx: tx, adapter: p, ctx: ctx}, nil
 }
 
 // postgresResult implements adapters.Result
@@ -221,13 +245,23 @@ func (r *postgresResult) LastInsertID() int64 {
 	return 0
 }
 
-// postgresRows implements adapters.Rows
+// postgresRows implements adapters.Rows. It counts the rows read and the size of their
+// values, and passes them to log when it is first closed.
 type postgresRows struct {
-	rows pgx.Rows
+	rows   pgx.Rows
+	log    func(err error, count, bytes int64)
+	count  int64
+	bytes  int64
+	closed bool
 }
 
 func (r *postgresRows) Next() bool {
-	return r.rows.Next()
+	if !r.rows.Next() {
+		return false
+	}
+	r.count++
+	r.bytes += RowSize(r.rows.RawValues())
+	return true
 }
 
 func (r *postgresRows) Scan(dest ...interface{}) error {
@@ -236,6 +270,12 @@ func (r *postgresRows) Scan(dest ...interface{}) error {
 
 func (r *postgresRows) Close() error {
 	r.rows.Close()
+	if !r.closed {
+		r.closed = true
+		if r.log != nil {
+			r.log(r.rows.Err(), r.count, r.bytes)
+		}
+	}
 	return nil
 }
 
@@ -256,6 +296,7 @@ func (r *postgresRow) Scan(dest ...interface{}) error {
 type postgresTransaction struct {
 	tx      pgx.Tx
 	adapter *PostgresAdapter
+	ctx     context.Context // Context of Begin, whose request ID commit and rollback are logged with
 }
 
 func (t *postgresTransaction) Commit() error {
@@ -268,7 +309,7 @@ func (t *postgresTransaction) Commit() error {
 	if err == nil {
 		response = "Transaction committed successfully"
 	}
-	t.adapter.LogActivity("COMMIT", "COMMIT TRANSACTION", duration, err, response)
+	t.adapter.LogActivity(t.ctx, "COMMIT", "COMMIT TRANSACTION", duration, err, response)
 
 	return err
 }
@@ -283,7 +324,7 @@ func (t *postgresTransaction) Rollback() error {
 	if err == nil {
 		response = "Transaction rolled back successfully"
 	}
-	t.adapter.LogActivity("ROLLBACK", "ROLLBACK TRANSACTION", duration, err, response)
+	t.adapter.LogActivity(t.ctx, "ROLLBACK", "ROLLBACK TRANSACTION", duration, err, response)
 
 	return err
 }
@@ -295,15 +336,12 @@ func (t *postgresTransaction) Execute(ctx context.Context, query string, args ..
 	t.adapter.RecordRequest(duration, err == nil)
 
 	// Log activity
-	command := query
-	if len(args) > 0 {
-		command = fmt.Sprintf("%s [args: %v]", query, args)
-	}
+	command := adapters.CommandWithArgs(query, args)
 	response := ""
 	if err == nil {
 		response = fmt.Sprintf("TX: Rows affected: %d", tag.RowsAffected())
 	}
-	t.adapter.LogActivity("TX_EXECUTE", command, duration, err, response)
+	t.adapter.LogActivity(ctx, "TX_EXECUTE", command, duration, err, response)
 
 	if err != nil {
 		return nil, err
@@ -318,22 +356,53 @@ func (t *postgresTransaction) Query(ctx context.Context, query string, args ...i
 	duration := time.Since(start)
 	t.adapter.RecordRequest(duration, err == nil)
 
-	// Log activity
-	command := query
-	if len(args) > 0 {
-		command = fmt.Sprintf("%s [args: %v]", query, args)
-	}
-	response := ""
-	if err == nil {
-		response = "TX: Query executed, rows available"
-	}
-	t.adapter.LogActivity("TX_QUERY", command, duration, err, response)
-
+	// Log activity once the rows are counted
+	command := adapters.CommandWithArgs(query, args)
 	if err != nil {
+		t.adapter.LogQuery(ctx, "TX_QUERY", command, duration, err, 0, 0)
 		return nil, err
 	}
 
-	return &postgresRows{rows: rows}, nil
+	return &postgresRows{rows: rows, log: func(err error, count, bytes int64) {
+		t.adapter.LogQuery(ctx, "TX_QUERY", command, duration, err, count, bytes)
+	}}, nil
+}
+
+// RecordQuery records a query run directly on the pool of the adapter in its metrics and
+// activity log, with the rows it returned and the size of their values
+func (p *PostgresAdapter) RecordQuery(ctx context.Context, query string, args []interface{}, duration time.Duration, err error, rows, bytes int64) {
+	p.RecordRequest(duration, err == nil)
+	p.LogQuery(ctx, "QUERY", adapters.CommandWithArgs(query, args), duration, err, rows, bytes)
+}
+
+// CollectRows reads the rows of a query into maps of their columns like pgx.CollectRows
+// with pgx.RowToMap, and also returns the size of their values. It closes the rows.
+func CollectRows(rows pgx.Rows) ([]map[string]interface{}, int64, error) {
+	defer rows.Close()
+
+	var bytes int64
+	result := []map[string]interface{}{}
+	for rows.Next() {
+		bytes += RowSize(rows.RawValues())
+		row, err := pgx.RowToMap(rows)
+		if err != nil {
+			return nil, bytes, err
+		}
+		result = append(result, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, bytes, err
+	}
+	return result, bytes, nil
+}
+
+// RowSize returns the size of the values of a row as sent by the database
+func RowSize(values [][]byte) int64 {
+	var size int64
+	for _, value := range values {
+		size += int64(len(value))
+	}
+	return size
 }
 
 // GetPoolStats returns connection pool statistics
@@ -345,6 +414,25 @@ func (p *PostgresAdapter) GetPoolStats() *pgxpool.Stat {
 	return stat
 }
 
+// PoolStats returns connection pool statistics in adapter-neutral form
+func (p *PostgresAdapter) PoolStats() *adapters.PoolStats {
+	stat := p.GetPoolStats()
+	if stat == nil {
+		return nil
+	}
+	return &adapters.PoolStats{
+		AcquiredConns:        int(stat.AcquiredConns()),
+		IdleConns:            int(stat.IdleConns()),
+		TotalConns:           int(stat.TotalConns()),
+		MaxConns:             int(stat.MaxConns()),
+		AcquireCount:         stat.AcquireCount(),
+		EmptyAcquireCount:    stat.EmptyAcquireCount(),
+		CanceledAcquireCount: stat.CanceledAcquireCount(),
+		AcquireDuration:      stat.AcquireDuration(),
+		NewConnsCount:        stat.NewConnsCount(),
+	}
+}
+
 // GetPool returns the underlying connection pool
 func (p *PostgresAdapter) GetPool() *pgxpool.Pool {
 	return p.pool
@@ -352,3 +440,6 @@ func (p *PostgresAdapter) GetPool() *pgxpool.Pool {
 
 // Ensure PostgresAdapter implements DatabaseAdapter
 var _ adapters.DatabaseAdapter = (*PostgresAdapter)(nil)
+
+// Ensure PostgresAdapter implements PoolStatsProvider
+var _ adapters.PoolStatsProvider = (*PostgresAdapter)(nil)
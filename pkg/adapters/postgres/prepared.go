@@ -0,0 +1,265 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/akmadan/throome/pkg/adapters"
+)
+
+// ErrStatementNotFound is returned for statement names that were never prepared or
+// have been deallocated
+var ErrStatementNotFound = errors.New("prepared statement not found")
+
+// ErrStatementExists is returned when preparing a name already used by another query
+var ErrStatementExists = errors.New("prepared statement already exists")
+
+// statementNamePattern restricts statement names to ones that are safe in URLs
+var statementNamePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,63}$`)
+
+// PreparedStatement is a named statement prepared through the adapter
+type PreparedStatement struct {
+	Name       string    `json:"name"`
+	Query      string    `json:"query"`
+	Params     int       `json:"params"`  // Number of $n parameters
+	Columns    []string  `json:"columns"` // Columns of the rows it returns, if any
+	Executions int64     `json:"executions"`
+	CreatedAt  time.Time `json:"created_at"`
+
+	// serverName names the statement on the server's connections. It is unique per
+	// preparation, so a name prepared again after being deallocated never reuses a
+	// statement left on a busy connection, and short enough for an identifier.
+	serverName string
+}
+
+// statementRegistry holds the named statements of an adapter
+type statementRegistry struct {
+	statements map[string]*PreparedStatement
+	sequence   uint64
+	mu         sync.RWMutex
+}
+
+// Prepare registers a named statement. The query is parsed and described by the
+// server once here, then prepared on each pooled connection the first time it runs
+// there, so later executions skip parsing and planning. Preparing a name again with
+// the same query returns the existing statement.
+func (p *PostgresAdapter) Prepare(ctx context.Context, name, query string) (*PreparedStatement, error) {
+	if !statementNamePattern.MatchString(name) {
+		return nil, fmt.Errorf("invalid statement name %q: use up to 63 letters, digits, '_', '.' or '-'", name)
+	}
+
+	if existing, err := p.existingStatement(name, query); existing != nil || err != nil {
+		return existing, err
+	}
+
+	// Parse outside of the lock, which executions of other statements need
+	start := time.Now()
+	p.statements.mu.Lock()
+	p.statements.sequence++
+	serverName := fmt.Sprintf("throome_%d", p.statements.sequence)
+	p.statements.mu.Unlock()
+
+	var description *pgconn.StatementDescription
+	err := p.pool.AcquireFunc(ctx, func(conn *pgxpool.Conn) error {
+		var prepareErr error
+		description, prepareErr = conn.Conn().Prepare(ctx, serverName, query)
+		return prepareErr
+	})
+	duration := time.Since(start)
+	p.RecordRequest(duration, err == nil)
+
+	response := ""
+	if err == nil {
+		response = fmt.Sprintf("Statement %s prepared", name)
+	}
+	p.LogActivity(ctx, "PREPARE", query, duration, err, response)
+
+	if err != nil {
+		return nil, err
+	}
+
+	stmt := &PreparedStatement{
+		Name:       name,
+		Query:      query,
+		Params:     len(description.ParamOIDs),
+		Columns:    make([]string, 0, len(description.Fields)),
+		CreatedAt:  time.Now(),
+		serverName: serverName,
+	}
+	for _, field := range description.Fields {
+		stmt.Columns = append(stmt.Columns, field.Name)
+	}
+
+	p.statements.mu.Lock()
+	defer p.statements.mu.Unlock()
+
+	// The name may have been prepared concurrently
+	if existing, exists := p.statements.statements[name]; exists {
+		if existing.Query != query {
+			return nil, fmt.Errorf("%w: %s", ErrStatementExists, name)
+		}
+		stmtCopy := *existing
+		return &stmtCopy, nil
+	}
+	if p.statements.statements == nil {
+		p.statements.statements = make(map[string]*PreparedStatement)
+	}
+	p.statements.statements[name] = stmt
+
+	result := *stmt
+	return &result, nil
+}
+
+// existingStatement returns the statement prepared under a name for the same query,
+// or ErrStatementExists if the name is used by another query
+func (p *PostgresAdapter) existingStatement(name, query string) (*PreparedStatement, error) {
+	p.statements.mu.RLock()
+	defer p.statements.mu.RUnlock()
+
+	existing, exists := p.statements.statements[name]
+	if !exists {
+		return nil, nil
+	}
+	if existing.Query != query {
+		return nil, fmt.Errorf("%w: %s", ErrStatementExists, name)
+	}
+	stmtCopy := *existing
+	return &stmtCopy, nil
+}
+
+// Statements returns the prepared statements, sorted by name
+func (p *PostgresAdapter) Statements() []*PreparedStatement {
+	p.statements.mu.RLock()
+	defer p.statements.mu.RUnlock()
+
+	result := make([]*PreparedStatement, 0, len(p.statements.statements))
+	for _, stmt := range p.statements.statements {
+		stmtCopy := *stmt
+		result = append(result, &stmtCopy)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// Deallocate removes a prepared statement. It is released on the idle connections
+// right away; busy connections keep it until they are closed.
+func (p *PostgresAdapter) Deallocate(ctx context.Context, name string) error {
+	p.statements.mu.Lock()
+	stmt, exists := p.statements.statements[name]
+	delete(p.statements.statements, name)
+	p.statements.mu.Unlock()
+
+	if !exists {
+		return fmt.Errorf("%w: %s", ErrStatementNotFound, name)
+	}
+
+	for _, conn := range p.pool.AcquireAllIdle(ctx) {
+		_ = conn.Conn().Deallocate(ctx, stmt.serverName)
+		conn.Release()
+	}
+
+	p.LogActivity(ctx, "DEALLOCATE", name, 0, nil, fmt.Sprintf("Statement %s deallocated", name))
+	return nil
+}
+
+// ExecutePrepared runs a prepared statement that returns no rows
+func (p *PostgresAdapter) ExecutePrepared(ctx context.Context, name string, args ...interface{}) (adapters.Result, error) {
+	stmt, err := p.statement(name)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	var tag pgconn.CommandTag
+	err = p.retry(ctx, func() error {
+		return p.withStatement(ctx, stmt, func(conn *pgxpool.Conn) error {
+			var execErr error
+			tag, execErr = conn.Exec(ctx, stmt.serverName, args...)
+			return execErr
+		})
+	})
+	duration := time.Since(start)
+	p.RecordRequest(duration, err == nil)
+
+	response := ""
+	if err == nil {
+		response = fmt.Sprintf("Rows affected: %d", tag.RowsAffected())
+	}
+	p.LogActivity(ctx, "EXECUTE_PREPARED", preparedCommand(stmt, args), duration, err, response)
+	p.ObserveQuery(ctx, stmt.Query, args, duration)
+
+	if err != nil {
+		return nil, err
+	}
+	return &postgresResult{tag: tag}, nil
+}
+
+// QueryPrepared runs a prepared statement and collects the rows it returns
+func (p *PostgresAdapter) QueryPrepared(ctx context.Context, name string, args ...interface{}) ([]map[string]interface{}, error) {
+	stmt, err := p.statement(name)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	var rows []map[string]interface{}
+	var bytes int64
+	err = p.retry(ctx, func() error {
+		return p.withStatement(ctx, stmt, func(conn *pgxpool.Conn) error {
+			pgxRows, queryErr := conn.Query(ctx, stmt.serverName, args...)
+			if queryErr != nil {
+				return queryErr
+			}
+			rows, bytes, queryErr = CollectRows(pgxRows)
+			return queryErr
+		})
+	})
+	duration := time.Since(start)
+	p.RecordRequest(duration, err == nil)
+	p.LogQuery(ctx, "QUERY_PREPARED", preparedCommand(stmt, args), duration, err, int64(len(rows)), bytes)
+	p.ObserveQuery(ctx, stmt.Query, args, duration)
+
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// statement looks up a prepared statement and counts an execution of it
+func (p *PostgresAdapter) statement(name string) (*PreparedStatement, error) {
+	p.statements.mu.Lock()
+	defer p.statements.mu.Unlock()
+
+	stmt, exists := p.statements.statements[name]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrStatementNotFound, name)
+	}
+	stmt.Executions++
+
+	stmtCopy := *stmt
+	return &stmtCopy, nil
+}
+
+// withStatement runs fn on a pooled connection the statement is prepared on
+func (p *PostgresAdapter) withStatement(ctx context.Context, stmt *PreparedStatement, fn func(conn *pgxpool.Conn) error) error {
+	return p.pool.AcquireFunc(ctx, func(conn *pgxpool.Conn) error {
+		// A no-op once the statement is prepared on this connection
+		if _, err := conn.Conn().Prepare(ctx, stmt.serverName, stmt.Query); err != nil {
+			return err
+		}
+		return fn(conn)
+	})
+}
+
+// preparedCommand describes an execution of a prepared statement in activity logs
+func preparedCommand(stmt *PreparedStatement, args []interface{}) string {
+	return adapters.CommandWithArgs(fmt.Sprintf("%s: %s", stmt.Name, stmt.Query), args)
+}
@@ -0,0 +1,51 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/akmadan/throome/pkg/cluster"
+)
+
+func TestPrepareRejectsInvalidNames(t *testing.T) {
+	adapter, _ := NewPostgresAdapter(&cluster.ServiceConfig{Type: "postgres"})
+	p := adapter.(*PostgresAdapter)
+
+	for _, name := range []string{"", "with space", "slash/name", "a-name-that-is-far-too-long-to-be-used-as-the-name-of-a-statement"} {
+		if _, err := p.Prepare(context.Background(), name, "SELECT 1"); err == nil {
+			t.Errorf("Expected %q to be rejected", name)
+		}
+	}
+}
+
+func TestPreparedStatementLookup(t *testing.T) {
+	adapter, _ := NewPostgresAdapter(&cluster.ServiceConfig{Type: "postgres"})
+	p := adapter.(*PostgresAdapter)
+	p.statements.statements = map[string]*PreparedStatement{
+		"get_user": {Name: "get_user", Query: "SELECT * FROM users WHERE id = $1", serverName: "throome_1"},
+	}
+
+	// Preparing the same query again returns the existing statement without a round trip
+	stmt, err := p.Prepare(context.Background(), "get_user", "SELECT * FROM users WHERE id = $1")
+	if err != nil || stmt.Name != "get_user" {
+		t.Errorf("Expected the existing statement, got %v and %v", stmt, err)
+	}
+	if _, err := p.Prepare(context.Background(), "get_user", "SELECT 1"); !errors.Is(err, ErrStatementExists) {
+		t.Errorf("Expected another query to conflict, got %v", err)
+	}
+
+	if _, err := p.ExecutePrepared(context.Background(), "missing"); !errors.Is(err, ErrStatementNotFound) {
+		t.Errorf("Expected an unknown statement to be reported, got %v", err)
+	}
+	if err := p.Deallocate(context.Background(), "missing"); !errors.Is(err, ErrStatementNotFound) {
+		t.Errorf("Expected an unknown statement to be reported, got %v", err)
+	}
+
+	if stmt, err := p.statement("get_user"); err != nil || stmt.Executions != 1 {
+		t.Errorf("Expected the execution to be counted, got %v and %v", stmt, err)
+	}
+	if statements := p.Statements(); len(statements) != 1 || statements[0].Executions != 1 {
+		t.Errorf("Unexpected statements: %v", statements)
+	}
+}
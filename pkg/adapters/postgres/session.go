@@ -0,0 +1,266 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/akmadan/throome/pkg/adapters"
+	"github.com/akmadan/throome/pkg/cluster"
+)
+
+// ErrTransactionNotFound is returned for transaction tokens that were never issued,
+// have been committed, rolled back or have expired
+var ErrTransactionNotFound = errors.New("transaction not found")
+
+// ErrTooManyTransactions is returned when beginning a transaction while
+// max_transactions are open
+var ErrTooManyTransactions = errors.New("too many open transactions")
+
+const (
+	// defaultTransactionIdleTimeout is how long a transaction stays open without a
+	// statement when the service sets no transaction_idle_seconds option. Open
+	// transactions hold their locks, so it is shorter than the one of cursors.
+	defaultTransactionIdleTimeout = time.Minute
+
+	// defaultMaxTransactions bounds the open transactions when the service sets no
+	// max_transactions option. Each one holds a pooled connection.
+	defaultMaxTransactions = 10
+)
+
+// session is a transaction that lives across requests, open on a connection of its
+// own until it is committed, rolled back or left idle too long
+type session struct {
+	tx     *postgresTransaction
+	timer  *time.Timer // Rolls the transaction back once it has been idle for the timeout
+	closed bool
+	mu     sync.Mutex
+}
+
+// sessionRegistry holds the open transactions of an adapter, by token
+type sessionRegistry struct {
+	sessions    map[string]*session
+	idleTimeout time.Duration
+	max         int
+	mu          sync.Mutex
+}
+
+// BeginSession begins a transaction for clients that run its statements one request
+// at a time, and returns the token that runs them with SessionExecute and
+// SessionQuery and ends it with CommitSession or RollbackSession. A transaction left
+// idle for the service's transaction_idle_seconds is rolled back.
+func (p *PostgresAdapter) BeginSession(ctx context.Context, options pgx.TxOptions) (string, error) {
+	if p.sessionsFull() {
+		return "", fmt.Errorf("%w: at most %d can be open", ErrTooManyTransactions, p.sessions.max)
+	}
+
+	tx, err := p.beginTx(ctx, options)
+	if err != nil {
+		return "", err
+	}
+
+	token, err := cursorToken()
+	if err != nil {
+		_ = tx.Rollback()
+		return "", err
+	}
+
+	// Transactions begun concurrently may have used up the limit meanwhile
+	p.sessions.mu.Lock()
+	if p.sessions.max > 0 && len(p.sessions.sessions) >= p.sessions.max {
+		p.sessions.mu.Unlock()
+		_ = tx.Rollback()
+		return "", fmt.Errorf("%w: at most %d can be open", ErrTooManyTransactions, p.sessions.max)
+	}
+	if p.sessions.sessions == nil {
+		p.sessions.sessions = make(map[string]*session)
+	}
+	s := &session{tx: tx}
+	s.mu.Lock()
+	p.sessions.sessions[token] = s
+	p.sessions.mu.Unlock()
+
+	s.timer = time.AfterFunc(p.sessions.idleTimeout, func() { p.expireSession(token) })
+	s.mu.Unlock()
+
+	return token, nil
+}
+
+// SessionExecute runs a statement that returns no rows in a transaction
+func (p *PostgresAdapter) SessionExecute(ctx context.Context, token, query string, args ...interface{}) (adapters.Result, error) {
+	var result adapters.Result
+	err := p.withSession(token, func(s *session) error {
+		var err error
+		result, err = s.tx.Execute(ctx, query, args...)
+		return err
+	})
+	return result, err
+}
+
+// SessionQuery runs a query in a transaction and collects the rows it returns
+func (p *PostgresAdapter) SessionQuery(ctx context.Context, token, query string, args ...interface{}) ([]map[string]interface{}, error) {
+	var rows []map[string]interface{}
+	err := p.withSession(token, func(s *session) error {
+		start := time.Now()
+		var bytes int64
+		pgxRows, err := s.tx.tx.Query(ctx, query, args...)
+		if err == nil {
+			rows, bytes, err = CollectRows(pgxRows)
+		}
+		duration := time.Since(start)
+		p.RecordRequest(duration, err == nil)
+		p.LogQuery(ctx, "TX_QUERY", adapters.CommandWithArgs(query, args), duration, err, int64(len(rows)), bytes)
+		return err
+	})
+	return rows, err
+}
+
+// CommitSession commits a transaction. It is ended even when the commit fails.
+func (p *PostgresAdapter) CommitSession(ctx context.Context, token string) error {
+	return p.endSession(ctx, token, (*postgresTransaction).Commit)
+}
+
+// RollbackSession rolls a transaction back
+func (p *PostgresAdapter) RollbackSession(ctx context.Context, token string) error {
+	return p.endSession(ctx, token, (*postgresTransaction).Rollback)
+}
+
+// OpenSessions returns how many transactions are open
+func (p *PostgresAdapter) OpenSessions() int {
+	p.sessions.mu.Lock()
+	defer p.sessions.mu.Unlock()
+	return len(p.sessions.sessions)
+}
+
+// sessionsFull reports whether max_transactions are open
+func (p *PostgresAdapter) sessionsFull() bool {
+	p.sessions.mu.Lock()
+	defer p.sessions.mu.Unlock()
+	return p.sessions.max > 0 && len(p.sessions.sessions) >= p.sessions.max
+}
+
+// withSession runs fn on an open transaction with its idle timer stopped, and
+// restarts the timer afterwards. Statements of a transaction run one at a time.
+func (p *PostgresAdapter) withSession(token string, fn func(s *session) error) error {
+	p.sessions.mu.Lock()
+	s, exists := p.sessions.sessions[token]
+	p.sessions.mu.Unlock()
+	if !exists {
+		return ErrTransactionNotFound
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// A timer that already fired is rolling the transaction back
+	if s.closed || !s.timer.Stop() {
+		return ErrTransactionNotFound
+	}
+	defer s.timer.Reset(p.sessions.idleTimeout)
+
+	return fn(s)
+}
+
+// endSession unregisters a transaction and ends it with end. Ending it is logged with
+// the request that ends it.
+func (p *PostgresAdapter) endSession(ctx context.Context, token string, end func(*postgresTransaction) error) error {
+	s := p.removeSession(token)
+	if s == nil {
+		return ErrTransactionNotFound
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return ErrTransactionNotFound
+	}
+	s.closed = true
+	s.timer.Stop()
+
+	s.tx.ctx = ctx
+	return end(s.tx)
+}
+
+// expireSession rolls back a transaction that has been idle for the idle timeout
+func (p *PostgresAdapter) expireSession(token string) {
+	s := p.removeSession(token)
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.close()
+}
+
+// closeSessions rolls back every open transaction, releasing their connections to the
+// pool
+func (p *PostgresAdapter) closeSessions() {
+	p.sessions.mu.Lock()
+	sessions := p.sessions.sessions
+	p.sessions.sessions = nil
+	p.sessions.mu.Unlock()
+
+	for _, s := range sessions {
+		s.mu.Lock()
+		s.close()
+		s.mu.Unlock()
+	}
+}
+
+// removeSession unregisters a transaction, returning nil if it was not registered
+func (p *PostgresAdapter) removeSession(token string) *session {
+	p.sessions.mu.Lock()
+	defer p.sessions.mu.Unlock()
+
+	s, exists := p.sessions.sessions[token]
+	if !exists {
+		return nil
+	}
+	delete(p.sessions.sessions, token)
+	return s
+}
+
+// close rolls the transaction back, which releases its connection. The caller holds
+// s.mu.
+func (s *session) close() {
+	if s.closed {
+		return
+	}
+	s.closed = true
+	if s.timer != nil {
+		s.timer.Stop()
+	}
+	_ = s.tx.Rollback()
+}
+
+// transactionIdleTimeout reads the transaction_idle_seconds option of a service
+func transactionIdleTimeout(config *cluster.ServiceConfig) time.Duration {
+	var timeout time.Duration
+	switch seconds := config.Options["transaction_idle_seconds"].(type) {
+	case int:
+		timeout = time.Duration(seconds) * time.Second
+	case float64:
+		timeout = time.Duration(seconds * float64(time.Second))
+	}
+	if timeout <= 0 {
+		return defaultTransactionIdleTimeout
+	}
+	return timeout
+}
+
+// maxTransactions reads the max_transactions option of a service
+func maxTransactions(config *cluster.ServiceConfig) int {
+	switch value := config.Options["max_transactions"].(type) {
+	case int:
+		return value
+	case float64:
+		return int(value)
+	default:
+		return defaultMaxTransactions
+	}
+}
@@ -0,0 +1,55 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/akmadan/throome/pkg/cluster"
+)
+
+func TestTransactionOptions(t *testing.T) {
+	tests := []struct {
+		options     map[string]interface{}
+		idleTimeout time.Duration
+		max         int
+	}{
+		{nil, defaultTransactionIdleTimeout, defaultMaxTransactions},
+		{map[string]interface{}{"transaction_idle_seconds": 30, "max_transactions": 4}, 30 * time.Second, 4},
+		{map[string]interface{}{"transaction_idle_seconds": 1.5, "max_transactions": 4.0}, 1500 * time.Millisecond, 4},
+		{map[string]interface{}{"transaction_idle_seconds": 0, "max_transactions": 0}, defaultTransactionIdleTimeout, 0},
+	}
+
+	for _, tt := range tests {
+		config := &cluster.ServiceConfig{Options: tt.options}
+		if got := transactionIdleTimeout(config); got != tt.idleTimeout {
+			t.Errorf("transactionIdleTimeout(%v) = %s, expected %s", tt.options, got, tt.idleTimeout)
+		}
+		if got := maxTransactions(config); got != tt.max {
+			t.Errorf("maxTransactions(%v) = %d, expected %d", tt.options, got, tt.max)
+		}
+	}
+}
+
+func TestUnknownTransaction(t *testing.T) {
+	adapter, _ := NewPostgresAdapter(&cluster.ServiceConfig{Type: "postgres"})
+	p := adapter.(*PostgresAdapter)
+	ctx := context.Background()
+
+	if _, err := p.SessionExecute(ctx, "missing", "SELECT 1"); !errors.Is(err, ErrTransactionNotFound) {
+		t.Errorf("Expected an unknown transaction to be reported, got %v", err)
+	}
+	if _, err := p.SessionQuery(ctx, "missing", "SELECT 1"); !errors.Is(err, ErrTransactionNotFound) {
+		t.Errorf("Expected an unknown transaction to be reported, got %v", err)
+	}
+	if err := p.CommitSession(ctx, "missing"); !errors.Is(err, ErrTransactionNotFound) {
+		t.Errorf("Expected an unknown transaction to be reported, got %v", err)
+	}
+	if err := p.RollbackSession(ctx, "missing"); !errors.Is(err, ErrTransactionNotFound) {
+		t.Errorf("Expected an unknown transaction to be reported, got %v", err)
+	}
+	if open := p.OpenSessions(); open != 0 {
+		t.Errorf("Expected no open transactions, got %d", open)
+	}
+}
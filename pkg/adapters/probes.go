@@ -0,0 +1,182 @@
+package adapters
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/akmadan/throome/pkg/cluster"
+)
+
+// ProbeResult is the outcome of a single application-level health probe
+type ProbeResult struct {
+	Name     string        `json:"name"`
+	Type     string        `json:"type"`
+	Healthy  bool          `json:"healthy"`
+	Message  string        `json:"message,omitempty"`
+	Duration time.Duration `json:"duration"`
+}
+
+// TopicLister is implemented by queue adapters that can list their topics
+type TopicLister interface {
+	ListTopics(ctx context.Context) ([]string, error)
+}
+
+// CheckHealth runs the adapter's connectivity check followed by its probes. Probes
+// only run against reachable services; the returned status is healthy only when the
+// service is reachable and every probe passes.
+func CheckHealth(ctx context.Context, adapter Adapter, probes []cluster.ProbeConfig, defaultTimeout time.Duration) (*HealthStatus, error) {
+	status, err := adapter.HealthCheck(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if !status.Reachable || len(probes) == 0 {
+		return status, nil
+	}
+
+	status.Probes = RunProbes(ctx, adapter, probes, defaultTimeout)
+	for _, result := range status.Probes {
+		if !result.Healthy {
+			status.Healthy = false
+			if status.ErrorMessage == "" {
+				status.ErrorMessage = fmt.Sprintf("probe %s failed: %s", result.Name, result.Message)
+			}
+		}
+	}
+
+	return status, nil
+}
+
+// RunProbes runs probes against an adapter in order
+func RunProbes(ctx context.Context, adapter Adapter, probes []cluster.ProbeConfig, defaultTimeout time.Duration) []ProbeResult {
+	results := make([]ProbeResult, 0, len(probes))
+	for i := range probes {
+		results = append(results, RunProbe(ctx, adapter, &probes[i], defaultTimeout))
+	}
+	return results
+}
+
+// RunProbe runs a single probe against an adapter
+func RunProbe(ctx context.Context, adapter Adapter, probe *cluster.ProbeConfig, defaultTimeout time.Duration) ProbeResult {
+	timeout := defaultTimeout
+	if probe.TimeoutMS > 0 {
+		timeout = time.Duration(probe.TimeoutMS) * time.Millisecond
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	err := runProbe(ctx, adapter, probe)
+
+	result := ProbeResult{
+		Name:     probe.Name,
+		Type:     probe.Type,
+		Healthy:  err == nil,
+		Duration: time.Since(start),
+	}
+	if err != nil {
+		result.Message = err.Error()
+	}
+
+	return result
+}
+
+func runProbe(ctx context.Context, adapter Adapter, probe *cluster.ProbeConfig) error {
+	switch probe.Type {
+	case "sql":
+		db, ok := adapter.(DatabaseAdapter)
+		if !ok {
+			return fmt.Errorf("service is not a database")
+		}
+		return probeSQL(ctx, db, probe)
+
+	case "cache_get":
+		cache, ok := adapter.(CacheAdapter)
+		if !ok {
+			return fmt.Errorf("service is not a cache")
+		}
+		return probeCacheGet(ctx, cache, probe)
+
+	case "topic_exists":
+		lister, ok := adapter.(TopicLister)
+		if !ok {
+			return fmt.Errorf("service cannot list topics")
+		}
+		return probeTopicExists(ctx, lister, probe)
+
+	default:
+		return fmt.Errorf("unsupported probe type: %s", probe.Type)
+	}
+}
+
+// probeSQL expects the query to return at least one row, matching Expect when set
+func probeSQL(ctx context.Context, db DatabaseAdapter, probe *cluster.ProbeConfig) error {
+	rows, err := db.Query(ctx, probe.Query)
+	if err != nil {
+		return fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("query failed: %w", err)
+		}
+		return fmt.Errorf("query returned no rows")
+	}
+
+	if probe.Expect != "" {
+		var value interface{}
+		if err := rows.Scan(&value); err != nil {
+			return fmt.Errorf("failed to read row: %w", err)
+		}
+		if got := fmt.Sprint(value); got != probe.Expect {
+			return fmt.Errorf("expected %q, got %q", probe.Expect, got)
+		}
+	}
+
+	return nil
+}
+
+// probeCacheGet expects the key to exist, holding Expect when set
+func probeCacheGet(ctx context.Context, cache CacheAdapter, probe *cluster.ProbeConfig) error {
+	if probe.Expect == "" {
+		exists, err := cache.Exists(ctx, probe.Key)
+		if err != nil {
+			return fmt.Errorf("lookup failed: %w", err)
+		}
+		if !exists {
+			return fmt.Errorf("key %s does not exist", probe.Key)
+		}
+		return nil
+	}
+
+	value, err := cache.Get(ctx, probe.Key)
+	if err != nil {
+		return fmt.Errorf("failed to get key %s: %w", probe.Key, err)
+	}
+	if value != probe.Expect {
+		return fmt.Errorf("expected %q, got %q", probe.Expect, value)
+	}
+
+	return nil
+}
+
+// probeTopicExists expects the topic to be present on the broker
+func probeTopicExists(ctx context.Context, lister TopicLister, probe *cluster.ProbeConfig) error {
+	topics, err := lister.ListTopics(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list topics: %w", err)
+	}
+
+	for _, topic := range topics {
+		if topic == probe.Topic {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("topic %s does not exist", probe.Topic)
+}
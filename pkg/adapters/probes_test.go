@@ -0,0 +1,75 @@
+package adapters
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/akmadan/throome/pkg/cluster"
+)
+
+// fakeCache implements the parts of CacheAdapter used by probes
+type fakeCache struct {
+	CacheAdapter
+	values map[string]string
+}
+
+func (c *fakeCache) HealthCheck(ctx context.Context) (*HealthStatus, error) {
+	return &HealthStatus{Healthy: true, Reachable: true, LastChecked: time.Now()}, nil
+}
+
+func (c *fakeCache) Exists(ctx context.Context, key string) (bool, error) {
+	_, exists := c.values[key]
+	return exists, nil
+}
+
+func (c *fakeCache) Get(ctx context.Context, key string) (string, error) {
+	return c.values[key], nil
+}
+
+func TestCheckHealthWithProbes(t *testing.T) {
+	cache := &fakeCache{values: map[string]string{"config:version": "42"}}
+
+	status, err := CheckHealth(context.Background(), cache, []cluster.ProbeConfig{
+		{Name: "version", Type: "cache_get", Key: "config:version", Expect: "42"},
+		{Name: "warm", Type: "cache_get", Key: "config:version"},
+	}, time.Second)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !status.Healthy || !status.Reachable {
+		t.Errorf("Expected healthy and reachable status, got %+v", status)
+	}
+	if len(status.Probes) != 2 {
+		t.Fatalf("Expected 2 probe results, got %d", len(status.Probes))
+	}
+
+	status, err = CheckHealth(context.Background(), cache, []cluster.ProbeConfig{
+		{Name: "version", Type: "cache_get", Key: "config:version", Expect: "43"},
+		{Name: "seeded", Type: "cache_get", Key: "missing"},
+	}, time.Second)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if status.Healthy {
+		t.Error("Expected status to be unhealthy when probes fail")
+	}
+	if !status.Reachable {
+		t.Error("Expected failing probes not to affect reachability")
+	}
+	for _, result := range status.Probes {
+		if result.Healthy || result.Message == "" {
+			t.Errorf("Expected probe %s to fail with a message, got %+v", result.Name, result)
+		}
+	}
+	if status.ErrorMessage == "" {
+		t.Error("Expected an error message describing the failed probe")
+	}
+}
+
+func TestRunProbeWrongServiceType(t *testing.T) {
+	result := RunProbe(context.Background(), &fakeCache{}, &cluster.ProbeConfig{Name: "orders", Type: "topic_exists", Topic: "orders"}, time.Second)
+	if result.Healthy {
+		t.Error("Expected topic probe against a cache to fail")
+	}
+}
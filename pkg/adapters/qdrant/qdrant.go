@@ -0,0 +1,352 @@
+package qdrant
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/akmadan/throome/pkg/adapters"
+	"github.com/akmadan/throome/pkg/cluster"
+)
+
+const (
+	defaultLimit   = 10
+	requestTimeout = 30 * time.Second
+)
+
+// distances maps the metric names accepted by CreateCollection to Qdrant's
+var distances = map[string]string{
+	"cosine":    "Cosine",
+	"euclid":    "Euclid",
+	"dot":       "Dot",
+	"manhattan": "Manhattan",
+}
+
+// QdrantAdapter implements the VectorAdapter interface for Qdrant over its REST API.
+// An API key, when the server requires one, is configured as the service password.
+type QdrantAdapter struct {
+	*adapters.BaseAdapter
+	config *cluster.ServiceConfig
+	client *http.Client
+}
+
+// NewQdrantAdapter creates a new Qdrant adapter
+func NewQdrantAdapter(config *cluster.ServiceConfig) (adapters.Adapter, error) {
+	adapter := &QdrantAdapter{
+		BaseAdapter: adapters.NewBaseAdapter(config),
+		config:      config,
+	}
+	return adapter, nil
+}
+
+// Connect creates the HTTP client and verifies Qdrant is reachable
+func (q *QdrantAdapter) Connect(ctx context.Context) error {
+	tlsConfig, err := adapters.BuildTLSConfig(&q.config.TLS)
+	if err != nil {
+		return err
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	q.client = &http.Client{Transport: transport, Timeout: requestTimeout}
+
+	// Test connection
+	if err := q.Ping(ctx); err != nil {
+		return fmt.Errorf("failed to connect to Qdrant: %w", err)
+	}
+
+	q.SetConnected(true)
+	return nil
+}
+
+// Disconnect releases idle connections
+func (q *QdrantAdapter) Disconnect(ctx context.Context) error {
+	if q.client != nil {
+		q.client.CloseIdleConnections()
+		q.SetConnected(false)
+	}
+	return nil
+}
+
+// Ping checks if Qdrant is alive
+func (q *QdrantAdapter) Ping(ctx context.Context) error {
+	start := time.Now()
+	err := q.call(ctx, http.MethodGet, "/healthz", nil, nil)
+	q.RecordRequest(time.Since(start), err == nil)
+	return err
+}
+
+// HealthCheck performs a health check
+func (q *QdrantAdapter) HealthCheck(ctx context.Context) (*adapters.HealthStatus, error) {
+	start := time.Now()
+	err := q.Ping(ctx)
+	responseTime := time.Since(start)
+
+	status := &adapters.HealthStatus{
+		Healthy:      err == nil,
+		Reachable:    err == nil,
+		ResponseTime: responseTime,
+		LastChecked:  time.Now(),
+	}
+
+	if err != nil {
+		status.ErrorMessage = err.Error()
+	}
+
+	return status, nil
+}
+
+// CreateCollection creates a collection of vectors with the given dimension and distance
+func (q *QdrantAdapter) CreateCollection(ctx context.Context, name string, dimension int, distance string) error {
+	start := time.Now()
+	err := q.createCollection(ctx, name, dimension, distance)
+	duration := time.Since(start)
+	q.RecordRequest(duration, err == nil)
+
+	// Log activity
+	response := ""
+	if err == nil {
+		response = fmt.Sprintf("Collection '%s' created successfully", name)
+	}
+	q.LogActivity(ctx, "CREATE_COLLECTION", fmt.Sprintf("CREATE COLLECTION %s (size: %d, distance: %s)", name, dimension, distance), duration, err, response)
+
+	return err
+}
+
+func (q *QdrantAdapter) createCollection(ctx context.Context, name string, dimension int, distance string) error {
+	if dimension <= 0 {
+		return fmt.Errorf("dimension must be positive")
+	}
+	if distance == "" {
+		distance = "cosine"
+	}
+	metric, ok := distances[strings.ToLower(distance)]
+	if !ok {
+		return fmt.Errorf("unsupported distance: %s", distance)
+	}
+
+	body := map[string]interface{}{
+		"vectors": map[string]interface{}{"size": dimension, "distance": metric},
+	}
+	return q.call(ctx, http.MethodPut, collectionPath(name), body, nil)
+}
+
+// Upsert inserts or replaces points. IDs must be unsigned integers or UUIDs.
+func (q *QdrantAdapter) Upsert(ctx context.Context, collection string, points []*adapters.VectorPoint) error {
+	start := time.Now()
+	err := q.upsert(ctx, collection, points)
+	duration := time.Since(start)
+	q.RecordRequest(duration, err == nil)
+
+	// Log activity
+	response := ""
+	if err == nil {
+		response = fmt.Sprintf("%d points upserted", len(points))
+	}
+	q.LogActivity(ctx, "UPSERT", fmt.Sprintf("UPSERT INTO %s (%d points)", collection, len(points)), duration, err, response)
+
+	return err
+}
+
+func (q *QdrantAdapter) upsert(ctx context.Context, collection string, points []*adapters.VectorPoint) error {
+	body := make([]map[string]interface{}, 0, len(points))
+	for _, point := range points {
+		if point.ID == "" || len(point.Vector) == 0 {
+			return fmt.Errorf("every point needs an id and a vector")
+		}
+		body = append(body, map[string]interface{}{
+			"id":      pointID(point.ID),
+			"vector":  point.Vector,
+			"payload": point.Payload,
+		})
+	}
+
+	// wait=true makes the points searchable before the call returns
+	return q.call(ctx, http.MethodPut, collectionPath(collection)+"/points?wait=true", map[string]interface{}{"points": body}, nil)
+}
+
+// SearchNearest returns the points closest to the query vector, best match first
+func (q *QdrantAdapter) SearchNearest(ctx context.Context, collection string, query adapters.VectorQuery) ([]*adapters.VectorMatch, error) {
+	start := time.Now()
+	matches, err := q.searchNearest(ctx, collection, query)
+	duration := time.Since(start)
+	q.RecordRequest(duration, err == nil)
+
+	// Log activity
+	command := fmt.Sprintf("SEARCH %s (dimension: %d, limit: %d)", collection, len(query.Vector), searchLimit(query))
+	if len(query.Filter) > 0 {
+		filter, _ := json.Marshal(query.Filter)
+		command += fmt.Sprintf(" FILTER %s", filter)
+	}
+	response := ""
+	if err == nil {
+		response = fmt.Sprintf("%d matches", len(matches))
+	}
+	q.LogActivity(ctx, "SEARCH", command, duration, err, response)
+
+	return matches, err
+}
+
+func (q *QdrantAdapter) searchNearest(ctx context.Context, collection string, query adapters.VectorQuery) ([]*adapters.VectorMatch, error) {
+	if len(query.Vector) == 0 {
+		return nil, fmt.Errorf("query vector is required")
+	}
+
+	body := map[string]interface{}{
+		"vector":       query.Vector,
+		"limit":        searchLimit(query),
+		"with_payload": true,
+	}
+	if filter := buildFilter(query.Filter); filter != nil {
+		body["filter"] = filter
+	}
+	if query.ScoreThreshold != nil {
+		body["score_threshold"] = *query.ScoreThreshold
+	}
+
+	var results []struct {
+		ID      json.RawMessage        `json:"id"`
+		Score   float64                `json:"score"`
+		Payload map[string]interface{} `json:"payload"`
+	}
+	if err := q.call(ctx, http.MethodPost, collectionPath(collection)+"/points/search", body, &results); err != nil {
+		return nil, err
+	}
+
+	matches := make([]*adapters.VectorMatch, 0, len(results))
+	for _, result := range results {
+		matches = append(matches, &adapters.VectorMatch{
+			ID:      rawID(result.ID),
+			Score:   result.Score,
+			Payload: result.Payload,
+		})
+	}
+	return matches, nil
+}
+
+// call sends a request to the Qdrant API and decodes the "result" field of the
+// response into result, when given
+func (q *QdrantAdapter) call(ctx context.Context, method, path string, body interface{}, result interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, baseURL(q.config)+path, reader)
+	if err != nil {
+		return err
+	}
+	if q.config.Password != "" {
+		req.Header.Set("api-key", q.config.Password)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := q.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach Qdrant: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return responseError(resp)
+	}
+	if result == nil {
+		return nil
+	}
+
+	envelope := struct {
+		Result interface{} `json:"result"`
+	}{Result: result}
+	if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		return fmt.Errorf("invalid Qdrant response: %w", err)
+	}
+	return nil
+}
+
+// responseError builds an error from the status of a Qdrant error response
+func responseError(resp *http.Response) error {
+	var body struct {
+		Status struct {
+			Error string `json:"error"`
+		} `json:"status"`
+	}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if err := json.Unmarshal(data, &body); err == nil && body.Status.Error != "" {
+		return fmt.Errorf("qdrant returned %s: %s", resp.Status, body.Status.Error)
+	}
+	return fmt.Errorf("qdrant returned %s", resp.Status)
+}
+
+// buildFilter turns exact payload matches into a Qdrant filter. Conditions are
+// sorted by key so identical filters serialize identically.
+func buildFilter(fields map[string]interface{}) map[string]interface{} {
+	if len(fields) == 0 {
+		return nil
+	}
+
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	must := make([]map[string]interface{}, 0, len(keys))
+	for _, key := range keys {
+		must = append(must, map[string]interface{}{
+			"key":   key,
+			"match": map[string]interface{}{"value": fields[key]},
+		})
+	}
+	return map[string]interface{}{"must": must}
+}
+
+// pointID converts an ID to the form Qdrant expects: a number for unsigned
+// integers and a string for UUIDs
+func pointID(id string) interface{} {
+	if number, err := strconv.ParseUint(id, 10, 64); err == nil {
+		return number
+	}
+	return id
+}
+
+// rawID converts a numeric or UUID point ID from a response to a string
+func rawID(raw json.RawMessage) string {
+	var id string
+	if err := json.Unmarshal(raw, &id); err == nil {
+		return id
+	}
+	return string(raw)
+}
+
+func searchLimit(query adapters.VectorQuery) int {
+	if query.Limit > 0 {
+		return query.Limit
+	}
+	return defaultLimit
+}
+
+func collectionPath(name string) string {
+	return "/collections/" + url.PathEscape(name)
+}
+
+func baseURL(config *cluster.ServiceConfig) string {
+	scheme := "http"
+	if config.TLS.Enabled {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s:%d", scheme, config.Host, config.Port)
+}
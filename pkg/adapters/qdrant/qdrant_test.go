@@ -0,0 +1,110 @@
+package qdrant
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/akmadan/throome/pkg/adapters"
+	"github.com/akmadan/throome/pkg/cluster"
+)
+
+func TestBuildFilter(t *testing.T) {
+	if buildFilter(nil) != nil {
+		t.Error("Expected no filter without fields")
+	}
+
+	filter := buildFilter(map[string]interface{}{"tenant": "acme", "lang": "en"})
+	expected := map[string]interface{}{
+		"must": []map[string]interface{}{
+			{"key": "lang", "match": map[string]interface{}{"value": "en"}},
+			{"key": "tenant", "match": map[string]interface{}{"value": "acme"}},
+		},
+	}
+	if !reflect.DeepEqual(filter, expected) {
+		t.Errorf("Unexpected filter: %v", filter)
+	}
+}
+
+func TestPointIDs(t *testing.T) {
+	if id := pointID("42"); id != uint64(42) {
+		t.Errorf("Expected numeric IDs to be sent as numbers, got %T", id)
+	}
+	uuid := "5c56c793-69f3-4fbf-87e6-c4bf54c28c26"
+	if id := pointID(uuid); id != uuid {
+		t.Errorf("Expected UUIDs to be sent as strings, got %v", id)
+	}
+
+	if id := rawID(json.RawMessage(`42`)); id != "42" {
+		t.Errorf("Unexpected numeric ID: %s", id)
+	}
+	if id := rawID(json.RawMessage(`"` + uuid + `"`)); id != uuid {
+		t.Errorf("Unexpected UUID: %s", id)
+	}
+}
+
+func TestUpsertAndSearch(t *testing.T) {
+	var upserted map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("api-key") != "secret" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		switch {
+		case r.URL.Path == "/healthz":
+			w.WriteHeader(http.StatusOK)
+		case r.Method == http.MethodPut && r.URL.Path == "/collections/docs/points":
+			if r.URL.Query().Get("wait") != "true" {
+				t.Error("Expected upserts to wait for indexing")
+			}
+			_ = json.NewDecoder(r.Body).Decode(&upserted)
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"result": map[string]string{"status": "completed"}})
+		case r.Method == http.MethodPost && r.URL.Path == "/collections/docs/points/search":
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"result": []map[string]interface{}{
+					{"id": 1, "score": 0.98, "payload": map[string]string{"title": "first"}},
+				},
+			})
+		case r.Method == http.MethodPut && r.URL.Path == "/collections/missing/points":
+			w.WriteHeader(http.StatusNotFound)
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"status": map[string]string{"error": "Collection `missing` doesn't exist!"}})
+		}
+	}))
+	defer server.Close()
+
+	host, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+	portNumber, _ := strconv.Atoi(port)
+	adapter, _ := NewQdrantAdapter(&cluster.ServiceConfig{Type: "qdrant", Host: host, Port: portNumber, Password: "secret"})
+	q := adapter.(*QdrantAdapter)
+
+	ctx := context.Background()
+	if err := q.Connect(ctx); err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+
+	points := []*adapters.VectorPoint{{ID: "1", Vector: []float32{0.1, 0.2}, Payload: map[string]interface{}{"title": "first"}}}
+	if err := q.Upsert(ctx, "docs", points); err != nil {
+		t.Fatalf("Failed to upsert: %v", err)
+	}
+	if sent := upserted["points"].([]interface{})[0].(map[string]interface{}); sent["id"] != float64(1) {
+		t.Errorf("Expected the ID to be sent as a number, got %v", sent["id"])
+	}
+
+	matches, err := q.SearchNearest(ctx, "docs", adapters.VectorQuery{Vector: []float32{0.1, 0.2}, Limit: 1})
+	if err != nil {
+		t.Fatalf("Failed to search: %v", err)
+	}
+	if len(matches) != 1 || matches[0].ID != "1" || matches[0].Payload["title"] != "first" {
+		t.Errorf("Unexpected matches: %+v", matches)
+	}
+
+	if err := q.Upsert(ctx, "missing", points); err == nil {
+		t.Error("Expected upserting into a missing collection to fail")
+	}
+}
@@ -0,0 +1,491 @@
+package rabbitmq
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"github.com/akmadan/throome/pkg/adapters"
+	"github.com/akmadan/throome/pkg/cluster"
+)
+
+const (
+	defaultUsername       = "guest"
+	defaultPassword       = "guest"
+	defaultManagementPort = 15672
+	defaultExchangeType   = amqp.ExchangeFanout
+)
+
+// RabbitMQAdapter implements the QueueAdapter interface for RabbitMQ. A topic is a
+// durable exchange of the same name with a durable queue bound to it, so messages
+// published before a consumer subscribes are kept until they are consumed.
+type RabbitMQAdapter struct {
+	*adapters.BaseAdapter
+	config *cluster.ServiceConfig
+	conn   *amqp.Connection
+
+	publishMu sync.Mutex // amqp channels must not be used concurrently
+	publisher *amqp.Channel
+	declared  map[string]bool // Topics whose topology was declared by this adapter
+
+	mu        sync.Mutex
+	consumers map[string]*amqp.Channel // Topic -> consuming channel
+}
+
+// NewRabbitMQAdapter creates a new RabbitMQ adapter
+func NewRabbitMQAdapter(config *cluster.ServiceConfig) (adapters.Adapter, error) {
+	adapter := &RabbitMQAdapter{
+		BaseAdapter: adapters.NewBaseAdapter(config),
+		config:      config,
+		declared:    make(map[string]bool),
+		consumers:   make(map[string]*amqp.Channel),
+	}
+	return adapter, nil
+}
+
+// Connect establishes a connection to RabbitMQ
+func (r *RabbitMQAdapter) Connect(ctx context.Context) error {
+	conn, err := amqp.DialConfig(connectionURL(r.config), amqp.Config{
+		Dial: amqp.DefaultDial(10 * time.Second),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to connect to RabbitMQ: %w", err)
+	}
+
+	publisher, err := conn.Channel()
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to open RabbitMQ channel: %w", err)
+	}
+
+	r.conn = conn
+	r.publisher = publisher
+	r.SetConnected(true)
+	return nil
+}
+
+// Disconnect closes the RabbitMQ connection
+func (r *RabbitMQAdapter) Disconnect(ctx context.Context) error {
+	r.mu.Lock()
+	for topic, channel := range r.consumers {
+		_ = channel.Close()
+		delete(r.consumers, topic)
+	}
+	r.mu.Unlock()
+
+	if r.conn != nil {
+		err := r.conn.Close()
+		r.SetConnected(false)
+		return err
+	}
+	return nil
+}
+
+// Ping checks if the RabbitMQ connection is alive
+func (r *RabbitMQAdapter) Ping(ctx context.Context) error {
+	start := time.Now()
+	var err error
+	if r.conn == nil || r.conn.IsClosed() {
+		err = fmt.Errorf("connection to RabbitMQ is closed")
+	}
+	r.RecordRequest(time.Since(start), err == nil)
+	return err
+}
+
+// HealthCheck performs a health check
+func (r *RabbitMQAdapter) HealthCheck(ctx context.Context) (*adapters.HealthStatus, error) {
+	start := time.Now()
+	err := r.Ping(ctx)
+	responseTime := time.Since(start)
+
+	status := &adapters.HealthStatus{
+		Healthy:      err == nil,
+		Reachable:    err == nil,
+		ResponseTime: responseTime,
+		LastChecked:  time.Now(),
+	}
+
+	if err != nil {
+		status.ErrorMessage = err.Error()
+	}
+
+	return status, nil
+}
+
+// Publish publishes a message to a topic, declaring the topic if needed
+func (r *RabbitMQAdapter) Publish(ctx context.Context, topic string, message []byte) error {
+	return r.publish(ctx, topic, "", message)
+}
+
+// PublishWithKey publishes a message with a routing key
+func (r *RabbitMQAdapter) PublishWithKey(ctx context.Context, topic string, key, message []byte) error {
+	return r.publish(ctx, topic, string(key), message)
+}
+
+func (r *RabbitMQAdapter) publish(ctx context.Context, topic, routingKey string, message []byte) error {
+	start := time.Now()
+
+	r.publishMu.Lock()
+	err := r.declareLocked(topic, nil)
+	if err == nil {
+		err = r.publisher.PublishWithContext(ctx, topic, routingKey, false, false, amqp.Publishing{
+			ContentType:  "application/octet-stream",
+			DeliveryMode: amqp.Persistent,
+			Timestamp:    time.Now(),
+			Body:         message,
+		})
+	}
+	r.publishMu.Unlock()
+
+	duration := time.Since(start)
+	r.RecordRequest(duration, err == nil)
+
+	// Log activity
+	command := fmt.Sprintf("PUBLISH to exchange '%s' (%d bytes)", topic, len(message))
+	if routingKey != "" {
+		command = fmt.Sprintf("PUBLISH to exchange '%s' with routing key '%s' (%d bytes)", topic, routingKey, len(message))
+	}
+	response := ""
+	if err == nil {
+		response = "Message published successfully"
+	}
+	r.LogActivity(ctx, "PUBLISH", command, duration, err, response)
+
+	return err
+}
+
+// Subscribe consumes the topic's queue. Messages are acknowledged once the handler
+// succeeds; failed messages are rejected without requeueing so a dead-letter
+// exchange configured on the broker receives them.
+func (r *RabbitMQAdapter) Subscribe(ctx context.Context, topic string, handler adapters.MessageHandler) error {
+	start := time.Now()
+	command := fmt.Sprintf("SUBSCRIBE to queue '%s'", topic)
+
+	deliveries, err := r.subscribe(topic)
+	if err != nil {
+		r.LogActivity(ctx, "SUBSCRIBE", command, time.Since(start), err, "")
+		return err
+	}
+
+	go func() {
+		for delivery := range deliveries {
+			if err := handler(ctx, toMessage(topic, &delivery)); err != nil {
+				_ = delivery.Nack(false, false)
+				continue
+			}
+			_ = delivery.Ack(false)
+		}
+	}()
+
+	response := fmt.Sprintf("Successfully subscribed to topic '%s'", topic)
+	r.LogActivity(ctx, "SUBSCRIBE", command, time.Since(start), nil, response)
+
+	return nil
+}
+
+func (r *RabbitMQAdapter) subscribe(topic string) (<-chan amqp.Delivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.consumers[topic]; exists {
+		return nil, fmt.Errorf("already subscribed to topic: %s", topic)
+	}
+
+	r.publishMu.Lock()
+	err := r.declareLocked(topic, nil)
+	r.publishMu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	channel, err := r.conn.Channel()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open RabbitMQ channel: %w", err)
+	}
+
+	deliveries, err := channel.Consume(topic, "throome-gateway", false, false, false, false, nil)
+	if err != nil {
+		channel.Close()
+		return nil, err
+	}
+
+	r.consumers[topic] = channel
+	return deliveries, nil
+}
+
+// Unsubscribe stops consuming a topic
+func (r *RabbitMQAdapter) Unsubscribe(ctx context.Context, topic string) error {
+	start := time.Now()
+
+	r.mu.Lock()
+	channel, exists := r.consumers[topic]
+	delete(r.consumers, topic)
+	r.mu.Unlock()
+
+	var err error
+	if exists {
+		// Closing the channel ends the deliveries and stops the consumer goroutine
+		err = channel.Close()
+	}
+
+	duration := time.Since(start)
+	command := fmt.Sprintf("UNSUBSCRIBE from queue '%s'", topic)
+	response := ""
+	if err == nil {
+		response = fmt.Sprintf("Successfully unsubscribed from topic '%s'", topic)
+	}
+	r.LogActivity(ctx, "UNSUBSCRIBE", command, duration, err, response)
+
+	return err
+}
+
+// CreateTopic declares an exchange and a queue bound to it. The exchange type can be
+// set with the "exchange_type" key (fanout, direct, topic or headers) and the routing
+// key used for the binding with "binding_key".
+func (r *RabbitMQAdapter) CreateTopic(ctx context.Context, topic string, config map[string]interface{}) error {
+	start := time.Now()
+
+	r.publishMu.Lock()
+	delete(r.declared, topic) // Always declare so invalid configs are reported
+	err := r.declareLocked(topic, config)
+	r.publishMu.Unlock()
+
+	duration := time.Since(start)
+	r.RecordRequest(duration, err == nil)
+
+	// Log activity
+	command := fmt.Sprintf("DECLARE EXCHANGE '%s' (type: %s) and QUEUE '%s'", topic, exchangeType(config), topic)
+	response := ""
+	if err == nil {
+		response = fmt.Sprintf("Topic '%s' created successfully", topic)
+	}
+	r.LogActivity(ctx, "CREATE_TOPIC", command, duration, err, response)
+
+	return err
+}
+
+// declareLocked declares a topic's exchange, queue and binding. The caller must hold publishMu.
+func (r *RabbitMQAdapter) declareLocked(topic string, config map[string]interface{}) error {
+	if r.declared[topic] {
+		return nil
+	}
+
+	// Implicit declarations keep existing exchanges as they are, whatever their type
+	if config == nil {
+		if err := r.publisher.ExchangeDeclarePassive(topic, defaultExchangeType, true, false, false, false, nil); err == nil {
+			r.declared[topic] = true
+			return nil
+		}
+		// The broker closes the channel when the exchange does not exist
+		if err := r.reopenPublisher(); err != nil {
+			return err
+		}
+	}
+
+	if err := r.publisher.ExchangeDeclare(topic, exchangeType(config), true, false, false, false, nil); err != nil {
+		return r.resetPublisher(fmt.Errorf("failed to declare exchange %s: %w", topic, err))
+	}
+	if _, err := r.publisher.QueueDeclare(topic, true, false, false, false, nil); err != nil {
+		return r.resetPublisher(fmt.Errorf("failed to declare queue %s: %w", topic, err))
+	}
+
+	bindingKey, _ := config["binding_key"].(string)
+	if bindingKey == "" && exchangeType(config) == amqp.ExchangeTopic {
+		bindingKey = "#"
+	}
+	if err := r.publisher.QueueBind(topic, bindingKey, topic, false, nil); err != nil {
+		return r.resetPublisher(fmt.Errorf("failed to bind queue %s: %w", topic, err))
+	}
+
+	r.declared[topic] = true
+	return nil
+}
+
+// resetPublisher reopens the publishing channel after a failed operation and returns
+// the operation's error. The caller must hold publishMu.
+func (r *RabbitMQAdapter) resetPublisher(err error) error {
+	_ = r.reopenPublisher()
+	return err
+}
+
+// reopenPublisher reopens the publishing channel if the broker closed it, which it
+// does on any channel-level error. The caller must hold publishMu.
+func (r *RabbitMQAdapter) reopenPublisher() error {
+	if !r.publisher.IsClosed() {
+		return nil
+	}
+	publisher, err := r.conn.Channel()
+	if err != nil {
+		return fmt.Errorf("failed to reopen RabbitMQ channel: %w", err)
+	}
+	r.publisher = publisher
+	return nil
+}
+
+// DeleteTopic deletes a topic's queue and exchange
+func (r *RabbitMQAdapter) DeleteTopic(ctx context.Context, topic string) error {
+	start := time.Now()
+
+	r.publishMu.Lock()
+	_, err := r.publisher.QueueDelete(topic, false, false, false)
+	if err == nil {
+		err = r.publisher.ExchangeDelete(topic, false, false)
+	}
+	if err != nil {
+		err = r.resetPublisher(err)
+	}
+	delete(r.declared, topic)
+	r.publishMu.Unlock()
+
+	duration := time.Since(start)
+	r.RecordRequest(duration, err == nil)
+
+	// Log activity
+	command := fmt.Sprintf("DELETE QUEUE '%s' and EXCHANGE '%s'", topic, topic)
+	response := ""
+	if err == nil {
+		response = fmt.Sprintf("Topic '%s' deleted successfully", topic)
+	}
+	r.LogActivity(ctx, "DELETE_TOPIC", command, duration, err, response)
+
+	return err
+}
+
+// ListTopics lists the exchanges of the virtual host. AMQP has no way to list
+// exchanges, so this uses the management API.
+func (r *RabbitMQAdapter) ListTopics(ctx context.Context) ([]string, error) {
+	start := time.Now()
+	topics, err := r.listExchanges(ctx)
+	duration := time.Since(start)
+	r.RecordRequest(duration, err == nil)
+
+	// Log activity
+	response := ""
+	if err == nil {
+		response = fmt.Sprintf("Found %d topics", len(topics))
+	}
+	r.LogActivity(ctx, "LIST_TOPICS", "LIST EXCHANGES", duration, err, response)
+
+	return topics, err
+}
+
+func (r *RabbitMQAdapter) listExchanges(ctx context.Context) ([]string, error) {
+	endpoint := fmt.Sprintf("%s/api/exchanges/%s", managementURL(r.config), url.PathEscape(virtualHost(r.config)))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	username, password := credentials(r.config)
+	req.SetBasicAuth(username, password)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach RabbitMQ management API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("RabbitMQ management API returned %s", resp.Status)
+	}
+
+	var exchanges []struct {
+		Name string `json:"name"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&exchanges); err != nil {
+		return nil, fmt.Errorf("invalid management API response: %w", err)
+	}
+
+	topics := make([]string, 0, len(exchanges))
+	for _, exchange := range exchanges {
+		// Skip the default and built-in exchanges
+		if exchange.Name == "" || strings.HasPrefix(exchange.Name, "amq.") {
+			continue
+		}
+		topics = append(topics, exchange.Name)
+	}
+	sort.Strings(topics)
+
+	return topics, nil
+}
+
+// GetConnection returns the underlying AMQP connection for advanced operations
+func (r *RabbitMQAdapter) GetConnection() *amqp.Connection {
+	return r.conn
+}
+
+func toMessage(topic string, delivery *amqp.Delivery) *adapters.Message {
+	headers := make(map[string]string, len(delivery.Headers))
+	for key, value := range delivery.Headers {
+		headers[key] = fmt.Sprintf("%v", value)
+	}
+
+	return &adapters.Message{
+		Topic:     topic,
+		Key:       []byte(delivery.RoutingKey),
+		Value:     delivery.Body,
+		Headers:   headers,
+		Timestamp: delivery.Timestamp,
+		Offset:    int64(delivery.DeliveryTag),
+	}
+}
+
+func credentials(config *cluster.ServiceConfig) (string, string) {
+	if config.Username == "" {
+		return defaultUsername, defaultPassword
+	}
+	return config.Username, config.Password
+}
+
+// virtualHost returns the service's virtual host, taken from the database field
+func virtualHost(config *cluster.ServiceConfig) string {
+	if config.Database == "" {
+		return "/"
+	}
+	return config.Database
+}
+
+// connectionURL builds the AMQP URL for a service
+func connectionURL(config *cluster.ServiceConfig) string {
+	username, password := credentials(config)
+	scheme := "amqp"
+	if config.TLS.Enabled {
+		scheme = "amqps"
+	}
+
+	vhost := virtualHost(config)
+	uri := url.URL{
+		Scheme:  scheme,
+		User:    url.UserPassword(username, password),
+		Host:    fmt.Sprintf("%s:%d", config.Host, config.Port),
+		Path:    "/" + vhost,
+		RawPath: "/" + url.PathEscape(vhost), // The default virtual host "/" must be sent as %2F
+	}
+	return uri.String()
+}
+
+// managementURL returns the base URL of the service's management API
+func managementURL(config *cluster.ServiceConfig) string {
+	port := defaultManagementPort
+	switch value := config.Options["management_port"].(type) {
+	case int:
+		port = value
+	case float64:
+		port = int(value)
+	}
+	return fmt.Sprintf("http://%s:%d", config.Host, port)
+}
+
+func exchangeType(config map[string]interface{}) string {
+	if kind, ok := config["exchange_type"].(string); ok && kind != "" {
+		return kind
+	}
+	return defaultExchangeType
+}
@@ -0,0 +1,93 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// KeyDump is a key serialized with DUMP, restorable on any Redis server of the same
+// or a newer version
+type KeyDump struct {
+	Key   string        `json:"key"`
+	Value []byte        `json:"value"`         // DUMP payload
+	TTL   time.Duration `json:"ttl,omitempty"` // Zero when the key does not expire
+}
+
+// DumpKeys serializes every key of the current database
+func (r *RedisAdapter) DumpKeys(ctx context.Context) ([]KeyDump, error) {
+	start := time.Now()
+	dumps, err := r.dumpKeys(ctx)
+	duration := time.Since(start)
+	r.RecordRequest(duration, err == nil)
+
+	// Log activity
+	response := ""
+	if err == nil {
+		response = fmt.Sprintf("%d keys dumped", len(dumps))
+	}
+	r.LogActivity(ctx, "DUMP", "SCAN 0 MATCH * + DUMP", duration, err, response)
+
+	return dumps, err
+}
+
+func (r *RedisAdapter) dumpKeys(ctx context.Context) ([]KeyDump, error) {
+	dumps := make([]KeyDump, 0)
+
+	var cursor uint64
+	for {
+		keys, next, err := r.client.Scan(ctx, cursor, "*", 1000).Result()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, key := range keys {
+			value, err := r.client.Dump(ctx, key).Result()
+			if err == redis.Nil {
+				continue // Expired or deleted since the scan
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to dump key %s: %w", key, err)
+			}
+
+			ttl, err := r.client.PTTL(ctx, key).Result()
+			if err != nil {
+				return nil, fmt.Errorf("failed to read TTL of key %s: %w", key, err)
+			}
+			if ttl < 0 {
+				ttl = 0
+			}
+
+			dumps = append(dumps, KeyDump{Key: key, Value: []byte(value), TTL: ttl})
+		}
+
+		cursor = next
+		if cursor == 0 {
+			return dumps, nil
+		}
+	}
+}
+
+// RestoreKeys restores dumped keys, replacing existing keys with the same name
+func (r *RedisAdapter) RestoreKeys(ctx context.Context, dumps []KeyDump) error {
+	start := time.Now()
+	_, err := r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, dump := range dumps {
+			pipe.RestoreReplace(ctx, dump.Key, dump.TTL, string(dump.Value))
+		}
+		return nil
+	})
+	duration := time.Since(start)
+	r.RecordRequest(duration, err == nil)
+
+	// Log activity
+	response := ""
+	if err == nil {
+		response = fmt.Sprintf("%d keys restored", len(dumps))
+	}
+	r.LogActivity(ctx, "RESTORE", fmt.Sprintf("RESTORE (%d keys) REPLACE", len(dumps)), duration, err, response)
+
+	return err
+}
@@ -0,0 +1,138 @@
+package redis
+
+import (
+	"bufio"
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/akmadan/throome/pkg/adapters"
+)
+
+// Info returns the raw INFO output of the Redis server, parsed into key/value pairs
+// per section
+func (r *RedisAdapter) Info(ctx context.Context) (map[string]map[string]string, error) {
+	start := time.Now()
+	raw, err := r.client.Info(ctx, "all").Result()
+	duration := time.Since(start)
+	r.RecordRequest(duration, err == nil)
+
+	// Log activity
+	response := ""
+	if err == nil {
+		response = "Server statistics retrieved"
+	}
+	r.LogActivity(ctx, "INFO", "INFO all", duration, err, response)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return parseInfo(raw), nil
+}
+
+// CacheStats returns hit/miss, memory, eviction, client and keyspace statistics.
+// It is polled on the monitoring interval, so unlike Info it is not recorded as a
+// request or logged as activity.
+func (r *RedisAdapter) CacheStats(ctx context.Context) (*adapters.CacheStats, error) {
+	raw, err := r.client.Info(ctx, "all").Result()
+	if err != nil {
+		return nil, err
+	}
+
+	return cacheStatsFromInfo(parseInfo(raw), time.Now()), nil
+}
+
+// parseInfo splits INFO output into sections of key/value pairs. Section names are
+// lowercased, e.g. "# Keyspace" becomes "keyspace".
+func parseInfo(raw string) map[string]map[string]string {
+	sections := make(map[string]map[string]string)
+	current := make(map[string]string)
+	sections["default"] = current
+
+	scanner := bufio.NewScanner(strings.NewReader(raw))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "#") {
+			name := strings.ToLower(strings.TrimSpace(strings.TrimPrefix(line, "#")))
+			current = make(map[string]string)
+			sections[name] = current
+			continue
+		}
+
+		key, value, found := strings.Cut(line, ":")
+		if found {
+			current[key] = value
+		}
+	}
+
+	return sections
+}
+
+// cacheStatsFromInfo extracts cache statistics from parsed INFO sections
+func cacheStatsFromInfo(info map[string]map[string]string, now time.Time) *adapters.CacheStats {
+	// Look keys up regardless of the section they are reported in
+	values := make(map[string]string)
+	for name, section := range info {
+		if name == "keyspace" {
+			continue
+		}
+		for key, value := range section {
+			values[key] = value
+		}
+	}
+
+	stats := &adapters.CacheStats{
+		Hits:             parseInt(values["keyspace_hits"]),
+		Misses:           parseInt(values["keyspace_misses"]),
+		UsedMemory:       parseInt(values["used_memory"]),
+		PeakMemory:       parseInt(values["used_memory_peak"]),
+		MaxMemory:        parseInt(values["maxmemory"]),
+		Evictions:        parseInt(values["evicted_keys"]),
+		ExpiredKeys:      parseInt(values["expired_keys"]),
+		ConnectedClients: int(parseInt(values["connected_clients"])),
+		BlockedClients:   int(parseInt(values["blocked_clients"])),
+		OpsPerSecond:     parseInt(values["instantaneous_ops_per_sec"]),
+		Uptime:           time.Duration(parseInt(values["uptime_in_seconds"])) * time.Second,
+		Version:          values["redis_version"],
+		Keyspace:         make(map[string]adapters.KeyspaceStats),
+		CollectedAt:      now,
+	}
+
+	if lookups := stats.Hits + stats.Misses; lookups > 0 {
+		stats.HitRatio = float64(stats.Hits) / float64(lookups) * 100
+	}
+
+	// Keyspace lines look like: db0:keys=12,expires=3,avg_ttl=5000
+	for db, value := range info["keyspace"] {
+		var keyspace adapters.KeyspaceStats
+		for _, field := range strings.Split(value, ",") {
+			name, number, _ := strings.Cut(field, "=")
+			switch name {
+			case "keys":
+				keyspace.Keys = parseInt(number)
+			case "expires":
+				keyspace.Expires = parseInt(number)
+			case "avg_ttl":
+				keyspace.AvgTTL = parseInt(number)
+			}
+		}
+		stats.Keyspace[db] = keyspace
+	}
+
+	return stats
+}
+
+// parseInt parses an INFO number, returning 0 for missing or malformed values
+func parseInt(value string) int64 {
+	number, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0
+	}
+	return number
+}
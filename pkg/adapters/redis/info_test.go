@@ -0,0 +1,79 @@
+package redis
+
+import (
+	"testing"
+	"time"
+)
+
+const sampleInfo = "# Server\r\n" +
+	"redis_version:7.2.4\r\n" +
+	"uptime_in_seconds:3600\r\n" +
+	"\r\n" +
+	"# Clients\r\n" +
+	"connected_clients:5\r\n" +
+	"blocked_clients:1\r\n" +
+	"\r\n" +
+	"# Memory\r\n" +
+	"used_memory:1048576\r\n" +
+	"used_memory_peak:2097152\r\n" +
+	"maxmemory:0\r\n" +
+	"\r\n" +
+	"# Stats\r\n" +
+	"instantaneous_ops_per_sec:42\r\n" +
+	"expired_keys:7\r\n" +
+	"evicted_keys:3\r\n" +
+	"keyspace_hits:90\r\n" +
+	"keyspace_misses:10\r\n" +
+	"\r\n" +
+	"# Keyspace\r\n" +
+	"db0:keys=12,expires=4,avg_ttl=5000\r\n" +
+	"db2:keys=1,expires=0,avg_ttl=0\r\n"
+
+func TestCacheStatsFromInfo(t *testing.T) {
+	now := time.Now()
+	stats := cacheStatsFromInfo(parseInfo(sampleInfo), now)
+
+	if stats.Hits != 90 || stats.Misses != 10 {
+		t.Errorf("Expected 90 hits and 10 misses, got %d and %d", stats.Hits, stats.Misses)
+	}
+	if stats.HitRatio != 90 {
+		t.Errorf("Expected hit ratio 90, got %v", stats.HitRatio)
+	}
+	if stats.UsedMemory != 1048576 || stats.PeakMemory != 2097152 {
+		t.Errorf("Unexpected memory usage: used %d, peak %d", stats.UsedMemory, stats.PeakMemory)
+	}
+	if stats.Evictions != 3 || stats.ExpiredKeys != 7 {
+		t.Errorf("Expected 3 evictions and 7 expired keys, got %d and %d", stats.Evictions, stats.ExpiredKeys)
+	}
+	if stats.ConnectedClients != 5 || stats.BlockedClients != 1 {
+		t.Errorf("Expected 5 connected and 1 blocked client, got %d and %d", stats.ConnectedClients, stats.BlockedClients)
+	}
+	if stats.Uptime != time.Hour {
+		t.Errorf("Expected uptime of 1h, got %s", stats.Uptime)
+	}
+	if stats.Version != "7.2.4" {
+		t.Errorf("Expected version 7.2.4, got '%s'", stats.Version)
+	}
+
+	db0, exists := stats.Keyspace["db0"]
+	if !exists {
+		t.Fatal("Expected keyspace stats for db0")
+	}
+	if db0.Keys != 12 || db0.Expires != 4 || db0.AvgTTL != 5000 {
+		t.Errorf("Unexpected db0 keyspace stats: %+v", db0)
+	}
+	if len(stats.Keyspace) != 2 {
+		t.Errorf("Expected 2 keyspace databases, got %d", len(stats.Keyspace))
+	}
+}
+
+func TestCacheStatsFromInfoWithoutLookups(t *testing.T) {
+	stats := cacheStatsFromInfo(parseInfo("# Stats\r\nkeyspace_hits:0\r\nkeyspace_misses:0\r\n"), time.Now())
+
+	if stats.HitRatio != 0 {
+		t.Errorf("Expected hit ratio 0 without lookups, got %v", stats.HitRatio)
+	}
+	if len(stats.Keyspace) != 0 {
+		t.Errorf("Expected empty keyspace, got %v", stats.Keyspace)
+	}
+}
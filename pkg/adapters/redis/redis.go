@@ -2,7 +2,10 @@ package redis
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/go-redis/redis/v8"
@@ -11,60 +14,88 @@ import (
 	"github.com/akmadan/throome/pkg/cluster"
 )
 
-// RedisAdapter implements the CacheAdapter interface for Redis
+// RedisAdapter implements the CacheAdapter interface for Redis, and the QueueAdapter
+// interface on top of Redis Streams
 type RedisAdapter struct {
 	*adapters.BaseAdapter
-	config *cluster.ServiceConfig
-	client *redis.Client
+	config        *cluster.ServiceConfig
+	client        *redis.Client
+	consumer      string
+	mu            sync.Mutex
+	subscriptions map[string]context.CancelFunc // topic -> stops its consumer
 }
 
 // NewRedisAdapter creates a new Redis adapter
 func NewRedisAdapter(config *cluster.ServiceConfig) (adapters.Adapter, error) {
 	adapter := &RedisAdapter{
-		BaseAdapter: adapters.NewBaseAdapter(config),
-		config:      config,
+		BaseAdapter:   adapters.NewBaseAdapter(config),
+		config:        config,
+		consumer:      consumerName(config.Options),
+		subscriptions: make(map[string]context.CancelFunc),
 	}
+	adapter.SetErrorClassifier(classifyError)
 	return adapter, nil
 }
 
 // Connect establishes a connection to Redis
 func (r *RedisAdapter) Connect(ctx context.Context) error {
+	options, err := clientOptions(r.config)
+	if err != nil {
+		return err
+	}
+
+	r.client = redis.NewClient(options)
+
+	// Test connection
+	if err := r.Ping(ctx); err != nil {
+		return fmt.Errorf("failed to connect to Redis: %w", err)
+	}
+
+	r.SetConnected(true)
+	return nil
+}
+
+// clientOptions builds the Redis client options for a service
+func clientOptions(config *cluster.ServiceConfig) (*redis.Options, error) {
 	options := &redis.Options{
-		Addr:     fmt.Sprintf("%s:%d", r.config.Host, r.config.Port),
-		Password: r.config.Password,
+		Addr:     fmt.Sprintf("%s:%d", config.Host, config.Port),
+		Password: config.Password,
 		DB:       0, // default DB
 	}
 
 	// Get DB from options if specified
-	if db, ok := r.config.Options["db"].(int); ok {
+	if db, ok := config.Options["db"].(int); ok {
 		options.DB = db
 	}
 
 	// Configure pool
-	if r.config.Pool.MaxConnections > 0 {
-		options.PoolSize = r.config.Pool.MaxConnections
+	if config.Pool.MaxConnections > 0 {
+		options.PoolSize = config.Pool.MaxConnections
 	}
-	if r.config.Pool.MinConnections > 0 {
-		options.MinIdleConns = r.config.Pool.MinConnections
+	if config.Pool.MinConnections > 0 {
+		options.MinIdleConns = config.Pool.MinConnections
 	}
-	if r.config.Pool.MaxIdleTime > 0 {
-		options.IdleTimeout = time.Duration(r.config.Pool.MaxIdleTime) * time.Second
+	if config.Pool.MaxIdleTime > 0 {
+		options.IdleTimeout = time.Duration(config.Pool.MaxIdleTime) * time.Second
 	}
 
-	r.client = redis.NewClient(options)
-
-	// Test connection
-	if err := r.Ping(ctx); err != nil {
-		return fmt.Errorf("failed to connect to Redis: %w", err)
+	// Configure TLS, required by most managed Redis offerings
+	tlsConfig, err := adapters.BuildTLSConfig(&config.TLS)
+	if err != nil {
+		return nil, err
 	}
+	if tlsConfig != nil && tlsConfig.ServerName == "" {
+		tlsConfig.ServerName = config.Host
+	}
+	options.TLSConfig = tlsConfig
 
-	r.SetConnected(true)
-	return nil
+	return options, nil
 }
 
 // Disconnect closes the Redis connection
 func (r *RedisAdapter) Disconnect(ctx context.Context) error {
 	if r.client != nil {
+		r.stopSubscriptions()
 		err := r.client.Close()
 		r.SetConnected(false)
 		return err
@@ -84,7 +115,7 @@ func (r *RedisAdapter) Ping(ctx context.Context) error {
 	if err != nil {
 		response = ""
 	}
-	r.LogActivity("PING", "PING", duration, err, response)
+	r.LogActivity(ctx, "PING", "PING", duration, err, response)
 
 	return err
 }
@@ -97,6 +128,7 @@ func (r *RedisAdapter) HealthCheck(ctx context.Context) (*adapters.HealthStatus,
 
 	status := &adapters.HealthStatus{
 		Healthy:      err == nil,
+		Reachable:    err == nil,
 		ResponseTime: responseTime,
 		LastChecked:  time.Now(),
 	}
@@ -121,7 +153,7 @@ func (r *RedisAdapter) Get(ctx context.Context, key string) (string, error) {
 		response = "(nil)"
 		err = nil // Key doesn't exist is not an error
 	}
-	r.LogActivity("GET", fmt.Sprintf("GET %s", key), duration, err, response)
+	r.LogActivity(ctx, "GET", fmt.Sprintf("GET %s", key), duration, err, response)
 
 	if err == redis.Nil {
 		return "", nil
@@ -146,7 +178,7 @@ func (r *RedisAdapter) Set(ctx context.Context, key, value string, expiration ti
 	if err != nil {
 		response = ""
 	}
-	r.LogActivity("SET", command, duration, err, response)
+	r.LogActivity(ctx, "SET", command, duration, err, response)
 
 	return err
 }
@@ -160,7 +192,7 @@ func (r *RedisAdapter) Delete(ctx context.Context, key string) error {
 
 	// Log activity
 	response := fmt.Sprintf("%d keys deleted", result)
-	r.LogActivity("DELETE", fmt.Sprintf("DEL %s", key), duration, err, response)
+	r.LogActivity(ctx, "DELETE", fmt.Sprintf("DEL %s", key), duration, err, response)
 
 	return err
 }
@@ -249,5 +281,74 @@ func (r *RedisAdapter) Incr(ctx context.Context, key string) (int64, error) {
 	return val, err
 }
 
+// PoolStats returns connection pool statistics in adapter-neutral form
+func (r *RedisAdapter) PoolStats() *adapters.PoolStats {
+	if r.client == nil {
+		return nil
+	}
+	stats := r.client.PoolStats()
+	return &adapters.PoolStats{
+		AcquiredConns: int(stats.TotalConns) - int(stats.IdleConns),
+		IdleConns:     int(stats.IdleConns),
+		TotalConns:    int(stats.TotalConns),
+		MaxConns:      r.client.Options().PoolSize,
+		AcquireCount:  int64(stats.Hits) + int64(stats.Misses),
+		NewConnsCount: int64(stats.Misses),
+		Timeouts:      int64(stats.Timeouts),
+	}
+}
+
+// KeyValue represents a single key to be written by SetAll
+type KeyValue struct {
+	Key        string
+	Value      string
+	Expiration time.Duration
+}
+
+// SetAll sets multiple keys atomically inside a MULTI/EXEC transaction
+func (r *RedisAdapter) SetAll(ctx context.Context, items []KeyValue) error {
+	start := time.Now()
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, item := range items {
+			pipe.Set(ctx, item.Key, item.Value, item.Expiration)
+		}
+		return nil
+	})
+	duration := time.Since(start)
+	r.RecordRequest(duration, err == nil)
+
+	// Log activity
+	response := ""
+	if err == nil {
+		response = fmt.Sprintf("%d keys set", len(items))
+	}
+	r.LogActivity(ctx, "SET_ALL", fmt.Sprintf("MULTI SET (%d keys) EXEC", len(items)), duration, err, response)
+
+	return err
+}
+
+// classifyError returns the type of a Redis error by its prefix
+func classifyError(err error) string {
+	if errors.Is(err, redis.Nil) {
+		return adapters.ErrorTypeNotFound
+	}
+	message := err.Error()
+	for _, prefix := range []string{"NOAUTH", "WRONGPASS", "NOPERM"} {
+		if strings.HasPrefix(message, prefix) {
+			return adapters.ErrorTypeAuthFailure
+		}
+	}
+	return ""
+}
+
 // Ensure RedisAdapter implements CacheAdapter
 var _ adapters.CacheAdapter = (*RedisAdapter)(nil)
+
+// Ensure RedisAdapter implements PoolStatsProvider
+var _ adapters.PoolStatsProvider = (*RedisAdapter)(nil)
+
+// Ensure RedisAdapter implements CacheStatsProvider
+var _ adapters.CacheStatsProvider = (*RedisAdapter)(nil)
+
+// Ensure RedisAdapter implements QueueAdapter
+var _ adapters.QueueAdapter = (*RedisAdapter)(nil)
@@ -0,0 +1,109 @@
+package redis
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/akmadan/throome/pkg/cluster"
+)
+
+func TestClientOptionsWithoutTLS(t *testing.T) {
+	options, err := clientOptions(&cluster.ServiceConfig{Host: "localhost", Port: 6379})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if options.TLSConfig != nil {
+		t.Error("Expected no TLS config when TLS is disabled")
+	}
+}
+
+func TestClientOptionsWithTLS(t *testing.T) {
+	caFile := writeTestCA(t)
+	config := &cluster.ServiceConfig{
+		Host: "cache.example.com",
+		Port: 6380,
+		TLS:  cluster.TLSConfig{Enabled: true, CAFile: caFile},
+	}
+
+	options, err := clientOptions(config)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if options.TLSConfig == nil {
+		t.Fatal("Expected a TLS config")
+	}
+	if options.TLSConfig.ServerName != "cache.example.com" {
+		t.Errorf("Expected the server name to default to the host, got %q", options.TLSConfig.ServerName)
+	}
+	if options.TLSConfig.RootCAs == nil {
+		t.Error("Expected the custom CA to be loaded")
+	}
+	if options.TLSConfig.InsecureSkipVerify {
+		t.Error("Expected certificate verification to be enabled")
+	}
+
+	config.TLS = cluster.TLSConfig{Enabled: true, InsecureSkipVerify: true}
+	options, err = clientOptions(config)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !options.TLSConfig.InsecureSkipVerify {
+		t.Error("Expected InsecureSkipVerify to be passed through")
+	}
+}
+
+func TestClientOptionsInvalidTLS(t *testing.T) {
+	invalidCA := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(invalidCA, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]cluster.TLSConfig{
+		"missing CA file":  {Enabled: true, CAFile: filepath.Join(t.TempDir(), "missing.pem")},
+		"invalid CA file":  {Enabled: true, CAFile: invalidCA},
+		"missing key pair": {Enabled: true, CertFile: "missing.crt", KeyFile: "missing.key"},
+	}
+
+	for name, tlsConfig := range tests {
+		if _, err := clientOptions(&cluster.ServiceConfig{Host: "localhost", Port: 6380, TLS: tlsConfig}); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+// writeTestCA writes a self-signed CA certificate and returns its path
+func writeTestCA(t *testing.T) string {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "throome test CA"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
@@ -0,0 +1,73 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// KeyInfo describes a key found by ScanKeys
+type KeyInfo struct {
+	Key  string
+	Type string        // string, list, set, zset, hash or stream
+	TTL  time.Duration // Zero when the key does not expire
+}
+
+// ScanKeys returns one page of the keys matching a pattern with their type and TTL,
+// along with the cursor of the next page, zero once the scan is complete. Count is a
+// hint to Redis of how many keys to look at, so a page may hold fewer keys or none at
+// all before the scan completes. Unlike KEYS, it never blocks the server for long.
+func (r *RedisAdapter) ScanKeys(ctx context.Context, cursor uint64, pattern string, count int64) ([]KeyInfo, uint64, error) {
+	start := time.Now()
+	keys, next, err := r.scanKeys(ctx, cursor, pattern, count)
+	duration := time.Since(start)
+	r.RecordRequest(duration, err == nil)
+
+	// Log activity
+	command := fmt.Sprintf("SCAN %d MATCH %s COUNT %d", cursor, pattern, count)
+	response := ""
+	if err == nil {
+		response = fmt.Sprintf("%d keys, next cursor %d", len(keys), next)
+	}
+	r.LogActivity(ctx, "SCAN", command, duration, err, response)
+
+	return keys, next, err
+}
+
+func (r *RedisAdapter) scanKeys(ctx context.Context, cursor uint64, pattern string, count int64) ([]KeyInfo, uint64, error) {
+	names, next, err := r.client.Scan(ctx, cursor, pattern, count).Result()
+	if err != nil {
+		return nil, 0, err
+	}
+	keys := make([]KeyInfo, 0, len(names))
+	if len(names) == 0 {
+		return keys, next, nil
+	}
+
+	// Read types and TTLs in a single round trip
+	pipe := r.client.Pipeline()
+	types := make([]*redis.StatusCmd, len(names))
+	ttls := make([]*redis.DurationCmd, len(names))
+	for i, name := range names {
+		types[i] = pipe.Type(ctx, name)
+		ttls[i] = pipe.PTTL(ctx, name)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, 0, fmt.Errorf("failed to read key types and TTLs: %w", err)
+	}
+
+	for i, name := range names {
+		keyType := types[i].Val()
+		if keyType == "none" {
+			continue // Expired or deleted since the scan
+		}
+		ttl := ttls[i].Val()
+		if ttl < 0 {
+			ttl = 0
+		}
+		keys = append(keys, KeyInfo{Key: name, Type: keyType, TTL: ttl})
+	}
+	return keys, next, nil
+}
@@ -0,0 +1,343 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/akmadan/throome/internal/logger"
+	"github.com/akmadan/throome/pkg/adapters"
+	"go.uber.org/zap"
+)
+
+const (
+	// defaultConsumerGroup is the consumer group gateway subscriptions read with
+	defaultConsumerGroup = "throome"
+	// Stream entry fields holding the message
+	fieldMessage = "message"
+	fieldKey     = "key"
+
+	readCount = 10
+	readBlock = 2 * time.Second
+)
+
+// The RedisAdapter also implements QueueAdapter on top of Redis Streams, so small
+// clusters can route simple event streams through Redis instead of Kafka. A topic is
+// a stream; subscriptions read it through a consumer group (the "consumer_group"
+// option, "throome" by default) as the "consumer_name" consumer, which defaults to
+// the hostname, and acknowledge entries once the handler succeeds. Streams are capped
+// to about "stream_max_len" entries when the option is set.
+
+// Publish appends a message to a stream
+func (r *RedisAdapter) Publish(ctx context.Context, topic string, message []byte) error {
+	return r.PublishWithKey(ctx, topic, nil, message)
+}
+
+// PublishWithKey appends a message with a key to a stream
+func (r *RedisAdapter) PublishWithKey(ctx context.Context, topic string, key, message []byte) error {
+	start := time.Now()
+
+	values := map[string]interface{}{fieldMessage: message}
+	if len(key) > 0 {
+		values[fieldKey] = key
+	}
+	id, err := r.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: topic,
+		MaxLen: streamMaxLen(r.config.Options),
+		Approx: true,
+		Values: values,
+	}).Result()
+
+	duration := time.Since(start)
+	r.RecordRequest(duration, err == nil)
+
+	// Log activity
+	command := fmt.Sprintf("XADD %s * (%d bytes)", topic, len(message))
+	if len(key) > 0 {
+		command = fmt.Sprintf("XADD %s * key=%s (%d bytes)", topic, key, len(message))
+	}
+	response := ""
+	if err == nil {
+		response = fmt.Sprintf("Entry %s added", id)
+	}
+	r.LogActivity(ctx, "PUBLISH", command, duration, err, response)
+
+	return err
+}
+
+// Subscribe consumes a stream through the adapter's consumer group. Entries left
+// pending by an earlier run of this consumer are delivered first.
+func (r *RedisAdapter) Subscribe(ctx context.Context, topic string, handler adapters.MessageHandler) error {
+	start := time.Now()
+	group := consumerGroup(r.config.Options)
+	command := fmt.Sprintf("XREADGROUP GROUP %s %s STREAMS %s", group, r.consumer, topic)
+
+	r.mu.Lock()
+	if _, exists := r.subscriptions[topic]; exists {
+		r.mu.Unlock()
+		err := fmt.Errorf("already subscribed to topic: %s", topic)
+		r.LogActivity(ctx, "SUBSCRIBE", command, time.Since(start), err, "")
+		return err
+	}
+
+	if err := r.ensureGroup(ctx, topic, group); err != nil {
+		r.mu.Unlock()
+		r.LogActivity(ctx, "SUBSCRIBE", command, time.Since(start), err, "")
+		return err
+	}
+
+	// Consumers outlive the request that started them
+	consumeCtx, cancel := context.WithCancel(context.Background())
+	r.subscriptions[topic] = cancel
+	r.mu.Unlock()
+
+	go r.consume(consumeCtx, topic, group, handler)
+
+	response := fmt.Sprintf("Successfully subscribed to topic '%s'", topic)
+	r.LogActivity(ctx, "SUBSCRIBE", command, time.Since(start), nil, response)
+	return nil
+}
+
+// consume reads a stream until ctx is cancelled
+func (r *RedisAdapter) consume(ctx context.Context, topic, group string, handler adapters.MessageHandler) {
+	// Pending entries of this consumer are read from ID "0" onwards, then ">" reads
+	// entries never delivered to the group
+	cursor := "0"
+	for ctx.Err() == nil {
+		streams, err := r.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    group,
+			Consumer: r.consumer,
+			Streams:  []string{topic, cursor},
+			Count:    readCount,
+			Block:    readBlock,
+		}).Result()
+		if err != nil {
+			if errors.Is(err, redis.Nil) || ctx.Err() != nil {
+				continue
+			}
+			logger.Warn("Failed to read stream",
+				zap.String("topic", topic),
+				zap.String("group", group),
+				zap.Error(err),
+			)
+			select {
+			case <-ctx.Done():
+			case <-time.After(readBlock):
+			}
+			continue
+		}
+
+		lastID := ""
+		for _, stream := range streams {
+			for _, entry := range stream.Messages {
+				lastID = entry.ID
+				if err := handler(ctx, toMessage(topic, entry)); err != nil {
+					// Left pending, so the entry is delivered again on the next start
+					continue
+				}
+				r.client.XAck(ctx, topic, group, entry.ID)
+			}
+		}
+
+		// Replay each pending entry once, then switch to new entries
+		if cursor != ">" {
+			cursor = lastID
+			if lastID == "" {
+				cursor = ">"
+			}
+		}
+	}
+}
+
+// Unsubscribe stops consuming a stream
+func (r *RedisAdapter) Unsubscribe(ctx context.Context, topic string) error {
+	start := time.Now()
+
+	r.mu.Lock()
+	cancel, exists := r.subscriptions[topic]
+	delete(r.subscriptions, topic)
+	r.mu.Unlock()
+
+	var err error
+	response := ""
+	if exists {
+		cancel()
+		response = fmt.Sprintf("Successfully unsubscribed from topic '%s'", topic)
+	} else {
+		err = fmt.Errorf("not subscribed to topic: %s", topic)
+	}
+	r.LogActivity(ctx, "UNSUBSCRIBE", fmt.Sprintf("UNSUBSCRIBE from topic '%s'", topic), time.Since(start), err, response)
+
+	return err
+}
+
+// CreateTopic creates an empty stream along with the adapter's consumer group. The
+// "consumer_group" key of config overrides the group.
+func (r *RedisAdapter) CreateTopic(ctx context.Context, topic string, config map[string]interface{}) error {
+	start := time.Now()
+
+	group := consumerGroup(r.config.Options)
+	if value, ok := config["consumer_group"].(string); ok && value != "" {
+		group = value
+	}
+	err := r.ensureGroup(ctx, topic, group)
+
+	duration := time.Since(start)
+	r.RecordRequest(duration, err == nil)
+
+	// Log activity
+	response := ""
+	if err == nil {
+		response = fmt.Sprintf("Stream '%s' created with group '%s'", topic, group)
+	}
+	r.LogActivity(ctx, "CREATE_TOPIC", fmt.Sprintf("XGROUP CREATE %s %s $ MKSTREAM", topic, group), duration, err, response)
+
+	return err
+}
+
+// DeleteTopic deletes a stream, its entries and its consumer groups
+func (r *RedisAdapter) DeleteTopic(ctx context.Context, topic string) error {
+	start := time.Now()
+	err := r.deleteStream(ctx, topic)
+	duration := time.Since(start)
+	r.RecordRequest(duration, err == nil)
+
+	// Log activity
+	response := ""
+	if err == nil {
+		response = fmt.Sprintf("Stream '%s' deleted", topic)
+	}
+	r.LogActivity(ctx, "DELETE_TOPIC", fmt.Sprintf("DEL %s", topic), duration, err, response)
+
+	return err
+}
+
+func (r *RedisAdapter) deleteStream(ctx context.Context, topic string) error {
+	// Refuse to delete cache keys through the queue API
+	keyType, err := r.client.Type(ctx, topic).Result()
+	if err != nil {
+		return err
+	}
+	switch keyType {
+	case "stream":
+		return r.client.Del(ctx, topic).Err()
+	case "none":
+		return fmt.Errorf("topic not found: %s", topic)
+	default:
+		return fmt.Errorf("key %s is a %s, not a stream", topic, keyType)
+	}
+}
+
+// ListTopics lists the streams in the database
+func (r *RedisAdapter) ListTopics(ctx context.Context) ([]string, error) {
+	start := time.Now()
+
+	topics := make([]string, 0)
+	var cursor uint64
+	var err error
+	for {
+		var keys []string
+		keys, cursor, err = r.client.ScanType(ctx, cursor, "*", 100, "stream").Result()
+		if err != nil {
+			break
+		}
+		topics = append(topics, keys...)
+		if cursor == 0 {
+			break
+		}
+	}
+
+	duration := time.Since(start)
+	r.RecordRequest(duration, err == nil)
+
+	// Log activity
+	response := ""
+	if err == nil {
+		response = fmt.Sprintf("%d streams", len(topics))
+	}
+	r.LogActivity(ctx, "LIST_TOPICS", "SCAN 0 TYPE stream", duration, err, response)
+
+	if err != nil {
+		return nil, err
+	}
+	return topics, nil
+}
+
+// ensureGroup creates a stream's consumer group, and the stream if it does not exist
+func (r *RedisAdapter) ensureGroup(ctx context.Context, topic, group string) error {
+	err := r.client.XGroupCreateMkStream(ctx, topic, group, "$").Err()
+	if err != nil && strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return nil // The group already exists
+	}
+	return err
+}
+
+// stopSubscriptions cancels every consumer
+func (r *RedisAdapter) stopSubscriptions() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for topic, cancel := range r.subscriptions {
+		cancel()
+		delete(r.subscriptions, topic)
+	}
+}
+
+// toMessage converts a stream entry to a queue message. Stream IDs are
+// "<milliseconds>-<sequence>", so the timestamp comes from the ID.
+func toMessage(topic string, entry redis.XMessage) *adapters.Message {
+	message := &adapters.Message{
+		Topic:   topic,
+		Headers: map[string]string{"stream_id": entry.ID},
+	}
+	if value, ok := entry.Values[fieldMessage].(string); ok {
+		message.Value = []byte(value)
+	}
+	if key, ok := entry.Values[fieldKey].(string); ok {
+		message.Key = []byte(key)
+	}
+
+	millis, _, _ := strings.Cut(entry.ID, "-")
+	if ms, err := strconv.ParseInt(millis, 10, 64); err == nil {
+		message.Timestamp = time.UnixMilli(ms)
+	}
+
+	return message
+}
+
+func consumerGroup(options map[string]interface{}) string {
+	if group, ok := options["consumer_group"].(string); ok && group != "" {
+		return group
+	}
+	return defaultConsumerGroup
+}
+
+// streamMaxLen reads the "stream_max_len" option, zero meaning uncapped
+func streamMaxLen(options map[string]interface{}) int64 {
+	switch value := options["stream_max_len"].(type) {
+	case int:
+		return int64(value)
+	case float64:
+		return int64(value)
+	default:
+		return 0
+	}
+}
+
+// consumerName identifies this gateway within consumer groups. It is stable across
+// restarts so a gateway picks up the entries it left pending.
+func consumerName(options map[string]interface{}) string {
+	if name, ok := options["consumer_name"].(string); ok && name != "" {
+		return name
+	}
+	if hostname, err := os.Hostname(); err == nil {
+		return hostname
+	}
+	return "throome"
+}
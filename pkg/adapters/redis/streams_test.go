@@ -0,0 +1,48 @@
+package redis
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+func TestToMessage(t *testing.T) {
+	message := toMessage("events", redis.XMessage{
+		ID:     "1700000000123-4",
+		Values: map[string]interface{}{fieldMessage: "hello", fieldKey: "user-1"},
+	})
+
+	if message.Topic != "events" || string(message.Value) != "hello" || string(message.Key) != "user-1" {
+		t.Errorf("Unexpected message: %+v", message)
+	}
+	if !message.Timestamp.Equal(time.UnixMilli(1700000000123)) {
+		t.Errorf("Expected the timestamp to come from the stream ID, got %v", message.Timestamp)
+	}
+	if message.Headers["stream_id"] != "1700000000123-4" {
+		t.Errorf("Expected the stream ID header, got %v", message.Headers)
+	}
+}
+
+func TestStreamOptions(t *testing.T) {
+	if consumerGroup(nil) != defaultConsumerGroup {
+		t.Error("Expected the default consumer group")
+	}
+	options := map[string]interface{}{
+		"consumer_group": "workers",
+		"consumer_name":  "gateway-1",
+		"stream_max_len": float64(1000),
+	}
+	if consumerGroup(options) != "workers" {
+		t.Error("Expected the consumer_group option to be used")
+	}
+	if consumerName(options) != "gateway-1" {
+		t.Error("Expected the consumer_name option to be used")
+	}
+	if consumerName(nil) == "" {
+		t.Error("Expected a default consumer name")
+	}
+	if streamMaxLen(options) != 1000 || streamMaxLen(nil) != 0 {
+		t.Error("Unexpected stream_max_len handling")
+	}
+}
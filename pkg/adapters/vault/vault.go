@@ -0,0 +1,283 @@
+package vault
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/akmadan/throome/pkg/adapters"
+	"github.com/akmadan/throome/pkg/cluster"
+)
+
+const (
+	// defaultToken is the root token the provisioner gives dev mode servers
+	defaultToken = "root"
+	// defaultMount is the KV version 2 engine that dev mode servers mount
+	defaultMount   = "secret"
+	requestTimeout = 10 * time.Second
+)
+
+// VaultAdapter implements the SecretAdapter interface for HashiCorp Vault's KV
+// version 2 secrets engine. The token is configured as the service password and the
+// engine's mount path with the "mount" option.
+type VaultAdapter struct {
+	*adapters.BaseAdapter
+	config *cluster.ServiceConfig
+	client *http.Client
+}
+
+// NewVaultAdapter creates a new Vault adapter
+func NewVaultAdapter(config *cluster.ServiceConfig) (adapters.Adapter, error) {
+	adapter := &VaultAdapter{
+		BaseAdapter: adapters.NewBaseAdapter(config),
+		config:      config,
+	}
+	return adapter, nil
+}
+
+// Connect creates the HTTP client and checks that Vault is unsealed
+func (v *VaultAdapter) Connect(ctx context.Context) error {
+	tlsConfig, err := adapters.BuildTLSConfig(&v.config.TLS)
+	if err != nil {
+		return err
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	v.client = &http.Client{Transport: transport, Timeout: requestTimeout}
+
+	// Test connection
+	if err := v.Ping(ctx); err != nil {
+		return fmt.Errorf("failed to connect to Vault: %w", err)
+	}
+
+	v.SetConnected(true)
+	return nil
+}
+
+// Disconnect releases idle connections
+func (v *VaultAdapter) Disconnect(ctx context.Context) error {
+	if v.client != nil {
+		v.client.CloseIdleConnections()
+		v.SetConnected(false)
+	}
+	return nil
+}
+
+// Ping checks that Vault is initialized and unsealed. Standby nodes count as healthy.
+func (v *VaultAdapter) Ping(ctx context.Context) error {
+	start := time.Now()
+	err := v.ping(ctx)
+	v.RecordRequest(time.Since(start), err == nil)
+	return err
+}
+
+func (v *VaultAdapter) ping(ctx context.Context) error {
+	resp, err := v.do(ctx, http.MethodGet, "/v1/sys/health", nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusTooManyRequests: // Active or standby
+		return nil
+	case http.StatusNotImplemented:
+		return fmt.Errorf("vault is not initialized")
+	case http.StatusServiceUnavailable:
+		return fmt.Errorf("vault is sealed")
+	default:
+		return responseError(resp)
+	}
+}
+
+// HealthCheck performs a health check
+func (v *VaultAdapter) HealthCheck(ctx context.Context) (*adapters.HealthStatus, error) {
+	start := time.Now()
+	err := v.Ping(ctx)
+	responseTime := time.Since(start)
+
+	status := &adapters.HealthStatus{
+		Healthy:      err == nil,
+		Reachable:    err == nil,
+		ResponseTime: responseTime,
+		LastChecked:  time.Now(),
+	}
+
+	if err != nil {
+		status.ErrorMessage = err.Error()
+	}
+
+	return status, nil
+}
+
+// ReadSecret reads the latest version of the secret at path
+func (v *VaultAdapter) ReadSecret(ctx context.Context, path string) (*adapters.Secret, error) {
+	start := time.Now()
+	secret, err := v.readSecret(ctx, path)
+	duration := time.Since(start)
+	v.RecordRequest(duration, err == nil)
+
+	// Log activity without the secret's values
+	response := ""
+	if err == nil {
+		response = fmt.Sprintf("version %d (%d keys)", secret.Version, len(secret.Data))
+	}
+	v.LogActivity(ctx, "READ_SECRET", fmt.Sprintf("GET %s", dataPath(v.config, path)), duration, err, response)
+
+	return secret, err
+}
+
+func (v *VaultAdapter) readSecret(ctx context.Context, path string) (*adapters.Secret, error) {
+	resp, err := v.do(ctx, http.MethodGet, dataPath(v.config, path), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// Vault also answers 404 when the latest version was deleted
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%w: %s", adapters.ErrSecretNotFound, path)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError(resp)
+	}
+
+	var body struct {
+		Data struct {
+			Data     map[string]interface{} `json:"data"`
+			Metadata secretMetadata         `json:"metadata"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("invalid Vault response: %w", err)
+	}
+
+	return &adapters.Secret{
+		Path:        path,
+		Data:        body.Data.Data,
+		Version:     body.Data.Metadata.Version,
+		CreatedTime: body.Data.Metadata.CreatedTime,
+	}, nil
+}
+
+// WriteSecret stores data as a new version of the secret at path. The returned
+// secret carries the new version but not the data.
+func (v *VaultAdapter) WriteSecret(ctx context.Context, path string, data map[string]interface{}) (*adapters.Secret, error) {
+	start := time.Now()
+	secret, err := v.writeSecret(ctx, path, data)
+	duration := time.Since(start)
+	v.RecordRequest(duration, err == nil)
+
+	// Log activity without the secret's values
+	response := ""
+	if err == nil {
+		response = fmt.Sprintf("version %d written", secret.Version)
+	}
+	v.LogActivity(ctx, "WRITE_SECRET", fmt.Sprintf("POST %s (%d keys)", dataPath(v.config, path), len(data)), duration, err, response)
+
+	return secret, err
+}
+
+func (v *VaultAdapter) writeSecret(ctx context.Context, path string, data map[string]interface{}) (*adapters.Secret, error) {
+	payload, err := json.Marshal(map[string]interface{}{"data": data})
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := v.do(ctx, http.MethodPost, dataPath(v.config, path), payload)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError(resp)
+	}
+
+	var body struct {
+		Data secretMetadata `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("invalid Vault response: %w", err)
+	}
+
+	return &adapters.Secret{
+		Path:        path,
+		Version:     body.Data.Version,
+		CreatedTime: body.Data.CreatedTime,
+	}, nil
+}
+
+// secretMetadata is the version metadata Vault returns for KV version 2 secrets
+type secretMetadata struct {
+	Version     int       `json:"version"`
+	CreatedTime time.Time `json:"created_time"`
+}
+
+func (v *VaultAdapter) do(ctx context.Context, method, path string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, baseURL(v.config)+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", token(v.config))
+	if namespace, ok := v.config.Options["namespace"].(string); ok && namespace != "" {
+		req.Header.Set("X-Vault-Namespace", namespace)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach Vault: %w", err)
+	}
+	return resp, nil
+}
+
+// responseError builds an error from the messages of a Vault error response
+func responseError(resp *http.Response) error {
+	var body struct {
+		Errors []string `json:"errors"`
+	}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if err := json.Unmarshal(data, &body); err == nil && len(body.Errors) > 0 {
+		return fmt.Errorf("vault returned %s: %s", resp.Status, strings.Join(body.Errors, "; "))
+	}
+	return fmt.Errorf("vault returned %s", resp.Status)
+}
+
+func baseURL(config *cluster.ServiceConfig) string {
+	scheme := "http"
+	if config.TLS.Enabled {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s:%d", scheme, config.Host, config.Port)
+}
+
+// dataPath returns the API path of a secret in the KV version 2 engine
+func dataPath(config *cluster.ServiceConfig, path string) string {
+	mount := defaultMount
+	if value, ok := config.Options["mount"].(string); ok && value != "" {
+		mount = strings.Trim(value, "/")
+	}
+
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return fmt.Sprintf("/v1/%s/data/%s", mount, strings.Join(segments, "/"))
+}
+
+func token(config *cluster.ServiceConfig) string {
+	if config.Password != "" {
+		return config.Password
+	}
+	return defaultToken
+}
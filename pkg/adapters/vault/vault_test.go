@@ -0,0 +1,111 @@
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/akmadan/throome/pkg/adapters"
+	"github.com/akmadan/throome/pkg/cluster"
+)
+
+func TestDataPath(t *testing.T) {
+	config := &cluster.ServiceConfig{}
+	if got := dataPath(config, "/app/db password/"); got != "/v1/secret/data/app/db%20password" {
+		t.Errorf("Unexpected path: %s", got)
+	}
+
+	config.Options = map[string]interface{}{"mount": "/kv/"}
+	if got := dataPath(config, "app"); got != "/v1/kv/data/app" {
+		t.Errorf("Expected the mount option to be used, got %s", got)
+	}
+}
+
+func TestReadWriteSecret(t *testing.T) {
+	stored := map[string]map[string]interface{}{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "test-token" {
+			w.WriteHeader(http.StatusForbidden)
+			_ = json.NewEncoder(w).Encode(map[string][]string{"errors": {"permission denied"}})
+			return
+		}
+
+		switch {
+		case r.URL.Path == "/v1/sys/health":
+			w.WriteHeader(http.StatusOK)
+		case r.Method == http.MethodPost:
+			var body struct {
+				Data map[string]interface{} `json:"data"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			stored[r.URL.Path] = body.Data
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"data": map[string]interface{}{"version": 1, "created_time": "2024-01-01T00:00:00Z"},
+			})
+		case r.Method == http.MethodGet:
+			data, ok := stored[r.URL.Path]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				_ = json.NewEncoder(w).Encode(map[string][]string{"errors": {}})
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"data": map[string]interface{}{
+					"data":     data,
+					"metadata": map[string]interface{}{"version": 1, "created_time": "2024-01-01T00:00:00Z"},
+				},
+			})
+		}
+	}))
+	defer server.Close()
+
+	host, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+	portNumber, _ := strconv.Atoi(port)
+	adapter, _ := NewVaultAdapter(&cluster.ServiceConfig{Type: "vault", Host: host, Port: portNumber, Password: "test-token"})
+	v := adapter.(*VaultAdapter)
+
+	ctx := context.Background()
+	if err := v.Connect(ctx); err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+
+	written, err := v.WriteSecret(ctx, "app/db", map[string]interface{}{"password": "s3cret"})
+	if err != nil {
+		t.Fatalf("Failed to write secret: %v", err)
+	}
+	if written.Version != 1 {
+		t.Errorf("Expected version 1, got %d", written.Version)
+	}
+
+	secret, err := v.ReadSecret(ctx, "app/db")
+	if err != nil {
+		t.Fatalf("Failed to read secret: %v", err)
+	}
+	if secret.Data["password"] != "s3cret" || secret.CreatedTime.IsZero() {
+		t.Errorf("Unexpected secret: %+v", secret)
+	}
+
+	if _, err := v.ReadSecret(ctx, "app/missing"); !errors.Is(err, adapters.ErrSecretNotFound) {
+		t.Errorf("Expected ErrSecretNotFound, got %v", err)
+	}
+}
+
+func TestPingReportsSealedVault(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	host, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+	portNumber, _ := strconv.Atoi(port)
+	adapter, _ := NewVaultAdapter(&cluster.ServiceConfig{Type: "vault", Host: host, Port: portNumber})
+
+	if err := adapter.Connect(context.Background()); err == nil {
+		t.Error("Expected connecting to a sealed Vault to fail")
+	}
+}
@@ -0,0 +1,40 @@
+package auth
+
+import "context"
+
+// Identity describes the caller a request was authenticated as
+type Identity struct {
+	Subject   string                 `json:"subject"` // Who the caller is, the API key name for API keys
+	Method    string                 `json:"method"`  // How the caller authenticated
+	Role      Role                   `json:"role"`
+	Namespace string                 `json:"namespace,omitempty"` // Namespace the caller is limited to, all when empty
+	KeyID     string                 `json:"key_id,omitempty"`    // ID of the API key, if one was used
+	Claims    map[string]interface{} `json:"claims,omitempty"`    // Claims of the bearer token, if one was used
+}
+
+// Authentication methods
+const (
+	MethodAPIKey = "api_key"
+	MethodJWT    = "jwt"
+)
+
+// Claim returns a string claim of the caller's token, such as a tenant ID, or "" when
+// the token has no such claim
+func (i *Identity) Claim(name string) string {
+	value, _ := i.Claims[name].(string)
+	return value
+}
+
+type identityKey struct{}
+
+// WithIdentity returns a copy of ctx carrying the caller's identity
+func WithIdentity(ctx context.Context, identity *Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, identity)
+}
+
+// IdentityFromContext returns the identity of an authenticated request, or nil when
+// the request was not authenticated
+func IdentityFromContext(ctx context.Context) *Identity {
+	identity, _ := ctx.Value(identityKey{}).(*Identity)
+	return identity
+}
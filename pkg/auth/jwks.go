@@ -0,0 +1,172 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	defaultJWKSRefresh = time.Hour
+	// minJWKSRefetch limits how often unknown key IDs trigger a fetch, so tokens with
+	// made-up key IDs cannot flood the identity provider
+	minJWKSRefetch = time.Minute
+)
+
+// JWKS fetches and caches the public keys an identity provider signs tokens with.
+// Keys are fetched again once the refresh interval passes, or when a token names a
+// key that is not cached, which happens when the provider rotates its keys.
+type JWKS struct {
+	url       string
+	refresh   time.Duration
+	client    *http.Client
+	keys      map[string]interface{} // kid -> *rsa.PublicKey or *ecdsa.PublicKey
+	fetchedAt time.Time
+	mu        sync.Mutex
+}
+
+// jsonWebKey is a key of a JWKS document (RFC 7517)
+type jsonWebKey struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// NewJWKS creates a key set fetched from url
+func NewJWKS(url string, refresh time.Duration) *JWKS {
+	if refresh <= 0 {
+		refresh = defaultJWKSRefresh
+	}
+	return &JWKS{
+		url:     url,
+		refresh: refresh,
+		client:  &http.Client{Timeout: 10 * time.Second},
+		keys:    make(map[string]interface{}),
+	}
+}
+
+// Key returns the public key with the given ID
+func (j *JWKS) Key(ctx context.Context, kid string) (interface{}, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	key, known := j.keys[kid]
+	age := time.Since(j.fetchedAt)
+	if age > j.refresh || (!known && age > minJWKSRefetch) {
+		if err := j.fetchLocked(ctx); err != nil {
+			// Keep using the cached keys while the provider is unreachable
+			if !known {
+				return nil, err
+			}
+			return key, nil
+		}
+		key, known = j.keys[kid]
+	}
+
+	if !known {
+		return nil, fmt.Errorf("unknown signing key: %q", kid)
+	}
+	return key, nil
+}
+
+// fetchLocked replaces the cached keys with the provider's current key set. Caller
+// must hold the lock.
+func (j *JWKS) fetchLocked(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", j.url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create JWKS request: %w", err)
+	}
+	resp, err := j.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to fetch JWKS: status %d", resp.StatusCode)
+	}
+
+	var document struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&document); err != nil {
+		return fmt.Errorf("failed to parse JWKS: %w", err)
+	}
+
+	keys := make(map[string]interface{}, len(document.Keys))
+	for _, jwk := range document.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		// Keys of unsupported types are skipped rather than failing the whole set
+		if key, err := jwk.publicKey(); err == nil {
+			keys[jwk.Kid] = key
+		}
+	}
+
+	j.keys = keys
+	j.fetchedAt = time.Now()
+	return nil
+}
+
+// publicKey decodes an RSA or elliptic curve public key
+func (k *jsonWebKey) publicKey() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve: %s", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported key type: %s", k.Kty)
+	}
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key parameter: %w", err)
+	}
+	return new(big.Int).SetBytes(data), nil
+}
@@ -0,0 +1,138 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// JWTOptions configures bearer token validation. Tokens signed with HMAC are checked
+// against the secret, all others against the keys published at the JWKS URL.
+type JWTOptions struct {
+	Secret         string        // HMAC secret for HS256, HS384 and HS512 tokens
+	JWKSURL        string        // Key set of the identity provider for RSA and ECDSA tokens
+	JWKSRefresh    time.Duration // How long fetched keys are used before they are fetched again
+	Issuer         string        // Required "iss" claim, when set
+	Audience       string        // Required "aud" claim, when set
+	SubjectClaim   string        // Claim identifying the caller, "sub" by default
+	RoleClaim      string        // Claim holding the caller's role or roles, "role" by default
+	DefaultRole    Role          // Role of callers whose token names no valid role
+	NamespaceClaim string        // Claim limiting the caller to one namespace, when set
+	Leeway         time.Duration // Tolerated clock skew when checking expiry
+}
+
+// JWTValidator validates bearer tokens issued by an identity provider
+type JWTValidator struct {
+	secret         []byte
+	jwks           *JWKS
+	parser         *jwt.Parser
+	subjectClaim   string
+	roleClaim      string
+	defaultRole    Role
+	namespaceClaim string
+}
+
+// NewJWTValidator creates a validator accepting the token types the options allow
+func NewJWTValidator(options JWTOptions) (*JWTValidator, error) {
+	if options.Secret == "" && options.JWKSURL == "" {
+		return nil, fmt.Errorf("JWT validation requires a secret or a JWKS URL")
+	}
+
+	validator := &JWTValidator{
+		subjectClaim:   options.SubjectClaim,
+		roleClaim:      options.RoleClaim,
+		defaultRole:    options.DefaultRole,
+		namespaceClaim: options.NamespaceClaim,
+	}
+	if validator.subjectClaim == "" {
+		validator.subjectClaim = "sub"
+	}
+	if validator.roleClaim == "" {
+		validator.roleClaim = "role"
+	}
+	if validator.defaultRole == "" {
+		validator.defaultRole = RoleReadOnly
+	}
+	if _, err := ParseRole(string(validator.defaultRole)); err != nil {
+		return nil, err
+	}
+
+	var methods []string
+	if options.Secret != "" {
+		validator.secret = []byte(options.Secret)
+		methods = append(methods, "HS256", "HS384", "HS512")
+	}
+	if options.JWKSURL != "" {
+		validator.jwks = NewJWKS(options.JWKSURL, options.JWKSRefresh)
+		methods = append(methods, "RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512")
+	}
+
+	parserOptions := []jwt.ParserOption{
+		jwt.WithValidMethods(methods),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(options.Leeway),
+	}
+	if options.Issuer != "" {
+		parserOptions = append(parserOptions, jwt.WithIssuer(options.Issuer))
+	}
+	if options.Audience != "" {
+		parserOptions = append(parserOptions, jwt.WithAudience(options.Audience))
+	}
+	validator.parser = jwt.NewParser(parserOptions...)
+
+	return validator, nil
+}
+
+// Validate checks a token's signature and claims and returns the caller's identity
+func (v *JWTValidator) Validate(ctx context.Context, token string) (*Identity, error) {
+	claims := jwt.MapClaims{}
+	_, err := v.parser.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); ok {
+			return v.secret, nil
+		}
+		kid, _ := t.Header["kid"].(string)
+		return v.jwks.Key(ctx, kid)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid token: %w", err)
+	}
+
+	subject, _ := claims[v.subjectClaim].(string)
+	if subject == "" {
+		return nil, fmt.Errorf("invalid token: missing %s claim", v.subjectClaim)
+	}
+
+	identity := &Identity{
+		Subject: subject,
+		Method:  MethodJWT,
+		Role:    v.role(claims),
+		Claims:  claims,
+	}
+	if v.namespaceClaim != "" {
+		identity.Namespace = identity.Claim(v.namespaceClaim)
+	}
+	return identity, nil
+}
+
+// role reads the caller's role from a claim holding a role name or a list of role
+// names, keeping the most privileged one
+func (v *JWTValidator) role(claims jwt.MapClaims) Role {
+	var names []string
+	switch value := claims[v.roleClaim].(type) {
+	case string:
+		names = []string{value}
+	case []interface{}:
+		for _, item := range value {
+			if name, ok := item.(string); ok {
+				names = append(names, name)
+			}
+		}
+	}
+
+	if role := highestRole(names); role != "" {
+		return role
+	}
+	return v.defaultRole
+}
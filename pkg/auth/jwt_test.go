@@ -0,0 +1,142 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestJWTValidatorHMAC(t *testing.T) {
+	validator, err := NewJWTValidator(JWTOptions{Secret: "secret", Issuer: "idp", Audience: "throome"})
+	if err != nil {
+		t.Fatalf("Failed to create validator: %v", err)
+	}
+
+	sign := func(secret string, claims jwt.MapClaims) string {
+		token, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+		return token
+	}
+	valid := jwt.MapClaims{
+		"sub":    "alice",
+		"iss":    "idp",
+		"aud":    "throome",
+		"tenant": "acme",
+		"role":   "operator",
+		"exp":    time.Now().Add(time.Hour).Unix(),
+	}
+
+	identity, err := validator.Validate(context.Background(), sign("secret", valid))
+	if err != nil {
+		t.Fatalf("Expected a valid token, got %v", err)
+	}
+	if identity.Subject != "alice" || identity.Method != MethodJWT || identity.Role != RoleOperator || identity.Claim("tenant") != "acme" {
+		t.Errorf("Unexpected identity %+v", identity)
+	}
+	if identity.Namespace != "" {
+		t.Errorf("Expected no namespace without a namespace claim, got %q", identity.Namespace)
+	}
+
+	scoped, _ := NewJWTValidator(JWTOptions{Secret: "secret", NamespaceClaim: "tenant"})
+	if identity, err := scoped.Validate(context.Background(), sign("secret", valid)); err != nil || identity.Namespace != "acme" {
+		t.Errorf("Expected the tenant claim as namespace, got %+v, %v", identity, err)
+	}
+
+	invalid := map[string]string{
+		"wrong secret": sign("other", valid),
+		"expired":      sign("secret", with(valid, "exp", time.Now().Add(-time.Hour).Unix())),
+		"no expiry":    sign("secret", with(valid, "exp", nil)),
+		"wrong issuer": sign("secret", with(valid, "iss", "other")),
+		"wrong aud":    sign("secret", with(valid, "aud", "other")),
+		"no subject":   sign("secret", with(valid, "sub", nil)),
+		"malformed":    "not-a-token",
+	}
+	for name, token := range invalid {
+		if _, err := validator.Validate(context.Background(), token); err == nil {
+			t.Errorf("%s: expected the token to be rejected", name)
+		}
+	}
+}
+
+func TestJWTValidatorJWKS(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+
+	fetches := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches++
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kid": "key-1",
+				"kty": "RSA",
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	}))
+	defer server.Close()
+
+	validator, err := NewJWTValidator(JWTOptions{JWKSURL: server.URL})
+	if err != nil {
+		t.Fatalf("Failed to create validator: %v", err)
+	}
+
+	sign := func(kid string) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+			"sub": "svc",
+			"exp": time.Now().Add(time.Hour).Unix(),
+		})
+		token.Header["kid"] = kid
+		signed, _ := token.SignedString(key)
+		return signed
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := validator.Validate(context.Background(), sign("key-1")); err != nil {
+			t.Fatalf("Expected a valid token, got %v", err)
+		}
+	}
+	if identity, _ := validator.Validate(context.Background(), sign("key-1")); identity.Role != RoleReadOnly {
+		t.Errorf("Expected tokens without a role to be read-only, got %q", identity.Role)
+	}
+	if fetches != 1 {
+		t.Errorf("Expected the key set to be cached, got %d fetches", fetches)
+	}
+	if _, err := validator.Validate(context.Background(), sign("unknown")); err == nil {
+		t.Error("Expected tokens signed with unknown keys to be rejected")
+	}
+
+	// HMAC tokens are not accepted without a secret
+	hmacToken, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub": "svc",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}).SignedString([]byte("guess"))
+	if _, err := validator.Validate(context.Background(), hmacToken); err == nil {
+		t.Error("Expected HMAC tokens to be rejected")
+	}
+}
+
+// with returns a copy of claims with a claim set, or removed when value is nil
+func with(claims jwt.MapClaims, name string, value interface{}) jwt.MapClaims {
+	copied := jwt.MapClaims{}
+	for k, v := range claims {
+		copied[k] = v
+	}
+	if value == nil {
+		delete(copied, name)
+	} else {
+		copied[name] = value
+	}
+	return copied
+}
@@ -0,0 +1,244 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// KeyPrefix starts every API key issued by the gateway, which tells them apart from
+// bearer tokens
+const KeyPrefix = "thr_"
+
+// ErrKeyNotFound is returned when revoking an API key that was not issued
+var ErrKeyNotFound = errors.New("API key not found")
+
+// APIKey describes an API key. The key itself is never stored, only its SHA-256 hash.
+type APIKey struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Hint      string    `json:"hint"` // The first characters of the key, to recognize it
+	Hash      string    `json:"hash"` // Hex-encoded SHA-256 of the key
+	Role      Role      `json:"role,omitempty"`
+	Namespace string    `json:"namespace,omitempty"` // Namespace the key is limited to, all when empty
+	Static    bool      `json:"static"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// KeyStore holds the API keys accepted by the gateway: static keys from the
+// configuration, and keys issued at runtime, which are persisted to a file
+type KeyStore struct {
+	path   string
+	keys   map[string]*APIKey // hash -> key
+	static map[string]*APIKey // hash -> key, not persisted
+	mu     sync.RWMutex
+}
+
+// NewKeyStore creates a key store persisting issued keys to path and loads the keys
+// issued earlier. An empty path keeps issued keys in memory only.
+func NewKeyStore(path string) (*KeyStore, error) {
+	store := &KeyStore{
+		path:   path,
+		keys:   make(map[string]*APIKey),
+		static: make(map[string]*APIKey),
+	}
+	if path == "" {
+		return store, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read API keys: %w", err)
+	}
+
+	var keys []*APIKey
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return nil, fmt.Errorf("failed to parse API keys: %w", err)
+	}
+	for _, key := range keys {
+		// Keys issued before roles existed had full access
+		if key.Role == "" {
+			key.Role = RoleAdmin
+		}
+		store.keys[key.Hash] = key
+	}
+	return store, nil
+}
+
+// AddStatic accepts a key from the configuration. Either the key or its hex-encoded
+// SHA-256 hash must be given.
+func (st *KeyStore) AddStatic(name, key, hash string, role Role, namespace string) error {
+	if _, err := ParseRole(string(role)); err != nil {
+		return fmt.Errorf("API key %s: %w", name, err)
+	}
+	if key != "" {
+		hash = HashKey(key)
+	}
+	hash = strings.ToLower(hash)
+	if len(hash) != sha256.Size*2 {
+		return fmt.Errorf("API key %s: a key or a SHA-256 hash is required", name)
+	}
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	st.static[hash] = &APIKey{
+		ID:        "static-" + name,
+		Name:      name,
+		Hash:      hash,
+		Role:      role,
+		Namespace: namespace,
+		Static:    true,
+	}
+	return nil
+}
+
+// Issue creates a new API key with the given role, limited to a namespace unless
+// namespace is empty. The key is returned once and cannot be recovered.
+func (st *KeyStore) Issue(name string, role Role, namespace string) (string, *APIKey, error) {
+	if name == "" {
+		return "", nil, fmt.Errorf("API key name cannot be empty")
+	}
+	if _, err := ParseRole(string(role)); err != nil {
+		return "", nil, err
+	}
+
+	secret := make([]byte, 24)
+	id := make([]byte, 8)
+	if _, err := rand.Read(secret); err != nil {
+		return "", nil, fmt.Errorf("failed to generate API key: %w", err)
+	}
+	if _, err := rand.Read(id); err != nil {
+		return "", nil, fmt.Errorf("failed to generate API key: %w", err)
+	}
+
+	key := KeyPrefix + base64.RawURLEncoding.EncodeToString(secret)
+	apiKey := &APIKey{
+		ID:        hex.EncodeToString(id),
+		Name:      name,
+		Hint:      key[:len(KeyPrefix)+4],
+		Hash:      HashKey(key),
+		Role:      role,
+		Namespace: namespace,
+		CreatedAt: time.Now(),
+	}
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	st.keys[apiKey.Hash] = apiKey
+	if err := st.saveLocked(); err != nil {
+		delete(st.keys, apiKey.Hash)
+		return "", nil, err
+	}
+	return key, apiKey, nil
+}
+
+// Revoke deletes an issued API key. Static keys can only be removed from the
+// configuration.
+func (st *KeyStore) Revoke(id string) error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	for hash, key := range st.keys {
+		if key.ID != id {
+			continue
+		}
+		delete(st.keys, hash)
+		if err := st.saveLocked(); err != nil {
+			st.keys[hash] = key
+			return err
+		}
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrKeyNotFound, id)
+}
+
+// List returns the issued and static keys, oldest first
+func (st *KeyStore) List() []*APIKey {
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+
+	keys := make([]*APIKey, 0, len(st.keys)+len(st.static))
+	for _, key := range st.static {
+		keys = append(keys, key)
+	}
+	for _, key := range st.keys {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if !keys[i].CreatedAt.Equal(keys[j].CreatedAt) {
+			return keys[i].CreatedAt.Before(keys[j].CreatedAt)
+		}
+		return keys[i].Name < keys[j].Name
+	})
+	return keys
+}
+
+// Authenticate returns the API key matching key, or nil when the key is unknown.
+// Keys are looked up by hash, so response times do not reveal the stored keys.
+func (st *KeyStore) Authenticate(key string) *APIKey {
+	if key == "" {
+		return nil
+	}
+	hash := HashKey(key)
+
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+
+	if apiKey, exists := st.static[hash]; exists {
+		return apiKey
+	}
+	return st.keys[hash]
+}
+
+// saveLocked writes the issued keys to disk. The file is replaced atomically so a
+// failed write never loses the existing keys. Caller must hold the lock.
+func (st *KeyStore) saveLocked() error {
+	if st.path == "" {
+		return nil
+	}
+
+	keys := make([]*APIKey, 0, len(st.keys))
+	for _, key := range st.keys {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].CreatedAt.Before(keys[j].CreatedAt) })
+
+	data, err := json.MarshalIndent(keys, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode API keys: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(st.path), 0700); err != nil {
+		return fmt.Errorf("failed to create API keys directory: %w", err)
+	}
+	tmp := st.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write API keys: %w", err)
+	}
+	if err := os.Rename(tmp, st.path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("failed to save API keys: %w", err)
+	}
+	return nil
+}
+
+// HashKey returns the hex-encoded SHA-256 hash of an API key
+func HashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
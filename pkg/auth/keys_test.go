@@ -0,0 +1,81 @@
+package auth
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestKeyStoreIssue(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.json")
+	store, err := NewKeyStore(path)
+	if err != nil {
+		t.Fatalf("Failed to create key store: %v", err)
+	}
+
+	key, apiKey, err := store.Issue("ci", RoleOperator, "")
+	if err != nil {
+		t.Fatalf("Failed to issue key: %v", err)
+	}
+	if !strings.HasPrefix(key, KeyPrefix) || !strings.HasPrefix(key, apiKey.Hint) {
+		t.Errorf("Unexpected key %s with hint %s", key, apiKey.Hint)
+	}
+	if found := store.Authenticate(key); found == nil || found.ID != apiKey.ID {
+		t.Error("Expected the issued key to authenticate")
+	}
+	if store.Authenticate(key+"x") != nil || store.Authenticate("") != nil {
+		t.Error("Expected unknown keys to be rejected")
+	}
+
+	// Only the hash is persisted, and keys survive a restart
+	data, _ := os.ReadFile(path)
+	if strings.Contains(string(data), key) {
+		t.Error("Expected the key not to be stored in plain text")
+	}
+	reloaded, err := NewKeyStore(path)
+	if err != nil {
+		t.Fatalf("Failed to reload key store: %v", err)
+	}
+	if reloaded.Authenticate(key) == nil {
+		t.Error("Expected the issued key to be loaded again")
+	}
+
+	if err := reloaded.Revoke(apiKey.ID); err != nil {
+		t.Fatalf("Failed to revoke key: %v", err)
+	}
+	if reloaded.Authenticate(key) != nil {
+		t.Error("Expected the revoked key to be rejected")
+	}
+	if err := reloaded.Revoke(apiKey.ID); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("Expected ErrKeyNotFound, got %v", err)
+	}
+}
+
+func TestKeyStoreStatic(t *testing.T) {
+	store, _ := NewKeyStore("")
+
+	if err := store.AddStatic("admin", "secret-key", "", RoleAdmin, ""); err != nil {
+		t.Fatalf("Failed to add static key: %v", err)
+	}
+	if err := store.AddStatic("hashed", "", strings.ToUpper(HashKey("other-key")), RoleReadOnly, ""); err != nil {
+		t.Fatalf("Failed to add hashed static key: %v", err)
+	}
+	if err := store.AddStatic("invalid", "", "abc", RoleAdmin, ""); err == nil {
+		t.Error("Expected an invalid hash to be rejected")
+	}
+
+	if apiKey := store.Authenticate("secret-key"); apiKey == nil || !apiKey.Static || apiKey.Name != "admin" {
+		t.Error("Expected the static key to authenticate")
+	}
+	if store.Authenticate("other-key") == nil {
+		t.Error("Expected the hashed static key to authenticate")
+	}
+	if err := store.Revoke("static-admin"); err == nil {
+		t.Error("Expected static keys not to be revocable")
+	}
+	if len(store.List()) != 2 {
+		t.Errorf("Expected 2 keys, got %d", len(store.List()))
+	}
+}
@@ -0,0 +1,51 @@
+package auth
+
+import "fmt"
+
+// Role is the access level of a caller. Each role includes the permissions of the
+// roles below it.
+type Role string
+
+// Roles, from least to most privileged
+const (
+	// RoleReadOnly may read clusters, health, metrics and activity
+	RoleReadOnly Role = "read-only"
+	// RoleOperator may also operate on the data of services and drive their lifecycle
+	RoleOperator Role = "operator"
+	// RoleAdmin may also create, change and delete clusters and manage API keys
+	RoleAdmin Role = "admin"
+)
+
+var roleLevels = map[Role]int{
+	RoleReadOnly: 1,
+	RoleOperator: 2,
+	RoleAdmin:    3,
+}
+
+// ParseRole validates a role name
+func ParseRole(name string) (Role, error) {
+	role := Role(name)
+	if _, ok := roleLevels[role]; !ok {
+		return "", fmt.Errorf("unknown role %q, expected admin, operator or read-only", name)
+	}
+	return role, nil
+}
+
+// Allows reports whether the role grants the permissions of required
+func (r Role) Allows(required Role) bool {
+	level, ok := roleLevels[r]
+	return ok && level >= roleLevels[required]
+}
+
+// highestRole returns the most privileged valid role among names, or "" when none is
+// valid
+func highestRole(names []string) Role {
+	var highest Role
+	for _, name := range names {
+		role := Role(name)
+		if roleLevels[role] > roleLevels[highest] {
+			highest = role
+		}
+	}
+	return highest
+}
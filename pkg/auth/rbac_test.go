@@ -0,0 +1,35 @@
+package auth
+
+import "testing"
+
+func TestRoleAllows(t *testing.T) {
+	tests := []struct {
+		role     Role
+		required Role
+		allowed  bool
+	}{
+		{RoleAdmin, RoleAdmin, true},
+		{RoleAdmin, RoleReadOnly, true},
+		{RoleOperator, RoleOperator, true},
+		{RoleOperator, RoleAdmin, false},
+		{RoleReadOnly, RoleReadOnly, true},
+		{RoleReadOnly, RoleOperator, false},
+		{"", RoleReadOnly, false},
+		{"superuser", RoleReadOnly, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.role.Allows(tt.required); got != tt.allowed {
+			t.Errorf("%q.Allows(%q) = %v, expected %v", tt.role, tt.required, got, tt.allowed)
+		}
+	}
+}
+
+func TestHighestRole(t *testing.T) {
+	if role := highestRole([]string{"read-only", "unknown", "operator"}); role != RoleOperator {
+		t.Errorf("Expected operator, got %q", role)
+	}
+	if role := highestRole([]string{"unknown"}); role != "" {
+		t.Errorf("Expected no role, got %q", role)
+	}
+}
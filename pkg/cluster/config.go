@@ -1,20 +1,42 @@
 package cluster
 
 import (
+	"regexp"
 	"time"
 )
 
+// DefaultNamespace is the namespace of clusters created without one, including all
+// clusters created before namespaces existed
+const DefaultNamespace = "default"
+
+// namespacePattern matches DNS labels, so namespaces can be used in URLs, metric
+// labels and container names
+var namespacePattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
 // Config represents a cluster configuration
 type Config struct {
 	ClusterID   string                   `yaml:"cluster_id" json:"cluster_id"`
+	Namespace   string                   `yaml:"namespace,omitempty" json:"namespace,omitempty"` // Tenant owning the cluster
 	Name        string                   `yaml:"name" json:"name"`
 	Description string                   `yaml:"description,omitempty" json:"description,omitempty"`
 	Services    map[string]ServiceConfig `yaml:"services" json:"services"`
 	Routing     RoutingConfig            `yaml:"routing,omitempty" json:"routing,omitempty"`
 	Health      HealthConfig             `yaml:"health,omitempty" json:"health,omitempty"`
 	AI          AIConfig                 `yaml:"ai,omitempty" json:"ai,omitempty"`
+	Activity    ActivityConfig           `yaml:"activity,omitempty" json:"activity,omitempty"`
+	Docker      *DockerHostConfig        `yaml:"docker,omitempty" json:"docker,omitempty"` // Docker host provisioning the cluster's services, the gateway's unless set
 	CreatedAt   time.Time                `yaml:"created_at,omitempty" json:"created_at,omitempty"`
 	UpdatedAt   time.Time                `yaml:"updated_at,omitempty" json:"updated_at,omitempty"`
+	ArchivedAt  *time.Time               `yaml:"archived_at,omitempty" json:"archived_at,omitempty"` // Set while the cluster is archived
+	DrainedAt   *time.Time               `yaml:"drained_at,omitempty" json:"drained_at,omitempty"`   // Set while the cluster is drained
+}
+
+// DockerHostConfig selects the Docker host that runs a cluster's containers
+type DockerHostConfig struct {
+	Host     string `yaml:"host" json:"host"` // e.g. tcp://builder-1:2376
+	CAFile   string `yaml:"ca_file,omitempty" json:"ca_file,omitempty"`
+	CertFile string `yaml:"cert_file,omitempty" json:"cert_file,omitempty"`
+	KeyFile  string `yaml:"key_file,omitempty" json:"key_file,omitempty"`
 }
 
 // ServiceConfig represents configuration for a single infrastructure service
@@ -30,8 +52,11 @@ type ServiceConfig struct {
 	Options     map[string]interface{} `yaml:"options,omitempty" json:"options,omitempty"`           // Service-specific options
 	Pool        PoolConfig             `yaml:"pool,omitempty" json:"pool,omitempty"`
 	TLS         TLSConfig              `yaml:"tls,omitempty" json:"tls,omitempty"`
-	Weight      int                    `yaml:"weight,omitempty" json:"weight,omitempty"` // For weighted routing
+	DeadLetter  DeadLetterConfig       `yaml:"dead_letter,omitempty" json:"dead_letter,omitempty"` // For queues
+	Weight      int                    `yaml:"weight,omitempty" json:"weight,omitempty"`           // For weighted routing
 	Replicas    []ReplicaConfig        `yaml:"replicas,omitempty" json:"replicas,omitempty"`
+	Probes      []ProbeConfig          `yaml:"probes,omitempty" json:"probes,omitempty"`       // Application-level health checks
+	Container   ContainerConfig        `yaml:"container,omitempty" json:"container,omitempty"` // For services provisioned by Throome
 }
 
 // PoolConfig represents connection pool configuration
@@ -51,21 +76,79 @@ type TLSConfig struct {
 	InsecureSkipVerify bool   `yaml:"insecure_skip_verify,omitempty" json:"insecure_skip_verify,omitempty"`
 }
 
+// DeadLetterConfig represents dead-letter handling for gateway-managed consumers
+type DeadLetterConfig struct {
+	Enabled        bool   `yaml:"enabled" json:"enabled"`
+	MaxRetries     int    `yaml:"max_retries,omitempty" json:"max_retries,omitempty"`           // Retries after the first delivery attempt
+	RetryBackoffMS int    `yaml:"retry_backoff_ms,omitempty" json:"retry_backoff_ms,omitempty"` // Delay before the first retry, doubled for each one after
+	TopicSuffix    string `yaml:"topic_suffix,omitempty" json:"topic_suffix,omitempty"`         // Appended to the topic name, defaults to ".dlq"
+}
+
+// ProbeConfig represents an application-level health check run against a service
+type ProbeConfig struct {
+	Name      string `yaml:"name" json:"name"`
+	Type      string `yaml:"type" json:"type"`                                 // sql, cache_get, topic_exists
+	Query     string `yaml:"query,omitempty" json:"query,omitempty"`           // For sql: must return at least one row
+	Key       string `yaml:"key,omitempty" json:"key,omitempty"`               // For cache_get: must exist
+	Topic     string `yaml:"topic,omitempty" json:"topic,omitempty"`           // For topic_exists
+	Expect    string `yaml:"expect,omitempty" json:"expect,omitempty"`         // Expected value of a single-column sql row or of the cached key, optional
+	TimeoutMS int    `yaml:"timeout_ms,omitempty" json:"timeout_ms,omitempty"` // Defaults to the health check timeout
+}
+
+// Restart policies of provisioned containers
+const (
+	RestartNo            = "no"
+	RestartOnFailure     = "on-failure"
+	RestartUnlessStopped = "unless-stopped"
+)
+
+// ContainerConfig tunes the container Throome provisions for a service. Unset values
+// keep the defaults of the service's type.
+type ContainerConfig struct {
+	RestartPolicy string                `yaml:"restart_policy,omitempty" json:"restart_policy,omitempty"` // no, on-failure or unless-stopped, unless-stopped by default
+	StopTimeout   int                   `yaml:"stop_timeout,omitempty" json:"stop_timeout,omitempty"`     // seconds to stop before the container is killed, 10 by default
+	HealthCheck   ContainerHealthConfig `yaml:"health_check,omitempty" json:"health_check,omitempty"`
+}
+
+// ContainerHealthConfig tunes the health check of a provisioned container
+type ContainerHealthConfig struct {
+	Interval    int `yaml:"interval,omitempty" json:"interval,omitempty"`         // seconds
+	Retries     int `yaml:"retries,omitempty" json:"retries,omitempty"`           // consecutive failures before the container is unhealthy
+	StartPeriod int `yaml:"start_period,omitempty" json:"start_period,omitempty"` // seconds during which failures are not counted
+}
+
+// Roles of a service's replicas
+const (
+	RolePrimary  = "primary"  // Takes writes as well as reads
+	RoleReplica  = "replica"  // Takes reads, the role of replicas that set none
+	RoleReadOnly = "readonly" // Takes reads
+)
+
 // ReplicaConfig represents a replica of a service
 type ReplicaConfig struct {
-	Host   string `yaml:"host" json:"host"`
-	Port   int    `yaml:"port" json:"port"`
-	Role   string `yaml:"role,omitempty" json:"role,omitempty"` // primary, replica, readonly
-	Weight int    `yaml:"weight,omitempty" json:"weight,omitempty"`
+	Host        string `yaml:"host" json:"host"`
+	Port        int    `yaml:"port" json:"port"`
+	Role        string `yaml:"role,omitempty" json:"role,omitempty"` // primary, replica, readonly
+	Weight      int    `yaml:"weight,omitempty" json:"weight,omitempty"`
+	ContainerID string `yaml:"container_id,omitempty" json:"container_id,omitempty"` // Docker container ID (if provisioned by Throome)
 }
 
 // RoutingConfig represents routing strategy configuration
 type RoutingConfig struct {
-	Strategy        string   `yaml:"strategy" json:"strategy"` // round_robin, weighted, least_connections, ai
+	Strategy        string   `yaml:"strategy" json:"strategy"` // round_robin, weighted, least_connections, ai, latency, or a registered strategy
 	FailoverEnabled bool     `yaml:"failover_enabled" json:"failover_enabled"`
 	TimeoutMS       int      `yaml:"timeout_ms,omitempty" json:"timeout_ms,omitempty"`
 	RetryAttempts   int      `yaml:"retry_attempts,omitempty" json:"retry_attempts,omitempty"`
 	CircuitBreaker  CBConfig `yaml:"circuit_breaker,omitempty" json:"circuit_breaker,omitempty"`
+
+	Sticky StickyConfig `yaml:"sticky,omitempty" json:"sticky,omitempty"`
+}
+
+// StickyConfig pins the requests of a client, identified by its API key or client ID,
+// to the service instance it was first routed to
+type StickyConfig struct {
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	TTL     int  `yaml:"ttl,omitempty" json:"ttl,omitempty"` // seconds without requests before a client is routed again, 300 by default
 }
 
 // CBConfig represents circuit breaker configuration
@@ -81,6 +164,32 @@ type HealthConfig struct {
 	Interval  int  `yaml:"interval,omitempty" json:"interval,omitempty"`   // seconds
 	Timeout   int  `yaml:"timeout,omitempty" json:"timeout,omitempty"`     // seconds
 	Threshold int  `yaml:"threshold,omitempty" json:"threshold,omitempty"` // consecutive failures
+
+	AutoRestart AutoRestartConfig `yaml:"auto_restart,omitempty" json:"auto_restart,omitempty"`
+}
+
+// AutoRestartConfig restarts the containers of provisioned services that turn
+// unhealthy. Each retry waits twice as long as the one before.
+type AutoRestartConfig struct {
+	Enabled     bool `yaml:"enabled" json:"enabled"`
+	MaxRestarts int  `yaml:"max_restarts,omitempty" json:"max_restarts,omitempty"` // restarts before giving up, 3 by default
+	Backoff     int  `yaml:"backoff,omitempty" json:"backoff,omitempty"`           // seconds before the first retry, 10 by default
+}
+
+// Redactions of the commands of activity logs
+const (
+	RedactionNone         = "none"          // Commands are logged as run
+	RedactionStripArgs    = "strip_args"    // Query arguments are dropped
+	RedactionHashLiterals = "hash_literals" // Arguments and literals are replaced with a hash of their value
+	RedactionTruncate     = "truncate"      // Commands are cut after max_length characters
+)
+
+// ActivityConfig controls how the operations of a cluster are recorded in the activity
+// log. Failed operations are always logged.
+type ActivityConfig struct {
+	Redaction  string  `yaml:"redaction,omitempty" json:"redaction,omitempty"`     // none by default
+	MaxLength  int     `yaml:"max_length,omitempty" json:"max_length,omitempty"`   // characters kept by truncate, 256 by default
+	SampleRate float64 `yaml:"sample_rate,omitempty" json:"sample_rate,omitempty"` // share of successful operations logged, every one when 0
 }
 
 // AIConfig represents AI optimization configuration
@@ -138,6 +247,47 @@ func DefaultPoolConfig() PoolConfig {
 	}
 }
 
+// IsArchived reports whether the cluster has been archived
+func (c *Config) IsArchived() bool {
+	return c.ArchivedAt != nil
+}
+
+// IsDrained reports whether the cluster is drained, refusing data writes
+func (c *Config) IsDrained() bool {
+	return c.DrainedAt != nil
+}
+
+// NamespaceOrDefault returns the namespace of the cluster, or the default namespace
+// when none is set
+func (c *Config) NamespaceOrDefault() string {
+	if c.Namespace == "" {
+		return DefaultNamespace
+	}
+	return c.Namespace
+}
+
+// ValidateNamespace checks that a namespace is a lowercase DNS label
+func ValidateNamespace(namespace string) error {
+	if !namespacePattern.MatchString(namespace) {
+		return ErrInvalidClusterConfig{
+			Field:   "namespace",
+			Message: "must be at most 63 lowercase letters, digits or dashes, starting and ending with a letter or digit",
+		}
+	}
+	return nil
+}
+
+// Clone returns a copy of the configuration whose services can be changed without
+// affecting the original. Service options are shared.
+func (c *Config) Clone() *Config {
+	clone := *c
+	clone.Services = make(map[string]ServiceConfig, len(c.Services))
+	for name, svc := range c.Services {
+		clone.Services[name] = svc
+	}
+	return &clone
+}
+
 // Validate validates the cluster configuration
 func (c *Config) Validate() error {
 	if c.ClusterID == "" {
@@ -148,10 +298,58 @@ func (c *Config) Validate() error {
 		return ErrInvalidClusterConfig{Field: "name", Message: "cannot be empty"}
 	}
 
+	if c.Namespace != "" {
+		if err := ValidateNamespace(c.Namespace); err != nil {
+			return err
+		}
+	}
+
 	if len(c.Services) == 0 {
 		return ErrInvalidClusterConfig{Field: "services", Message: "at least one service is required"}
 	}
 
+	if c.Docker != nil && c.Docker.Host == "" {
+		return ErrInvalidClusterConfig{Field: "docker.host", Message: "cannot be empty"}
+	}
+
+	if c.Routing.Strategy != "" && !IsRoutingStrategyRegistered(c.Routing.Strategy) {
+		return ErrInvalidClusterConfig{Field: "routing.strategy", Message: "unsupported strategy: " + c.Routing.Strategy}
+	}
+
+	if c.Routing.TimeoutMS < 0 {
+		return ErrInvalidClusterConfig{Field: "routing.timeout_ms", Message: "cannot be negative"}
+	}
+
+	if c.Routing.RetryAttempts < 0 {
+		return ErrInvalidClusterConfig{Field: "routing.retry_attempts", Message: "cannot be negative"}
+	}
+
+	if c.Routing.CircuitBreaker.FailureThreshold < 0 {
+		return ErrInvalidClusterConfig{Field: "routing.circuit_breaker.failure_threshold", Message: "cannot be negative"}
+	}
+
+	if c.Routing.CircuitBreaker.ResetTimeout < 0 {
+		return ErrInvalidClusterConfig{Field: "routing.circuit_breaker.reset_timeout", Message: "cannot be negative"}
+	}
+
+	if c.Routing.Sticky.TTL < 0 {
+		return ErrInvalidClusterConfig{Field: "routing.sticky.ttl", Message: "cannot be negative"}
+	}
+
+	switch c.Activity.Redaction {
+	case "", RedactionNone, RedactionStripArgs, RedactionHashLiterals, RedactionTruncate:
+	default:
+		return ErrInvalidClusterConfig{Field: "activity.redaction", Message: "unsupported redaction: " + c.Activity.Redaction}
+	}
+
+	if c.Activity.MaxLength < 0 {
+		return ErrInvalidClusterConfig{Field: "activity.max_length", Message: "cannot be negative"}
+	}
+
+	if c.Activity.SampleRate < 0 || c.Activity.SampleRate > 1 {
+		return ErrInvalidClusterConfig{Field: "activity.sample_rate", Message: "must be between 0 and 1"}
+	}
+
 	for name := range c.Services {
 		svc := c.Services[name]
 		if err := svc.Validate(); err != nil {
@@ -162,22 +360,28 @@ func (c *Config) Validate() error {
 	return nil
 }
 
+// ContainerIDs returns the IDs of the containers Throome provisioned for a service
+// and its replicas
+func (s *ServiceConfig) ContainerIDs() []string {
+	var containerIDs []string
+	if s.ContainerID != "" {
+		containerIDs = append(containerIDs, s.ContainerID)
+	}
+	for _, replica := range s.Replicas {
+		if replica.ContainerID != "" {
+			containerIDs = append(containerIDs, replica.ContainerID)
+		}
+	}
+	return containerIDs
+}
+
 // Validate validates a service configuration
 func (s *ServiceConfig) Validate() error {
 	if s.Type == "" {
 		return ErrInvalidClusterConfig{Field: "type", Message: "cannot be empty"}
 	}
 
-	validTypes := map[string]bool{
-		"postgres": true,
-		"redis":    true,
-		"kafka":    true,
-		"mongodb":  true,
-		"mysql":    true,
-		"rabbitmq": true,
-	}
-
-	if !validTypes[s.Type] {
+	if !IsServiceTypeRegistered(s.Type) {
 		return ErrInvalidClusterConfig{Field: "type", Message: "unsupported service type: " + s.Type}
 	}
 
@@ -189,6 +393,74 @@ func (s *ServiceConfig) Validate() error {
 		return ErrInvalidClusterConfig{Field: "port", Message: "must be between 1 and 65535"}
 	}
 
+	for i := range s.Probes {
+		if err := s.Probes[i].Validate(s.Type); err != nil {
+			return err
+		}
+	}
+
+	for _, replica := range s.Replicas {
+		switch replica.Role {
+		case "", RolePrimary, RoleReplica, RoleReadOnly:
+		default:
+			return ErrInvalidClusterConfig{Field: "replicas.role", Message: "must be primary, replica or readonly"}
+		}
+	}
+
+	return s.Container.Validate()
+}
+
+// Validate validates the container settings of a service
+func (c *ContainerConfig) Validate() error {
+	switch c.RestartPolicy {
+	case "", RestartNo, RestartOnFailure, RestartUnlessStopped:
+	default:
+		return ErrInvalidClusterConfig{Field: "container.restart_policy", Message: "must be no, on-failure or unless-stopped"}
+	}
+
+	if c.StopTimeout < 0 {
+		return ErrInvalidClusterConfig{Field: "container.stop_timeout", Message: "cannot be negative"}
+	}
+	if c.HealthCheck.Interval < 0 || c.HealthCheck.Retries < 0 || c.HealthCheck.StartPeriod < 0 {
+		return ErrInvalidClusterConfig{Field: "container.health_check", Message: "cannot be negative"}
+	}
+
+	return nil
+}
+
+// Validate validates a probe against the type of the service it runs on
+func (p *ProbeConfig) Validate(serviceType string) error {
+	if p.Name == "" {
+		return ErrInvalidClusterConfig{Field: "probes", Message: "probe name cannot be empty"}
+	}
+
+	field := "probes." + p.Name
+	switch p.Type {
+	case "sql":
+		if serviceType != "postgres" && serviceType != "mysql" {
+			return ErrInvalidClusterConfig{Field: field, Message: "sql probes require a database service"}
+		}
+		if p.Query == "" {
+			return ErrInvalidClusterConfig{Field: field, Message: "query cannot be empty"}
+		}
+	case "cache_get":
+		if serviceType != "redis" {
+			return ErrInvalidClusterConfig{Field: field, Message: "cache_get probes require a cache service"}
+		}
+		if p.Key == "" {
+			return ErrInvalidClusterConfig{Field: field, Message: "key cannot be empty"}
+		}
+	case "topic_exists":
+		if serviceType != "kafka" {
+			return ErrInvalidClusterConfig{Field: field, Message: "topic_exists probes require a queue service"}
+		}
+		if p.Topic == "" {
+			return ErrInvalidClusterConfig{Field: field, Message: "topic cannot be empty"}
+		}
+	default:
+		return ErrInvalidClusterConfig{Field: field, Message: "unsupported probe type: " + p.Type}
+	}
+
 	return nil
 }
 
@@ -124,6 +124,86 @@ func TestConfigValidate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "docker host without address",
+			config: &Config{
+				ClusterID: "test-01",
+				Name:      "Test",
+				Docker:    &DockerHostConfig{CAFile: "/certs/ca.pem"},
+				Services: map[string]ServiceConfig{
+					"cache": {
+						Type: "redis",
+						Host: "localhost",
+						Port: 6379,
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative circuit breaker threshold",
+			config: &Config{
+				ClusterID: "test-01",
+				Name:      "Test",
+				Routing:   RoutingConfig{CircuitBreaker: CBConfig{Enabled: true, FailureThreshold: -1}},
+				Services: map[string]ServiceConfig{
+					"cache": {
+						Type: "redis",
+						Host: "localhost",
+						Port: 6379,
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "unknown routing strategy",
+			config: &Config{
+				ClusterID: "test-01",
+				Name:      "Test",
+				Routing:   RoutingConfig{Strategy: "random"},
+				Services: map[string]ServiceConfig{
+					"cache": {
+						Type: "redis",
+						Host: "localhost",
+						Port: 6379,
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "unknown activity redaction",
+			config: &Config{
+				ClusterID: "test-01",
+				Name:      "Test",
+				Activity:  ActivityConfig{Redaction: "mask"},
+				Services: map[string]ServiceConfig{
+					"cache": {
+						Type: "redis",
+						Host: "localhost",
+						Port: 6379,
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "activity sample rate above 1",
+			config: &Config{
+				ClusterID: "test-01",
+				Name:      "Test",
+				Activity:  ActivityConfig{SampleRate: 1.5},
+				Services: map[string]ServiceConfig{
+					"cache": {
+						Type: "redis",
+						Host: "localhost",
+						Port: 6379,
+					},
+				},
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -194,6 +274,60 @@ func TestServiceConfigValidate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "valid sql probe",
+			service: ServiceConfig{
+				Type:   "postgres",
+				Host:   "localhost",
+				Port:   5432,
+				Probes: []ProbeConfig{{Name: "migrated", Type: "sql", Query: "SELECT 1 FROM schema_migrations"}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "probe type mismatch",
+			service: ServiceConfig{
+				Type:   "redis",
+				Host:   "localhost",
+				Port:   6379,
+				Probes: []ProbeConfig{{Name: "migrated", Type: "sql", Query: "SELECT 1"}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "probe missing key",
+			service: ServiceConfig{
+				Type:   "redis",
+				Host:   "localhost",
+				Port:   6379,
+				Probes: []ProbeConfig{{Name: "warm", Type: "cache_get"}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "valid container settings",
+			service: ServiceConfig{
+				Type: "kafka",
+				Host: "localhost",
+				Port: 9092,
+				Container: ContainerConfig{
+					RestartPolicy: RestartOnFailure,
+					StopTimeout:   30,
+					HealthCheck:   ContainerHealthConfig{Interval: 10, Retries: 30, StartPeriod: 120},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid restart policy",
+			service: ServiceConfig{
+				Type:      "redis",
+				Host:      "localhost",
+				Port:      6379,
+				Container: ContainerConfig{RestartPolicy: "always"},
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -41,6 +41,11 @@ func (l *Loader) Load(clusterID string) (*Config, error) {
 		return nil, fmt.Errorf("failed to parse config: %w", err)
 	}
 
+	// Clusters created before namespaces existed belong to the default namespace
+	if config.Namespace == "" {
+		config.Namespace = DefaultNamespace
+	}
+
 	// Validate
 	if err := config.Validate(); err != nil {
 		return nil, fmt.Errorf("invalid config: %w", err)
@@ -40,6 +40,9 @@ func (m *Manager) Create(name string, config *Config) (string, error) {
 
 	// Set metadata
 	config.Name = name
+	if config.Namespace == "" {
+		config.Namespace = DefaultNamespace
+	}
 	config.CreatedAt = time.Now()
 	config.UpdatedAt = time.Now()
 
@@ -127,6 +130,91 @@ func (m *Manager) Delete(clusterID string) error {
 	return nil
 }
 
+// Archive marks a cluster as archived. Its configuration is kept on disk until the
+// cluster is purged with Delete.
+func (m *Manager) Archive(clusterID string) (*Config, error) {
+	return m.setArchived(clusterID, true)
+}
+
+// Restore clears the archived mark of a cluster
+func (m *Manager) Restore(clusterID string) (*Config, error) {
+	return m.setArchived(clusterID, false)
+}
+
+func (m *Manager) setArchived(clusterID string, archived bool) (*Config, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	config, err := m.loader.Load(clusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	if config.IsArchived() == archived {
+		if archived {
+			return nil, fmt.Errorf("cluster already archived: %s", clusterID)
+		}
+		return nil, fmt.Errorf("cluster is not archived: %s", clusterID)
+	}
+
+	now := time.Now()
+	config.ArchivedAt = nil
+	if archived {
+		config.ArchivedAt = &now
+	}
+	config.UpdatedAt = now
+
+	if err := m.loader.Save(config); err != nil {
+		return nil, fmt.Errorf("failed to save cluster: %w", err)
+	}
+
+	m.registry.Register(clusterID, config)
+
+	return config, nil
+}
+
+// Drain marks a cluster as drained
+func (m *Manager) Drain(clusterID string) (*Config, error) {
+	return m.setDrained(clusterID, true)
+}
+
+// Resume clears the drained mark of a cluster
+func (m *Manager) Resume(clusterID string) (*Config, error) {
+	return m.setDrained(clusterID, false)
+}
+
+func (m *Manager) setDrained(clusterID string, drained bool) (*Config, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	config, err := m.loader.Load(clusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	if config.IsDrained() == drained {
+		if drained {
+			return nil, fmt.Errorf("cluster already drained: %s", clusterID)
+		}
+		return nil, fmt.Errorf("cluster is not drained: %s", clusterID)
+	}
+
+	now := time.Now()
+	config.DrainedAt = nil
+	if drained {
+		config.DrainedAt = &now
+	}
+	config.UpdatedAt = now
+
+	if err := m.loader.Save(config); err != nil {
+		return nil, fmt.Errorf("failed to save cluster: %w", err)
+	}
+
+	m.registry.Register(clusterID, config)
+
+	return config, nil
+}
+
 // List lists all clusters
 func (m *Manager) List() ([]string, error) {
 	m.mu.RLock()
@@ -160,6 +248,22 @@ func (m *Manager) GetAllConfigs() map[string]*Config {
 	return m.registry.GetAll()
 }
 
+// GetNamespaceConfigs returns the loaded cluster configurations of a namespace
+func (m *Manager) GetNamespaceConfigs(namespace string) map[string]*Config {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.registry.GetNamespace(namespace)
+}
+
+// Namespaces returns the namespaces of the loaded clusters
+func (m *Manager) Namespaces() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.registry.Namespaces()
+}
+
 // Exists checks if a cluster exists
 func (m *Manager) Exists(clusterID string) bool {
 	m.mu.RLock()
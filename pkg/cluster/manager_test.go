@@ -211,3 +211,161 @@ func TestManagerExists(t *testing.T) {
 		t.Error("Expected cluster to not exist")
 	}
 }
+
+func TestManagerArchive(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "throome-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	manager := NewManager(tmpDir)
+
+	config := DefaultConfig("", "test-cluster")
+	config.Services = map[string]ServiceConfig{
+		"cache": {
+			Type: "redis",
+			Host: "localhost",
+			Port: 6379,
+		},
+	}
+
+	clusterID, err := manager.Create("test-cluster", config)
+	if err != nil {
+		t.Fatalf("Failed to create cluster: %v", err)
+	}
+
+	if _, err := manager.Archive(clusterID); err != nil {
+		t.Fatalf("Failed to archive cluster: %v", err)
+	}
+	if _, err := manager.Archive(clusterID); err == nil {
+		t.Error("Expected error when archiving an archived cluster")
+	}
+
+	// The archived mark and configuration survive a reload from disk
+	reloaded, err := NewManager(tmpDir).Get(clusterID)
+	if err != nil {
+		t.Fatalf("Failed to load archived cluster: %v", err)
+	}
+	if !reloaded.IsArchived() {
+		t.Error("Expected reloaded cluster to be archived")
+	}
+	if len(reloaded.Services) != 1 {
+		t.Errorf("Expected archived cluster to keep its services, got %d", len(reloaded.Services))
+	}
+
+	restored, err := manager.Restore(clusterID)
+	if err != nil {
+		t.Fatalf("Failed to restore cluster: %v", err)
+	}
+	if restored.IsArchived() {
+		t.Error("Expected restored cluster not to be archived")
+	}
+	if _, err := manager.Restore(clusterID); err == nil {
+		t.Error("Expected error when restoring a cluster that is not archived")
+	}
+}
+
+func TestManagerDrain(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "throome-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	manager := NewManager(tmpDir)
+
+	config := DefaultConfig("", "test-cluster")
+	config.Services = map[string]ServiceConfig{
+		"cache": {
+			Type: "redis",
+			Host: "localhost",
+			Port: 6379,
+		},
+	}
+
+	clusterID, err := manager.Create("test-cluster", config)
+	if err != nil {
+		t.Fatalf("Failed to create cluster: %v", err)
+	}
+
+	if _, err := manager.Drain(clusterID); err != nil {
+		t.Fatalf("Failed to drain cluster: %v", err)
+	}
+	if _, err := manager.Drain(clusterID); err == nil {
+		t.Error("Expected error when draining a drained cluster")
+	}
+
+	// The drained mark survives a reload from disk
+	reloaded, err := NewManager(tmpDir).Get(clusterID)
+	if err != nil {
+		t.Fatalf("Failed to load drained cluster: %v", err)
+	}
+	if !reloaded.IsDrained() {
+		t.Error("Expected reloaded cluster to be drained")
+	}
+
+	resumed, err := manager.Resume(clusterID)
+	if err != nil {
+		t.Fatalf("Failed to resume cluster: %v", err)
+	}
+	if resumed.IsDrained() {
+		t.Error("Expected resumed cluster not to be drained")
+	}
+	if _, err := manager.Resume(clusterID); err == nil {
+		t.Error("Expected error when resuming a cluster that is not drained")
+	}
+}
+
+func TestManagerNamespaces(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "throome-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	manager := NewManager(tmpDir)
+
+	for i, namespace := range []string{"", "team-a", "team-a", "team-b"} {
+		config := DefaultConfig("", fmt.Sprintf("test-cluster-%d", i))
+		config.Namespace = namespace
+		config.Services = map[string]ServiceConfig{
+			"cache": {
+				Type: "redis",
+				Host: "localhost",
+				Port: 6379,
+			},
+		}
+
+		if _, err := manager.Create(config.Name, config); err != nil {
+			t.Fatalf("Failed to create cluster %d: %v", i, err)
+		}
+	}
+
+	namespaces := manager.Namespaces()
+	if fmt.Sprint(namespaces) != "[default team-a team-b]" {
+		t.Errorf("Expected namespaces [default team-a team-b], got %v", namespaces)
+	}
+
+	if got := len(manager.GetNamespaceConfigs("team-a")); got != 2 {
+		t.Errorf("Expected 2 clusters in team-a, got %d", got)
+	}
+
+	// The namespace is persisted with the cluster
+	reloaded := NewManager(tmpDir)
+	if err := reloaded.LoadAll(); err != nil {
+		t.Fatalf("Failed to load clusters: %v", err)
+	}
+	if got := len(reloaded.GetNamespaceConfigs("team-b")); got != 1 {
+		t.Errorf("Expected 1 cluster in team-b after reload, got %d", got)
+	}
+
+	config := DefaultConfig("invalid", "invalid")
+	config.Namespace = "Team_A"
+	config.Services = map[string]ServiceConfig{
+		"cache": {Type: "redis", Host: "localhost", Port: 6379},
+	}
+	if _, err := manager.Create("invalid", config); err == nil {
+		t.Error("Expected an invalid namespace to be rejected")
+	}
+}
@@ -1,6 +1,7 @@
 package cluster
 
 import (
+	"sort"
 	"sync"
 )
 
@@ -55,6 +56,40 @@ func (r *Registry) GetAll() map[string]*Config {
 	return result
 }
 
+// GetNamespace returns the registered clusters of a namespace
+func (r *Registry) GetNamespace(namespace string) map[string]*Config {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := make(map[string]*Config)
+	for id, config := range r.clusters {
+		if config.NamespaceOrDefault() == namespace {
+			result[id] = config
+		}
+	}
+
+	return result
+}
+
+// Namespaces returns the sorted namespaces that have registered clusters
+func (r *Registry) Namespaces() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	seen := make(map[string]bool)
+	namespaces := make([]string, 0)
+	for _, config := range r.clusters {
+		namespace := config.NamespaceOrDefault()
+		if !seen[namespace] {
+			seen[namespace] = true
+			namespaces = append(namespaces, namespace)
+		}
+	}
+	sort.Strings(namespaces)
+
+	return namespaces
+}
+
 // Exists checks if a cluster is registered
 func (r *Registry) Exists(clusterID string) bool {
 	r.mu.RLock()
@@ -0,0 +1,125 @@
+package cluster
+
+import "strings"
+
+// RedactedSecret replaces the credentials of services in API responses
+const RedactedSecret = "[REDACTED]"
+
+// secretOptions are the service options that hold credentials
+var secretOptions = map[string]bool{
+	"token":      true, // InfluxDB
+	"password":   true,
+	"secret_key": true,
+	"api_key":    true,
+}
+
+// envOption is the service option holding environment variables of provisioned
+// containers, whose credentials are redacted by name
+const envOption = "env"
+
+// secretEnvParts mark the environment variables that hold credentials, such as
+// POSTGRES_PASSWORD or MINIO_ROOT_PASSWORD
+var secretEnvParts = []string{"PASSWORD", "SECRET", "TOKEN", "KEY"}
+
+// secretEnvVariable reports whether an environment variable holds a credential
+func secretEnvVariable(name string) bool {
+	name = strings.ToUpper(name)
+	for _, part := range secretEnvParts {
+		if strings.Contains(name, part) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactedEnv returns a copy of the env option with its credentials redacted.
+// Options that are not a map of variables are returned as they are.
+func redactedEnv(value interface{}) interface{} {
+	env, ok := value.(map[string]interface{})
+	if !ok {
+		return value
+	}
+	redacted := make(map[string]interface{}, len(env))
+	for name, variable := range env {
+		if secretEnvVariable(name) && variable != "" {
+			variable = RedactedSecret
+		}
+		redacted[name] = variable
+	}
+	return redacted
+}
+
+// restoredEnv returns a copy of the env option whose redacted variables are
+// replaced by those of the current env option
+func restoredEnv(value, current interface{}) interface{} {
+	env, ok := value.(map[string]interface{})
+	if !ok {
+		return value
+	}
+	currentEnv, _ := current.(map[string]interface{})
+	restored := make(map[string]interface{}, len(env))
+	for name, variable := range env {
+		if variable == RedactedSecret {
+			variable = currentEnv[name]
+		}
+		restored[name] = variable
+	}
+	return restored
+}
+
+// Redacted returns a copy of the service configuration with its credentials,
+// including the credentials among its env option, replaced by RedactedSecret.
+// Empty credentials stay empty.
+func (s ServiceConfig) Redacted() ServiceConfig {
+	if s.Password != "" {
+		s.Password = RedactedSecret
+	}
+	if len(s.Options) > 0 {
+		options := make(map[string]interface{}, len(s.Options))
+		for key, value := range s.Options {
+			switch {
+			case key == envOption:
+				value = redactedEnv(value)
+			case secretOptions[key] && value != "":
+				value = RedactedSecret
+			}
+			options[key] = value
+		}
+		s.Options = options
+	}
+	return s
+}
+
+// WithSecretsFrom returns a copy of the service configuration whose redacted
+// credentials are replaced by those of the current configuration, so that a
+// configuration read from the API can be sent back without revealing its secrets
+func (s ServiceConfig) WithSecretsFrom(current ServiceConfig) ServiceConfig {
+	if s.Password == RedactedSecret {
+		s.Password = current.Password
+	}
+	if len(s.Options) > 0 {
+		options := make(map[string]interface{}, len(s.Options))
+		for key, value := range s.Options {
+			switch {
+			case key == envOption:
+				value = restoredEnv(value, current.Options[key])
+			case value == RedactedSecret:
+				value = current.Options[key]
+			}
+			options[key] = value
+		}
+		s.Options = options
+	}
+	return s
+}
+
+// Redacted returns a copy of the cluster configuration with the credentials of its
+// services redacted
+func (c *Config) Redacted() *Config {
+	redacted := *c
+	redacted.Services = make(map[string]ServiceConfig, len(c.Services))
+	for serviceName, serviceConfig := range c.Services {
+		redacted.Services[serviceName] = serviceConfig.Redacted()
+	}
+	return &redacted
+}
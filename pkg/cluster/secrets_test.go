@@ -0,0 +1,71 @@
+package cluster
+
+import "testing"
+
+func TestServiceConfigRedacted(t *testing.T) {
+	service := ServiceConfig{
+		Type:     "influxdb",
+		Username: "admin",
+		Password: "hunter2",
+		Options:  map[string]interface{}{"token": "secret-token", "org": "acme"},
+	}
+
+	redacted := service.Redacted()
+	if redacted.Password != RedactedSecret || redacted.Options["token"] != RedactedSecret {
+		t.Errorf("Expected credentials to be redacted, got %+v", redacted)
+	}
+	if redacted.Username != "admin" || redacted.Options["org"] != "acme" {
+		t.Errorf("Expected other settings to be kept, got %+v", redacted)
+	}
+	if service.Password != "hunter2" || service.Options["token"] != "secret-token" {
+		t.Error("Expected redaction to leave the original configuration alone")
+	}
+	if empty := (ServiceConfig{Type: "redis"}).Redacted(); empty.Password != "" {
+		t.Errorf("Expected an empty password to stay empty, got %q", empty.Password)
+	}
+
+	// Redacted credentials sent back keep their values, new ones replace them
+	restored := redacted.WithSecretsFrom(service)
+	if restored.Password != "hunter2" || restored.Options["token"] != "secret-token" {
+		t.Errorf("Expected redacted credentials to be restored, got %+v", restored)
+	}
+	redacted.Password = "changed"
+	if restored := redacted.WithSecretsFrom(service); restored.Password != "changed" {
+		t.Errorf("Expected a new password to be kept, got %q", restored.Password)
+	}
+}
+
+func TestServiceConfigRedactedEnv(t *testing.T) {
+	service := ServiceConfig{
+		Type: "postgres",
+		Options: map[string]interface{}{"env": map[string]interface{}{
+			"POSTGRES_PASSWORD":     "hunter2",
+			"minio_root_password":   "s3cret",
+			"AWS_SECRET_ACCESS_KEY": "aws-secret",
+			"API_TOKEN":             "token",
+			"EMPTY_PASSWORD":        "",
+			"POSTGRES_INITDB_ARGS":  "--data-checksums",
+		}},
+	}
+
+	redacted := service.Redacted()
+	env := redacted.Options["env"].(map[string]interface{})
+	for _, name := range []string{"POSTGRES_PASSWORD", "minio_root_password", "AWS_SECRET_ACCESS_KEY", "API_TOKEN"} {
+		if env[name] != RedactedSecret {
+			t.Errorf("Expected %s to be redacted, got %v", name, env[name])
+		}
+	}
+	if env["POSTGRES_INITDB_ARGS"] != "--data-checksums" || env["EMPTY_PASSWORD"] != "" {
+		t.Errorf("Expected other variables to be kept, got %v", env)
+	}
+	if original := service.Options["env"].(map[string]interface{}); original["POSTGRES_PASSWORD"] != "hunter2" {
+		t.Error("Expected redaction to leave the original environment alone")
+	}
+
+	// Redacted variables sent back keep their values, new ones replace them
+	env["API_TOKEN"] = "new-token"
+	restored := redacted.WithSecretsFrom(service).Options["env"].(map[string]interface{})
+	if restored["POSTGRES_PASSWORD"] != "hunter2" || restored["AWS_SECRET_ACCESS_KEY"] != "aws-secret" || restored["API_TOKEN"] != "new-token" {
+		t.Errorf("Expected redacted variables to be restored, got %v", restored)
+	}
+}
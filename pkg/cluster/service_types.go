@@ -0,0 +1,69 @@
+package cluster
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// serviceTypes holds the service types a cluster configuration may use
+var serviceTypes = struct {
+	types map[string]bool
+	mu    sync.RWMutex
+}{
+	types: map[string]bool{
+		"postgres":    true,
+		"redis":       true,
+		"kafka":       true,
+		"mongodb":     true,
+		"mysql":       true,
+		"rabbitmq":    true,
+		"minio":       true,
+		"s3":          true,
+		"etcd":        true,
+		"influxdb":    true,
+		"mqtt":        true,
+		"vault":       true,
+		"dynamodb":    true,
+		"qdrant":      true,
+		"cockroachdb": true,
+	},
+}
+
+// RegisterServiceType adds a service type that cluster configurations may use.
+// Registering a type that already exists is an error.
+func RegisterServiceType(serviceType string) error {
+	if serviceType == "" {
+		return fmt.Errorf("service type cannot be empty")
+	}
+
+	serviceTypes.mu.Lock()
+	defer serviceTypes.mu.Unlock()
+
+	if serviceTypes.types[serviceType] {
+		return fmt.Errorf("service type already registered: %s", serviceType)
+	}
+	serviceTypes.types[serviceType] = true
+	return nil
+}
+
+// IsServiceTypeRegistered reports whether a service type is supported
+func IsServiceTypeRegistered(serviceType string) bool {
+	serviceTypes.mu.RLock()
+	defer serviceTypes.mu.RUnlock()
+
+	return serviceTypes.types[serviceType]
+}
+
+// ServiceTypes returns the supported service types in alphabetical order
+func ServiceTypes() []string {
+	serviceTypes.mu.RLock()
+	defer serviceTypes.mu.RUnlock()
+
+	types := make([]string, 0, len(serviceTypes.types))
+	for serviceType := range serviceTypes.types {
+		types = append(types, serviceType)
+	}
+	sort.Strings(types)
+	return types
+}
@@ -0,0 +1,59 @@
+package cluster
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// routingStrategies holds the routing strategies a cluster configuration may use
+var routingStrategies = struct {
+	names map[string]bool
+	mu    sync.RWMutex
+}{
+	names: map[string]bool{
+		"round_robin":       true,
+		"weighted":          true,
+		"least_connections": true,
+		"ai":                true,
+		"latency":           true,
+	},
+}
+
+// RegisterRoutingStrategy adds a routing strategy that cluster configurations may
+// use. Registering a strategy that already exists is an error.
+func RegisterRoutingStrategy(name string) error {
+	if name == "" {
+		return fmt.Errorf("routing strategy cannot be empty")
+	}
+
+	routingStrategies.mu.Lock()
+	defer routingStrategies.mu.Unlock()
+
+	if routingStrategies.names[name] {
+		return fmt.Errorf("routing strategy already registered: %s", name)
+	}
+	routingStrategies.names[name] = true
+	return nil
+}
+
+// IsRoutingStrategyRegistered reports whether a routing strategy is supported
+func IsRoutingStrategyRegistered(name string) bool {
+	routingStrategies.mu.RLock()
+	defer routingStrategies.mu.RUnlock()
+
+	return routingStrategies.names[name]
+}
+
+// RoutingStrategies returns the supported routing strategies in alphabetical order
+func RoutingStrategies() []string {
+	routingStrategies.mu.RLock()
+	defer routingStrategies.mu.RUnlock()
+
+	names := make([]string, 0, len(routingStrategies.names))
+	for name := range routingStrategies.names {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,105 @@
+package gateway
+
+import (
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/akmadan/throome/internal/config"
+	"github.com/akmadan/throome/internal/logger"
+	"github.com/akmadan/throome/pkg/monitor"
+)
+
+// maxResolvedAlerts is how many resolved alerts are kept for the alert endpoints
+const maxResolvedAlerts = 500
+
+// NewAlertEngine creates the alert engine of an alerting configuration, with a
+// notifier for each of its channels
+func NewAlertEngine(cfg config.AlertingConfig) *monitor.AlertEngine {
+	httpClient := &http.Client{Timeout: 10 * time.Second}
+
+	notifiers := make(map[string]monitor.Notifier, len(cfg.Channels))
+	for _, channel := range cfg.Channels {
+		switch channel.Type {
+		case monitor.ChannelSlack:
+			notifiers[channel.Name] = &monitor.SlackNotifier{URL: channel.URL, Client: httpClient}
+		case monitor.ChannelWebhook:
+			notifiers[channel.Name] = &monitor.WebhookNotifier{URL: channel.URL, Headers: channel.Headers, Client: httpClient}
+		case monitor.ChannelEmail:
+			notifiers[channel.Name] = &monitor.EmailNotifier{
+				Host:     channel.SMTPHost,
+				Port:     channel.SMTPPort,
+				Username: channel.Username,
+				Password: channel.Password,
+				From:     channel.From,
+				To:       channel.To,
+			}
+		}
+	}
+
+	rules := make([]monitor.AlertRule, 0, len(cfg.Rules))
+	for _, rule := range cfg.Rules {
+		rules = append(rules, monitor.AlertRule{
+			Name:        rule.Name,
+			Metric:      rule.Metric,
+			Threshold:   rule.Threshold,
+			For:         time.Duration(rule.For) * time.Second,
+			ClusterID:   rule.ClusterID,
+			ServiceName: rule.Service,
+			Channels:    rule.Channels,
+		})
+	}
+
+	return monitor.NewAlertEngine(rules, notifiers, maxResolvedAlerts)
+}
+
+// SetAlertEngine makes the gateway evaluate the rules of an alert engine at every
+// interval, with the anomalies detected in its clusters, recording the alerts that
+// fire and resolve in the activity feed. It must be called before Initialize.
+func (g *Gateway) SetAlertEngine(engine *monitor.AlertEngine, interval time.Duration) {
+	engine.OnAlert(func(alert *monitor.Alert) {
+		status := "warning"
+		if alert.State == monitor.AlertResolved {
+			status = "success"
+		}
+		g.activityLogger.Log(&monitor.ActivityLog{
+			Timestamp:   time.Now(),
+			ClusterID:   alert.ClusterID,
+			ServiceName: alert.ServiceName,
+			Operation:   "ALERT",
+			Command:     alert.Rule,
+			Status:      status,
+			Response:    alert.Message,
+		})
+	})
+	g.anomalies.OnAnomaly(engine.RecordAnomaly)
+
+	g.alerts = engine
+	g.alertInterval = interval
+}
+
+// GetAlertEngine returns the alert engine, or nil when alerting is disabled
+func (g *Gateway) GetAlertEngine() *monitor.AlertEngine {
+	return g.alerts
+}
+
+// evaluateAlerts periodically evaluates the alert rules
+func (g *Gateway) evaluateAlerts(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	logger.Info("Alerting started",
+		zap.Int("rules", len(g.alerts.Rules())),
+		zap.Duration("interval", interval),
+	)
+
+	for {
+		select {
+		case <-g.stopChan:
+			return
+		case now := <-ticker.C:
+			g.alerts.Evaluate(g.collector, g.healthChecker, now)
+		}
+	}
+}
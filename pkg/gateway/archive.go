@@ -0,0 +1,143 @@
+package gateway
+
+import (
+	"context"
+	"time"
+
+	"github.com/akmadan/throome/internal/logger"
+	"github.com/akmadan/throome/pkg/cluster"
+	"go.uber.org/zap"
+)
+
+// containerManager is the part of the provisioner used to stop, start and remove the
+// containers of archived clusters and individual services
+type containerManager interface {
+	StartService(ctx context.Context, containerID string) error
+	StopService(ctx context.Context, containerID string) error
+	RestartService(ctx context.Context, containerID string) error
+	RemoveService(ctx context.Context, containerID string) error
+	WaitForHealthy(ctx context.Context, containerID string, timeout time.Duration) error
+}
+
+// SetArchiveRetention sets how long archived clusters are kept before they are purged.
+// Zero keeps them until purged explicitly. It must be called before Initialize.
+func (g *Gateway) SetArchiveRetention(retention time.Duration) {
+	g.archiveRetention = retention
+}
+
+// ArchiveCluster stops a cluster's containers and unloads it, keeping its
+// configuration and volumes so it can be restored
+func (g *Gateway) ArchiveCluster(ctx context.Context, clusterID string) error {
+	config, err := g.clusterManager.Archive(clusterID)
+	if err != nil {
+		return err
+	}
+
+	g.mu.Lock()
+	g.unloadCluster(ctx, clusterID)
+	g.mu.Unlock()
+
+	// Stop containers without holding the lock, each stop can take several seconds
+	g.forEachContainer(config, "Failed to stop container", func(manager containerManager, containerID string) error {
+		return manager.StopService(ctx, containerID)
+	})
+
+	logger.Info("Cluster archived", zap.String("cluster_id", clusterID))
+	return nil
+}
+
+// RestoreCluster starts an archived cluster's containers and loads it again
+func (g *Gateway) RestoreCluster(ctx context.Context, clusterID string) error {
+	config, err := g.clusterManager.Restore(clusterID)
+	if err != nil {
+		return err
+	}
+
+	g.forEachContainer(config, "Failed to start container", func(manager containerManager, containerID string) error {
+		return manager.StartService(ctx, containerID)
+	})
+
+	if err := g.initializeCluster(ctx, clusterID, config); err != nil {
+		return err
+	}
+
+	logger.Info("Cluster restored", zap.String("cluster_id", clusterID))
+	return nil
+}
+
+// PurgeCluster permanently deletes a cluster along with its containers
+func (g *Gateway) PurgeCluster(ctx context.Context, clusterID string) error {
+	config, err := g.clusterManager.Get(clusterID)
+	if err != nil {
+		return err
+	}
+
+	logger.Info("Removing provisioned containers", zap.String("cluster_id", clusterID))
+	g.forEachContainer(config, "Failed to remove container", func(manager containerManager, containerID string) error {
+		return manager.RemoveService(ctx, containerID)
+	})
+
+	return g.DeleteCluster(ctx, clusterID)
+}
+
+// forEachContainer applies an operation to every provisioned container of a cluster.
+// Failures are logged and do not stop the remaining containers from being handled.
+func (g *Gateway) forEachContainer(config *cluster.Config, failure string, op func(manager containerManager, containerID string) error) {
+	if g.provisioner == nil {
+		return
+	}
+	manager, err := g.clusterProvisioner(config)
+	if err != nil {
+		logger.Error(failure, zap.String("cluster_id", config.ClusterID), zap.Error(err))
+		return
+	}
+
+	for serviceName, serviceConfig := range config.Services {
+		for _, containerID := range serviceConfig.ContainerIDs() {
+			if err := op(manager, containerID); err != nil {
+				logger.Error(failure,
+					zap.String("cluster_id", config.ClusterID),
+					zap.String("service", serviceName),
+					zap.Error(err),
+				)
+			}
+		}
+	}
+}
+
+// purgeExpiredArchives periodically purges clusters archived for longer than the
+// retention window
+func (g *Gateway) purgeExpiredArchives(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		g.purgeArchivedBefore(time.Now().Add(-g.archiveRetention))
+
+		select {
+		case <-g.stopChan:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// purgeArchivedBefore purges clusters archived before the cutoff
+func (g *Gateway) purgeArchivedBefore(cutoff time.Time) {
+	for clusterID, config := range g.clusterManager.GetAllConfigs() {
+		if !config.IsArchived() || config.ArchivedAt.After(cutoff) {
+			continue
+		}
+
+		logger.Info("Purging expired archived cluster",
+			zap.String("cluster_id", clusterID),
+			zap.Time("archived_at", *config.ArchivedAt),
+		)
+		if err := g.PurgeCluster(context.Background(), clusterID); err != nil {
+			logger.Error("Failed to purge archived cluster",
+				zap.String("cluster_id", clusterID),
+				zap.Error(err),
+			)
+		}
+	}
+}
@@ -0,0 +1,363 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/akmadan/throome/internal/logger"
+	"github.com/akmadan/throome/pkg/auth"
+	"github.com/akmadan/throome/pkg/cluster"
+	"github.com/akmadan/throome/pkg/requestid"
+	"go.uber.org/zap"
+)
+
+// APIKeyHeader is the request header carrying an API key
+const APIKeyHeader = "X-API-Key"
+
+// newKeyStore loads the issued API keys and adds the static keys of the configuration.
+// When the issued keys cannot be loaded only the static keys are accepted.
+func (s *Server) newKeyStore() *auth.KeyStore {
+	keys, err := auth.NewKeyStore(s.config.Auth.KeysFile)
+	if err != nil {
+		logger.Error("Failed to load API keys, only static keys are accepted", zap.Error(err))
+		keys, _ = auth.NewKeyStore("")
+	}
+
+	for _, key := range s.config.Auth.APIKeys {
+		role := auth.Role(key.Role)
+		if role == "" {
+			role = auth.RoleAdmin
+		}
+		if err := keys.AddStatic(key.Name, key.Key, key.Hash, role, key.Namespace); err != nil {
+			logger.Error("Ignoring invalid static API key", zap.String("name", key.Name), zap.Error(err))
+		}
+	}
+	return keys
+}
+
+// newJWTValidator creates the bearer token validator, or returns nil when JWT
+// authentication is disabled or misconfigured
+func (s *Server) newJWTValidator() *auth.JWTValidator {
+	cfg := s.config.Auth.JWT
+	if !cfg.Enabled {
+		return nil
+	}
+
+	validator, err := auth.NewJWTValidator(auth.JWTOptions{
+		Secret:         cfg.Secret,
+		JWKSURL:        cfg.JWKSURL,
+		JWKSRefresh:    time.Duration(cfg.JWKSRefresh) * time.Second,
+		Issuer:         cfg.Issuer,
+		Audience:       cfg.Audience,
+		SubjectClaim:   cfg.SubjectClaim,
+		RoleClaim:      cfg.RoleClaim,
+		DefaultRole:    auth.Role(cfg.DefaultRole),
+		NamespaceClaim: cfg.NamespaceClaim,
+		Leeway:         time.Duration(cfg.Leeway) * time.Second,
+	})
+	if err != nil {
+		logger.Error("Failed to configure JWT authentication, bearer tokens are rejected", zap.Error(err))
+		return nil
+	}
+	return validator
+}
+
+// authMiddleware rejects API requests without a valid API key or bearer token when
+// authentication is enabled, as well as requests the caller's role does not permit
+// and requests of callers limited to a namespace outside of that namespace's routes,
+// and attaches the caller's identity to the request context. The dashboard, the
+// gateway health check and the OpenAPI document stay open.
+func (s *Server) authMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.config.Auth.Enabled || r.Method == "OPTIONS" || !requiresAuth(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		identity, err := s.authenticate(r)
+		if err != nil {
+			logger.Warn("Unauthorized request",
+				zap.String("method", r.Method),
+				zap.String("path", r.URL.Path),
+				zap.String("remote_addr", r.RemoteAddr),
+				zap.String("request_id", requestid.FromContext(r.Context())),
+				zap.Error(err),
+			)
+			w.Header().Set("WWW-Authenticate", `Bearer realm="throome"`)
+			s.errorResponse(w, http.StatusUnauthorized, "Missing or invalid credentials", nil)
+			return
+		}
+
+		if required := requiredRole(r); !identity.Role.Allows(required) {
+			logger.Warn("Forbidden request",
+				zap.String("method", r.Method),
+				zap.String("path", r.URL.Path),
+				zap.String("identity", identity.Subject),
+				zap.String("role", string(identity.Role)),
+				zap.String("required_role", string(required)),
+				zap.String("request_id", requestid.FromContext(r.Context())),
+			)
+			s.errorResponse(w, http.StatusForbidden, fmt.Sprintf("The %s role is required", required), nil)
+			return
+		}
+
+		if identity.Namespace != "" && mux.Vars(r)["namespace"] != identity.Namespace {
+			logger.Warn("Request outside of the caller's namespace",
+				zap.String("method", r.Method),
+				zap.String("path", r.URL.Path),
+				zap.String("identity", identity.Subject),
+				zap.String("namespace", identity.Namespace),
+				zap.String("request_id", requestid.FromContext(r.Context())),
+			)
+			s.errorResponse(w, http.StatusForbidden,
+				fmt.Sprintf("Access is limited to /api/v1/namespaces/%s", identity.Namespace), nil)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(auth.WithIdentity(r.Context(), identity)))
+	})
+}
+
+// authenticate identifies the caller of a request by its API key, or by its bearer
+// token when the token is not an API key
+func (s *Server) authenticate(r *http.Request) (*auth.Identity, error) {
+	token, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return s.authenticateCredentials(r.Context(), apiKeyFromRequest(r), token)
+}
+
+// authenticateCredentials identifies a caller by an API key, or by a bearer token
+// when no API key is given
+func (s *Server) authenticateCredentials(ctx context.Context, key, token string) (*auth.Identity, error) {
+	if key != "" {
+		apiKey := s.keys.Authenticate(key)
+		if apiKey == nil {
+			return nil, fmt.Errorf("unknown API key")
+		}
+		return &auth.Identity{
+			Subject:   apiKey.Name,
+			Method:    auth.MethodAPIKey,
+			Role:      apiKey.Role,
+			Namespace: apiKey.Namespace,
+			KeyID:     apiKey.ID,
+		}, nil
+	}
+
+	if token == "" {
+		return nil, fmt.Errorf("no credentials")
+	}
+	if s.jwt == nil {
+		return nil, fmt.Errorf("bearer tokens are not accepted")
+	}
+	return s.jwt.Validate(ctx, token)
+}
+
+// adminRoutes are the routes that change the gateway itself rather than the data of
+// its services, keyed by method and path template
+var adminRoutes = map[string]bool{
+	"POST /api/v1/clusters":                                               true,
+	"PUT /api/v1/clusters/{cluster_id}":                                   true,
+	"DELETE /api/v1/clusters/{cluster_id}":                                true,
+	"POST /api/v1/clusters/{cluster_id}/reload":                           true,
+	"POST /api/v1/clusters/{cluster_id}/restore":                          true,
+	"POST /api/v1/clusters/{cluster_id}/purge":                            true,
+	"POST /api/v1/clusters/{cluster_id}/drain":                            true,
+	"POST /api/v1/clusters/{cluster_id}/resume":                           true,
+	"PATCH /api/v1/clusters/{cluster_id}/routing":                         true,
+	"POST /api/v1/clusters/{cluster_id}/services":                         true,
+	"DELETE /api/v1/clusters/{cluster_id}/services/{service_name}":        true,
+	"PUT /api/v1/clusters/{cluster_id}/services/{service_name}/faults":    true,
+	"DELETE /api/v1/clusters/{cluster_id}/services/{service_name}/faults": true,
+	"POST /api/v1/clusters/{cluster_id}/services/{service_name}/exec":     true,
+	"POST /api/v1/clusters/{cluster_id}/services/{service_name}/scale":    true,
+	"POST /api/v1/admin/reload":                                           true,
+	"DELETE /api/v1/snapshots/{name}":                                     true,
+	"POST /api/v1/snapshots/{name}/restore":                               true,
+	"GET /api/v1/auth/keys":                                               true,
+	"POST /api/v1/auth/keys":                                              true,
+	"DELETE /api/v1/auth/keys/{key_id}":                                   true,
+}
+
+// operatorRoutes are the reads that return stored data rather than the state of the
+// gateway, or consume messages, and so need the operator role like the matching gRPC
+// methods, keyed by method and path template
+var operatorRoutes = map[string]bool{
+	"GET /api/v1/clusters/{cluster_id}/secrets/{path:.+}":                                 true,
+	"GET /api/v1/clusters/{cluster_id}/services/{service_name}/snapshots/{name}/download": true,
+	"GET /api/v1/clusters/{cluster_id}/services/{service_name}/logs":                      true,
+	"GET /api/v1/clusters/{cluster_id}/objects/{bucket}/{key:.+}":                         true,
+	"GET /api/v1/clusters/{cluster_id}/cache/keys":                                        true,
+	"GET /api/v1/clusters/{cluster_id}/kv/keys":                                           true,
+	"GET /api/v1/clusters/{cluster_id}/kv/watch":                                          true,
+	"GET /api/v1/clusters/{cluster_id}/queue/subscribe":                                   true,
+	"GET /api/v1/clusters/{cluster_id}/queue/topics/{topic}/dlq":                          true,
+}
+
+// RevealSecretsParam is the query parameter that asks for the credentials of
+// services in responses, which are redacted otherwise
+const RevealSecretsParam = "reveal_secrets"
+
+// revealSecrets reports whether a request asked for credentials to be revealed
+func revealSecrets(r *http.Request) bool {
+	return r.URL.Query().Get(RevealSecretsParam) == "true"
+}
+
+// requiredRole returns the role a request needs. Reads are open to every role and
+// other requests need the operator role, except for the admin routes, which need
+// the admin role whether they are reached directly or through a namespace, and the
+// operator routes, reads of secrets, snapshots, objects, keys, logs and messages.
+// Revealing credentials needs the admin role too.
+func requiredRole(r *http.Request) auth.Role {
+	if revealSecrets(r) {
+		return auth.RoleAdmin
+	}
+	if route := mux.CurrentRoute(r); route != nil {
+		if template, err := route.GetPathTemplate(); err == nil {
+			template = strings.Replace(template, "/namespaces/{namespace}", "", 1)
+			if adminRoutes[r.Method+" "+template] {
+				return auth.RoleAdmin
+			}
+			if operatorRoutes[r.Method+" "+template] {
+				return auth.RoleOperator
+			}
+		}
+	}
+	if r.Method == "GET" || r.Method == "HEAD" {
+		return auth.RoleReadOnly
+	}
+	return auth.RoleOperator
+}
+
+// requiresAuth reports whether a path is part of the authenticated API
+func requiresAuth(path string) bool {
+	return strings.HasPrefix(path, "/api/") && path != "/api/v1/health" && path != "/api/v1/openapi.json"
+}
+
+// apiKeyFromRequest returns the API key of a request, sent in the X-API-Key header or
+// as a bearer token. Browsers cannot set headers on WebSocket handshakes, so those
+// may pass the key in the api_key query parameter instead.
+func apiKeyFromRequest(r *http.Request) string {
+	if key := r.Header.Get(APIKeyHeader); key != "" {
+		return key
+	}
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok && strings.HasPrefix(token, auth.KeyPrefix) {
+		return token
+	}
+	if strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+		return r.URL.Query().Get("api_key")
+	}
+	return ""
+}
+
+// handleIssueAPIKey issues a new API key. The key is only part of this response.
+func (s *Server) handleIssueAPIKey(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name      string    `json:"name"`
+		Role      auth.Role `json:"role"`      // read-only unless set
+		Namespace string    `json:"namespace"` // all namespaces unless set
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.errorResponse(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	if req.Name == "" {
+		s.errorResponse(w, http.StatusBadRequest, "API key name is required", nil)
+		return
+	}
+
+	if req.Role == "" {
+		req.Role = auth.RoleReadOnly
+	}
+	if _, err := auth.ParseRole(string(req.Role)); err != nil {
+		s.errorResponse(w, http.StatusBadRequest, "Invalid role", err)
+		return
+	}
+
+	if req.Namespace != "" {
+		if err := cluster.ValidateNamespace(req.Namespace); err != nil {
+			s.errorResponse(w, http.StatusBadRequest, "Invalid namespace", err)
+			return
+		}
+	}
+
+	key, apiKey, err := s.keys.Issue(req.Name, req.Role, req.Namespace)
+	if err != nil {
+		s.errorResponse(w, http.StatusInternalServerError, "Failed to issue API key", err)
+		return
+	}
+
+	issuer := "anonymous"
+	if identity := auth.IdentityFromContext(r.Context()); identity != nil {
+		issuer = identity.Subject
+	}
+	logger.Info("API key issued",
+		zap.String("key_id", apiKey.ID),
+		zap.String("name", apiKey.Name),
+		zap.String("role", string(apiKey.Role)),
+		zap.String("namespace", apiKey.Namespace),
+		zap.String("issued_by", issuer),
+	)
+
+	s.jsonResponse(w, http.StatusCreated, map[string]interface{}{
+		"id":         apiKey.ID,
+		"name":       apiKey.Name,
+		"role":       apiKey.Role,
+		"namespace":  apiKey.Namespace,
+		"key":        key,
+		"created_at": apiKey.CreatedAt.Format(time.RFC3339),
+		"message":    "Store the key now, it cannot be retrieved again",
+	})
+}
+
+// handleListAPIKeys lists the accepted API keys without the keys themselves
+func (s *Server) handleListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	keys := make([]map[string]interface{}, 0)
+	for _, apiKey := range s.keys.List() {
+		entry := map[string]interface{}{
+			"id":     apiKey.ID,
+			"name":   apiKey.Name,
+			"role":   apiKey.Role,
+			"static": apiKey.Static,
+		}
+		if apiKey.Namespace != "" {
+			entry["namespace"] = apiKey.Namespace
+		}
+		if !apiKey.Static {
+			entry["hint"] = apiKey.Hint
+			entry["created_at"] = apiKey.CreatedAt.Format(time.RFC3339)
+		}
+		keys = append(keys, entry)
+	}
+
+	s.jsonResponse(w, http.StatusOK, map[string]interface{}{
+		"enabled": s.config.Auth.Enabled,
+		"keys":    keys,
+	})
+}
+
+// handleRevokeAPIKey revokes an issued API key
+func (s *Server) handleRevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	keyID := vars["key_id"]
+
+	if err := s.keys.Revoke(keyID); err != nil {
+		if errors.Is(err, auth.ErrKeyNotFound) {
+			s.errorResponse(w, http.StatusNotFound, "API key not found", err)
+			return
+		}
+		s.errorResponse(w, http.StatusInternalServerError, "Failed to revoke API key", err)
+		return
+	}
+
+	logger.Info("API key revoked", zap.String("key_id", keyID))
+	s.jsonResponse(w, http.StatusOK, map[string]interface{}{
+		"message": "API key revoked successfully",
+	})
+}
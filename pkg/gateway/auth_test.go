@@ -0,0 +1,201 @@
+package gateway
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/gorilla/mux"
+
+	"github.com/akmadan/throome/internal/config"
+	"github.com/akmadan/throome/pkg/auth"
+)
+
+func TestAuthMiddleware(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Auth.Enabled = true
+	keys, _ := auth.NewKeyStore("")
+	key, _, _ := keys.Issue("ci", auth.RoleOperator, "")
+	validator, _ := auth.NewJWTValidator(auth.JWTOptions{Secret: "secret"})
+	token, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub": "ci",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}).SignedString([]byte("secret"))
+	s := &Server{config: cfg, keys: keys, jwt: validator}
+
+	var identity *auth.Identity
+	handler := s.authMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		identity = auth.IdentityFromContext(r.Context())
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name   string
+		path   string
+		header string
+		value  string
+		status int
+	}{
+		{"missing key", "/api/v1/clusters", "", "", http.StatusUnauthorized},
+		{"invalid key", "/api/v1/clusters", APIKeyHeader, "thr_invalid", http.StatusUnauthorized},
+		{"api key header", "/api/v1/clusters", APIKeyHeader, key, http.StatusOK},
+		{"bearer api key", "/api/v1/clusters", "Authorization", "Bearer " + key, http.StatusOK},
+		{"jwt", "/api/v1/clusters", "Authorization", "Bearer " + token, http.StatusOK},
+		{"invalid jwt", "/api/v1/clusters", "Authorization", "Bearer invalid", http.StatusUnauthorized},
+		{"health check", "/api/v1/health", "", "", http.StatusOK},
+		{"dashboard", "/clusters", "", "", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			identity = nil
+			req := httptest.NewRequest("GET", tt.path, nil)
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Errorf("Expected status %d, got %d", tt.status, rec.Code)
+			}
+			if tt.status == http.StatusOK && tt.header != "" && (identity == nil || identity.Subject != "ci") {
+				t.Errorf("Expected the key's identity in the context, got %+v", identity)
+			}
+		})
+	}
+}
+
+func TestAuthMiddlewareRoles(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Auth.Enabled = true
+	keys, _ := auth.NewKeyStore("")
+	readOnly, _, _ := keys.Issue("viewer", auth.RoleReadOnly, "")
+	operator, _, _ := keys.Issue("operator", auth.RoleOperator, "")
+	admin, _, _ := keys.Issue("admin", auth.RoleAdmin, "")
+	s := &Server{config: cfg, keys: keys}
+
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+	router := mux.NewRouter()
+	api := router.PathPrefix("/api/v1").Subrouter()
+	api.HandleFunc("/clusters", ok).Methods("GET", "POST")
+	api.HandleFunc("/clusters/{cluster_id}/db/execute", ok).Methods("POST")
+	api.HandleFunc("/clusters/{cluster_id}/secrets/{path:.+}", ok).Methods("GET")
+	api.HandleFunc("/clusters/{cluster_id}/services/{service_name}/snapshots/{name}/download", ok).Methods("GET")
+	api.HandleFunc("/clusters/{cluster_id}/objects/{bucket}/{key:.+}", ok).Methods("GET")
+	api.HandleFunc("/clusters/{cluster_id}/objects/{bucket}", ok).Methods("GET")
+	api.HandleFunc("/clusters/{cluster_id}/queue/subscribe", ok).Methods("GET")
+	api.HandleFunc("/clusters/{cluster_id}/queue/topics/{topic}/dlq", ok).Methods("GET")
+	api.HandleFunc("/clusters/{cluster_id}/queue/topics", ok).Methods("GET")
+	router.Use(s.authMiddleware)
+
+	tests := []struct {
+		key    string
+		method string
+		path   string
+		status int
+	}{
+		{readOnly, "GET", "/api/v1/clusters", http.StatusOK},
+		{readOnly, "POST", "/api/v1/clusters/c1/db/execute", http.StatusForbidden},
+		{readOnly, "POST", "/api/v1/clusters", http.StatusForbidden},
+		{operator, "POST", "/api/v1/clusters/c1/db/execute", http.StatusOK},
+		{operator, "POST", "/api/v1/clusters", http.StatusForbidden},
+		{admin, "POST", "/api/v1/clusters", http.StatusOK},
+		{operator, "GET", "/api/v1/clusters?reveal_secrets=true", http.StatusForbidden},
+		{admin, "GET", "/api/v1/clusters?reveal_secrets=true", http.StatusOK},
+		{readOnly, "GET", "/api/v1/clusters/c1/secrets/db/password", http.StatusForbidden},
+		{operator, "GET", "/api/v1/clusters/c1/secrets/db/password", http.StatusOK},
+		{admin, "GET", "/api/v1/clusters/c1/secrets/db/password", http.StatusOK},
+		{readOnly, "GET", "/api/v1/clusters/c1/services/db/snapshots/nightly/download", http.StatusForbidden},
+		{operator, "GET", "/api/v1/clusters/c1/services/db/snapshots/nightly/download", http.StatusOK},
+		{readOnly, "GET", "/api/v1/clusters/c1/objects/reports/2024/q1.csv", http.StatusForbidden},
+		{operator, "GET", "/api/v1/clusters/c1/objects/reports/2024/q1.csv", http.StatusOK},
+		{readOnly, "GET", "/api/v1/clusters/c1/objects/reports", http.StatusOK},
+		{readOnly, "GET", "/api/v1/clusters/c1/queue/subscribe?topic=orders", http.StatusForbidden},
+		{operator, "GET", "/api/v1/clusters/c1/queue/subscribe?topic=orders", http.StatusOK},
+		{readOnly, "GET", "/api/v1/clusters/c1/queue/topics/orders/dlq", http.StatusForbidden},
+		{operator, "GET", "/api/v1/clusters/c1/queue/topics/orders/dlq", http.StatusOK},
+		{readOnly, "GET", "/api/v1/clusters/c1/queue/topics", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, nil)
+		req.Header.Set(APIKeyHeader, tt.key)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		if rec.Code != tt.status {
+			t.Errorf("%s %s with %s: expected status %d, got %d", tt.method, tt.path, keys.Authenticate(tt.key).Role, tt.status, rec.Code)
+		}
+	}
+}
+
+func TestAdminRoutesExist(t *testing.T) {
+	s := &Server{config: config.DefaultConfig(), router: mux.NewRouter()}
+	s.setupRoutes()
+
+	registered := make(map[string]bool)
+	_ = s.router.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		template, _ := route.GetPathTemplate()
+		methods, _ := route.GetMethods()
+		for _, method := range methods {
+			registered[method+" "+template] = true
+		}
+		return nil
+	})
+
+	for route := range adminRoutes {
+		if !registered[route] {
+			t.Errorf("Admin route %s is not registered", route)
+		}
+	}
+	for route := range operatorRoutes {
+		if !registered[route] {
+			t.Errorf("Operator route %s is not registered", route)
+		}
+	}
+}
+
+func TestAuthMiddlewareNamespaces(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Auth.Enabled = true
+	keys, _ := auth.NewKeyStore("")
+	global, _, _ := keys.Issue("global", auth.RoleOperator, "")
+	teamA, _, _ := keys.Issue("team-a", auth.RoleOperator, "team-a")
+	s := &Server{config: cfg, keys: keys}
+
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+	router := mux.NewRouter()
+	api := router.PathPrefix("/api/v1").Subrouter()
+	api.HandleFunc("/clusters", ok).Methods("GET", "POST")
+	api.HandleFunc("/namespaces/{namespace}/clusters", ok).Methods("GET", "POST")
+	router.Use(s.authMiddleware)
+
+	tests := []struct {
+		key    string
+		method string
+		path   string
+		status int
+	}{
+		{global, "GET", "/api/v1/clusters", http.StatusOK},
+		{global, "GET", "/api/v1/namespaces/team-b/clusters", http.StatusOK},
+		{teamA, "GET", "/api/v1/namespaces/team-a/clusters", http.StatusOK},
+		{teamA, "GET", "/api/v1/namespaces/team-b/clusters", http.StatusForbidden},
+		{teamA, "GET", "/api/v1/clusters", http.StatusForbidden},
+		// Admin routes need the admin role in a namespace too
+		{teamA, "POST", "/api/v1/namespaces/team-a/clusters", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, nil)
+		req.Header.Set(APIKeyHeader, tt.key)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		if rec.Code != tt.status {
+			t.Errorf("%s %s with %s: expected status %d, got %d", tt.method, tt.path, keys.Authenticate(tt.key).Name, tt.status, rec.Code)
+		}
+	}
+}
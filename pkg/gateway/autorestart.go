@@ -0,0 +1,187 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/akmadan/throome/internal/logger"
+	"github.com/akmadan/throome/pkg/cluster"
+	"github.com/akmadan/throome/pkg/monitor"
+)
+
+// Defaults and limits of automatic restarts
+const (
+	defaultMaxRestarts = 3
+	defaultBackoff     = 10 * time.Second
+	maxBackoff         = 5 * time.Minute
+	restartTimeout     = 2 * time.Minute
+)
+
+// Stages of an automatic restart
+const (
+	RestartStarted   = "restarting"
+	RestartDone      = "restarted"
+	RestartFailed    = "failed"
+	RestartGaveUp    = "gave_up"   // The service stayed unhealthy after the last restart
+	RestartRecovered = "recovered" // The service is healthy again
+)
+
+// AutoRestart reports the progress of restarting an unhealthy service
+type AutoRestart struct {
+	ClusterID   string    `json:"cluster_id"`
+	ServiceName string    `json:"service_name"`
+	Stage       string    `json:"stage"`
+	Attempt     int       `json:"attempt"`
+	MaxRestarts int       `json:"max_restarts"`
+	Error       string    `json:"error,omitempty"`
+	Timestamp   time.Time `json:"timestamp"`
+}
+
+// AutoRestartHandler is called for every stage of an automatic restart
+type AutoRestartHandler func(restart *AutoRestart)
+
+// OnAutoRestart registers a handler that is called as unhealthy services are
+// restarted
+func (g *Gateway) OnAutoRestart(handler AutoRestartHandler) {
+	g.restartMu.Lock()
+	defer g.restartMu.Unlock()
+
+	g.restartHandlers = append(g.restartHandlers, handler)
+}
+
+// restartUnhealthy restarts the container of a provisioned service that turned
+// unhealthy, when its cluster enables automatic restarts
+func (g *Gateway) restartUnhealthy(transition *monitor.HealthTransition) {
+	if transition.Healthy {
+		return
+	}
+	clusterID, serviceName, ok := strings.Cut(transition.ServiceName, "/")
+	if !ok {
+		return
+	}
+
+	config, err := g.clusterManager.Get(clusterID)
+	if err != nil || !config.Health.AutoRestart.Enabled || config.IsArchived() {
+		return
+	}
+	serviceConfig, exists := config.Services[serviceName]
+	if !exists || serviceConfig.ContainerID == "" {
+		return
+	}
+
+	g.restartMu.Lock()
+	if g.restarting[transition.ServiceName] {
+		g.restartMu.Unlock()
+		return
+	}
+	g.restarting[transition.ServiceName] = true
+	g.restartMu.Unlock()
+
+	go g.autoRestart(clusterID, serviceName, serviceConfig.Type, config.Health.AutoRestart)
+}
+
+// autoRestart restarts a service until it is healthy again, waiting longer after
+// each restart, and gives up after the cluster's maximum number of restarts
+func (g *Gateway) autoRestart(clusterID, serviceName, serviceType string, policy cluster.AutoRestartConfig) {
+	name := clusterID + "/" + serviceName
+	defer func() {
+		g.restartMu.Lock()
+		delete(g.restarting, name)
+		g.restartMu.Unlock()
+	}()
+
+	maxRestarts := policy.MaxRestarts
+	if maxRestarts <= 0 {
+		maxRestarts = defaultMaxRestarts
+	}
+	backoff := defaultBackoff
+	if policy.Backoff > 0 {
+		backoff = time.Duration(policy.Backoff) * time.Second
+	}
+
+	report := func(stage string, attempt int, err error) {
+		g.reportRestart(&AutoRestart{
+			ClusterID:   clusterID,
+			ServiceName: serviceName,
+			Stage:       stage,
+			Attempt:     attempt,
+			MaxRestarts: maxRestarts,
+		}, serviceType, err)
+	}
+
+	for attempt := 1; attempt <= maxRestarts; attempt++ {
+		report(RestartStarted, attempt, nil)
+		ctx, cancel := context.WithTimeout(context.Background(), restartTimeout)
+		err := g.RestartService(ctx, clusterID, serviceName)
+		cancel()
+		if err != nil {
+			report(RestartFailed, attempt, err)
+		} else {
+			report(RestartDone, attempt, nil)
+		}
+
+		// Give the health checker time to see the restarted service
+		select {
+		case <-g.stopChan:
+			return
+		case <-time.After(backoff):
+		}
+		if g.healthChecker.IsHealthy(name) {
+			report(RestartRecovered, attempt, nil)
+			return
+		}
+		backoff = min(2*backoff, maxBackoff)
+	}
+	report(RestartGaveUp, maxRestarts, nil)
+}
+
+// reportRestart logs a restart stage, records it in the activity feed and notifies
+// the handlers
+func (g *Gateway) reportRestart(restart *AutoRestart, serviceType string, err error) {
+	restart.Timestamp = time.Now()
+	if err != nil {
+		restart.Error = err.Error()
+	}
+
+	fields := []zap.Field{
+		zap.String("cluster_id", restart.ClusterID),
+		zap.String("service", restart.ServiceName),
+		zap.String("stage", restart.Stage),
+		zap.Int("attempt", restart.Attempt),
+		zap.Int("max_restarts", restart.MaxRestarts),
+	}
+	status := "success"
+	switch restart.Stage {
+	case RestartStarted:
+		status = "warning"
+		logger.Warn("Restarting unhealthy service", fields...)
+	case RestartFailed, RestartGaveUp:
+		status = "error"
+		logger.Error("Failed to restart unhealthy service", append(fields, zap.Error(err))...)
+	default:
+		logger.Info("Unhealthy service restarted", fields...)
+	}
+
+	g.activityLogger.Log(&monitor.ActivityLog{
+		Timestamp:   restart.Timestamp,
+		ClusterID:   restart.ClusterID,
+		ServiceName: restart.ServiceName,
+		ServiceType: serviceType,
+		Operation:   "RESTART",
+		Command:     fmt.Sprintf("auto-restart %d/%d", restart.Attempt, restart.MaxRestarts),
+		Status:      status,
+		Response:    restart.Stage,
+		Error:       restart.Error,
+	})
+
+	g.restartMu.Lock()
+	handlers := g.restartHandlers
+	g.restartMu.Unlock()
+	for _, handler := range handlers {
+		handler(restart)
+	}
+}
@@ -0,0 +1,64 @@
+package gateway
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/akmadan/throome/pkg/cluster"
+	"github.com/akmadan/throome/pkg/monitor"
+)
+
+func TestAutoRestart(t *testing.T) {
+	gw := newTestGateway(t)
+	containers := &fakeContainers{}
+	gw.SetProvisioner(containers)
+	defer gw.SetProvisioner(nil)
+
+	config := cluster.DefaultConfig("", "autorestart")
+	config.Health.AutoRestart = cluster.AutoRestartConfig{Enabled: true, MaxRestarts: 2, Backoff: 1}
+	config.Services = map[string]cluster.ServiceConfig{
+		"managed": {Type: "test-update", Host: "localhost", Port: 9831, ContainerID: "c1"},
+		"remote":  {Type: "test-update", Host: "localhost", Port: 9832},
+	}
+	clusterID, err := gw.CreateCluster(context.Background(), "autorestart", config)
+	if err != nil {
+		t.Fatalf("Failed to create cluster: %v", err)
+	}
+
+	stages := make(chan string, 10)
+	gw.OnAutoRestart(func(restart *AutoRestart) {
+		if restart.ClusterID == clusterID {
+			stages <- restart.Stage
+		}
+	})
+
+	// Services without a container are left alone, and so are repeated transitions
+	gw.restartUnhealthy(&monitor.HealthTransition{ServiceName: clusterID + "/remote"})
+	gw.restartUnhealthy(&monitor.HealthTransition{ServiceName: clusterID + "/managed"})
+	gw.restartUnhealthy(&monitor.HealthTransition{ServiceName: clusterID + "/managed"})
+
+	expected := []string{RestartStarted, RestartDone, RestartRecovered}
+	for _, stage := range expected {
+		select {
+		case got := <-stages:
+			if got != stage {
+				t.Fatalf("Expected stage %s, got %s", stage, got)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Timed out waiting for stage %s", stage)
+		}
+	}
+	if len(containers.calls) != 1 || containers.calls[0] != "restart c1" {
+		t.Errorf("Expected one restart of the managed container, got %v", containers.calls)
+	}
+
+	activity := gw.GetActivityBuffer().GetByCluster(clusterID, 10)
+	found := false
+	for _, entry := range activity {
+		found = found || entry.Operation == "RESTART"
+	}
+	if !found {
+		t.Error("Expected the restart in the activity feed")
+	}
+}
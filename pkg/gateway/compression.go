@@ -0,0 +1,254 @@
+package gateway
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/akmadan/throome/internal/config"
+)
+
+// encoder is a compressing writer, reused across responses through Reset
+type encoder interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+// compressor compresses responses as set in server.compression
+type compressor struct {
+	minSize      int
+	contentTypes []string
+	gzipWriters  sync.Pool
+	zlibWriters  sync.Pool
+}
+
+func newCompressor(cfg config.CompressionConfig) *compressor {
+	c := &compressor{
+		minSize:      cfg.MinSize,
+		contentTypes: cfg.ContentTypes,
+	}
+	// The level was validated with the configuration
+	c.gzipWriters.New = func() interface{} {
+		w, _ := gzip.NewWriterLevel(io.Discard, cfg.Level)
+		return w
+	}
+	c.zlibWriters.New = func() interface{} {
+		w, _ := zlib.NewWriterLevel(io.Discard, cfg.Level)
+		return w
+	}
+	return c
+}
+
+// compressionMiddleware compresses responses for clients accepting gzip or deflate.
+// A response is buffered until it reaches the minimum size, so small ones are sent
+// as is; streamed responses are compressed from their first flush.
+func (s *Server) compressionMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// WebSocket upgrades take over the connection
+		if r.Header.Get("Upgrade") != "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Add("Vary", "Accept-Encoding")
+		encoding := acceptedEncoding(r.Header.Get("Accept-Encoding"))
+		if encoding == "" || r.Method == http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		cw := &compressWriter{ResponseWriter: w, compressor: s.compressor, encoding: encoding, status: http.StatusOK}
+		defer cw.Close()
+		next.ServeHTTP(cw, r)
+	})
+}
+
+// encoder returns a pooled encoder writing to w
+func (c *compressor) encoder(encoding string, w io.Writer) encoder {
+	var e encoder
+	if encoding == "gzip" {
+		e = c.gzipWriters.Get().(*gzip.Writer)
+	} else {
+		e = c.zlibWriters.Get().(*zlib.Writer)
+	}
+	e.Reset(w)
+	return e
+}
+
+// release returns a closed encoder to its pool
+func (c *compressor) release(encoding string, e encoder) {
+	if encoding == "gzip" {
+		c.gzipWriters.Put(e)
+	} else {
+		c.zlibWriters.Put(e)
+	}
+}
+
+// compresses reports whether responses of a content type are compressed
+func (c *compressor) compresses(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, listed := range c.contentTypes {
+		if prefix, ok := strings.CutSuffix(listed, "*"); ok {
+			if strings.HasPrefix(mediaType, prefix) {
+				return true
+			}
+		} else if mediaType == listed {
+			return true
+		}
+	}
+	return false
+}
+
+// compressWriter holds back the status and body of a response until it knows whether
+// to compress it
+type compressWriter struct {
+	http.ResponseWriter
+	compressor *compressor
+	encoding   string
+	status     int
+	buf        []byte
+	decided    bool
+	encoder    encoder // Nil when the response is sent as is
+}
+
+func (cw *compressWriter) WriteHeader(status int) {
+	if cw.decided || status < http.StatusOK {
+		cw.ResponseWriter.WriteHeader(status)
+		return
+	}
+	cw.status = status
+
+	// Some responses show from their header alone that they are sent as is
+	h := cw.Header()
+	length, err := strconv.Atoi(h.Get("Content-Length"))
+	if status == http.StatusNoContent || status == http.StatusNotModified ||
+		h.Get("Content-Encoding") != "" ||
+		(err == nil && length < cw.compressor.minSize) ||
+		(h.Get("Content-Type") != "" && !cw.compressor.compresses(h.Get("Content-Type"))) {
+		cw.decide(false)
+	}
+}
+
+func (cw *compressWriter) Write(b []byte) (int, error) {
+	if cw.decided {
+		return cw.writer().Write(b)
+	}
+
+	cw.buf = append(cw.buf, b...)
+	if len(cw.buf) >= cw.compressor.minSize {
+		if err := cw.decide(cw.compressible()); err != nil {
+			return 0, err
+		}
+	}
+	return len(b), nil
+}
+
+// Flush sends what has been written so far, compressing a response not decided on yet
+// if its content type is compressed
+func (cw *compressWriter) Flush() {
+	if !cw.decided {
+		_ = cw.decide(cw.compressible())
+	}
+	if cw.encoder != nil {
+		_ = cw.encoder.Flush()
+	}
+	_ = http.NewResponseController(cw.ResponseWriter).Flush()
+}
+
+// Unwrap gives http.ResponseController access to the underlying writer
+func (cw *compressWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+// Close sends a response that stayed below the minimum size and ends a compressed one
+func (cw *compressWriter) Close() {
+	if !cw.decided {
+		_ = cw.decide(false)
+	}
+	if cw.encoder != nil {
+		_ = cw.encoder.Close()
+		cw.compressor.release(cw.encoding, cw.encoder)
+		cw.encoder = nil
+	}
+}
+
+// compressible reports whether the response is compressed, from its header and the
+// body buffered so far
+func (cw *compressWriter) compressible() bool {
+	h := cw.Header()
+	if h.Get("Content-Encoding") != "" {
+		return false
+	}
+	// As net/http would, which could otherwise only sniff the compressed body
+	if h.Get("Content-Type") == "" && len(cw.buf) > 0 {
+		h.Set("Content-Type", http.DetectContentType(cw.buf))
+	}
+	return cw.compressor.compresses(h.Get("Content-Type"))
+}
+
+// decide sends the header, compressed or not, and the body buffered so far
+func (cw *compressWriter) decide(compress bool) error {
+	cw.decided = true
+	if compress {
+		h := cw.Header()
+		h.Del("Content-Length")
+		h.Set("Content-Encoding", cw.encoding)
+		cw.encoder = cw.compressor.encoder(cw.encoding, cw.ResponseWriter)
+	}
+	cw.ResponseWriter.WriteHeader(cw.status)
+
+	buffered := cw.buf
+	cw.buf = nil
+	if len(buffered) == 0 {
+		return nil
+	}
+	_, err := cw.writer().Write(buffered)
+	return err
+}
+
+// writer returns where the body goes once the response is decided on
+func (cw *compressWriter) writer() io.Writer {
+	if cw.encoder != nil {
+		return cw.encoder
+	}
+	return cw.ResponseWriter
+}
+
+// acceptedEncoding picks the encoding of an Accept-Encoding header to compress with,
+// gzip or deflate, or "" when the client accepts neither
+func acceptedEncoding(header string) string {
+	best, bestQ := "", 0.0
+	for _, accepted := range strings.Split(header, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(accepted), ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		if coding == "*" {
+			coding = "gzip"
+		}
+		if coding != "gzip" && coding != "deflate" {
+			continue
+		}
+
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		// gzip wins ties, as it is the more widely supported
+		if q > bestQ || (q == bestQ && q > 0 && coding == "gzip") {
+			best, bestQ = coding, q
+		}
+	}
+	return best
+}
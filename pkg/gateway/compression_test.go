@@ -0,0 +1,106 @@
+package gateway
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/akmadan/throome/internal/config"
+)
+
+func TestAcceptedEncoding(t *testing.T) {
+	tests := map[string]string{
+		"":                        "",
+		"gzip":                    "gzip",
+		"deflate":                 "deflate",
+		"deflate, gzip":           "gzip",
+		"gzip;q=0.5, deflate":     "deflate",
+		"gzip;q=0":                "",
+		"br, *":                   "gzip",
+		"identity":                "",
+		"GZIP;q=1.0, deflate;q=1": "gzip",
+	}
+	for header, expected := range tests {
+		if encoding := acceptedEncoding(header); encoding != expected {
+			t.Errorf("acceptedEncoding(%q) = %q, expected %q", header, encoding, expected)
+		}
+	}
+}
+
+func TestCompressionMiddleware(t *testing.T) {
+	cfg := config.DefaultConfig().Server.Compression
+	cfg.Enabled = true
+	s := &Server{compressor: newCompressor(cfg)}
+
+	large := strings.Repeat(`{"id": 1},`, 500)
+	serve := func(contentType, body string) *httptest.ResponseRecorder {
+		handler := s.compressionMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", contentType)
+			w.WriteHeader(http.StatusOK)
+			_, _ = io.WriteString(w, body)
+		}))
+		req := httptest.NewRequest("GET", "/api/v1/activity", nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := serve("application/json", large)
+	if rec.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("Expected a large JSON response to be compressed, got encoding %q", rec.Header().Get("Content-Encoding"))
+	}
+	reader, err := gzip.NewReader(rec.Body)
+	if err != nil {
+		t.Fatalf("Failed to read compressed response: %v", err)
+	}
+	body, _ := io.ReadAll(reader)
+	if string(body) != large {
+		t.Error("Expected the compressed response to decompress to the body")
+	}
+
+	rec = serve("application/json", `{"id": 1}`)
+	if rec.Header().Get("Content-Encoding") != "" || rec.Body.String() != `{"id": 1}` {
+		t.Error("Expected a small response to be sent as is")
+	}
+
+	rec = serve("image/png", large)
+	if rec.Header().Get("Content-Encoding") != "" || rec.Body.String() != large {
+		t.Error("Expected a response of an unlisted content type to be sent as is")
+	}
+	if rec.Header().Get("Vary") != "Accept-Encoding" {
+		t.Error("Expected responses to vary by Accept-Encoding")
+	}
+}
+
+func TestCompressionMiddlewareFlush(t *testing.T) {
+	cfg := config.DefaultConfig().Server.Compression
+	cfg.Enabled = true
+	s := &Server{compressor: newCompressor(cfg)}
+
+	handler := s.compressionMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", NDJSONContentType)
+		_, _ = io.WriteString(w, "{\"id\": 1}\n")
+		_ = http.NewResponseController(w).Flush()
+		_, _ = io.WriteString(w, "{\"id\": 2}\n")
+	}))
+	req := httptest.NewRequest("POST", "/api/v1/clusters/c1/db/query", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if !rec.Flushed || rec.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatal("Expected a flushed stream to be compressed from its first flush")
+	}
+	reader, err := gzip.NewReader(rec.Body)
+	if err != nil {
+		t.Fatalf("Failed to read compressed response: %v", err)
+	}
+	body, _ := io.ReadAll(reader)
+	if string(body) != "{\"id\": 1}\n{\"id\": 2}\n" {
+		t.Errorf("Unexpected streamed body: %q", body)
+	}
+}
@@ -0,0 +1,92 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/akmadan/throome/internal/logger"
+	"github.com/akmadan/throome/pkg/adapters"
+	"github.com/akmadan/throome/pkg/cluster"
+	"go.uber.org/zap"
+)
+
+// Subscribe starts a gateway-managed consumer for a topic on a queue service and
+// returns the function that stops it. When the service has dead-lettering enabled,
+// messages the handler fails on are retried and then moved to the topic's dead-letter
+// topic instead of being dropped. On services with consumer groups each consumer is a
+// member of its own, joining the named group or the service's default one, so several
+// consumers of a topic share its messages.
+func (g *Gateway) Subscribe(ctx context.Context, clusterID, serviceName, topic, group string, handler adapters.MessageHandler) (func(ctx context.Context) error, error) {
+	config, err := g.GetClusterConfig(clusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	serviceConfig, exists := config.Services[serviceName]
+	if !exists {
+		return nil, fmt.Errorf("service not found: %s", serviceName)
+	}
+
+	adapter, err := g.GetAdapter(clusterID, serviceName)
+	if err != nil {
+		return nil, err
+	}
+
+	queue, ok := adapter.(adapters.QueueAdapter)
+	if !ok {
+		return nil, fmt.Errorf("service %s is not a queue", serviceName)
+	}
+
+	subscriber, groups := adapters.Unwrap(adapter).(adapters.GroupSubscriber)
+	if group != "" && !groups {
+		return nil, fmt.Errorf("service %s does not support consumer groups", serviceName)
+	}
+
+	if serviceConfig.DeadLetter.Enabled {
+		policy := deadLetterPolicy(serviceConfig.DeadLetter)
+		next := adapters.WithDeadLetter(queue, policy, handler)
+		handler = func(ctx context.Context, message *adapters.Message) error {
+			err := next(ctx, message)
+			if err != nil {
+				logger.Error("Failed to dead-letter message",
+					zap.String("cluster_id", clusterID),
+					zap.String("topic", message.Topic),
+					zap.Int64("offset", message.Offset),
+					zap.Error(err),
+				)
+			}
+			return err
+		}
+	}
+
+	if !groups {
+		if err := queue.Subscribe(ctx, topic, handler); err != nil {
+			return nil, err
+		}
+		return func(ctx context.Context) error {
+			return queue.Unsubscribe(ctx, topic)
+		}, nil
+	}
+
+	// The subscriber is the underlying adapter, so its faults are injected here
+	if err := adapters.InjectFault(ctx, adapter); err != nil {
+		return nil, err
+	}
+	id, err := subscriber.SubscribeGroup(ctx, topic, group, handler)
+	if err != nil {
+		return nil, err
+	}
+	return func(ctx context.Context) error {
+		return subscriber.UnsubscribeGroup(ctx, id)
+	}, nil
+}
+
+// deadLetterPolicy converts a service's dead-letter config to an adapter policy
+func deadLetterPolicy(config cluster.DeadLetterConfig) adapters.DeadLetterPolicy {
+	return adapters.DeadLetterPolicy{
+		MaxRetries:   config.MaxRetries,
+		RetryBackoff: time.Duration(config.RetryBackoffMS) * time.Millisecond,
+		TopicSuffix:  config.TopicSuffix,
+	}
+}
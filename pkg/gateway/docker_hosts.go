@@ -0,0 +1,33 @@
+package gateway
+
+import (
+	"fmt"
+
+	"github.com/akmadan/throome/pkg/cluster"
+	"github.com/akmadan/throome/pkg/provisioner"
+)
+
+// hostSelector is implemented by provisioners that can run containers on other
+// Docker hosts than the gateway's
+type hostSelector interface {
+	ForHost(options provisioner.DockerHostOptions) (provisioner.Provisioner, error)
+}
+
+// provisionerFor returns the provisioner of the Docker host a cluster's containers
+// run on. Clusters without a Docker host use the gateway's provisioner.
+func provisionerFor(base provisioner.Provisioner, config *cluster.Config) (provisioner.Provisioner, error) {
+	if base == nil || config.Docker == nil {
+		return base, nil
+	}
+
+	selector, ok := base.(hostSelector)
+	if !ok {
+		return nil, fmt.Errorf("provisioner cannot run containers on Docker host %s", config.Docker.Host)
+	}
+	return selector.ForHost(provisioner.DockerHostOptions{
+		Host:     config.Docker.Host,
+		CAFile:   config.Docker.CAFile,
+		CertFile: config.Docker.CertFile,
+		KeyFile:  config.Docker.KeyFile,
+	})
+}
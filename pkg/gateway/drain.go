@@ -0,0 +1,27 @@
+package gateway
+
+import (
+	"github.com/akmadan/throome/internal/logger"
+	"go.uber.org/zap"
+)
+
+// DrainCluster makes a cluster read-only: data operations that write are refused
+// until it is resumed, while its services stay connected and health-checked
+func (g *Gateway) DrainCluster(clusterID string) error {
+	if _, err := g.clusterManager.Drain(clusterID); err != nil {
+		return err
+	}
+
+	logger.Info("Cluster drained", zap.String("cluster_id", clusterID))
+	return nil
+}
+
+// ResumeCluster accepts writes to a drained cluster again
+func (g *Gateway) ResumeCluster(clusterID string) error {
+	if _, err := g.clusterManager.Resume(clusterID); err != nil {
+		return err
+	}
+
+	logger.Info("Cluster resumed", zap.String("cluster_id", clusterID))
+	return nil
+}
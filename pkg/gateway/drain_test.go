@@ -0,0 +1,108 @@
+package gateway
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/akmadan/throome/internal/config"
+	"github.com/akmadan/throome/pkg/cluster"
+	"github.com/gorilla/mux"
+)
+
+func TestDrainCluster(t *testing.T) {
+	gw := newTestGateway(t)
+	s := &Server{config: config.DefaultConfig(), gateway: gw}
+
+	ctx := context.Background()
+	clusterID, err := gw.CreateCluster(ctx, "drain", &cluster.Config{
+		Services: map[string]cluster.ServiceConfig{
+			"store": {Type: "test-update", Host: "localhost", Port: 9801},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create cluster: %v", err)
+	}
+
+	written := false
+	router := mux.NewRouter()
+	router.HandleFunc("/clusters/{cluster_id}/write", s.mutating(func(w http.ResponseWriter, r *http.Request) {
+		written = true
+	}))
+	write := func() int {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest("POST", "/clusters/"+clusterID+"/write", nil))
+		return rec.Code
+	}
+
+	if err := gw.DrainCluster(clusterID); err != nil {
+		t.Fatalf("Failed to drain cluster: %v", err)
+	}
+	if code := write(); code != http.StatusServiceUnavailable || written {
+		t.Errorf("Expected writes to a drained cluster to be refused, got %d", code)
+	}
+	if _, err := gw.GetAdapter(clusterID, "store"); err != nil {
+		t.Errorf("Expected a drained cluster to stay connected, got %v", err)
+	}
+
+	// Updating the configuration keeps the cluster drained
+	current, _ := gw.GetClusterConfig(clusterID)
+	if err := gw.UpdateCluster(ctx, clusterID, &cluster.Config{Name: "drained", Services: current.Services}); err != nil {
+		t.Fatalf("Failed to update cluster: %v", err)
+	}
+	if updated, _ := gw.GetClusterConfig(clusterID); !updated.IsDrained() {
+		t.Error("Expected the updated cluster to stay drained")
+	}
+
+	if err := gw.ResumeCluster(clusterID); err != nil {
+		t.Fatalf("Failed to resume cluster: %v", err)
+	}
+	if code := write(); code != http.StatusOK || !written {
+		t.Errorf("Expected writes to a resumed cluster to be served, got %d", code)
+	}
+}
+
+func TestDrainedClusterRefusesTransactionsAndCommits(t *testing.T) {
+	gw := newTestGateway(t)
+	clusterID, err := gw.CreateCluster(context.Background(), "drain-routes", &cluster.Config{
+		Services: map[string]cluster.ServiceConfig{
+			"store": {Type: "test-update", Host: "localhost", Port: 9894},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create cluster: %v", err)
+	}
+	if err := gw.DrainCluster(clusterID); err != nil {
+		t.Fatalf("Failed to drain cluster: %v", err)
+	}
+
+	s := &Server{config: config.DefaultConfig(), gateway: gw, router: mux.NewRouter()}
+	s.setupRoutes()
+	send := func(path, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		s.router.ServeHTTP(rec, httptest.NewRequest("POST", "/api/v1/clusters/"+clusterID+path, strings.NewReader(body)))
+		return rec
+	}
+
+	// A transaction begun before the drain can neither write nor commit
+	for _, path := range []string{
+		"/db/transactions",
+		"/db/transactions/tx-1/execute",
+		"/db/transactions/tx-1/query",
+		"/db/transactions/tx-1/commit",
+		"/queue/commit",
+		"/queue/groups/billing/reset",
+		"/snapshots",
+	} {
+		if rec := send(path, `{}`); rec.Code != http.StatusServiceUnavailable {
+			t.Errorf("Expected POST %s on a drained cluster to answer 503, got %d: %s", path, rec.Code, rec.Body.String())
+		}
+	}
+
+	// It can still be rolled back
+	if rec := send("/db/transactions/tx-1/rollback", ""); rec.Code == http.StatusServiceUnavailable {
+		t.Errorf("Expected rollbacks to be served on a drained cluster, got %d", rec.Code)
+	}
+}
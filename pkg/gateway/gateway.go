@@ -3,32 +3,60 @@ package gateway
 import (
 	"context"
 	"fmt"
+	"reflect"
 	"sync"
 	"time"
 
 	"github.com/akmadan/throome/internal/logger"
 	"github.com/akmadan/throome/pkg/adapters"
+	"github.com/akmadan/throome/pkg/adapters/dynamodb"
+	"github.com/akmadan/throome/pkg/adapters/etcd"
+	"github.com/akmadan/throome/pkg/adapters/influxdb"
 	"github.com/akmadan/throome/pkg/adapters/kafka"
+	"github.com/akmadan/throome/pkg/adapters/minio"
+	"github.com/akmadan/throome/pkg/adapters/mongodb"
+	"github.com/akmadan/throome/pkg/adapters/mqtt"
 	"github.com/akmadan/throome/pkg/adapters/postgres"
+	"github.com/akmadan/throome/pkg/adapters/qdrant"
+	"github.com/akmadan/throome/pkg/adapters/rabbitmq"
 	"github.com/akmadan/throome/pkg/adapters/redis"
+	"github.com/akmadan/throome/pkg/adapters/vault"
 	"github.com/akmadan/throome/pkg/cluster"
 	"github.com/akmadan/throome/pkg/monitor"
+	"github.com/akmadan/throome/pkg/provisioner"
 	"github.com/akmadan/throome/pkg/router"
 	"go.uber.org/zap"
 )
 
 // Gateway is the main Throome gateway service
 type Gateway struct {
-	clusterManager *cluster.Manager
-	routers        map[string]*router.Router
-	adapters       map[string]map[string]adapters.Adapter // clusterID -> serviceName -> adapter
-	adapterFactory *adapters.Factory
-	collector      *monitor.Collector
-	healthChecker  *monitor.HealthChecker
-	provisioner    interface{} // Docker provisioner (interface for flexibility)
-	activityBuffer *monitor.ActivityBuffer
-	activityLogger *monitor.DefaultActivityLogger
-	mu             sync.RWMutex
+	clusterManager    *cluster.Manager
+	routers           map[string]*router.Router
+	adapters          map[string]map[string]adapters.Adapter   // clusterID -> serviceName -> adapter
+	replicas          map[string]map[string][]adapters.Adapter // clusterID -> serviceName -> adapters of the service's replicas
+	adapterFactory    *adapters.Factory
+	collector         *monitor.Collector
+	healthChecker     *monitor.HealthChecker
+	provisioner       provisioner.Provisioner // Runs provisioned services, nil when none is available
+	activityBuffer    *monitor.ActivityBuffer
+	activityLogger    *monitor.DefaultActivityLogger
+	anomalies         *monitor.AnomalyDetector
+	aiStops           map[string]chan struct{} // clusterID -> stop channel for anomaly detection
+	capacity          *monitor.CapacityPlanner
+	alerts            *monitor.AlertEngine               // Evaluates alert rules, nil when alerting is disabled
+	alertInterval     time.Duration                      // How often alert rules are evaluated
+	faults            map[string]*adapters.FaultInjector // clusterID/serviceName -> injected faults
+	interval          time.Duration                      // How often service statistics are collected
+	archiveRetention  time.Duration                      // How long archived clusters are kept, forever when zero
+	stopChan          chan struct{}
+	restarting        map[string]bool // clusterID/serviceName -> being restarted after turning unhealthy
+	restartHandlers   []AutoRestartHandler
+	restartMu         sync.Mutex
+	provisionHandlers []ProvisionHandler
+	provisionMu       sync.Mutex
+	loaded            bool // Clusters are loaded and the gateway is not shutting down
+	mu                sync.RWMutex
+	updateMu          sync.Mutex // Serializes changes to cluster configurations
 }
 
 // NewGateway creates a new gateway instance
@@ -42,34 +70,73 @@ func NewGateway(clustersDir string) (*Gateway, error) {
 	// Register adapter constructors
 	factory.Register("redis", redis.NewRedisAdapter)
 	factory.Register("postgres", postgres.NewPostgresAdapter)
+	factory.Register("cockroachdb", postgres.NewCockroachDBAdapter)
 	factory.Register("kafka", kafka.NewKafkaAdapter)
+	factory.Register("mongodb", mongodb.NewMongoDBAdapter)
+	factory.Register("rabbitmq", rabbitmq.NewRabbitMQAdapter)
+	factory.Register("minio", minio.NewMinIOAdapter)
+	factory.Register("s3", minio.NewMinIOAdapter)
+	factory.Register("etcd", etcd.NewEtcdAdapter)
+	factory.Register("influxdb", influxdb.NewInfluxDBAdapter)
+	factory.Register("mqtt", mqtt.NewMQTTAdapter)
+	factory.Register("vault", vault.NewVaultAdapter)
+	factory.Register("dynamodb", dynamodb.NewDynamoDBAdapter)
+	factory.Register("qdrant", qdrant.NewQdrantAdapter)
+	registerCustomAdapters(factory)
 
 	// Create collector
 	collector := monitor.NewCollector()
 
-	// Create health checker (10s interval, 5s timeout, 3 failures threshold)
+	// Create health checker (10s interval, 5s timeout, 3 failures threshold for clusters
+	// that leave them unset)
 	healthChecker := monitor.NewHealthChecker(10*time.Second, 5*time.Second, 3)
 
 	// Create activity buffer (store last 1000 activities)
 	activityBuffer := monitor.NewActivityBuffer(1000)
 	activityLogger := monitor.NewActivityLogger(activityBuffer).(*monitor.DefaultActivityLogger)
-
-	// Create Docker provisioner (optional - continues if Docker is not available)
-	var provisioner interface{}
-	// Provisioner will be initialized later to avoid import cycles
-	// It will be set via SetProvisioner method
-
-	return &Gateway{
+	activityLogger.SetCollector(collector)
+
+	// Create anomaly detector (keep last 500 anomalies) and surface findings in the activity feed
+	anomalies := monitor.NewAnomalyDetector(500)
+	anomalies.OnAnomaly(func(anomaly *monitor.Anomaly) {
+		logger.Warn("Anomaly detected",
+			zap.String("cluster_id", anomaly.ClusterID),
+			zap.String("service", anomaly.ServiceName),
+			zap.String("metric", anomaly.Metric),
+			zap.String("explanation", anomaly.Explanation),
+		)
+		activityLogger.Log(&monitor.ActivityLog{
+			Timestamp:   anomaly.DetectedAt,
+			ClusterID:   anomaly.ClusterID,
+			ServiceName: anomaly.ServiceName,
+			ServiceType: anomaly.ServiceType,
+			Operation:   "ANOMALY",
+			Command:     anomaly.Metric,
+			Status:      "warning",
+			Response:    anomaly.Explanation,
+		})
+	})
+
+	g := &Gateway{
 		clusterManager: clusterManager,
 		routers:        make(map[string]*router.Router),
 		adapters:       make(map[string]map[string]adapters.Adapter),
+		replicas:       make(map[string]map[string][]adapters.Adapter),
 		adapterFactory: factory,
 		collector:      collector,
 		healthChecker:  healthChecker,
-		provisioner:    provisioner,
 		activityBuffer: activityBuffer,
 		activityLogger: activityLogger,
-	}, nil
+		anomalies:      anomalies,
+		aiStops:        make(map[string]chan struct{}),
+		capacity:       monitor.NewCapacityPlanner(),
+		faults:         make(map[string]*adapters.FaultInjector),
+		interval:       10 * time.Second,
+		stopChan:       make(chan struct{}),
+		restarting:     make(map[string]bool),
+	}
+	healthChecker.OnTransition(g.restartUnhealthy)
+	return g, nil
 }
 
 // Initialize initializes the gateway by loading all clusters
@@ -86,6 +153,10 @@ func (g *Gateway) Initialize(ctx context.Context) error {
 
 	// Initialize adapters for each cluster
 	for clusterID, config := range configs {
+		if config.IsArchived() {
+			logger.Info("Skipping archived cluster", zap.String("cluster_id", clusterID))
+			continue
+		}
 		if err := g.initializeCluster(ctx, clusterID, config); err != nil {
 			logger.Error("Failed to initialize cluster",
 				zap.String("cluster_id", clusterID),
@@ -95,10 +166,112 @@ func (g *Gateway) Initialize(ctx context.Context) error {
 		}
 	}
 
+	// Start collecting pool and cache statistics
+	go g.collectServiceStats(g.interval)
+
+	// Start evaluating alert rules
+	if g.alerts != nil {
+		go g.evaluateAlerts(g.alertInterval)
+	}
+
+	// Start purging archives past their retention window
+	if g.archiveRetention > 0 {
+		go g.purgeExpiredArchives(time.Hour)
+	}
+
+	g.mu.Lock()
+	g.loaded = true
+	g.mu.Unlock()
+
 	logger.Info("Gateway initialized successfully")
 	return nil
 }
 
+// Readiness reports whether the gateway has loaded its clusters and is not shutting
+// down, and how many services of its active clusters are configured and connected
+func (g *Gateway) Readiness() (loaded bool, connected, configured int) {
+	configs := g.clusterManager.GetAllConfigs()
+
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	for clusterID, config := range configs {
+		if config.IsArchived() {
+			continue
+		}
+		configured += len(config.Services)
+		for serviceName := range config.Services {
+			if _, exists := g.adapters[clusterID][serviceName]; exists {
+				connected++
+			}
+		}
+	}
+	return g.loaded, connected, configured
+}
+
+// SetCollectionInterval sets how often service statistics are collected. It must be
+// called before Initialize.
+func (g *Gateway) SetCollectionInterval(interval time.Duration) {
+	if interval > 0 {
+		g.interval = interval
+	}
+}
+
+// collectServiceStats periodically records the connection pool usage of every adapter
+// for capacity planning and Prometheus, and the server statistics of every cache
+func (g *Gateway) collectServiceStats(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	type cacheService struct {
+		clusterID   string
+		serviceName string
+		serviceType string
+		provider    adapters.CacheStatsProvider
+	}
+
+	for {
+		select {
+		case <-g.stopChan:
+			return
+		case now := <-ticker.C:
+			var caches []cacheService
+
+			g.mu.RLock()
+			for clusterID, clusterAdapters := range g.adapters {
+				for serviceName, adapter := range clusterAdapters {
+					adapter = adapters.Unwrap(adapter)
+					if provider, ok := adapter.(adapters.PoolStatsProvider); ok {
+						stats := provider.PoolStats()
+						g.capacity.Record(clusterID, serviceName, stats, now)
+						g.collector.RecordPoolStats(clusterID, serviceName, adapter.GetType(), stats)
+					}
+					if provider, ok := adapter.(adapters.CacheStatsProvider); ok {
+						caches = append(caches, cacheService{clusterID, serviceName, adapter.GetType(), provider})
+					}
+				}
+			}
+			g.mu.RUnlock()
+
+			// Query caches without holding the lock, they involve a round trip
+			for _, cache := range caches {
+				ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				stats, err := cache.provider.CacheStats(ctx)
+				cancel()
+				if err != nil {
+					logger.Debug("Failed to collect cache statistics",
+						zap.String("cluster_id", cache.clusterID),
+						zap.String("service", cache.serviceName),
+						zap.Error(err),
+					)
+					continue
+				}
+				g.collector.RecordCacheStats(cache.clusterID, cache.serviceName, cache.serviceType, stats)
+			}
+		}
+	}
+}
+
 // initializeCluster initializes a single cluster
 func (g *Gateway) initializeCluster(ctx context.Context, clusterID string, config *cluster.Config) error {
 	g.mu.Lock()
@@ -106,56 +279,201 @@ func (g *Gateway) initializeCluster(ctx context.Context, clusterID string, confi
 
 	logger.Info("Initializing cluster",
 		zap.String("cluster_id", clusterID),
+		zap.String("namespace", config.NamespaceOrDefault()),
 		zap.String("name", config.Name),
 	)
+	g.collector.SetNamespace(clusterID, config.NamespaceOrDefault())
+	g.activityLogger.SetClusterConfig(clusterID, config.Activity)
 
 	// Create adapters for this cluster
 	clusterAdapters := make(map[string]adapters.Adapter)
 
 	for serviceName := range config.Services {
 		serviceConfig := config.Services[serviceName]
-		adapter, err := g.adapterFactory.Create(&serviceConfig)
+		adapter, err := g.connectService(ctx, clusterID, serviceName, &serviceConfig)
 		if err != nil {
-			logger.Error("Failed to create adapter",
-				zap.String("cluster_id", clusterID),
-				zap.String("service", serviceName),
-				zap.Error(err),
-			)
 			continue
 		}
+		clusterAdapters[serviceName] = adapter
+	}
 
-		// Set activity logger if adapter supports it
-		if baseAdapter, ok := adapter.(interface {
-			SetActivityLogger(logger adapters.ActivityLogger, clusterID, serviceName string)
-		}); ok {
-			baseAdapter.SetActivityLogger(g.activityLogger, clusterID, serviceName)
+	// Store adapters
+	g.adapters[clusterID] = clusterAdapters
+
+	// Create router for this cluster
+	g.routers[clusterID] = g.newRouter(clusterID, config, clusterAdapters)
+	g.syncReplicas(ctx, clusterID, nil, config)
+	g.watchHealth(clusterID, config)
+
+	// Start learning metric baselines if AI optimization is enabled
+	if config.AI.Enabled {
+		g.startAnomalyDetection(clusterID, config.AI)
+	}
+
+	return nil
+}
+
+// newRouter creates the router of a cluster, which routes around the services that
+// fail their health checks
+func (g *Gateway) newRouter(clusterID string, config *cluster.Config, clusterAdapters map[string]adapters.Adapter) *router.Router {
+	r := router.NewRouter(config, clusterAdapters)
+	r.SetHealth(func(serviceName string) bool {
+		return g.healthChecker.IsHealthy(clusterID + "/" + serviceName)
+	})
+	return r
+}
+
+// watchHealth starts or stops the periodic health checks of a cluster's services and
+// their replicas by its health settings. Caller must hold the lock.
+func (g *Gateway) watchHealth(clusterID string, config *cluster.Config) {
+	if !config.Health.Enabled {
+		g.healthChecker.Unwatch(clusterID)
+		return
+	}
+	g.healthChecker.Watch(clusterID, config.Health, func() map[string]adapters.Adapter {
+		g.mu.RLock()
+		defer g.mu.RUnlock()
+
+		services := make(map[string]adapters.Adapter, len(g.adapters[clusterID]))
+		for serviceName, adapter := range g.adapters[clusterID] {
+			services[serviceName] = adapter
+		}
+		for serviceName, replicas := range g.replicas[clusterID] {
+			for i, replica := range replicas {
+				if replica != nil {
+					services[replicaName(serviceName, i)] = replica
+				}
+			}
 		}
+		return services
+	})
+}
 
-		// Connect to the service
-		if err := adapter.Connect(ctx); err != nil {
-			logger.Error("Failed to connect adapter",
-				zap.String("cluster_id", clusterID),
-				zap.String("service", serviceName),
-				zap.Error(err),
-			)
+// ServiceHealth returns the health of a cluster's services: their last check by the
+// health checker, or a check made now for the services it has not checked yet
+func (g *Gateway) ServiceHealth(ctx context.Context, clusterID string) (map[string]*adapters.HealthStatus, error) {
+	r, err := g.GetRouter(clusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make(map[string]*adapters.HealthStatus)
+	unchecked := false
+	for serviceName := range r.GetAllAdapters() {
+		status, checked := g.healthChecker.LastStatus(clusterID + "/" + serviceName)
+		if !checked {
+			unchecked = true
 			continue
 		}
+		results[serviceName] = status
+	}
 
-		clusterAdapters[serviceName] = adapter
-		logger.Info("Connected to service",
+	if unchecked {
+		for serviceName, status := range r.HealthCheckAll(ctx) {
+			if _, checked := results[serviceName]; !checked {
+				results[serviceName] = status
+			}
+		}
+	}
+	return results, nil
+}
+
+// connectService creates and connects the adapter of a service and routes it through
+// a new fault injector. Failures are logged. Caller must hold the lock.
+func (g *Gateway) connectService(ctx context.Context, clusterID, serviceName string, serviceConfig *cluster.ServiceConfig) (adapters.Adapter, error) {
+	adapter, err := g.adapterFactory.Create(serviceConfig)
+	if err != nil {
+		logger.Error("Failed to create adapter",
 			zap.String("cluster_id", clusterID),
 			zap.String("service", serviceName),
-			zap.String("type", serviceConfig.Type),
+			zap.Error(err),
 		)
+		return nil, err
 	}
 
-	// Store adapters
-	g.adapters[clusterID] = clusterAdapters
+	// Set activity logger if adapter supports it
+	if baseAdapter, ok := adapter.(interface {
+		SetActivityLogger(logger adapters.ActivityLogger, clusterID, serviceName string)
+	}); ok {
+		baseAdapter.SetActivityLogger(g.activityLogger, clusterID, serviceName)
+	}
 
-	// Create router for this cluster
-	g.routers[clusterID] = router.NewRouter(config, clusterAdapters)
+	// Connect to the service
+	if err := adapter.Connect(ctx); err != nil {
+		logger.Error("Failed to connect adapter",
+			zap.String("cluster_id", clusterID),
+			zap.String("service", serviceName),
+			zap.Error(err),
+		)
+		return nil, err
+	}
 
-	return nil
+	// Route all operations through the service's fault injector
+	injector := adapters.NewFaultInjector()
+	g.faults[clusterID+"/"+serviceName] = injector
+
+	g.healthChecker.SetProbes(clusterID+"/"+serviceName, serviceConfig.Probes)
+	logger.Info("Connected to service",
+		zap.String("cluster_id", clusterID),
+		zap.String("service", serviceName),
+		zap.String("type", serviceConfig.Type),
+	)
+	return adapters.WithFaults(adapter, injector), nil
+}
+
+// disconnectService disconnects the adapter of a service and drops its probes and
+// fault injector. Caller must hold the lock.
+func (g *Gateway) disconnectService(ctx context.Context, clusterID, serviceName string, adapter adapters.Adapter) {
+	g.healthChecker.SetProbes(clusterID+"/"+serviceName, nil)
+	delete(g.faults, clusterID+"/"+serviceName)
+	if err := adapter.Disconnect(ctx); err != nil {
+		logger.Error("Failed to disconnect adapter",
+			zap.String("cluster_id", clusterID),
+			zap.String("service", serviceName),
+			zap.Error(err),
+		)
+	}
+}
+
+// startAnomalyDetection periodically feeds the cluster's metrics into the anomaly
+// detector. Caller must hold the lock.
+func (g *Gateway) startAnomalyDetection(clusterID string, aiConfig cluster.AIConfig) {
+	g.stopAnomalyDetection(clusterID)
+
+	interval := time.Duration(aiConfig.UpdateInterval) * time.Second
+	if interval <= 0 {
+		interval = 5 * time.Minute
+	}
+	settings := monitor.AnomalySettings{
+		MinDataPoints: aiConfig.MinDataPoints,
+		Features:      aiConfig.Features,
+	}
+
+	stop := make(chan struct{})
+	g.aiStops[clusterID] = stop
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case now := <-ticker.C:
+				g.anomalies.Observe(g.collector.SnapshotCluster(clusterID), settings, now)
+			}
+		}
+	}()
+}
+
+// stopAnomalyDetection stops anomaly detection for a cluster. Caller must hold the lock.
+func (g *Gateway) stopAnomalyDetection(clusterID string) {
+	if stop, exists := g.aiStops[clusterID]; exists {
+		close(stop)
+		delete(g.aiStops, clusterID)
+	}
+	g.anomalies.ForgetCluster(clusterID)
 }
 
 // GetRouter returns the router for a cluster
@@ -171,6 +489,18 @@ func (g *Gateway) GetRouter(clusterID string) (*router.Router, error) {
 	return r, nil
 }
 
+// RouteService returns the connected service of a type that the cluster's routing
+// strategy selects
+func (g *Gateway) RouteService(ctx context.Context, clusterID, serviceType string) (string, error) {
+	r, err := g.GetRouter(clusterID)
+	if err != nil {
+		return "", err
+	}
+
+	serviceName, _, err := r.RouteService(ctx, serviceType)
+	return serviceName, err
+}
+
 // GetAdapter returns an adapter for a specific service in a cluster
 func (g *Gateway) GetAdapter(clusterID, serviceName string) (adapters.Adapter, error) {
 	g.mu.RLock()
@@ -189,6 +519,68 @@ func (g *Gateway) GetAdapter(clusterID, serviceName string) (adapters.Adapter, e
 	return adapter, nil
 }
 
+// SetFault injects faults into a service's operations, replacing any injected before
+func (g *Gateway) SetFault(clusterID, serviceName string, config adapters.FaultConfig) error {
+	g.mu.RLock()
+	injector, exists := g.faults[clusterID+"/"+serviceName]
+	g.mu.RUnlock()
+
+	if !exists {
+		return fmt.Errorf("service not found: %s", serviceName)
+	}
+	if err := injector.Set(config); err != nil {
+		return err
+	}
+
+	logger.Warn("Fault injection enabled",
+		zap.String("cluster_id", clusterID),
+		zap.String("service", serviceName),
+		zap.Duration("latency", config.Latency),
+		zap.Float64("error_rate", config.ErrorRate),
+		zap.Float64("drop_rate", config.DropRate),
+		zap.Bool("unhealthy", config.Unhealthy),
+	)
+	return nil
+}
+
+// ClearFault removes the faults injected into a service
+func (g *Gateway) ClearFault(clusterID, serviceName string) error {
+	g.mu.RLock()
+	injector, exists := g.faults[clusterID+"/"+serviceName]
+	g.mu.RUnlock()
+
+	if !exists {
+		return fmt.Errorf("service not found: %s", serviceName)
+	}
+	injector.Clear()
+
+	logger.Info("Fault injection cleared",
+		zap.String("cluster_id", clusterID),
+		zap.String("service", serviceName),
+	)
+	return nil
+}
+
+// GetFaults returns the active faults of a cluster's services, keyed by service name
+func (g *Gateway) GetFaults(clusterID string) (map[string]*adapters.FaultConfig, error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	clusterAdapters, exists := g.adapters[clusterID]
+	if !exists {
+		return nil, fmt.Errorf("cluster not found: %s", clusterID)
+	}
+
+	faults := make(map[string]*adapters.FaultConfig)
+	for serviceName := range clusterAdapters {
+		if config := g.faults[clusterID+"/"+serviceName].Config(); config != nil {
+			faults[serviceName] = config
+		}
+	}
+
+	return faults, nil
+}
+
 // GetCollector returns the metrics collector
 func (g *Gateway) GetCollector() *monitor.Collector {
 	return g.collector
@@ -199,6 +591,16 @@ func (g *Gateway) GetHealthChecker() *monitor.HealthChecker {
 	return g.healthChecker
 }
 
+// GetAnomalyDetector returns the anomaly detector
+func (g *Gateway) GetAnomalyDetector() *monitor.AnomalyDetector {
+	return g.anomalies
+}
+
+// GetCapacityPlanner returns the capacity planner
+func (g *Gateway) GetCapacityPlanner() *monitor.CapacityPlanner {
+	return g.capacity
+}
+
 // GetClusterManager returns the cluster manager
 func (g *Gateway) GetClusterManager() *cluster.Manager {
 	return g.clusterManager
@@ -209,28 +611,47 @@ func (g *Gateway) GetActivityBuffer() *monitor.ActivityBuffer {
 	return g.activityBuffer
 }
 
-// SetProvisioner sets the Docker provisioner
-func (g *Gateway) SetProvisioner(provisioner interface{}) {
-	g.provisioner = provisioner
+// SetActivityBufferSize sets how many operations the activity buffer keeps, the
+// oldest being dropped first
+func (g *Gateway) SetActivityBufferSize(size int) {
+	if size > 0 {
+		g.activityBuffer.Resize(size)
+	}
+}
+
+// SetProvisioner sets the provisioner of provisioned services. It must be called
+// before clusters are created.
+func (g *Gateway) SetProvisioner(serviceProvisioner provisioner.Provisioner) {
+	g.provisioner = serviceProvisioner
+}
+
+// Provisioner returns the provisioner of provisioned services, or nil when none is
+// available
+func (g *Gateway) Provisioner() provisioner.Provisioner {
+	return g.provisioner
 }
 
-// CreateCluster creates a new cluster and provisions containers
+// CreateCluster creates a new cluster, first provisioning a container for every
+// service that requests one when a provisioner is available. The services of config
+// are pointed at their containers. If the cluster cannot be created its containers
+// are removed; failures to provision are returned as a *ProvisionError.
 func (g *Gateway) CreateCluster(ctx context.Context, name string, config *cluster.Config) (string, error) {
 	logger.Info("Creating cluster",
 		zap.String("name", name),
 		zap.Int("services", len(config.Services)),
 	)
 
-	// If provisioner is available, provision Docker containers first
-	if g.provisioner != nil {
-		logger.Info("Provisioning services with Docker...")
-		// Type assert to access provisioner methods
-		// This will be handled by the server layer
+	// Containers are named after their cluster
+	config.Name = name
+	if err := g.provisionServices(ctx, config); err != nil {
+		return "", err
 	}
 
 	// Create cluster
 	clusterID, err := g.clusterManager.Create(name, config)
 	if err != nil {
+		// Cleanup provisioned containers on failure
+		g.removeProvisioned(ctx, config)
 		return "", err
 	}
 
@@ -252,26 +673,192 @@ func (g *Gateway) CreateCluster(ctx context.Context, name string, config *cluste
 	return clusterID, nil
 }
 
-// DeleteCluster deletes a cluster
-func (g *Gateway) DeleteCluster(ctx context.Context, clusterID string) error {
+// UpdateCluster replaces a cluster's configuration. Services whose configuration is
+// unchanged keep their connections, changed and new services are reconnected, and
+// removed services are disconnected and their metrics dropped. Containers are left to
+// the caller.
+func (g *Gateway) UpdateCluster(ctx context.Context, clusterID string, config *cluster.Config) error {
+	g.updateMu.Lock()
+	defer g.updateMu.Unlock()
+
+	current, err := g.clusterManager.Get(clusterID)
+	if err != nil {
+		return err
+	}
+	return g.updateCluster(ctx, clusterID, current, config)
+}
+
+// AddService adds a service to a running cluster and connects it, leaving the
+// cluster's other services untouched
+func (g *Gateway) AddService(ctx context.Context, clusterID, serviceName string, serviceConfig cluster.ServiceConfig) error {
+	g.updateMu.Lock()
+	defer g.updateMu.Unlock()
+
+	current, err := g.clusterManager.Get(clusterID)
+	if err != nil {
+		return err
+	}
+	if _, exists := current.Services[serviceName]; exists {
+		return fmt.Errorf("service already exists: %s", serviceName)
+	}
+
+	config := current.Clone()
+	config.Services[serviceName] = serviceConfig
+	return g.updateCluster(ctx, clusterID, current, config)
+}
+
+// RemoveService removes a service from a running cluster. Its adapter is disconnected
+// and the configuration saved without it before its container is removed.
+func (g *Gateway) RemoveService(ctx context.Context, clusterID, serviceName string) error {
+	g.updateMu.Lock()
+	defer g.updateMu.Unlock()
+
+	current, err := g.clusterManager.Get(clusterID)
+	if err != nil {
+		return err
+	}
+	serviceConfig, exists := current.Services[serviceName]
+	if !exists {
+		return fmt.Errorf("service not found: %s", serviceName)
+	}
+
+	config := current.Clone()
+	delete(config.Services, serviceName)
+	if err := g.updateCluster(ctx, clusterID, current, config); err != nil {
+		return err
+	}
+
+	removed := &cluster.Config{
+		ClusterID: clusterID,
+		Docker:    current.Docker,
+		Services:  map[string]cluster.ServiceConfig{serviceName: serviceConfig},
+	}
+	g.forEachContainer(removed, "Failed to remove container", func(manager containerManager, containerID string) error {
+		return manager.RemoveService(ctx, containerID)
+	})
+	return nil
+}
+
+// updateCluster replaces the current configuration of a cluster. Caller must hold
+// the update lock.
+func (g *Gateway) updateCluster(ctx context.Context, clusterID string, current, config *cluster.Config) error {
+	if current.IsArchived() {
+		return fmt.Errorf("cluster is archived: %s", clusterID)
+	}
+
+	// Clusters cannot move between namespaces or Docker hosts
+	config.Namespace = current.Namespace
+	config.Docker = current.Docker
+	config.CreatedAt = current.CreatedAt
+	config.DrainedAt = current.DrainedAt
+	if err := g.clusterManager.Update(clusterID, config); err != nil {
+		return err
+	}
+
+	g.reconcileCluster(ctx, clusterID, current, config)
+	return nil
+}
+
+// ReloadCluster re-reads a cluster's configuration from disk and reconciles its
+// adapters with it, so edits to its config.yaml apply without restarting the gateway
+func (g *Gateway) ReloadCluster(ctx context.Context, clusterID string) error {
+	g.updateMu.Lock()
+	defer g.updateMu.Unlock()
+
+	current, err := g.clusterManager.Get(clusterID)
+	if err != nil {
+		return err
+	}
+	if current.IsArchived() {
+		return fmt.Errorf("cluster is archived: %s", clusterID)
+	}
+
+	if err := g.clusterManager.Reload(clusterID); err != nil {
+		return err
+	}
+	config, err := g.clusterManager.Get(clusterID)
+	if err != nil {
+		return err
+	}
+
+	// A cluster archived in its file is unloaded, its containers are left running
+	if config.IsArchived() {
+		g.mu.Lock()
+		g.unloadCluster(ctx, clusterID)
+		g.mu.Unlock()
+		logger.Info("Reloaded cluster is archived", zap.String("cluster_id", clusterID))
+		return nil
+	}
+
+	g.reconcileCluster(ctx, clusterID, current, config)
+	return nil
+}
+
+// reconcileCluster brings a cluster's adapters, router and anomaly detection in line
+// with its new configuration. Unchanged services keep their connections. Caller must
+// hold the update lock.
+func (g *Gateway) reconcileCluster(ctx context.Context, clusterID string, current, config *cluster.Config) {
 	g.mu.Lock()
 	defer g.mu.Unlock()
 
-	// Disconnect all adapters
-	if clusterAdapters, exists := g.adapters[clusterID]; exists {
-		for _, adapter := range clusterAdapters {
-			if err := adapter.Disconnect(ctx); err != nil {
-				logger.Error("Failed to disconnect adapter",
-					zap.String("cluster_id", clusterID),
-					zap.Error(err),
-				)
-			}
+	logger.Info("Updating cluster",
+		zap.String("cluster_id", clusterID),
+		zap.String("name", config.Name),
+	)
+
+	clusterAdapters := make(map[string]adapters.Adapter)
+	for serviceName, adapter := range g.adapters[clusterID] {
+		serviceConfig, exists := config.Services[serviceName]
+		if exists && sameConnection(current.Services[serviceName], serviceConfig) {
+			clusterAdapters[serviceName] = adapter
+			continue
+		}
+
+		g.disconnectService(ctx, clusterID, serviceName, adapter)
+		if !exists {
+			g.capacity.ForgetService(clusterID, serviceName)
+			g.collector.ForgetService(clusterID, serviceName)
+			g.healthChecker.ForgetService(clusterID, serviceName)
 		}
-		delete(g.adapters, clusterID)
 	}
 
-	// Remove router
-	delete(g.routers, clusterID)
+	for serviceName := range config.Services {
+		if _, connected := clusterAdapters[serviceName]; connected {
+			continue
+		}
+		serviceConfig := config.Services[serviceName]
+		adapter, err := g.connectService(ctx, clusterID, serviceName, &serviceConfig)
+		if err != nil {
+			continue
+		}
+		clusterAdapters[serviceName] = adapter
+	}
+
+	g.adapters[clusterID] = clusterAdapters
+	g.routers[clusterID] = g.newRouter(clusterID, config, clusterAdapters)
+	g.syncReplicas(ctx, clusterID, current, config)
+	g.watchHealth(clusterID, config)
+	g.activityLogger.SetClusterConfig(clusterID, config.Activity)
+
+	// Baselines are only relearned when the AI settings change
+	_, detecting := g.aiStops[clusterID]
+	switch {
+	case !config.AI.Enabled:
+		g.stopAnomalyDetection(clusterID)
+	case !detecting || !reflect.DeepEqual(current.AI, config.AI):
+		g.startAnomalyDetection(clusterID, config.AI)
+	}
+
+	logger.Info("Cluster updated", zap.String("cluster_id", clusterID))
+}
+
+// DeleteCluster deletes a cluster's configuration. Provisioned containers are left
+// untouched; use PurgeCluster to remove them as well.
+func (g *Gateway) DeleteCluster(ctx context.Context, clusterID string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.unloadCluster(ctx, clusterID)
 
 	// Delete cluster
 	if err := g.clusterManager.Delete(clusterID); err != nil {
@@ -282,17 +869,54 @@ func (g *Gateway) DeleteCluster(ctx context.Context, clusterID string) error {
 	return nil
 }
 
+// unloadCluster disconnects a cluster's adapters and drops its router, background
+// jobs and metrics. Caller must hold the lock.
+func (g *Gateway) unloadCluster(ctx context.Context, clusterID string) {
+	// Disconnect all adapters
+	if clusterAdapters, exists := g.adapters[clusterID]; exists {
+		for serviceName, adapter := range clusterAdapters {
+			g.disconnectService(ctx, clusterID, serviceName, adapter)
+		}
+		delete(g.adapters, clusterID)
+	}
+	g.disconnectReplicas(ctx, clusterID)
+
+	// Remove router
+	delete(g.routers, clusterID)
+
+	// Stop health checks and anomaly detection and drop capacity samples and metrics
+	g.healthChecker.Unwatch(clusterID)
+	g.healthChecker.ForgetCluster(clusterID)
+	g.stopAnomalyDetection(clusterID)
+	g.capacity.ForgetCluster(clusterID)
+	g.collector.ForgetCluster(clusterID)
+	g.activityLogger.ForgetCluster(clusterID)
+	if g.alerts != nil {
+		g.alerts.ForgetCluster(clusterID)
+	}
+}
+
 // Shutdown gracefully shuts down the gateway
 func (g *Gateway) Shutdown(ctx context.Context) error {
 	logger.Info("Shutting down gateway...")
 
 	g.mu.Lock()
 	defer g.mu.Unlock()
+	g.loaded = false
 
-	// Stop health checker
+	// Stop health checker and background sampling
 	g.healthChecker.Stop()
+	close(g.stopChan)
+
+	// Stop anomaly detection
+	for clusterID := range g.aiStops {
+		g.stopAnomalyDetection(clusterID)
+	}
 
 	// Disconnect all adapters
+	for clusterID := range g.replicas {
+		g.disconnectReplicas(ctx, clusterID)
+	}
 	for clusterID, clusterAdapters := range g.adapters {
 		for serviceName, adapter := range clusterAdapters {
 			logger.Info("Disconnecting adapter",
@@ -318,6 +942,11 @@ func (g *Gateway) ListClusters() ([]string, error) {
 	return g.clusterManager.List()
 }
 
+// ListNamespaces returns the namespaces that have clusters
+func (g *Gateway) ListNamespaces() []string {
+	return g.clusterManager.Namespaces()
+}
+
 // GetClusterConfig returns the configuration for a cluster
 func (g *Gateway) GetClusterConfig(clusterID string) (*cluster.Config, error) {
 	return g.clusterManager.Get(clusterID)
@@ -0,0 +1,286 @@
+package gateway
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/akmadan/throome/pkg/adapters"
+	"github.com/akmadan/throome/pkg/cluster"
+)
+
+var (
+	testGateway     *Gateway
+	testGatewayDir  string
+	testGatewayOnce sync.Once
+)
+
+// newTestGateway returns a gateway shared by the package's tests, since its
+// Prometheus metrics can only be registered once per process
+func newTestGateway(t *testing.T) *Gateway {
+	testGatewayOnce.Do(func() {
+		dir, err := os.MkdirTemp("", "throome-gateway")
+		if err != nil {
+			t.Fatalf("Failed to create clusters directory: %v", err)
+		}
+		testGatewayDir = dir
+		testGateway, err = NewGateway(dir)
+		if err != nil {
+			t.Fatalf("Failed to create gateway: %v", err)
+		}
+
+		// Clusters of the tests use "test-update" services backed by customAdapter
+		if err := cluster.RegisterServiceType("test-update"); err != nil {
+			t.Fatalf("Failed to register service type: %v", err)
+		}
+		testGateway.adapterFactory.Register("test-update", func(config *cluster.ServiceConfig) (adapters.Adapter, error) {
+			return &customAdapter{BaseAdapter: adapters.NewBaseAdapter(config)}, nil
+		})
+	})
+	return testGateway
+}
+
+func TestUpdateCluster(t *testing.T) {
+	gw := newTestGateway(t)
+
+	ctx := context.Background()
+	clusterID, err := gw.CreateCluster(ctx, "update", &cluster.Config{
+		Services: map[string]cluster.ServiceConfig{
+			"kept":    {Type: "test-update", Host: "localhost", Port: 9001},
+			"changed": {Type: "test-update", Host: "localhost", Port: 9002},
+			"removed": {Type: "test-update", Host: "localhost", Port: 9003},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create cluster: %v", err)
+	}
+	kept, _ := gw.GetAdapter(clusterID, "kept")
+	changed, _ := gw.GetAdapter(clusterID, "changed")
+
+	current, _ := gw.GetClusterConfig(clusterID)
+	err = gw.UpdateCluster(ctx, clusterID, &cluster.Config{
+		Name: "renamed",
+		Services: map[string]cluster.ServiceConfig{
+			"kept":    current.Services["kept"],
+			"changed": {Type: "test-update", Host: "localhost", Port: 9012},
+			"added":   {Type: "test-update", Host: "localhost", Port: 9004},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to update cluster: %v", err)
+	}
+
+	if adapter, _ := gw.GetAdapter(clusterID, "kept"); adapter != kept {
+		t.Error("Expected the unchanged service to keep its connection")
+	}
+	if adapter, _ := gw.GetAdapter(clusterID, "changed"); adapter == nil || adapter == changed {
+		t.Error("Expected the changed service to be reconnected")
+	}
+	if _, err := gw.GetAdapter(clusterID, "added"); err != nil {
+		t.Errorf("Expected the added service to be connected, got %v", err)
+	}
+	if _, err := gw.GetAdapter(clusterID, "removed"); err == nil {
+		t.Error("Expected the removed service to be disconnected")
+	}
+
+	config, _ := gw.GetClusterConfig(clusterID)
+	if config.Name != "renamed" || len(config.Services) != 3 {
+		t.Errorf("Unexpected configuration: %s with %d services", config.Name, len(config.Services))
+	}
+	if !config.CreatedAt.Equal(current.CreatedAt) {
+		t.Error("Expected the creation time to be kept")
+	}
+}
+
+func TestReloadCluster(t *testing.T) {
+	gw := newTestGateway(t)
+
+	ctx := context.Background()
+	clusterID, err := gw.CreateCluster(ctx, "reload", &cluster.Config{
+		Services: map[string]cluster.ServiceConfig{
+			"kept":    {Type: "test-update", Host: "localhost", Port: 9021},
+			"changed": {Type: "test-update", Host: "localhost", Port: 9022},
+			"removed": {Type: "test-update", Host: "localhost", Port: 9023},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create cluster: %v", err)
+	}
+	kept, _ := gw.GetAdapter(clusterID, "kept")
+	changed, _ := gw.GetAdapter(clusterID, "changed")
+
+	// Edit the configuration on disk, behind the gateway's back
+	current, _ := gw.GetClusterConfig(clusterID)
+	edited := current.Clone()
+	edited.Services["changed"] = cluster.ServiceConfig{Type: "test-update", Host: "localhost", Port: 9032}
+	edited.Services["added"] = cluster.ServiceConfig{Type: "test-update", Host: "localhost", Port: 9024}
+	delete(edited.Services, "removed")
+	if err := cluster.NewLoader(testGatewayDir).Save(edited); err != nil {
+		t.Fatalf("Failed to save configuration: %v", err)
+	}
+
+	if err := gw.ReloadCluster(ctx, clusterID); err != nil {
+		t.Fatalf("Failed to reload cluster: %v", err)
+	}
+	if adapter, _ := gw.GetAdapter(clusterID, "kept"); adapter != kept {
+		t.Error("Expected the unchanged service to keep its connection")
+	}
+	if adapter, _ := gw.GetAdapter(clusterID, "changed"); adapter == nil || adapter == changed {
+		t.Error("Expected the changed service to be reconnected")
+	}
+	if _, err := gw.GetAdapter(clusterID, "added"); err != nil {
+		t.Errorf("Expected the added service to be connected, got %v", err)
+	}
+	if _, err := gw.GetAdapter(clusterID, "removed"); err == nil {
+		t.Error("Expected the removed service to be disconnected")
+	}
+	if config, _ := gw.GetClusterConfig(clusterID); config.Services["changed"].Port != 9032 {
+		t.Errorf("Expected the reloaded configuration, got port %d", config.Services["changed"].Port)
+	}
+
+	// A broken file leaves the cluster as it was
+	configPath := filepath.Join(testGatewayDir, clusterID, "config.yaml")
+	if err := os.WriteFile(configPath, []byte("services: ["), 0o644); err != nil {
+		t.Fatalf("Failed to write configuration: %v", err)
+	}
+	if err := gw.ReloadCluster(ctx, clusterID); err == nil {
+		t.Error("Expected an invalid configuration to fail the reload")
+	}
+	if _, err := gw.GetAdapter(clusterID, "added"); err != nil {
+		t.Errorf("Expected the cluster to keep its services, got %v", err)
+	}
+}
+
+func TestDiffServices(t *testing.T) {
+	current := map[string]cluster.ServiceConfig{
+		"db":    {Type: "postgres", Provision: true, Host: "localhost", Port: 5432, ContainerID: "abc"},
+		"cache": {Type: "redis", Host: "cache.internal", Port: 6379},
+	}
+	requested := map[string]cluster.ServiceConfig{
+		"db":    {Type: "postgres", Provision: true, Host: "ignored", Port: 5432},
+		"cache": {Type: "redis", Host: "other.internal", Port: 6379},
+		"queue": {Type: "kafka", Host: "localhost", Port: 9092},
+	}
+
+	unchanged, changed := diffServices(current, requested)
+	if len(unchanged) != 1 || unchanged["db"].ContainerID != "abc" {
+		t.Errorf("Expected the provisioned service to keep its container, got %v", unchanged)
+	}
+	if _, ok := changed["cache"]; !ok {
+		t.Error("Expected a new host to change a remote service")
+	}
+	if _, ok := changed["queue"]; !ok {
+		t.Error("Expected new services to be changed")
+	}
+}
+
+func TestAddService(t *testing.T) {
+	gw := newTestGateway(t)
+
+	ctx := context.Background()
+	clusterID, err := gw.CreateCluster(ctx, "add", &cluster.Config{
+		Services: map[string]cluster.ServiceConfig{
+			"first": {Type: "test-update", Host: "localhost", Port: 9101},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create cluster: %v", err)
+	}
+	first, _ := gw.GetAdapter(clusterID, "first")
+
+	second := cluster.ServiceConfig{Type: "test-update", Host: "localhost", Port: 9102}
+	if err := gw.AddService(ctx, clusterID, "second", second); err != nil {
+		t.Fatalf("Failed to add service: %v", err)
+	}
+	if err := gw.AddService(ctx, clusterID, "second", second); err == nil {
+		t.Error("Expected adding an existing service to fail")
+	}
+
+	if adapter, _ := gw.GetAdapter(clusterID, "first"); adapter != first {
+		t.Error("Expected the existing service to keep its connection")
+	}
+	if _, err := gw.GetAdapter(clusterID, "second"); err != nil {
+		t.Errorf("Expected the added service to be connected, got %v", err)
+	}
+	config, _ := gw.GetClusterConfig(clusterID)
+	if _, exists := config.Services["second"]; !exists {
+		t.Error("Expected the added service to be saved")
+	}
+}
+
+func TestRemoveService(t *testing.T) {
+	gw := newTestGateway(t)
+
+	ctx := context.Background()
+	clusterID, err := gw.CreateCluster(ctx, "remove", &cluster.Config{
+		Services: map[string]cluster.ServiceConfig{
+			"kept":    {Type: "test-update", Host: "localhost", Port: 9201},
+			"removed": {Type: "test-update", Host: "localhost", Port: 9202},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create cluster: %v", err)
+	}
+
+	if err := gw.RemoveService(ctx, clusterID, "removed"); err != nil {
+		t.Fatalf("Failed to remove service: %v", err)
+	}
+	if err := gw.RemoveService(ctx, clusterID, "removed"); err == nil {
+		t.Error("Expected removing a missing service to fail")
+	}
+	if err := gw.RemoveService(ctx, clusterID, "kept"); err == nil {
+		t.Error("Expected removing the last service to fail")
+	}
+
+	if _, err := gw.GetAdapter(clusterID, "removed"); err == nil {
+		t.Error("Expected the removed service to be disconnected")
+	}
+	router, _ := gw.GetRouter(clusterID)
+	if _, err := router.GetAdapter("removed"); err == nil {
+		t.Error("Expected the removed service to be dropped from the router")
+	}
+	config, _ := gw.GetClusterConfig(clusterID)
+	if _, exists := config.Services["removed"]; exists || len(config.Services) != 1 {
+		t.Errorf("Expected the configuration to keep only one service, got %d", len(config.Services))
+	}
+}
+
+func TestClusterHealthChecks(t *testing.T) {
+	gw := newTestGateway(t)
+
+	ctx := context.Background()
+	clusterID, err := gw.CreateCluster(ctx, "health-checks", &cluster.Config{
+		Services: map[string]cluster.ServiceConfig{
+			"store": {Type: "test-update", Host: "localhost", Port: 9886},
+		},
+		Health: cluster.HealthConfig{Enabled: true, Interval: 1, Threshold: 2},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create cluster: %v", err)
+	}
+
+	// Services are checked as soon as their cluster is initialized
+	name := clusterID + "/store"
+	deadline := time.Now().Add(5 * time.Second)
+	for gw.GetHealthChecker().GetHealthHistory(name) == nil {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the service to be checked")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	health, err := gw.ServiceHealth(ctx, clusterID)
+	if err != nil || health["store"] == nil || !health["store"].Healthy || health["store"].LastChecked.IsZero() {
+		t.Errorf("Expected the last check of the service, got %+v (%v)", health["store"], err)
+	}
+
+	if err := gw.DeleteCluster(ctx, clusterID); err != nil {
+		t.Fatalf("Failed to delete cluster: %v", err)
+	}
+	if history := gw.GetHealthChecker().GetHealthHistory(name); history != nil {
+		t.Errorf("Expected the health of a deleted cluster to be dropped, got %+v", history)
+	}
+}
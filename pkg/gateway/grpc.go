@@ -0,0 +1,316 @@
+package gateway
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	throomev1 "github.com/akmadan/throome/api/proto/throome/v1"
+	"github.com/akmadan/throome/internal/logger"
+	"github.com/akmadan/throome/pkg/auth"
+	"github.com/akmadan/throome/pkg/cluster"
+	"github.com/akmadan/throome/pkg/requestid"
+	"github.com/akmadan/throome/pkg/router"
+	"go.uber.org/zap"
+)
+
+// gRPC metadata keys. Metadata keys are lowercase.
+const (
+	grpcAPIKeyMetadata    = "x-api-key"
+	grpcRequestIDMetadata = "x-request-id"
+	grpcClientIDMetadata  = "x-client-id"
+)
+
+// newGRPCServer creates the gRPC server of the API. With TLS enabled it serves
+// with the certificate and client certificate settings of the HTTPS server.
+func (s *Server) newGRPCServer() (*grpc.Server, error) {
+	options := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(s.grpcUnaryInterceptor),
+		grpc.ChainStreamInterceptor(s.grpcStreamInterceptor),
+	}
+
+	if cfg := s.config.Server.TLS; cfg.Enabled {
+		tlsConfig, err := s.serverTLSConfig()
+		if err != nil {
+			return nil, fmt.Errorf("failed to configure TLS: %w", err)
+		}
+		certificate, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load TLS certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+		options = append(options, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+
+	server := grpc.NewServer(options...)
+	throomev1.RegisterClusterServiceServer(server, &clusterRPC{s: s})
+	throomev1.RegisterDatabaseServiceServer(server, &databaseRPC{s: s})
+	throomev1.RegisterCacheServiceServer(server, &cacheRPC{s: s})
+	throomev1.RegisterQueueServiceServer(server, &queueRPC{s: s})
+	return server, nil
+}
+
+// startGRPC starts serving the gRPC API in the background
+func (s *Server) startGRPC() error {
+	server, err := s.newGRPCServer()
+	if err != nil {
+		return err
+	}
+
+	addr := fmt.Sprintf("%s:%d", s.config.Server.Host, s.config.Server.GRPC.Port)
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+	s.grpc = server
+
+	go func() {
+		logger.Info("Starting gRPC server",
+			zap.String("addr", addr),
+			zap.Bool("tls", s.config.Server.TLS.Enabled),
+		)
+		if err := server.Serve(listener); err != nil {
+			logger.Error("gRPC server failed", zap.Error(err))
+		}
+	}()
+	return nil
+}
+
+// stopGRPC stops the gRPC server, waiting for running calls until ctx is done.
+// Subscriptions only end when their client cancels them, so they are cut off then.
+func (s *Server) stopGRPC(ctx context.Context) {
+	stopped := make(chan struct{})
+	go func() {
+		s.grpc.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		s.grpc.Stop()
+	}
+}
+
+// grpcUnaryInterceptor applies grpcCall to unary calls
+func (s *Server) grpcUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	var resp interface{}
+	err := s.grpcCall(ctx, info.FullMethod, grpc.SetHeader, func(ctx context.Context) error {
+		var err error
+		resp, err = handler(ctx, req)
+		return err
+	})
+	return resp, err
+}
+
+// grpcStreamInterceptor applies grpcCall to streaming calls
+func (s *Server) grpcStreamInterceptor(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	setHeader := func(_ context.Context, md metadata.MD) error {
+		return stream.SetHeader(md)
+	}
+	return s.grpcCall(stream.Context(), info.FullMethod, setHeader, func(ctx context.Context) error {
+		return handler(srv, &grpcServerStream{ServerStream: stream, ctx: ctx})
+	})
+}
+
+// grpcServerStream is a server stream with the context grpcCall prepared
+type grpcServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *grpcServerStream) Context() context.Context {
+	return s.ctx
+}
+
+// grpcCall does for gRPC calls what the middleware does for HTTP requests: it
+// assigns the call a request ID, returned in the x-request-id header, authenticates
+// and authorizes the caller when authentication is enabled, and logs the call.
+func (s *Server) grpcCall(ctx context.Context, method string, setHeader func(context.Context, metadata.MD) error, call func(context.Context) error) error {
+	start := time.Now()
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	id := firstMetadata(md, grpcRequestIDMetadata)
+	if !requestid.Valid(id) {
+		id = requestid.New()
+	}
+	ctx = requestid.WithID(ctx, id)
+	_ = setHeader(ctx, metadata.Pairs(grpcRequestIDMetadata, id)) //nolint:errcheck // headers cannot be sent once the call ended
+
+	var identity *auth.Identity
+	err := func() error {
+		if !s.config.Auth.Enabled {
+			return nil
+		}
+
+		token, _ := strings.CutPrefix(firstMetadata(md, "authorization"), "Bearer ")
+		key := firstMetadata(md, grpcAPIKeyMetadata)
+		if key == "" && strings.HasPrefix(token, auth.KeyPrefix) {
+			key = token
+		}
+
+		var err error
+		identity, err = s.authenticateCredentials(ctx, key, token)
+		if err != nil {
+			logger.Warn("Unauthorized gRPC call",
+				zap.String("method", method),
+				zap.String("request_id", id),
+				zap.Error(err),
+			)
+			return status.Error(codes.Unauthenticated, "Missing or invalid credentials")
+		}
+
+		if required := grpcRequiredRole(method); !identity.Role.Allows(required) {
+			logger.Warn("Forbidden gRPC call",
+				zap.String("method", method),
+				zap.String("identity", identity.Subject),
+				zap.String("role", string(identity.Role)),
+				zap.String("required_role", string(required)),
+				zap.String("request_id", id),
+			)
+			return status.Errorf(codes.PermissionDenied, "The %s role is required", required)
+		}
+
+		ctx = auth.WithIdentity(ctx, identity)
+		return nil
+	}()
+	if clientID := requestClientID(ctx, firstMetadata(md, grpcClientIDMetadata)); clientID != "" {
+		ctx = router.WithClient(ctx, clientID)
+	}
+	if err == nil {
+		err = call(ctx)
+	}
+
+	fields := []zap.Field{
+		zap.String("method", method),
+		zap.String("code", status.Code(err).String()),
+		zap.Duration("duration", time.Since(start)),
+		zap.String("request_id", id),
+	}
+	if identity != nil {
+		fields = append(fields, zap.String("identity", identity.Subject), zap.String("auth_method", identity.Method))
+	}
+	logger.Info("gRPC request", fields...)
+
+	return err
+}
+
+// firstMetadata returns the first value of a metadata key, or ""
+func firstMetadata(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// grpcReadMethods and grpcAdminMethods are the gRPC methods that, like GET requests
+// and the admin routes of the HTTP API, need the read-only and the admin role
+var (
+	grpcReadMethods = map[string]bool{
+		throomev1.ClusterService_ListClusters_FullMethodName:     true,
+		throomev1.ClusterService_GetCluster_FullMethodName:       true,
+		throomev1.ClusterService_GetClusterHealth_FullMethodName: true,
+	}
+	grpcAdminMethods = map[string]bool{
+		throomev1.ClusterService_CreateCluster_FullMethodName: true,
+		throomev1.ClusterService_DeleteCluster_FullMethodName: true,
+	}
+)
+
+// grpcRequiredRole returns the role a gRPC method needs. Like with the HTTP API,
+// data operations need the operator role.
+func grpcRequiredRole(method string) auth.Role {
+	switch {
+	case grpcAdminMethods[method]:
+		return auth.RoleAdmin
+	case grpcReadMethods[method]:
+		return auth.RoleReadOnly
+	default:
+		return auth.RoleOperator
+	}
+}
+
+// grpcClusterConfig returns the configuration of the cluster a call is for.
+// Clusters outside the namespace the caller is limited to are reported as not
+// found, like on the namespace routes of the HTTP API.
+func (s *Server) grpcClusterConfig(ctx context.Context, clusterID string) (*cluster.Config, error) {
+	config, err := s.gateway.GetClusterConfig(clusterID)
+	if err != nil {
+		return nil, grpcError(codes.NotFound, "Cluster not found", err)
+	}
+	if identity := auth.IdentityFromContext(ctx); identity != nil && identity.Namespace != "" &&
+		config.NamespaceOrDefault() != identity.Namespace {
+		return nil, grpcError(codes.NotFound, "Cluster not found", nil)
+	}
+	return config, nil
+}
+
+// grpcWritableCluster returns the configuration of a cluster a call writes to,
+// refusing calls to drained clusters as the HTTP API does
+func (s *Server) grpcWritableCluster(ctx context.Context, clusterID string) (*cluster.Config, error) {
+	config, err := s.grpcClusterConfig(ctx, clusterID)
+	if err != nil {
+		return nil, err
+	}
+	if config.IsDrained() {
+		return nil, grpcError(codes.Unavailable, "Cluster is drained", nil)
+	}
+	return config, nil
+}
+
+// grpcNamespace returns the namespace a call is made in: the requested one, or the
+// namespace the caller is limited to. Callers limited to a namespace may not ask
+// for another.
+func grpcNamespace(ctx context.Context, namespace string) (string, error) {
+	identity := auth.IdentityFromContext(ctx)
+	if identity == nil || identity.Namespace == "" {
+		return namespace, nil
+	}
+	if namespace != "" && namespace != identity.Namespace {
+		return "", status.Errorf(codes.PermissionDenied, "Access is limited to namespace %s", identity.Namespace)
+	}
+	return identity.Namespace, nil
+}
+
+// grpcError creates the status of a failed call, with the cause in its message
+func grpcError(code codes.Code, message string, err error) error {
+	if err != nil {
+		return status.Errorf(code, "%s: %v", message, err)
+	}
+	return status.Error(code, message)
+}
+
+// grpcAdapterError converts an adapterError to the status of a failed call
+func grpcAdapterError(adapterErr *adapterError) error {
+	return grpcError(grpcCode(adapterErr.status), adapterErr.message, adapterErr.err)
+}
+
+// grpcCode returns the gRPC code matching an HTTP status
+func grpcCode(httpStatus int) codes.Code {
+	switch httpStatus {
+	case http.StatusBadRequest:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusConflict:
+		return codes.FailedPrecondition
+	case http.StatusServiceUnavailable:
+		return codes.Unavailable
+	default:
+		return codes.Internal
+	}
+}
@@ -0,0 +1,446 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	throomev1 "github.com/akmadan/throome/api/proto/throome/v1"
+	"github.com/akmadan/throome/internal/logger"
+	"github.com/akmadan/throome/pkg/adapters"
+	"github.com/akmadan/throome/pkg/cluster"
+	"go.uber.org/zap"
+)
+
+// queueUnsubscribeTimeout bounds how long ending a subscription may take
+const queueUnsubscribeTimeout = 10 * time.Second
+
+// clusterRPC implements the gRPC ClusterService
+type clusterRPC struct {
+	throomev1.UnimplementedClusterServiceServer
+	s *Server
+}
+
+func (c *clusterRPC) ListClusters(ctx context.Context, req *throomev1.ListClustersRequest) (*throomev1.ListClustersResponse, error) {
+	namespace, err := grpcNamespace(ctx, req.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	clusterIDs, err := c.s.gateway.ListClusters()
+	if err != nil {
+		return nil, grpcError(codes.Internal, "Failed to list clusters", err)
+	}
+
+	resp := &throomev1.ListClustersResponse{}
+	for _, clusterID := range clusterIDs {
+		config, err := c.s.gateway.GetClusterConfig(clusterID)
+		if err != nil {
+			logger.Error("Failed to get cluster config", zap.String("cluster_id", clusterID), zap.Error(err))
+			continue
+		}
+		if req.Archived != throomev1.ArchivedFilter_ARCHIVED_FILTER_ALL &&
+			config.IsArchived() != (req.Archived == throomev1.ArchivedFilter_ARCHIVED_FILTER_ONLY) {
+			continue
+		}
+		if namespace != "" && config.NamespaceOrDefault() != namespace {
+			continue
+		}
+		resp.Clusters = append(resp.Clusters, c.cluster(ctx, clusterID, config))
+	}
+	return resp, nil
+}
+
+func (c *clusterRPC) GetCluster(ctx context.Context, req *throomev1.GetClusterRequest) (*throomev1.Cluster, error) {
+	config, err := c.s.grpcClusterConfig(ctx, req.ClusterId)
+	if err != nil {
+		return nil, err
+	}
+	return c.cluster(ctx, req.ClusterId, config), nil
+}
+
+func (c *clusterRPC) CreateCluster(ctx context.Context, req *throomev1.CreateClusterRequest) (*throomev1.Cluster, error) {
+	if req.Name == "" {
+		return nil, grpcError(codes.InvalidArgument, "Cluster name is required", nil)
+	}
+
+	namespace, err := grpcNamespace(ctx, req.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	if namespace == "" {
+		namespace = cluster.DefaultNamespace
+	}
+	if err := cluster.ValidateNamespace(namespace); err != nil {
+		return nil, grpcError(codes.InvalidArgument, "Invalid namespace", err)
+	}
+
+	jsonConfig := req.Config.AsMap()
+	if jsonConfig["services"] == nil {
+		return nil, grpcError(codes.InvalidArgument, "Cluster services configuration is required", nil)
+	}
+	clusterConfig, err := c.s.convertJSONToClusterConfig(req.Name, jsonConfig)
+	if err != nil {
+		return nil, grpcError(codes.InvalidArgument, "Invalid cluster configuration", err)
+	}
+	clusterConfig.Namespace = namespace
+
+	clusterID, err := c.s.gateway.CreateCluster(ctx, req.Name, clusterConfig)
+	if err != nil {
+		var provisionErr *ProvisionError
+		if errors.As(err, &provisionErr) {
+			return nil, grpcError(codes.Internal, provisionErr.Message, provisionErr.Err)
+		}
+		return nil, grpcError(codes.Internal, "Failed to create cluster", err)
+	}
+
+	config, err := c.s.gateway.GetClusterConfig(clusterID)
+	if err != nil {
+		return nil, grpcError(codes.Internal, "Failed to get cluster config", err)
+	}
+	return c.cluster(ctx, clusterID, config), nil
+}
+
+func (c *clusterRPC) DeleteCluster(ctx context.Context, req *throomev1.DeleteClusterRequest) (*throomev1.DeleteClusterResponse, error) {
+	config, err := c.s.grpcClusterConfig(ctx, req.ClusterId)
+	if err != nil {
+		return nil, err
+	}
+	if config.IsArchived() {
+		return nil, grpcError(codes.FailedPrecondition, "Cluster is already archived, purge it to delete it permanently", nil)
+	}
+
+	if err := c.s.gateway.ArchiveCluster(ctx, req.ClusterId); err != nil {
+		return nil, grpcError(codes.Internal, "Failed to archive cluster", err)
+	}
+	return &throomev1.DeleteClusterResponse{}, nil
+}
+
+func (c *clusterRPC) GetClusterHealth(ctx context.Context, req *throomev1.GetClusterHealthRequest) (*throomev1.ClusterHealth, error) {
+	if _, err := c.s.grpcClusterConfig(ctx, req.ClusterId); err != nil {
+		return nil, err
+	}
+	services, err := c.s.gateway.ServiceHealth(ctx, req.ClusterId)
+	if err != nil {
+		return nil, grpcError(codes.NotFound, "Cluster not found", err)
+	}
+
+	resp := &throomev1.ClusterHealth{ClusterId: req.ClusterId}
+	for serviceName, health := range services {
+		resp.Services = append(resp.Services, &throomev1.ServiceHealth{
+			Name:           serviceName,
+			Healthy:        health.Healthy,
+			Reachable:      health.Reachable,
+			ResponseTimeMs: health.ResponseTime.Milliseconds(),
+			Error:          health.ErrorMessage,
+		})
+	}
+	sort.Slice(resp.Services, func(i, j int) bool { return resp.Services[i].Name < resp.Services[j].Name })
+	return resp, nil
+}
+
+// cluster describes a cluster and the health of its services
+func (c *clusterRPC) cluster(ctx context.Context, clusterID string, config *cluster.Config) *throomev1.Cluster {
+	resp := &throomev1.Cluster{
+		Id:        clusterID,
+		Name:      config.Name,
+		Namespace: config.NamespaceOrDefault(),
+	}
+	if !config.CreatedAt.IsZero() {
+		resp.CreatedAt = timestamppb.New(config.CreatedAt)
+	}
+	if config.IsArchived() {
+		resp.ArchivedAt = timestamppb.New(*config.ArchivedAt)
+	}
+
+	for serviceName, serviceConfig := range config.Services {
+		healthy := false
+		if adapter, err := c.s.gateway.GetAdapter(clusterID, serviceName); err == nil {
+			status, err := adapter.HealthCheck(ctx)
+			healthy = err == nil && status.Healthy
+		}
+
+		resp.Services = append(resp.Services, &throomev1.Service{
+			Name:     serviceName,
+			Type:     serviceConfig.Type,
+			Host:     serviceConfig.Host,
+			Port:     int32(serviceConfig.Port), //nolint:gosec // ports are validated to fit
+			Username: serviceConfig.Username,
+			Database: serviceConfig.Database,
+			Healthy:  healthy,
+		})
+	}
+	sort.Slice(resp.Services, func(i, j int) bool { return resp.Services[i].Name < resp.Services[j].Name })
+	return resp
+}
+
+// databaseRPC implements the gRPC DatabaseService
+type databaseRPC struct {
+	throomev1.UnimplementedDatabaseServiceServer
+	s *Server
+}
+
+func (d *databaseRPC) Execute(ctx context.Context, req *throomev1.ExecuteRequest) (*throomev1.ExecuteResponse, error) {
+	if _, err := d.s.grpcWritableCluster(ctx, req.ClusterId); err != nil {
+		return nil, err
+	}
+	pgAdapter, adapterErr := d.s.postgresAdapter(ctx, req.ClusterId)
+	if adapterErr != nil {
+		return nil, grpcAdapterError(adapterErr)
+	}
+
+	result, err := pgAdapter.Execute(ctx, req.Query, grpcArgs(req.Args)...)
+	if err != nil {
+		return nil, grpcError(codes.Internal, "Failed to execute query", err)
+	}
+	return &throomev1.ExecuteResponse{RowsAffected: result.RowsAffected()}, nil
+}
+
+// Query streams the rows of a query as they are read, so results larger than
+// the gateway's memory can be read
+func (d *databaseRPC) Query(req *throomev1.QueryRequest, stream throomev1.DatabaseService_QueryServer) error {
+	ctx := stream.Context()
+	if _, err := d.s.grpcClusterConfig(ctx, req.ClusterId); err != nil {
+		return err
+	}
+	pgAdapter, adapterErr := d.s.postgresAdapter(withReadAccess(ctx, readOnlyQuery(req.Query)), req.ClusterId)
+	if adapterErr != nil {
+		return grpcAdapterError(adapterErr)
+	}
+
+	conn, done, err := d.s.queryConn(ctx, req.ClusterId, pgAdapter)
+	if err != nil {
+		return grpcError(codes.Internal, "Failed to execute query", err)
+	}
+	defer done()
+
+	args := grpcArgs(req.Args)
+	start := time.Now()
+	rows, err := conn.Query(ctx, req.Query, args...)
+	if err != nil {
+		return grpcError(codes.Internal, "Failed to execute query", err)
+	}
+	defer rows.Close()
+
+	fields := rows.FieldDescriptions()
+	for rows.Next() {
+		values, err := rows.Values()
+		if err != nil {
+			return grpcError(codes.Internal, "Failed to read row", err)
+		}
+		row := make(map[string]interface{}, len(fields))
+		for i, field := range fields {
+			row[field.Name] = values[i]
+		}
+
+		message, err := grpcStruct(row)
+		if err != nil {
+			return grpcError(codes.Internal, "Failed to encode row", err)
+		}
+		if err := stream.Send(&throomev1.QueryRow{Values: message}); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return grpcError(codes.Internal, "Failed to read rows", err)
+	}
+	pgAdapter.ObserveQuery(ctx, req.Query, args, time.Since(start))
+
+	return nil
+}
+
+// grpcArgs converts query arguments to the values the database driver expects
+func grpcArgs(values []*structpb.Value) []interface{} {
+	args := make([]interface{}, len(values))
+	for i, value := range values {
+		args[i] = value.AsInterface()
+	}
+	return args
+}
+
+// grpcStruct converts a row to a protobuf struct. Values are encoded as the JSON
+// API encodes them, so timestamps, numerics and byte arrays read the same.
+func grpcStruct(row map[string]interface{}) (*structpb.Struct, error) {
+	data, err := json.Marshal(row)
+	if err != nil {
+		return nil, err
+	}
+	var values map[string]interface{}
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil, err
+	}
+	return structpb.NewStruct(values)
+}
+
+// cacheRPC implements the gRPC CacheService
+type cacheRPC struct {
+	throomev1.UnimplementedCacheServiceServer
+	s *Server
+}
+
+func (c *cacheRPC) Get(ctx context.Context, req *throomev1.CacheGetRequest) (*throomev1.CacheGetResponse, error) {
+	cache, err := c.adapter(withReadAccess(ctx, true), req.ClusterId)
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := cache.Get(ctx, req.Key)
+	if err != nil {
+		return nil, grpcError(codes.Internal, "Failed to get key", err)
+	}
+	return &throomev1.CacheGetResponse{Value: value}, nil
+}
+
+func (c *cacheRPC) Set(ctx context.Context, req *throomev1.CacheSetRequest) (*throomev1.CacheSetResponse, error) {
+	if _, err := c.s.grpcWritableCluster(ctx, req.ClusterId); err != nil {
+		return nil, err
+	}
+	cache, err := c.adapter(ctx, req.ClusterId)
+	if err != nil {
+		return nil, err
+	}
+
+	ttl := time.Duration(req.TtlSeconds) * time.Second
+	if err := cache.Set(ctx, req.Key, req.Value, ttl); err != nil {
+		return nil, grpcError(codes.Internal, "Failed to set key", err)
+	}
+	return &throomev1.CacheSetResponse{}, nil
+}
+
+func (c *cacheRPC) Delete(ctx context.Context, req *throomev1.CacheDeleteRequest) (*throomev1.CacheDeleteResponse, error) {
+	if _, err := c.s.grpcWritableCluster(ctx, req.ClusterId); err != nil {
+		return nil, err
+	}
+	cache, err := c.adapter(ctx, req.ClusterId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cache.Delete(ctx, req.Key); err != nil {
+		return nil, grpcError(codes.Internal, "Failed to delete key", err)
+	}
+	return &throomev1.CacheDeleteResponse{}, nil
+}
+
+// adapter resolves the Redis adapter of a cluster, like the cache routes of the
+// HTTP API
+func (c *cacheRPC) adapter(ctx context.Context, clusterID string) (adapters.CacheAdapter, error) {
+	config, err := c.s.grpcClusterConfig(ctx, clusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	redisService, adapterErr := c.s.selectService(ctx, clusterID, config, "redis")
+	if adapterErr != nil {
+		return nil, grpcAdapterError(adapterErr)
+	}
+	if redisService == "" {
+		return nil, grpcError(codes.NotFound, "No Redis service found in cluster", nil)
+	}
+
+	adapter, err := c.s.serviceAdapter(ctx, clusterID, redisService)
+	if err != nil {
+		return nil, grpcError(grpcCode(adapterStatus(err)), "Failed to get cache adapter", err)
+	}
+	cache, ok := adapter.(adapters.CacheAdapter)
+	if !ok {
+		return nil, grpcError(codes.Internal, "Adapter is not a CacheAdapter", nil)
+	}
+	return cache, nil
+}
+
+// queueRPC implements the gRPC QueueService
+type queueRPC struct {
+	throomev1.UnimplementedQueueServiceServer
+	s *Server
+}
+
+func (q *queueRPC) Publish(ctx context.Context, req *throomev1.PublishRequest) (*throomev1.PublishResponse, error) {
+	if _, err := q.s.grpcWritableCluster(ctx, req.ClusterId); err != nil {
+		return nil, err
+	}
+	queue, adapterErr := q.s.queueAdapter(ctx, req.ClusterId)
+	if adapterErr != nil {
+		return nil, grpcAdapterError(adapterErr)
+	}
+
+	var err error
+	if keyed, ok := queue.(keyedPublisher); ok && len(req.Key) > 0 {
+		err = keyed.PublishWithKey(ctx, req.Topic, req.Key, req.Message)
+	} else {
+		err = queue.Publish(ctx, req.Topic, req.Message)
+	}
+	if err != nil {
+		return nil, grpcError(codes.Internal, "Failed to publish message", err)
+	}
+	return &throomev1.PublishResponse{}, nil
+}
+
+// Subscribe subscribes the gateway to a topic for as long as the call lasts and
+// streams the messages it receives. Messages are only taken from the queue as fast
+// as the client reads them.
+func (q *queueRPC) Subscribe(req *throomev1.SubscribeRequest, stream throomev1.QueueService_SubscribeServer) error {
+	ctx := stream.Context()
+	if req.Topic == "" {
+		return grpcError(codes.InvalidArgument, "Topic is required", nil)
+	}
+	if _, err := q.s.grpcClusterConfig(ctx, req.ClusterId); err != nil {
+		return err
+	}
+	queue, adapterErr := q.s.queueAdapter(ctx, req.ClusterId)
+	if adapterErr != nil {
+		return grpcAdapterError(adapterErr)
+	}
+
+	messages := make(chan *adapters.Message)
+	handler := func(_ context.Context, message *adapters.Message) error {
+		select {
+		case messages <- message:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	if err := queue.Subscribe(ctx, req.Topic, handler); err != nil {
+		return grpcError(codes.Internal, "Failed to subscribe to topic", err)
+	}
+	defer func() {
+		unsubscribeCtx, cancel := context.WithTimeout(context.Background(), queueUnsubscribeTimeout)
+		defer cancel()
+		if err := queue.Unsubscribe(unsubscribeCtx, req.Topic); err != nil {
+			logger.Warn("Failed to unsubscribe from topic",
+				zap.String("cluster_id", req.ClusterId),
+				zap.String("topic", req.Topic),
+				zap.Error(err),
+			)
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case message := <-messages:
+			resp := &throomev1.Message{
+				Topic:     message.Topic,
+				Key:       message.Key,
+				Value:     message.Value,
+				Headers:   message.Headers,
+				Partition: int32(message.Partition), //nolint:gosec // partitions fit in 32 bits
+				Offset:    message.Offset,
+			}
+			if !message.Timestamp.IsZero() {
+				resp.Timestamp = timestamppb.New(message.Timestamp)
+			}
+			if err := stream.Send(resp); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package gateway
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	throomev1 "github.com/akmadan/throome/api/proto/throome/v1"
+	"github.com/akmadan/throome/internal/config"
+	"github.com/akmadan/throome/pkg/auth"
+	"github.com/akmadan/throome/pkg/cluster"
+)
+
+func TestGRPCClusterService(t *testing.T) {
+	gw := newTestGateway(t)
+	cfg := config.DefaultConfig()
+	cfg.Auth.Enabled = true
+	keys, _ := auth.NewKeyStore("")
+	global, _, _ := keys.Issue("global", auth.RoleReadOnly, "")
+	grpcTeam, _, _ := keys.Issue("grpc-team", auth.RoleReadOnly, "grpc-team")
+	s := &Server{config: cfg, gateway: gw, keys: keys}
+
+	ctx := context.Background()
+	defaultID, err := gw.CreateCluster(ctx, "grpc-default", &cluster.Config{
+		Services: map[string]cluster.ServiceConfig{
+			"app": {Type: "test-update", Host: "localhost", Port: 9301},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create cluster: %v", err)
+	}
+	grpcTeamID, err := gw.CreateCluster(ctx, "grpc-team", &cluster.Config{
+		Namespace: "grpc-team",
+		Services: map[string]cluster.ServiceConfig{
+			"app": {Type: "test-update", Host: "localhost", Port: 9302},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create cluster: %v", err)
+	}
+
+	server, err := s.newGRPCServer()
+	if err != nil {
+		t.Fatalf("Failed to create gRPC server: %v", err)
+	}
+	listener := bufconn.Listen(1 << 20)
+	go server.Serve(listener) //nolint:errcheck // ends when the server stops
+	defer server.Stop()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer conn.Close()
+	client := throomev1.NewClusterServiceClient(conn)
+
+	withKey := func(key string) context.Context {
+		return metadata.AppendToOutgoingContext(ctx, grpcAPIKeyMetadata, key)
+	}
+
+	// Calls without credentials are rejected
+	if _, err := client.ListClusters(ctx, &throomev1.ListClustersRequest{}); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("Expected Unauthenticated without credentials, got %v", err)
+	}
+
+	// Every call gets a request ID, the client's if it sent one
+	var header metadata.MD
+	callCtx := metadata.AppendToOutgoingContext(withKey(global), grpcRequestIDMetadata, "grpc-test-1")
+	resp, err := client.ListClusters(callCtx, &throomev1.ListClustersRequest{}, grpc.Header(&header))
+	if err != nil {
+		t.Fatalf("Failed to list clusters: %v", err)
+	}
+	if got := header.Get(grpcRequestIDMetadata); len(got) != 1 || got[0] != "grpc-test-1" {
+		t.Errorf("Expected request ID grpc-test-1, got %v", got)
+	}
+	listed := make(map[string]bool)
+	for _, cl := range resp.Clusters {
+		listed[cl.Id] = true
+	}
+	if !listed[defaultID] || !listed[grpcTeamID] {
+		t.Errorf("Expected clusters %s and %s, got %v", defaultID, grpcTeamID, resp.Clusters)
+	}
+
+	// Callers limited to a namespace only see its clusters
+	resp, err = client.ListClusters(withKey(grpcTeam), &throomev1.ListClustersRequest{})
+	if err != nil {
+		t.Fatalf("Failed to list clusters: %v", err)
+	}
+	if len(resp.Clusters) != 1 || resp.Clusters[0].Id != grpcTeamID || resp.Clusters[0].Namespace != "grpc-team" {
+		t.Errorf("Expected only cluster %s, got %v", grpcTeamID, resp.Clusters)
+	}
+	if _, err := client.GetCluster(withKey(grpcTeam), &throomev1.GetClusterRequest{ClusterId: defaultID}); status.Code(err) != codes.NotFound {
+		t.Errorf("Expected NotFound for a cluster of another namespace, got %v", err)
+	}
+	if _, err := client.ListClusters(withKey(grpcTeam), &throomev1.ListClustersRequest{Namespace: "default"}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("Expected PermissionDenied for another namespace, got %v", err)
+	}
+
+	cl, err := client.GetCluster(withKey(global), &throomev1.GetClusterRequest{ClusterId: defaultID})
+	if err != nil {
+		t.Fatalf("Failed to get cluster: %v", err)
+	}
+	if cl.Name != "grpc-default" || len(cl.Services) != 1 || cl.Services[0].Port != 9301 {
+		t.Errorf("Unexpected cluster: %v", cl)
+	}
+
+	// Archiving needs the admin role
+	if _, err := client.DeleteCluster(withKey(global), &throomev1.DeleteClusterRequest{ClusterId: defaultID}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("Expected PermissionDenied for a read-only caller, got %v", err)
+	}
+}
+
+func TestGRPCRequiredRole(t *testing.T) {
+	tests := []struct {
+		method string
+		role   auth.Role
+	}{
+		{throomev1.ClusterService_ListClusters_FullMethodName, auth.RoleReadOnly},
+		{throomev1.ClusterService_CreateCluster_FullMethodName, auth.RoleAdmin},
+		{throomev1.DatabaseService_Query_FullMethodName, auth.RoleOperator},
+		{throomev1.CacheService_Get_FullMethodName, auth.RoleOperator},
+		{throomev1.QueueService_Subscribe_FullMethodName, auth.RoleOperator},
+	}
+
+	for _, tt := range tests {
+		if role := grpcRequiredRole(tt.method); role != tt.role {
+			t.Errorf("%s: expected role %s, got %s", tt.method, tt.role, role)
+		}
+	}
+}
+
+func TestGRPCCacheAdapter(t *testing.T) {
+	gw := newTestGateway(t)
+	c := &cacheRPC{s: &Server{gateway: gw}}
+
+	ctx := context.Background()
+	clusterID, err := gw.CreateCluster(ctx, "grpc-cache", &cluster.Config{
+		Services: map[string]cluster.ServiceConfig{
+			"other": {Type: "test-update", Host: "localhost", Port: 9703},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create cluster: %v", err)
+	}
+	defer gw.DeleteCluster(ctx, clusterID)
+
+	if _, err := c.adapter(ctx, clusterID); status.Code(err) != codes.NotFound {
+		t.Errorf("Expected no Redis service to be found, got %v", err)
+	}
+	if _, err := c.adapter(withTargetService(ctx, "other"), clusterID); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected a targeted service of another type to be rejected, got %v", err)
+	}
+}
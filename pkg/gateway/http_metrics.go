@@ -0,0 +1,96 @@
+package gateway
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// metricsMiddleware records the requests of each route in the Prometheus metrics:
+// their count by status, their latency and how many are in flight. Nothing is
+// recorded while metrics are disabled.
+func (s *Server) metricsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.settings().metrics {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		collector := s.gateway.GetCollector()
+		collector.HTTPRequestStarted()
+
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		defer func() {
+			collector.HTTPRequestFinished(routeLabel(r), methodLabel(r.Method), recorder.status, time.Since(start))
+		}()
+
+		next.ServeHTTP(recorder, r)
+	})
+}
+
+// routeLabel returns the path template of a request's route, which unlike its path
+// does not grow the metrics with every cluster and key
+func routeLabel(r *http.Request) string {
+	if route := mux.CurrentRoute(r); route != nil {
+		if template, err := route.GetPathTemplate(); err == nil {
+			return template
+		}
+	}
+	return "unmatched"
+}
+
+// methodLabel returns the method of a request, folding unknown methods into one label
+func methodLabel(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut,
+		http.MethodPatch, http.MethodDelete, http.MethodOptions:
+		return method
+	default:
+		return "OTHER"
+	}
+}
+
+// statusRecorder records the status of a response
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (sr *statusRecorder) WriteHeader(status int) {
+	if !sr.wroteHeader && status >= http.StatusOK {
+		sr.status = status
+		sr.wroteHeader = true
+	}
+	sr.ResponseWriter.WriteHeader(status)
+}
+
+func (sr *statusRecorder) Write(b []byte) (int, error) {
+	sr.wroteHeader = true
+	return sr.ResponseWriter.Write(b)
+}
+
+// Flush lets streaming handlers flush through the recorder
+func (sr *statusRecorder) Flush() {
+	_ = http.NewResponseController(sr.ResponseWriter).Flush()
+}
+
+// Hijack lets WebSocket upgrades take over the connection, which is recorded as
+// switching protocols
+func (sr *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := http.NewResponseController(sr.ResponseWriter).Hijack()
+	if err == nil {
+		sr.status = http.StatusSwitchingProtocols
+		sr.wroteHeader = true
+	}
+	return conn, rw, err
+}
+
+// Unwrap gives http.ResponseController access to the underlying writer
+func (sr *statusRecorder) Unwrap() http.ResponseWriter {
+	return sr.ResponseWriter
+}
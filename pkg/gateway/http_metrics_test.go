@@ -0,0 +1,58 @@
+package gateway
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/akmadan/throome/internal/config"
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestMetricsMiddleware(t *testing.T) {
+	s := &Server{config: config.DefaultConfig(), gateway: newTestGateway(t)}
+
+	router := mux.NewRouter()
+	router.Use(s.metricsMiddleware)
+	router.HandleFunc("/metrics-test/{cluster_id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}).Methods("GET")
+
+	for _, clusterID := range []string{"c1", "c2"} {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics-test/"+clusterID, nil))
+	}
+
+	families, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+	var requests float64
+	for _, family := range families {
+		if family.GetName() != "throome_http_requests_total" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			labels := make(map[string]string)
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			if labels["route"] == "/metrics-test/{cluster_id}" && labels["method"] == "GET" && labels["status"] == "418" {
+				requests = metric.GetCounter().GetValue()
+			}
+		}
+	}
+	if requests != 2 {
+		t.Errorf("Expected 2 requests counted under the route template, got %v", requests)
+	}
+}
+
+func TestMethodLabel(t *testing.T) {
+	if label := methodLabel("POST"); label != "POST" {
+		t.Errorf("Expected POST, got %s", label)
+	}
+	if label := methodLabel("PROPFIND"); label != "OTHER" {
+		t.Errorf("Expected unknown methods to be folded, got %s", label)
+	}
+}
@@ -0,0 +1,196 @@
+package gateway
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/akmadan/throome/pkg/auth"
+	"github.com/akmadan/throome/pkg/requestid"
+)
+
+// IdempotencyKeyHeader is the request header carrying the client-supplied idempotency key
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// IdempotencyStore remembers responses of mutating requests keyed by their caller and
+// idempotency key
+type IdempotencyStore struct {
+	entries map[string]*idempotencyEntry
+	ttl     time.Duration
+	mu      sync.Mutex
+}
+
+// idempotencyEntry holds a recorded response, or marks a request that is still in flight
+type idempotencyEntry struct {
+	requestHash string
+	inFlight    bool
+	status      int
+	header      http.Header
+	body        []byte
+	createdAt   time.Time
+}
+
+// NewIdempotencyStore creates a new idempotency store that keeps responses for ttl
+func NewIdempotencyStore(ttl time.Duration) *IdempotencyStore {
+	return &IdempotencyStore{
+		entries: make(map[string]*idempotencyEntry),
+		ttl:     ttl,
+	}
+}
+
+// begin reserves a key for a request. It returns the previously recorded entry if one
+// exists, and false if the caller should not proceed with the request.
+func (st *IdempotencyStore) begin(key, requestHash string) (*idempotencyEntry, bool) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	st.evictExpired()
+
+	if entry, exists := st.entries[key]; exists {
+		return entry, false
+	}
+
+	st.entries[key] = &idempotencyEntry{
+		requestHash: requestHash,
+		inFlight:    true,
+		createdAt:   time.Now(),
+	}
+	return nil, true
+}
+
+// complete records the response for a key
+func (st *IdempotencyStore) complete(key string, status int, header http.Header, body []byte) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	entry, exists := st.entries[key]
+	if !exists {
+		return
+	}
+
+	entry.inFlight = false
+	entry.status = status
+	entry.header = header
+	entry.body = body
+}
+
+// release forgets a key so the request can be retried
+func (st *IdempotencyStore) release(key string) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	delete(st.entries, key)
+}
+
+// evictExpired removes entries older than the TTL. Caller must hold the lock.
+func (st *IdempotencyStore) evictExpired() {
+	cutoff := time.Now().Add(-st.ttl)
+	for key, entry := range st.entries {
+		if !entry.inFlight && entry.createdAt.Before(cutoff) {
+			delete(st.entries, key)
+		}
+	}
+}
+
+// recordingResponseWriter captures the status and body written by a handler
+type recordingResponseWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (rw *recordingResponseWriter) WriteHeader(status int) {
+	rw.status = status
+	rw.ResponseWriter.WriteHeader(status)
+}
+
+func (rw *recordingResponseWriter) Write(b []byte) (int, error) {
+	if rw.status == 0 {
+		rw.status = http.StatusOK
+	}
+	rw.body.Write(b)
+	return rw.ResponseWriter.Write(b)
+}
+
+// idempotent wraps a mutating handler so that requests carrying an Idempotency-Key
+// header are executed at most once. Replays return the recorded response; a key
+// reused with a different request returns 422, and one still in progress returns 409.
+// Keys are scoped to the authenticated caller, so callers never see each other's
+// responses. Server errors are not recorded so the client can retry them.
+func (s *Server) idempotent(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		idempotencyKey := r.Header.Get(IdempotencyKeyHeader)
+		if idempotencyKey == "" {
+			next(w, r)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			s.errorResponse(w, http.StatusBadRequest, "Failed to read request body", err)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		key := idempotencyPrincipal(r) + " " + r.Method + " " + r.URL.Path + " " + idempotencyKey
+		requestHash := hashRequestBody(body)
+
+		entry, proceed := s.idempotency.begin(key, requestHash)
+		if !proceed {
+			switch {
+			case entry.requestHash != requestHash:
+				s.errorResponse(w, http.StatusUnprocessableEntity,
+					"Idempotency key was already used with a different request", nil)
+			case entry.inFlight:
+				s.errorResponse(w, http.StatusConflict,
+					"A request with this idempotency key is already in progress", nil)
+			default:
+				// The replay keeps its own request ID
+				id := w.Header().Get(requestid.Header)
+				for name, values := range entry.header {
+					w.Header()[name] = values
+				}
+				if id != "" {
+					w.Header().Set(requestid.Header, id)
+				}
+				w.Header().Set("Idempotent-Replayed", "true")
+				w.WriteHeader(entry.status)
+				_, _ = w.Write(entry.body) //nolint:errcheck // HTTP response write errors cannot be handled
+			}
+			return
+		}
+
+		recorder := &recordingResponseWriter{ResponseWriter: w}
+		next(recorder, r)
+
+		if recorder.status >= http.StatusInternalServerError || recorder.status == 0 {
+			s.idempotency.release(key)
+			return
+		}
+		s.idempotency.complete(key, recorder.status, w.Header().Clone(), recorder.body.Bytes())
+	}
+}
+
+// idempotencyPrincipal identifies the caller of a request to scope its idempotency
+// keys: the API key by ID, otherwise the subject of its authentication method. It is
+// empty when authentication is disabled.
+func idempotencyPrincipal(r *http.Request) string {
+	identity := auth.IdentityFromContext(r.Context())
+	if identity == nil {
+		return ""
+	}
+	if identity.KeyID != "" {
+		return auth.MethodAPIKey + ":" + identity.KeyID
+	}
+	return identity.Method + ":" + identity.Subject
+}
+
+// hashRequestBody returns a stable fingerprint of a request body
+func hashRequestBody(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,124 @@
+package gateway
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/akmadan/throome/pkg/auth"
+)
+
+func TestIdempotentReplaysResponse(t *testing.T) {
+	s := &Server{idempotency: NewIdempotencyStore(time.Hour)}
+
+	calls := 0
+	handler := s.idempotent(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		s.jsonResponse(w, http.StatusCreated, map[string]interface{}{"calls": calls})
+	})
+
+	send := func(key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api/v1/clusters", strings.NewReader(body))
+		if key != "" {
+			req.Header.Set(IdempotencyKeyHeader, key)
+		}
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+		return rec
+	}
+
+	first := send("abc", `{"name":"test"}`)
+	if first.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d", first.Code)
+	}
+
+	replay := send("abc", `{"name":"test"}`)
+	if replay.Code != http.StatusCreated {
+		t.Errorf("Expected replayed status 201, got %d", replay.Code)
+	}
+	if replay.Body.String() != first.Body.String() {
+		t.Errorf("Expected replayed body %q, got %q", first.Body.String(), replay.Body.String())
+	}
+	if replay.Header().Get("Idempotent-Replayed") != "true" {
+		t.Error("Expected Idempotent-Replayed header on replay")
+	}
+	if calls != 1 {
+		t.Errorf("Expected handler to run once, ran %d times", calls)
+	}
+
+	mismatch := send("abc", `{"name":"other"}`)
+	if mismatch.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected status 422 for reused key, got %d", mismatch.Code)
+	}
+
+	send("", `{"name":"test"}`)
+	send("", `{"name":"test"}`)
+	if calls != 3 {
+		t.Errorf("Expected requests without a key to always run, handler ran %d times", calls)
+	}
+}
+
+func TestIdempotentDoesNotRecordServerErrors(t *testing.T) {
+	s := &Server{idempotency: NewIdempotencyStore(time.Hour)}
+
+	calls := 0
+	handler := s.idempotent(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		s.errorResponse(w, http.StatusInternalServerError, "boom", nil)
+	})
+
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest("DELETE", "/api/v1/clusters/abc", nil)
+		req.Header.Set(IdempotencyKeyHeader, "retry-me")
+		handler(httptest.NewRecorder(), req)
+	}
+
+	if calls != 2 {
+		t.Errorf("Expected failed request to be retried, handler ran %d times", calls)
+	}
+}
+
+func TestIdempotentKeysArePerCaller(t *testing.T) {
+	s := &Server{idempotency: NewIdempotencyStore(time.Hour)}
+
+	calls := 0
+	handler := s.idempotent(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		s.jsonResponse(w, http.StatusCreated, map[string]interface{}{
+			"calls":  calls,
+			"caller": auth.IdentityFromContext(r.Context()).Subject,
+		})
+	})
+
+	send := func(identity *auth.Identity) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api/v1/clusters", strings.NewReader(`{"name":"test"}`))
+		req.Header.Set(IdempotencyKeyHeader, "abc")
+		req = req.WithContext(auth.WithIdentity(req.Context(), identity))
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+		return rec
+	}
+
+	alice := &auth.Identity{Subject: "alice", Method: auth.MethodAPIKey, KeyID: "key-1"}
+	bob := &auth.Identity{Subject: "bob", Method: auth.MethodAPIKey, KeyID: "key-2"}
+	token := &auth.Identity{Subject: "alice", Method: auth.MethodJWT}
+
+	first := send(alice)
+	for _, identity := range []*auth.Identity{bob, token} {
+		rec := send(identity)
+		if rec.Header().Get("Idempotent-Replayed") != "" || !strings.Contains(rec.Body.String(), identity.Subject) || rec.Body.String() == first.Body.String() {
+			t.Errorf("Expected %s %s to get a response of its own, got %s", identity.Method, identity.Subject, rec.Body.String())
+		}
+	}
+	if calls != 3 {
+		t.Errorf("Expected the handler to run for each caller, ran %d times", calls)
+	}
+
+	// The same caller still gets its recorded response
+	replay := send(alice)
+	if replay.Header().Get("Idempotent-Replayed") != "true" || replay.Body.String() != first.Body.String() {
+		t.Errorf("Expected the response of alice to be replayed, got %s", replay.Body.String())
+	}
+}
@@ -0,0 +1,147 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/akmadan/throome/internal/logger"
+	"github.com/akmadan/throome/pkg/cluster"
+	"go.uber.org/zap"
+)
+
+// serviceHealthTimeout is how long a started service's container may take to become
+// healthy before its adapter is connected
+const serviceHealthTimeout = 30 * time.Second
+
+// healthTimeout returns how long a service's container may take to become healthy:
+// serviceHealthTimeout, or longer when its health check is tuned to give the service
+// more time
+func healthTimeout(serviceConfig *cluster.ServiceConfig) time.Duration {
+	health := serviceConfig.Container.HealthCheck
+	tuned := time.Duration(health.StartPeriod+health.Interval*health.Retries) * time.Second
+	return max(serviceHealthTimeout, tuned)
+}
+
+// StartService starts a stopped service's container and connects its adapter once
+// the container is healthy
+func (g *Gateway) StartService(ctx context.Context, clusterID, serviceName string) error {
+	return g.cycleService(ctx, clusterID, serviceName, "start", func(manager containerManager, containerID string) error {
+		return manager.StartService(ctx, containerID)
+	})
+}
+
+// StopService disconnects a service's adapter and stops its container. The service
+// stays in the cluster's configuration.
+func (g *Gateway) StopService(ctx context.Context, clusterID, serviceName string) error {
+	manager, serviceConfig, err := g.serviceContainer(clusterID, serviceName)
+	if err != nil {
+		return err
+	}
+
+	g.mu.Lock()
+	g.dropService(ctx, clusterID, serviceName)
+	g.mu.Unlock()
+
+	if err := manager.StopService(ctx, serviceConfig.ContainerID); err != nil {
+		return fmt.Errorf("failed to stop service %s: %w", serviceName, err)
+	}
+
+	logger.Info("Service stopped",
+		zap.String("cluster_id", clusterID),
+		zap.String("service", serviceName),
+	)
+	return nil
+}
+
+// RestartService restarts a service's container and reconnects its adapter once the
+// container is healthy
+func (g *Gateway) RestartService(ctx context.Context, clusterID, serviceName string) error {
+	return g.cycleService(ctx, clusterID, serviceName, "restart", func(manager containerManager, containerID string) error {
+		return manager.RestartService(ctx, containerID)
+	})
+}
+
+// cycleService applies a start or restart to a service's container, waits for it to
+// become healthy and reconnects the service's adapter
+func (g *Gateway) cycleService(ctx context.Context, clusterID, serviceName, action string, op func(manager containerManager, containerID string) error) error {
+	manager, serviceConfig, err := g.serviceContainer(clusterID, serviceName)
+	if err != nil {
+		return err
+	}
+
+	// The old connection is useless while the container is down
+	g.mu.Lock()
+	g.dropService(ctx, clusterID, serviceName)
+	g.mu.Unlock()
+
+	if err := op(manager, serviceConfig.ContainerID); err != nil {
+		return fmt.Errorf("failed to %s service %s: %w", action, serviceName, err)
+	}
+	if err := manager.WaitForHealthy(ctx, serviceConfig.ContainerID, healthTimeout(serviceConfig)); err != nil {
+		return fmt.Errorf("service %s failed to become healthy: %w", serviceName, err)
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	adapter, err := g.connectService(ctx, clusterID, serviceName, serviceConfig)
+	if err != nil {
+		return fmt.Errorf("failed to connect service %s: %w", serviceName, err)
+	}
+	if clusterRouter, exists := g.routers[clusterID]; exists {
+		clusterRouter.AddAdapter(serviceName, adapter)
+	}
+
+	logger.Info("Service started",
+		zap.String("cluster_id", clusterID),
+		zap.String("service", serviceName),
+		zap.String("action", action),
+	)
+	return nil
+}
+
+// serviceContainer looks up the container of a provisioned service
+func (g *Gateway) serviceContainer(clusterID, serviceName string) (containerManager, *cluster.ServiceConfig, error) {
+	if g.provisioner == nil {
+		return nil, nil, fmt.Errorf("provisioner not available")
+	}
+
+	config, err := g.clusterManager.Get(clusterID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if config.IsArchived() {
+		return nil, nil, fmt.Errorf("cluster is archived: %s", clusterID)
+	}
+	manager, err := g.clusterProvisioner(config)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	serviceConfig, exists := config.Services[serviceName]
+	if !exists {
+		return nil, nil, fmt.Errorf("service not found: %s", serviceName)
+	}
+	if serviceConfig.ContainerID == "" {
+		return nil, nil, fmt.Errorf("service %s is not provisioned by Throome", serviceName)
+	}
+	return manager, &serviceConfig, nil
+}
+
+// dropService disconnects a service's adapter and removes it from the cluster's
+// router, keeping its configuration. Caller must hold the lock.
+func (g *Gateway) dropService(ctx context.Context, clusterID, serviceName string) {
+	adapter, exists := g.adapters[clusterID][serviceName]
+	if !exists {
+		return
+	}
+
+	// The router shares the cluster's adapter map
+	if clusterRouter, exists := g.routers[clusterID]; exists {
+		clusterRouter.RemoveAdapter(serviceName)
+	} else {
+		delete(g.adapters[clusterID], serviceName)
+	}
+	g.disconnectService(ctx, clusterID, serviceName, adapter)
+}
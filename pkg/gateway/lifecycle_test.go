@@ -0,0 +1,94 @@
+package gateway
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/akmadan/throome/pkg/cluster"
+	"github.com/akmadan/throome/pkg/provisioner"
+)
+
+// fakeContainers records the containers operations were applied to
+type fakeContainers struct {
+	provisioner.Provisioner
+	calls []string
+}
+
+func (f *fakeContainers) StartService(ctx context.Context, containerID string) error {
+	f.calls = append(f.calls, "start "+containerID)
+	return nil
+}
+
+func (f *fakeContainers) StopService(ctx context.Context, containerID string) error {
+	f.calls = append(f.calls, "stop "+containerID)
+	return nil
+}
+
+func (f *fakeContainers) RestartService(ctx context.Context, containerID string) error {
+	f.calls = append(f.calls, "restart "+containerID)
+	return nil
+}
+
+func (f *fakeContainers) RemoveService(ctx context.Context, containerID string) error {
+	f.calls = append(f.calls, "remove "+containerID)
+	return nil
+}
+
+func (f *fakeContainers) WaitForHealthy(ctx context.Context, containerID string, timeout time.Duration) error {
+	return nil
+}
+
+func TestServiceLifecycle(t *testing.T) {
+	gw := newTestGateway(t)
+	containers := &fakeContainers{}
+	gw.SetProvisioner(containers)
+	defer gw.SetProvisioner(nil)
+
+	ctx := context.Background()
+	clusterID, err := gw.CreateCluster(ctx, "lifecycle", &cluster.Config{
+		Services: map[string]cluster.ServiceConfig{
+			"managed": {Type: "test-update", Host: "localhost", Port: 9301, ContainerID: "c1"},
+			"remote":  {Type: "test-update", Host: "localhost", Port: 9302},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create cluster: %v", err)
+	}
+	router, _ := gw.GetRouter(clusterID)
+
+	if err := gw.StopService(ctx, clusterID, "managed"); err != nil {
+		t.Fatalf("Failed to stop service: %v", err)
+	}
+	if _, err := gw.GetAdapter(clusterID, "managed"); err == nil {
+		t.Error("Expected the stopped service to be disconnected")
+	}
+	if _, err := router.GetAdapter("managed"); err == nil {
+		t.Error("Expected the stopped service to be dropped from the router")
+	}
+
+	if err := gw.StartService(ctx, clusterID, "managed"); err != nil {
+		t.Fatalf("Failed to start service: %v", err)
+	}
+	if _, err := router.GetAdapter("managed"); err != nil {
+		t.Errorf("Expected the started service to be routed again, got %v", err)
+	}
+
+	if err := gw.RestartService(ctx, clusterID, "managed"); err != nil {
+		t.Fatalf("Failed to restart service: %v", err)
+	}
+	if err := gw.StopService(ctx, clusterID, "remote"); err == nil {
+		t.Error("Expected services without a container to be rejected")
+	}
+
+	expected := []string{"stop c1", "start c1", "restart c1"}
+	if len(containers.calls) != len(expected) {
+		t.Fatalf("Expected calls %v, got %v", expected, containers.calls)
+	}
+	for i, call := range expected {
+		if containers.calls[i] != call {
+			t.Errorf("Expected calls %v, got %v", expected, containers.calls)
+			break
+		}
+	}
+}
@@ -0,0 +1,815 @@
+package gateway
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/akmadan/throome/pkg/adapters"
+	"github.com/akmadan/throome/pkg/adapters/kafka"
+	"github.com/akmadan/throome/pkg/adapters/postgres"
+	"github.com/akmadan/throome/pkg/auth"
+	"github.com/akmadan/throome/pkg/cluster"
+	"github.com/akmadan/throome/pkg/snapshot"
+)
+
+// routeDoc describes an API route in the OpenAPI document
+type routeDoc struct {
+	ID       string            // Operation ID, the method name of generated clients
+	Summary  string            // One line description of the route
+	Tag      string            // Group of the route
+	Status   int               // Status of a successful response, 200 unless set
+	Query    map[string]string // Query parameters by name, with their description
+	Request  interface{}       // Value whose type is the JSON request body, if any
+	Response interface{}       // Value whose type is the JSON response body, any object unless set
+	Binary   bool              // The request body of a PUT or the response body of a GET is raw content
+}
+
+// clusterConfigBody is the body of requests creating or updating a cluster
+type clusterConfigBody struct {
+	Name      string                 `json:"name"`
+	Namespace string                 `json:"namespace,omitempty"` // The default namespace unless set
+	Config    map[string]interface{} `json:"config"`
+}
+
+// serviceConfigBody is the body of requests adding a service to a cluster
+type serviceConfigBody struct {
+	Name   string                 `json:"name"`
+	Config map[string]interface{} `json:"config"`
+}
+
+// apiKeyBody is the body of requests issuing an API key
+type apiKeyBody struct {
+	Name      string    `json:"name"`
+	Role      auth.Role `json:"role,omitempty"`      // read-only unless set
+	Namespace string    `json:"namespace,omitempty"` // all namespaces unless set
+}
+
+// routeDocs documents the API routes, keyed by method and path template like
+// adminRoutes. Cluster routes served under /namespaces/{namespace} share the
+// documentation of the route for all namespaces.
+var routeDocs = map[string]routeDoc{
+	// Gateway
+	"GET /api/v1/openapi.json": {ID: "getOpenAPI", Summary: "Get this OpenAPI document", Tag: "gateway"},
+	"GET /api/v1/health":       {ID: "getHealth", Summary: "Get the health of the gateway", Tag: "gateway"},
+	"GET /api/v1/realtime":     {ID: "subscribeRealtime", Summary: "Stream cluster metrics and health over a WebSocket", Tag: "gateway"},
+	"POST /api/v1/admin/reload": {
+		ID: "reloadConfig", Summary: "Reload the gateway's configuration file without restarting", Tag: "gateway",
+	},
+
+	// Namespaces
+	"GET /api/v1/namespaces": {ID: "listNamespaces", Summary: "List the namespaces that have clusters", Tag: "namespaces"},
+
+	// API keys
+	"GET /api/v1/auth/keys":             {ID: "listAPIKeys", Summary: "List the accepted API keys", Tag: "auth"},
+	"POST /api/v1/auth/keys":            {ID: "issueAPIKey", Summary: "Issue an API key", Tag: "auth", Status: http.StatusCreated, Request: apiKeyBody{}},
+	"DELETE /api/v1/auth/keys/{key_id}": {ID: "revokeAPIKey", Summary: "Revoke an API key", Tag: "auth"},
+
+	// Activity
+	"GET /api/v1/activity": {ID: "getActivity", Summary: "List the recent operations of all clusters", Tag: "activity", Query: activityQuery},
+	"GET /api/v1/activity/export": {
+		ID: "exportActivity", Summary: "Download every recent operation matching the filters as CSV or NDJSON, oldest first", Tag: "activity",
+		Query: activityExportQuery, Binary: true,
+	},
+	"GET /api/v1/activity/stream": {
+		ID: "streamActivity", Summary: "Stream the operations matching the filters over a WebSocket as they are recorded", Tag: "activity",
+		Query: activityStreamQuery,
+	},
+	"GET /api/v1/clusters/{cluster_id}/activity": {
+		ID: "getClusterActivity", Summary: "List the recent operations of a cluster", Tag: "activity", Query: activityQuery,
+	},
+	"GET /api/v1/clusters/{cluster_id}/services/{service_name}/activity": {
+		ID: "getServiceActivity", Summary: "List the recent operations of a service", Tag: "activity", Query: activityQuery,
+	},
+
+	// Alerts
+	"GET /api/v1/alerts": {
+		ID: "getAlerts", Summary: "List the pending and firing alerts, then the recently resolved ones", Tag: "alerts",
+		Query: map[string]string{"state": "Only list alerts in this state: pending, firing or resolved"},
+	},
+	"GET /api/v1/alerts/rules": {ID: "getAlertRules", Summary: "List the alert rules the gateway evaluates", Tag: "alerts"},
+
+	// Dashboard
+	"GET /api/v1/dashboard/traffic": {
+		ID: "getDashboardTraffic", Summary: "Get the requests per minute and error rate of every cluster over a window", Tag: "dashboard",
+		Query: map[string]string{
+			"window":    "How far back the samples go, such as 6h, 1h by default and at most 24h",
+			"namespace": "Only return the clusters of this namespace",
+		},
+	},
+	"GET /api/v1/dashboard/slowest": {
+		ID: "getDashboardSlowest", Summary: "List the slowest recent operations, slowest first", Tag: "dashboard",
+		Query: map[string]string{
+			"window":       "How far back the operations go, such as 6h, 1h by default and at most 24h",
+			"limit":        "Number of operations to return, 10 by default and at most 100",
+			"cluster_id":   "Only return operations of this cluster",
+			"service_name": "Only return operations of this service",
+			"service_type": "Only return operations of this service type",
+			"operation":    "Only return this operation",
+		},
+	},
+	"GET /api/v1/dashboard/health": {
+		ID: "getDashboardHealth", Summary: "Summarize the health, uptime and latency of the services of every cluster", Tag: "dashboard",
+		Query: map[string]string{"namespace": "Only return the clusters of this namespace"},
+	},
+
+	// Snapshots
+	"GET /api/v1/snapshots": {ID: "listSnapshots", Summary: "List snapshots", Tag: "snapshots", Query: revealSecretsQuery},
+	"GET /api/v1/snapshots/{name}": {
+		ID: "getSnapshot", Summary: "Get a snapshot", Tag: "snapshots", Response: snapshot.Manifest{}, Query: revealSecretsQuery,
+	},
+	"DELETE /api/v1/snapshots/{name}": {ID: "deleteSnapshot", Summary: "Delete a snapshot", Tag: "snapshots"},
+	"POST /api/v1/snapshots/{name}/restore": {
+		ID: "restoreSnapshot", Summary: "Restore a snapshot into a new cluster", Tag: "snapshots",
+		Status: http.StatusCreated, Request: SnapshotRestoreRequest{},
+	},
+	"POST /api/v1/clusters/{cluster_id}/snapshots": {
+		ID: "createSnapshot", Summary: "Snapshot the data of a cluster", Tag: "snapshots",
+		Status: http.StatusCreated, Request: SnapshotCreateRequest{}, Response: snapshot.Manifest{},
+	},
+	"POST /api/v1/clusters/{cluster_id}/services/{service_name}/snapshot": {
+		ID: "snapshotService", Summary: "Snapshot the data of a provisioned service", Tag: "snapshots",
+		Status: http.StatusCreated, Request: ServiceSnapshotRequest{}, Response: snapshot.VolumeSnapshot{},
+	},
+	"GET /api/v1/clusters/{cluster_id}/services/{service_name}/snapshots": {
+		ID: "listServiceSnapshots", Summary: "List the snapshots of a service's data", Tag: "snapshots",
+	},
+	"GET /api/v1/clusters/{cluster_id}/services/{service_name}/snapshots/{name}/download": {
+		ID: "downloadServiceSnapshot", Summary: "Download the tar archive of a snapshot of a service's data", Tag: "snapshots",
+		Binary: true,
+	},
+
+	// Clusters
+	"GET /api/v1/clusters": {
+		ID: "listClusters", Summary: "List clusters", Tag: "clusters",
+		Query: map[string]string{
+			"archived":  "Set to true to list archived clusters, or all to list every cluster",
+			"namespace": "Only list the clusters of this namespace",
+			"limit":     "Maximum number of clusters, all by default and at most 1000",
+			"offset":    "Number of clusters to skip",
+		},
+	},
+	"POST /api/v1/clusters": {
+		ID: "createCluster", Summary: "Create a cluster", Tag: "clusters",
+		Status: http.StatusCreated, Request: clusterConfigBody{},
+	},
+	"GET /api/v1/clusters/{cluster_id}":          {ID: "getCluster", Summary: "Get a cluster", Tag: "clusters", Query: revealSecretsQuery},
+	"PUT /api/v1/clusters/{cluster_id}":          {ID: "updateCluster", Summary: "Update a cluster", Tag: "clusters", Request: clusterConfigBody{}},
+	"DELETE /api/v1/clusters/{cluster_id}":       {ID: "deleteCluster", Summary: "Archive a cluster", Tag: "clusters"},
+	"POST /api/v1/clusters/{cluster_id}/reload":  {ID: "reloadCluster", Summary: "Re-read a cluster's configuration from disk", Tag: "clusters"},
+	"POST /api/v1/clusters/{cluster_id}/restore": {ID: "restoreCluster", Summary: "Restore an archived cluster", Tag: "clusters"},
+	"POST /api/v1/clusters/{cluster_id}/purge":   {ID: "purgeCluster", Summary: "Permanently delete an archived cluster", Tag: "clusters"},
+	"POST /api/v1/clusters/{cluster_id}/drain":   {ID: "drainCluster", Summary: "Make a cluster read-only, refusing data writes", Tag: "clusters"},
+	"POST /api/v1/clusters/{cluster_id}/resume":  {ID: "resumeCluster", Summary: "Accept data writes to a drained cluster again", Tag: "clusters"},
+	"PATCH /api/v1/clusters/{cluster_id}/routing": {
+		ID: "updateRouting", Summary: "Change the routing settings of a running cluster", Tag: "clusters",
+		Request: RoutingRequest{}, Response: cluster.RoutingConfig{},
+	},
+	"GET /api/v1/clusters/{cluster_id}/compose": {
+		ID: "exportCompose", Summary: "Render a cluster's services as a docker-compose.yaml", Tag: "clusters",
+		Binary: true,
+		Query: map[string]string{
+			RevealSecretsParam: "Set to true to render credentials rather than variables, which needs the admin role",
+		},
+	},
+	"POST /api/v1/clusters/{cluster_id}/seed": {
+		ID: "seedCluster", Summary: "Load fixture data into a cluster", Tag: "clusters",
+		Request: SeedRequest{}, Response: SeedResponse{},
+	},
+
+	// Monitoring
+	"GET /api/v1/clusters/{cluster_id}/health": {ID: "getClusterHealth", Summary: "Get the health of a cluster's services", Tag: "monitoring"},
+	"GET /api/v1/clusters/{cluster_id}/health/stream": {
+		ID: "streamClusterHealth", Summary: "Stream the health transitions of a cluster's services as server-sent events", Tag: "monitoring",
+	},
+	"GET /api/v1/clusters/{cluster_id}/services/{service_name}/health/history": {
+		ID: "getServiceHealthHistory", Summary: "Get the last health checks of a service with its failure counts and uptime", Tag: "monitoring",
+	},
+	"GET /api/v1/clusters/{cluster_id}/metrics": {ID: "getClusterMetrics", Summary: "Get the metrics of a cluster", Tag: "monitoring"},
+	"GET /api/v1/clusters/{cluster_id}/metrics/history": {
+		ID: "getClusterMetricsHistory", Summary: "Get the per-minute request rates and latencies of a cluster's services", Tag: "monitoring",
+		Query: map[string]string{
+			"window":       "How far back the samples go, such as 6h, 1h by default and at most 24h",
+			"service_name": "Only return the samples of this service",
+		},
+	},
+	"POST /api/v1/clusters/{cluster_id}/metrics/reset": {
+		ID: "resetClusterMetrics", Summary: "Clear the request counts, latencies and history of a cluster's services", Tag: "monitoring",
+	},
+	"POST /api/v1/clusters/{cluster_id}/services/{service_name}/metrics/reset": {
+		ID: "resetServiceMetrics", Summary: "Clear the request counts, latencies and history of a service", Tag: "monitoring",
+	},
+	"GET /api/v1/clusters/{cluster_id}/sla": {
+		ID: "getClusterSLA", Summary: "Get the weekly and monthly uptime, MTTR and incidents of a cluster's services", Tag: "monitoring",
+	},
+	"GET /api/v1/clusters/{cluster_id}/anomalies": {
+		ID: "getAnomalies", Summary: "List the anomalies detected in a cluster", Tag: "monitoring",
+		Query: map[string]string{"limit": "Maximum number of anomalies"},
+	},
+	"GET /api/v1/clusters/{cluster_id}/recommendations": {
+		ID: "getRecommendations", Summary: "Get tuning recommendations for a cluster", Tag: "monitoring",
+	},
+
+	// Services
+	"POST /api/v1/clusters/{cluster_id}/services": {
+		ID: "addService", Summary: "Add a service to a cluster", Tag: "services",
+		Status: http.StatusCreated, Request: serviceConfigBody{},
+	},
+	"GET /api/v1/clusters/{cluster_id}/services/{service_name}":           {ID: "getService", Summary: "Get a service", Tag: "services"},
+	"DELETE /api/v1/clusters/{cluster_id}/services/{service_name}":        {ID: "removeService", Summary: "Remove a service from a cluster", Tag: "services"},
+	"POST /api/v1/clusters/{cluster_id}/services/{service_name}/start":    {ID: "startService", Summary: "Start a service's container", Tag: "services"},
+	"POST /api/v1/clusters/{cluster_id}/services/{service_name}/stop":     {ID: "stopService", Summary: "Stop a service's container", Tag: "services"},
+	"POST /api/v1/clusters/{cluster_id}/services/{service_name}/restart":  {ID: "restartService", Summary: "Restart a service's container", Tag: "services"},
+	"POST /api/v1/clusters/{cluster_id}/services/{service_name}/scale":    {ID: "scaleService", Summary: "Scale a service to a number of replicas", Tag: "services", Request: ScaleRequest{}},
+	"GET /api/v1/clusters/{cluster_id}/services/{service_name}/pool":      {ID: "getServicePool", Summary: "Get the connection pool statistics of a service", Tag: "services"},
+	"GET /api/v1/clusters/{cluster_id}/faults":                            {ID: "getFaults", Summary: "List the faults injected into a cluster", Tag: "services"},
+	"PUT /api/v1/clusters/{cluster_id}/services/{service_name}/faults":    {ID: "setFault", Summary: "Inject faults into a service", Tag: "services", Request: FaultRequest{}},
+	"DELETE /api/v1/clusters/{cluster_id}/services/{service_name}/faults": {ID: "clearFault", Summary: "Stop injecting faults into a service", Tag: "services"},
+
+	// Logs
+	"GET /api/v1/clusters/{cluster_id}/services/{service_name}/logs": {
+		ID: "getServiceLogs", Summary: "Get the container logs of a service", Tag: "logs",
+		Query: map[string]string{
+			"tail":       "Number of lines from the end of the logs, 100 by default",
+			"timestamps": "Set to true to prefix lines with their timestamp",
+		},
+	},
+	"POST /api/v1/clusters/{cluster_id}/services/{service_name}/exec": {
+		ID: "execService", Summary: "Run a diagnostic command in a service's container and stream its output", Tag: "logs",
+		Request: ExecRequest{},
+	},
+
+	// Database
+	"POST /api/v1/clusters/{cluster_id}/db/execute": {
+		ID: "dbExecute", Summary: "Execute a statement", Tag: "db",
+		Request: DBExecuteRequest{}, Response: DBExecuteResponse{},
+	},
+	"POST /api/v1/clusters/{cluster_id}/db/query": {
+		ID: "dbQuery", Summary: "Run a query and return its rows, page through them with fetch_size and cursor, or stream them with Accept: application/x-ndjson", Tag: "db",
+		Request: DBQueryRequest{}, Response: DBQueryResponse{},
+	},
+	"POST /api/v1/clusters/{cluster_id}/db/explain":            {ID: "dbExplain", Summary: "Get the plan of a query", Tag: "db", Request: DBExplainRequest{}},
+	"GET /api/v1/clusters/{cluster_id}/db/slow-queries":        {ID: "dbSlowQueries", Summary: "List the slow queries of the database", Tag: "db"},
+	"DELETE /api/v1/clusters/{cluster_id}/db/cursors/{cursor}": {ID: "dbCloseCursor", Summary: "Close a query cursor before its last page", Tag: "db"},
+	"POST /api/v1/clusters/{cluster_id}/db/statements": {
+		ID: "dbPrepare", Summary: "Prepare a named statement", Tag: "db",
+		Status: http.StatusCreated, Request: PrepareStatementRequest{}, Response: postgres.PreparedStatement{},
+	},
+	"GET /api/v1/clusters/{cluster_id}/db/statements": {
+		ID: "dbListStatements", Summary: "List the prepared statements", Tag: "db", Response: ListStatementsResponse{},
+	},
+	"DELETE /api/v1/clusters/{cluster_id}/db/statements/{name}": {ID: "dbDeallocate", Summary: "Deallocate a prepared statement", Tag: "db"},
+	"POST /api/v1/clusters/{cluster_id}/db/statements/{name}/execute": {
+		ID: "dbExecuteStatement", Summary: "Execute a prepared statement", Tag: "db",
+		Request: StatementArgsRequest{}, Response: DBExecuteResponse{},
+	},
+	"POST /api/v1/clusters/{cluster_id}/db/statements/{name}/query": {
+		ID: "dbQueryStatement", Summary: "Run a prepared statement and return its rows", Tag: "db",
+		Request: StatementArgsRequest{}, Response: DBQueryResponse{},
+	},
+	"POST /api/v1/clusters/{cluster_id}/db/transactions": {
+		ID: "dbBegin", Summary: "Begin a transaction whose statements are run by later requests", Tag: "db",
+		Status: http.StatusCreated, Request: DBBeginRequest{}, Response: DBTransactionResponse{},
+	},
+	"POST /api/v1/clusters/{cluster_id}/db/transactions/{transaction}/execute": {
+		ID: "dbTransactionExecute", Summary: "Execute a statement in a transaction", Tag: "db",
+		Request: DBExecuteRequest{}, Response: DBExecuteResponse{},
+	},
+	"POST /api/v1/clusters/{cluster_id}/db/transactions/{transaction}/query": {
+		ID: "dbTransactionQuery", Summary: "Run a query in a transaction and return its rows", Tag: "db",
+		Request: DBExecuteRequest{}, Response: DBQueryResponse{},
+	},
+	"POST /api/v1/clusters/{cluster_id}/db/transactions/{transaction}/commit":   {ID: "dbCommit", Summary: "Commit a transaction", Tag: "db"},
+	"POST /api/v1/clusters/{cluster_id}/db/transactions/{transaction}/rollback": {ID: "dbRollback", Summary: "Roll back a transaction", Tag: "db"},
+
+	// Documents
+	"POST /api/v1/clusters/{cluster_id}/documents/{collection}/insert": {
+		ID: "documentInsert", Summary: "Insert documents", Tag: "documents", Request: DocumentInsertRequest{},
+	},
+	"POST /api/v1/clusters/{cluster_id}/documents/{collection}/find": {
+		ID: "documentFind", Summary: "Find documents", Tag: "documents",
+		Request: DocumentFindRequest{}, Response: DocumentsResponse{},
+	},
+	"POST /api/v1/clusters/{cluster_id}/documents/{collection}/update": {
+		ID: "documentUpdate", Summary: "Update documents", Tag: "documents", Request: DocumentUpdateRequest{},
+	},
+	"POST /api/v1/clusters/{cluster_id}/documents/{collection}/delete": {
+		ID: "documentDelete", Summary: "Delete documents", Tag: "documents", Request: DocumentDeleteRequest{},
+	},
+	"POST /api/v1/clusters/{cluster_id}/documents/{collection}/aggregate": {
+		ID: "documentAggregate", Summary: "Run an aggregation pipeline", Tag: "documents",
+		Request: DocumentAggregateRequest{}, Response: DocumentsResponse{},
+	},
+
+	// Items
+	"POST /api/v1/clusters/{cluster_id}/tables": {
+		ID: "createTable", Summary: "Create a table", Tag: "items", Status: http.StatusCreated, Request: TableCreateRequest{},
+	},
+	"POST /api/v1/clusters/{cluster_id}/items/{table}/put": {ID: "putItem", Summary: "Write an item", Tag: "items", Request: ItemPutRequest{}},
+	"POST /api/v1/clusters/{cluster_id}/items/{table}/get": {ID: "getItem", Summary: "Read an item by its key", Tag: "items", Request: ItemGetRequest{}},
+	"POST /api/v1/clusters/{cluster_id}/items/{table}/query": {
+		ID: "queryItems", Summary: "Query the items of a partition", Tag: "items",
+		Request: adapters.KeyQuery{}, Response: ItemQueryResponse{},
+	},
+
+	// Vectors
+	"PUT /api/v1/clusters/{cluster_id}/vectors/{collection}": {
+		ID: "createVectorCollection", Summary: "Create a vector collection", Tag: "vectors",
+		Status: http.StatusCreated, Request: VectorCollectionRequest{},
+	},
+	"POST /api/v1/clusters/{cluster_id}/vectors/{collection}/upsert": {
+		ID: "vectorUpsert", Summary: "Write vectors", Tag: "vectors", Request: VectorUpsertRequest{},
+	},
+	"POST /api/v1/clusters/{cluster_id}/vectors/{collection}/search": {
+		ID: "vectorSearch", Summary: "Find the vectors nearest to a vector", Tag: "vectors",
+		Request: adapters.VectorQuery{}, Response: VectorSearchResponse{},
+	},
+
+	// Objects
+	"GET /api/v1/clusters/{cluster_id}/objects/{bucket}": {
+		ID: "listObjects", Summary: "List the objects of a bucket", Tag: "objects", Response: ObjectsResponse{},
+		Query: map[string]string{
+			"prefix": "Only list the objects whose key starts with this prefix",
+			"limit":  "Maximum number of objects",
+		},
+	},
+	"POST /api/v1/clusters/{cluster_id}/objects/{bucket}/presign": {
+		ID: "presignObject", Summary: "Create a presigned URL for an object", Tag: "objects",
+		Request: ObjectPresignRequest{}, Response: ObjectPresignResponse{},
+	},
+	"PUT /api/v1/clusters/{cluster_id}/objects/{bucket}/{key}": {
+		ID: "putObject", Summary: "Upload an object", Tag: "objects", Binary: true, Response: adapters.ObjectInfo{},
+	},
+	"GET /api/v1/clusters/{cluster_id}/objects/{bucket}/{key}": {
+		ID: "getObject", Summary: "Download an object", Tag: "objects", Binary: true,
+	},
+
+	// Time series
+	"POST /api/v1/clusters/{cluster_id}/ts/write": {
+		ID: "timeSeriesWrite", Summary: "Write points", Tag: "timeseries", Request: TimeSeriesWriteRequest{},
+	},
+	"POST /api/v1/clusters/{cluster_id}/ts/query": {
+		ID: "timeSeriesQuery", Summary: "Query points", Tag: "timeseries",
+		Request: TimeSeriesQueryRequest{}, Response: TimeSeriesQueryResponse{},
+	},
+
+	// Secrets
+	"GET /api/v1/clusters/{cluster_id}/secrets/{path}": {ID: "readSecret", Summary: "Read a secret", Tag: "secrets"},
+	"PUT /api/v1/clusters/{cluster_id}/secrets/{path}": {ID: "writeSecret", Summary: "Write a secret", Tag: "secrets", Request: SecretWriteRequest{}},
+
+	// Cache
+	"POST /api/v1/clusters/{cluster_id}/cache/get": {
+		ID: "cacheGet", Summary: "Read a key", Tag: "cache", Request: CacheGetRequest{}, Response: CacheGetResponse{},
+	},
+	"POST /api/v1/clusters/{cluster_id}/cache/set":    {ID: "cacheSet", Summary: "Write a key", Tag: "cache", Request: CacheSetRequest{}},
+	"POST /api/v1/clusters/{cluster_id}/cache/delete": {ID: "cacheDelete", Summary: "Delete a key", Tag: "cache", Request: CacheDeleteRequest{}},
+	"POST /api/v1/clusters/{cluster_id}/cache/exists": {
+		ID: "cacheExists", Summary: "Check whether a key exists", Tag: "cache", Request: CacheGetRequest{}, Response: CacheExistsResponse{},
+	},
+	"POST /api/v1/clusters/{cluster_id}/cache/ttl": {
+		ID: "cacheTTL", Summary: "Get the time-to-live of a key", Tag: "cache", Request: CacheGetRequest{}, Response: CacheTTLResponse{},
+	},
+	"POST /api/v1/clusters/{cluster_id}/cache/expire": {ID: "cacheExpire", Summary: "Set the time-to-live of a key", Tag: "cache", Request: CacheExpireRequest{}},
+	"POST /api/v1/clusters/{cluster_id}/cache/incr": {
+		ID: "cacheIncr", Summary: "Increment the integer value of a key", Tag: "cache", Request: CacheGetRequest{}, Response: CacheIncrResponse{},
+	},
+	"GET /api/v1/clusters/{cluster_id}/cache/stats": {
+		ID: "cacheStats", Summary: "Get the statistics of the cache", Tag: "cache",
+		Query: map[string]string{"refresh": "Set to true to read the statistics from the cache rather than the last poll"},
+	},
+	"GET /api/v1/clusters/{cluster_id}/cache/keys": {
+		ID: "cacheKeys", Summary: "Scan the keys of the cache", Tag: "cache", Response: CacheKeysResponse{},
+		Query: map[string]string{
+			"cursor":  "Cursor returned by the previous page, 0 to start a scan",
+			"pattern": "Glob-style pattern the keys must match, * by default",
+			"count":   "How many keys to look at, 100 by default and at most 1000",
+		},
+	},
+
+	// Key-value store
+	"POST /api/v1/clusters/{cluster_id}/kv/get": {
+		ID: "kvGet", Summary: "Read a key", Tag: "kv", Request: CacheGetRequest{}, Response: CacheGetResponse{},
+	},
+	"POST /api/v1/clusters/{cluster_id}/kv/set":    {ID: "kvSet", Summary: "Write a key", Tag: "kv", Request: CacheSetRequest{}},
+	"POST /api/v1/clusters/{cluster_id}/kv/delete": {ID: "kvDelete", Summary: "Delete a key", Tag: "kv", Request: CacheDeleteRequest{}},
+	"GET /api/v1/clusters/{cluster_id}/kv/keys": {
+		ID: "kvKeys", Summary: "List keys", Tag: "kv", Response: KVKeysResponse{},
+		Query: map[string]string{"pattern": "Glob pattern the keys match"},
+	},
+	"GET /api/v1/clusters/{cluster_id}/kv/watch": {
+		ID: "kvWatch", Summary: "Stream the changes of keys over a WebSocket", Tag: "kv",
+		Query: map[string]string{"prefix": "Only stream the changes of keys with this prefix"},
+	},
+
+	// Queue
+	"POST /api/v1/clusters/{cluster_id}/queue/publish": {ID: "queuePublish", Summary: "Publish a message", Tag: "queue", Request: QueuePublishRequest{}},
+	"GET /api/v1/clusters/{cluster_id}/queue/subscribe": {
+		ID: "queueSubscribe", Summary: "Consume the messages of a topic over a WebSocket", Tag: "queue",
+		Query: map[string]string{
+			"topic": "Topic to consume",
+			"group": "Consumer group to join, on services that support them",
+		},
+	},
+	"POST /api/v1/clusters/{cluster_id}/queue/consume": {
+		ID: "queueConsume", Summary: "Pull messages of a topic for a consumer group", Tag: "queue",
+		Request: QueueConsumeRequest{}, Response: QueueConsumeResponse{},
+	},
+	"POST /api/v1/clusters/{cluster_id}/queue/commit": {
+		ID: "queueCommit", Summary: "Commit pulled messages by their receipts", Tag: "queue",
+		Request: QueueCommitRequest{}, Response: QueueCommitResponse{},
+	},
+	"GET /api/v1/clusters/{cluster_id}/queue/groups": {
+		ID: "queueListGroups", Summary: "List the consumer groups of the queue", Tag: "queue",
+		Response: ListConsumerGroupsResponse{},
+	},
+	"GET /api/v1/clusters/{cluster_id}/queue/groups/{group}": {
+		ID: "queueGetGroup", Summary: "Get the members and lag per partition of a consumer group", Tag: "queue",
+		Response: kafka.ConsumerGroupDetail{},
+	},
+	"POST /api/v1/clusters/{cluster_id}/queue/groups/{group}/reset": {
+		ID: "queueResetGroup", Summary: "Reset the offsets of a consumer group to earliest, latest or a timestamp", Tag: "queue",
+		Request: ResetOffsetsRequest{}, Response: ResetOffsetsResponse{},
+	},
+	"GET /api/v1/clusters/{cluster_id}/queue/topics": {
+		ID: "listTopics", Summary: "List topics", Tag: "queue", Response: ListTopicsResponse{},
+		Query: map[string]string{
+			"limit":  "Maximum number of topics, all by default and at most 1000",
+			"offset": "Number of topics to skip",
+		},
+	},
+	"POST /api/v1/clusters/{cluster_id}/queue/topics": {ID: "createTopic", Summary: "Create a topic", Tag: "queue", Request: CreateTopicRequest{}},
+	"GET /api/v1/clusters/{cluster_id}/queue/topics/{topic}": {
+		ID: "getTopic", Summary: "Get the partitions, watermarks and message counts of a topic", Tag: "queue",
+		Response: kafka.TopicDetail{},
+	},
+	"DELETE /api/v1/clusters/{cluster_id}/queue/topics/{topic}": {ID: "deleteTopic", Summary: "Delete a topic", Tag: "queue"},
+	"POST /api/v1/clusters/{cluster_id}/queue/topics/{topic}/replay": {
+		ID: "replayTopic", Summary: "Replay the messages of a topic", Tag: "queue",
+		Request: QueueReplayRequest{}, Response: QueueReplayResponse{},
+	},
+	"GET /api/v1/clusters/{cluster_id}/queue/topics/{topic}/dlq": {
+		ID: "getDLQ", Summary: "List the dead-lettered messages of a topic", Tag: "queue",
+		Query: map[string]string{"limit": "Maximum number of messages"},
+	},
+	"POST /api/v1/clusters/{cluster_id}/queue/topics/{topic}/dlq/replay": {
+		ID: "replayDLQ", Summary: "Republish dead-lettered messages to their topic", Tag: "queue",
+		Request: DLQReplayRequest{}, Response: DLQReplayResponse{},
+	},
+}
+
+// revealSecretsQuery is the query parameter of the routes returning credentials
+var revealSecretsQuery = map[string]string{
+	RevealSecretsParam: "Set to true to return credentials rather than " + cluster.RedactedSecret + ", which needs the admin role",
+}
+
+// activityQuery are the query parameters of the activity routes
+var activityQuery = map[string]string{
+	"cluster_id":   "Only list the operations of this cluster",
+	"service_type": "Only list the operations of this service type",
+	"operation":    "Only list operations of this kind",
+	"status":       "Only list operations with this status, success or error",
+	"request_id":   "Only list the operations caused by the API request with this ID",
+	"since":        "Only list operations after this RFC 3339 time",
+	"limit":        "Maximum number of operations, 100 by default and at most 1000",
+	"offset":       "Number of operations to skip, newest first",
+}
+
+// activityExportQuery are the query parameters of the activity export
+var activityExportQuery = map[string]string{
+	"format":       "Format of the export, " + ActivityExportCSV + " or " + ActivityExportNDJSON + ", " + ActivityExportNDJSON + " by default",
+	"cluster_id":   "Only export the operations of this cluster",
+	"service_name": "Only export the operations of services with this name",
+	"service_type": "Only export the operations of this service type",
+	"operation":    "Only export operations of this kind",
+	"status":       "Only export operations with this status, success or error",
+	"request_id":   "Only export the operations caused by the API request with this ID",
+	"since":        "Only export operations after this RFC 3339 time",
+}
+
+// activityStreamQuery are the query parameters of the activity stream
+var activityStreamQuery = map[string]string{
+	"cluster_id":   "Only stream the operations of this cluster",
+	"service_name": "Only stream the operations of services with this name",
+	"service_type": "Only stream the operations of this service type",
+	"operation":    "Only stream operations of this kind",
+	"status":       "Only stream operations with this status, success or error",
+	"request_id":   "Only stream the operations caused by the API request with this ID",
+	"tail":         "Number of recent matching operations sent first, none by default and at most 1000",
+}
+
+// pathParamPattern matches the variables of path templates, with their pattern
+var pathParamPattern = regexp.MustCompile(`\{([^}:]+)(:[^}]*)?\}`)
+
+// handleOpenAPI serves the OpenAPI document of the API
+func (s *Server) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	s.openAPIOnce.Do(func() {
+		s.openAPI = s.buildOpenAPI()
+	})
+	s.jsonResponse(w, http.StatusOK, s.openAPI)
+}
+
+// buildOpenAPI generates the OpenAPI document of the API from its routes, so the
+// document cannot miss routes. Routes the documentation does not know are listed
+// without a description.
+func (s *Server) buildOpenAPI() map[string]interface{} {
+	schemas := newSchemaSet()
+	paths := make(map[string]map[string]interface{})
+	tags := make(map[string]bool)
+
+	_ = s.router.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error { //nolint:errcheck // the walk function never fails
+		template, err := route.GetPathTemplate()
+		if err != nil || !strings.HasPrefix(template, "/api/v1/") {
+			return nil
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			return nil
+		}
+
+		path := pathParamPattern.ReplaceAllString(template, "{$1}")
+		namespaced := strings.Contains(path, "/namespaces/{namespace}/clusters")
+		key := strings.Replace(path, "/namespaces/{namespace}", "", 1)
+
+		for _, method := range methods {
+			doc := routeDocs[method+" "+key]
+			if namespaced && doc.ID != "" {
+				doc.ID += "InNamespace"
+			}
+			if doc.Tag != "" {
+				tags[doc.Tag] = true
+			}
+
+			if paths[path] == nil {
+				paths[path] = make(map[string]interface{})
+			}
+			paths[path][strings.ToLower(method)] = openAPIOperation(method, path, doc, schemas)
+		}
+		return nil
+	})
+
+	tagList := make([]string, 0, len(tags))
+	for tag := range tags {
+		tagList = append(tagList, tag)
+	}
+	sort.Strings(tagList)
+	tagObjects := make([]map[string]string, 0, len(tagList))
+	for _, tag := range tagList {
+		tagObjects = append(tagObjects, map[string]string{"name": tag})
+	}
+
+	schemas.schemas["Error"] = map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"error":      map[string]interface{}{"type": "string"},
+			"status":     map[string]interface{}{"type": "integer"},
+			"details":    map[string]interface{}{"type": "string"},
+			"request_id": map[string]interface{}{"type": "string"},
+		},
+		"required": []string{"error", "status"},
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":       "Throome Gateway API",
+			"description": "API of the Throome gateway for clusters of databases, caches and queues",
+			"version":     "0.1.0",
+		},
+		"servers": []map[string]string{{"url": "/"}},
+		"tags":    tagObjects,
+		"paths":   paths,
+		"components": map[string]interface{}{
+			"schemas": schemas.schemas,
+			"securitySchemes": map[string]interface{}{
+				"apiKey": map[string]interface{}{
+					"type": "apiKey",
+					"in":   "header",
+					"name": APIKeyHeader,
+				},
+				"bearer": map[string]interface{}{
+					"type":         "http",
+					"scheme":       "bearer",
+					"bearerFormat": "JWT",
+				},
+			},
+		},
+		"security": []map[string][]string{{"apiKey": {}}, {"bearer": {}}},
+	}
+}
+
+// openAPIOperation describes one method of a path
+func openAPIOperation(method, path string, doc routeDoc, schemas *schemaSet) map[string]interface{} {
+	operation := map[string]interface{}{}
+	if doc.ID != "" {
+		operation["operationId"] = doc.ID
+	}
+	if doc.Summary != "" {
+		operation["summary"] = doc.Summary
+	}
+	if doc.Tag != "" {
+		operation["tags"] = []string{doc.Tag}
+	}
+	if path == "/api/v1/health" || path == "/api/v1/openapi.json" {
+		operation["security"] = []interface{}{}
+	}
+
+	parameters := make([]map[string]interface{}, 0)
+	for _, match := range pathParamPattern.FindAllStringSubmatch(path, -1) {
+		parameters = append(parameters, map[string]interface{}{
+			"name":     match[1],
+			"in":       "path",
+			"required": true,
+			"schema":   map[string]string{"type": "string"},
+		})
+	}
+	names := make([]string, 0, len(doc.Query))
+	for name := range doc.Query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		parameters = append(parameters, map[string]interface{}{
+			"name":        name,
+			"in":          "query",
+			"description": doc.Query[name],
+			"schema":      map[string]string{"type": "string"},
+		})
+	}
+	parameters = append(parameters, map[string]interface{}{
+		"name":        "X-Request-ID",
+		"in":          "header",
+		"description": "ID of the request, generated by the gateway unless set",
+		"schema":      map[string]string{"type": "string"},
+	})
+	operation["parameters"] = parameters
+
+	binary := map[string]interface{}{
+		"application/octet-stream": map[string]interface{}{
+			"schema": map[string]string{"type": "string", "format": "binary"},
+		},
+	}
+	if doc.Binary && method == "PUT" {
+		operation["requestBody"] = map[string]interface{}{"required": true, "content": binary}
+	} else if doc.Request != nil {
+		operation["requestBody"] = map[string]interface{}{
+			"required": true,
+			"content": map[string]interface{}{
+				"application/json": map[string]interface{}{"schema": schemas.of(reflect.TypeOf(doc.Request))},
+			},
+		}
+	}
+
+	response := map[string]interface{}{"type": "object"}
+	if doc.Response != nil {
+		response = schemas.of(reflect.TypeOf(doc.Response))
+	}
+	status := doc.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	errorResponse := map[string]interface{}{
+		"description": "The request failed",
+		"content": map[string]interface{}{
+			"application/json": map[string]interface{}{
+				"schema": map[string]string{"$ref": "#/components/schemas/Error"},
+			},
+		},
+	}
+	content := map[string]interface{}{
+		"application/json": map[string]interface{}{"schema": response},
+	}
+	if doc.Binary && method == "GET" {
+		content = binary
+	}
+	operation["responses"] = map[string]interface{}{
+		strconv.Itoa(status): map[string]interface{}{
+			"description": http.StatusText(status),
+			"content":     content,
+		},
+		"default": errorResponse,
+	}
+
+	return operation
+}
+
+// schemaSet collects the schemas of the named types of request and response bodies
+type schemaSet struct {
+	schemas map[string]interface{}
+	names   map[reflect.Type]string
+}
+
+func newSchemaSet() *schemaSet {
+	return &schemaSet{
+		schemas: make(map[string]interface{}),
+		names:   make(map[reflect.Type]string),
+	}
+}
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	durationType   = reflect.TypeOf(time.Duration(0))
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+// of returns the schema of the JSON encoding of a type. Named structs are added to
+// the components and referenced.
+func (s *schemaSet) of(t reflect.Type) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t {
+	case timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case durationType:
+		return map[string]interface{}{"type": "integer", "description": "Duration in nanoseconds"}
+	case rawMessageType:
+		return map[string]interface{}{}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string", "format": "byte"}
+		}
+		return map[string]interface{}{"type": "array", "items": s.of(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": s.of(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return s.object(t)
+		}
+		return map[string]interface{}{"$ref": "#/components/schemas/" + s.name(t)}
+	}
+	return map[string]interface{}{}
+}
+
+// name returns the component name of a named struct, adding its schema on first use
+func (s *schemaSet) name(t reflect.Type) string {
+	if name, ok := s.names[t]; ok {
+		return name
+	}
+
+	name := strings.ToUpper(t.Name()[:1]) + t.Name()[1:]
+	if _, taken := s.schemas[name]; taken {
+		name = strings.ReplaceAll(t.PkgPath()[strings.LastIndex(t.PkgPath(), "/")+1:], "-", "") + name
+	}
+	s.names[t] = name
+	// Reserve the name before describing the fields, for types referring to themselves
+	s.schemas[name] = map[string]interface{}{}
+	s.schemas[name] = s.object(t)
+	return name
+}
+
+// object describes the fields of a struct as encoding/json encodes them
+func (s *schemaSet) object(t reflect.Type) map[string]interface{} {
+	properties := make(map[string]interface{})
+	s.addFields(t, properties)
+	return map[string]interface{}{"type": "object", "properties": properties}
+}
+
+func (s *schemaSet) addFields(t reflect.Type, properties map[string]interface{}) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			for embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				s.addFields(embedded, properties)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+		properties[name] = s.of(field.Type)
+	}
+}
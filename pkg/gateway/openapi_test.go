@@ -0,0 +1,109 @@
+package gateway
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+
+	"github.com/akmadan/throome/internal/config"
+)
+
+func TestRouteDocs(t *testing.T) {
+	s := &Server{config: config.DefaultConfig(), router: mux.NewRouter()}
+	s.setupRoutes()
+
+	routes := make(map[string]bool)
+	_ = s.router.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error { //nolint:errcheck // the walk function never fails
+		template, err := route.GetPathTemplate()
+		if err != nil || !strings.HasPrefix(template, "/api/v1/") {
+			return nil
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			return nil
+		}
+		path := pathParamPattern.ReplaceAllString(template, "{$1}")
+		path = strings.Replace(path, "/namespaces/{namespace}", "", 1)
+		for _, method := range methods {
+			routes[method+" "+path] = true
+		}
+		return nil
+	})
+
+	for route := range routes {
+		doc, ok := routeDocs[route]
+		if !ok {
+			t.Errorf("Route %s is not documented", route)
+			continue
+		}
+		if doc.ID == "" || doc.Summary == "" || doc.Tag == "" {
+			t.Errorf("Route %s is missing its operation ID, summary or tag", route)
+		}
+	}
+	for route := range routeDocs {
+		if !routes[route] {
+			t.Errorf("Documented route %s does not exist", route)
+		}
+	}
+}
+
+func TestOpenAPIDocument(t *testing.T) {
+	s := &Server{config: config.DefaultConfig(), gateway: newTestGateway(t), router: mux.NewRouter()}
+	s.setupRoutes()
+
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, httptest.NewRequest("GET", "/api/v1/openapi.json", nil))
+	if rec.Code != 200 {
+		t.Fatalf("Expected status 200, got %d", rec.Code)
+	}
+
+	var doc struct {
+		OpenAPI    string                                       `json:"openapi"`
+		Paths      map[string]map[string]map[string]interface{} `json:"paths"`
+		Components struct {
+			Schemas map[string]map[string]interface{} `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&doc); err != nil {
+		t.Fatalf("Failed to decode document: %v", err)
+	}
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		t.Errorf("Expected an OpenAPI 3 document, got version %q", doc.OpenAPI)
+	}
+
+	for _, path := range []string{
+		"/api/v1/clusters/{cluster_id}/db/query",
+		"/api/v1/namespaces/{namespace}/clusters/{cluster_id}/db/query",
+		"/api/v1/clusters/{cluster_id}/objects/{bucket}/{key}",
+	} {
+		if doc.Paths[path] == nil {
+			t.Errorf("Expected path %s in the document", path)
+		}
+	}
+
+	ids := make(map[string]string)
+	for path, operations := range doc.Paths {
+		for method, operation := range operations {
+			id, _ := operation["operationId"].(string)
+			if other, ok := ids[id]; ok {
+				t.Errorf("Operation ID %s is used by %s and %s %s", id, other, method, path)
+			}
+			ids[id] = method + " " + path
+		}
+	}
+
+	query := doc.Components.Schemas["DBQueryRequest"]
+	if query == nil {
+		t.Fatalf("Expected a DBQueryRequest schema, got %v", doc.Components.Schemas)
+	}
+	properties, _ := query["properties"].(map[string]interface{})
+	if properties["query"] == nil {
+		t.Errorf("Expected a query property in the DBQueryRequest schema, got %v", query)
+	}
+	if doc.Components.Schemas["Error"] == nil {
+		t.Error("Expected an Error schema")
+	}
+}
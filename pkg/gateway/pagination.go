@@ -0,0 +1,79 @@
+package gateway
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// maxPageLimit is the largest page a listing returns
+const maxPageLimit = 1000
+
+// TotalCountHeader carries the number of items of a paginated listing
+const TotalCountHeader = "X-Total-Count"
+
+// page is the part of a listing a request asks for with the limit and offset query
+// parameters. A limit of 0 asks for all items.
+type page struct {
+	Limit  int
+	Offset int
+}
+
+// requestPage parses the limit and offset query parameters, ignoring invalid values
+// like the listings always have. Limits are capped at maxPageLimit.
+func requestPage(r *http.Request, defaultLimit int) page {
+	query := r.URL.Query()
+	p := page{Limit: defaultLimit}
+
+	if limit, err := strconv.Atoi(query.Get("limit")); err == nil && limit > 0 {
+		p.Limit = limit
+	}
+	if p.Limit > maxPageLimit {
+		p.Limit = maxPageLimit
+	}
+	if offset, err := strconv.Atoi(query.Get("offset")); err == nil && offset > 0 {
+		p.Offset = offset
+	}
+	return p
+}
+
+// bounds returns the range of a listing of total items that the page covers
+func (p page) bounds(total int) (start, end int) {
+	start = min(p.Offset, total)
+	end = total
+	if p.Limit > 0 {
+		end = min(start+p.Limit, total)
+	}
+	return start, end
+}
+
+// setPageHeaders sets the X-Total-Count header of a listing and, for limited pages,
+// a Link header with the first, previous, next and last pages
+func setPageHeaders(w http.ResponseWriter, r *http.Request, p page, total int) {
+	w.Header().Set(TotalCountHeader, strconv.Itoa(total))
+	if p.Limit <= 0 {
+		return
+	}
+
+	link := func(offset int, rel string) string {
+		query := r.URL.Query()
+		query.Set("limit", strconv.Itoa(p.Limit))
+		query.Set("offset", strconv.Itoa(offset))
+		return fmt.Sprintf("<%s?%s>; rel=%q", r.URL.Path, query.Encode(), rel)
+	}
+
+	last := 0
+	if total > 0 {
+		last = (total - 1) / p.Limit * p.Limit
+	}
+	links := []string{link(0, "first")}
+	if p.Offset > 0 {
+		links = append(links, link(max(p.Offset-p.Limit, 0), "prev"))
+	}
+	if p.Offset+p.Limit < total {
+		links = append(links, link(p.Offset+p.Limit, "next"))
+	}
+	links = append(links, link(last, "last"))
+	w.Header().Set("Link", strings.Join(links, ", "))
+}
@@ -0,0 +1,68 @@
+package gateway
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequestPage(t *testing.T) {
+	tests := []struct {
+		query  string
+		limit  int
+		offset int
+	}{
+		{"", 100, 0},
+		{"?limit=10&offset=20", 10, 20},
+		{"?limit=5000", maxPageLimit, 0},
+		{"?limit=-1&offset=oops", 100, 0},
+	}
+
+	for _, tt := range tests {
+		p := requestPage(httptest.NewRequest("GET", "/api/v1/activity"+tt.query, nil), 100)
+		if p.Limit != tt.limit || p.Offset != tt.offset {
+			t.Errorf("%q: expected limit %d and offset %d, got %+v", tt.query, tt.limit, tt.offset, p)
+		}
+	}
+}
+
+func TestPageBounds(t *testing.T) {
+	tests := []struct {
+		page       page
+		start, end int
+	}{
+		{page{}, 0, 25},
+		{page{Limit: 10}, 0, 10},
+		{page{Limit: 10, Offset: 20}, 20, 25},
+		{page{Limit: 10, Offset: 30}, 25, 25},
+	}
+
+	for _, tt := range tests {
+		if start, end := tt.page.bounds(25); start != tt.start || end != tt.end {
+			t.Errorf("%+v: expected [%d:%d], got [%d:%d]", tt.page, tt.start, tt.end, start, end)
+		}
+	}
+}
+
+func TestSetPageHeaders(t *testing.T) {
+	r := httptest.NewRequest("GET", "/api/v1/clusters?archived=all&limit=10&offset=10", nil)
+	rec := httptest.NewRecorder()
+	setPageHeaders(rec, r, page{Limit: 10, Offset: 10}, 25)
+
+	if total := rec.Header().Get(TotalCountHeader); total != "25" {
+		t.Errorf("Expected a total count of 25, got %q", total)
+	}
+	expected := `</api/v1/clusters?archived=all&limit=10&offset=0>; rel="first", ` +
+		`</api/v1/clusters?archived=all&limit=10&offset=0>; rel="prev", ` +
+		`</api/v1/clusters?archived=all&limit=10&offset=20>; rel="next", ` +
+		`</api/v1/clusters?archived=all&limit=10&offset=20>; rel="last"`
+	if link := rec.Header().Get("Link"); link != expected {
+		t.Errorf("Unexpected Link header:\n%s", link)
+	}
+
+	// Unlimited listings only get the total count
+	rec = httptest.NewRecorder()
+	setPageHeaders(rec, r, page{}, 25)
+	if link := rec.Header().Get("Link"); link != "" {
+		t.Errorf("Expected no Link header for an unlimited listing, got %q", link)
+	}
+}
@@ -0,0 +1,53 @@
+package gateway
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/akmadan/throome/pkg/adapters"
+	"github.com/akmadan/throome/pkg/cluster"
+	"github.com/akmadan/throome/pkg/provisioner"
+)
+
+// customAdapters holds the adapter constructors of service types registered by
+// programs embedding the gateway
+var customAdapters = struct {
+	constructors map[string]adapters.AdapterConstructor
+	mu           sync.RWMutex
+}{constructors: make(map[string]adapters.AdapterConstructor)}
+
+// RegisterAdapter adds a service type to the gateway without forking it. Clusters may
+// then use the type in their configuration, and the gateway creates its adapters with
+// constructor. When spec is set, the Docker provisioner can also start containers for
+// the type; without it, services of the type must already be running.
+//
+// RegisterAdapter must be called before NewGateway, typically from an init function.
+// Registering a built-in or already registered type is an error.
+func RegisterAdapter(serviceType string, constructor adapters.AdapterConstructor, spec provisioner.ContainerSpecFunc) error {
+	if constructor == nil {
+		return fmt.Errorf("adapter constructor for %s cannot be nil", serviceType)
+	}
+
+	if err := cluster.RegisterServiceType(serviceType); err != nil {
+		return err
+	}
+
+	customAdapters.mu.Lock()
+	customAdapters.constructors[serviceType] = constructor
+	customAdapters.mu.Unlock()
+
+	if spec != nil {
+		provisioner.RegisterServiceType(serviceType, spec)
+	}
+	return nil
+}
+
+// registerCustomAdapters adds the registered service types to an adapter factory
+func registerCustomAdapters(factory *adapters.Factory) {
+	customAdapters.mu.RLock()
+	defer customAdapters.mu.RUnlock()
+
+	for serviceType, constructor := range customAdapters.constructors {
+		factory.Register(serviceType, constructor)
+	}
+}
@@ -0,0 +1,54 @@
+package gateway
+
+import (
+	"context"
+	"testing"
+
+	"github.com/akmadan/throome/pkg/adapters"
+	"github.com/akmadan/throome/pkg/cluster"
+)
+
+type customAdapter struct {
+	*adapters.BaseAdapter
+}
+
+func (c *customAdapter) Connect(ctx context.Context) error    { return nil }
+func (c *customAdapter) Disconnect(ctx context.Context) error { return nil }
+func (c *customAdapter) Ping(ctx context.Context) error       { return nil }
+func (c *customAdapter) HealthCheck(ctx context.Context) (*adapters.HealthStatus, error) {
+	return &adapters.HealthStatus{Healthy: true}, nil
+}
+
+func TestRegisterAdapter(t *testing.T) {
+	constructor := func(config *cluster.ServiceConfig) (adapters.Adapter, error) {
+		return &customAdapter{BaseAdapter: adapters.NewBaseAdapter(config)}, nil
+	}
+
+	if err := RegisterAdapter("test-custom", constructor, nil); err != nil {
+		t.Fatalf("Failed to register adapter: %v", err)
+	}
+	if err := RegisterAdapter("test-custom", constructor, nil); err == nil {
+		t.Error("Expected registering a type twice to fail")
+	}
+	if err := RegisterAdapter("redis", constructor, nil); err == nil {
+		t.Error("Expected registering a built-in type to fail")
+	}
+	if err := RegisterAdapter("test-nil", nil, nil); err == nil {
+		t.Error("Expected registering a nil constructor to fail")
+	}
+
+	config := &cluster.ServiceConfig{Type: "test-custom", Host: "localhost", Port: 9999}
+	if err := config.Validate(); err != nil {
+		t.Errorf("Expected the registered type to validate, got %v", err)
+	}
+
+	factory := adapters.NewFactory()
+	registerCustomAdapters(factory)
+	adapter, err := factory.Create(config)
+	if err != nil {
+		t.Fatalf("Expected the factory to create the custom adapter, got %v", err)
+	}
+	if _, ok := adapter.(*customAdapter); !ok {
+		t.Errorf("Unexpected adapter type %T", adapter)
+	}
+}
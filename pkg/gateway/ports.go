@@ -0,0 +1,58 @@
+package gateway
+
+import (
+	"context"
+
+	"github.com/akmadan/throome/pkg/cluster"
+)
+
+// portAllocator is implemented by provisioners that publish services on host ports
+type portAllocator interface {
+	AllocatePort(ctx context.Context, port int, reserved map[int]bool) (int, error)
+}
+
+// reservedPorts returns the host ports of the containers of every stored cluster on
+// the same Docker host as a cluster, stopped ones and replicas included, and of the
+// services of the cluster provisioned so far. The stored services a cluster is
+// replacing do not reserve their ports.
+func (g *Gateway) reservedPorts(clusterConfig *cluster.Config, serviceName string) map[int]bool {
+	reserved := make(map[int]bool)
+	for clusterID, config := range g.clusterManager.GetAllConfigs() {
+		if dockerHost(config) != dockerHost(clusterConfig) {
+			continue
+		}
+		for name, serviceConfig := range config.Services {
+			if _, replaced := clusterConfig.Services[name]; replaced && clusterID == clusterConfig.ClusterID {
+				continue
+			}
+			reserveServicePorts(reserved, serviceConfig)
+		}
+	}
+	for name, serviceConfig := range clusterConfig.Services {
+		if name != serviceName {
+			reserveServicePorts(reserved, serviceConfig)
+		}
+	}
+	return reserved
+}
+
+// reserveServicePorts adds the host ports of a service's containers, its replicas'
+// included, to reserved
+func reserveServicePorts(reserved map[int]bool, serviceConfig cluster.ServiceConfig) {
+	if serviceConfig.ContainerID != "" {
+		reserved[serviceConfig.Port] = true
+	}
+	for _, replica := range serviceConfig.Replicas {
+		if replica.ContainerID != "" {
+			reserved[replica.Port] = true
+		}
+	}
+}
+
+// dockerHost returns the Docker host of a cluster, empty for the gateway's
+func dockerHost(config *cluster.Config) string {
+	if config.Docker == nil {
+		return ""
+	}
+	return config.Docker.Host
+}
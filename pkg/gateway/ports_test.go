@@ -0,0 +1,47 @@
+package gateway
+
+import (
+	"context"
+	"testing"
+
+	"github.com/akmadan/throome/pkg/cluster"
+)
+
+func TestReservedPorts(t *testing.T) {
+	gw := newTestGateway(t)
+
+	clusterID, err := gw.CreateCluster(context.Background(), "ports", &cluster.Config{
+		Services: map[string]cluster.ServiceConfig{
+			"db":     {Type: "test-update", Host: "localhost", Port: 9841, ContainerID: "ports-c1"},
+			"cache":  {Type: "test-update", Host: "localhost", Port: 9842, ContainerID: "ports-c2"},
+			"remote": {Type: "test-update", Host: "localhost", Port: 9843},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create cluster: %v", err)
+	}
+
+	// Other clusters cannot take the ports of stored containers
+	other := &cluster.Config{Services: map[string]cluster.ServiceConfig{
+		"queue": {Type: "test-update", Port: 9844, Provision: true},
+		"ready": {Type: "test-update", Port: 9845, ContainerID: "ports-c3"},
+	}}
+	reserved := gw.reservedPorts(other, "queue")
+	if !reserved[9841] || !reserved[9842] || !reserved[9845] || reserved[9843] {
+		t.Errorf("Expected the ports of provisioned containers to be reserved, got %v", reserved)
+	}
+
+	// A service replacing its container keeps its port
+	replacing := &cluster.Config{ClusterID: clusterID, Services: map[string]cluster.ServiceConfig{
+		"db": {Type: "test-update", Port: 9841, Provision: true},
+	}}
+	if reserved := gw.reservedPorts(replacing, "db"); reserved[9841] || !reserved[9842] {
+		t.Errorf("Expected only the replaced service's port to be free, got %v", reserved)
+	}
+
+	// Clusters on other Docker hosts have ports of their own
+	remote := &cluster.Config{Docker: &cluster.DockerHostConfig{Host: "tcp://builder-1:2376"}, Services: other.Services}
+	if reserved := gw.reservedPorts(remote, "queue"); reserved[9841] {
+		t.Errorf("Expected ports of other hosts not to be reserved, got %v", reserved)
+	}
+}
@@ -0,0 +1,231 @@
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+
+	"go.uber.org/zap"
+
+	"github.com/akmadan/throome/internal/logger"
+	"github.com/akmadan/throome/pkg/cluster"
+	"github.com/akmadan/throome/pkg/provisioner"
+)
+
+// Stages of provisioning a service
+const (
+	ProvisionStarted = "provisioning"
+	ProvisionPulling = "pulling"
+	ProvisionWaiting = "waiting" // The container runs, its health check has not passed yet
+	ProvisionReady   = "ready"
+	ProvisionFailed  = "failed"
+)
+
+// ProvisionProgress reports the progress of provisioning a service
+type ProvisionProgress struct {
+	ClusterName string `json:"cluster_name"`
+	Service     string `json:"service"`
+	Type        string `json:"type"`
+	Stage       string `json:"stage"`
+	Error       string `json:"error,omitempty"`
+
+	Pull *provisioner.PullProgress `json:"pull,omitempty"` // Progress of the image pull while pulling
+}
+
+// ProvisionHandler is called for every stage of provisioning a service
+type ProvisionHandler func(progress *ProvisionProgress)
+
+// ProvisionError describes which service failed to provision
+type ProvisionError struct {
+	Message string
+	Err     error
+}
+
+func (e *ProvisionError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Message, e.Err)
+}
+
+func (e *ProvisionError) Unwrap() error {
+	return e.Err
+}
+
+// OnProvision registers a handler that is called as services are provisioned
+func (g *Gateway) OnProvision(handler ProvisionHandler) {
+	g.provisionMu.Lock()
+	defer g.provisionMu.Unlock()
+
+	g.provisionHandlers = append(g.provisionHandlers, handler)
+}
+
+// reportProvision notifies the handlers of a provisioning stage
+func (g *Gateway) reportProvision(progress *ProvisionProgress) {
+	g.provisionMu.Lock()
+	handlers := g.provisionHandlers
+	g.provisionMu.Unlock()
+	for _, handler := range handlers {
+		handler(progress)
+	}
+}
+
+// clusterProvisioner returns the provisioner of a cluster's containers, or nil when
+// the gateway has none
+func (g *Gateway) clusterProvisioner(config *cluster.Config) (provisioner.Provisioner, error) {
+	return provisionerFor(g.provisioner, config)
+}
+
+// provisionServices provisions a container for every service that requests one and
+// points the service at it. On failure every container provisioned so far is removed
+// and a *ProvisionError is returned.
+func (g *Gateway) provisionServices(ctx context.Context, clusterConfig *cluster.Config) error {
+	serviceProvisioner, err := g.clusterProvisioner(clusterConfig)
+	if err != nil {
+		return &ProvisionError{"Failed to select the cluster's Docker host", err}
+	}
+	if serviceProvisioner == nil {
+		return nil
+	}
+
+	logger.Info("Processing services", zap.Int("total", len(clusterConfig.Services)))
+
+	for serviceName, serviceConfig := range clusterConfig.Services {
+		// Check if service should be provisioned or if it's an existing remote service
+		if !serviceConfig.Provision {
+			// Using existing remote service - skip provisioning
+			logger.Info("Using existing remote service",
+				zap.String("service", serviceName),
+				zap.String("type", serviceConfig.Type),
+				zap.String("host", serviceConfig.Host),
+				zap.Int("port", serviceConfig.Port),
+			)
+			continue
+		}
+
+		// Provision the service with Docker
+		logger.Info("Provisioning new service",
+			zap.String("service", serviceName),
+			zap.String("type", serviceConfig.Type),
+		)
+
+		report := func(stage string, err error) {
+			progress := &ProvisionProgress{
+				ClusterName: clusterConfig.Name,
+				Service:     serviceName,
+				Type:        serviceConfig.Type,
+				Stage:       stage,
+			}
+			if err != nil {
+				progress.Error = err.Error()
+			}
+			g.reportProvision(progress)
+		}
+		report(ProvisionStarted, nil)
+
+		// Services whose port is taken are published on the next free one
+		if allocator, ok := serviceProvisioner.(portAllocator); ok {
+			port, err := allocator.AllocatePort(ctx, serviceConfig.Port, g.reservedPorts(clusterConfig, serviceName))
+			if err != nil {
+				report(ProvisionFailed, err)
+				g.removeProvisioned(ctx, clusterConfig)
+				return &ProvisionError{fmt.Sprintf("Failed to allocate a port for service %s", serviceName), err}
+			}
+			if port != serviceConfig.Port {
+				logger.Info("Requested port is taken, using the next free port",
+					zap.String("service", serviceName),
+					zap.Int("requested", serviceConfig.Port),
+					zap.Int("port", port),
+				)
+				serviceConfig.Port = port
+			}
+		}
+
+		pullCtx := provisioner.WithPullProgress(ctx, func(pull provisioner.PullProgress) {
+			g.reportProvision(&ProvisionProgress{
+				ClusterName: clusterConfig.Name,
+				Service:     serviceName,
+				Type:        serviceConfig.Type,
+				Stage:       ProvisionPulling,
+				Pull:        &pull,
+			})
+		})
+		container, err := serviceProvisioner.ProvisionService(pullCtx, clusterConfig.Name, serviceName, &serviceConfig)
+		if err != nil {
+			report(ProvisionFailed, err)
+			// Cleanup any already provisioned containers
+			g.removeProvisioned(ctx, clusterConfig)
+			return &ProvisionError{fmt.Sprintf("Failed to provision service %s", serviceName), err}
+		}
+
+		// Update config with container ID
+		svc := clusterConfig.Services[serviceName]
+		svc.ContainerID = container.ContainerID
+		svc.Port = serviceConfig.Port
+		// Set the host based on where Throome is running
+		// If the provisioner names a host, such as a Kubernetes Service, use it
+		// If Throome is in Podman, use host.containers.internal to reach host containers
+		// If Throome is in Docker, use host.docker.internal to reach host containers
+		// If Throome is running natively, use localhost
+		if container.Host != "" {
+			svc.Host = container.Host
+		} else if isRunningInPodman() {
+			svc.Host = "host.containers.internal"
+		} else if isRunningInDocker() {
+			svc.Host = "host.docker.internal"
+		} else {
+			svc.Host = "localhost"
+		}
+		clusterConfig.Services[serviceName] = svc
+
+		logger.Info("Service provisioned",
+			zap.String("service", serviceName),
+			zap.String("container_id", container.ContainerID),
+		)
+
+		// Wait for container to be healthy before proceeding
+		report(ProvisionWaiting, nil)
+		if err := serviceProvisioner.WaitForHealthy(ctx, container.ContainerID, healthTimeout(&serviceConfig)); err != nil {
+			report(ProvisionFailed, err)
+			// Cleanup all provisioned containers on failure
+			g.removeProvisioned(ctx, clusterConfig)
+			return &ProvisionError{fmt.Sprintf("Service %s failed to become healthy", serviceName), err}
+		}
+		report(ProvisionReady, nil)
+	}
+
+	return nil
+}
+
+// removeProvisioned removes the containers provisioned for a cluster's services
+func (g *Gateway) removeProvisioned(ctx context.Context, clusterConfig *cluster.Config) {
+	serviceProvisioner, err := g.clusterProvisioner(clusterConfig)
+	if err != nil || serviceProvisioner == nil {
+		return
+	}
+
+	for _, serviceConfig := range clusterConfig.Services {
+		for _, containerID := range serviceConfig.ContainerIDs() {
+			_ = serviceProvisioner.RemoveService(ctx, containerID)
+		}
+	}
+}
+
+// isRunningInPodman checks if Throome is running inside a Podman container
+func isRunningInPodman() bool {
+	_, err := os.Stat("/run/.containerenv")
+	return err == nil
+}
+
+// isRunningInDocker checks if Throome is running inside a Docker container
+func isRunningInDocker() bool {
+	// Check for /.dockerenv file (common indicator)
+	if _, err := os.Stat("/.dockerenv"); err == nil {
+		return true
+	}
+
+	// Check cgroup file for docker
+	if data, err := os.ReadFile("/proc/self/cgroup"); err == nil {
+		return bytes.Contains(data, []byte("docker")) || bytes.Contains(data, []byte("containerd"))
+	}
+
+	return false
+}
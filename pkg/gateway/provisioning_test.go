@@ -0,0 +1,83 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/akmadan/throome/pkg/cluster"
+	"github.com/akmadan/throome/pkg/provisioner"
+)
+
+// fakeProvisioner provisions containers named after their services, failing for
+// services named "broken"
+type fakeProvisioner struct {
+	fakeContainers
+}
+
+func (f *fakeProvisioner) ProvisionService(ctx context.Context, clusterName, serviceName string, config *cluster.ServiceConfig) (*provisioner.ServiceContainer, error) {
+	if serviceName == "broken" {
+		return nil, errors.New("image not found")
+	}
+	f.calls = append(f.calls, "provision "+serviceName)
+	return &provisioner.ServiceContainer{ContainerID: clusterName + "-" + serviceName, Host: "provisioned.local"}, nil
+}
+
+func TestCreateClusterProvisions(t *testing.T) {
+	gw := newTestGateway(t)
+	containers := &fakeProvisioner{}
+	gw.SetProvisioner(containers)
+	defer gw.SetProvisioner(nil)
+
+	var stages []string
+	gw.OnProvision(func(progress *ProvisionProgress) {
+		if progress.ClusterName == "provisioned" {
+			stages = append(stages, progress.Stage)
+		}
+	})
+
+	ctx := context.Background()
+	clusterID, err := gw.CreateCluster(ctx, "provisioned", &cluster.Config{
+		Services: map[string]cluster.ServiceConfig{
+			"db": {Type: "test-update", Port: 9851, Provision: true},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create cluster: %v", err)
+	}
+	defer func() { _ = gw.DeleteCluster(ctx, clusterID) }()
+	config, _ := gw.GetClusterConfig(clusterID)
+	if db := config.Services["db"]; db.ContainerID != "provisioned-db" || db.Host != "provisioned.local" {
+		t.Errorf("Expected the service to point at its container, got %s on %s", db.ContainerID, db.Host)
+	}
+	want := []string{ProvisionStarted, ProvisionWaiting, ProvisionReady}
+	if fmt.Sprint(stages) != fmt.Sprint(want) {
+		t.Errorf("Expected stages %v, got %v", want, stages)
+	}
+
+	_, err = gw.CreateCluster(ctx, "unprovisioned", &cluster.Config{
+		Services: map[string]cluster.ServiceConfig{
+			"broken": {Type: "test-update", Port: 9852, Provision: true},
+		},
+	})
+	var provisionErr *ProvisionError
+	if !errors.As(err, &provisionErr) || provisionErr.Message != "Failed to provision service broken" {
+		t.Errorf("Expected the failed service to be named, got %v", err)
+	}
+
+	// Containers are removed when the cluster cannot be created
+	containers.calls = nil
+	_, err = gw.CreateCluster(ctx, "invalid", &cluster.Config{
+		Namespace: "Not A Namespace",
+		Services: map[string]cluster.ServiceConfig{
+			"db": {Type: "test-update", Port: 9853, Provision: true},
+		},
+	})
+	if err == nil {
+		t.Fatal("Expected a cluster in an invalid namespace to be refused")
+	}
+	if len(containers.calls) != 2 || containers.calls[1] != "remove invalid-db" {
+		t.Errorf("Expected the provisioned container to be removed, got %v", containers.calls)
+	}
+}
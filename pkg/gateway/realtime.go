@@ -0,0 +1,269 @@
+package gateway
+
+import (
+	"sync"
+	"time"
+
+	"github.com/akmadan/throome/internal/logger"
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+)
+
+// Realtime event types
+const (
+	EventHealth       = "health"       // Service health, sent on subscribe and on every transition
+	EventMetrics      = "metrics"      // Cluster metrics, sent on every tick
+	EventActivity     = "activity"     // Activity log entries as they are recorded
+	EventProvisioning = "provisioning" // Container provisioning progress
+	EventRestart      = "restart"      // Progress of restarting an unhealthy service
+	EventSubscribed   = "subscribed"
+	EventUnsubscribed = "unsubscribed"
+	EventError        = "error"
+)
+
+// AllClusters subscribes to events of every cluster, including provisioning events
+// of clusters that are still being created
+const AllClusters = "*"
+
+const (
+	realtimeWriteTimeout = 10 * time.Second
+	realtimePongTimeout  = 60 * time.Second
+	realtimePingInterval = 30 * time.Second
+	realtimeSendBuffer   = 256
+	realtimeMaxMessage   = 4096
+)
+
+// RealtimeEvent is a message pushed to realtime clients
+type RealtimeEvent struct {
+	Type      string      `json:"type"`
+	ClusterID string      `json:"cluster_id,omitempty"`
+	Timestamp time.Time   `json:"timestamp"`
+	Data      interface{} `json:"data,omitempty"`
+}
+
+// RealtimeCommand is a message sent by a realtime client to manage its subscriptions
+type RealtimeCommand struct {
+	Action    string   `json:"action"`           // subscribe or unsubscribe
+	ClusterID string   `json:"cluster_id"`       // Cluster ID, or * for all clusters
+	Events    []string `json:"events,omitempty"` // Event types to receive, defaults to all
+}
+
+// RealtimeHub fans events out to connected realtime clients
+type RealtimeHub struct {
+	mu      sync.RWMutex
+	clients map[*realtimeClient]struct{}
+}
+
+// realtimeClient is a single WebSocket connection and its subscriptions
+type realtimeClient struct {
+	conn *websocket.Conn
+	send chan *RealtimeEvent
+	done chan struct{}
+	once sync.Once
+
+	mu            sync.RWMutex
+	subscriptions map[string]map[string]bool // Cluster ID -> event types, empty for all
+}
+
+// NewRealtimeHub creates a new realtime hub
+func NewRealtimeHub() *RealtimeHub {
+	return &RealtimeHub{
+		clients: make(map[*realtimeClient]struct{}),
+	}
+}
+
+// Publish sends an event to every client subscribed to it. Clients that fall
+// too far behind miss events rather than blocking the publisher.
+func (h *RealtimeHub) Publish(event *RealtimeEvent) {
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for client := range h.clients {
+		if !client.subscribed(event) {
+			continue
+		}
+		select {
+		case client.send <- event:
+		default:
+			logger.Debug("Dropping realtime event for slow client", zap.String("type", event.Type))
+		}
+	}
+}
+
+// Subscriptions returns the clusters that at least one client is subscribed to
+func (h *RealtimeHub) Subscriptions() map[string]bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	clusters := make(map[string]bool)
+	for client := range h.clients {
+		client.mu.RLock()
+		for clusterID := range client.subscriptions {
+			clusters[clusterID] = true
+		}
+		client.mu.RUnlock()
+	}
+	return clusters
+}
+
+// ClientCount returns the number of connected clients
+func (h *RealtimeHub) ClientCount() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.clients)
+}
+
+// Close disconnects all clients
+func (h *RealtimeHub) Close() {
+	h.mu.Lock()
+	clients := make([]*realtimeClient, 0, len(h.clients))
+	for client := range h.clients {
+		clients = append(clients, client)
+	}
+	h.clients = make(map[*realtimeClient]struct{})
+	h.mu.Unlock()
+
+	for _, client := range clients {
+		client.close()
+	}
+}
+
+// serve runs a client connection until it is closed. onSubscribe is called after
+// each subscription so the caller can send the cluster's current state.
+func (h *RealtimeHub) serve(conn *websocket.Conn, onSubscribe func(client *realtimeClient, clusterID string)) {
+	client := &realtimeClient{
+		conn:          conn,
+		send:          make(chan *RealtimeEvent, realtimeSendBuffer),
+		done:          make(chan struct{}),
+		subscriptions: make(map[string]map[string]bool),
+	}
+
+	h.mu.Lock()
+	h.clients[client] = struct{}{}
+	h.mu.Unlock()
+
+	defer func() {
+		h.mu.Lock()
+		delete(h.clients, client)
+		h.mu.Unlock()
+		client.close()
+	}()
+
+	go client.writeLoop()
+
+	conn.SetReadLimit(realtimeMaxMessage)
+	_ = conn.SetReadDeadline(time.Now().Add(realtimePongTimeout))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(realtimePongTimeout))
+	})
+
+	for {
+		var cmd RealtimeCommand
+		if err := conn.ReadJSON(&cmd); err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseNormalClosure) {
+				logger.Debug("Realtime client disconnected", zap.Error(err))
+			}
+			return
+		}
+		_ = conn.SetReadDeadline(time.Now().Add(realtimePongTimeout))
+
+		if cmd.ClusterID == "" {
+			client.push(&RealtimeEvent{Type: EventError, Data: map[string]string{"error": "cluster_id is required"}})
+			continue
+		}
+
+		switch cmd.Action {
+		case "subscribe":
+			client.subscribe(cmd.ClusterID, cmd.Events)
+			client.push(&RealtimeEvent{Type: EventSubscribed, ClusterID: cmd.ClusterID, Data: map[string]interface{}{"events": cmd.Events}})
+			if onSubscribe != nil {
+				onSubscribe(client, cmd.ClusterID)
+			}
+		case "unsubscribe":
+			client.unsubscribe(cmd.ClusterID)
+			client.push(&RealtimeEvent{Type: EventUnsubscribed, ClusterID: cmd.ClusterID})
+		default:
+			client.push(&RealtimeEvent{Type: EventError, ClusterID: cmd.ClusterID, Data: map[string]string{"error": "unknown action: " + cmd.Action}})
+		}
+	}
+}
+
+func (c *realtimeClient) subscribe(clusterID string, events []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	filter := make(map[string]bool, len(events))
+	for _, event := range events {
+		filter[event] = true
+	}
+	c.subscriptions[clusterID] = filter
+}
+
+func (c *realtimeClient) unsubscribe(clusterID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.subscriptions, clusterID)
+}
+
+// subscribed checks if the client wants an event, either through a subscription to
+// the event's cluster or to all clusters
+func (c *realtimeClient) subscribed(event *RealtimeEvent) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, clusterID := range []string{event.ClusterID, AllClusters} {
+		filter, exists := c.subscriptions[clusterID]
+		if exists && (len(filter) == 0 || filter[event.Type]) {
+			return true
+		}
+	}
+	return false
+}
+
+// push queues an event for this client only
+func (c *realtimeClient) push(event *RealtimeEvent) {
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+	select {
+	case c.send <- event:
+	case <-c.done:
+	default:
+	}
+}
+
+func (c *realtimeClient) writeLoop() {
+	ticker := time.NewTicker(realtimePingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case event := <-c.send:
+			_ = c.conn.SetWriteDeadline(time.Now().Add(realtimeWriteTimeout))
+			if err := c.conn.WriteJSON(event); err != nil {
+				c.close()
+				return
+			}
+		case <-ticker.C:
+			_ = c.conn.SetWriteDeadline(time.Now().Add(realtimeWriteTimeout))
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				c.close()
+				return
+			}
+		case <-c.done:
+			return
+		}
+	}
+}
+
+func (c *realtimeClient) close() {
+	c.once.Do(func() {
+		close(c.done)
+		c.conn.Close()
+	})
+}
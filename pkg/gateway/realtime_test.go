@@ -0,0 +1,69 @@
+package gateway
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestRealtimeHubSubscriptions(t *testing.T) {
+	hub := NewRealtimeHub()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := realtimeUpgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		hub.serve(conn, nil)
+	}))
+	defer server.Close()
+	defer hub.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer conn.Close()
+
+	read := func() *RealtimeEvent {
+		_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		var event RealtimeEvent
+		if err := conn.ReadJSON(&event); err != nil {
+			t.Fatalf("Failed to read event: %v", err)
+		}
+		return &event
+	}
+
+	if err := conn.WriteJSON(RealtimeCommand{Action: "subscribe", ClusterID: "c1", Events: []string{EventActivity}}); err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+	if event := read(); event.Type != EventSubscribed || event.ClusterID != "c1" {
+		t.Fatalf("Expected subscription ack, got %+v", event)
+	}
+	if subscriptions := hub.Subscriptions(); !subscriptions["c1"] {
+		t.Errorf("Expected hub to report subscription to c1, got %v", subscriptions)
+	}
+
+	hub.Publish(&RealtimeEvent{Type: EventMetrics, ClusterID: "c1"})  // Filtered by event type
+	hub.Publish(&RealtimeEvent{Type: EventActivity, ClusterID: "c2"}) // Other cluster
+	hub.Publish(&RealtimeEvent{Type: EventActivity, ClusterID: "c1", Data: "entry"})
+
+	if event := read(); event.Type != EventActivity || event.ClusterID != "c1" || event.Data != "entry" {
+		t.Errorf("Expected only the c1 activity event, got %+v", event)
+	}
+
+	if err := conn.WriteJSON(RealtimeCommand{Action: "subscribe", ClusterID: AllClusters}); err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+	if event := read(); event.Type != EventSubscribed || event.ClusterID != AllClusters {
+		t.Fatalf("Expected subscription ack, got %+v", event)
+	}
+
+	hub.Publish(&RealtimeEvent{Type: EventProvisioning})
+	if event := read(); event.Type != EventProvisioning {
+		t.Errorf("Expected provisioning event for all-cluster subscriber, got %+v", event)
+	}
+}
@@ -0,0 +1,188 @@
+package gateway
+
+import (
+	"context"
+	"sort"
+
+	"go.uber.org/zap"
+
+	"github.com/akmadan/throome/internal/logger"
+	"github.com/akmadan/throome/pkg/provisioner"
+)
+
+// ReconcileReport describes how the containers found on the provisioner's hosts were
+// matched to the stored clusters
+type ReconcileReport struct {
+	Adopted []string                     // Services given back their container, as cluster_id/service
+	Missing []string                     // Services whose container was not found, as cluster_id/service
+	Orphans []provisioner.ManagedService // Containers of no stored service
+	Removed int                          // Orphans removed
+}
+
+// hostListing is the containers one provisioner found, and the clusters it runs
+type hostListing struct {
+	lister   provisioner.Provisioner
+	clusters []string
+	services []provisioner.ManagedService
+}
+
+// ReconcileContainers matches the containers Throome provisioned to the stored
+// clusters. A service whose container ID is missing or stale adopts the container
+// provisioned for it on its host. Containers no stored service refers to, left by
+// deleted clusters or by crashes while provisioning, are reported as orphans, and
+// removed when removeOrphans is set.
+func (g *Gateway) ReconcileContainers(ctx context.Context, removeOrphans bool) *ReconcileReport {
+	report := &ReconcileReport{}
+	if g.provisioner == nil {
+		return report
+	}
+
+	g.updateMu.Lock()
+	defer g.updateMu.Unlock()
+
+	// Clusters are grouped by the provisioner of their Docker host
+	configs := g.clusterManager.GetAllConfigs()
+	clusterIDs := make([]string, 0, len(configs))
+	for clusterID := range configs {
+		clusterIDs = append(clusterIDs, clusterID)
+	}
+	sort.Strings(clusterIDs)
+
+	listings := []*hostListing{{lister: g.provisioner}}
+	for _, clusterID := range clusterIDs {
+		lister, err := g.clusterProvisioner(configs[clusterID])
+		if err != nil {
+			logger.Warn("Skipping containers of cluster", zap.String("cluster_id", clusterID), zap.Error(err))
+			continue
+		}
+		var listing *hostListing
+		for _, existing := range listings {
+			if existing.lister == lister {
+				listing = existing
+			}
+		}
+		if listing == nil {
+			listing = &hostListing{lister: lister}
+			listings = append(listings, listing)
+		}
+		listing.clusters = append(listing.clusters, clusterID)
+	}
+
+	// Containers stored services refer to are not orphans
+	referenced := make(map[string]bool)
+	for _, config := range configs {
+		for _, serviceConfig := range config.Services {
+			for _, containerID := range serviceConfig.ContainerIDs() {
+				referenced[containerID] = true
+			}
+		}
+	}
+
+	listed := make(map[string]bool)
+	for _, listing := range listings {
+		services, err := listing.lister.ListServices(ctx)
+		if err != nil {
+			logger.Warn("Failed to list provisioned containers", zap.Error(err))
+			continue
+		}
+		listing.services = services
+		for _, service := range services {
+			listed[service.ContainerID] = true
+		}
+	}
+
+	// Adopt containers for services whose container is gone from their host
+	for _, listing := range listings {
+		if listing.services == nil {
+			continue
+		}
+		for _, clusterID := range listing.clusters {
+			config := configs[clusterID]
+			var adopted map[string]string
+			for serviceName, serviceConfig := range config.Services {
+				if !serviceConfig.Provision || (serviceConfig.ContainerID != "" && listed[serviceConfig.ContainerID]) {
+					continue
+				}
+				name := clusterID + "/" + serviceName
+				containerID := ""
+				for _, service := range listing.services {
+					if service.Cluster == config.Name && service.Service == serviceName && !referenced[service.ContainerID] {
+						containerID = service.ContainerID
+						break
+					}
+				}
+				if containerID == "" {
+					if serviceConfig.ContainerID != "" {
+						logger.Warn("Container of service not found",
+							zap.String("cluster_id", clusterID),
+							zap.String("service", serviceName),
+							zap.String("container_id", serviceConfig.ContainerID),
+						)
+						report.Missing = append(report.Missing, name)
+					}
+					continue
+				}
+
+				if adopted == nil {
+					adopted = make(map[string]string)
+				}
+				adopted[serviceName] = containerID
+				referenced[containerID] = true
+			}
+			if adopted == nil {
+				continue
+			}
+
+			updated := config.Clone()
+			for serviceName, containerID := range adopted {
+				serviceConfig := updated.Services[serviceName]
+				serviceConfig.ContainerID = containerID
+				updated.Services[serviceName] = serviceConfig
+			}
+			if err := g.clusterManager.Update(clusterID, updated); err != nil {
+				logger.Error("Failed to adopt containers", zap.String("cluster_id", clusterID), zap.Error(err))
+				continue
+			}
+			for serviceName, containerID := range adopted {
+				logger.Info("Container adopted",
+					zap.String("cluster_id", clusterID),
+					zap.String("service", serviceName),
+					zap.String("container_id", containerID),
+				)
+				report.Adopted = append(report.Adopted, clusterID+"/"+serviceName)
+			}
+		}
+	}
+
+	// Whatever is left over belongs to no stored service
+	for _, listing := range listings {
+		for _, service := range listing.services {
+			if referenced[service.ContainerID] {
+				continue
+			}
+			referenced[service.ContainerID] = true // Hosts may be listed by several provisioners
+			report.Orphans = append(report.Orphans, service)
+
+			fields := []zap.Field{
+				zap.String("container_id", service.ContainerID),
+				zap.String("cluster", service.Cluster),
+				zap.String("service", service.Service),
+				zap.String("status", service.Status),
+			}
+			if !removeOrphans {
+				logger.Warn("Orphaned container found, set gateway.provisioner.remove_orphans to remove it", fields...)
+				continue
+			}
+			if err := listing.lister.RemoveService(ctx, service.ContainerID); err != nil {
+				logger.Error("Failed to remove orphaned container", append(fields, zap.Error(err))...)
+				continue
+			}
+			logger.Info("Orphaned container removed", fields...)
+			report.Removed++
+		}
+	}
+
+	sort.Strings(report.Adopted)
+	sort.Strings(report.Missing)
+	return report
+}
@@ -0,0 +1,65 @@
+package gateway
+
+import (
+	"context"
+	"testing"
+
+	"github.com/akmadan/throome/pkg/cluster"
+	"github.com/akmadan/throome/pkg/provisioner"
+)
+
+// fakeHost lists the containers of a host
+type fakeHost struct {
+	fakeContainers
+	services []provisioner.ManagedService
+}
+
+func (f *fakeHost) ListServices(ctx context.Context) ([]provisioner.ManagedService, error) {
+	return f.services, nil
+}
+
+func TestReconcileContainers(t *testing.T) {
+	gw := newTestGateway(t)
+	ctx := context.Background()
+	clusterID, err := gw.CreateCluster(ctx, "reconcile", &cluster.Config{
+		Services: map[string]cluster.ServiceConfig{
+			"kept":    {Type: "test-update", Host: "localhost", Port: 9821, Provision: true, ContainerID: "c1"},
+			"lost":    {Type: "test-update", Host: "localhost", Port: 9822, Provision: true, ContainerID: "gone"},
+			"missing": {Type: "test-update", Host: "localhost", Port: 9823, Provision: true, ContainerID: "gone-too"},
+			"remote":  {Type: "test-update", Host: "localhost", Port: 9824},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create cluster: %v", err)
+	}
+
+	host := &fakeHost{services: []provisioner.ManagedService{
+		{ContainerID: "c1", Cluster: "reconcile", Service: "kept"},
+		{ContainerID: "c2", Cluster: "reconcile", Service: "lost"},
+		{ContainerID: "c3", Cluster: "deleted", Service: "cache"},
+	}}
+	gw.SetProvisioner(host)
+	defer gw.SetProvisioner(nil)
+
+	report := gw.ReconcileContainers(ctx, false)
+	if len(report.Adopted) != 1 || report.Adopted[0] != clusterID+"/lost" {
+		t.Errorf("Expected the lost service to adopt its container, got %v", report.Adopted)
+	}
+	if len(report.Missing) != 1 || report.Missing[0] != clusterID+"/missing" {
+		t.Errorf("Expected the missing container to be reported, got %v", report.Missing)
+	}
+	if len(report.Orphans) != 1 || report.Orphans[0].ContainerID != "c3" || report.Removed != 0 {
+		t.Errorf("Expected the orphan to be flagged only, got %+v", report)
+	}
+	if config, _ := gw.GetClusterConfig(clusterID); config.Services["lost"].ContainerID != "c2" {
+		t.Errorf("Expected the adopted container to be stored, got %s", config.Services["lost"].ContainerID)
+	}
+
+	report = gw.ReconcileContainers(ctx, true)
+	if len(report.Adopted) != 0 || report.Removed != 1 {
+		t.Errorf("Expected only the orphan to be removed, got %+v", report)
+	}
+	if len(host.calls) != 1 || host.calls[0] != "remove c3" {
+		t.Errorf("Expected the orphan to be removed, got %v", host.calls)
+	}
+}
@@ -0,0 +1,127 @@
+package gateway
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+
+	"github.com/akmadan/throome/internal/config"
+	"github.com/akmadan/throome/internal/logger"
+	"go.uber.org/zap"
+)
+
+// liveSettings are the settings of the configuration that apply while the gateway
+// runs, so reloading the configuration changes them without a restart
+type liveSettings struct {
+	corsOrigins   []string
+	metrics       bool
+	maxStreamRows int
+}
+
+func newLiveSettings(cfg *config.AppConfig) *liveSettings {
+	return &liveSettings{
+		corsOrigins:   cfg.Server.CORS.AllowedOrigins,
+		metrics:       cfg.Monitoring.Enabled,
+		maxStreamRows: cfg.Gateway.MaxStreamRows,
+	}
+}
+
+// settings returns the settings currently in effect
+func (s *Server) settings() *liveSettings {
+	if settings := s.live.Load(); settings != nil {
+		return settings
+	}
+	return newLiveSettings(s.config)
+}
+
+// SetConfigLoader sets how ReloadConfig reads the configuration again, typically
+// from the file the gateway was started with
+func (s *Server) SetConfigLoader(load func() (*config.AppConfig, error)) {
+	s.loadConfig = load
+}
+
+// ReloadConfig reads the configuration again and applies it like Reload
+func (s *Server) ReloadConfig() error {
+	if s.loadConfig == nil {
+		return fmt.Errorf("gateway was started without a configuration file")
+	}
+	cfg, err := s.loadConfig()
+	if err != nil {
+		return err
+	}
+	return s.Reload(cfg)
+}
+
+// Reload applies a new configuration without restarting the gateway or touching the
+// connections of its clusters. The log level, allowed CORS origins, whether metrics
+// are recorded and the streamed row limit change right away. Every other setting,
+// including authentication, alerting and the listening ports, is logged as needing
+// a restart and keeps its current value until then.
+func (s *Server) Reload(cfg *config.AppConfig) error {
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+
+	if err := logger.SetLevel(cfg.Logging.Level); err != nil {
+		return err
+	}
+	s.live.Store(newLiveSettings(cfg))
+	if pending := restartSettings(s.config, cfg); len(pending) > 0 {
+		logger.Warn("Reloaded settings need a restart to apply", zap.Strings("settings", pending))
+	}
+
+	logger.Info("Configuration reloaded", zap.String("log_level", cfg.Logging.Level))
+	return nil
+}
+
+// restartSettings lists the sections of a configuration that differ from the one the
+// gateway started with in settings that only apply on start
+func restartSettings(started, reloaded *config.AppConfig) []string {
+	// Compare copies with the live settings of the reloaded configuration, so
+	// differences in those are not reported
+	startedCopy, reloadedCopy := *started, *reloaded
+	reloadedCopy.Server.CORS = startedCopy.Server.CORS
+	reloadedCopy.Monitoring.Enabled = startedCopy.Monitoring.Enabled
+	reloadedCopy.Gateway.MaxStreamRows = startedCopy.Gateway.MaxStreamRows
+	reloadedCopy.Logging.Level = startedCopy.Logging.Level
+
+	pending := make([]string, 0)
+	sections := []struct {
+		name              string
+		started, reloaded interface{}
+	}{
+		{"server", startedCopy.Server, reloadedCopy.Server},
+		{"gateway", startedCopy.Gateway, reloadedCopy.Gateway},
+		{"dashboard", startedCopy.Dashboard, reloadedCopy.Dashboard},
+		{"monitoring", startedCopy.Monitoring, reloadedCopy.Monitoring},
+		{"logging", startedCopy.Logging, reloadedCopy.Logging},
+		{"auth", startedCopy.Auth, reloadedCopy.Auth},
+		{"alerting", startedCopy.Alerting, reloadedCopy.Alerting},
+	}
+	for _, section := range sections {
+		if !reflect.DeepEqual(section.started, section.reloaded) {
+			pending = append(pending, section.name)
+		}
+	}
+	return pending
+}
+
+// handleReloadConfig reloads the gateway's configuration file
+func (s *Server) handleReloadConfig(w http.ResponseWriter, r *http.Request) {
+	if s.loadConfig == nil {
+		s.errorResponse(w, http.StatusConflict, "Gateway was started without a configuration file", nil)
+		return
+	}
+
+	if err := s.ReloadConfig(); err != nil {
+		s.errorResponse(w, http.StatusBadRequest, "Failed to reload configuration", err)
+		return
+	}
+
+	s.jsonResponse(w, http.StatusOK, map[string]interface{}{
+		"message": "Configuration reloaded successfully",
+	})
+}
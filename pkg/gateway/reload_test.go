@@ -0,0 +1,74 @@
+package gateway
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/akmadan/throome/internal/config"
+)
+
+func TestReload(t *testing.T) {
+	s := &Server{config: config.DefaultConfig()}
+	handler := s.corsMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	allowOrigin := func(origin string) string {
+		req := httptest.NewRequest("GET", "/api/v1/clusters", nil)
+		req.Header.Set("Origin", origin)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Header().Get("Access-Control-Allow-Origin")
+	}
+
+	if origin := allowOrigin("https://app.example.com"); origin != "*" {
+		t.Errorf("Expected any origin to be allowed by default, got %q", origin)
+	}
+
+	reloaded := config.DefaultConfig()
+	reloaded.Server.CORS.AllowedOrigins = []string{"https://app.example.com"}
+	reloaded.Gateway.MaxStreamRows = 10
+	if err := s.Reload(reloaded); err != nil {
+		t.Fatalf("Failed to reload configuration: %v", err)
+	}
+	if origin := allowOrigin("https://app.example.com"); origin != "https://app.example.com" {
+		t.Errorf("Expected the allowed origin to be echoed, got %q", origin)
+	}
+	if origin := allowOrigin("https://other.example.com"); origin != "" {
+		t.Errorf("Expected other origins to be refused, got %q", origin)
+	}
+	if limit := s.settings().maxStreamRows; limit != 10 {
+		t.Errorf("Expected the reloaded row limit, got %d", limit)
+	}
+
+	invalid := config.DefaultConfig()
+	invalid.Logging.Level = "verbose"
+	if err := s.Reload(invalid); err == nil {
+		t.Error("Expected an invalid configuration to be rejected")
+	}
+	if limit := s.settings().maxStreamRows; limit != 10 {
+		t.Errorf("Expected a rejected configuration to change nothing, got a limit of %d", limit)
+	}
+
+	if err := s.ReloadConfig(); err == nil {
+		t.Error("Expected reloading without a configuration file to fail")
+	}
+}
+
+func TestRestartSettings(t *testing.T) {
+	started := config.DefaultConfig()
+
+	reloaded := config.DefaultConfig()
+	reloaded.Server.CORS.AllowedOrigins = []string{"https://app.example.com"}
+	reloaded.Logging.Level = "debug"
+	reloaded.Monitoring.Enabled = false
+	if pending := restartSettings(started, reloaded); len(pending) != 0 {
+		t.Errorf("Expected live settings not to need a restart, got %v", pending)
+	}
+
+	reloaded.Server.Port = 9100
+	reloaded.Auth.Enabled = true
+	reloaded.Alerting.Enabled = true
+	pending := restartSettings(started, reloaded)
+	if len(pending) != 3 || pending[0] != "server" || pending[1] != "auth" || pending[2] != "alerting" {
+		t.Errorf("Expected server, auth and alerting to need a restart, got %v", pending)
+	}
+}
@@ -0,0 +1,322 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"go.uber.org/zap"
+
+	"github.com/akmadan/throome/internal/logger"
+	"github.com/akmadan/throome/pkg/adapters"
+	"github.com/akmadan/throome/pkg/cluster"
+	"github.com/akmadan/throome/pkg/router"
+)
+
+// MaxReplicas is the largest number of replicas a service can be scaled to
+const MaxReplicas = 10
+
+// sameConnection reports whether two configurations of a service connect to it the
+// same way. Replicas are connected separately, so they are not compared.
+func sameConnection(current, config cluster.ServiceConfig) bool {
+	current.Replicas, config.Replicas = nil, nil
+	return reflect.DeepEqual(current, config)
+}
+
+// replicaServiceConfig returns the configuration of a service pointed at one of its
+// replicas
+func replicaServiceConfig(serviceConfig cluster.ServiceConfig, replica cluster.ReplicaConfig) cluster.ServiceConfig {
+	replicaConfig := serviceConfig
+	replicaConfig.Host = replica.Host
+	replicaConfig.Port = replica.Port
+	replicaConfig.ContainerID = replica.ContainerID
+	replicaConfig.Replicas = nil
+	if replica.Weight > 0 {
+		replicaConfig.Weight = replica.Weight
+	}
+	return replicaConfig
+}
+
+// replicaName returns the name of the replica of a service at an index, which its
+// container is provisioned and its health is checked as
+func replicaName(serviceName string, index int) string {
+	return fmt.Sprintf("%s-replica-%d", serviceName, index+1)
+}
+
+// syncReplicas connects the replicas of a cluster's services and hands them to its
+// router. Replicas unchanged since current keep their connections and those no longer
+// configured are disconnected. current is nil for clusters being loaded. Caller must
+// hold the lock.
+func (g *Gateway) syncReplicas(ctx context.Context, clusterID string, current, config *cluster.Config) {
+	connected := g.replicas[clusterID]
+	clusterReplicas := make(map[string][]adapters.Adapter)
+
+	for serviceName, serviceConfig := range config.Services {
+		if len(serviceConfig.Replicas) == 0 {
+			continue
+		}
+		var previous cluster.ServiceConfig
+		var kept []adapters.Adapter
+		if current != nil {
+			previous = current.Services[serviceName]
+			if sameConnection(previous, serviceConfig) {
+				kept = connected[serviceName]
+			}
+		}
+
+		replicas := make([]adapters.Adapter, len(serviceConfig.Replicas))
+		for i, replica := range serviceConfig.Replicas {
+			if i < len(kept) && kept[i] != nil && i < len(previous.Replicas) && previous.Replicas[i] == replica {
+				replicas[i] = kept[i]
+				kept[i] = nil
+				continue
+			}
+			replicas[i] = g.connectReplica(ctx, clusterID, serviceName, replicaServiceConfig(serviceConfig, replica))
+		}
+		clusterReplicas[serviceName] = replicas
+	}
+
+	// Whatever was not kept belongs to replicas that are gone or changed, whose health
+	// is checked afresh
+	for serviceName, replicas := range connected {
+		for i, replica := range replicas {
+			if replica != nil {
+				g.disconnectReplica(ctx, clusterID, serviceName, replica)
+				g.healthChecker.ForgetService(clusterID, replicaName(serviceName, i))
+			}
+		}
+	}
+
+	if len(clusterReplicas) == 0 {
+		delete(g.replicas, clusterID)
+	} else {
+		g.replicas[clusterID] = clusterReplicas
+	}
+
+	clusterRouter, exists := g.routers[clusterID]
+	if !exists {
+		return
+	}
+	for serviceName, serviceConfig := range config.Services {
+		var routed []router.Replica
+		for i, replica := range clusterReplicas[serviceName] {
+			if replica != nil {
+				routed = append(routed, router.Replica{Adapter: replica, Role: serviceConfig.Replicas[i].Role, Name: replicaName(serviceName, i)})
+			}
+		}
+		clusterRouter.SetReplicas(serviceName, routed)
+	}
+}
+
+// connectReplica creates and connects the adapter of a service's replica, returning
+// nil when it cannot connect. Caller must hold the lock.
+func (g *Gateway) connectReplica(ctx context.Context, clusterID, serviceName string, replicaConfig cluster.ServiceConfig) adapters.Adapter {
+	fields := []zap.Field{
+		zap.String("cluster_id", clusterID),
+		zap.String("service", serviceName),
+		zap.String("host", replicaConfig.Host),
+		zap.Int("port", replicaConfig.Port),
+	}
+
+	adapter, err := g.adapterFactory.Create(&replicaConfig)
+	if err != nil {
+		logger.Error("Failed to create replica adapter", append(fields, zap.Error(err))...)
+		return nil
+	}
+
+	// Activity of replicas is recorded as the service's
+	if baseAdapter, ok := adapter.(interface {
+		SetActivityLogger(logger adapters.ActivityLogger, clusterID, serviceName string)
+	}); ok {
+		baseAdapter.SetActivityLogger(g.activityLogger, clusterID, serviceName)
+	}
+
+	if err := adapter.Connect(ctx); err != nil {
+		logger.Error("Failed to connect replica", append(fields, zap.Error(err))...)
+		return nil
+	}
+
+	logger.Info("Connected to replica", fields...)
+	return adapter
+}
+
+// disconnectReplica disconnects the adapter of a service's replica. Caller must hold
+// the lock.
+func (g *Gateway) disconnectReplica(ctx context.Context, clusterID, serviceName string, adapter adapters.Adapter) {
+	if err := adapter.Disconnect(ctx); err != nil {
+		logger.Error("Failed to disconnect replica",
+			zap.String("cluster_id", clusterID),
+			zap.String("service", serviceName),
+			zap.Error(err),
+		)
+	}
+}
+
+// disconnectReplicas disconnects the replicas of every service of a cluster. Caller
+// must hold the lock.
+func (g *Gateway) disconnectReplicas(ctx context.Context, clusterID string) {
+	for serviceName, replicas := range g.replicas[clusterID] {
+		for _, replica := range replicas {
+			if replica != nil {
+				g.disconnectReplica(ctx, clusterID, serviceName, replica)
+			}
+		}
+	}
+	delete(g.replicas, clusterID)
+}
+
+// replicasConnected reports which of a service's replicas are connected, in the order
+// they are configured
+func (g *Gateway) replicasConnected(clusterID, serviceName string) []bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	replicas := g.replicas[clusterID][serviceName]
+	connected := make([]bool, len(replicas))
+	for i, replica := range replicas {
+		connected[i] = replica != nil && replica.IsConnected()
+	}
+	return connected
+}
+
+// routeAdapter returns the adapter of a service that a request is routed to by its
+// access: one of the service's replicas for reads, or the service's own adapter,
+// which is also returned while none of them can take the request. It fails with
+// router.ErrCircuitOpen while the circuit breakers of all of them are open.
+func (g *Gateway) routeAdapter(ctx context.Context, clusterID, serviceName string, adapter adapters.Adapter) (adapters.Adapter, error) {
+	clusterRouter, err := g.GetRouter(clusterID)
+	if err != nil {
+		return adapter, nil
+	}
+	routed, err := clusterRouter.Route(ctx, serviceName, adapter.GetType())
+	if errors.Is(err, router.ErrCircuitOpen) {
+		return nil, err
+	}
+	if err != nil {
+		return adapter, nil
+	}
+	return routed, nil
+}
+
+// recordFailure records a failed request on the circuit breaker of an adapter
+func (g *Gateway) recordFailure(clusterID string, adapter adapters.Adapter) {
+	if clusterRouter, err := g.GetRouter(clusterID); err == nil {
+		clusterRouter.Record(adapter, false)
+	}
+}
+
+// circuitState returns the state of the circuit breaker of a service, and false when
+// it has none
+func (g *Gateway) circuitState(clusterID, serviceName string) (router.CircuitState, bool) {
+	clusterRouter, err := g.GetRouter(clusterID)
+	if err != nil {
+		return "", false
+	}
+	adapter, err := g.GetAdapter(clusterID, serviceName)
+	if err != nil {
+		return "", false
+	}
+	return clusterRouter.Circuit(adapter)
+}
+
+// ScaleService scales a provisioned service to the given number of replicas, each
+// running in a container of its own next to the service's. New replicas are
+// provisioned and connected, and requests for the service are routed to them as well
+// as to the service. Scaling down removes the most recently added replicas and their
+// containers. The service's updated configuration is returned.
+func (g *Gateway) ScaleService(ctx context.Context, clusterID, serviceName string, replicas int) (*cluster.ServiceConfig, error) {
+	if replicas < 0 || replicas > MaxReplicas {
+		return nil, fmt.Errorf("replicas must be between 0 and %d", MaxReplicas)
+	}
+	if g.provisioner == nil {
+		return nil, fmt.Errorf("provisioner not available")
+	}
+
+	current, err := g.clusterManager.Get(clusterID)
+	if err != nil {
+		return nil, err
+	}
+	if current.IsArchived() {
+		return nil, fmt.Errorf("cluster is archived: %s", clusterID)
+	}
+	serviceConfig, exists := current.Services[serviceName]
+	if !exists {
+		return nil, fmt.Errorf("service not found: %s", serviceName)
+	}
+	if serviceConfig.ContainerID == "" {
+		return nil, fmt.Errorf("service %s is not provisioned by Throome", serviceName)
+	}
+
+	// New replicas are provisioned like services of their own, before taking the
+	// update lock since pulling images can take minutes
+	pending := &cluster.Config{
+		ClusterID: clusterID,
+		Name:      current.Name,
+		Docker:    current.Docker,
+		Services:  make(map[string]cluster.ServiceConfig),
+	}
+	for i := len(serviceConfig.Replicas); i < replicas; i++ {
+		replicaConfig := serviceConfig
+		replicaConfig.Provision = true
+		replicaConfig.ContainerID = ""
+		replicaConfig.Replicas = nil
+		pending.Services[replicaName(serviceName, i)] = replicaConfig
+	}
+	if err := g.provisionServices(ctx, pending); err != nil {
+		return nil, err
+	}
+
+	g.updateMu.Lock()
+	defer g.updateMu.Unlock()
+
+	latest, err := g.clusterManager.Get(clusterID)
+	if err == nil {
+		serviceConfig, exists = latest.Services[serviceName]
+		if !exists {
+			err = fmt.Errorf("service not found: %s", serviceName)
+		}
+	}
+	if err != nil {
+		g.removeProvisioned(ctx, pending)
+		return nil, err
+	}
+
+	// The service may have been scaled meanwhile, so containers of replicas dropped
+	// from it and provisioned ones it does not need are removed
+	kept := min(replicas, len(serviceConfig.Replicas))
+	unused := pending.Clone()
+	unused.Services[serviceName] = cluster.ServiceConfig{Replicas: serviceConfig.Replicas[kept:]}
+	scaled := make([]cluster.ReplicaConfig, kept, replicas)
+	copy(scaled, serviceConfig.Replicas)
+	for i := kept; i < replicas; i++ {
+		name := replicaName(serviceName, i)
+		replicaConfig, exists := pending.Services[name]
+		if !exists {
+			break
+		}
+		delete(unused.Services, name)
+		scaled = append(scaled, cluster.ReplicaConfig{
+			Host:        replicaConfig.Host,
+			Port:        replicaConfig.Port,
+			Role:        cluster.RoleReplica,
+			ContainerID: replicaConfig.ContainerID,
+		})
+	}
+
+	config := latest.Clone()
+	serviceConfig.Replicas = scaled
+	config.Services[serviceName] = serviceConfig
+	if err := g.updateCluster(ctx, clusterID, latest, config); err != nil {
+		g.removeProvisioned(ctx, pending)
+		return nil, err
+	}
+	g.removeProvisioned(ctx, unused)
+
+	logger.Info("Service scaled",
+		zap.String("cluster_id", clusterID),
+		zap.String("service", serviceName),
+		zap.Int("replicas", len(scaled)),
+	)
+	return &serviceConfig, nil
+}
@@ -0,0 +1,65 @@
+package gateway
+
+import (
+	"context"
+	"testing"
+
+	"github.com/akmadan/throome/pkg/cluster"
+)
+
+func TestScaleService(t *testing.T) {
+	gw := newTestGateway(t)
+	containers := &fakeProvisioner{}
+	gw.SetProvisioner(containers)
+	defer gw.SetProvisioner(nil)
+
+	ctx := context.Background()
+	clusterID, err := gw.CreateCluster(ctx, "scaled", &cluster.Config{
+		Services: map[string]cluster.ServiceConfig{
+			"db":     {Type: "test-update", Port: 9861, Provision: true},
+			"remote": {Type: "test-update", Host: "localhost", Port: 9862},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create cluster: %v", err)
+	}
+	defer func() { _ = gw.PurgeCluster(ctx, clusterID) }()
+	primary, _ := gw.GetAdapter(clusterID, "db")
+
+	scaled, err := gw.ScaleService(ctx, clusterID, "db", 2)
+	if err != nil {
+		t.Fatalf("Failed to scale service: %v", err)
+	}
+	if len(scaled.Replicas) != 2 || scaled.Replicas[1].ContainerID != "scaled-db-replica-2" {
+		t.Fatalf("Expected two provisioned replicas, got %+v", scaled.Replicas)
+	}
+	config, _ := gw.GetClusterConfig(clusterID)
+	if len(config.Services["db"].Replicas) != 2 {
+		t.Errorf("Expected the replicas to be saved, got %+v", config.Services["db"].Replicas)
+	}
+	if replicas := gw.replicas[clusterID]["db"]; len(replicas) != 2 || replicas[0] == nil || replicas[1] == nil {
+		t.Errorf("Expected both replicas to be connected, got %v", replicas)
+	}
+	if adapter, _ := gw.GetAdapter(clusterID, "db"); adapter != primary {
+		t.Error("Expected the service to keep its connection while scaling")
+	}
+
+	kept := gw.replicas[clusterID]["db"][0]
+	containers.calls = nil
+	if _, err := gw.ScaleService(ctx, clusterID, "db", 1); err != nil {
+		t.Fatalf("Failed to scale service down: %v", err)
+	}
+	if len(containers.calls) != 1 || containers.calls[0] != "remove scaled-db-replica-2" {
+		t.Errorf("Expected the last replica's container to be removed, got %v", containers.calls)
+	}
+	if replicas := gw.replicas[clusterID]["db"]; len(replicas) != 1 || replicas[0] != kept {
+		t.Errorf("Expected the first replica to keep its connection, got %v", replicas)
+	}
+
+	if _, err := gw.ScaleService(ctx, clusterID, "remote", 1); err == nil {
+		t.Error("Expected services not provisioned by Throome to be refused")
+	}
+	if _, err := gw.ScaleService(ctx, clusterID, "db", MaxReplicas+1); err == nil {
+		t.Error("Expected scaling over the limit to be refused")
+	}
+}
@@ -0,0 +1,141 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	"github.com/akmadan/throome/internal/utils"
+	"github.com/akmadan/throome/pkg/adapters"
+)
+
+// Backoff between the attempts of a data operation
+const (
+	retryInitialDelay = 50 * time.Millisecond
+	retryMaxDelay     = time.Second
+)
+
+// attemptKey is the context key of the attempt of a data operation
+type attemptKey struct{}
+
+// attempt records the service an attempt of a data operation was routed to
+type attempt struct {
+	clusterID   string
+	serviceName string
+	serviceType string
+}
+
+// noteAttempt records the service an attempt of a data operation was routed to, so
+// its retries are counted against it
+func noteAttempt(ctx context.Context, clusterID, serviceName, serviceType string) {
+	if current, ok := ctx.Value(attemptKey{}).(*attempt); ok {
+		*current = attempt{clusterID: clusterID, serviceName: serviceName, serviceType: serviceType}
+	}
+}
+
+// retryableError marks a transient failure for utils.Retry, keeping the error it
+// wraps as is
+type retryableError struct {
+	err  error
+	kind error
+}
+
+func (e *retryableError) Error() string        { return e.err.Error() }
+func (e *retryableError) Unwrap() error        { return e.err }
+func (e *retryableError) Is(target error) bool { return target == e.kind }
+
+// retryOperation runs a data operation of a cluster, retrying transient failures with
+// backoff up to the retry attempts of the cluster's routing. Every attempt is bounded
+// by the routing timeout and resolves its adapter again, so it can be routed to
+// another candidate, and must finish reading its result before returning. Operations
+// that are not idempotent are only retried when they failed before reaching the
+// service.
+func retryOperation[T any](ctx context.Context, s *Server, clusterID string, idempotent bool, operation func(ctx context.Context) (T, error)) (T, error) {
+	retryConfig := utils.RetryConfig{
+		MaxAttempts:  1,
+		InitialDelay: retryInitialDelay,
+		MaxDelay:     retryMaxDelay,
+		Multiplier:   2,
+	}
+	var timeout time.Duration
+	if config, err := s.gateway.GetClusterConfig(clusterID); err == nil {
+		retryConfig.MaxAttempts += max(config.Routing.RetryAttempts, 0)
+		timeout = time.Duration(config.Routing.TimeoutMS) * time.Millisecond
+	}
+
+	attempts := 0
+	result, err := utils.RetryWithResult(ctx, retryConfig, func() (T, error) {
+		attempts++
+		var current attempt
+		attemptCtx := context.WithValue(ctx, attemptKey{}, &current)
+		if timeout > 0 {
+			var cancel context.CancelFunc
+			attemptCtx, cancel = context.WithTimeout(attemptCtx, timeout)
+			defer cancel()
+		}
+
+		result, err := operation(attemptCtx)
+		if err == nil {
+			return result, nil
+		}
+		kind := transientFailure(ctx, err, idempotent)
+		if kind == nil {
+			return result, err
+		}
+
+		// Failed attempts that are retried count against the service they ran on
+		if attempts < retryConfig.MaxAttempts && current.serviceName != "" {
+			s.gateway.collector.RecordRetry(current.clusterID, current.serviceName, current.serviceType)
+		}
+		return result, &retryableError{err: err, kind: kind}
+	})
+
+	// Callers see the failure of the last attempt
+	var retryable *retryableError
+	if errors.As(err, &retryable) {
+		err = retryable.err
+	}
+	return result, err
+}
+
+// transientFailure returns the utils error a failed attempt of an operation is
+// retried as, or nil when it is not retried. Connections that were refused or dropped
+// by fault injection never reached the service; other network failures and timeouts
+// of the attempt may have, so only idempotent operations retry them.
+func transientFailure(ctx context.Context, err error, idempotent bool) error {
+	if errors.Is(err, adapters.ErrInjectedDrop) || errors.Is(err, syscall.ECONNREFUSED) {
+		return utils.ErrConnectionFailed
+	}
+	if !idempotent {
+		return nil
+	}
+
+	// The attempt timed out while the request is still waiting for it
+	if errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil {
+		return utils.ErrOperationTimeout
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() && ctx.Err() == nil {
+		return utils.ErrOperationTimeout
+	}
+	if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNABORTED) || errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, net.ErrClosed) {
+		return utils.ErrConnectionFailed
+	}
+	return nil
+}
+
+// operationErrorResponse writes the error of a failed data operation, or the error of
+// the adapter lookup it failed on
+func (s *Server) operationErrorResponse(w http.ResponseWriter, message string, err error) {
+	var adapterErr *adapterError
+	if errors.As(err, &adapterErr) {
+		s.errorResponse(w, adapterErr.status, adapterErr.message, adapterErr.err)
+		return
+	}
+	s.errorResponse(w, http.StatusInternalServerError, message, err)
+}
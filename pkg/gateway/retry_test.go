@@ -0,0 +1,76 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"syscall"
+	"testing"
+
+	"github.com/akmadan/throome/pkg/adapters"
+	"github.com/akmadan/throome/pkg/cluster"
+)
+
+func TestRetryOperation(t *testing.T) {
+	gw := newTestGateway(t)
+	s := &Server{gateway: gw}
+
+	ctx := context.Background()
+	clusterID, err := gw.CreateCluster(ctx, "retries", &cluster.Config{
+		Routing: cluster.RoutingConfig{RetryAttempts: 2, TimeoutMS: 1000},
+		Services: map[string]cluster.ServiceConfig{
+			"store": {Type: "test-update", Host: "localhost", Port: 9882},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create cluster: %v", err)
+	}
+	defer func() { _ = gw.DeleteCluster(ctx, clusterID) }()
+	if err := gw.SetFault(clusterID, "store", adapters.FaultConfig{DropRate: 100}); err != nil {
+		t.Fatalf("Failed to inject fault: %v", err)
+	}
+
+	attempts := 0
+	_, err = retryOperation(ctx, s, clusterID, false, func(ctx context.Context) (struct{}, error) {
+		attempts++
+		_, err := s.serviceAdapter(ctx, clusterID, "store")
+		return struct{}{}, err
+	})
+	if attempts != 3 || !errors.Is(err, adapters.ErrInjectedDrop) {
+		t.Errorf("Expected dropped connections to be retried twice, got %d attempts (%v)", attempts, err)
+	}
+	if metrics := gw.GetCollector().GetServiceMetrics(clusterID, "store"); metrics == nil || metrics.Retries != 2 {
+		t.Errorf("Expected the retries to be recorded, got %+v", metrics)
+	}
+
+	attempts = 0
+	value, err := retryOperation(ctx, s, clusterID, true, func(ctx context.Context) (string, error) {
+		attempts++
+		if attempts == 1 {
+			return "", fmt.Errorf("read: %w", syscall.ECONNRESET)
+		}
+		return "value", nil
+	})
+	if err != nil || value != "value" || attempts != 2 {
+		t.Errorf("Expected a reset connection to be retried, got %q after %d attempts (%v)", value, attempts, err)
+	}
+
+	attempts = 0
+	_, err = retryOperation(ctx, s, clusterID, false, func(ctx context.Context) (struct{}, error) {
+		attempts++
+		return struct{}{}, io.ErrUnexpectedEOF
+	})
+	if attempts != 1 || !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("Expected writes that may have reached the service not to be retried, got %d attempts", attempts)
+	}
+
+	attempts = 0
+	_, err = retryOperation(ctx, s, clusterID, true, func(ctx context.Context) (struct{}, error) {
+		attempts++
+		return struct{}{}, errors.New("syntax error")
+	})
+	if attempts != 1 || err == nil {
+		t.Errorf("Expected permanent failures not to be retried, got %d attempts", attempts)
+	}
+}
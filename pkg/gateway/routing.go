@@ -0,0 +1,45 @@
+package gateway
+
+import (
+	"fmt"
+
+	"github.com/akmadan/throome/internal/logger"
+	"github.com/akmadan/throome/pkg/cluster"
+	"go.uber.org/zap"
+)
+
+// UpdateRouting replaces a cluster's routing settings and saves them to its
+// configuration. They apply to the cluster's next requests without reconnecting its
+// services, and its circuit breakers and sticky sessions are kept unless their own
+// settings change.
+func (g *Gateway) UpdateRouting(clusterID string, routing cluster.RoutingConfig) error {
+	g.updateMu.Lock()
+	defer g.updateMu.Unlock()
+
+	current, err := g.clusterManager.Get(clusterID)
+	if err != nil {
+		return err
+	}
+	if current.IsArchived() {
+		return fmt.Errorf("cluster is archived: %s", clusterID)
+	}
+
+	config := current.Clone()
+	config.Routing = routing
+	if err := g.clusterManager.Update(clusterID, config); err != nil {
+		return err
+	}
+
+	g.mu.RLock()
+	clusterRouter, exists := g.routers[clusterID]
+	g.mu.RUnlock()
+	if exists {
+		clusterRouter.UpdateRouting(routing)
+	}
+
+	logger.Info("Cluster routing updated",
+		zap.String("cluster_id", clusterID),
+		zap.String("strategy", routing.Strategy),
+	)
+	return nil
+}
@@ -0,0 +1,62 @@
+package gateway
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/akmadan/throome/internal/config"
+	"github.com/akmadan/throome/pkg/cluster"
+	"github.com/gorilla/mux"
+)
+
+func TestUpdateRouting(t *testing.T) {
+	gw := newTestGateway(t)
+	s := &Server{config: config.DefaultConfig(), gateway: gw}
+
+	ctx := context.Background()
+	clusterID, err := gw.CreateCluster(ctx, "routing", &cluster.Config{
+		Routing: cluster.RoutingConfig{FailoverEnabled: true, RetryAttempts: 2},
+		Services: map[string]cluster.ServiceConfig{
+			"store": {Type: "test-update", Host: "localhost", Port: 9883},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create cluster: %v", err)
+	}
+	defer gw.DeleteCluster(ctx, clusterID)
+
+	router := mux.NewRouter()
+	router.HandleFunc("/clusters/{cluster_id}/routing", s.handleUpdateRouting).Methods("PATCH")
+	patch := func(body string) int {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest("PATCH", "/clusters/"+clusterID+"/routing", strings.NewReader(body)))
+		return rec.Code
+	}
+
+	if code := patch(`{"strategy": "latency", "timeout_ms": 250}`); code != http.StatusOK {
+		t.Fatalf("Expected the routing to be updated, got %d", code)
+	}
+	clusterRouter, _ := gw.GetRouter(clusterID)
+	if name := clusterRouter.GetStrategy().Name(); name != "latency" {
+		t.Errorf("Expected the router to switch strategy, got %s", name)
+	}
+
+	// The change is saved, and settings left out are kept
+	if err := gw.ReloadCluster(ctx, clusterID); err != nil {
+		t.Fatalf("Failed to reload cluster: %v", err)
+	}
+	saved, _ := gw.GetClusterConfig(clusterID)
+	routing := saved.Routing
+	if routing.Strategy != "latency" || routing.TimeoutMS != 250 || routing.RetryAttempts != 2 || !routing.FailoverEnabled {
+		t.Errorf("Unexpected saved routing %+v", routing)
+	}
+
+	for _, body := range []string{`{"strategy": "random"}`, `{"retry_attempts": -1}`, `{"strategy": 1}`} {
+		if code := patch(body); code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", body, code)
+		}
+	}
+}
@@ -1,21 +1,30 @@
 package gateway
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
-	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc"
 
 	"github.com/akmadan/throome/internal/config"
 	"github.com/akmadan/throome/internal/logger"
+	"github.com/akmadan/throome/pkg/adapters"
+	"github.com/akmadan/throome/pkg/auth"
 	"github.com/akmadan/throome/pkg/cluster"
+	"github.com/akmadan/throome/pkg/monitor"
 	"github.com/akmadan/throome/pkg/provisioner"
+	"github.com/akmadan/throome/pkg/requestid"
+	"github.com/akmadan/throome/pkg/snapshot"
 	"go.uber.org/zap"
 )
 
@@ -25,27 +34,75 @@ type Server struct {
 	gateway     *Gateway
 	router      *mux.Router
 	server      *http.Server
-	provisioner *provisioner.DockerProvisioner
+	redirect    *http.Server // Redirects plain HTTP to HTTPS, if enabled
+	grpc        *grpc.Server // Serves the gRPC API, if enabled
+	idempotency *IdempotencyStore
+	compressor  *compressor // Compresses responses, if enabled
+	snapshots   *snapshot.Store
+	volumes     *snapshot.VolumeStore // Snapshots of provisioned services' data
+	keys        *auth.KeyStore
+	jwt         *auth.JWTValidator
+	openAPIOnce sync.Once
+	openAPI     map[string]interface{} // OpenAPI document, built on first request
+	startedAt   time.Time
+
+	realtime        *RealtimeHub
+	activityStreams *activityStreams
+	realtimeStop    chan struct{}
+	healthStreams   *healthStreams
+
+	live       atomic.Pointer[liveSettings] // Settings changed by reloading the configuration
+	loadConfig func() (*config.AppConfig, error)
+	reloadMu   sync.Mutex
 }
 
 // NewServer creates a new HTTP server
 func NewServer(cfg *config.AppConfig, gateway *Gateway) *Server {
 	s := &Server{
-		config:  cfg,
-		gateway: gateway,
-		router:  mux.NewRouter(),
+		config:      cfg,
+		gateway:     gateway,
+		router:      mux.NewRouter(),
+		idempotency: NewIdempotencyStore(24 * time.Hour),
+		snapshots:   snapshot.NewStore(cfg.Gateway.SnapshotsDir),
+		volumes:     snapshot.NewVolumeStore(filepath.Join(cfg.Gateway.SnapshotsDir, "volumes")),
+		realtime:    NewRealtimeHub(),
+		startedAt:   time.Now(),
 	}
 
-	// Initialize Docker provisioner (optional - continues if Docker is not available)
-	dockerProvisioner, err := provisioner.NewDockerProvisioner()
+	s.live.Store(newLiveSettings(cfg))
+	s.keys = s.newKeyStore()
+	s.jwt = s.newJWTValidator()
+	s.realtimeStop = make(chan struct{})
+	gateway.GetActivityBuffer().OnAdd(s.publishActivity)
+	s.activityStreams = newActivityStreams()
+	gateway.GetActivityBuffer().OnAdd(s.activityStreams.publish)
+	gateway.OnAutoRestart(s.publishRestart)
+	gateway.OnProvision(s.publishProvisioning)
+	s.healthStreams = newHealthStreams()
+	gateway.GetHealthChecker().OnTransition(s.healthStreams.publish)
+
+	// Initialize the provisioner (optional - continues if it is not available)
+	provisionerType := cfg.Gateway.Provisioner.Type
+	serviceProvisioner, err := newProvisioner(cfg.Gateway.Provisioner)
 	if err != nil {
-		logger.Warn("Docker provisioner not available - services must be manually started",
+		logger.Warn("Provisioner not available - services must be manually started",
+			zap.String("provisioner", provisionerType),
 			zap.Error(err),
 		)
 	} else {
-		s.provisioner = dockerProvisioner
-		gateway.SetProvisioner(dockerProvisioner)
-		logger.Info("Docker provisioner initialized successfully")
+		gateway.SetProvisioner(serviceProvisioner)
+		logger.Info("Provisioner initialized successfully", zap.String("provisioner", provisionerType))
+
+		// Containers may have been left behind or lost track of while the gateway was down
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		report := gateway.ReconcileContainers(ctx, cfg.Gateway.Provisioner.RemoveOrphans)
+		cancel()
+		logger.Info("Provisioned containers reconciled",
+			zap.Int("adopted", len(report.Adopted)),
+			zap.Int("missing", len(report.Missing)),
+			zap.Int("orphans", len(report.Orphans)),
+			zap.Int("removed", report.Removed),
+		)
 	}
 
 	s.setupRoutes()
@@ -57,54 +114,221 @@ func NewServer(cfg *config.AppConfig, gateway *Gateway) *Server {
 func (s *Server) setupRoutes() {
 	// API v1 routes
 	api := s.router.PathPrefix("/api/v1").Subrouter()
+	api.Use(s.targetServiceMiddleware)
+
+	// Cluster routes are served for all namespaces under /clusters and for a single
+	// namespace under /namespaces/{namespace}/clusters
+	s.setupClusterRoutes(api)
+	api.HandleFunc("/namespaces", s.handleListNamespaces).Methods("GET")
+	namespaced := api.PathPrefix("/namespaces/{namespace}").Subrouter()
+	namespaced.Use(s.namespaceMiddleware)
+	s.setupClusterRoutes(namespaced)
+
+	// API keys
+	api.HandleFunc("/auth/keys", s.handleListAPIKeys).Methods("GET")
+	api.HandleFunc("/auth/keys", s.idempotent(s.handleIssueAPIKey)).Methods("POST")
+	api.HandleFunc("/auth/keys/{key_id}", s.handleRevokeAPIKey).Methods("DELETE")
+
+	// Health and metrics
+	api.HandleFunc("/health", s.handleHealth).Methods("GET")
 
+	// API description
+	api.HandleFunc("/openapi.json", s.handleOpenAPI).Methods("GET")
+
+	// Activity logs
+	api.HandleFunc("/activity", s.handleGetActivity).Methods("GET")
+	api.HandleFunc("/activity/export", s.handleExportActivity).Methods("GET")
+	api.HandleFunc("/activity/stream", s.handleActivityStream).Methods("GET")
+	api.HandleFunc("/alerts", s.handleGetAlerts).Methods("GET")
+	api.HandleFunc("/alerts/rules", s.handleGetAlertRules).Methods("GET")
+
+	// Dashboard
+	api.HandleFunc("/dashboard/traffic", s.handleDashboardTraffic).Methods("GET")
+	api.HandleFunc("/dashboard/slowest", s.handleDashboardSlowest).Methods("GET")
+	api.HandleFunc("/dashboard/health", s.handleDashboardHealth).Methods("GET")
+
+	// Realtime (WebSocket)
+	api.HandleFunc("/realtime", s.handleRealtime).Methods("GET")
+
+	// Gateway configuration
+	api.HandleFunc("/admin/reload", s.handleReloadConfig).Methods("POST")
+
+	// Snapshots
+	api.HandleFunc("/snapshots", s.handleListSnapshots).Methods("GET")
+	api.HandleFunc("/snapshots/{name}", s.handleGetSnapshot).Methods("GET")
+	api.HandleFunc("/snapshots/{name}", s.handleDeleteSnapshot).Methods("DELETE")
+	api.HandleFunc("/snapshots/{name}/restore", s.idempotent(s.handleRestoreSnapshot)).Methods("POST")
+
+	// Liveness and readiness probes, outside of the API so they need no credentials
+	s.router.HandleFunc("/healthz", s.handleLiveness).Methods("GET")
+	s.router.HandleFunc("/readyz", s.handleReadiness).Methods("GET")
+
+	// Prometheus metrics endpoint, served while metrics are enabled
+	metrics := promhttp.Handler()
+	s.router.HandleFunc(s.config.Monitoring.MetricsPath, func(w http.ResponseWriter, r *http.Request) {
+		if !s.settings().metrics {
+			http.NotFound(w, r)
+			return
+		}
+		metrics.ServeHTTP(w, r)
+	})
+
+	// Middleware. Requests get their ID first, so every response carries it, and
+	// are authenticated before they are logged, so the log carries the caller's
+	// identity. Metrics wrap the rest, so rejected requests are counted too.
+	s.router.Use(s.requestIDMiddleware)
+	s.router.Use(s.metricsMiddleware)
+	if s.config.Server.Compression.Enabled {
+		s.compressor = newCompressor(s.config.Server.Compression)
+		s.router.Use(s.compressionMiddleware)
+	}
+	s.router.Use(s.corsMiddleware)
+	s.router.Use(s.authMiddleware)
+	s.router.Use(s.loggingMiddleware)
+
+	// Serve embedded UI - must be last to catch all unmatched routes
+	uiHandler := GetUIHandler()
+	s.router.PathPrefix("/").Handler(uiHandler)
+}
+
+// setupClusterRoutes registers the routes of clusters and their services
+func (s *Server) setupClusterRoutes(api *mux.Router) {
 	// Cluster management
 	api.HandleFunc("/clusters", s.handleListClusters).Methods("GET")
-	api.HandleFunc("/clusters", s.handleCreateCluster).Methods("POST")
+	api.HandleFunc("/clusters", s.idempotent(s.handleCreateCluster)).Methods("POST")
 	api.HandleFunc("/clusters/{cluster_id}", s.handleGetCluster).Methods("GET")
-	api.HandleFunc("/clusters/{cluster_id}", s.handleDeleteCluster).Methods("DELETE")
+	api.HandleFunc("/clusters/{cluster_id}", s.idempotent(s.handleUpdateCluster)).Methods("PUT")
+	api.HandleFunc("/clusters/{cluster_id}", s.idempotent(s.handleDeleteCluster)).Methods("DELETE")
+	api.HandleFunc("/clusters/{cluster_id}/reload", s.handleReloadCluster).Methods("POST")
+	api.HandleFunc("/clusters/{cluster_id}/restore", s.idempotent(s.handleRestoreCluster)).Methods("POST")
+	api.HandleFunc("/clusters/{cluster_id}/purge", s.idempotent(s.handlePurgeCluster)).Methods("POST")
+	api.HandleFunc("/clusters/{cluster_id}/drain", s.handleDrainCluster).Methods("POST")
+	api.HandleFunc("/clusters/{cluster_id}/resume", s.handleResumeCluster).Methods("POST")
+	api.HandleFunc("/clusters/{cluster_id}/routing", s.handleUpdateRouting).Methods("PATCH")
+	api.HandleFunc("/clusters/{cluster_id}/seed", s.mutating(s.handleSeedCluster)).Methods("POST")
+	api.HandleFunc("/clusters/{cluster_id}/compose", s.handleExportCompose).Methods("GET")
 
 	// Health and metrics
-	api.HandleFunc("/health", s.handleHealth).Methods("GET")
 	api.HandleFunc("/clusters/{cluster_id}/health", s.handleClusterHealth).Methods("GET")
+	api.HandleFunc("/clusters/{cluster_id}/health/stream", s.handleClusterHealthStream).Methods("GET")
 	api.HandleFunc("/clusters/{cluster_id}/metrics", s.handleClusterMetrics).Methods("GET")
+	api.HandleFunc("/clusters/{cluster_id}/metrics/history", s.handleClusterMetricsHistory).Methods("GET")
+	api.HandleFunc("/clusters/{cluster_id}/metrics/reset", s.handleResetClusterMetrics).Methods("POST")
+	api.HandleFunc("/clusters/{cluster_id}/services/{service_name}/metrics/reset", s.handleResetServiceMetrics).Methods("POST")
+	api.HandleFunc("/clusters/{cluster_id}/sla", s.handleClusterSLA).Methods("GET")
+	api.HandleFunc("/clusters/{cluster_id}/anomalies", s.handleGetAnomalies).Methods("GET")
+	api.HandleFunc("/clusters/{cluster_id}/recommendations", s.handleGetRecommendations).Methods("GET")
 
 	// Activity logs
-	api.HandleFunc("/activity", s.handleGetActivity).Methods("GET")
 	api.HandleFunc("/clusters/{cluster_id}/activity", s.handleGetClusterActivity).Methods("GET")
 	api.HandleFunc("/clusters/{cluster_id}/services/{service_name}/activity", s.handleGetServiceActivity).Methods("GET")
 
 	// Service management
+	api.HandleFunc("/clusters/{cluster_id}/services", s.idempotent(s.handleAddService)).Methods("POST")
 	api.HandleFunc("/clusters/{cluster_id}/services/{service_name}", s.handleGetServiceInfo).Methods("GET")
+	api.HandleFunc("/clusters/{cluster_id}/services/{service_name}", s.idempotent(s.handleRemoveService)).Methods("DELETE")
+	api.HandleFunc("/clusters/{cluster_id}/services/{service_name}/start", s.handleStartService).Methods("POST")
+	api.HandleFunc("/clusters/{cluster_id}/services/{service_name}/stop", s.handleStopService).Methods("POST")
+	api.HandleFunc("/clusters/{cluster_id}/services/{service_name}/restart", s.handleRestartService).Methods("POST")
+	api.HandleFunc("/clusters/{cluster_id}/services/{service_name}/scale", s.idempotent(s.handleScaleService)).Methods("POST")
 	api.HandleFunc("/clusters/{cluster_id}/services/{service_name}/logs", s.handleGetServiceLogs).Methods("GET")
+	api.HandleFunc("/clusters/{cluster_id}/services/{service_name}/exec", s.handleExecService).Methods("POST")
+	api.HandleFunc("/clusters/{cluster_id}/services/{service_name}/pool", s.handleGetServicePool).Methods("GET")
+	api.HandleFunc("/clusters/{cluster_id}/services/{service_name}/health/history", s.handleServiceHealthHistory).Methods("GET")
+	api.HandleFunc("/clusters/{cluster_id}/services/{service_name}/snapshot", s.idempotent(s.handleSnapshotService)).Methods("POST")
+	api.HandleFunc("/clusters/{cluster_id}/services/{service_name}/snapshots", s.handleListServiceSnapshots).Methods("GET")
+	api.HandleFunc("/clusters/{cluster_id}/services/{service_name}/snapshots/{name}/download", s.handleDownloadServiceSnapshot).Methods("GET")
+
+	// Fault injection (chaos testing)
+	api.HandleFunc("/clusters/{cluster_id}/faults", s.handleGetFaults).Methods("GET")
+	api.HandleFunc("/clusters/{cluster_id}/services/{service_name}/faults", s.handleSetFault).Methods("PUT")
+	api.HandleFunc("/clusters/{cluster_id}/services/{service_name}/faults", s.handleClearFault).Methods("DELETE")
+
+	// Snapshots
+	api.HandleFunc("/clusters/{cluster_id}/snapshots", s.mutating(s.handleCreateSnapshot)).Methods("POST")
 
 	// Database operation routes
-	api.HandleFunc("/clusters/{cluster_id}/db/execute", s.handleDBExecute).Methods("POST")
+	api.HandleFunc("/clusters/{cluster_id}/db/execute", s.mutating(s.handleDBExecute)).Methods("POST")
 	api.HandleFunc("/clusters/{cluster_id}/db/query", s.handleDBQuery).Methods("POST")
+	api.HandleFunc("/clusters/{cluster_id}/db/explain", s.handleDBExplain).Methods("POST")
+	api.HandleFunc("/clusters/{cluster_id}/db/slow-queries", s.handleDBSlowQueries).Methods("GET")
+	api.HandleFunc("/clusters/{cluster_id}/db/cursors/{cursor}", s.handleCloseCursor).Methods("DELETE")
+	api.HandleFunc("/clusters/{cluster_id}/db/statements", s.handlePrepareStatement).Methods("POST")
+	api.HandleFunc("/clusters/{cluster_id}/db/statements", s.handleListStatements).Methods("GET")
+	api.HandleFunc("/clusters/{cluster_id}/db/statements/{name}", s.handleDeallocateStatement).Methods("DELETE")
+	api.HandleFunc("/clusters/{cluster_id}/db/statements/{name}/execute", s.mutating(s.handleExecuteStatement)).Methods("POST")
+	api.HandleFunc("/clusters/{cluster_id}/db/statements/{name}/query", s.handleQueryStatement).Methods("POST")
+	api.HandleFunc("/clusters/{cluster_id}/db/transactions", s.mutating(s.handleBeginTransaction)).Methods("POST")
+	api.HandleFunc("/clusters/{cluster_id}/db/transactions/{transaction}/execute", s.mutating(s.handleTransactionExecute)).Methods("POST")
+	api.HandleFunc("/clusters/{cluster_id}/db/transactions/{transaction}/query", s.mutating(s.handleTransactionQuery)).Methods("POST")
+	api.HandleFunc("/clusters/{cluster_id}/db/transactions/{transaction}/commit", s.mutating(s.handleCommitTransaction)).Methods("POST")
+	api.HandleFunc("/clusters/{cluster_id}/db/transactions/{transaction}/rollback", s.handleRollbackTransaction).Methods("POST")
+
+	// Document operation routes
+	api.HandleFunc("/clusters/{cluster_id}/documents/{collection}/insert", s.mutating(s.handleDocumentInsert)).Methods("POST")
+	api.HandleFunc("/clusters/{cluster_id}/documents/{collection}/find", s.handleDocumentFind).Methods("POST")
+	api.HandleFunc("/clusters/{cluster_id}/documents/{collection}/update", s.mutating(s.handleDocumentUpdate)).Methods("POST")
+	api.HandleFunc("/clusters/{cluster_id}/documents/{collection}/delete", s.mutating(s.handleDocumentDelete)).Methods("POST")
+	api.HandleFunc("/clusters/{cluster_id}/documents/{collection}/aggregate", s.handleDocumentAggregate).Methods("POST")
+
+	// Key-document routes
+	api.HandleFunc("/clusters/{cluster_id}/tables", s.mutating(s.handleCreateTable)).Methods("POST")
+	api.HandleFunc("/clusters/{cluster_id}/items/{table}/put", s.mutating(s.handlePutItem)).Methods("POST")
+	api.HandleFunc("/clusters/{cluster_id}/items/{table}/get", s.handleGetItem).Methods("POST")
+	api.HandleFunc("/clusters/{cluster_id}/items/{table}/query", s.handleQueryItems).Methods("POST")
+
+	// Vector routes
+	api.HandleFunc("/clusters/{cluster_id}/vectors/{collection}", s.mutating(s.handleCreateVectorCollection)).Methods("PUT")
+	api.HandleFunc("/clusters/{cluster_id}/vectors/{collection}/upsert", s.mutating(s.handleVectorUpsert)).Methods("POST")
+	api.HandleFunc("/clusters/{cluster_id}/vectors/{collection}/search", s.handleVectorSearch).Methods("POST")
+
+	// Object storage routes
+	api.HandleFunc("/clusters/{cluster_id}/objects/{bucket}", s.handleListObjects).Methods("GET")
+	api.HandleFunc("/clusters/{cluster_id}/objects/{bucket}/presign", s.handlePresignObject).Methods("POST")
+	api.HandleFunc("/clusters/{cluster_id}/objects/{bucket}/{key:.+}", s.mutating(s.handlePutObject)).Methods("PUT")
+	api.HandleFunc("/clusters/{cluster_id}/objects/{bucket}/{key:.+}", s.handleGetObject).Methods("GET")
+
+	// Time series routes
+	api.HandleFunc("/clusters/{cluster_id}/ts/write", s.mutating(s.handleTimeSeriesWrite)).Methods("POST")
+	api.HandleFunc("/clusters/{cluster_id}/ts/query", s.handleTimeSeriesQuery).Methods("POST")
+
+	// Secret routes
+	api.HandleFunc("/clusters/{cluster_id}/secrets/{path:.+}", s.handleReadSecret).Methods("GET")
+	api.HandleFunc("/clusters/{cluster_id}/secrets/{path:.+}", s.mutating(s.handleWriteSecret)).Methods("PUT")
 
 	// Cache operation routes
-	api.HandleFunc("/clusters/{cluster_id}/cache/get", s.handleCacheGet).Methods("POST")
-	api.HandleFunc("/clusters/{cluster_id}/cache/set", s.handleCacheSet).Methods("POST")
-	api.HandleFunc("/clusters/{cluster_id}/cache/delete", s.handleCacheDelete).Methods("POST")
+	api.HandleFunc("/clusters/{cluster_id}/cache/get", s.reading(s.handleCacheGet)).Methods("POST")
+	api.HandleFunc("/clusters/{cluster_id}/cache/set", s.mutating(s.handleCacheSet)).Methods("POST")
+	api.HandleFunc("/clusters/{cluster_id}/cache/delete", s.mutating(s.handleCacheDelete)).Methods("POST")
+	api.HandleFunc("/clusters/{cluster_id}/cache/exists", s.reading(s.handleCacheExists)).Methods("POST")
+	api.HandleFunc("/clusters/{cluster_id}/cache/ttl", s.reading(s.handleCacheTTL)).Methods("POST")
+	api.HandleFunc("/clusters/{cluster_id}/cache/expire", s.mutating(s.handleCacheExpire)).Methods("POST")
+	api.HandleFunc("/clusters/{cluster_id}/cache/incr", s.mutating(s.handleCacheIncr)).Methods("POST")
+	api.HandleFunc("/clusters/{cluster_id}/cache/stats", s.handleCacheStats).Methods("GET")
+	api.HandleFunc("/clusters/{cluster_id}/cache/keys", s.reading(s.handleCacheKeys)).Methods("GET")
+
+	// Key-value store routes
+	api.HandleFunc("/clusters/{cluster_id}/kv/get", s.handleKVGet).Methods("POST")
+	api.HandleFunc("/clusters/{cluster_id}/kv/set", s.mutating(s.handleKVSet)).Methods("POST")
+	api.HandleFunc("/clusters/{cluster_id}/kv/delete", s.mutating(s.handleKVDelete)).Methods("POST")
+	api.HandleFunc("/clusters/{cluster_id}/kv/keys", s.handleKVKeys).Methods("GET")
+	api.HandleFunc("/clusters/{cluster_id}/kv/watch", s.handleKVWatch).Methods("GET")
 
 	// Queue/Kafka operation routes
-	api.HandleFunc("/clusters/{cluster_id}/queue/publish", s.handleQueuePublish).Methods("POST")
+	api.HandleFunc("/clusters/{cluster_id}/queue/publish", s.mutating(s.handleQueuePublish)).Methods("POST")
+	api.HandleFunc("/clusters/{cluster_id}/queue/subscribe", s.handleQueueSubscribe).Methods("GET")
+	api.HandleFunc("/clusters/{cluster_id}/queue/consume", s.handleQueueConsume).Methods("POST")
+	api.HandleFunc("/clusters/{cluster_id}/queue/commit", s.mutating(s.handleQueueCommit)).Methods("POST")
+	api.HandleFunc("/clusters/{cluster_id}/queue/groups", s.handleListConsumerGroups).Methods("GET")
+	api.HandleFunc("/clusters/{cluster_id}/queue/groups/{group}", s.handleGetConsumerGroup).Methods("GET")
+	api.HandleFunc("/clusters/{cluster_id}/queue/groups/{group}/reset", s.mutating(s.handleResetConsumerGroup)).Methods("POST")
 	api.HandleFunc("/clusters/{cluster_id}/queue/topics", s.handleListTopics).Methods("GET")
-	api.HandleFunc("/clusters/{cluster_id}/queue/topics", s.handleCreateTopic).Methods("POST")
-	api.HandleFunc("/clusters/{cluster_id}/queue/topics/{topic}", s.handleDeleteTopic).Methods("DELETE")
-
-	// Prometheus metrics endpoint
-	if s.config.Monitoring.Enabled {
-		s.router.Handle(s.config.Monitoring.MetricsPath, promhttp.Handler())
-	}
-
-	// Middleware
-	s.router.Use(s.loggingMiddleware)
-	s.router.Use(s.corsMiddleware)
-
-	// Serve embedded UI - must be last to catch all unmatched routes
-	uiHandler := GetUIHandler()
-	s.router.PathPrefix("/").Handler(uiHandler)
+	api.HandleFunc("/clusters/{cluster_id}/queue/topics", s.mutating(s.handleCreateTopic)).Methods("POST")
+	api.HandleFunc("/clusters/{cluster_id}/queue/topics/{topic}", s.handleGetTopic).Methods("GET")
+	api.HandleFunc("/clusters/{cluster_id}/queue/topics/{topic}", s.mutating(s.handleDeleteTopic)).Methods("DELETE")
+	api.HandleFunc("/clusters/{cluster_id}/queue/topics/{topic}/replay", s.mutating(s.handleReplayTopic)).Methods("POST")
+	api.HandleFunc("/clusters/{cluster_id}/queue/topics/{topic}/dlq", s.handleGetDLQ).Methods("GET")
+	api.HandleFunc("/clusters/{cluster_id}/queue/topics/{topic}/dlq/replay", s.mutating(s.handleReplayDLQ)).Methods("POST")
 }
 
 // Start starts the HTTP server
@@ -118,6 +342,22 @@ func (s *Server) Start() error {
 		WriteTimeout: time.Duration(s.config.Server.WriteTimeout) * time.Second,
 	}
 
+	interval := time.Duration(s.config.Monitoring.CollectionInterval) * time.Second
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+	go s.runRealtime(interval)
+
+	if s.config.Server.GRPC.Enabled {
+		if err := s.startGRPC(); err != nil {
+			return fmt.Errorf("failed to start gRPC server: %w", err)
+		}
+	}
+
+	if s.config.Server.TLS.Enabled {
+		return s.startTLS(addr)
+	}
+
 	logger.Info("Starting HTTP server", zap.String("addr", addr))
 
 	if err := s.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
@@ -127,9 +367,57 @@ func (s *Server) Start() error {
 	return nil
 }
 
+// startTLS serves HTTPS, and starts the redirect from plain HTTP if configured
+func (s *Server) startTLS(addr string) error {
+	cfg := s.config.Server.TLS
+
+	tlsConfig, err := s.serverTLSConfig()
+	if err != nil {
+		return fmt.Errorf("failed to configure TLS: %w", err)
+	}
+	s.server.TLSConfig = tlsConfig
+
+	if cfg.RedirectPort != 0 {
+		s.redirect = s.newRedirectServer()
+		go func() {
+			logger.Info("Starting HTTP to HTTPS redirect", zap.String("addr", s.redirect.Addr))
+			if err := s.redirect.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				logger.Error("HTTP to HTTPS redirect failed", zap.Error(err))
+			}
+		}()
+	}
+
+	logger.Info("Starting HTTPS server",
+		zap.String("addr", addr),
+		zap.Bool("client_certificates", cfg.ClientCAFile != ""),
+	)
+
+	if err := s.server.ListenAndServeTLS(cfg.CertFile, cfg.KeyFile); err != nil && err != http.ErrServerClosed {
+		return fmt.Errorf("failed to start server: %w", err)
+	}
+
+	return nil
+}
+
 // Shutdown gracefully shuts down the server
 func (s *Server) Shutdown(ctx context.Context) error {
 	logger.Info("Shutting down HTTP server...")
+
+	// Hijacked WebSocket connections are not closed by the HTTP server
+	close(s.realtimeStop)
+	s.realtime.Close()
+
+	if s.redirect != nil {
+		if err := s.redirect.Shutdown(ctx); err != nil {
+			logger.Error("Failed to shut down HTTP to HTTPS redirect", zap.Error(err))
+		}
+	}
+
+	if s.grpc != nil {
+		logger.Info("Shutting down gRPC server...")
+		s.stopGRPC(ctx)
+	}
+
 	return s.server.Shutdown(ctx)
 }
 
@@ -153,20 +441,42 @@ func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *Server) handleListClusters(w http.ResponseWriter, r *http.Request) {
+	// Archived clusters are hidden unless requested with archived=true (only archived)
+	// or archived=all
+	archived := r.URL.Query().Get("archived")
+	namespace := requestNamespace(r)
+	p := requestPage(r, 0)
+
 	clusterIDs, err := s.gateway.ListClusters()
 	if err != nil {
 		s.errorResponse(w, http.StatusInternalServerError, "Failed to list clusters", err)
 		return
 	}
 
-	// Get detailed info for each cluster
-	clusters := make([]map[string]interface{}, 0)
+	// Select the matching clusters before checking the health of the page's services
+	matching := make([]string, 0, len(clusterIDs))
+	configs := make(map[string]*cluster.Config, len(clusterIDs))
 	for _, clusterID := range clusterIDs {
 		config, err := s.gateway.GetClusterConfig(clusterID)
 		if err != nil {
 			logger.Error("Failed to get cluster config", zap.String("cluster_id", clusterID), zap.Error(err))
 			continue
 		}
+		if archived != "all" && config.IsArchived() != (archived == "true") {
+			continue
+		}
+		if namespace != "" && config.NamespaceOrDefault() != namespace {
+			continue
+		}
+		matching = append(matching, clusterID)
+		configs[clusterID] = config
+	}
+	start, end := p.bounds(len(matching))
+
+	// Get detailed info for each cluster
+	clusters := make([]map[string]interface{}, 0, end-start)
+	for _, clusterID := range matching[start:end] {
+		config := configs[clusterID]
 
 		// Get service info with health status
 		services := make([]map[string]interface{}, 0)
@@ -192,21 +502,31 @@ func (s *Server) handleListClusters(w http.ResponseWriter, r *http.Request) {
 			})
 		}
 
-		clusters = append(clusters, map[string]interface{}{
+		entry := map[string]interface{}{
 			"id":         clusterID,
+			"namespace":  config.NamespaceOrDefault(),
 			"name":       config.Name,
 			"created_at": time.Now().Format(time.RFC3339), // TODO: Store actual creation time
 			"services":   services,
-		})
+		}
+		if config.IsArchived() {
+			entry["archived_at"] = config.ArchivedAt.Format(time.RFC3339)
+		}
+		if config.IsDrained() {
+			entry["drained_at"] = config.DrainedAt.Format(time.RFC3339)
+		}
+		clusters = append(clusters, entry)
 	}
 
+	setPageHeaders(w, r, p, len(matching))
 	s.jsonResponse(w, http.StatusOK, clusters)
 }
 
 func (s *Server) handleCreateCluster(w http.ResponseWriter, r *http.Request) {
 	var req struct {
-		Name   string                 `json:"name"`
-		Config map[string]interface{} `json:"config"`
+		Name      string                 `json:"name"`
+		Namespace string                 `json:"namespace"` // The default namespace unless set
+		Config    map[string]interface{} `json:"config"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -220,6 +540,18 @@ func (s *Server) handleCreateCluster(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Clusters created under /namespaces/{namespace} belong to that namespace
+	if namespace := mux.Vars(r)["namespace"]; namespace != "" {
+		req.Namespace = namespace
+	}
+	if req.Namespace == "" {
+		req.Namespace = cluster.DefaultNamespace
+	}
+	if err := cluster.ValidateNamespace(req.Namespace); err != nil {
+		s.errorResponse(w, http.StatusBadRequest, "Invalid namespace", err)
+		return
+	}
+
 	if req.Config == nil || req.Config["services"] == nil {
 		s.errorResponse(w, http.StatusBadRequest, "Cluster services configuration is required", nil)
 		return
@@ -231,91 +563,12 @@ func (s *Server) handleCreateCluster(w http.ResponseWriter, r *http.Request) {
 		s.errorResponse(w, http.StatusBadRequest, "Invalid cluster configuration", err)
 		return
 	}
+	clusterConfig.Namespace = req.Namespace
 
-	// Provision services with Docker if provisioner is available
-	if s.provisioner != nil {
-		logger.Info("Processing services", zap.Int("total", len(clusterConfig.Services)))
-
-		for serviceName, serviceConfig := range clusterConfig.Services {
-			// Check if service should be provisioned or if it's an existing remote service
-			if !serviceConfig.Provision {
-				// Using existing remote service - skip provisioning
-				logger.Info("Using existing remote service",
-					zap.String("service", serviceName),
-					zap.String("type", serviceConfig.Type),
-					zap.String("host", serviceConfig.Host),
-					zap.Int("port", serviceConfig.Port),
-				)
-				continue
-			}
-
-			// Provision the service with Docker
-			logger.Info("Provisioning new service",
-				zap.String("service", serviceName),
-				zap.String("type", serviceConfig.Type),
-			)
-
-			container, err := s.provisioner.ProvisionService(r.Context(), serviceName, &serviceConfig)
-			if err != nil {
-				// Cleanup any already provisioned containers
-				for sn, sc := range clusterConfig.Services {
-					if sc.ContainerID != "" {
-						_ = s.provisioner.RemoveService(r.Context(), sc.ContainerID)
-					}
-					if sn == serviceName {
-						break
-					}
-				}
-				s.errorResponse(w, http.StatusInternalServerError,
-					fmt.Sprintf("Failed to provision service %s", serviceName), err)
-				return
-			}
-
-			// Update config with container ID
-			svc := clusterConfig.Services[serviceName]
-			svc.ContainerID = container.ContainerID
-			// Set the host based on where Throome is running
-			// If Throome is in Docker, use host.docker.internal to reach host containers
-			// If Throome is running natively, use localhost
-			if s.isRunningInDocker() {
-				svc.Host = "host.docker.internal"
-			} else {
-				svc.Host = "localhost"
-			}
-			clusterConfig.Services[serviceName] = svc
-
-			logger.Info("Service provisioned",
-				zap.String("service", serviceName),
-				zap.String("container_id", container.ContainerID[:12]),
-			)
-
-			// Wait for container to be healthy before proceeding
-			if err := s.provisioner.WaitForHealthy(r.Context(), container.ContainerID, 30*time.Second); err != nil {
-				// Cleanup all provisioned containers on failure
-				for _, sc := range clusterConfig.Services {
-					if sc.ContainerID != "" {
-						_ = s.provisioner.RemoveService(r.Context(), sc.ContainerID)
-					}
-				}
-				s.errorResponse(w, http.StatusInternalServerError,
-					fmt.Sprintf("Service %s failed to become healthy", serviceName), err)
-				return
-			}
-		}
-	}
-
-	// Create cluster
+	// Create cluster, provisioning its services if a provisioner is available
 	clusterID, err := s.gateway.CreateCluster(r.Context(), req.Name, clusterConfig)
 	if err != nil {
-		// Cleanup provisioned containers on failure
-		if s.provisioner != nil {
-			for _, serviceConfig := range clusterConfig.Services {
-				if serviceConfig.ContainerID != "" {
-					_ = s.provisioner.RemoveService(r.Context(), serviceConfig.ContainerID)
-				}
-			}
-		}
-		s.errorResponse(w, http.StatusInternalServerError, "Failed to create cluster", err)
+		s.provisionErrorResponse(w, "Failed to create cluster", err)
 		return
 	}
 
@@ -347,6 +600,7 @@ func (s *Server) handleCreateCluster(w http.ResponseWriter, r *http.Request) {
 
 	response := map[string]interface{}{
 		"id":         clusterID,
+		"namespace":  req.Namespace,
 		"name":       req.Name,
 		"created_at": time.Now().Format(time.RFC3339),
 		"services":   services,
@@ -356,6 +610,156 @@ func (s *Server) handleCreateCluster(w http.ResponseWriter, r *http.Request) {
 	s.jsonResponse(w, http.StatusCreated, response)
 }
 
+// handleUpdateCluster replaces the services of a cluster. Unchanged services keep
+// their containers and connections, while new and changed services are provisioned
+// when requested. Containers of replaced services are removed before their
+// replacements are provisioned, since they share a name and usually a port; those of
+// removed services are removed once the cluster is updated.
+func (s *Server) handleUpdateCluster(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	clusterID := vars["cluster_id"]
+
+	current, err := s.gateway.GetClusterConfig(clusterID)
+	if err != nil {
+		s.errorResponse(w, http.StatusNotFound, "Cluster not found", err)
+		return
+	}
+	if current.IsArchived() {
+		s.errorResponse(w, http.StatusConflict, "Cluster is archived, restore it before updating it", nil)
+		return
+	}
+
+	var req struct {
+		Name   string                 `json:"name"`
+		Config map[string]interface{} `json:"config"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.errorResponse(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	if req.Config == nil || req.Config["services"] == nil {
+		s.errorResponse(w, http.StatusBadRequest, "Cluster services configuration is required", nil)
+		return
+	}
+
+	name := req.Name
+	if name == "" {
+		name = current.Name
+	}
+	requested, err := s.convertJSONToClusterConfig(name, req.Config)
+	if err != nil {
+		s.errorResponse(w, http.StatusBadRequest, "Invalid cluster configuration", err)
+		return
+	}
+
+	// Services read from the API carry redacted credentials, which keep their values
+	for serviceName, serviceConfig := range requested.Services {
+		if previous, exists := current.Services[serviceName]; exists {
+			requested.Services[serviceName] = serviceConfig.WithSecretsFrom(previous)
+		}
+	}
+
+	// Routing, health and AI settings are kept
+	updated := *current
+	updated.Name = name
+	updated.Services, requested.Services = diffServices(current.Services, requested.Services)
+	for serviceName, serviceConfig := range requested.Services {
+		updated.Services[serviceName] = serviceConfig
+	}
+	if err := updated.Validate(); err != nil {
+		s.errorResponse(w, http.StatusBadRequest, "Invalid cluster configuration", err)
+		return
+	}
+
+	requested.ClusterID = clusterID
+	requested.Docker = current.Docker
+	replaced := &cluster.Config{Docker: current.Docker, Services: make(map[string]cluster.ServiceConfig)}
+	removed := &cluster.Config{Docker: current.Docker, Services: make(map[string]cluster.ServiceConfig)}
+	for serviceName, serviceConfig := range current.Services {
+		if _, exists := requested.Services[serviceName]; exists {
+			replaced.Services[serviceName] = serviceConfig
+		} else if _, exists := updated.Services[serviceName]; !exists {
+			removed.Services[serviceName] = serviceConfig
+		}
+	}
+	s.gateway.removeProvisioned(r.Context(), replaced)
+
+	// Provision new and changed services and point the cluster at their containers
+	if err := s.gateway.provisionServices(r.Context(), requested); err != nil {
+		s.provisionErrorResponse(w, "Failed to provision services", err)
+		return
+	}
+	for serviceName, serviceConfig := range requested.Services {
+		updated.Services[serviceName] = serviceConfig
+	}
+
+	if err := s.gateway.UpdateCluster(r.Context(), clusterID, &updated); err != nil {
+		// Cleanup provisioned containers on failure
+		s.gateway.removeProvisioned(r.Context(), requested)
+		s.errorResponse(w, http.StatusInternalServerError, "Failed to update cluster", err)
+		return
+	}
+	s.gateway.removeProvisioned(r.Context(), removed)
+
+	services := make([]map[string]interface{}, 0)
+	for serviceName, serviceConfig := range updated.Services {
+		// Check health status
+		healthy := false
+		adapter, err := s.gateway.GetAdapter(clusterID, serviceName)
+		if err == nil {
+			status, err := adapter.HealthCheck(r.Context())
+			if err == nil && status.Healthy {
+				healthy = true
+			}
+		}
+
+		services = append(services, map[string]interface{}{
+			"name":    serviceName,
+			"type":    serviceConfig.Type,
+			"host":    serviceConfig.Host,
+			"port":    serviceConfig.Port,
+			"healthy": healthy,
+		})
+	}
+
+	s.jsonResponse(w, http.StatusOK, map[string]interface{}{
+		"id":         clusterID,
+		"name":       name,
+		"updated_at": updated.UpdatedAt.Format(time.RFC3339),
+		"services":   services,
+		"message":    "Cluster updated successfully",
+	})
+}
+
+// diffServices splits the requested services of a cluster into those that are
+// unchanged, which keep their current configuration, and those that are new or
+// changed
+func diffServices(current, requested map[string]cluster.ServiceConfig) (unchanged, changed map[string]cluster.ServiceConfig) {
+	unchanged = make(map[string]cluster.ServiceConfig)
+	changed = make(map[string]cluster.ServiceConfig)
+	for serviceName, serviceConfig := range requested {
+		if previous, exists := current[serviceName]; exists && sameService(previous, serviceConfig) {
+			unchanged[serviceName] = previous
+		} else {
+			changed[serviceName] = serviceConfig
+		}
+	}
+	return unchanged, changed
+}
+
+// sameService reports whether a requested service matches its current configuration.
+// The host of provisioned services is set by the gateway, so it is not compared.
+func sameService(current, requested cluster.ServiceConfig) bool {
+	if current.Type != requested.Type || current.Provision != requested.Provision ||
+		current.Port != requested.Port || current.Username != requested.Username ||
+		current.Password != requested.Password || current.Database != requested.Database {
+		return false
+	}
+	return current.Provision || current.Host == requested.Host
+}
+
 func (s *Server) handleGetCluster(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	clusterID := vars["cluster_id"]
@@ -366,6 +770,11 @@ func (s *Server) handleGetCluster(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Credentials are redacted unless explicitly revealed
+	if !revealSecrets(r) {
+		config = config.Redacted()
+	}
+
 	// Build response with health status for services
 	servicesWithHealth := make(map[string]interface{})
 	for serviceName, serviceConfig := range config.Services {
@@ -392,50 +801,113 @@ func (s *Server) handleGetCluster(w http.ResponseWriter, r *http.Request) {
 
 	response := map[string]interface{}{
 		"id":         clusterID,
+		"namespace":  config.NamespaceOrDefault(),
 		"name":       config.Name,
 		"created_at": time.Now().Format(time.RFC3339),
 		"config": map[string]interface{}{
 			"services": servicesWithHealth,
 		},
 	}
+	if config.IsArchived() {
+		response["archived_at"] = config.ArchivedAt.Format(time.RFC3339)
+	}
+	if config.IsDrained() {
+		response["drained_at"] = config.DrainedAt.Format(time.RFC3339)
+	}
 
 	s.jsonResponse(w, http.StatusOK, response)
 }
 
+// handleDeleteCluster archives a cluster: its containers are stopped and it is
+// unloaded, but its configuration and volumes are kept until it is purged
 func (s *Server) handleDeleteCluster(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	clusterID := vars["cluster_id"]
 
-	// Get cluster config to find container IDs
 	config, err := s.gateway.GetClusterConfig(clusterID)
 	if err != nil {
 		s.errorResponse(w, http.StatusNotFound, "Cluster not found", err)
 		return
 	}
+	if config.IsArchived() {
+		s.errorResponse(w, http.StatusConflict, "Cluster is already archived, purge it to delete it permanently", nil)
+		return
+	}
 
-	// Stop and remove Docker containers if provisioner is available
-	if s.provisioner != nil {
-		logger.Info("Removing provisioned containers", zap.String("cluster_id", clusterID))
-		for serviceName, serviceConfig := range config.Services {
-			if serviceConfig.ContainerID != "" {
-				logger.Info("Removing container",
-					zap.String("service", serviceName),
-					zap.String("container_id", serviceConfig.ContainerID[:12]),
-				)
-				if err := s.provisioner.RemoveService(r.Context(), serviceConfig.ContainerID); err != nil {
-					logger.Error("Failed to remove container",
-						zap.String("service", serviceName),
-						zap.Error(err),
-					)
-					// Continue with deletion even if container removal fails
-				}
-			}
-		}
+	if err := s.gateway.ArchiveCluster(r.Context(), clusterID); err != nil {
+		s.errorResponse(w, http.StatusInternalServerError, "Failed to archive cluster", err)
+		return
+	}
+
+	s.jsonResponse(w, http.StatusOK, map[string]interface{}{
+		"message": "Cluster archived successfully",
+	})
+}
+
+// handleReloadCluster re-reads a cluster's config.yaml and applies it. New services
+// are connected, removed ones disconnected and changed ones reconnected; nothing is
+// provisioned.
+func (s *Server) handleReloadCluster(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	clusterID := vars["cluster_id"]
+
+	config, err := s.gateway.GetClusterConfig(clusterID)
+	if err != nil {
+		s.errorResponse(w, http.StatusNotFound, "Cluster not found", err)
+		return
+	}
+	if config.IsArchived() {
+		s.errorResponse(w, http.StatusConflict, "Cluster is archived, restore it before reloading it", nil)
+		return
+	}
+
+	if err := s.gateway.ReloadCluster(r.Context(), clusterID); err != nil {
+		s.errorResponse(w, http.StatusInternalServerError, "Failed to reload cluster", err)
+		return
+	}
+
+	s.jsonResponse(w, http.StatusOK, map[string]interface{}{
+		"message": "Cluster reloaded successfully",
+	})
+}
+
+// handleRestoreCluster brings an archived cluster back
+func (s *Server) handleRestoreCluster(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	clusterID := vars["cluster_id"]
+
+	config, err := s.gateway.GetClusterConfig(clusterID)
+	if err != nil {
+		s.errorResponse(w, http.StatusNotFound, "Cluster not found", err)
+		return
+	}
+	if !config.IsArchived() {
+		s.errorResponse(w, http.StatusConflict, "Cluster is not archived", nil)
+		return
 	}
 
-	// Delete cluster
-	if err := s.gateway.DeleteCluster(r.Context(), clusterID); err != nil {
-		s.errorResponse(w, http.StatusInternalServerError, "Failed to delete cluster", err)
+	if err := s.gateway.RestoreCluster(r.Context(), clusterID); err != nil {
+		s.errorResponse(w, http.StatusInternalServerError, "Failed to restore cluster", err)
+		return
+	}
+
+	s.jsonResponse(w, http.StatusOK, map[string]interface{}{
+		"message": "Cluster restored successfully",
+	})
+}
+
+// handlePurgeCluster permanently deletes a cluster and its containers
+func (s *Server) handlePurgeCluster(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	clusterID := vars["cluster_id"]
+
+	if _, err := s.gateway.GetClusterConfig(clusterID); err != nil {
+		s.errorResponse(w, http.StatusNotFound, "Cluster not found", err)
+		return
+	}
+
+	if err := s.gateway.PurgeCluster(r.Context(), clusterID); err != nil {
+		s.errorResponse(w, http.StatusInternalServerError, "Failed to purge cluster", err)
 		return
 	}
 
@@ -448,25 +920,66 @@ func (s *Server) handleClusterHealth(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	clusterID := vars["cluster_id"]
 
-	router, err := s.gateway.GetRouter(clusterID)
+	healthStatuses, err := s.gateway.ServiceHealth(r.Context(), clusterID)
 	if err != nil {
 		s.errorResponse(w, http.StatusNotFound, "Cluster not found", err)
 		return
 	}
 
-	healthStatuses := router.HealthCheckAll(r.Context())
-
 	s.jsonResponse(w, http.StatusOK, map[string]interface{}{
 		"cluster_id": clusterID,
 		"services":   healthStatuses,
 	})
 }
 
+// handleServiceHealthHistory returns the last checks of a service by the health
+// checker, oldest first, with its failure counts and uptime
+func (s *Server) handleServiceHealthHistory(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	clusterID := vars["cluster_id"]
+	serviceName := vars["service_name"]
+
+	if _, err := s.gateway.GetAdapter(clusterID, serviceName); err != nil {
+		s.errorResponse(w, http.StatusNotFound, "Service not found", err)
+		return
+	}
+
+	checker := s.gateway.GetHealthChecker()
+	name := clusterID + "/" + serviceName
+	history := checker.GetHealthHistory(name)
+	if history == nil {
+		history = &monitor.HealthHistory{ServiceName: name}
+	}
+
+	response := map[string]interface{}{
+		"cluster_id":            clusterID,
+		"service_name":          serviceName,
+		"healthy":               checker.IsHealthy(name),
+		"consecutive_failures":  history.ConsecutiveFails,
+		"consecutive_successes": history.ConsecutiveSuccess,
+		"total_checks":          history.TotalChecks,
+		"failed_checks":         history.FailedChecks,
+		"uptime_percentage":     history.UptimePercent(),
+		"checks":                append([]adapters.HealthStatus{}, history.History...),
+	}
+	if !history.LastHealthy.IsZero() {
+		response["last_healthy"] = history.LastHealthy
+	}
+	if !history.LastUnhealthy.IsZero() {
+		response["last_unhealthy"] = history.LastUnhealthy
+	}
+	if !history.UnhealthySince.IsZero() {
+		response["unhealthy_since"] = history.UnhealthySince
+	}
+
+	s.jsonResponse(w, http.StatusOK, response)
+}
+
 func (s *Server) handleClusterMetrics(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	clusterID := vars["cluster_id"]
 
-	metrics := s.gateway.GetCollector().GetClusterMetrics(clusterID)
+	metrics := s.gateway.GetCollector().SnapshotCluster(clusterID)
 	if metrics == nil {
 		s.errorResponse(w, http.StatusNotFound, "No metrics found for cluster", nil)
 		return
@@ -475,6 +988,104 @@ func (s *Server) handleClusterMetrics(w http.ResponseWriter, r *http.Request) {
 	s.jsonResponse(w, http.StatusOK, metrics)
 }
 
+// defaultHistoryWindow is how far back the metrics history goes unless asked otherwise
+const defaultHistoryWindow = time.Hour
+
+// requestWindow returns the window query parameter of a request, defaultHistoryWindow
+// when it is missing and at most the retention of the metrics history
+func requestWindow(r *http.Request) (time.Duration, error) {
+	windowStr := r.URL.Query().Get("window")
+	if windowStr == "" {
+		return defaultHistoryWindow, nil
+	}
+
+	window, err := time.ParseDuration(windowStr)
+	if err != nil || window <= 0 {
+		return 0, fmt.Errorf("window must be a positive duration such as 1h, got %q", windowStr)
+	}
+	return min(window, monitor.HistoryRetention), nil
+}
+
+// handleClusterMetricsHistory returns the per-minute request samples of a cluster's
+// services over a window, for charts of request rates and latencies
+func (s *Server) handleClusterMetricsHistory(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	clusterID := vars["cluster_id"]
+
+	if _, err := s.gateway.GetClusterConfig(clusterID); err != nil {
+		s.errorResponse(w, http.StatusNotFound, "Cluster not found", err)
+		return
+	}
+
+	window, err := requestWindow(r)
+	if err != nil {
+		s.errorResponse(w, http.StatusBadRequest, "Invalid window", err)
+		return
+	}
+
+	now := time.Now()
+	history := s.gateway.GetCollector().ClusterHistory(clusterID, now.Add(-window), now)
+	if history == nil {
+		history = make(map[string][]monitor.MetricsSample)
+	}
+	if serviceName := r.URL.Query().Get("service_name"); serviceName != "" {
+		history = map[string][]monitor.MetricsSample{serviceName: history[serviceName]}
+		if history[serviceName] == nil {
+			history[serviceName] = make([]monitor.MetricsSample, 0)
+		}
+	}
+
+	s.jsonResponse(w, http.StatusOK, map[string]interface{}{
+		"cluster_id": clusterID,
+		"window":     window.String(),
+		"resolution": monitor.HistoryResolution.String(),
+		"services":   history,
+	})
+}
+
+// handleResetClusterMetrics clears the collected metrics of a cluster's services, so
+// benchmarks can start from a clean baseline without restarting the gateway
+func (s *Server) handleResetClusterMetrics(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	clusterID := vars["cluster_id"]
+
+	if _, err := s.gateway.GetClusterConfig(clusterID); err != nil {
+		s.errorResponse(w, http.StatusNotFound, "Cluster not found", err)
+		return
+	}
+
+	s.gateway.GetCollector().ResetCluster(clusterID)
+	logger.Info("Cluster metrics reset", zap.String("cluster_id", clusterID))
+
+	s.jsonResponse(w, http.StatusOK, map[string]interface{}{
+		"message": "Cluster metrics reset successfully",
+	})
+}
+
+// handleResetServiceMetrics clears the collected metrics of a single service
+func (s *Server) handleResetServiceMetrics(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	clusterID := vars["cluster_id"]
+	serviceName := vars["service_name"]
+
+	config, err := s.gateway.GetClusterConfig(clusterID)
+	if err != nil {
+		s.errorResponse(w, http.StatusNotFound, "Cluster not found", err)
+		return
+	}
+	if _, exists := config.Services[serviceName]; !exists {
+		s.errorResponse(w, http.StatusNotFound, "Service not found", fmt.Errorf("service not found: %s", serviceName))
+		return
+	}
+
+	s.gateway.GetCollector().ResetService(clusterID, serviceName)
+	logger.Info("Service metrics reset", zap.String("cluster_id", clusterID), zap.String("service", serviceName))
+
+	s.jsonResponse(w, http.StatusOK, map[string]interface{}{
+		"message": "Service metrics reset successfully",
+	})
+}
+
 // Middleware
 
 func (s *Server) loggingMiddleware(next http.Handler) http.Handler {
@@ -485,20 +1096,45 @@ func (s *Server) loggingMiddleware(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r)
 
 		// Log request
-		logger.Info("HTTP request",
+		fields := []zap.Field{
 			zap.String("method", r.Method),
 			zap.String("path", r.URL.Path),
 			zap.String("remote_addr", r.RemoteAddr),
 			zap.Duration("duration", time.Since(start)),
-		)
+			zap.String("request_id", requestid.FromContext(r.Context())),
+		}
+		if identity := auth.IdentityFromContext(r.Context()); identity != nil {
+			fields = append(fields, zap.String("identity", identity.Subject), zap.String("auth_method", identity.Method))
+		}
+		logger.Info("HTTP request", fields...)
 	})
 }
 
+// requestIDMiddleware assigns every request an ID, the client's X-Request-ID if it
+// sent a valid one, and returns it in the X-Request-ID response header. The ID is
+// carried by the request context into logs and service activity.
+func (s *Server) requestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestid.Header)
+		if !requestid.Valid(id) {
+			id = requestid.New()
+		}
+
+		w.Header().Set(requestid.Header, id)
+		next.ServeHTTP(w, r.WithContext(requestid.WithID(r.Context(), id)))
+	})
+}
+
+// corsMiddleware allows the origins of server.cors.allowed_origins to call the API
 func (s *Server) corsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		if origin := allowedOrigin(s.settings().corsOrigins, r.Header.Get("Origin")); origin != "" {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+		}
+		w.Header().Add("Vary", "Origin")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, Idempotency-Key, X-API-Key, X-Client-ID, X-Request-ID")
+		w.Header().Set("Access-Control-Expose-Headers", "X-Request-ID, X-Total-Count, Link")
 
 		if r.Method == "OPTIONS" {
 			w.WriteHeader(http.StatusOK)
@@ -509,6 +1145,20 @@ func (s *Server) corsMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// allowedOrigin returns the Access-Control-Allow-Origin of a request's origin, "" when
+// it is not allowed
+func allowedOrigin(allowed []string, origin string) string {
+	for _, entry := range allowed {
+		if entry == "*" {
+			return "*"
+		}
+		if origin != "" && strings.EqualFold(entry, origin) {
+			return origin
+		}
+	}
+	return ""
+}
+
 // Helper methods
 
 func (s *Server) jsonResponse(w http.ResponseWriter, status int, data interface{}) {
@@ -527,9 +1177,39 @@ func (s *Server) errorResponse(w http.ResponseWriter, status int, message string
 		response["details"] = err.Error()
 	}
 
+	// The request ID is set on the response by requestIDMiddleware
+	if id := w.Header().Get(requestid.Header); id != "" {
+		response["request_id"] = id
+	}
+
 	s.jsonResponse(w, status, response)
 }
 
+// provisionErrorResponse writes the error of a request that provisions services,
+// naming the service that failed to provision
+func (s *Server) provisionErrorResponse(w http.ResponseWriter, message string, err error) {
+	var provisionErr *ProvisionError
+	if errors.As(err, &provisionErr) {
+		s.errorResponse(w, http.StatusInternalServerError, provisionErr.Message, provisionErr.Err)
+		return
+	}
+	s.errorResponse(w, http.StatusInternalServerError, message, err)
+}
+
+// findServiceByType returns the name of the first service of the given type in a cluster
+func findServiceByType(config *cluster.Config, serviceType string) string {
+	for serviceName, serviceConfig := range config.Services {
+		if serviceConfig.Type == serviceType {
+			return serviceName
+		}
+	}
+	return ""
+}
+
+// databaseServiceTypes are the SQL database service types, in order of preference.
+// CockroachDB services are served by the PostgreSQL adapter.
+var databaseServiceTypes = []string{"postgres", "cockroachdb"}
+
 // convertJSONToClusterConfig converts JSON configuration to cluster.Config
 func (s *Server) convertJSONToClusterConfig(name string, jsonConfig map[string]interface{}) (*cluster.Config, error) {
 	config := &cluster.Config{
@@ -549,66 +1229,94 @@ func (s *Server) convertJSONToClusterConfig(name string, jsonConfig map[string]i
 			return nil, fmt.Errorf("invalid service configuration for %s", serviceName)
 		}
 
-		serviceConfig := cluster.ServiceConfig{}
-
-		// Type
-		if serviceType, ok := serviceMap["type"].(string); ok {
-			serviceConfig.Type = serviceType
-		} else {
-			return nil, fmt.Errorf("service %s: type is required", serviceName)
+		serviceConfig, err := s.convertJSONToServiceConfig(serviceName, serviceMap)
+		if err != nil {
+			return nil, err
 		}
+		config.Services[serviceName] = serviceConfig
+	}
 
-		// Provision - default to true if not specified (for backward compatibility)
-		if provision, ok := serviceMap["provision"].(bool); ok {
-			serviceConfig.Provision = provision
-		} else {
-			serviceConfig.Provision = true // Default to provisioning new containers
+	// Docker host the cluster's containers are provisioned on
+	if dockerMap, ok := jsonConfig["docker"].(map[string]interface{}); ok {
+		config.Docker = &cluster.DockerHostConfig{}
+		if config.Docker.Host, _ = dockerMap["host"].(string); config.Docker.Host == "" {
+			return nil, fmt.Errorf("docker: host is required")
 		}
+		config.Docker.CAFile, _ = dockerMap["ca_file"].(string)
+		config.Docker.CertFile, _ = dockerMap["cert_file"].(string)
+		config.Docker.KeyFile, _ = dockerMap["key_file"].(string)
+	}
 
-		// Host
-		if host, ok := serviceMap["host"].(string); ok {
-			serviceConfig.Host = host
-		} else {
-			return nil, fmt.Errorf("service %s: host is required", serviceName)
-		}
+	return config, nil
+}
 
-		// Port
-		if port, ok := serviceMap["port"].(float64); ok {
-			serviceConfig.Port = int(port)
-		} else {
-			return nil, fmt.Errorf("service %s: port is required", serviceName)
-		}
+// convertJSONToServiceConfig converts the JSON configuration of a service to cluster.ServiceConfig
+func (s *Server) convertJSONToServiceConfig(serviceName string, serviceMap map[string]interface{}) (cluster.ServiceConfig, error) {
+	serviceConfig := cluster.ServiceConfig{}
 
-		// Optional fields
-		if username, ok := serviceMap["username"].(string); ok {
-			serviceConfig.Username = username
-		}
+	// Type
+	if serviceType, ok := serviceMap["type"].(string); ok {
+		serviceConfig.Type = serviceType
+	} else {
+		return cluster.ServiceConfig{}, fmt.Errorf("service %s: type is required", serviceName)
+	}
 
-		if password, ok := serviceMap["password"].(string); ok {
-			serviceConfig.Password = password
-		}
+	// Provision - default to true if not specified (for backward compatibility)
+	if provision, ok := serviceMap["provision"].(bool); ok {
+		serviceConfig.Provision = provision
+	} else {
+		serviceConfig.Provision = true // Default to provisioning new containers
+	}
 
-		if database, ok := serviceMap["database"].(string); ok {
-			serviceConfig.Database = database
-		}
+	// Host
+	if host, ok := serviceMap["host"].(string); ok {
+		serviceConfig.Host = host
+	} else {
+		return cluster.ServiceConfig{}, fmt.Errorf("service %s: host is required", serviceName)
+	}
 
-		config.Services[serviceName] = serviceConfig
+	// Port
+	if port, ok := serviceMap["port"].(float64); ok {
+		serviceConfig.Port = int(port)
+	} else {
+		return cluster.ServiceConfig{}, fmt.Errorf("service %s: port is required", serviceName)
 	}
 
-	return config, nil
-}
+	// Optional fields
+	if username, ok := serviceMap["username"].(string); ok {
+		serviceConfig.Username = username
+	}
 
-// isRunningInDocker checks if Throome is running inside a Docker container
-func (s *Server) isRunningInDocker() bool {
-	// Check for /.dockerenv file (common indicator)
-	if _, err := os.Stat("/.dockerenv"); err == nil {
-		return true
+	if password, ok := serviceMap["password"].(string); ok {
+		serviceConfig.Password = password
 	}
 
-	// Check cgroup file for docker
-	if data, err := os.ReadFile("/proc/self/cgroup"); err == nil {
-		return bytes.Contains(data, []byte("docker")) || bytes.Contains(data, []byte("containerd"))
+	if database, ok := serviceMap["database"].(string); ok {
+		serviceConfig.Database = database
 	}
 
-	return false
+	return serviceConfig, nil
+}
+
+// newProvisioner creates the provisioner selected in the gateway configuration
+func newProvisioner(cfg config.ProvisionerConfig) (provisioner.Provisioner, error) {
+	switch cfg.Type {
+	case "podman":
+		return provisioner.NewPodmanProvisioner(cfg.Podman.Socket)
+	case "kubernetes":
+		return provisioner.NewKubernetesProvisioner(provisioner.KubernetesOptions{
+			Kubeconfig:      cfg.Kubernetes.Kubeconfig,
+			Context:         cfg.Kubernetes.Context,
+			NamespacePrefix: cfg.Kubernetes.NamespacePrefix,
+			StorageClass:    cfg.Kubernetes.StorageClass,
+			StorageSize:     cfg.Kubernetes.StorageSize,
+		})
+	default:
+		return provisioner.NewDockerProvisionerForHost(provisioner.DockerHostOptions{
+			Host:     cfg.Docker.Host,
+			CAFile:   cfg.Docker.CAFile,
+			CertFile: cfg.Docker.CertFile,
+			KeyFile:  cfg.Docker.KeyFile,
+		})
+	}
 }
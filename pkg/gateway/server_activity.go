@@ -1,6 +1,9 @@
 package gateway
 
 import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
 	"net/http"
 	"strconv"
 	"time"
@@ -13,23 +16,16 @@ import (
 func (s *Server) handleGetActivity(w http.ResponseWriter, r *http.Request) {
 	// Parse query parameters
 	query := r.URL.Query()
+	p := requestPage(r, 100)
 
 	filters := monitor.ActivityFilters{
 		ClusterID:   query.Get("cluster_id"),
 		ServiceType: query.Get("service_type"),
 		Operation:   query.Get("operation"),
 		Status:      query.Get("status"),
-		Limit:       100, // default
-	}
-
-	// Parse limit
-	if limitStr := query.Get("limit"); limitStr != "" {
-		if limit, err := strconv.Atoi(limitStr); err == nil && limit > 0 {
-			if limit > 1000 {
-				limit = 1000 // max limit
-			}
-			filters.Limit = limit
-		}
+		RequestID:   query.Get("request_id"),
+		Limit:       p.Limit,
+		Offset:      p.Offset,
 	}
 
 	// Parse since timestamp
@@ -43,11 +39,14 @@ func (s *Server) handleGetActivity(w http.ResponseWriter, r *http.Request) {
 	buffer := s.gateway.GetActivityBuffer()
 
 	// Apply filters
-	activities := buffer.Filter(filters)
+	activities, total := buffer.FilterPage(filters)
 
+	setPageHeaders(w, r, p, total)
 	s.jsonResponse(w, http.StatusOK, map[string]interface{}{
 		"activities": activities,
 		"count":      len(activities),
+		"total":      total,
+		"offset":     p.Offset,
 		"filters":    filters,
 	})
 }
@@ -57,28 +56,25 @@ func (s *Server) handleGetClusterActivity(w http.ResponseWriter, r *http.Request
 	vars := mux.Vars(r)
 	clusterID := vars["cluster_id"]
 
-	// Parse query parameters
-	query := r.URL.Query()
-	limit := 100
-
-	if limitStr := query.Get("limit"); limitStr != "" {
-		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
-			if l > 1000 {
-				l = 1000
-			}
-			limit = l
-		}
-	}
+	p := requestPage(r, 100)
 
 	// Get activity buffer
 	buffer := s.gateway.GetActivityBuffer()
 
 	// Get activities for this cluster
-	activities := buffer.GetByCluster(clusterID, limit)
+	activities, total := buffer.FilterPage(monitor.ActivityFilters{
+		ClusterID: clusterID,
+		RequestID: r.URL.Query().Get("request_id"),
+		Limit:     p.Limit,
+		Offset:    p.Offset,
+	})
 
+	setPageHeaders(w, r, p, total)
 	s.jsonResponse(w, http.StatusOK, map[string]interface{}{
 		"activities": activities,
 		"count":      len(activities),
+		"total":      total,
+		"offset":     p.Offset,
 		"cluster_id": clusterID,
 	})
 }
@@ -89,29 +85,155 @@ func (s *Server) handleGetServiceActivity(w http.ResponseWriter, r *http.Request
 	clusterID := vars["cluster_id"]
 	serviceName := vars["service_name"]
 
-	// Parse query parameters
-	query := r.URL.Query()
-	limit := 100
-
-	if limitStr := query.Get("limit"); limitStr != "" {
-		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
-			if l > 1000 {
-				l = 1000
-			}
-			limit = l
-		}
-	}
+	p := requestPage(r, 100)
 
 	// Get activity buffer
 	buffer := s.gateway.GetActivityBuffer()
 
 	// Get activities for this service
-	activities := buffer.GetByService(clusterID, serviceName, limit)
+	activities, total := buffer.FilterPage(monitor.ActivityFilters{
+		ClusterID:   clusterID,
+		ServiceName: serviceName,
+		RequestID:   r.URL.Query().Get("request_id"),
+		Limit:       p.Limit,
+		Offset:      p.Offset,
+	})
 
+	setPageHeaders(w, r, p, total)
 	s.jsonResponse(w, http.StatusOK, map[string]interface{}{
 		"activities":   activities,
 		"count":        len(activities),
+		"total":        total,
+		"offset":       p.Offset,
 		"cluster_id":   clusterID,
 		"service_name": serviceName,
 	})
 }
+
+// Formats of activity exports
+const (
+	ActivityExportCSV    = "csv"
+	ActivityExportNDJSON = "ndjson"
+)
+
+// activityExportColumns are the header of CSV activity exports
+var activityExportColumns = []string{
+	"id", "timestamp", "cluster_id", "service_name", "service_type", "operation", "command", "parameters",
+	"duration", "status", "response", "error", "rows_affected", "client_info",
+}
+
+// handleExportActivity streams every operation of the activity buffer matching the
+// filters, oldest first, as CSV or NDJSON. Exports are not paginated, so they hold
+// as many operations as monitoring.activity_buffer_size keeps.
+func (s *Server) handleExportActivity(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	format := query.Get("format")
+	if format == "" {
+		format = ActivityExportNDJSON
+	}
+	if format != ActivityExportCSV && format != ActivityExportNDJSON {
+		s.errorResponse(w, http.StatusBadRequest, "Invalid format", fmt.Errorf("format must be %s or %s", ActivityExportCSV, ActivityExportNDJSON))
+		return
+	}
+
+	filters := monitor.ActivityFilters{
+		ClusterID:   query.Get("cluster_id"),
+		ServiceName: query.Get("service_name"),
+		ServiceType: query.Get("service_type"),
+		Operation:   query.Get("operation"),
+		Status:      query.Get("status"),
+		RequestID:   query.Get("request_id"),
+	}
+
+	// An archive of everything is worse than an error when the range is wrong
+	if sinceStr := query.Get("since"); sinceStr != "" {
+		since, err := time.Parse(time.RFC3339, sinceStr)
+		if err != nil {
+			s.errorResponse(w, http.StatusBadRequest, "Invalid since", err)
+			return
+		}
+		filters.Since = &since
+	}
+
+	// The matching logs are collected first, so slow clients do not hold up the buffer
+	activities := s.gateway.GetActivityBuffer().Matching(filters)
+
+	contentType := NDJSONContentType
+	if format == ActivityExportCSV {
+		contentType = "text/csv; charset=utf-8"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="activity.%s"`, format))
+	w.Header().Set(TotalCountHeader, strconv.Itoa(len(activities)))
+	w.WriteHeader(http.StatusOK)
+
+	// Large exports outlast the server's write timeout
+	rc := http.NewResponseController(w)
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	if format == ActivityExportCSV {
+		writeActivityCSV(w, rc, activities)
+	} else {
+		writeActivityNDJSON(w, rc, activities)
+	}
+	_ = rc.Flush()
+}
+
+// writeActivityNDJSON writes activity logs one JSON object per line
+func writeActivityNDJSON(w http.ResponseWriter, rc *http.ResponseController, activities []*monitor.ActivityLog) {
+	encoder := json.NewEncoder(w)
+	for i, activity := range activities {
+		if err := encoder.Encode(activity); err != nil {
+			return // The client went away
+		}
+		if (i+1)%streamFlushRows == 0 {
+			_ = rc.Flush()
+		}
+	}
+}
+
+// writeActivityCSV writes activity logs as CSV rows under a header. Parameters and
+// client info are written as JSON.
+func writeActivityCSV(w http.ResponseWriter, rc *http.ResponseController, activities []*monitor.ActivityLog) {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(activityExportColumns); err != nil {
+		return
+	}
+
+	for i, activity := range activities {
+		record := []string{
+			activity.ID,
+			activity.Timestamp.Format(time.RFC3339Nano),
+			activity.ClusterID,
+			activity.ServiceName,
+			activity.ServiceType,
+			activity.Operation,
+			activity.Command,
+			activityExportJSON(activity.Parameters),
+			strconv.FormatInt(activity.Duration, 10),
+			activity.Status,
+			activity.Response,
+			activity.Error,
+			strconv.FormatInt(activity.RowsAffected, 10),
+			activityExportJSON(activity.ClientInfo),
+		}
+		if err := writer.Write(record); err != nil {
+			return // The client went away
+		}
+		if (i+1)%streamFlushRows == 0 {
+			writer.Flush()
+			_ = rc.Flush()
+		}
+	}
+	writer.Flush()
+}
+
+// activityExportJSON returns a field of an activity log as JSON, or "" when it is unset
+func activityExportJSON(value interface{}) string {
+	data, err := json.Marshal(value)
+	if err != nil || string(data) == "null" {
+		return "" // Parameters are decoded from JSON, so they encode back
+	}
+	return string(data)
+}
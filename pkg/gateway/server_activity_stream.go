@@ -0,0 +1,195 @@
+package gateway
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/akmadan/throome/internal/logger"
+	"github.com/akmadan/throome/pkg/monitor"
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+)
+
+// EventDropped reports activity log entries an activity stream client missed because
+// it fell behind
+const EventDropped = "dropped"
+
+const (
+	activityStreamBuffer  = 256  // Entries queued per client before new ones are dropped
+	activityStreamMaxTail = 1000 // Most recent entries sent when a client connects
+)
+
+// ActivityDropped is the payload of a dropped event
+type ActivityDropped struct {
+	Count int64 `json:"count"`
+}
+
+// activityStreams fans activity log entries out to activity stream clients
+type activityStreams struct {
+	mu          sync.RWMutex
+	subscribers map[*activitySubscriber]struct{}
+}
+
+// activitySubscriber is a single activity stream client and its filters
+type activitySubscriber struct {
+	filters monitor.ActivityFilters
+	send    chan *monitor.ActivityLog
+	dropped atomic.Int64 // Entries dropped since the client was last told
+}
+
+func newActivityStreams() *activityStreams {
+	return &activityStreams{
+		subscribers: make(map[*activitySubscriber]struct{}),
+	}
+}
+
+// publish sends an entry to every client whose filters it matches. Clients that fall
+// too far behind miss entries rather than blocking the operation that logged them,
+// and are told how many they missed.
+func (a *activityStreams) publish(log *monitor.ActivityLog) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	for subscriber := range a.subscribers {
+		if !subscriber.filters.Matches(log) {
+			continue
+		}
+		select {
+		case subscriber.send <- log:
+		default:
+			subscriber.dropped.Add(1)
+		}
+	}
+}
+
+func (a *activityStreams) subscribe(filters monitor.ActivityFilters) *activitySubscriber {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	subscriber := &activitySubscriber{
+		filters: filters,
+		send:    make(chan *monitor.ActivityLog, activityStreamBuffer),
+	}
+	a.subscribers[subscriber] = struct{}{}
+	return subscriber
+}
+
+func (a *activityStreams) unsubscribe(subscriber *activitySubscriber) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	delete(a.subscribers, subscriber)
+}
+
+// handleActivityStream upgrades the connection to a WebSocket that pushes activity log
+// entries matching the query's filters as they are recorded, after the most recent
+// tail entries. Entries are sent as activity events; a client that falls behind gets
+// a dropped event with the number of entries it missed.
+func (s *Server) handleActivityStream(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	filters := monitor.ActivityFilters{
+		ClusterID:   query.Get("cluster_id"),
+		ServiceName: query.Get("service_name"),
+		ServiceType: query.Get("service_type"),
+		Operation:   query.Get("operation"),
+		Status:      query.Get("status"),
+		RequestID:   query.Get("request_id"),
+	}
+
+	tail := 0
+	if tailStr := query.Get("tail"); tailStr != "" {
+		parsed, err := strconv.Atoi(tailStr)
+		if err != nil || parsed < 0 {
+			s.errorResponse(w, http.StatusBadRequest, "Invalid tail", fmt.Errorf("tail must be a number of entries, got %q", tailStr))
+			return
+		}
+		tail = min(parsed, activityStreamMaxTail)
+	}
+
+	conn, err := realtimeUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade has already written the error response
+		logger.Warn("Failed to upgrade activity stream connection", zap.Error(err))
+		return
+	}
+	defer conn.Close()
+
+	// Subscribe before reading the tail, so no entry falls in between
+	subscriber := s.activityStreams.subscribe(filters)
+	defer s.activityStreams.unsubscribe(subscriber)
+
+	write := func(event *RealtimeEvent) bool {
+		_ = conn.SetWriteDeadline(time.Now().Add(realtimeWriteTimeout))
+		return conn.WriteJSON(event) == nil
+	}
+	writeActivity := func(log *monitor.ActivityLog) bool {
+		return write(&RealtimeEvent{Type: EventActivity, ClusterID: log.ClusterID, Timestamp: log.Timestamp, Data: log})
+	}
+	writeDropped := func() bool {
+		dropped := subscriber.dropped.Swap(0)
+		return dropped == 0 || write(&RealtimeEvent{Type: EventDropped, Data: &ActivityDropped{Count: dropped}})
+	}
+
+	sent := make(map[string]bool)
+	if tail > 0 {
+		logs := s.gateway.GetActivityBuffer().Matching(filters)
+		for _, log := range logs[max(len(logs)-tail, 0):] {
+			if !writeActivity(log) {
+				return
+			}
+			sent[log.ID] = true
+		}
+	}
+
+	// Clients send nothing but control frames, which are read to notice disconnects
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		conn.SetReadLimit(realtimeMaxMessage)
+		_ = conn.SetReadDeadline(time.Now().Add(realtimePongTimeout))
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(realtimePongTimeout))
+		})
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	ping := time.NewTicker(realtimePingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case log := <-subscriber.send:
+			if !writeDropped() {
+				return
+			}
+			if sent[log.ID] {
+				delete(sent, log.ID)
+				continue
+			}
+			if !writeActivity(log) {
+				return
+			}
+		case <-ping.C:
+			if !writeDropped() {
+				return
+			}
+			_ = conn.SetWriteDeadline(time.Now().Add(realtimeWriteTimeout))
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		case <-closed:
+			return
+		case <-s.realtimeStop:
+			return
+		}
+	}
+}
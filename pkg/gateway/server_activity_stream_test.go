@@ -0,0 +1,77 @@
+package gateway
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/akmadan/throome/internal/config"
+	"github.com/akmadan/throome/pkg/monitor"
+)
+
+func TestActivityStream(t *testing.T) {
+	gw := newTestGateway(t)
+	s := &Server{config: config.DefaultConfig(), gateway: gw, activityStreams: newActivityStreams(), realtimeStop: make(chan struct{})}
+	server := httptest.NewServer(http.HandlerFunc(s.handleActivityStream))
+	defer server.Close()
+
+	buffer := gw.GetActivityBuffer()
+	buffer.Add(&monitor.ActivityLog{ClusterID: "stream-c1", Operation: "GET", Status: "success", Timestamp: time.Now()})
+	buffer.Add(&monitor.ActivityLog{ClusterID: "stream-c1", Operation: "SET", Status: "error", Timestamp: time.Now()})
+
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/v1/activity/stream?cluster_id=stream-c1&status=error&tail=5"
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer conn.Close()
+
+	read := func() (*RealtimeEvent, *monitor.ActivityLog) {
+		_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		var event struct {
+			RealtimeEvent
+			Data json.RawMessage `json:"data"`
+		}
+		if err := conn.ReadJSON(&event); err != nil {
+			t.Fatalf("Failed to read event: %v", err)
+		}
+		var log monitor.ActivityLog
+		_ = json.Unmarshal(event.Data, &log)
+		return &event.RealtimeEvent, &log
+	}
+
+	// The tail only holds the matching entries
+	if event, log := read(); event.Type != EventActivity || log.Operation != "SET" {
+		t.Fatalf("Expected the failed SET from the tail, got %+v %+v", event, log)
+	}
+
+	s.activityStreams.publish(&monitor.ActivityLog{ID: "other", ClusterID: "stream-c2", Operation: "DEL", Status: "error"})
+	s.activityStreams.publish(&monitor.ActivityLog{ID: "ok", ClusterID: "stream-c1", Operation: "DEL", Status: "success"})
+	s.activityStreams.publish(&monitor.ActivityLog{ID: "failed", ClusterID: "stream-c1", Operation: "DEL", Status: "error"})
+	if event, log := read(); event.Type != EventActivity || log.ID != "failed" {
+		t.Errorf("Expected only the matching new entry, got %+v %+v", event, log)
+	}
+}
+
+func TestActivityStreamBackpressure(t *testing.T) {
+	streams := newActivityStreams()
+	subscriber := streams.subscribe(monitor.ActivityFilters{ClusterID: "c1"})
+	defer streams.unsubscribe(subscriber)
+
+	for i := 0; i < activityStreamBuffer+10; i++ {
+		streams.publish(&monitor.ActivityLog{ClusterID: "c1"})
+	}
+	streams.publish(&monitor.ActivityLog{ClusterID: "c2"})
+
+	if queued := len(subscriber.send); queued != activityStreamBuffer {
+		t.Errorf("Expected the buffer to be full, got %d entries", queued)
+	}
+	if dropped := subscriber.dropped.Load(); dropped != 10 {
+		t.Errorf("Expected 10 dropped entries, got %d", dropped)
+	}
+}
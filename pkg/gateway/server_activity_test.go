@@ -0,0 +1,75 @@
+package gateway
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/akmadan/throome/internal/config"
+	"github.com/akmadan/throome/pkg/monitor"
+)
+
+func TestExportActivity(t *testing.T) {
+	s := &Server{config: config.DefaultConfig(), gateway: newTestGateway(t)}
+
+	buffer := s.gateway.GetActivityBuffer()
+	start := time.Now()
+	buffer.Add(&monitor.ActivityLog{Timestamp: start.Add(-time.Hour), ClusterID: "export", Operation: "GET", Status: "success"})
+	buffer.Add(&monitor.ActivityLog{
+		Timestamp: start, ClusterID: "export", Operation: "SELECT", Command: "SELECT 1, \"a\"", Status: "success",
+		ClientInfo: map[string]string{"request_id": "r1"},
+	})
+	buffer.Add(&monitor.ActivityLog{Timestamp: start, ClusterID: "export", Operation: "SET", Status: "error", Error: "down"})
+
+	since := start.Add(-time.Minute).UTC().Format(time.RFC3339)
+	r := httptest.NewRequest("GET", "/api/v1/activity/export?cluster_id=export&since="+since, nil)
+	rec := httptest.NewRecorder()
+	s.handleExportActivity(rec, r)
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != NDJSONContentType {
+		t.Fatalf("Expected an NDJSON export, got %d %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+
+	// The operation before since is left out, the others come oldest first
+	var operations []string
+	scanner := bufio.NewScanner(rec.Body)
+	for scanner.Scan() {
+		var activity monitor.ActivityLog
+		if err := json.Unmarshal(scanner.Bytes(), &activity); err != nil {
+			t.Fatalf("Failed to decode %q: %v", scanner.Text(), err)
+		}
+		operations = append(operations, activity.Operation)
+	}
+	if strings.Join(operations, ",") != "SELECT,SET" {
+		t.Errorf("Expected SELECT and SET, got %v", operations)
+	}
+	if total := rec.Header().Get(TotalCountHeader); total != "2" {
+		t.Errorf("Expected a total count of 2, got %q", total)
+	}
+
+	r = httptest.NewRequest("GET", "/api/v1/activity/export?format=csv&cluster_id=export&status=success", nil)
+	rec = httptest.NewRecorder()
+	s.handleExportActivity(rec, r)
+	records, err := csv.NewReader(rec.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("Failed to read the CSV export: %v", err)
+	}
+	if len(records) != 3 || strings.Join(records[0], ",") != strings.Join(activityExportColumns, ",") {
+		t.Fatalf("Expected a header and 2 rows, got %v", records)
+	}
+	if row := records[2]; row[5] != "SELECT" || row[6] != "SELECT 1, \"a\"" || row[13] != `{"request_id":"r1"}` {
+		t.Errorf("Unexpected row %v", row)
+	}
+
+	for _, query := range []string{"?format=xml", "?since=yesterday"} {
+		rec = httptest.NewRecorder()
+		s.handleExportActivity(rec, httptest.NewRequest("GET", "/api/v1/activity/export"+query, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%q: expected status 400, got %d", query, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package gateway
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// handleGetAnomalies returns anomalies detected for a cluster's services
+func (s *Server) handleGetAnomalies(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	clusterID := vars["cluster_id"]
+
+	config, err := s.gateway.GetClusterConfig(clusterID)
+	if err != nil {
+		s.errorResponse(w, http.StatusNotFound, "Cluster not found", err)
+		return
+	}
+
+	limit := 100
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
+			if l > 500 {
+				l = 500
+			}
+			limit = l
+		}
+	}
+
+	anomalies := s.gateway.GetAnomalyDetector().GetAnomalies(clusterID, limit)
+
+	s.jsonResponse(w, http.StatusOK, map[string]interface{}{
+		"cluster_id": clusterID,
+		"enabled":    config.AI.Enabled,
+		"anomalies":  anomalies,
+		"count":      len(anomalies),
+	})
+}
+
+// handleGetRecommendations returns capacity forecasts and pool-size recommendations for a cluster
+func (s *Server) handleGetRecommendations(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	clusterID := vars["cluster_id"]
+
+	config, err := s.gateway.GetClusterConfig(clusterID)
+	if err != nil {
+		s.errorResponse(w, http.StatusNotFound, "Cluster not found", err)
+		return
+	}
+
+	recommendations := s.gateway.GetCapacityPlanner().Recommend(config)
+
+	s.jsonResponse(w, http.StatusOK, map[string]interface{}{
+		"cluster_id":      clusterID,
+		"recommendations": recommendations,
+		"count":           len(recommendations),
+	})
+}
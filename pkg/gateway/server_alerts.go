@@ -0,0 +1,48 @@
+package gateway
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/akmadan/throome/pkg/monitor"
+)
+
+// handleGetAlerts returns the pending and firing alerts, then the recently resolved
+// ones, optionally only those in one state
+func (s *Server) handleGetAlerts(w http.ResponseWriter, r *http.Request) {
+	state := r.URL.Query().Get("state")
+	switch state {
+	case "", monitor.AlertPending, monitor.AlertFiring, monitor.AlertResolved:
+	default:
+		err := fmt.Errorf("state must be %s, %s or %s", monitor.AlertPending, monitor.AlertFiring, monitor.AlertResolved)
+		s.errorResponse(w, http.StatusBadRequest, "Invalid state", err)
+		return
+	}
+
+	alerts := make([]*monitor.Alert, 0)
+	engine := s.gateway.GetAlertEngine()
+	if engine != nil {
+		alerts = engine.GetAlerts(state)
+	}
+
+	s.jsonResponse(w, http.StatusOK, map[string]interface{}{
+		"enabled": engine != nil,
+		"alerts":  alerts,
+		"count":   len(alerts),
+	})
+}
+
+// handleGetAlertRules returns the alert rules the gateway evaluates
+func (s *Server) handleGetAlertRules(w http.ResponseWriter, r *http.Request) {
+	rules := make([]monitor.AlertRule, 0)
+	engine := s.gateway.GetAlertEngine()
+	if engine != nil {
+		rules = engine.Rules()
+	}
+
+	s.jsonResponse(w, http.StatusOK, map[string]interface{}{
+		"enabled": engine != nil,
+		"rules":   rules,
+		"count":   len(rules),
+	})
+}
@@ -0,0 +1,37 @@
+package gateway
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/akmadan/throome/pkg/provisioner"
+)
+
+// handleExportCompose renders a cluster's services as a docker-compose.yaml, so the
+// same topology can run outside Throome. Credentials are rendered as variables
+// unless revealed.
+func (s *Server) handleExportCompose(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	clusterID := vars["cluster_id"]
+
+	config, err := s.gateway.GetClusterConfig(clusterID)
+	if err != nil {
+		s.errorResponse(w, http.StatusNotFound, "Cluster not found", err)
+		return
+	}
+	if !revealSecrets(r) {
+		config = config.Redacted()
+	}
+
+	content, err := provisioner.ComposeFile(config)
+	if err != nil {
+		s.errorResponse(w, http.StatusUnprocessableEntity, "Failed to render compose file", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/yaml")
+	w.Header().Set("Content-Disposition", `attachment; filename="docker-compose.yaml"`)
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(content)
+}
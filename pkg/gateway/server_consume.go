@@ -0,0 +1,182 @@
+package gateway
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/akmadan/throome/pkg/adapters/kafka"
+	"github.com/gorilla/mux"
+)
+
+// Limits of queue pulls
+const (
+	defaultConsumeMessages   = 10
+	maxConsumeMessages       = 100
+	defaultConsumeWait       = time.Second
+	maxConsumeWait           = 20 * time.Second
+	defaultVisibilityTimeout = 30 * time.Second
+	maxVisibilityTimeout     = 12 * time.Hour
+)
+
+type QueueConsumeRequest struct {
+	Topic             string `json:"topic"`
+	Group             string `json:"group"`
+	MaxMessages       int    `json:"max_messages"`
+	WaitSeconds       *int   `json:"wait_seconds"`
+	VisibilityTimeout int    `json:"visibility_timeout"` // Seconds
+}
+
+type QueueConsumeResponse struct {
+	Messages []*ConsumedMessage `json:"messages"`
+}
+
+// ConsumedMessage is a pulled message, to be committed with its receipt
+type ConsumedMessage struct {
+	Receipt      string            `json:"receipt"`
+	Topic        string            `json:"topic"`
+	Key          []byte            `json:"key,omitempty"`
+	Value        []byte            `json:"value"`
+	Headers      map[string]string `json:"headers,omitempty"`
+	Timestamp    *time.Time        `json:"timestamp,omitempty"`
+	Partition    int               `json:"partition"`
+	Offset       int64             `json:"offset"`
+	Deliveries   int               `json:"deliveries"`
+	VisibleUntil time.Time         `json:"visible_until"`
+}
+
+type QueueCommitRequest struct {
+	Topic    string   `json:"topic"`
+	Group    string   `json:"group"`
+	Receipts []string `json:"receipts"`
+}
+
+type QueueCommitResponse struct {
+	Committed int      `json:"committed"`
+	Unknown   []string `json:"unknown"` // Receipts that expired or were already committed
+}
+
+// handleQueueConsume pulls messages from a topic for a consumer group. Pulled messages
+// are hidden from other pulls of the group for the visibility timeout; messages not
+// committed by then are pulled again.
+func (s *Server) handleQueueConsume(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var req QueueConsumeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.errorResponse(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	if req.Topic == "" {
+		s.errorResponse(w, http.StatusBadRequest, "Topic is required", nil)
+		return
+	}
+	opts, err := pullOptions(&req)
+	if err != nil {
+		s.errorResponse(w, http.StatusBadRequest, "Invalid consume request", err)
+		return
+	}
+
+	kafkaAdapter, _, adapterErr := s.kafkaAdapter(r.Context(), vars["cluster_id"])
+	if adapterErr != nil {
+		s.errorResponse(w, adapterErr.status, adapterErr.message, adapterErr.err)
+		return
+	}
+
+	leases, err := kafkaAdapter.Pull(r.Context(), req.Topic, opts)
+	if err != nil {
+		s.errorResponse(w, http.StatusInternalServerError, "Failed to consume messages", err)
+		return
+	}
+
+	response := QueueConsumeResponse{Messages: make([]*ConsumedMessage, 0, len(leases))}
+	for _, lease := range leases {
+		response.Messages = append(response.Messages, newConsumedMessage(lease))
+	}
+	s.jsonResponse(w, http.StatusOK, response)
+}
+
+// handleQueueCommit commits pulled messages by their receipts
+func (s *Server) handleQueueCommit(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var req QueueCommitRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.errorResponse(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	if req.Topic == "" || len(req.Receipts) == 0 {
+		s.errorResponse(w, http.StatusBadRequest, "Topic and receipts are required", nil)
+		return
+	}
+
+	kafkaAdapter, _, adapterErr := s.kafkaAdapter(r.Context(), vars["cluster_id"])
+	if adapterErr != nil {
+		s.errorResponse(w, adapterErr.status, adapterErr.message, adapterErr.err)
+		return
+	}
+
+	committed, unknown, err := kafkaAdapter.CommitPulled(r.Context(), req.Topic, req.Group, req.Receipts)
+	if err != nil {
+		s.errorResponse(w, http.StatusInternalServerError, "Failed to commit messages", err)
+		return
+	}
+
+	s.jsonResponse(w, http.StatusOK, QueueCommitResponse{
+		Committed: committed,
+		Unknown:   unknown,
+	})
+}
+
+// pullOptions converts a consume request to pull options, applying defaults
+func pullOptions(req *QueueConsumeRequest) (kafka.PullOptions, error) {
+	opts := kafka.PullOptions{
+		GroupID:           req.Group,
+		MaxMessages:       defaultConsumeMessages,
+		Wait:              defaultConsumeWait,
+		VisibilityTimeout: defaultVisibilityTimeout,
+	}
+
+	if req.MaxMessages != 0 {
+		if req.MaxMessages < 0 || req.MaxMessages > maxConsumeMessages {
+			return opts, fmt.Errorf("max_messages must be between 1 and %d", maxConsumeMessages)
+		}
+		opts.MaxMessages = req.MaxMessages
+	}
+	if req.WaitSeconds != nil {
+		wait := time.Duration(*req.WaitSeconds) * time.Second
+		if wait < 0 || wait > maxConsumeWait {
+			return opts, fmt.Errorf("wait_seconds must be between 0 and %d", int(maxConsumeWait.Seconds()))
+		}
+		opts.Wait = wait
+	}
+	if req.VisibilityTimeout != 0 {
+		timeout := time.Duration(req.VisibilityTimeout) * time.Second
+		if timeout < 0 || timeout > maxVisibilityTimeout {
+			return opts, fmt.Errorf("visibility_timeout must be between 1 and %d seconds", int(maxVisibilityTimeout.Seconds()))
+		}
+		opts.VisibilityTimeout = timeout
+	}
+
+	return opts, nil
+}
+
+// newConsumedMessage converts a lease to its response
+func newConsumedMessage(lease *kafka.Lease) *ConsumedMessage {
+	message := &ConsumedMessage{
+		Receipt:      lease.Receipt,
+		Topic:        lease.Message.Topic,
+		Key:          lease.Message.Key,
+		Value:        lease.Message.Value,
+		Headers:      lease.Message.Headers,
+		Partition:    lease.Message.Partition,
+		Offset:       lease.Message.Offset,
+		Deliveries:   lease.Deliveries,
+		VisibleUntil: lease.VisibleUntil,
+	}
+	if !lease.Message.Timestamp.IsZero() {
+		message.Timestamp = &lease.Message.Timestamp
+	}
+	return message
+}
@@ -0,0 +1,42 @@
+package gateway
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPullOptions(t *testing.T) {
+	zero, wait, tooLong := 0, 5, 60
+
+	opts, err := pullOptions(&QueueConsumeRequest{Topic: "orders"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if opts.MaxMessages != defaultConsumeMessages || opts.Wait != defaultConsumeWait || opts.VisibilityTimeout != defaultVisibilityTimeout {
+		t.Errorf("Expected the defaults, got %+v", opts)
+	}
+
+	opts, err = pullOptions(&QueueConsumeRequest{Topic: "orders", Group: "billing", MaxMessages: 50, WaitSeconds: &wait, VisibilityTimeout: 120})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if opts.GroupID != "billing" || opts.MaxMessages != 50 || opts.Wait != 5*time.Second || opts.VisibilityTimeout != 2*time.Minute {
+		t.Errorf("Unexpected options: %+v", opts)
+	}
+
+	// A wait of zero returns right away
+	if opts, err := pullOptions(&QueueConsumeRequest{Topic: "orders", WaitSeconds: &zero}); err != nil || opts.Wait != 0 {
+		t.Errorf("Expected no wait, got %+v and %v", opts, err)
+	}
+
+	for _, req := range []*QueueConsumeRequest{
+		{Topic: "orders", MaxMessages: maxConsumeMessages + 1},
+		{Topic: "orders", MaxMessages: -1},
+		{Topic: "orders", WaitSeconds: &tooLong},
+		{Topic: "orders", VisibilityTimeout: -1},
+	} {
+		if _, err := pullOptions(req); err == nil {
+			t.Errorf("Expected %+v to be rejected", req)
+		}
+	}
+}
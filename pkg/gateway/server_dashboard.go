@@ -0,0 +1,273 @@
+package gateway
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/akmadan/throome/internal/logger"
+	"github.com/akmadan/throome/pkg/cluster"
+	"github.com/akmadan/throome/pkg/monitor"
+	"go.uber.org/zap"
+)
+
+// Number of slowest operations returned by default and at most
+const (
+	defaultSlowestLimit = 10
+	maxSlowestLimit     = 100
+)
+
+// TrafficSample holds the requests of a cluster's services during one minute
+type TrafficSample struct {
+	Time           time.Time `json:"time"` // Start of the minute
+	Requests       int64     `json:"requests"`
+	FailedRequests int64     `json:"failed_requests"`
+}
+
+// ClusterTraffic summarizes the requests of a cluster over a window
+type ClusterTraffic struct {
+	ClusterID         string          `json:"cluster_id"`
+	Name              string          `json:"name"`
+	Namespace         string          `json:"namespace"`
+	Requests          int64           `json:"requests"`
+	FailedRequests    int64           `json:"failed_requests"`
+	RequestsPerMinute float64         `json:"requests_per_minute"`
+	ErrorRate         float64         `json:"error_rate"` // Percentage of failed requests
+	Samples           []TrafficSample `json:"samples"`    // Oldest first
+}
+
+// ServiceHealthSummary is the health of a service on the dashboard
+type ServiceHealthSummary struct {
+	ServiceName         string        `json:"service_name"`
+	ServiceType         string        `json:"service_type"`
+	Connected           bool          `json:"connected"`
+	Healthy             bool          `json:"healthy"` // Connected and under its cluster's failure threshold
+	ConsecutiveFailures int           `json:"consecutive_failures"`
+	UptimePercentage    float64       `json:"uptime_percentage"`
+	LastChecked         *time.Time    `json:"last_checked,omitempty"`
+	SuccessRate         float64       `json:"success_rate"` // Percentage of successful requests, 100 without requests
+	P99Latency          time.Duration `json:"p99_latency"`
+}
+
+// ClusterHealthSummary is the health of a cluster's services on the dashboard
+type ClusterHealthSummary struct {
+	ClusterID       string                 `json:"cluster_id"`
+	Name            string                 `json:"name"`
+	Namespace       string                 `json:"namespace"`
+	Healthy         bool                   `json:"healthy"` // Every service is healthy
+	HealthyServices int                    `json:"healthy_services"`
+	TotalServices   int                    `json:"total_services"`
+	Services        []ServiceHealthSummary `json:"services"`
+}
+
+// dashboardClusters returns the configurations of the active clusters of the
+// request's namespace, or of every namespace, ordered by ID
+func (s *Server) dashboardClusters(r *http.Request) ([]*cluster.Config, error) {
+	clusterIDs, err := s.gateway.ListClusters()
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(clusterIDs)
+
+	namespace := requestNamespace(r)
+	configs := make([]*cluster.Config, 0, len(clusterIDs))
+	for _, clusterID := range clusterIDs {
+		config, err := s.gateway.GetClusterConfig(clusterID)
+		if err != nil {
+			logger.Error("Failed to get cluster config", zap.String("cluster_id", clusterID), zap.Error(err))
+			continue
+		}
+		if config.IsArchived() || (namespace != "" && config.NamespaceOrDefault() != namespace) {
+			continue
+		}
+		configs = append(configs, config)
+	}
+	return configs, nil
+}
+
+// handleDashboardTraffic returns the requests per minute and error rate of every
+// cluster over a window, with a sample for every minute
+func (s *Server) handleDashboardTraffic(w http.ResponseWriter, r *http.Request) {
+	window, err := requestWindow(r)
+	if err != nil {
+		s.errorResponse(w, http.StatusBadRequest, "Invalid window", err)
+		return
+	}
+
+	configs, err := s.dashboardClusters(r)
+	if err != nil {
+		s.errorResponse(w, http.StatusInternalServerError, "Failed to list clusters", err)
+		return
+	}
+
+	now := time.Now()
+	clusters := make([]*ClusterTraffic, 0, len(configs))
+	for _, config := range configs {
+		clusters = append(clusters, clusterTraffic(config, s.gateway.GetCollector().ClusterHistory(config.ClusterID, now.Add(-window), now)))
+	}
+
+	s.jsonResponse(w, http.StatusOK, map[string]interface{}{
+		"window":     window.String(),
+		"resolution": monitor.HistoryResolution.String(),
+		"clusters":   clusters,
+		"count":      len(clusters),
+	})
+}
+
+// clusterTraffic sums the request history of a cluster's services
+func clusterTraffic(config *cluster.Config, history map[string][]monitor.MetricsSample) *ClusterTraffic {
+	traffic := &ClusterTraffic{
+		ClusterID: config.ClusterID,
+		Name:      config.Name,
+		Namespace: config.NamespaceOrDefault(),
+		Samples:   make([]TrafficSample, 0),
+	}
+
+	// Every service has a sample for every minute of the window
+	for _, samples := range history {
+		if len(traffic.Samples) == 0 {
+			for _, sample := range samples {
+				traffic.Samples = append(traffic.Samples, TrafficSample{Time: sample.Time})
+			}
+		}
+		for i, sample := range samples {
+			traffic.Samples[i].Requests += sample.Requests
+			traffic.Samples[i].FailedRequests += sample.FailedRequests
+			traffic.Requests += sample.Requests
+			traffic.FailedRequests += sample.FailedRequests
+		}
+	}
+
+	if len(traffic.Samples) > 0 {
+		traffic.RequestsPerMinute = float64(traffic.Requests) / float64(len(traffic.Samples))
+	}
+	if traffic.Requests > 0 {
+		traffic.ErrorRate = float64(traffic.FailedRequests) / float64(traffic.Requests) * 100
+	}
+	return traffic
+}
+
+// handleDashboardSlowest returns the slowest recorded operations of a window, slowest
+// first
+func (s *Server) handleDashboardSlowest(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	window, err := requestWindow(r)
+	if err != nil {
+		s.errorResponse(w, http.StatusBadRequest, "Invalid window", err)
+		return
+	}
+
+	limit := defaultSlowestLimit
+	if limitStr := query.Get("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed <= 0 {
+			s.errorResponse(w, http.StatusBadRequest, "Invalid limit", fmt.Errorf("limit must be a positive number, got %q", limitStr))
+			return
+		}
+		limit = min(parsed, maxSlowestLimit)
+	}
+
+	since := time.Now().Add(-window)
+	operations := s.gateway.GetActivityBuffer().Matching(monitor.ActivityFilters{
+		ClusterID:   query.Get("cluster_id"),
+		ServiceName: query.Get("service_name"),
+		ServiceType: query.Get("service_type"),
+		Operation:   query.Get("operation"),
+		Since:       &since,
+	})
+
+	// The most recent of operations that took as long comes first
+	sort.SliceStable(operations, func(i, j int) bool {
+		if operations[i].Duration != operations[j].Duration {
+			return operations[i].Duration > operations[j].Duration
+		}
+		return operations[i].Timestamp.After(operations[j].Timestamp)
+	})
+	operations = operations[:min(limit, len(operations))]
+
+	s.jsonResponse(w, http.StatusOK, map[string]interface{}{
+		"window":     window.String(),
+		"operations": operations,
+		"count":      len(operations),
+	})
+}
+
+// handleDashboardHealth returns the health of the services of every cluster along
+// with their uptime, success rate and p99 latency
+func (s *Server) handleDashboardHealth(w http.ResponseWriter, r *http.Request) {
+	configs, err := s.dashboardClusters(r)
+	if err != nil {
+		s.errorResponse(w, http.StatusInternalServerError, "Failed to list clusters", err)
+		return
+	}
+
+	clusters := make([]*ClusterHealthSummary, 0, len(configs))
+	var services, healthy int
+	for _, config := range configs {
+		summary := s.clusterHealthSummary(config)
+		clusters = append(clusters, summary)
+		services += summary.TotalServices
+		healthy += summary.HealthyServices
+	}
+
+	s.jsonResponse(w, http.StatusOK, map[string]interface{}{
+		"clusters":           clusters,
+		"count":              len(clusters),
+		"total_services":     services,
+		"healthy_services":   healthy,
+		"unhealthy_services": services - healthy,
+	})
+}
+
+// clusterHealthSummary summarizes the health of a cluster's services, ordered by name
+func (s *Server) clusterHealthSummary(config *cluster.Config) *ClusterHealthSummary {
+	summary := &ClusterHealthSummary{
+		ClusterID: config.ClusterID,
+		Name:      config.Name,
+		Namespace: config.NamespaceOrDefault(),
+		Services:  make([]ServiceHealthSummary, 0, len(config.Services)),
+	}
+
+	checker := s.gateway.GetHealthChecker()
+	metrics := s.gateway.GetCollector().SnapshotCluster(config.ClusterID)
+	for serviceName, serviceConfig := range config.Services {
+		name := config.ClusterID + "/" + serviceName
+		service := ServiceHealthSummary{
+			ServiceName:      serviceName,
+			ServiceType:      serviceConfig.Type,
+			UptimePercentage: 100,
+			SuccessRate:      100,
+		}
+
+		_, err := s.gateway.GetAdapter(config.ClusterID, serviceName)
+		service.Connected = err == nil
+		service.Healthy = service.Connected && checker.IsHealthy(name)
+		if history := checker.GetHealthHistory(name); history != nil {
+			service.ConsecutiveFailures = history.ConsecutiveFails
+			service.UptimePercentage = history.UptimePercent()
+			if len(history.History) > 0 {
+				lastChecked := history.History[len(history.History)-1].LastChecked
+				service.LastChecked = &lastChecked
+			}
+		}
+		if metrics != nil {
+			if svc, exists := metrics.ServiceMetrics[serviceName]; exists && svc.TotalRequests > 0 {
+				service.SuccessRate = svc.SuccessRate
+				service.P99Latency = svc.P99Latency
+			}
+		}
+
+		summary.Services = append(summary.Services, service)
+		if service.Healthy {
+			summary.HealthyServices++
+		}
+	}
+
+	sort.Slice(summary.Services, func(i, j int) bool { return summary.Services[i].ServiceName < summary.Services[j].ServiceName })
+	summary.TotalServices = len(summary.Services)
+	summary.Healthy = summary.HealthyServices == summary.TotalServices
+	return summary
+}
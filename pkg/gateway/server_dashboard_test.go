@@ -0,0 +1,105 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/akmadan/throome/internal/config"
+	"github.com/akmadan/throome/pkg/cluster"
+	"github.com/akmadan/throome/pkg/monitor"
+)
+
+func TestDashboard(t *testing.T) {
+	gw := newTestGateway(t)
+	s := &Server{config: config.DefaultConfig(), gateway: gw}
+
+	ctx := context.Background()
+	clusterID, err := gw.CreateCluster(ctx, "dashboard", &cluster.Config{
+		Namespace: "dashboard",
+		Services: map[string]cluster.ServiceConfig{
+			"store": {Type: "test-update", Host: "localhost", Port: 9887},
+			"cache": {Type: "test-update", Host: "localhost", Port: 9888},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create cluster: %v", err)
+	}
+	defer func() { _ = gw.DeleteCluster(ctx, clusterID) }()
+
+	collector := gw.GetCollector()
+	collector.RecordRequest(clusterID, "store", "test-update", time.Millisecond, true)
+	collector.RecordRequest(clusterID, "store", "test-update", time.Millisecond, true)
+	collector.RecordRequest(clusterID, "cache", "test-update", time.Millisecond, true)
+	collector.RecordRequest(clusterID, "cache", "test-update", time.Millisecond, false)
+
+	get := func(handler http.HandlerFunc, path string, v interface{}) int {
+		rec := httptest.NewRecorder()
+		handler(rec, httptest.NewRequest("GET", path, nil))
+		if rec.Code == http.StatusOK {
+			if err := json.NewDecoder(rec.Body).Decode(v); err != nil {
+				t.Fatalf("Failed to decode %s: %v", path, err)
+			}
+		}
+		return rec.Code
+	}
+
+	var traffic struct {
+		Clusters []ClusterTraffic `json:"clusters"`
+	}
+	if code := get(s.handleDashboardTraffic, "/api/v1/dashboard/traffic?window=10m&namespace=dashboard", &traffic); code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", code)
+	}
+	if len(traffic.Clusters) != 1 || traffic.Clusters[0].ClusterID != clusterID {
+		t.Fatalf("Expected only the cluster of the namespace, got %+v", traffic.Clusters)
+	}
+	summary := traffic.Clusters[0]
+	if summary.Requests != 4 || summary.FailedRequests != 1 || summary.ErrorRate != 25 {
+		t.Errorf("Expected 4 requests at a 25%% error rate, got %+v", summary)
+	}
+	if len(summary.Samples) != 11 {
+		t.Errorf("Expected 11 samples over 10 minutes, got %d", len(summary.Samples))
+	}
+	if code := get(s.handleDashboardTraffic, "/api/v1/dashboard/traffic?window=soon", &traffic); code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid window, got %d", code)
+	}
+
+	buffer := gw.GetActivityBuffer()
+	for _, duration := range []int64{3, 9, 1, 7} {
+		buffer.Add(&monitor.ActivityLog{ClusterID: clusterID, Operation: "QUERY", Duration: duration, Timestamp: time.Now()})
+	}
+	buffer.Add(&monitor.ActivityLog{ClusterID: clusterID, Operation: "SLOW", Duration: 1000, Timestamp: time.Now().Add(-2 * time.Hour)})
+
+	var slowest struct {
+		Operations []monitor.ActivityLog `json:"operations"`
+	}
+	if code := get(s.handleDashboardSlowest, "/api/v1/dashboard/slowest?limit=2&cluster_id="+clusterID, &slowest); code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", code)
+	}
+	if len(slowest.Operations) != 2 || slowest.Operations[0].Duration != 9 || slowest.Operations[1].Duration != 7 {
+		t.Errorf("Expected the two slowest operations of the last hour, got %+v", slowest.Operations)
+	}
+	if code := get(s.handleDashboardSlowest, "/api/v1/dashboard/slowest?limit=0", &slowest); code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid limit, got %d", code)
+	}
+
+	var health struct {
+		Clusters        []ClusterHealthSummary `json:"clusters"`
+		HealthyServices int                    `json:"healthy_services"`
+	}
+	if code := get(s.handleDashboardHealth, "/api/v1/dashboard/health?namespace=dashboard", &health); code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", code)
+	}
+	if len(health.Clusters) != 1 || health.Clusters[0].TotalServices != 2 || !health.Clusters[0].Healthy {
+		t.Fatalf("Expected the healthy cluster with 2 services, got %+v", health.Clusters)
+	}
+	if services := health.Clusters[0].Services; services[0].ServiceName != "cache" || services[0].SuccessRate != 50 {
+		t.Errorf("Expected the cache first with a 50%% success rate, got %+v", services[0])
+	}
+	if health.HealthyServices != 2 {
+		t.Errorf("Expected 2 healthy services, got %d", health.HealthyServices)
+	}
+}
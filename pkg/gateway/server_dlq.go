@@ -0,0 +1,152 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/akmadan/throome/internal/logger"
+	"github.com/akmadan/throome/pkg/adapters"
+	"github.com/akmadan/throome/pkg/adapters/kafka"
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+)
+
+// DLQ request/response types
+type DLQEntry struct {
+	*adapters.DeadLetter
+	DLQOffset    int64 `json:"dlq_offset"`
+	DLQPartition int   `json:"dlq_partition"`
+}
+
+type DLQReplayRequest struct {
+	IDs   []string `json:"ids,omitempty"`   // Dead letters to replay, all when empty
+	Limit int      `json:"limit,omitempty"` // Maximum number of dead letters to replay
+}
+
+type DLQReplayResponse struct {
+	Replayed int      `json:"replayed"`
+	Failed   []string `json:"failed,omitempty"` // IDs that could not be republished
+}
+
+// handleGetDLQ lists the dead letters recorded for a topic
+func (s *Server) handleGetDLQ(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	clusterID := vars["cluster_id"]
+	topic := vars["topic"]
+
+	limit := 100
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if parsed, err := strconv.Atoi(limitStr); err == nil && parsed > 0 {
+			limit = parsed
+		}
+	}
+	if limit > 1000 {
+		limit = 1000
+	}
+
+	kafkaAdapter, serviceConfig, adapterErr := s.kafkaAdapter(r.Context(), clusterID)
+	if adapterErr != nil {
+		s.errorResponse(w, adapterErr.status, adapterErr.message, adapterErr.err)
+		return
+	}
+
+	dlqTopic := deadLetterPolicy(serviceConfig.DeadLetter).DeadLetterTopic(topic)
+	entries, err := readDeadLetters(r.Context(), kafkaAdapter, dlqTopic, limit)
+	if err != nil {
+		s.errorResponse(w, http.StatusInternalServerError, "Failed to read dead-letter topic", err)
+		return
+	}
+
+	s.jsonResponse(w, http.StatusOK, map[string]interface{}{
+		"topic":        topic,
+		"dlq_topic":    dlqTopic,
+		"dead_letters": entries,
+		"count":        len(entries),
+	})
+}
+
+// handleReplayDLQ republishes dead letters to the topic they originally failed on
+func (s *Server) handleReplayDLQ(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	clusterID := vars["cluster_id"]
+	topic := vars["topic"]
+
+	var req DLQReplayRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		s.errorResponse(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	kafkaAdapter, serviceConfig, adapterErr := s.kafkaAdapter(r.Context(), clusterID)
+	if adapterErr != nil {
+		s.errorResponse(w, adapterErr.status, adapterErr.message, adapterErr.err)
+		return
+	}
+
+	dlqTopic := deadLetterPolicy(serviceConfig.DeadLetter).DeadLetterTopic(topic)
+	entries, err := readDeadLetters(r.Context(), kafkaAdapter, dlqTopic, 0)
+	if err != nil {
+		s.errorResponse(w, http.StatusInternalServerError, "Failed to read dead-letter topic", err)
+		return
+	}
+
+	selected := make(map[string]bool, len(req.IDs))
+	for _, id := range req.IDs {
+		selected[id] = true
+	}
+
+	response := DLQReplayResponse{}
+	for _, entry := range entries {
+		if len(selected) > 0 && !selected[entry.ID] {
+			continue
+		}
+		if req.Limit > 0 && response.Replayed+len(response.Failed) >= req.Limit {
+			break
+		}
+
+		if err := kafkaAdapter.PublishWithKey(r.Context(), entry.OriginalTopic, entry.Key, entry.Value); err != nil {
+			logger.Error("Failed to replay dead letter",
+				zap.String("cluster_id", clusterID),
+				zap.String("id", entry.ID),
+				zap.Error(err),
+			)
+			response.Failed = append(response.Failed, entry.ID)
+			continue
+		}
+		response.Replayed++
+	}
+
+	s.jsonResponse(w, http.StatusOK, response)
+}
+
+// readDeadLetters reads and decodes the envelopes on a dead-letter topic. Messages that
+// aren't valid envelopes are skipped.
+func readDeadLetters(ctx context.Context, kafkaAdapter *kafka.KafkaAdapter, dlqTopic string, limit int) ([]*DLQEntry, error) {
+	messages, err := kafkaAdapter.ReadMessages(ctx, dlqTopic, kafka.ReadOptions{Limit: limit})
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]*DLQEntry, 0, len(messages))
+	for _, message := range messages {
+		letter, err := adapters.DecodeDeadLetter(message.Value)
+		if err != nil {
+			logger.Warn("Skipping invalid dead letter",
+				zap.String("topic", dlqTopic),
+				zap.Int64("offset", message.Offset),
+				zap.Error(err),
+			)
+			continue
+		}
+		entries = append(entries, &DLQEntry{
+			DeadLetter:   letter,
+			DLQOffset:    message.Offset,
+			DLQPartition: message.Partition,
+		})
+	}
+
+	return entries, nil
+}
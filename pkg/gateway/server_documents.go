@@ -0,0 +1,211 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/akmadan/throome/pkg/adapters"
+	"github.com/gorilla/mux"
+)
+
+// Document operation request/response types
+type DocumentInsertRequest struct {
+	Documents []adapters.Document `json:"documents"`
+}
+
+type DocumentFindRequest struct {
+	Filter adapters.Document `json:"filter"`
+	adapters.FindOptions
+}
+
+type DocumentUpdateRequest struct {
+	Filter adapters.Document `json:"filter"`
+	Update adapters.Document `json:"update"` // Update operators such as $set
+}
+
+type DocumentDeleteRequest struct {
+	Filter adapters.Document `json:"filter"`
+}
+
+type DocumentAggregateRequest struct {
+	Pipeline []adapters.Document `json:"pipeline"`
+}
+
+type DocumentsResponse struct {
+	Documents []adapters.Document `json:"documents"`
+	Count     int                 `json:"count"`
+}
+
+// handleDocumentInsert inserts documents into a collection
+func (s *Server) handleDocumentInsert(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var req DocumentInsertRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.errorResponse(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	if len(req.Documents) == 0 {
+		s.errorResponse(w, http.StatusBadRequest, "At least one document is required", nil)
+		return
+	}
+
+	documentAdapter, adapterErr := s.documentAdapter(r.Context(), vars["cluster_id"])
+	if adapterErr != nil {
+		s.errorResponse(w, adapterErr.status, adapterErr.message, adapterErr.err)
+		return
+	}
+
+	ids, err := documentAdapter.Insert(r.Context(), vars["collection"], req.Documents)
+	if err != nil {
+		s.errorResponse(w, http.StatusInternalServerError, "Failed to insert documents", err)
+		return
+	}
+
+	s.jsonResponse(w, http.StatusOK, map[string]interface{}{
+		"inserted_ids": ids,
+		"count":        len(ids),
+	})
+}
+
+// handleDocumentFind finds documents in a collection
+func (s *Server) handleDocumentFind(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var req DocumentFindRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.errorResponse(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	documentAdapter, adapterErr := s.documentAdapter(r.Context(), vars["cluster_id"])
+	if adapterErr != nil {
+		s.errorResponse(w, adapterErr.status, adapterErr.message, adapterErr.err)
+		return
+	}
+
+	documents, err := documentAdapter.Find(r.Context(), vars["collection"], req.Filter, req.FindOptions)
+	if err != nil {
+		s.errorResponse(w, http.StatusInternalServerError, "Failed to find documents", err)
+		return
+	}
+
+	s.jsonResponse(w, http.StatusOK, DocumentsResponse{Documents: documents, Count: len(documents)})
+}
+
+// handleDocumentUpdate updates the documents of a collection matching a filter
+func (s *Server) handleDocumentUpdate(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var req DocumentUpdateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.errorResponse(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	if len(req.Update) == 0 {
+		s.errorResponse(w, http.StatusBadRequest, "Update is required", nil)
+		return
+	}
+
+	documentAdapter, adapterErr := s.documentAdapter(r.Context(), vars["cluster_id"])
+	if adapterErr != nil {
+		s.errorResponse(w, adapterErr.status, adapterErr.message, adapterErr.err)
+		return
+	}
+
+	modified, err := documentAdapter.Update(r.Context(), vars["collection"], req.Filter, req.Update)
+	if err != nil {
+		s.errorResponse(w, http.StatusInternalServerError, "Failed to update documents", err)
+		return
+	}
+
+	s.jsonResponse(w, http.StatusOK, map[string]interface{}{
+		"modified_count": modified,
+	})
+}
+
+// handleDocumentDelete deletes the documents of a collection matching a filter
+func (s *Server) handleDocumentDelete(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var req DocumentDeleteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.errorResponse(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	// Refuse to empty a collection by accident
+	if len(req.Filter) == 0 {
+		s.errorResponse(w, http.StatusBadRequest, "Filter is required", nil)
+		return
+	}
+
+	documentAdapter, adapterErr := s.documentAdapter(r.Context(), vars["cluster_id"])
+	if adapterErr != nil {
+		s.errorResponse(w, adapterErr.status, adapterErr.message, adapterErr.err)
+		return
+	}
+
+	deleted, err := documentAdapter.Delete(r.Context(), vars["collection"], req.Filter)
+	if err != nil {
+		s.errorResponse(w, http.StatusInternalServerError, "Failed to delete documents", err)
+		return
+	}
+
+	s.jsonResponse(w, http.StatusOK, map[string]interface{}{
+		"deleted_count": deleted,
+	})
+}
+
+// handleDocumentAggregate runs an aggregation pipeline on a collection
+func (s *Server) handleDocumentAggregate(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var req DocumentAggregateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.errorResponse(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	documentAdapter, adapterErr := s.documentAdapter(r.Context(), vars["cluster_id"])
+	if adapterErr != nil {
+		s.errorResponse(w, adapterErr.status, adapterErr.message, adapterErr.err)
+		return
+	}
+
+	documents, err := documentAdapter.Aggregate(r.Context(), vars["collection"], req.Pipeline)
+	if err != nil {
+		s.errorResponse(w, http.StatusInternalServerError, "Failed to run aggregation", err)
+		return
+	}
+
+	s.jsonResponse(w, http.StatusOK, DocumentsResponse{Documents: documents, Count: len(documents)})
+}
+
+// documentAdapter resolves the document store adapter of a cluster
+func (s *Server) documentAdapter(ctx context.Context, clusterID string) (adapters.DocumentAdapter, *adapterError) {
+	config, err := s.gateway.GetClusterConfig(clusterID)
+	if err != nil {
+		return nil, &adapterError{http.StatusNotFound, "Cluster not found", err}
+	}
+
+	mongoService, adapterErr := s.selectService(ctx, clusterID, config, "mongodb")
+	if adapterErr != nil {
+		return nil, adapterErr
+	}
+	if mongoService == "" {
+		return nil, &adapterError{http.StatusNotFound, "No MongoDB service found in cluster", nil}
+	}
+
+	adapter, err := s.serviceAdapter(ctx, clusterID, mongoService)
+	if err != nil {
+		return nil, &adapterError{adapterStatus(err), "Failed to get document adapter", err}
+	}
+
+	documentAdapter, ok := adapter.(adapters.DocumentAdapter)
+	if !ok {
+		return nil, &adapterError{http.StatusInternalServerError, "Adapter is not a DocumentAdapter", nil}
+	}
+
+	return documentAdapter, nil
+}
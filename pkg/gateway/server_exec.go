@@ -0,0 +1,192 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+
+	"github.com/akmadan/throome/internal/logger"
+)
+
+// ExitCodeTrailer carries the exit code of a command run with /exec
+const ExitCodeTrailer = "X-Exit-Code"
+
+// Time limits of a command run with /exec
+const (
+	defaultExecTimeout = 60 * time.Second
+	maxExecTimeout     = 10 * time.Minute
+)
+
+// execCommands are the commands that can be run in the container of each service
+// type, mapped to the program they run
+var execCommands = map[string]map[string]string{
+	"postgres":    {"psql": "psql", "pg_isready": "pg_isready"},
+	"cockroachdb": {"cockroach": "cockroach"},
+	"redis":       {"redis-cli": "redis-cli"},
+	"kafka": {
+		"kafka-topics":          "/opt/kafka/bin/kafka-topics.sh",
+		"kafka-consumer-groups": "/opt/kafka/bin/kafka-consumer-groups.sh",
+		"kafka-configs":         "/opt/kafka/bin/kafka-configs.sh",
+	},
+	"mongodb":  {"mongosh": "mongosh"},
+	"rabbitmq": {"rabbitmqctl": "rabbitmqctl", "rabbitmq-diagnostics": "rabbitmq-diagnostics"},
+	"minio":    {"mc": "mc"},
+	"etcd":     {"etcdctl": "etcdctl"},
+	"influxdb": {"influx": "influx"},
+	"mqtt":     {"mosquitto_sub": "mosquitto_sub", "mosquitto_pub": "mosquitto_pub"},
+	"vault":    {"vault": "vault"},
+}
+
+// ExecRequest is the command to run in a service's container
+type ExecRequest struct {
+	Command        []string `json:"command"`                   // An allowlisted command followed by its arguments
+	TimeoutSeconds int      `json:"timeout_seconds,omitempty"` // 60 by default, at most 600
+}
+
+// handleExecService runs an allowlisted diagnostic command in the container of a
+// provisioned service. Its output is streamed as it is produced and its exit code is
+// sent in the ExitCodeTrailer trailer.
+func (s *Server) handleExecService(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	clusterID := vars["cluster_id"]
+	serviceName := vars["service_name"]
+
+	var req ExecRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.errorResponse(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	if len(req.Command) == 0 {
+		s.errorResponse(w, http.StatusBadRequest, "Command is required", nil)
+		return
+	}
+	timeout := defaultExecTimeout
+	if req.TimeoutSeconds > 0 {
+		timeout = time.Duration(req.TimeoutSeconds) * time.Second
+	}
+	if timeout > maxExecTimeout {
+		s.errorResponse(w, http.StatusBadRequest, fmt.Sprintf("Timeout cannot exceed %d seconds", int(maxExecTimeout.Seconds())), nil)
+		return
+	}
+
+	config, err := s.gateway.GetClusterConfig(clusterID)
+	if err != nil {
+		s.errorResponse(w, http.StatusNotFound, "Cluster not found", err)
+		return
+	}
+	if config.IsArchived() {
+		s.errorResponse(w, http.StatusConflict, "Cluster is archived, restore it first", nil)
+		return
+	}
+	serviceConfig, exists := config.Services[serviceName]
+	if !exists {
+		s.errorResponse(w, http.StatusNotFound, "Service not found", nil)
+		return
+	}
+	if serviceConfig.ContainerID == "" {
+		s.errorResponse(w, http.StatusBadRequest, "Service is not provisioned by Throome", nil)
+		return
+	}
+
+	commands := execCommands[serviceConfig.Type]
+	program, allowed := commands[req.Command[0]]
+	if !allowed {
+		names := make([]string, 0, len(commands))
+		for name := range commands {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		s.errorResponse(w, http.StatusForbidden,
+			fmt.Sprintf("Command %s is not allowed for %s services, allowed: %s", req.Command[0], serviceConfig.Type, strings.Join(names, ", ")), nil)
+		return
+	}
+
+	serviceProvisioner, err := s.gateway.clusterProvisioner(config)
+	if err != nil {
+		s.errorResponse(w, http.StatusInternalServerError, "Failed to select the cluster's Docker host", err)
+		return
+	}
+	if serviceProvisioner == nil {
+		s.errorResponse(w, http.StatusServiceUnavailable, "Provisioner not available", nil)
+		return
+	}
+
+	logger.Info("Running command in service",
+		zap.String("cluster_id", clusterID),
+		zap.String("service", serviceName),
+		zap.Strings("command", req.Command),
+	)
+
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	defer cancel()
+
+	cmd := append([]string{program}, req.Command[1:]...)
+	output := newExecWriter(w)
+	exitCode, err := serviceProvisioner.ExecStream(ctx, serviceConfig.ContainerID, cmd, nil, output, output)
+	if err != nil && !output.started {
+		s.errorResponse(w, http.StatusInternalServerError, "Failed to run command", err)
+		return
+	}
+
+	output.start()
+	w.Header().Set(ExitCodeTrailer, strconv.Itoa(exitCode))
+	if err != nil {
+		w.Header().Set(StreamErrorTrailer, strings.ReplaceAll(err.Error(), "\n", " "))
+	}
+	_ = output.rc.Flush()
+}
+
+// execWriter streams the output of a command to a response, sending the status with
+// the first output so failures to start the command can still be reported as errors.
+// Standard output and error may be written concurrently.
+type execWriter struct {
+	mu      sync.Mutex
+	w       http.ResponseWriter
+	rc      *http.ResponseController
+	started bool
+}
+
+func newExecWriter(w http.ResponseWriter) *execWriter {
+	return &execWriter{w: w, rc: http.NewResponseController(w)}
+}
+
+func (ew *execWriter) Write(p []byte) (int, error) {
+	ew.mu.Lock()
+	defer ew.mu.Unlock()
+
+	ew.startLocked()
+	n, err := ew.w.Write(p)
+	if err == nil {
+		_ = ew.rc.Flush()
+	}
+	return n, err
+}
+
+// start sends the status of a response that has not been started yet
+func (ew *execWriter) start() {
+	ew.mu.Lock()
+	defer ew.mu.Unlock()
+	ew.startLocked()
+}
+
+func (ew *execWriter) startLocked() {
+	if ew.started {
+		return
+	}
+	ew.started = true
+
+	ew.w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	ew.w.Header().Set("Trailer", strings.Join([]string{ExitCodeTrailer, StreamErrorTrailer}, ", "))
+	ew.w.WriteHeader(http.StatusOK)
+	// Long-running commands outlast the server's write timeout
+	_ = ew.rc.SetWriteDeadline(time.Time{})
+}
@@ -0,0 +1,84 @@
+package gateway
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+
+	"github.com/akmadan/throome/internal/config"
+	"github.com/akmadan/throome/pkg/cluster"
+	"github.com/akmadan/throome/pkg/provisioner"
+)
+
+// fakeExec runs commands by echoing them
+type fakeExec struct {
+	provisioner.Provisioner
+	commands [][]string
+}
+
+func (f *fakeExec) ExecStream(ctx context.Context, containerID string, cmd []string, stdin io.Reader, stdout, stderr io.Writer) (int, error) {
+	f.commands = append(f.commands, cmd)
+	_, _ = io.WriteString(stdout, strings.Join(cmd[1:], " ")+"\n")
+	_, _ = io.WriteString(stderr, "done\n")
+	return 3, nil
+}
+
+func TestExecService(t *testing.T) {
+	gw := newTestGateway(t)
+	containers := &fakeExec{}
+	gw.SetProvisioner(containers)
+	defer gw.SetProvisioner(nil)
+	s := &Server{config: config.DefaultConfig(), gateway: gw}
+	execCommands["test-update"] = map[string]string{"diag": "/usr/bin/diag"}
+	defer delete(execCommands, "test-update")
+
+	clusterID, err := gw.CreateCluster(context.Background(), "exec", &cluster.Config{
+		Services: map[string]cluster.ServiceConfig{
+			"managed": {Type: "test-update", Host: "localhost", Port: 9811, ContainerID: "c1"},
+			"remote":  {Type: "test-update", Host: "localhost", Port: 9812},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create cluster: %v", err)
+	}
+
+	router := mux.NewRouter()
+	router.HandleFunc("/clusters/{cluster_id}/services/{service_name}/exec", s.handleExecService)
+	exec := func(serviceName, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest("POST", "/clusters/"+clusterID+"/services/"+serviceName+"/exec", strings.NewReader(body)))
+		return rec
+	}
+
+	rec := exec("managed", `{"command": ["diag", "--verbose"]}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected the command to run, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec.Body.String() != "--verbose\ndone\n" {
+		t.Errorf("Expected the command's output, got %q", rec.Body.String())
+	}
+	if code := rec.Result().Trailer.Get(ExitCodeTrailer); code != "3" {
+		t.Errorf("Expected exit code 3 in the trailer, got %q", code)
+	}
+	if len(containers.commands) != 1 || containers.commands[0][0] != "/usr/bin/diag" {
+		t.Errorf("Expected the allowlisted program to run, got %v", containers.commands)
+	}
+
+	if rec := exec("managed", `{"command": ["sh", "-c", "id"]}`); rec.Code != http.StatusForbidden {
+		t.Errorf("Expected commands outside the allowlist to be refused, got %d", rec.Code)
+	}
+	if rec := exec("remote", `{"command": ["diag"]}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected services without a container to be refused, got %d", rec.Code)
+	}
+	if rec := exec("managed", `{"command": ["diag"], "timeout_seconds": 3600}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected timeouts over the limit to be refused, got %d", rec.Code)
+	}
+	if len(containers.commands) != 1 {
+		t.Errorf("Expected refused commands not to run, got %v", containers.commands)
+	}
+}
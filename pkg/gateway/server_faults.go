@@ -0,0 +1,120 @@
+package gateway
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/akmadan/throome/pkg/adapters"
+	"github.com/gorilla/mux"
+)
+
+// Fault injection request/response types
+type FaultRequest struct {
+	LatencyMS       int     `json:"latency_ms"`
+	JitterMS        int     `json:"jitter_ms"`
+	ErrorRate       float64 `json:"error_rate"` // Percentage of operations that fail
+	DropRate        float64 `json:"drop_rate"`  // Percentage of operations that fail as dropped connections
+	Unhealthy       bool    `json:"unhealthy"`
+	DurationSeconds int     `json:"duration_seconds,omitempty"` // Faults are removed after this long, kept until cleared when zero
+}
+
+type FaultResponse struct {
+	LatencyMS int       `json:"latency_ms"`
+	JitterMS  int       `json:"jitter_ms"`
+	ErrorRate float64   `json:"error_rate"`
+	DropRate  float64   `json:"drop_rate"`
+	Unhealthy bool      `json:"unhealthy"`
+	ExpiresAt time.Time `json:"expires_at,omitempty"`
+}
+
+func newFaultResponse(config *adapters.FaultConfig) FaultResponse {
+	return FaultResponse{
+		LatencyMS: int(config.Latency / time.Millisecond),
+		JitterMS:  int(config.Jitter / time.Millisecond),
+		ErrorRate: config.ErrorRate,
+		DropRate:  config.DropRate,
+		Unhealthy: config.Unhealthy,
+		ExpiresAt: config.ExpiresAt,
+	}
+}
+
+// handleGetFaults lists the faults injected into a cluster's services
+func (s *Server) handleGetFaults(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	clusterID := vars["cluster_id"]
+
+	faults, err := s.gateway.GetFaults(clusterID)
+	if err != nil {
+		s.errorResponse(w, http.StatusNotFound, "Cluster not found", err)
+		return
+	}
+
+	response := make(map[string]FaultResponse, len(faults))
+	for serviceName, config := range faults {
+		response[serviceName] = newFaultResponse(config)
+	}
+
+	s.jsonResponse(w, http.StatusOK, map[string]interface{}{
+		"cluster_id": clusterID,
+		"faults":     response,
+	})
+}
+
+// handleSetFault injects faults into a service to test application resilience and
+// router failover
+func (s *Server) handleSetFault(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	clusterID := vars["cluster_id"]
+	serviceName := vars["service_name"]
+
+	var req FaultRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.errorResponse(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	if _, err := s.gateway.GetAdapter(clusterID, serviceName); err != nil {
+		s.errorResponse(w, http.StatusNotFound, "Service not found", err)
+		return
+	}
+
+	config := adapters.FaultConfig{
+		Latency:   time.Duration(req.LatencyMS) * time.Millisecond,
+		Jitter:    time.Duration(req.JitterMS) * time.Millisecond,
+		ErrorRate: req.ErrorRate,
+		DropRate:  req.DropRate,
+		Unhealthy: req.Unhealthy,
+	}
+	if req.DurationSeconds > 0 {
+		config.ExpiresAt = time.Now().Add(time.Duration(req.DurationSeconds) * time.Second)
+	}
+
+	if err := s.gateway.SetFault(clusterID, serviceName, config); err != nil {
+		s.errorResponse(w, http.StatusBadRequest, "Invalid fault configuration", err)
+		return
+	}
+
+	s.jsonResponse(w, http.StatusOK, map[string]interface{}{
+		"cluster_id": clusterID,
+		"service":    serviceName,
+		"fault":      newFaultResponse(&config),
+	})
+}
+
+// handleClearFault removes the faults injected into a service
+func (s *Server) handleClearFault(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	clusterID := vars["cluster_id"]
+	serviceName := vars["service_name"]
+
+	if err := s.gateway.ClearFault(clusterID, serviceName); err != nil {
+		s.errorResponse(w, http.StatusNotFound, "Service not found", err)
+		return
+	}
+
+	s.jsonResponse(w, http.StatusOK, map[string]interface{}{
+		"message": "Faults cleared",
+		"service": serviceName,
+	})
+}
@@ -0,0 +1,123 @@
+package gateway
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/akmadan/throome/pkg/adapters/kafka"
+	"github.com/gorilla/mux"
+)
+
+// Consumer group request/response types
+type ListConsumerGroupsResponse struct {
+	Groups []*kafka.ConsumerGroup `json:"groups"`
+}
+
+type ResetOffsetsRequest struct {
+	Topic      string    `json:"topic"`
+	To         string    `json:"to"`                   // earliest, latest or timestamp
+	Timestamp  time.Time `json:"timestamp,omitempty"`  // For to timestamp
+	Partitions []int     `json:"partitions,omitempty"` // All partitions when empty
+}
+
+type ResetOffsetsResponse struct {
+	GroupID string        `json:"group_id"`
+	Topic   string        `json:"topic"`
+	Offsets map[int]int64 `json:"offsets"` // Committed offset per partition
+}
+
+// handleListConsumerGroups lists the consumer groups of the cluster's Kafka service
+func (s *Server) handleListConsumerGroups(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	kafkaAdapter, _, adapterErr := s.kafkaAdapter(r.Context(), vars["cluster_id"])
+	if adapterErr != nil {
+		s.errorResponse(w, adapterErr.status, adapterErr.message, adapterErr.err)
+		return
+	}
+
+	groups, err := kafkaAdapter.ListConsumerGroups(r.Context())
+	if err != nil {
+		s.errorResponse(w, http.StatusInternalServerError, "Failed to list consumer groups", err)
+		return
+	}
+
+	s.jsonResponse(w, http.StatusOK, ListConsumerGroupsResponse{
+		Groups: groups,
+	})
+}
+
+// handleGetConsumerGroup describes a consumer group with its members and its lag per
+// partition
+func (s *Server) handleGetConsumerGroup(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	kafkaAdapter, _, adapterErr := s.kafkaAdapter(r.Context(), vars["cluster_id"])
+	if adapterErr != nil {
+		s.errorResponse(w, adapterErr.status, adapterErr.message, adapterErr.err)
+		return
+	}
+
+	detail, err := kafkaAdapter.DescribeConsumerGroup(r.Context(), vars["group"])
+	if err != nil {
+		if errors.Is(err, kafka.ErrGroupNotFound) {
+			s.errorResponse(w, http.StatusNotFound, "Consumer group not found", err)
+			return
+		}
+		s.errorResponse(w, http.StatusInternalServerError, "Failed to describe consumer group", err)
+		return
+	}
+
+	s.jsonResponse(w, http.StatusOK, detail)
+}
+
+// handleResetConsumerGroup moves a consumer group's offsets on a topic to the earliest
+// or latest message, or to a timestamp. The group must have no active members.
+func (s *Server) handleResetConsumerGroup(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var req ResetOffsetsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.errorResponse(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	if req.Topic == "" {
+		s.errorResponse(w, http.StatusBadRequest, "Topic is required", nil)
+		return
+	}
+	switch req.To {
+	case kafka.ResetEarliest, kafka.ResetLatest:
+	case kafka.ResetTimestamp:
+		if req.Timestamp.IsZero() {
+			s.errorResponse(w, http.StatusBadRequest, "timestamp is required to reset to a timestamp", nil)
+			return
+		}
+	default:
+		s.errorResponse(w, http.StatusBadRequest, "to must be earliest, latest or timestamp", nil)
+		return
+	}
+
+	kafkaAdapter, _, adapterErr := s.kafkaAdapter(r.Context(), vars["cluster_id"])
+	if adapterErr != nil {
+		s.errorResponse(w, adapterErr.status, adapterErr.message, adapterErr.err)
+		return
+	}
+
+	offsets, err := kafkaAdapter.ResetGroupOffsets(r.Context(), vars["group"], req.Topic, req.To, req.Timestamp, req.Partitions)
+	if err != nil {
+		if errors.Is(err, kafka.ErrGroupActive) {
+			s.errorResponse(w, http.StatusConflict, "Consumer group has active members", err)
+			return
+		}
+		s.errorResponse(w, http.StatusInternalServerError, "Failed to reset consumer group offsets", err)
+		return
+	}
+
+	s.jsonResponse(w, http.StatusOK, ResetOffsetsResponse{
+		GroupID: vars["group"],
+		Topic:   req.Topic,
+		Offsets: offsets,
+	})
+}
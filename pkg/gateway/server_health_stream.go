@@ -0,0 +1,148 @@
+package gateway
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/akmadan/throome/internal/logger"
+	"github.com/akmadan/throome/pkg/adapters"
+	"github.com/akmadan/throome/pkg/monitor"
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+)
+
+const (
+	// healthStreamHeartbeat is how often idle health streams get a comment, so
+	// proxies do not close them
+	healthStreamHeartbeat = 30 * time.Second
+	healthStreamBuffer    = 16
+)
+
+// HealthStreamEvent is the data of a health event on the health stream
+type HealthStreamEvent struct {
+	ClusterID        string                `json:"cluster_id"`
+	Service          string                `json:"service"`
+	Healthy          bool                  `json:"healthy"`
+	ConsecutiveFails int                   `json:"consecutive_fails"`
+	Status           adapters.HealthStatus `json:"status"` // The check that caused the transition
+	Timestamp        time.Time             `json:"timestamp"`
+}
+
+// healthStreams fans the health checker's transitions out to health stream clients
+type healthStreams struct {
+	mu          sync.RWMutex
+	subscribers map[chan *monitor.HealthTransition]struct{}
+}
+
+func newHealthStreams() *healthStreams {
+	return &healthStreams{
+		subscribers: make(map[chan *monitor.HealthTransition]struct{}),
+	}
+}
+
+// publish sends a transition to every client. Clients that fall too far behind
+// miss transitions rather than blocking the health checker.
+func (h *healthStreams) publish(transition *monitor.HealthTransition) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for subscriber := range h.subscribers {
+		select {
+		case subscriber <- transition:
+		default:
+			logger.Debug("Dropping health transition for slow client", zap.String("service", transition.ServiceName))
+		}
+	}
+}
+
+func (h *healthStreams) subscribe() chan *monitor.HealthTransition {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	subscriber := make(chan *monitor.HealthTransition, healthStreamBuffer)
+	h.subscribers[subscriber] = struct{}{}
+	return subscriber
+}
+
+func (h *healthStreams) unsubscribe(subscriber chan *monitor.HealthTransition) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	delete(h.subscribers, subscriber)
+}
+
+// handleClusterHealthStream streams the health transitions of a cluster's services
+// as server-sent events. An event is sent whenever the health checker finds that a
+// service turned unhealthy or recovered; the current health is served by the
+// cluster health endpoint.
+func (s *Server) handleClusterHealthStream(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	clusterID := vars["cluster_id"]
+
+	if _, err := s.gateway.GetClusterConfig(clusterID); err != nil {
+		s.errorResponse(w, http.StatusNotFound, "Cluster not found", err)
+		return
+	}
+
+	transitions := s.healthStreams.subscribe()
+	defer s.healthStreams.unsubscribe(transitions)
+
+	// Streams outlive the server's write timeout
+	controller := http.NewResponseController(w)
+	_ = controller.SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no") // Keep nginx from buffering events
+	w.WriteHeader(http.StatusOK)
+	if err := controller.Flush(); err != nil {
+		logger.Warn("Health stream not supported by connection", zap.Error(err))
+		return
+	}
+
+	heartbeat := time.NewTicker(healthStreamHeartbeat)
+	defer heartbeat.Stop()
+
+	prefix := clusterID + "/"
+	var eventID int64
+	for {
+		select {
+		case transition := <-transitions:
+			serviceName, ok := strings.CutPrefix(transition.ServiceName, prefix)
+			if !ok {
+				continue
+			}
+			data, err := json.Marshal(&HealthStreamEvent{
+				ClusterID:        clusterID,
+				Service:          serviceName,
+				Healthy:          transition.Healthy,
+				ConsecutiveFails: transition.ConsecutiveFails,
+				Status:           transition.Status,
+				Timestamp:        transition.Timestamp,
+			})
+			if err != nil {
+				continue
+			}
+			eventID++
+			if _, err := fmt.Fprintf(w, "id: %d\nevent: health\ndata: %s\n\n", eventID, data); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		case <-r.Context().Done():
+			return
+		case <-s.realtimeStop:
+			return
+		}
+		if err := controller.Flush(); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package gateway
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/akmadan/throome/internal/config"
+	"github.com/akmadan/throome/pkg/cluster"
+	"github.com/akmadan/throome/pkg/monitor"
+)
+
+func TestClusterHealthStream(t *testing.T) {
+	gw := newTestGateway(t)
+	s := &Server{config: config.DefaultConfig(), gateway: gw, router: mux.NewRouter(), healthStreams: newHealthStreams()}
+	s.setupRoutes()
+	server := httptest.NewServer(s.router)
+	defer server.Close()
+
+	clusterID, err := gw.CreateCluster(context.Background(), "health-stream", &cluster.Config{
+		Services: map[string]cluster.ServiceConfig{
+			"app": {Type: "test-update", Host: "localhost", Port: 9501},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create cluster: %v", err)
+	}
+
+	resp, err := http.Get(server.URL + "/api/v1/clusters/missing/health/stream")
+	if err != nil {
+		t.Fatalf("Failed to request stream: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected 404 for a missing cluster, got %d", resp.StatusCode)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, "GET", server.URL+"/api/v1/clusters/"+clusterID+"/health/stream", nil)
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to request stream: %v", err)
+	}
+	defer resp.Body.Close()
+	if contentType := resp.Header.Get("Content-Type"); contentType != "text/event-stream" {
+		t.Fatalf("Expected an event stream, got %s", contentType)
+	}
+
+	// Only transitions of the cluster's services are streamed
+	s.healthStreams.publish(&monitor.HealthTransition{ServiceName: "other/app", Healthy: false})
+	s.healthStreams.publish(&monitor.HealthTransition{ServiceName: clusterID + "/app", Healthy: false, ConsecutiveFails: 3})
+
+	reader := bufio.NewReader(resp.Body)
+	var event, data string
+	for data == "" {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("Failed to read event: %v", err)
+		}
+		switch {
+		case strings.HasPrefix(line, "event: "):
+			event = strings.TrimSpace(strings.TrimPrefix(line, "event: "))
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+
+	var health HealthStreamEvent
+	if err := json.Unmarshal([]byte(data), &health); err != nil {
+		t.Fatalf("Failed to decode event: %v", err)
+	}
+	if event != "health" || health.ClusterID != clusterID || health.Service != "app" || health.Healthy || health.ConsecutiveFails != 3 {
+		t.Errorf("Unexpected event %s: %+v", event, health)
+	}
+}
@@ -0,0 +1,192 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/akmadan/throome/pkg/adapters"
+	"github.com/akmadan/throome/pkg/adapters/dynamodb"
+	"github.com/gorilla/mux"
+)
+
+// Key-document operation request/response types
+type TableCreateRequest struct {
+	Name         string `json:"name"`
+	PartitionKey string `json:"partition_key"`
+	SortKey      string `json:"sort_key,omitempty"`
+}
+
+type ItemPutRequest struct {
+	Item adapters.Document `json:"item"`
+}
+
+type ItemGetRequest struct {
+	Key adapters.Document `json:"key"`
+}
+
+type ItemQueryResponse struct {
+	Items []adapters.Document `json:"items"`
+	Count int                 `json:"count"`
+}
+
+// handleCreateTable creates a table keyed by string attributes
+func (s *Server) handleCreateTable(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var req TableCreateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.errorResponse(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	if req.Name == "" || req.PartitionKey == "" {
+		s.errorResponse(w, http.StatusBadRequest, "Name and partition_key are required", nil)
+		return
+	}
+
+	items, adapterErr := s.keyDocumentAdapter(r.Context(), vars["cluster_id"])
+	if adapterErr != nil {
+		s.errorResponse(w, adapterErr.status, adapterErr.message, adapterErr.err)
+		return
+	}
+
+	dynamoAdapter, ok := items.(*dynamodb.DynamoDBAdapter)
+	if !ok {
+		s.errorResponse(w, http.StatusInternalServerError, "Adapter is not a DynamoDBAdapter", nil)
+		return
+	}
+
+	if err := dynamoAdapter.CreateTable(r.Context(), req.Name, req.PartitionKey, req.SortKey); err != nil {
+		s.errorResponse(w, http.StatusInternalServerError, "Failed to create table", err)
+		return
+	}
+
+	s.jsonResponse(w, http.StatusCreated, map[string]string{
+		"message": "Table created successfully",
+		"table":   req.Name,
+	})
+}
+
+// handlePutItem creates or replaces an item
+func (s *Server) handlePutItem(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var req ItemPutRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.errorResponse(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	if len(req.Item) == 0 {
+		s.errorResponse(w, http.StatusBadRequest, "Item is required", nil)
+		return
+	}
+
+	items, adapterErr := s.keyDocumentAdapter(r.Context(), vars["cluster_id"])
+	if adapterErr != nil {
+		s.errorResponse(w, adapterErr.status, adapterErr.message, adapterErr.err)
+		return
+	}
+
+	if err := items.PutItem(r.Context(), vars["table"], req.Item); err != nil {
+		s.errorResponse(w, http.StatusInternalServerError, "Failed to put item", err)
+		return
+	}
+
+	s.jsonResponse(w, http.StatusOK, map[string]string{
+		"message": "Item stored successfully",
+	})
+}
+
+// handleGetItem returns the item with the given primary key
+func (s *Server) handleGetItem(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var req ItemGetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.errorResponse(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	if len(req.Key) == 0 {
+		s.errorResponse(w, http.StatusBadRequest, "Key is required", nil)
+		return
+	}
+
+	items, adapterErr := s.keyDocumentAdapter(r.Context(), vars["cluster_id"])
+	if adapterErr != nil {
+		s.errorResponse(w, adapterErr.status, adapterErr.message, adapterErr.err)
+		return
+	}
+
+	item, err := items.GetItem(r.Context(), vars["table"], req.Key)
+	if err != nil {
+		if errors.Is(err, adapters.ErrItemNotFound) {
+			s.errorResponse(w, http.StatusNotFound, "Item not found", err)
+			return
+		}
+		s.errorResponse(w, http.StatusInternalServerError, "Failed to get item", err)
+		return
+	}
+
+	s.jsonResponse(w, http.StatusOK, item)
+}
+
+// handleQueryItems returns the items of a partition
+func (s *Server) handleQueryItems(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var req adapters.KeyQuery
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.errorResponse(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	if req.PartitionKey == "" || req.PartitionValue == nil {
+		s.errorResponse(w, http.StatusBadRequest, "partition_key and partition_value are required", nil)
+		return
+	}
+
+	items, adapterErr := s.keyDocumentAdapter(r.Context(), vars["cluster_id"])
+	if adapterErr != nil {
+		s.errorResponse(w, adapterErr.status, adapterErr.message, adapterErr.err)
+		return
+	}
+
+	results, err := items.Query(r.Context(), vars["table"], req)
+	if err != nil {
+		s.errorResponse(w, http.StatusInternalServerError, "Failed to query items", err)
+		return
+	}
+
+	s.jsonResponse(w, http.StatusOK, ItemQueryResponse{
+		Items: results,
+		Count: len(results),
+	})
+}
+
+// keyDocumentAdapter resolves the key-document store adapter of a cluster
+func (s *Server) keyDocumentAdapter(ctx context.Context, clusterID string) (adapters.KeyDocumentAdapter, *adapterError) {
+	config, err := s.gateway.GetClusterConfig(clusterID)
+	if err != nil {
+		return nil, &adapterError{http.StatusNotFound, "Cluster not found", err}
+	}
+
+	dynamoService, adapterErr := s.selectService(ctx, clusterID, config, "dynamodb")
+	if adapterErr != nil {
+		return nil, adapterErr
+	}
+	if dynamoService == "" {
+		return nil, &adapterError{http.StatusNotFound, "No DynamoDB service found in cluster", nil}
+	}
+
+	adapter, err := s.serviceAdapter(ctx, clusterID, dynamoService)
+	if err != nil {
+		return nil, &adapterError{adapterStatus(err), "Failed to get key-document adapter", err}
+	}
+
+	items, ok := adapter.(adapters.KeyDocumentAdapter)
+	if !ok {
+		return nil, &adapterError{http.StatusInternalServerError, "Adapter is not a KeyDocumentAdapter", nil}
+	}
+
+	return items, nil
+}
@@ -0,0 +1,200 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/akmadan/throome/internal/logger"
+	"github.com/akmadan/throome/pkg/adapters"
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+)
+
+// kvWriteTimeout bounds how long a watch event may take to reach a slow client
+const kvWriteTimeout = 10 * time.Second
+
+type KVKeysResponse struct {
+	Keys  []string `json:"keys"`
+	Count int      `json:"count"`
+}
+
+// handleKVGet reads a key from the cluster's key-value store
+func (s *Server) handleKVGet(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var req CacheGetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.errorResponse(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	kv, adapterErr := s.kvAdapter(r.Context(), vars["cluster_id"])
+	if adapterErr != nil {
+		s.errorResponse(w, adapterErr.status, adapterErr.message, adapterErr.err)
+		return
+	}
+
+	value, err := kv.Get(r.Context(), req.Key)
+	if err != nil {
+		s.errorResponse(w, http.StatusInternalServerError, "Failed to get key", err)
+		return
+	}
+
+	s.jsonResponse(w, http.StatusOK, CacheGetResponse{
+		Value: value,
+	})
+}
+
+// handleKVSet writes a key to the cluster's key-value store
+func (s *Server) handleKVSet(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var req CacheSetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.errorResponse(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	kv, adapterErr := s.kvAdapter(r.Context(), vars["cluster_id"])
+	if adapterErr != nil {
+		s.errorResponse(w, adapterErr.status, adapterErr.message, adapterErr.err)
+		return
+	}
+
+	ttl := time.Duration(req.TTL) * time.Second
+	if err := kv.Set(r.Context(), req.Key, req.Value, ttl); err != nil {
+		s.errorResponse(w, http.StatusInternalServerError, "Failed to set key", err)
+		return
+	}
+
+	s.jsonResponse(w, http.StatusOK, map[string]string{
+		"status": "success",
+	})
+}
+
+// handleKVDelete deletes a key from the cluster's key-value store
+func (s *Server) handleKVDelete(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var req CacheDeleteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.errorResponse(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	kv, adapterErr := s.kvAdapter(r.Context(), vars["cluster_id"])
+	if adapterErr != nil {
+		s.errorResponse(w, adapterErr.status, adapterErr.message, adapterErr.err)
+		return
+	}
+
+	if err := kv.Delete(r.Context(), req.Key); err != nil {
+		s.errorResponse(w, http.StatusInternalServerError, "Failed to delete key", err)
+		return
+	}
+
+	s.jsonResponse(w, http.StatusOK, map[string]string{
+		"status": "success",
+	})
+}
+
+// handleKVKeys lists the keys matching a glob pattern, all keys by default
+func (s *Server) handleKVKeys(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	pattern := r.URL.Query().Get("pattern")
+	if pattern == "" {
+		pattern = "*"
+	}
+
+	kv, adapterErr := s.kvAdapter(r.Context(), vars["cluster_id"])
+	if adapterErr != nil {
+		s.errorResponse(w, adapterErr.status, adapterErr.message, adapterErr.err)
+		return
+	}
+
+	keys, err := kv.Keys(r.Context(), pattern)
+	if err != nil {
+		s.errorResponse(w, http.StatusInternalServerError, "Failed to list keys", err)
+		return
+	}
+
+	s.jsonResponse(w, http.StatusOK, KVKeysResponse{Keys: keys, Count: len(keys)})
+}
+
+// handleKVWatch upgrades the connection to a WebSocket that streams changes to the
+// keys starting with the prefix query parameter
+func (s *Server) handleKVWatch(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	prefix := r.URL.Query().Get("prefix")
+
+	// Resolve the adapter first so failures are reported as plain HTTP errors
+	kv, adapterErr := s.kvAdapter(r.Context(), vars["cluster_id"])
+	if adapterErr != nil {
+		s.errorResponse(w, adapterErr.status, adapterErr.message, adapterErr.err)
+		return
+	}
+
+	conn, err := realtimeUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade has already written the error response
+		logger.Warn("Failed to upgrade watch connection", zap.Error(err))
+		return
+	}
+	defer conn.Close()
+
+	// The request context outlives hijacked connections, so stop on client disconnect
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		defer cancel()
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	events, err := kv.Watch(ctx, prefix)
+	if err != nil {
+		_ = conn.WriteJSON(map[string]string{"error": err.Error()})
+		return
+	}
+
+	for event := range events {
+		_ = conn.SetWriteDeadline(time.Now().Add(kvWriteTimeout))
+		if err := conn.WriteJSON(event); err != nil {
+			return
+		}
+	}
+}
+
+// kvAdapter resolves the watchable key-value store of a cluster
+func (s *Server) kvAdapter(ctx context.Context, clusterID string) (adapters.WatchAdapter, *adapterError) {
+	config, err := s.gateway.GetClusterConfig(clusterID)
+	if err != nil {
+		return nil, &adapterError{http.StatusNotFound, "Cluster not found", err}
+	}
+
+	etcdService, adapterErr := s.selectService(ctx, clusterID, config, "etcd")
+	if adapterErr != nil {
+		return nil, adapterErr
+	}
+	if etcdService == "" {
+		return nil, &adapterError{http.StatusNotFound, "No etcd service found in cluster", nil}
+	}
+
+	adapter, err := s.serviceAdapter(ctx, clusterID, etcdService)
+	if err != nil {
+		return nil, &adapterError{adapterStatus(err), "Failed to get key-value adapter", err}
+	}
+
+	kv, ok := adapter.(adapters.WatchAdapter)
+	if !ok {
+		return nil, &adapterError{http.StatusInternalServerError, "Adapter is not a WatchAdapter", nil}
+	}
+
+	return kv, nil
+}
@@ -2,17 +2,16 @@ package gateway
 
 import (
 	"context"
-	"io"
 	"net/http"
 	"strconv"
 	"time"
 
-	"github.com/docker/docker/api/types/container"
-	"github.com/docker/docker/client"
+	"github.com/akmadan/throome/pkg/adapters"
+	"github.com/akmadan/throome/pkg/cluster"
 	"github.com/gorilla/mux"
 )
 
-// handleGetServiceLogs returns Docker container logs for a service
+// handleGetServiceLogs returns the container logs of a service
 func (s *Server) handleGetServiceLogs(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	clusterID := vars["cluster_id"]
@@ -48,38 +47,25 @@ func (s *Server) handleGetServiceLogs(w http.ResponseWriter, r *http.Request) {
 
 	timestamps := r.URL.Query().Get("timestamps") == "true"
 
-	// Create Docker client
-	dockerClient, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	serviceProvisioner, err := s.gateway.clusterProvisioner(cfg)
 	if err != nil {
-		s.errorResponse(w, http.StatusInternalServerError, "Failed to create Docker client", err)
+		s.errorResponse(w, http.StatusInternalServerError, "Failed to select the cluster's Docker host", err)
+		return
+	}
+	if serviceProvisioner == nil {
+		s.errorResponse(w, http.StatusServiceUnavailable, "Provisioner not available", nil)
 		return
 	}
-	defer dockerClient.Close()
 
 	// Get container logs
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	options := container.LogsOptions{
-		ShowStdout: true,
-		ShowStderr: true,
-		Timestamps: timestamps,
-		Tail:       strconv.Itoa(tailLines),
-	}
-
-	logs, err := dockerClient.ContainerLogs(ctx, serviceConfig.ContainerID, options)
+	logBytes, err := serviceProvisioner.Logs(ctx, serviceConfig.ContainerID, tailLines, timestamps)
 	if err != nil {
 		s.errorResponse(w, http.StatusInternalServerError, "Failed to get container logs", err)
 		return
 	}
-	defer logs.Close()
-
-	// Read logs
-	logBytes, err := io.ReadAll(logs)
-	if err != nil {
-		s.errorResponse(w, http.StatusInternalServerError, "Failed to read logs", err)
-		return
-	}
 
 	// Return logs as plain text
 	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
@@ -118,30 +104,89 @@ func (s *Server) handleGetServiceInfo(w http.ResponseWriter, r *http.Request) {
 		"container_id": serviceConfig.ContainerID,
 	}
 
-	// If service has a container, get its status
-	if serviceConfig.ContainerID != "" {
-		dockerClient, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
-		if err == nil {
-			defer dockerClient.Close()
+	// Stopped services have no adapter
+	_, err = s.gateway.GetAdapter(clusterID, serviceName)
+	response["connected"] = err == nil
+	if state, ok := s.gateway.circuitState(clusterID, serviceName); ok {
+		response["circuit_breaker"] = state
+	}
 
-			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-			defer cancel()
+	// Reads are routed to replicas by their role
+	if len(serviceConfig.Replicas) > 0 {
+		connected := s.gateway.replicasConnected(clusterID, serviceName)
+		replicas := make([]map[string]interface{}, 0, len(serviceConfig.Replicas))
+		for i, replica := range serviceConfig.Replicas {
+			role := replica.Role
+			if role == "" {
+				role = cluster.RoleReplica
+			}
+			replicas = append(replicas, map[string]interface{}{
+				"host":         replica.Host,
+				"port":         replica.Port,
+				"role":         role,
+				"container_id": replica.ContainerID,
+				"connected":    i < len(connected) && connected[i],
+			})
+		}
+		response["replicas"] = replicas
+	}
 
-			inspect, err := dockerClient.ContainerInspect(ctx, serviceConfig.ContainerID)
-			if err == nil {
-				response["container_status"] = inspect.State.Status
-				response["container_running"] = inspect.State.Running
-				response["container_started_at"] = inspect.State.StartedAt
-				response["container_image"] = inspect.Config.Image
+	// If service has a container, get its status
+	if serviceProvisioner, err := s.gateway.clusterProvisioner(cfg); err == nil && serviceProvisioner != nil && serviceConfig.ContainerID != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		info, err := serviceProvisioner.InspectService(ctx, serviceConfig.ContainerID)
+		if err == nil {
+			running := info.Status == "running"
+			response["container_status"] = info.Status
+			response["container_running"] = running
+			response["container_started_at"] = info.StartedAt
+			response["container_image"] = info.Image
+			response["state"] = "stopped"
+			if running {
+				response["state"] = "running"
 			}
 		}
 	}
 
-	// Add database-specific fields for PostgreSQL
-	if serviceConfig.Type == "postgres" {
+	// Add database-specific fields for PostgreSQL and CockroachDB
+	if serviceConfig.Type == "postgres" || serviceConfig.Type == "cockroachdb" {
 		response["database"] = serviceConfig.Database
 		response["username"] = serviceConfig.Username
 	}
 
 	s.jsonResponse(w, http.StatusOK, response)
 }
+
+// handleGetServicePool returns connection pool statistics for a service
+func (s *Server) handleGetServicePool(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	clusterID := vars["cluster_id"]
+	serviceName := vars["service_name"]
+
+	adapter, err := s.gateway.GetAdapter(clusterID, serviceName)
+	if err != nil {
+		s.errorResponse(w, http.StatusNotFound, "Service not found", err)
+		return
+	}
+
+	provider, ok := adapters.Unwrap(adapter).(adapters.PoolStatsProvider)
+	if !ok {
+		s.errorResponse(w, http.StatusNotFound, "Service does not use a connection pool", nil)
+		return
+	}
+
+	stats := provider.PoolStats()
+	if stats == nil {
+		s.errorResponse(w, http.StatusServiceUnavailable, "Service is not connected", nil)
+		return
+	}
+
+	s.jsonResponse(w, http.StatusOK, map[string]interface{}{
+		"cluster_id":   clusterID,
+		"service_name": serviceName,
+		"type":         adapter.GetType(),
+		"pool":         stats,
+	})
+}
@@ -0,0 +1,59 @@
+package gateway
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/akmadan/throome/pkg/cluster"
+)
+
+// namespaceMiddleware confines the routes under /namespaces/{namespace} to the
+// clusters of that namespace. Clusters of other namespaces are reported as not
+// found, so callers cannot tell them apart from clusters that do not exist.
+func (s *Server) namespaceMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		namespace := vars["namespace"]
+
+		if err := cluster.ValidateNamespace(namespace); err != nil {
+			s.errorResponse(w, http.StatusBadRequest, "Invalid namespace", err)
+			return
+		}
+
+		if clusterID, ok := vars["cluster_id"]; ok {
+			config, err := s.gateway.GetClusterConfig(clusterID)
+			if err != nil || config.NamespaceOrDefault() != namespace {
+				s.errorResponse(w, http.StatusNotFound, "Cluster not found", err)
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// handleListNamespaces lists the namespaces that have clusters
+func (s *Server) handleListNamespaces(w http.ResponseWriter, r *http.Request) {
+	manager := s.gateway.GetClusterManager()
+
+	namespaces := make([]map[string]interface{}, 0)
+	for _, namespace := range s.gateway.ListNamespaces() {
+		namespaces = append(namespaces, map[string]interface{}{
+			"name":     namespace,
+			"clusters": len(manager.GetNamespaceConfigs(namespace)),
+		})
+	}
+
+	s.jsonResponse(w, http.StatusOK, namespaces)
+}
+
+// requestNamespace returns the namespace a cluster request is made in: the one of
+// the route, or the namespace query parameter on the routes of all namespaces.
+// An empty namespace stands for all namespaces.
+func requestNamespace(r *http.Request) string {
+	if namespace := mux.Vars(r)["namespace"]; namespace != "" {
+		return namespace
+	}
+	return r.URL.Query().Get("namespace")
+}
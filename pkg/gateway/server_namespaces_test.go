@@ -0,0 +1,62 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+
+	"github.com/akmadan/throome/internal/config"
+	"github.com/akmadan/throome/pkg/cluster"
+)
+
+func TestNamespaceRoutes(t *testing.T) {
+	gw := newTestGateway(t)
+	s := &Server{config: config.DefaultConfig(), gateway: gw, router: mux.NewRouter()}
+	s.setupRoutes()
+
+	ctx := context.Background()
+	clusterID, err := gw.CreateCluster(ctx, "namespaced", &cluster.Config{
+		Namespace: "team-a",
+		Services: map[string]cluster.ServiceConfig{
+			"app": {Type: "test-update", Host: "localhost", Port: 9201},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create cluster: %v", err)
+	}
+
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		s.router.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+		return rec
+	}
+
+	rec := get("/api/v1/namespaces/team-a/clusters")
+	var clusters []map[string]interface{}
+	if err := json.NewDecoder(rec.Body).Decode(&clusters); err != nil {
+		t.Fatalf("Failed to decode clusters: %v", err)
+	}
+	if len(clusters) != 1 || clusters[0]["id"] != clusterID || clusters[0]["namespace"] != "team-a" {
+		t.Errorf("Expected only cluster %s in team-a, got %v", clusterID, clusters)
+	}
+
+	tests := []struct {
+		path   string
+		status int
+	}{
+		{"/api/v1/namespaces/team-a/clusters/" + clusterID, http.StatusOK},
+		{"/api/v1/clusters/" + clusterID, http.StatusOK},
+		{"/api/v1/namespaces/team-b/clusters/" + clusterID, http.StatusNotFound},
+		{"/api/v1/namespaces/team-b/clusters/" + clusterID + "/health", http.StatusNotFound},
+		{"/api/v1/namespaces/Team_A/clusters", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		if rec := get(tt.path); rec.Code != tt.status {
+			t.Errorf("GET %s: expected status %d, got %d", tt.path, tt.status, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/akmadan/throome/internal/logger"
+	"github.com/akmadan/throome/pkg/adapters"
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+)
+
+// Presigned URL limits. S3 rejects signatures valid for more than seven days.
+const (
+	defaultPresignExpiry = 15 * time.Minute
+	maxPresignExpiry     = 7 * 24 * time.Hour
+)
+
+// objectStoreServiceTypes are the service types backing the object API, in order of preference
+var objectStoreServiceTypes = []string{"minio", "s3"}
+
+// Object storage request/response types
+type ObjectPresignRequest struct {
+	Key            string `json:"key"`
+	Method         string `json:"method"` // GET or PUT, defaults to GET
+	ExpiresSeconds int    `json:"expires_seconds"`
+}
+
+type ObjectPresignResponse struct {
+	URL       string    `json:"url"`
+	Method    string    `json:"method"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type ObjectsResponse struct {
+	Objects []*adapters.ObjectInfo `json:"objects"`
+	Count   int                    `json:"count"`
+}
+
+// handleListObjects lists the objects of a bucket
+func (s *Server) handleListObjects(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	limit := 0
+	if value := r.URL.Query().Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			s.errorResponse(w, http.StatusBadRequest, "Invalid limit", err)
+			return
+		}
+		limit = parsed
+	}
+
+	objectStore, adapterErr := s.objectStoreAdapter(r.Context(), vars["cluster_id"])
+	if adapterErr != nil {
+		s.errorResponse(w, adapterErr.status, adapterErr.message, adapterErr.err)
+		return
+	}
+
+	objects, err := objectStore.ListObjects(r.Context(), vars["bucket"], r.URL.Query().Get("prefix"), limit)
+	if err != nil {
+		if errors.Is(err, adapters.ErrObjectNotFound) {
+			s.errorResponse(w, http.StatusNotFound, "Bucket not found", err)
+			return
+		}
+		s.errorResponse(w, http.StatusInternalServerError, "Failed to list objects", err)
+		return
+	}
+
+	s.jsonResponse(w, http.StatusOK, ObjectsResponse{Objects: objects, Count: len(objects)})
+}
+
+// handlePutObject stores the request body as an object
+func (s *Server) handlePutObject(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	objectStore, adapterErr := s.objectStoreAdapter(r.Context(), vars["cluster_id"])
+	if adapterErr != nil {
+		s.errorResponse(w, adapterErr.status, adapterErr.message, adapterErr.err)
+		return
+	}
+
+	// A content length of -1 makes the adapter stream the body as a multipart upload
+	info, err := objectStore.PutObject(r.Context(), vars["bucket"], vars["key"], r.Body, r.ContentLength, r.Header.Get("Content-Type"))
+	if err != nil {
+		s.errorResponse(w, http.StatusInternalServerError, "Failed to store object", err)
+		return
+	}
+
+	s.jsonResponse(w, http.StatusOK, info)
+}
+
+// handleGetObject streams an object to the client
+func (s *Server) handleGetObject(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	objectStore, adapterErr := s.objectStoreAdapter(r.Context(), vars["cluster_id"])
+	if adapterErr != nil {
+		s.errorResponse(w, adapterErr.status, adapterErr.message, adapterErr.err)
+		return
+	}
+
+	object, info, err := objectStore.GetObject(r.Context(), vars["bucket"], vars["key"])
+	if err != nil {
+		if errors.Is(err, adapters.ErrObjectNotFound) {
+			s.errorResponse(w, http.StatusNotFound, "Object not found", err)
+			return
+		}
+		s.errorResponse(w, http.StatusInternalServerError, "Failed to get object", err)
+		return
+	}
+	defer object.Close()
+
+	contentType := info.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(info.Size, 10))
+	if info.ETag != "" {
+		w.Header().Set("ETag", `"`+info.ETag+`"`)
+	}
+	w.Header().Set("Last-Modified", info.LastModified.UTC().Format(http.TimeFormat))
+	w.WriteHeader(http.StatusOK)
+
+	if _, err := io.Copy(w, object); err != nil {
+		// Headers are already sent, so the client only sees a truncated body
+		logger.Warn("Failed to stream object",
+			zap.String("bucket", vars["bucket"]),
+			zap.String("key", vars["key"]),
+			zap.Error(err))
+	}
+}
+
+// handlePresignObject returns a presigned URL for downloading or uploading an object
+func (s *Server) handlePresignObject(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var req ObjectPresignRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.errorResponse(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	if req.Key == "" {
+		s.errorResponse(w, http.StatusBadRequest, "Key is required", nil)
+		return
+	}
+
+	method := strings.ToUpper(req.Method)
+	if method == "" {
+		method = http.MethodGet
+	}
+	if method != http.MethodGet && method != http.MethodPut {
+		s.errorResponse(w, http.StatusBadRequest, "Method must be GET or PUT", nil)
+		return
+	}
+
+	expiry := defaultPresignExpiry
+	if req.ExpiresSeconds > 0 {
+		expiry = time.Duration(req.ExpiresSeconds) * time.Second
+	}
+	if expiry > maxPresignExpiry {
+		s.errorResponse(w, http.StatusBadRequest, "Expiry cannot exceed 7 days", nil)
+		return
+	}
+
+	objectStore, adapterErr := s.objectStoreAdapter(r.Context(), vars["cluster_id"])
+	if adapterErr != nil {
+		s.errorResponse(w, adapterErr.status, adapterErr.message, adapterErr.err)
+		return
+	}
+
+	url, err := objectStore.Presign(r.Context(), method, vars["bucket"], req.Key, expiry)
+	if err != nil {
+		s.errorResponse(w, http.StatusInternalServerError, "Failed to presign object", err)
+		return
+	}
+
+	s.jsonResponse(w, http.StatusOK, ObjectPresignResponse{
+		URL:       url,
+		Method:    method,
+		ExpiresAt: time.Now().Add(expiry),
+	})
+}
+
+// objectStoreAdapter resolves the object storage adapter of a cluster
+func (s *Server) objectStoreAdapter(ctx context.Context, clusterID string) (adapters.ObjectStoreAdapter, *adapterError) {
+	config, err := s.gateway.GetClusterConfig(clusterID)
+	if err != nil {
+		return nil, &adapterError{http.StatusNotFound, "Cluster not found", err}
+	}
+
+	objectService, adapterErr := s.selectService(ctx, clusterID, config, objectStoreServiceTypes...)
+	if adapterErr != nil {
+		return nil, adapterErr
+	}
+	if objectService == "" {
+		return nil, &adapterError{http.StatusNotFound, "No object storage service found in cluster", nil}
+	}
+
+	adapter, err := s.serviceAdapter(ctx, clusterID, objectService)
+	if err != nil {
+		return nil, &adapterError{adapterStatus(err), "Failed to get object storage adapter", err}
+	}
+
+	objectStore, ok := adapter.(adapters.ObjectStoreAdapter)
+	if !ok {
+		return nil, &adapterError{http.StatusInternalServerError, "Adapter is not an ObjectStoreAdapter", nil}
+	}
+
+	return objectStore, nil
+}
@@ -1,16 +1,24 @@
 package gateway
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"sort"
+	"strconv"
 	"time"
 
 	"github.com/akmadan/throome/internal/logger"
+	"github.com/akmadan/throome/pkg/adapters"
 	"github.com/akmadan/throome/pkg/adapters/kafka"
 	"github.com/akmadan/throome/pkg/adapters/postgres"
 	"github.com/akmadan/throome/pkg/adapters/redis"
+	"github.com/akmadan/throome/pkg/cluster"
+	"github.com/akmadan/throome/pkg/monitor"
+	"github.com/akmadan/throome/pkg/router"
 	"github.com/gorilla/mux"
-	"github.com/jackc/pgx/v5"
 	"go.uber.org/zap"
 )
 
@@ -21,18 +29,27 @@ type DBExecuteRequest struct {
 }
 
 type DBQueryRequest struct {
-	Query string        `json:"query"`
-	Args  []interface{} `json:"args"`
+	Query     string        `json:"query"`
+	Args      []interface{} `json:"args"`
+	FetchSize int           `json:"fetch_size,omitempty"` // Page through the rows with a cursor
+	Cursor    string        `json:"cursor,omitempty"`     // Fetch the next page of a cursor
 }
 
 type DBQueryResponse struct {
-	Rows []map[string]interface{} `json:"rows"`
+	Rows   []map[string]interface{} `json:"rows"`
+	Cursor string                   `json:"cursor,omitempty"` // Set while there are more rows
 }
 
 type DBExecuteResponse struct {
 	RowsAffected int64 `json:"rows_affected"`
 }
 
+type DBExplainRequest struct {
+	Query   string        `json:"query"`
+	Args    []interface{} `json:"args"`
+	Analyze bool          `json:"analyze"`
+}
+
 // Cache operation request/response types
 type CacheGetRequest struct {
 	Key string `json:"key"`
@@ -52,6 +69,35 @@ type CacheGetResponse struct {
 	Value string `json:"value"`
 }
 
+type CacheExpireRequest struct {
+	Key string `json:"key"`
+	TTL int    `json:"ttl"` // TTL in seconds
+}
+
+type CacheExistsResponse struct {
+	Exists bool `json:"exists"`
+}
+
+type CacheTTLResponse struct {
+	TTL int64 `json:"ttl"` // Seconds, -1 when the key does not expire and -2 when it does not exist
+}
+
+type CacheIncrResponse struct {
+	Value int64 `json:"value"` // Value after the increment
+}
+
+// CacheKey is a key listed by the cache key scan
+type CacheKey struct {
+	Key  string `json:"key"`
+	Type string `json:"type"`
+	TTL  int64  `json:"ttl"` // Seconds, -1 when the key does not expire
+}
+
+type CacheKeysResponse struct {
+	Keys       []CacheKey `json:"keys"`
+	NextCursor string     `json:"next_cursor"` // "0" once the scan is complete
+}
+
 // handleDBExecute handles database execute operations (INSERT, UPDATE, DELETE, DDL)
 func (s *Server) handleDBExecute(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
@@ -63,116 +109,368 @@ func (s *Server) handleDBExecute(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Find the PostgreSQL service in the cluster
-	config, err := s.gateway.GetClusterConfig(clusterID)
+	// Execute the query, which may write, so it is only retried when it never reached
+	// the database
+	result, err := retryOperation(r.Context(), s, clusterID, false, func(ctx context.Context) (adapters.Result, error) {
+		pgAdapter, adapterErr := s.postgresAdapter(ctx, clusterID)
+		if adapterErr != nil {
+			return nil, adapterErr
+		}
+		return pgAdapter.Execute(ctx, req.Query, req.Args...)
+	})
 	if err != nil {
-		s.errorResponse(w, http.StatusNotFound, "Cluster not found", err)
+		s.operationErrorResponse(w, "Failed to execute query", err)
 		return
 	}
 
-	var postgresService string
-	for serviceName, serviceConfig := range config.Services {
-		if serviceConfig.Type == "postgres" {
-			postgresService = serviceName
-			break
-		}
-	}
+	s.jsonResponse(w, http.StatusOK, DBExecuteResponse{
+		RowsAffected: result.RowsAffected(),
+	})
+}
 
-	if postgresService == "" {
-		s.errorResponse(w, http.StatusNotFound, "No PostgreSQL service found in cluster", nil)
+// handleDBQuery handles database query operations (SELECT). Requests with a fetch
+// size or cursor get their rows a page at a time by pageQueryRows, and requests that
+// accept NDJSON get them streamed by streamQueryRows.
+func (s *Server) handleDBQuery(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	clusterID := vars["cluster_id"]
+
+	var req DBQueryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.errorResponse(w, http.StatusBadRequest, "Invalid request body", err)
 		return
 	}
 
-	// Get the adapter
-	adapter, err := s.gateway.GetAdapter(clusterID, postgresService)
-	if err != nil {
-		s.errorResponse(w, http.StatusInternalServerError, "Failed to get database adapter", err)
-		return
+	// Queries that only read run on a replica, except those paging through a cursor,
+	// which lives on the adapter that opened it
+	ctx := r.Context()
+	if req.FetchSize == 0 && req.Cursor == "" {
+		ctx = withReadAccess(ctx, readOnlyQuery(req.Query))
 	}
 
-	// Type assert to PostgresAdapter
-	pgAdapter, ok := adapter.(*postgres.PostgresAdapter)
-	if !ok {
-		s.errorResponse(w, http.StatusInternalServerError, "Adapter is not a PostgresAdapter", nil)
+	// Cursors and streams send rows as they are read, so they are not retried
+	if req.FetchSize != 0 || req.Cursor != "" || acceptsNDJSON(r) {
+		pgAdapter, adapterErr := s.postgresAdapter(ctx, clusterID)
+		if adapterErr != nil {
+			s.errorResponse(w, adapterErr.status, adapterErr.message, adapterErr.err)
+			return
+		}
+
+		// Cursors are read-only already
+		if req.FetchSize != 0 || req.Cursor != "" {
+			s.pageQueryRows(w, r, pgAdapter, &req)
+			return
+		}
+
+		conn, done, err := s.queryConn(r.Context(), clusterID, pgAdapter)
+		if err != nil {
+			s.errorResponse(w, http.StatusInternalServerError, "Failed to execute query", err)
+			return
+		}
+		defer done()
+		s.streamQueryRows(w, r, pgAdapter, conn, &req)
 		return
 	}
 
-	// Execute the query
-	result, err := pgAdapter.Execute(r.Context(), req.Query, req.Args...)
+	// Queries that only read are retried on transient failures
+	result, err := retryOperation(ctx, s, clusterID, readOnlyQuery(req.Query), func(ctx context.Context) ([]map[string]interface{}, error) {
+		pgAdapter, adapterErr := s.postgresAdapter(ctx, clusterID)
+		if adapterErr != nil {
+			return nil, adapterErr
+		}
+		conn, done, err := s.queryConn(ctx, clusterID, pgAdapter)
+		if err != nil {
+			return nil, err
+		}
+		defer done()
+
+		// Execute the query directly with pgx to get access to pgx.Rows
+		start := time.Now()
+		var rows []map[string]interface{}
+		var bytes int64
+		pgxRows, err := conn.Query(ctx, req.Query, req.Args...)
+		if err == nil {
+			rows, bytes, err = postgres.CollectRows(pgxRows)
+		}
+		duration := time.Since(start)
+		pgAdapter.RecordQuery(ctx, req.Query, req.Args, duration, err, int64(len(rows)), bytes)
+		if err != nil {
+			return nil, err
+		}
+		pgAdapter.ObserveQuery(ctx, req.Query, req.Args, duration)
+		return rows, nil
+	})
 	if err != nil {
-		s.errorResponse(w, http.StatusInternalServerError, "Failed to execute query", err)
+		s.operationErrorResponse(w, "Failed to execute query", err)
 		return
 	}
 
-	s.jsonResponse(w, http.StatusOK, DBExecuteResponse{
-		RowsAffected: result.RowsAffected(),
+	s.jsonResponse(w, http.StatusOK, DBQueryResponse{
+		Rows: result,
 	})
 }
 
-// handleDBQuery handles database query operations (SELECT)
-func (s *Server) handleDBQuery(w http.ResponseWriter, r *http.Request) {
+// handleDBExplain returns the parsed query plan for a statement
+func (s *Server) handleDBExplain(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	clusterID := vars["cluster_id"]
 
-	var req DBQueryRequest
+	var req DBExplainRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		s.errorResponse(w, http.StatusBadRequest, "Invalid request body", err)
 		return
 	}
+	if req.Query == "" {
+		s.errorResponse(w, http.StatusBadRequest, "Query is required", nil)
+		return
+	}
 
-	// Find the PostgreSQL service in the cluster
-	config, err := s.gateway.GetClusterConfig(clusterID)
+	pgAdapter, adapterErr := s.postgresAdapter(r.Context(), clusterID)
+	if adapterErr != nil {
+		s.errorResponse(w, adapterErr.status, adapterErr.message, adapterErr.err)
+		return
+	}
+
+	plan, err := pgAdapter.Explain(r.Context(), req.Query, req.Analyze, req.Args...)
 	if err != nil {
-		s.errorResponse(w, http.StatusNotFound, "Cluster not found", err)
+		s.errorResponse(w, http.StatusBadRequest, "Failed to explain query", err)
 		return
 	}
 
-	var postgresService string
-	for serviceName, serviceConfig := range config.Services {
-		if serviceConfig.Type == "postgres" {
-			postgresService = serviceName
-			break
+	s.jsonResponse(w, http.StatusOK, plan)
+}
+
+// handleDBSlowQueries returns the slow queries recorded for a cluster's database,
+// along with their automatically captured plans
+func (s *Server) handleDBSlowQueries(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	clusterID := vars["cluster_id"]
+
+	pgAdapter, adapterErr := s.postgresAdapter(r.Context(), clusterID)
+	if adapterErr != nil {
+		s.errorResponse(w, adapterErr.status, adapterErr.message, adapterErr.err)
+		return
+	}
+
+	queries := pgAdapter.SlowQueries()
+	if config, err := s.gateway.GetClusterConfig(clusterID); err == nil {
+		for _, query := range queries {
+			redactSlowQuery(query, config.Activity)
+		}
+	}
+	s.jsonResponse(w, http.StatusOK, map[string]interface{}{
+		"cluster_id":   clusterID,
+		"slow_queries": queries,
+		"count":        len(queries),
+	})
+}
+
+// redactSlowQuery applies the activity redaction of a cluster to a slow query, as it is
+// applied to the activity log. strip_args and hash_literals drop the arguments and
+// hash the literals of the plan's conditions, where the arguments show up, and
+// hash_literals hashes those of the query too.
+func redactSlowQuery(query *postgres.SlowQuery, config cluster.ActivityConfig) {
+	switch config.Redaction {
+	case cluster.RedactionStripArgs, cluster.RedactionHashLiterals:
+		query.Query = monitor.RedactCommand(query.Query, config)
+		query.Args = nil
+		if query.Plan != nil {
+			plan := *query.Plan
+			plan.Plan = redactedPlanNode(plan.Plan)
+			plan.Raw = nil
+			query.Plan = &plan
 		}
+	case cluster.RedactionTruncate:
+		query.Query = monitor.RedactCommand(query.Query, config)
+	}
+}
+
+// redactedPlanNode returns a copy of a plan whose conditions have their literals hashed
+func redactedPlanNode(node *postgres.PlanNode) *postgres.PlanNode {
+	if node == nil {
+		return nil
+	}
+	redacted := *node
+	redacted.Filter = monitor.HashLiterals(node.Filter)
+	redacted.IndexCond = monitor.HashLiterals(node.IndexCond)
+	redacted.Plans = make([]*postgres.PlanNode, 0, len(node.Plans))
+	for _, child := range node.Plans {
+		redacted.Plans = append(redacted.Plans, redactedPlanNode(child))
+	}
+	return &redacted
+}
+
+// serviceAdapter returns the underlying adapter of a service for handlers that use
+// adapter-specific methods, or of one of its replicas for reads. Faults injected into
+// the service are applied first, so these handlers fail the same way the adapter's
+// own operations would.
+func (s *Server) serviceAdapter(ctx context.Context, clusterID, serviceName string) (adapters.Adapter, error) {
+	adapter, err := s.gateway.GetAdapter(clusterID, serviceName)
+	if err != nil {
+		return nil, err
+	}
+
+	routed, err := s.gateway.routeAdapter(ctx, clusterID, serviceName, adapter)
+	if err != nil {
+		return nil, err
+	}
+	noteAttempt(ctx, clusterID, serviceName, adapter.GetType())
+
+	// Injected faults count against the service's circuit breaker
+	if err := adapters.InjectFault(ctx, adapter); err != nil {
+		s.gateway.recordFailure(clusterID, adapter)
+		return nil, err
+	}
+
+	return adapters.Unwrap(routed), nil
+}
+
+// adapterStatus returns the status of a failure to get the adapter of a service:
+// 503 while the service's circuit breakers are open, else 500
+func adapterStatus(err error) int {
+	if errors.Is(err, router.ErrCircuitOpen) {
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
+
+// adapterError describes why a handler could not resolve the adapter it needs
+type adapterError struct {
+	status  int
+	message string
+	err     error
+}
+
+func (e *adapterError) Error() string {
+	if e.err != nil {
+		return e.message + ": " + e.err.Error()
+	}
+	return e.message
+}
+
+func (e *adapterError) Unwrap() error {
+	return e.err
+}
+
+// postgresAdapter resolves the PostgreSQL adapter of a cluster
+func (s *Server) postgresAdapter(ctx context.Context, clusterID string) (*postgres.PostgresAdapter, *adapterError) {
+	config, err := s.gateway.GetClusterConfig(clusterID)
+	if err != nil {
+		return nil, &adapterError{http.StatusNotFound, "Cluster not found", err}
 	}
 
+	postgresService, adapterErr := s.selectService(ctx, clusterID, config, databaseServiceTypes...)
+	if adapterErr != nil {
+		return nil, adapterErr
+	}
 	if postgresService == "" {
-		s.errorResponse(w, http.StatusNotFound, "No PostgreSQL service found in cluster", nil)
-		return
+		return nil, &adapterError{http.StatusNotFound, "No PostgreSQL service found in cluster", nil}
 	}
 
-	// Get the adapter
-	adapter, err := s.gateway.GetAdapter(clusterID, postgresService)
+	adapter, err := s.serviceAdapter(ctx, clusterID, postgresService)
 	if err != nil {
-		s.errorResponse(w, http.StatusInternalServerError, "Failed to get database adapter", err)
-		return
+		return nil, &adapterError{adapterStatus(err), "Failed to get database adapter", err}
 	}
 
-	// Type assert to PostgresAdapter
 	pgAdapter, ok := adapter.(*postgres.PostgresAdapter)
 	if !ok {
-		s.errorResponse(w, http.StatusInternalServerError, "Adapter is not a PostgresAdapter", nil)
-		return
+		return nil, &adapterError{http.StatusInternalServerError, "Adapter is not a PostgresAdapter", nil}
 	}
 
-	// Execute the query directly with pgx to get access to pgx.Rows
-	pool := pgAdapter.GetPool()
-	pgxRows, err := pool.Query(r.Context(), req.Query, req.Args...)
+	return pgAdapter, nil
+}
+
+// kafkaAdapter resolves the Kafka adapter of a cluster along with its service config
+func (s *Server) kafkaAdapter(ctx context.Context, clusterID string) (*kafka.KafkaAdapter, *cluster.ServiceConfig, *adapterError) {
+	config, err := s.gateway.GetClusterConfig(clusterID)
 	if err != nil {
-		s.errorResponse(w, http.StatusInternalServerError, "Failed to execute query", err)
-		return
+		return nil, nil, &adapterError{http.StatusNotFound, "Cluster not found", err}
 	}
-	defer pgxRows.Close()
 
-	// Use pgx.CollectRows to convert rows to maps
-	result, err := pgx.CollectRows(pgxRows, pgx.RowToMap)
+	kafkaService, adapterErr := s.selectService(ctx, clusterID, config, "kafka")
+	if adapterErr != nil {
+		return nil, nil, adapterErr
+	}
+	if kafkaService == "" {
+		return nil, nil, &adapterError{http.StatusNotFound, "No Kafka service found in cluster", nil}
+	}
+
+	adapter, err := s.serviceAdapter(ctx, clusterID, kafkaService)
 	if err != nil {
-		s.errorResponse(w, http.StatusInternalServerError, "Failed to collect rows", err)
-		return
+		return nil, nil, &adapterError{adapterStatus(err), "Failed to get queue adapter", err}
 	}
 
-	s.jsonResponse(w, http.StatusOK, DBQueryResponse{
-		Rows: result,
-	})
+	kafkaAdapter, ok := adapter.(*kafka.KafkaAdapter)
+	if !ok {
+		return nil, nil, &adapterError{http.StatusInternalServerError, "Adapter is not a KafkaAdapter", nil}
+	}
+
+	serviceConfig := config.Services[kafkaService]
+	return kafkaAdapter, &serviceConfig, nil
+}
+
+// redisAdapter resolves the Redis adapter of a cluster
+func (s *Server) redisAdapter(ctx context.Context, clusterID string) (*redis.RedisAdapter, *adapterError) {
+	config, err := s.gateway.GetClusterConfig(clusterID)
+	if err != nil {
+		return nil, &adapterError{http.StatusNotFound, "Cluster not found", err}
+	}
+
+	redisService, adapterErr := s.selectService(ctx, clusterID, config, "redis")
+	if adapterErr != nil {
+		return nil, adapterErr
+	}
+	if redisService == "" {
+		return nil, &adapterError{http.StatusNotFound, "No Redis service found in cluster", nil}
+	}
+
+	adapter, err := s.serviceAdapter(ctx, clusterID, redisService)
+	if err != nil {
+		return nil, &adapterError{adapterStatus(err), "Failed to get cache adapter", err}
+	}
+
+	redisAdapter, ok := adapter.(*redis.RedisAdapter)
+	if !ok {
+		return nil, &adapterError{http.StatusInternalServerError, "Adapter is not a RedisAdapter", nil}
+	}
+
+	return redisAdapter, nil
+}
+
+// queueServiceTypes are the service types backing the queue API, in order of preference
+var queueServiceTypes = []string{"kafka", "rabbitmq", "mqtt", "redis"}
+
+// keyedPublisher is implemented by queue adapters that can publish with a message key
+type keyedPublisher interface {
+	PublishWithKey(ctx context.Context, topic string, key, message []byte) error
+}
+
+// queueAdapter resolves the queue adapter of a cluster
+func (s *Server) queueAdapter(ctx context.Context, clusterID string) (adapters.QueueAdapter, *adapterError) {
+	config, err := s.gateway.GetClusterConfig(clusterID)
+	if err != nil {
+		return nil, &adapterError{http.StatusNotFound, "Cluster not found", err}
+	}
+
+	queueService, adapterErr := s.selectService(ctx, clusterID, config, queueServiceTypes...)
+	if adapterErr != nil {
+		return nil, adapterErr
+	}
+	if queueService == "" {
+		return nil, &adapterError{http.StatusNotFound, "No queue service found in cluster", nil}
+	}
+
+	adapter, err := s.serviceAdapter(ctx, clusterID, queueService)
+	if err != nil {
+		return nil, &adapterError{adapterStatus(err), "Failed to get queue adapter", err}
+	}
+
+	queue, ok := adapter.(adapters.QueueAdapter)
+	if !ok {
+		return nil, &adapterError{http.StatusInternalServerError, "Adapter is not a QueueAdapter", nil}
+	}
+
+	return queue, nil
 }
 
 // handleCacheGet handles cache GET operations
@@ -186,101 +484,179 @@ func (s *Server) handleCacheGet(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Find the Redis service in the cluster
-	config, err := s.gateway.GetClusterConfig(clusterID)
+	// Get the value
+	value, err := retryOperation(r.Context(), s, clusterID, true, func(ctx context.Context) (string, error) {
+		redisAdapter, adapterErr := s.redisAdapter(ctx, clusterID)
+		if adapterErr != nil {
+			return "", adapterErr
+		}
+		return redisAdapter.Get(ctx, req.Key)
+	})
 	if err != nil {
-		s.errorResponse(w, http.StatusNotFound, "Cluster not found", err)
+		s.operationErrorResponse(w, "Failed to get key", err)
 		return
 	}
 
-	var redisService string
-	for serviceName, serviceConfig := range config.Services {
-		if serviceConfig.Type == "redis" {
-			redisService = serviceName
-			break
-		}
-	}
+	s.jsonResponse(w, http.StatusOK, CacheGetResponse{
+		Value: value,
+	})
+}
 
-	if redisService == "" {
-		s.errorResponse(w, http.StatusNotFound, "No Redis service found in cluster", nil)
+// handleCacheSet handles cache SET operations
+func (s *Server) handleCacheSet(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	clusterID := vars["cluster_id"]
+
+	var req CacheSetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.errorResponse(w, http.StatusBadRequest, "Invalid request body", err)
 		return
 	}
 
-	// Get the adapter
-	adapter, err := s.gateway.GetAdapter(clusterID, redisService)
+	// Set the value
+	ttl := time.Duration(req.TTL) * time.Second
+	_, err := retryOperation(r.Context(), s, clusterID, true, func(ctx context.Context) (struct{}, error) {
+		redisAdapter, adapterErr := s.redisAdapter(ctx, clusterID)
+		if adapterErr != nil {
+			return struct{}{}, adapterErr
+		}
+		return struct{}{}, redisAdapter.Set(ctx, req.Key, req.Value, ttl)
+	})
 	if err != nil {
-		s.errorResponse(w, http.StatusInternalServerError, "Failed to get cache adapter", err)
+		s.operationErrorResponse(w, "Failed to set key", err)
 		return
 	}
 
-	// Type assert to RedisAdapter
-	redisAdapter, ok := adapter.(*redis.RedisAdapter)
-	if !ok {
-		s.errorResponse(w, http.StatusInternalServerError, "Adapter is not a RedisAdapter", nil)
+	s.jsonResponse(w, http.StatusOK, map[string]string{
+		"status": "success",
+	})
+}
+
+// handleCacheDelete handles cache DELETE operations
+func (s *Server) handleCacheDelete(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	clusterID := vars["cluster_id"]
+
+	var req CacheDeleteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.errorResponse(w, http.StatusBadRequest, "Invalid request body", err)
 		return
 	}
 
-	// Get the value
-	value, err := redisAdapter.Get(r.Context(), req.Key)
+	// Delete the key
+	_, err := retryOperation(r.Context(), s, clusterID, true, func(ctx context.Context) (struct{}, error) {
+		redisAdapter, adapterErr := s.redisAdapter(ctx, clusterID)
+		if adapterErr != nil {
+			return struct{}{}, adapterErr
+		}
+		return struct{}{}, redisAdapter.Delete(ctx, req.Key)
+	})
 	if err != nil {
-		s.errorResponse(w, http.StatusInternalServerError, "Failed to get key", err)
+		logger.Error("Failed to delete key", zap.Error(err))
+		s.operationErrorResponse(w, "Failed to delete key", err)
 		return
 	}
 
-	s.jsonResponse(w, http.StatusOK, CacheGetResponse{
-		Value: value,
+	s.jsonResponse(w, http.StatusOK, map[string]string{
+		"status": "success",
 	})
 }
 
-// handleCacheSet handles cache SET operations
-func (s *Server) handleCacheSet(w http.ResponseWriter, r *http.Request) {
+// handleCacheExists reports whether a key exists
+func (s *Server) handleCacheExists(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	clusterID := vars["cluster_id"]
 
-	var req CacheSetRequest
+	var req CacheGetRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		s.errorResponse(w, http.StatusBadRequest, "Invalid request body", err)
 		return
 	}
 
-	// Find the Redis service in the cluster
-	config, err := s.gateway.GetClusterConfig(clusterID)
+	exists, err := retryOperation(r.Context(), s, clusterID, true, func(ctx context.Context) (bool, error) {
+		redisAdapter, adapterErr := s.redisAdapter(ctx, clusterID)
+		if adapterErr != nil {
+			return false, adapterErr
+		}
+		return redisAdapter.Exists(ctx, req.Key)
+	})
 	if err != nil {
-		s.errorResponse(w, http.StatusNotFound, "Cluster not found", err)
+		s.operationErrorResponse(w, "Failed to check key", err)
 		return
 	}
 
-	var redisService string
-	for serviceName, serviceConfig := range config.Services {
-		if serviceConfig.Type == "redis" {
-			redisService = serviceName
-			break
-		}
-	}
+	s.jsonResponse(w, http.StatusOK, CacheExistsResponse{Exists: exists})
+}
 
-	if redisService == "" {
-		s.errorResponse(w, http.StatusNotFound, "No Redis service found in cluster", nil)
+// handleCacheTTL returns the time-to-live of a key in seconds, with the sentinel
+// values of the TTL command for keys that do not expire or do not exist
+func (s *Server) handleCacheTTL(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	clusterID := vars["cluster_id"]
+
+	var req CacheGetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.errorResponse(w, http.StatusBadRequest, "Invalid request body", err)
 		return
 	}
 
-	// Get the adapter
-	adapter, err := s.gateway.GetAdapter(clusterID, redisService)
+	ttl, err := retryOperation(r.Context(), s, clusterID, true, func(ctx context.Context) (time.Duration, error) {
+		redisAdapter, adapterErr := s.redisAdapter(ctx, clusterID)
+		if adapterErr != nil {
+			return 0, adapterErr
+		}
+		return redisAdapter.TTL(ctx, req.Key)
+	})
 	if err != nil {
-		s.errorResponse(w, http.StatusInternalServerError, "Failed to get cache adapter", err)
+		s.operationErrorResponse(w, "Failed to get TTL", err)
 		return
 	}
 
-	// Type assert to RedisAdapter
-	redisAdapter, ok := adapter.(*redis.RedisAdapter)
-	if !ok {
-		s.errorResponse(w, http.StatusInternalServerError, "Adapter is not a RedisAdapter", nil)
+	s.jsonResponse(w, http.StatusOK, CacheTTLResponse{TTL: ttlCommandSeconds(ttl)})
+}
+
+// ttlCommandSeconds converts the result of the TTL command to seconds. The client
+// returns its -1 and -2 sentinels as nanoseconds rather than seconds.
+func ttlCommandSeconds(ttl time.Duration) int64 {
+	if ttl == -1 || ttl == -2 {
+		return int64(ttl)
+	}
+	return int64(ttl / time.Second)
+}
+
+// handleCacheExpire sets the time-to-live of an existing key
+func (s *Server) handleCacheExpire(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	clusterID := vars["cluster_id"]
+
+	var req CacheExpireRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.errorResponse(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	if req.TTL <= 0 {
+		s.errorResponse(w, http.StatusBadRequest, "TTL must be a positive number of seconds", nil)
 		return
 	}
 
-	// Set the value
-	ttl := time.Duration(req.TTL) * time.Second
-	if err := redisAdapter.Set(r.Context(), req.Key, req.Value, ttl); err != nil {
-		s.errorResponse(w, http.StatusInternalServerError, "Failed to set key", err)
+	// EXPIRE succeeds on missing keys, so check first to report them
+	exists, err := retryOperation(r.Context(), s, clusterID, true, func(ctx context.Context) (bool, error) {
+		redisAdapter, adapterErr := s.redisAdapter(ctx, clusterID)
+		if adapterErr != nil {
+			return false, adapterErr
+		}
+		exists, err := redisAdapter.Exists(ctx, req.Key)
+		if err != nil || !exists {
+			return false, err
+		}
+		return true, redisAdapter.Expire(ctx, req.Key, time.Duration(req.TTL)*time.Second)
+	})
+	if err != nil {
+		s.operationErrorResponse(w, "Failed to set TTL", err)
+		return
+	}
+	if !exists {
+		s.errorResponse(w, http.StatusNotFound, "Key not found", nil)
 		return
 	}
 
@@ -289,61 +665,165 @@ func (s *Server) handleCacheSet(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// handleCacheDelete handles cache DELETE operations
-func (s *Server) handleCacheDelete(w http.ResponseWriter, r *http.Request) {
+// handleCacheIncr increments the integer value of a key by one. Missing keys start
+// from zero.
+func (s *Server) handleCacheIncr(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	clusterID := vars["cluster_id"]
 
-	var req CacheDeleteRequest
+	var req CacheGetRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		s.errorResponse(w, http.StatusBadRequest, "Invalid request body", err)
 		return
 	}
 
-	// Find the Redis service in the cluster
+	// Increments are only retried when they never reached the cache
+	value, err := retryOperation(r.Context(), s, clusterID, false, func(ctx context.Context) (int64, error) {
+		redisAdapter, adapterErr := s.redisAdapter(ctx, clusterID)
+		if adapterErr != nil {
+			return 0, adapterErr
+		}
+		return redisAdapter.Incr(ctx, req.Key)
+	})
+	if err != nil {
+		s.operationErrorResponse(w, "Failed to increment key", err)
+		return
+	}
+
+	s.jsonResponse(w, http.StatusOK, CacheIncrResponse{Value: value})
+}
+
+// handleCacheStats returns server statistics of the cluster's Redis service. The values
+// collected on the monitoring interval are returned unless refresh=true is passed or
+// none have been collected yet.
+func (s *Server) handleCacheStats(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	clusterID := vars["cluster_id"]
+
 	config, err := s.gateway.GetClusterConfig(clusterID)
 	if err != nil {
 		s.errorResponse(w, http.StatusNotFound, "Cluster not found", err)
 		return
 	}
 
-	var redisService string
-	for serviceName, serviceConfig := range config.Services {
-		if serviceConfig.Type == "redis" {
-			redisService = serviceName
-			break
-		}
+	redisService, adapterErr := s.selectService(r.Context(), clusterID, config, "redis")
+	if adapterErr != nil {
+		s.errorResponse(w, adapterErr.status, adapterErr.message, adapterErr.err)
+		return
 	}
-
 	if redisService == "" {
 		s.errorResponse(w, http.StatusNotFound, "No Redis service found in cluster", nil)
 		return
 	}
 
-	// Get the adapter
-	adapter, err := s.gateway.GetAdapter(clusterID, redisService)
+	collector := s.gateway.GetCollector()
+	stats := collector.GetCacheStats(clusterID, redisService)
+
+	if stats == nil || r.URL.Query().Get("refresh") == "true" {
+		adapter, err := s.serviceAdapter(r.Context(), clusterID, redisService)
+		if err != nil {
+			s.errorResponse(w, adapterStatus(err), "Failed to get cache adapter", err)
+			return
+		}
+
+		redisAdapter, ok := adapter.(*redis.RedisAdapter)
+		if !ok {
+			s.errorResponse(w, http.StatusInternalServerError, "Adapter is not a RedisAdapter", nil)
+			return
+		}
+
+		stats, err = redisAdapter.CacheStats(r.Context())
+		if err != nil {
+			s.errorResponse(w, http.StatusInternalServerError, "Failed to get cache statistics", err)
+			return
+		}
+		collector.RecordCacheStats(clusterID, redisService, redisAdapter.GetType(), stats)
+	}
+
+	s.jsonResponse(w, http.StatusOK, map[string]interface{}{
+		"cluster_id":   clusterID,
+		"service_name": redisService,
+		"stats":        stats,
+	})
+}
+
+// Cache key scans look at 100 keys per page unless asked otherwise, and at most 1000
+const (
+	defaultCacheScanCount = 100
+	maxCacheScanCount     = 1000
+)
+
+// cacheScanParams reads the cursor, pattern and count of a cache key scan
+func cacheScanParams(r *http.Request) (cursor uint64, pattern string, count int64, err error) {
+	query := r.URL.Query()
+	if value := query.Get("cursor"); value != "" {
+		cursor, err = strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return 0, "", 0, fmt.Errorf("invalid cursor: %s", value)
+		}
+	}
+
+	pattern = query.Get("pattern")
+	if pattern == "" {
+		pattern = "*"
+	}
+
+	count = defaultCacheScanCount
+	if value := query.Get("count"); value != "" {
+		count, err = strconv.ParseInt(value, 10, 64)
+		if err != nil || count <= 0 {
+			return 0, "", 0, fmt.Errorf("invalid count: %s", value)
+		}
+		if count > maxCacheScanCount {
+			count = maxCacheScanCount
+		}
+	}
+	return cursor, pattern, count, nil
+}
+
+// handleCacheKeys lists one page of the keys of the cluster's Redis service with SCAN,
+// which unlike KEYS does not block the server on large datasets. Pages are fetched by
+// passing back the next_cursor of the previous one until it is "0"; a page may be
+// empty before then.
+func (s *Server) handleCacheKeys(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	clusterID := vars["cluster_id"]
+
+	cursor, pattern, count, err := cacheScanParams(r)
 	if err != nil {
-		s.errorResponse(w, http.StatusInternalServerError, "Failed to get cache adapter", err)
+		s.errorResponse(w, http.StatusBadRequest, "Invalid scan parameters", err)
 		return
 	}
 
-	// Type assert to RedisAdapter
-	redisAdapter, ok := adapter.(*redis.RedisAdapter)
-	if !ok {
-		s.errorResponse(w, http.StatusInternalServerError, "Adapter is not a RedisAdapter", nil)
+	redisAdapter, adapterErr := s.redisAdapter(r.Context(), clusterID)
+	if adapterErr != nil {
+		s.errorResponse(w, adapterErr.status, adapterErr.message, adapterErr.err)
 		return
 	}
 
-	// Delete the key
-	if err := redisAdapter.Delete(r.Context(), req.Key); err != nil {
-		logger.Error("Failed to delete key", zap.Error(err))
-		s.errorResponse(w, http.StatusInternalServerError, "Failed to delete key", err)
+	keys, next, err := redisAdapter.ScanKeys(r.Context(), cursor, pattern, count)
+	if err != nil {
+		s.errorResponse(w, http.StatusInternalServerError, "Failed to scan keys", err)
 		return
 	}
 
-	s.jsonResponse(w, http.StatusOK, map[string]string{
-		"status": "success",
-	})
+	resp := CacheKeysResponse{
+		Keys:       make([]CacheKey, 0, len(keys)),
+		NextCursor: strconv.FormatUint(next, 10),
+	}
+	for _, key := range keys {
+		resp.Keys = append(resp.Keys, CacheKey{Key: key.Key, Type: key.Type, TTL: ttlSeconds(key.TTL)})
+	}
+	s.jsonResponse(w, http.StatusOK, resp)
+}
+
+// ttlSeconds converts a key's TTL to whole seconds, rounded up so expiring keys never
+// read as persistent. Keys without a TTL are reported as -1 like the TTL command does.
+func ttlSeconds(ttl time.Duration) int64 {
+	if ttl <= 0 {
+		return -1
+	}
+	return int64((ttl + time.Second - 1) / time.Second)
 }
 
 // Queue/Kafka operation request/response types
@@ -355,15 +835,32 @@ type QueuePublishRequest struct {
 
 type CreateTopicRequest struct {
 	Topic             string `json:"topic"`
-	NumPartitions     int    `json:"num_partitions"`
-	ReplicationFactor int    `json:"replication_factor"`
+	NumPartitions     int    `json:"num_partitions"`          // Kafka, 1 if omitted
+	ReplicationFactor int    `json:"replication_factor"`      // Kafka, 1 if omitted
+	ExchangeType      string `json:"exchange_type,omitempty"` // RabbitMQ: fanout, direct, topic or headers
+	BindingKey        string `json:"binding_key,omitempty"`   // RabbitMQ
+}
+
+// validate checks a topic creation request before it reaches the queue
+func (req *CreateTopicRequest) validate() error {
+	if req.Topic == "" {
+		return fmt.Errorf("topic is required")
+	}
+	if req.NumPartitions < 0 {
+		return fmt.Errorf("num_partitions must not be negative, got %d", req.NumPartitions)
+	}
+	if req.ReplicationFactor < 0 {
+		return fmt.Errorf("replication_factor must not be negative, got %d", req.ReplicationFactor)
+	}
+	return nil
 }
 
 type ListTopicsResponse struct {
 	Topics []string `json:"topics"`
+	Total  int      `json:"total"` // Number of topics across all pages
 }
 
-// handleQueuePublish handles message publishing to Kafka topics
+// handleQueuePublish handles message publishing to queue topics
 func (s *Server) handleQueuePublish(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	clusterID := vars["cluster_id"]
@@ -374,50 +871,20 @@ func (s *Server) handleQueuePublish(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Find the Kafka service in the cluster
-	config, err := s.gateway.GetClusterConfig(clusterID)
-	if err != nil {
-		s.errorResponse(w, http.StatusNotFound, "Cluster not found", err)
-		return
-	}
-
-	var kafkaService string
-	for serviceName, serviceConfig := range config.Services {
-		if serviceConfig.Type == "kafka" {
-			kafkaService = serviceName
-			break
+	// Publish the message, only retrying when it never reached the queue so it is not
+	// published twice
+	_, err := retryOperation(r.Context(), s, clusterID, false, func(ctx context.Context) (struct{}, error) {
+		queue, adapterErr := s.queueAdapter(ctx, clusterID)
+		if adapterErr != nil {
+			return struct{}{}, adapterErr
 		}
-	}
-
-	if kafkaService == "" {
-		s.errorResponse(w, http.StatusNotFound, "No Kafka service found in cluster", nil)
-		return
-	}
-
-	// Get the adapter
-	adapter, err := s.gateway.GetAdapter(clusterID, kafkaService)
+		if keyed, ok := queue.(keyedPublisher); ok && len(req.Key) > 0 {
+			return struct{}{}, keyed.PublishWithKey(ctx, req.Topic, req.Key, req.Message)
+		}
+		return struct{}{}, queue.Publish(ctx, req.Topic, req.Message)
+	})
 	if err != nil {
-		s.errorResponse(w, http.StatusInternalServerError, "Failed to get Kafka adapter", err)
-		return
-	}
-
-	// Type assert to KafkaAdapter
-	kafkaAdapter, ok := adapter.(*kafka.KafkaAdapter)
-	if !ok {
-		s.errorResponse(w, http.StatusInternalServerError, "Adapter is not a KafkaAdapter", nil)
-		return
-	}
-
-	// Publish the message
-	var publishErr error
-	if len(req.Key) > 0 {
-		publishErr = kafkaAdapter.PublishWithKey(r.Context(), req.Topic, req.Key, req.Message)
-	} else {
-		publishErr = kafkaAdapter.Publish(r.Context(), req.Topic, req.Message)
-	}
-
-	if publishErr != nil {
-		s.errorResponse(w, http.StatusInternalServerError, "Failed to publish message", publishErr)
+		s.operationErrorResponse(w, "Failed to publish message", err)
 		return
 	}
 
@@ -426,58 +893,36 @@ func (s *Server) handleQueuePublish(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// handleListTopics handles listing Kafka topics
+// handleListTopics handles listing queue topics
 func (s *Server) handleListTopics(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	clusterID := vars["cluster_id"]
 
-	// Find the Kafka service in the cluster
-	config, err := s.gateway.GetClusterConfig(clusterID)
-	if err != nil {
-		s.errorResponse(w, http.StatusNotFound, "Cluster not found", err)
-		return
-	}
-
-	var kafkaService string
-	for serviceName, serviceConfig := range config.Services {
-		if serviceConfig.Type == "kafka" {
-			kafkaService = serviceName
-			break
-		}
-	}
-
-	if kafkaService == "" {
-		s.errorResponse(w, http.StatusNotFound, "No Kafka service found in cluster", nil)
+	queue, adapterErr := s.queueAdapter(r.Context(), clusterID)
+	if adapterErr != nil {
+		s.errorResponse(w, adapterErr.status, adapterErr.message, adapterErr.err)
 		return
 	}
 
-	// Get the adapter
-	adapter, err := s.gateway.GetAdapter(clusterID, kafkaService)
-	if err != nil {
-		s.errorResponse(w, http.StatusInternalServerError, "Failed to get Kafka adapter", err)
-		return
-	}
-
-	// Type assert to KafkaAdapter
-	kafkaAdapter, ok := adapter.(*kafka.KafkaAdapter)
-	if !ok {
-		s.errorResponse(w, http.StatusInternalServerError, "Adapter is not a KafkaAdapter", nil)
-		return
-	}
-
-	// List topics
-	topics, err := kafkaAdapter.ListTopics(r.Context())
+	// List topics, sorted so pages are stable
+	topics, err := queue.ListTopics(r.Context())
 	if err != nil {
 		s.errorResponse(w, http.StatusInternalServerError, "Failed to list topics", err)
 		return
 	}
+	sort.Strings(topics)
 
+	p := requestPage(r, 0)
+	start, end := p.bounds(len(topics))
+
+	setPageHeaders(w, r, p, len(topics))
 	s.jsonResponse(w, http.StatusOK, ListTopicsResponse{
-		Topics: topics,
+		Topics: topics[start:end],
+		Total:  len(topics),
 	})
 }
 
-// handleCreateTopic handles creating a new Kafka topic
+// handleCreateTopic handles creating a new queue topic
 func (s *Server) handleCreateTopic(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	clusterID := vars["cluster_id"]
@@ -487,38 +932,14 @@ func (s *Server) handleCreateTopic(w http.ResponseWriter, r *http.Request) {
 		s.errorResponse(w, http.StatusBadRequest, "Invalid request body", err)
 		return
 	}
-
-	// Find the Kafka service in the cluster
-	config, err := s.gateway.GetClusterConfig(clusterID)
-	if err != nil {
-		s.errorResponse(w, http.StatusNotFound, "Cluster not found", err)
-		return
-	}
-
-	var kafkaService string
-	for serviceName, serviceConfig := range config.Services {
-		if serviceConfig.Type == "kafka" {
-			kafkaService = serviceName
-			break
-		}
-	}
-
-	if kafkaService == "" {
-		s.errorResponse(w, http.StatusNotFound, "No Kafka service found in cluster", nil)
+	if err := req.validate(); err != nil {
+		s.errorResponse(w, http.StatusBadRequest, "Invalid topic request", err)
 		return
 	}
 
-	// Get the adapter
-	adapter, err := s.gateway.GetAdapter(clusterID, kafkaService)
-	if err != nil {
-		s.errorResponse(w, http.StatusInternalServerError, "Failed to get Kafka adapter", err)
-		return
-	}
-
-	// Type assert to KafkaAdapter
-	kafkaAdapter, ok := adapter.(*kafka.KafkaAdapter)
-	if !ok {
-		s.errorResponse(w, http.StatusInternalServerError, "Adapter is not a KafkaAdapter", nil)
+	queue, adapterErr := s.queueAdapter(r.Context(), clusterID)
+	if adapterErr != nil {
+		s.errorResponse(w, adapterErr.status, adapterErr.message, adapterErr.err)
 		return
 	}
 
@@ -526,9 +947,11 @@ func (s *Server) handleCreateTopic(w http.ResponseWriter, r *http.Request) {
 	topicConfig := map[string]interface{}{
 		"num_partitions":     req.NumPartitions,
 		"replication_factor": req.ReplicationFactor,
+		"exchange_type":      req.ExchangeType,
+		"binding_key":        req.BindingKey,
 	}
 
-	if err := kafkaAdapter.CreateTopic(r.Context(), req.Topic, topicConfig); err != nil {
+	if err := queue.CreateTopic(r.Context(), req.Topic, topicConfig); err != nil {
 		s.errorResponse(w, http.StatusInternalServerError, "Failed to create topic", err)
 		return
 	}
@@ -538,48 +961,44 @@ func (s *Server) handleCreateTopic(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// handleDeleteTopic handles deleting a Kafka topic
-func (s *Server) handleDeleteTopic(w http.ResponseWriter, r *http.Request) {
+// handleGetTopic returns the partitions of a Kafka topic with their replicas,
+// watermarks and approximate message counts
+func (s *Server) handleGetTopic(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
-	clusterID := vars["cluster_id"]
-	topic := vars["topic"]
 
-	// Find the Kafka service in the cluster
-	config, err := s.gateway.GetClusterConfig(clusterID)
-	if err != nil {
-		s.errorResponse(w, http.StatusNotFound, "Cluster not found", err)
+	kafkaAdapter, _, adapterErr := s.kafkaAdapter(r.Context(), vars["cluster_id"])
+	if adapterErr != nil {
+		s.errorResponse(w, adapterErr.status, adapterErr.message, adapterErr.err)
 		return
 	}
 
-	var kafkaService string
-	for serviceName, serviceConfig := range config.Services {
-		if serviceConfig.Type == "kafka" {
-			kafkaService = serviceName
-			break
+	detail, err := kafkaAdapter.DescribeTopic(r.Context(), vars["topic"])
+	if err != nil {
+		if errors.Is(err, kafka.ErrTopicNotFound) {
+			s.errorResponse(w, http.StatusNotFound, "Topic not found", err)
+			return
 		}
-	}
-
-	if kafkaService == "" {
-		s.errorResponse(w, http.StatusNotFound, "No Kafka service found in cluster", nil)
+		s.errorResponse(w, http.StatusInternalServerError, "Failed to describe topic", err)
 		return
 	}
 
-	// Get the adapter
-	adapter, err := s.gateway.GetAdapter(clusterID, kafkaService)
-	if err != nil {
-		s.errorResponse(w, http.StatusInternalServerError, "Failed to get Kafka adapter", err)
-		return
-	}
+	s.jsonResponse(w, http.StatusOK, detail)
+}
 
-	// Type assert to KafkaAdapter
-	kafkaAdapter, ok := adapter.(*kafka.KafkaAdapter)
-	if !ok {
-		s.errorResponse(w, http.StatusInternalServerError, "Adapter is not a KafkaAdapter", nil)
+// handleDeleteTopic handles deleting a queue topic
+func (s *Server) handleDeleteTopic(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	clusterID := vars["cluster_id"]
+	topic := vars["topic"]
+
+	queue, adapterErr := s.queueAdapter(r.Context(), clusterID)
+	if adapterErr != nil {
+		s.errorResponse(w, adapterErr.status, adapterErr.message, adapterErr.err)
 		return
 	}
 
 	// Delete topic
-	if err := kafkaAdapter.DeleteTopic(r.Context(), topic); err != nil {
+	if err := queue.DeleteTopic(r.Context(), topic); err != nil {
 		logger.Error("Failed to delete topic", zap.Error(err))
 		s.errorResponse(w, http.StatusInternalServerError, "Failed to delete topic", err)
 		return
@@ -0,0 +1,296 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/akmadan/throome/internal/config"
+	"github.com/akmadan/throome/pkg/adapters"
+	"github.com/akmadan/throome/pkg/adapters/postgres"
+	"github.com/akmadan/throome/pkg/cluster"
+	"github.com/akmadan/throome/pkg/router"
+	"github.com/jackc/pgx/v5"
+)
+
+func TestCacheScanParams(t *testing.T) {
+	tests := []struct {
+		query   string
+		cursor  uint64
+		pattern string
+		count   int64
+		invalid bool
+	}{
+		{"", 0, "*", defaultCacheScanCount, false},
+		{"?cursor=1234&pattern=user:*&count=50", 1234, "user:*", 50, false},
+		{"?count=100000", 0, "*", maxCacheScanCount, false},
+		{"?cursor=-1", 0, "", 0, true},
+		{"?count=0", 0, "", 0, true},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/api/v1/clusters/c1/cache/keys"+tt.query, nil)
+		cursor, pattern, count, err := cacheScanParams(r)
+		if tt.invalid {
+			if err == nil {
+				t.Errorf("%q: expected an error", tt.query)
+			}
+			continue
+		}
+		if err != nil || cursor != tt.cursor || pattern != tt.pattern || count != tt.count {
+			t.Errorf("%q: expected %d, %q and %d, got %d, %q, %d and %v", tt.query, tt.cursor, tt.pattern, tt.count, cursor, pattern, count, err)
+		}
+	}
+}
+
+func TestTTLSeconds(t *testing.T) {
+	tests := []struct {
+		ttl     time.Duration
+		seconds int64
+	}{
+		{0, -1},
+		{300 * time.Millisecond, 1},
+		{90 * time.Second, 90},
+		{90*time.Second + time.Millisecond, 91},
+	}
+
+	for _, tt := range tests {
+		if seconds := ttlSeconds(tt.ttl); seconds != tt.seconds {
+			t.Errorf("%s: expected %d seconds, got %d", tt.ttl, tt.seconds, seconds)
+		}
+	}
+}
+
+func TestTTLCommandSeconds(t *testing.T) {
+	tests := []struct {
+		ttl     time.Duration
+		seconds int64
+	}{
+		{-1, -1},
+		{-2, -2},
+		{0, 0},
+		{42 * time.Second, 42},
+	}
+
+	for _, tt := range tests {
+		if seconds := ttlCommandSeconds(tt.ttl); seconds != tt.seconds {
+			t.Errorf("%d: expected %d seconds, got %d", tt.ttl, tt.seconds, seconds)
+		}
+	}
+}
+
+func TestQueryFetchSize(t *testing.T) {
+	tests := []struct {
+		fetchSize int
+		expected  int
+		invalid   bool
+	}{
+		{0, defaultFetchSize, false},
+		{500, 500, false},
+		{maxFetchSize, maxFetchSize, false},
+		{maxFetchSize + 1, 0, true},
+		{-1, 0, true},
+	}
+
+	for _, tt := range tests {
+		fetchSize, err := queryFetchSize(&DBQueryRequest{FetchSize: tt.fetchSize})
+		if tt.invalid {
+			if err == nil {
+				t.Errorf("%d: expected an error", tt.fetchSize)
+			}
+			continue
+		}
+		if err != nil || fetchSize != tt.expected {
+			t.Errorf("%d: expected %d, got %d and %v", tt.fetchSize, tt.expected, fetchSize, err)
+		}
+	}
+}
+
+func TestTransactionOptions(t *testing.T) {
+	tests := []struct {
+		req      DBBeginRequest
+		expected pgx.TxOptions
+		invalid  bool
+	}{
+		{DBBeginRequest{}, pgx.TxOptions{}, false},
+		{DBBeginRequest{IsolationLevel: "serializable"}, pgx.TxOptions{IsoLevel: pgx.Serializable}, false},
+		{DBBeginRequest{IsolationLevel: "REPEATABLE READ", ReadOnly: true}, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly}, false},
+		{DBBeginRequest{IsolationLevel: "snapshot"}, pgx.TxOptions{}, true},
+	}
+
+	for _, tt := range tests {
+		options, err := transactionOptions(&tt.req)
+		if tt.invalid {
+			if err == nil {
+				t.Errorf("%+v: expected an error", tt.req)
+			}
+			continue
+		}
+		if err != nil || options != tt.expected {
+			t.Errorf("%+v: expected %+v, got %+v and %v", tt.req, tt.expected, options, err)
+		}
+	}
+}
+
+func TestServiceCircuitBreaker(t *testing.T) {
+	gw := newTestGateway(t)
+	s := &Server{gateway: gw}
+
+	ctx := context.Background()
+	clusterID, err := gw.CreateCluster(ctx, "breaker", &cluster.Config{
+		Routing: cluster.RoutingConfig{
+			CircuitBreaker: cluster.CBConfig{Enabled: true, FailureThreshold: 2, ResetTimeout: 60},
+		},
+		Services: map[string]cluster.ServiceConfig{
+			"store": {Type: "test-update", Host: "localhost", Port: 9881},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create cluster: %v", err)
+	}
+	defer func() { _ = gw.DeleteCluster(ctx, clusterID) }()
+
+	adapter, _ := gw.GetAdapter(clusterID, "store")
+	adapters.Unwrap(adapter).(*customAdapter).SetConnected(true)
+	if err := gw.SetFault(clusterID, "store", adapters.FaultConfig{ErrorRate: 100}); err != nil {
+		t.Fatalf("Failed to inject fault: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := s.serviceAdapter(ctx, clusterID, "store"); !errors.Is(err, adapters.ErrInjectedFault) {
+			t.Fatalf("Expected the injected fault, got %v", err)
+		}
+	}
+	_, err = s.serviceAdapter(ctx, clusterID, "store")
+	if !errors.Is(err, router.ErrCircuitOpen) || adapterStatus(err) != http.StatusServiceUnavailable {
+		t.Errorf("Expected the open breaker to fail fast with 503, got %v", err)
+	}
+	if state, _ := gw.circuitState(clusterID, "store"); state != router.CircuitOpen {
+		t.Errorf("Expected the service's breaker to be open, got %q", state)
+	}
+}
+
+func TestCreateTopicRequestValidate(t *testing.T) {
+	for _, req := range []CreateTopicRequest{
+		{Topic: "orders"},
+		{Topic: "orders", NumPartitions: 6, ReplicationFactor: 3},
+	} {
+		if err := req.validate(); err != nil {
+			t.Errorf("Expected %+v to be valid, got %v", req, err)
+		}
+	}
+
+	for _, req := range []CreateTopicRequest{
+		{NumPartitions: 1},
+		{Topic: "orders", NumPartitions: -1},
+		{Topic: "orders", ReplicationFactor: -2},
+	} {
+		if err := req.validate(); err == nil {
+			t.Errorf("Expected %+v to be rejected", req)
+		}
+	}
+}
+
+func TestTopicHandlers(t *testing.T) {
+	gw := newTestGateway(t)
+	clusterID, err := gw.CreateCluster(context.Background(), "topics", &cluster.Config{
+		Services: map[string]cluster.ServiceConfig{
+			"app": {Type: "test-update", Host: "localhost", Port: 9893},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create cluster: %v", err)
+	}
+
+	s := &Server{config: config.DefaultConfig(), gateway: gw, router: mux.NewRouter()}
+	s.setupRoutes()
+	topics := "/api/v1/clusters/" + clusterID + "/queue/topics"
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		status int
+	}{
+		{"invalid body", "POST", topics, `{`, http.StatusBadRequest},
+		{"missing topic", "POST", topics, `{"num_partitions": 3}`, http.StatusBadRequest},
+		{"negative partitions", "POST", topics, `{"topic": "orders", "num_partitions": -1}`, http.StatusBadRequest},
+		{"negative replication", "POST", topics, `{"topic": "orders", "replication_factor": -1}`, http.StatusBadRequest},
+		{"fractional partitions", "POST", topics, `{"topic": "orders", "num_partitions": 1.5}`, http.StatusBadRequest},
+		// Valid requests get as far as looking up the cluster's queue service
+		{"create", "POST", topics, `{"topic": "orders", "num_partitions": 3, "replication_factor": 1}`, http.StatusNotFound},
+		{"create with defaults", "POST", topics, `{"topic": "orders"}`, http.StatusNotFound},
+		{"describe", "GET", topics + "/orders", "", http.StatusNotFound},
+		{"delete", "DELETE", topics + "/orders", "", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			s.router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Errorf("Expected status %d, got %d: %s", tt.status, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestRedactSlowQuery(t *testing.T) {
+	slowQuery := func() *postgres.SlowQuery {
+		return &postgres.SlowQuery{
+			Query: "SELECT * FROM users WHERE email = 'ada@example.com' AND id = $1",
+			Args:  []interface{}{4242},
+			Plan: &postgres.ExplainResult{
+				Plan: &postgres.PlanNode{
+					NodeType: "Nested Loop",
+					Plans: []*postgres.PlanNode{{
+						NodeType:  "Index Scan",
+						IndexCond: "(id = 4242)",
+						Filter:    "(email = 'ada@example.com'::text)",
+					}},
+				},
+				Raw: json.RawMessage(`[{"Plan": {"Filter": "(email = 'ada@example.com'::text)"}}]`),
+			},
+		}
+	}
+
+	for _, redaction := range []string{cluster.RedactionStripArgs, cluster.RedactionHashLiterals} {
+		query := slowQuery()
+		plan := query.Plan
+		redactSlowQuery(query, cluster.ActivityConfig{Redaction: redaction})
+
+		output, err := json.Marshal(query)
+		if err != nil {
+			t.Fatalf("Failed to encode: %v", err)
+		}
+		if strings.Contains(string(output), "4242") {
+			t.Errorf("Expected no arguments in the %s output, got %s", redaction, output)
+		}
+		if redaction == cluster.RedactionHashLiterals && strings.Contains(string(output), "ada@example.com") {
+			t.Errorf("Expected no literals in the %s output, got %s", redaction, output)
+		}
+		if query.Plan.Plan.Plans[0].NodeType != "Index Scan" {
+			t.Errorf("Expected the plan to be kept, got %+v", query.Plan.Plan)
+		}
+		if plan.Plan.Plans[0].IndexCond != "(id = 4242)" || plan.Raw == nil {
+			t.Error("Expected the recorded plan to be left alone")
+		}
+	}
+
+	// Without redaction slow queries are served as recorded
+	query := slowQuery()
+	redactSlowQuery(query, cluster.ActivityConfig{})
+	if len(query.Args) != 1 || query.Plan.Plan.Plans[0].Filter != "(email = 'ada@example.com'::text)" {
+		t.Errorf("Expected the slow query unchanged, got %+v", query)
+	}
+}
@@ -0,0 +1,120 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"runtime"
+	"strconv"
+	"time"
+)
+
+// readinessTimeout bounds how long /readyz waits for the Docker daemon
+const readinessTimeout = 2 * time.Second
+
+// Readiness check statuses
+const (
+	CheckOK      = "ok"
+	CheckFailing = "failing"
+	CheckSkipped = "skipped" // The check does not apply, and does not hold readiness back
+)
+
+// LivenessResponse is the body of /healthz
+type LivenessResponse struct {
+	Status        string `json:"status"`
+	StartedAt     string `json:"started_at"`
+	UptimeSeconds int64  `json:"uptime_seconds"`
+	Goroutines    int    `json:"goroutines"`
+	Timestamp     int64  `json:"timestamp"`
+}
+
+// ReadinessCheck is the outcome of one of the checks behind /readyz
+type ReadinessCheck struct {
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+// ReadinessResponse is the body of /readyz
+type ReadinessResponse struct {
+	Status    string                    `json:"status"` // ready or not_ready
+	Checks    map[string]ReadinessCheck `json:"checks"`
+	Adapters  AdapterCounts             `json:"adapters"`
+	Timestamp int64                     `json:"timestamp"`
+}
+
+// AdapterCounts are the services of active clusters and how many of them are connected
+type AdapterCounts struct {
+	Connected  int `json:"connected"`
+	Configured int `json:"configured"`
+	Required   int `json:"required"`
+}
+
+// handleLiveness reports that the process is up and serving requests. It checks
+// nothing else, so orchestrators only restart a gateway that stopped responding.
+func (s *Server) handleLiveness(w http.ResponseWriter, r *http.Request) {
+	s.jsonResponse(w, http.StatusOK, LivenessResponse{
+		Status:        "alive",
+		StartedAt:     s.startedAt.Format(time.RFC3339),
+		UptimeSeconds: int64(time.Since(s.startedAt).Seconds()),
+		Goroutines:    runtime.NumGoroutine(),
+		Timestamp:     time.Now().Unix(),
+	})
+}
+
+// handleReadiness reports whether the gateway can serve traffic: its clusters are
+// loaded, the Docker daemon is reachable when provisioning is available, and enough
+// adapters are connected. Every configured adapter is required unless min_adapters is
+// set. It answers 503 when a check fails.
+func (s *Server) handleReadiness(w http.ResponseWriter, r *http.Request) {
+	loaded, connected, configured := s.gateway.Readiness()
+	required := configured
+	if value := r.URL.Query().Get("min_adapters"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			s.errorResponse(w, http.StatusBadRequest, "Invalid min_adapters", err)
+			return
+		}
+		required = n
+	}
+
+	checks := make(map[string]ReadinessCheck)
+	if loaded {
+		checks["clusters"] = ReadinessCheck{Status: CheckOK}
+	} else {
+		checks["clusters"] = ReadinessCheck{Status: CheckFailing, Message: "Clusters are not loaded"}
+	}
+
+	if s.gateway.Provisioner() == nil {
+		checks["provisioner"] = ReadinessCheck{Status: CheckSkipped, Message: "Provisioner is not available"}
+	} else {
+		ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+		err := s.gateway.Provisioner().Ping(ctx)
+		cancel()
+		if err != nil {
+			checks["provisioner"] = ReadinessCheck{Status: CheckFailing, Message: err.Error()}
+		} else {
+			checks["provisioner"] = ReadinessCheck{Status: CheckOK}
+		}
+	}
+
+	adapters := ReadinessCheck{Status: CheckOK, Message: fmt.Sprintf("%d of %d adapters connected", connected, configured)}
+	if connected < required {
+		adapters.Status = CheckFailing
+	}
+	checks["adapters"] = adapters
+
+	status, code := "ready", http.StatusOK
+	for _, check := range checks {
+		if check.Status == CheckFailing {
+			status, code = "not_ready", http.StatusServiceUnavailable
+			break
+		}
+	}
+
+	s.jsonResponse(w, code, ReadinessResponse{
+		Status:    status,
+		Checks:    checks,
+		Adapters:  AdapterCounts{Connected: connected, Configured: configured, Required: required},
+		Timestamp: time.Now().Unix(),
+	})
+}
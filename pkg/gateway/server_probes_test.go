@@ -0,0 +1,90 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+
+	"github.com/akmadan/throome/internal/config"
+	"github.com/akmadan/throome/pkg/cluster"
+)
+
+func TestReadiness(t *testing.T) {
+	gw := newTestGateway(t)
+	s := &Server{config: config.DefaultConfig(), gateway: gw, router: mux.NewRouter()}
+	s.setupRoutes()
+
+	if _, err := gw.CreateCluster(context.Background(), "probes", &cluster.Config{
+		Services: map[string]cluster.ServiceConfig{
+			"app": {Type: "test-update", Host: "localhost", Port: 9601},
+		},
+	}); err != nil {
+		t.Fatalf("Failed to create cluster: %v", err)
+	}
+
+	readiness := func(query string) (int, ReadinessResponse) {
+		rec := httptest.NewRecorder()
+		s.router.ServeHTTP(rec, httptest.NewRequest("GET", "/readyz"+query, nil))
+		var resp ReadinessResponse
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatalf("Failed to decode readiness: %v", err)
+		}
+		return rec.Code, resp
+	}
+
+	// The shared gateway is never initialized, so its clusters count as not loaded
+	code, resp := readiness("?min_adapters=0")
+	if code != http.StatusServiceUnavailable || resp.Checks["clusters"].Status != CheckFailing {
+		t.Errorf("Expected an uninitialized gateway not to be ready, got %d: %+v", code, resp)
+	}
+
+	gw.mu.Lock()
+	gw.loaded = true
+	gw.mu.Unlock()
+	defer func() {
+		gw.mu.Lock()
+		gw.loaded = false
+		gw.mu.Unlock()
+	}()
+
+	code, resp = readiness("?min_adapters=1")
+	if code != http.StatusOK || resp.Status != "ready" {
+		t.Errorf("Expected the gateway to be ready, got %d: %+v", code, resp)
+	}
+	if resp.Checks["provisioner"].Status != CheckSkipped {
+		t.Errorf("Expected the provisioner check to be skipped, got %+v", resp.Checks["provisioner"])
+	}
+	if resp.Adapters.Connected < 1 || resp.Adapters.Connected > resp.Adapters.Configured {
+		t.Errorf("Unexpected adapter counts: %+v", resp.Adapters)
+	}
+
+	code, resp = readiness("?min_adapters=100000")
+	if code != http.StatusServiceUnavailable || resp.Checks["adapters"].Status != CheckFailing {
+		t.Errorf("Expected too few adapters to fail readiness, got %d: %+v", code, resp)
+	}
+
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, httptest.NewRequest("GET", "/readyz?min_adapters=some", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected an invalid minimum to be rejected, got %d", rec.Code)
+	}
+}
+
+func TestLiveness(t *testing.T) {
+	s := &Server{config: config.DefaultConfig(), gateway: newTestGateway(t), router: mux.NewRouter()}
+	s.setupRoutes()
+
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, httptest.NewRequest("GET", "/healthz", nil))
+	var resp LivenessResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode liveness: %v", err)
+	}
+	if rec.Code != http.StatusOK || resp.Status != "alive" {
+		t.Errorf("Expected the gateway to be alive, got %d: %+v", rec.Code, resp)
+	}
+}
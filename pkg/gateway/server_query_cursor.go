@@ -0,0 +1,104 @@
+package gateway
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/akmadan/throome/pkg/adapters/postgres"
+	"github.com/gorilla/mux"
+)
+
+const (
+	// defaultFetchSize is the page size of cursor fetches that set no fetch size
+	defaultFetchSize = 1000
+
+	// maxFetchSize bounds the rows of one page, which is collected in memory
+	maxFetchSize = 10000
+)
+
+// pageQueryRows answers a query a page of fetch_size rows at a time. The first page
+// opens a server-side cursor whose token is returned with it; passing the token back
+// as cursor fetches the next page, until a page is returned without one.
+func (s *Server) pageQueryRows(w http.ResponseWriter, r *http.Request, pgAdapter *postgres.PostgresAdapter, req *DBQueryRequest) {
+	fetchSize, err := queryFetchSize(req)
+	if err != nil {
+		s.errorResponse(w, http.StatusBadRequest, "Invalid fetch size", err)
+		return
+	}
+
+	if req.Cursor != "" {
+		rows, done, err := pgAdapter.FetchCursor(r.Context(), req.Cursor, fetchSize)
+		if err != nil {
+			s.cursorError(w, err)
+			return
+		}
+
+		response := DBQueryResponse{Rows: rows}
+		if !done {
+			response.Cursor = req.Cursor
+		}
+		s.jsonResponse(w, http.StatusOK, response)
+		return
+	}
+
+	if req.Query == "" {
+		s.errorResponse(w, http.StatusBadRequest, "Query is required", nil)
+		return
+	}
+
+	rows, cursor, err := pgAdapter.OpenCursor(r.Context(), req.Query, fetchSize, req.Args...)
+	if err != nil {
+		s.cursorError(w, err)
+		return
+	}
+
+	s.jsonResponse(w, http.StatusOK, DBQueryResponse{
+		Rows:   rows,
+		Cursor: cursor,
+	})
+}
+
+// handleCloseCursor closes a cursor the client stopped paging through before its end
+func (s *Server) handleCloseCursor(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	pgAdapter, adapterErr := s.postgresAdapter(r.Context(), vars["cluster_id"])
+	if adapterErr != nil {
+		s.errorResponse(w, adapterErr.status, adapterErr.message, adapterErr.err)
+		return
+	}
+
+	if err := pgAdapter.CloseCursor(r.Context(), vars["cursor"]); err != nil {
+		s.cursorError(w, err)
+		return
+	}
+
+	s.jsonResponse(w, http.StatusOK, map[string]string{
+		"status": "success",
+	})
+}
+
+// queryFetchSize returns the page size of a paged query
+func queryFetchSize(req *DBQueryRequest) (int, error) {
+	switch {
+	case req.FetchSize == 0:
+		return defaultFetchSize, nil
+	case req.FetchSize < 0 || req.FetchSize > maxFetchSize:
+		return 0, fmt.Errorf("fetch_size must be between 1 and %d", maxFetchSize)
+	default:
+		return req.FetchSize, nil
+	}
+}
+
+// cursorError responds to a failed use of a cursor
+func (s *Server) cursorError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, postgres.ErrCursorNotFound):
+		s.errorResponse(w, http.StatusNotFound, "Cursor not found or expired", err)
+	case errors.Is(err, postgres.ErrTooManyCursors):
+		s.errorResponse(w, http.StatusTooManyRequests, "Too many open cursors", err)
+	default:
+		s.errorResponse(w, http.StatusInternalServerError, "Failed to execute query", err)
+	}
+}
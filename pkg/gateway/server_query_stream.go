@@ -0,0 +1,115 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/akmadan/throome/pkg/adapters/postgres"
+)
+
+// NDJSONContentType asks /db/query to stream its rows, one JSON object per line
+const NDJSONContentType = "application/x-ndjson"
+
+// Trailers of a streamed query, sent once its last row has been written
+const (
+	RowCountTrailer      = "X-Row-Count"
+	RowsTruncatedTrailer = "X-Rows-Truncated" // "true" when the query hit the row limit
+	StreamErrorTrailer   = "X-Stream-Error"   // Why the query failed after rows were sent
+)
+
+// streamFlushRows is how many rows are written between flushes of a streamed query
+const streamFlushRows = 100
+
+// acceptsNDJSON reports whether a request asked for a streamed response
+func acceptsNDJSON(r *http.Request) bool {
+	for _, accepted := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(accepted))
+		if err == nil && mediaType == NDJSONContentType {
+			return true
+		}
+	}
+	return false
+}
+
+// streamQueryRows runs a query and writes its rows as they are read rather than
+// collecting them, so large results never sit in memory. The status is sent with the
+// first row, so errors from then on are reported in the StreamErrorTrailer trailer,
+// and at most gateway.max_stream_rows rows are written. The query runs on conn.
+func (s *Server) streamQueryRows(w http.ResponseWriter, r *http.Request, pgAdapter *postgres.PostgresAdapter, conn rowQuerier, req *DBQueryRequest) {
+	// Cancelling the query stops the server from sending rows past the limit, which
+	// closing the rows would otherwise read to the end
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	start := time.Now()
+	rows, err := conn.Query(ctx, req.Query, req.Args...)
+	if err != nil {
+		pgAdapter.RecordQuery(r.Context(), req.Query, req.Args, time.Since(start), err, 0, 0)
+		s.errorResponse(w, http.StatusInternalServerError, "Failed to execute query", err)
+		return
+	}
+	defer rows.Close()
+
+	w.Header().Set("Content-Type", NDJSONContentType)
+	w.Header().Set("Trailer", strings.Join([]string{RowCountTrailer, RowsTruncatedTrailer, StreamErrorTrailer}, ", "))
+	w.WriteHeader(http.StatusOK)
+
+	// Large results outlast the server's write timeout
+	rc := http.NewResponseController(w)
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	limit := s.settings().maxStreamRows
+	encoder := json.NewEncoder(w)
+	count, truncated := 0, false
+	var bytes int64
+	var queryErr, streamErr error
+	for rows.Next() {
+		if limit > 0 && count >= limit {
+			truncated = true
+			cancel()
+			break
+		}
+
+		row, err := pgx.RowToMap(rows)
+		if err != nil {
+			queryErr = err
+			break
+		}
+		if err := encoder.Encode(row); err != nil {
+			streamErr = err // The client went away
+			break
+		}
+
+		count++
+		bytes += postgres.RowSize(rows.RawValues())
+		if count%streamFlushRows == 0 {
+			_ = rc.Flush()
+		}
+	}
+	rows.Close()
+	if queryErr == nil && streamErr == nil && !truncated {
+		queryErr = rows.Err()
+	}
+	if streamErr == nil {
+		streamErr = queryErr
+	}
+
+	// Only failures of the database count against the service, not clients that went away
+	duration := time.Since(start)
+	pgAdapter.RecordQuery(r.Context(), req.Query, req.Args, duration, queryErr, int64(count), bytes)
+	pgAdapter.ObserveQuery(r.Context(), req.Query, req.Args, duration)
+
+	w.Header().Set(RowCountTrailer, strconv.Itoa(count))
+	w.Header().Set(RowsTruncatedTrailer, strconv.FormatBool(truncated))
+	if streamErr != nil {
+		w.Header().Set(StreamErrorTrailer, strings.ReplaceAll(streamErr.Error(), "\n", " "))
+	}
+	_ = rc.Flush()
+}
@@ -0,0 +1,27 @@
+package gateway
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAcceptsNDJSON(t *testing.T) {
+	tests := []struct {
+		accept string
+		stream bool
+	}{
+		{"", false},
+		{"application/json", false},
+		{"application/x-ndjson", true},
+		{"application/json, application/x-ndjson; q=0.9", true},
+		{"application/x-ndjsonx", false},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest("POST", "/api/v1/clusters/c1/db/query", nil)
+		r.Header.Set("Accept", tt.accept)
+		if stream := acceptsNDJSON(r); stream != tt.stream {
+			t.Errorf("%q: expected %v, got %v", tt.accept, tt.stream, stream)
+		}
+	}
+}
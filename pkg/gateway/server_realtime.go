@@ -0,0 +1,163 @@
+package gateway
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/akmadan/throome/internal/logger"
+	"github.com/akmadan/throome/pkg/adapters"
+	"github.com/akmadan/throome/pkg/monitor"
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+)
+
+var realtimeUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	// The API allows any origin (see corsMiddleware), so the WebSocket does too
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// RealtimeHealth is the payload of a health event
+type RealtimeHealth struct {
+	Services map[string]*adapters.HealthStatus `json:"services"`
+	Changed  []string                          `json:"changed,omitempty"` // Services whose health changed since the last tick
+}
+
+// handleRealtime upgrades the connection to a WebSocket that streams live cluster
+// state. Clients send RealtimeCommand messages to choose the clusters they follow.
+func (s *Server) handleRealtime(w http.ResponseWriter, r *http.Request) {
+	conn, err := realtimeUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade has already written the error response
+		logger.Warn("Failed to upgrade realtime connection", zap.Error(err))
+		return
+	}
+
+	s.realtime.serve(conn, s.sendClusterState)
+}
+
+// sendClusterState sends the current health and metrics of a cluster to a new subscriber
+func (s *Server) sendClusterState(client *realtimeClient, clusterID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	for _, id := range s.realtimeClusters(map[string]bool{clusterID: true}) {
+		if services := s.checkClusterHealth(ctx, id); services != nil {
+			client.push(&RealtimeEvent{Type: EventHealth, ClusterID: id, Data: &RealtimeHealth{Services: services}})
+		}
+		if metrics := s.gateway.GetCollector().SnapshotCluster(id); metrics != nil {
+			client.push(&RealtimeEvent{Type: EventMetrics, ClusterID: id, Data: metrics})
+		}
+	}
+}
+
+// runRealtime publishes metrics ticks and health transitions for subscribed clusters
+func (s *Server) runRealtime(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	// Last known health per clusterID/serviceName, only touched by this goroutine
+	healthy := make(map[string]bool)
+
+	for {
+		select {
+		case <-ticker.C:
+			s.publishRealtimeTick(healthy)
+		case <-s.realtimeStop:
+			return
+		}
+	}
+}
+
+func (s *Server) publishRealtimeTick(healthy map[string]bool) {
+	subscriptions := s.realtime.Subscriptions()
+	if len(subscriptions) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	for _, clusterID := range s.realtimeClusters(subscriptions) {
+		if services := s.checkClusterHealth(ctx, clusterID); services != nil {
+			changed := make([]string, 0)
+			for serviceName, status := range services {
+				key := clusterID + "/" + serviceName
+				previous, known := healthy[key]
+				if known && previous != status.Healthy {
+					changed = append(changed, serviceName)
+				}
+				healthy[key] = status.Healthy
+			}
+
+			if len(changed) > 0 {
+				sort.Strings(changed)
+				s.realtime.Publish(&RealtimeEvent{
+					Type:      EventHealth,
+					ClusterID: clusterID,
+					Data:      &RealtimeHealth{Services: services, Changed: changed},
+				})
+			}
+		}
+
+		if metrics := s.gateway.GetCollector().SnapshotCluster(clusterID); metrics != nil {
+			s.realtime.Publish(&RealtimeEvent{Type: EventMetrics, ClusterID: clusterID, Data: metrics})
+		}
+	}
+}
+
+// realtimeClusters resolves subscriptions to the IDs of loaded clusters
+func (s *Server) realtimeClusters(subscriptions map[string]bool) []string {
+	if subscriptions[AllClusters] {
+		clusterIDs, err := s.gateway.ListClusters()
+		if err != nil {
+			return nil
+		}
+		return clusterIDs
+	}
+
+	clusterIDs := make([]string, 0, len(subscriptions))
+	for clusterID := range subscriptions {
+		clusterIDs = append(clusterIDs, clusterID)
+	}
+	return clusterIDs
+}
+
+// checkClusterHealth returns the health of a cluster's services, or nil when the
+// cluster is not loaded
+func (s *Server) checkClusterHealth(ctx context.Context, clusterID string) map[string]*adapters.HealthStatus {
+	health, err := s.gateway.ServiceHealth(ctx, clusterID)
+	if err != nil {
+		return nil
+	}
+	return health
+}
+
+// publishActivity forwards activity log entries to realtime subscribers
+func (s *Server) publishActivity(log *monitor.ActivityLog) {
+	s.realtime.Publish(&RealtimeEvent{
+		Type:      EventActivity,
+		ClusterID: log.ClusterID,
+		Timestamp: log.Timestamp,
+		Data:      log,
+	})
+}
+
+// publishRestart reports the progress of restarting an unhealthy service
+func (s *Server) publishRestart(restart *AutoRestart) {
+	s.realtime.Publish(&RealtimeEvent{
+		Type:      EventRestart,
+		ClusterID: restart.ClusterID,
+		Timestamp: restart.Timestamp,
+		Data:      restart,
+	})
+}
+
+// publishProvisioning reports provisioning progress. The cluster has no ID until it
+// is created, so these events only reach subscribers of all clusters.
+func (s *Server) publishProvisioning(progress *ProvisionProgress) {
+	s.realtime.Publish(&RealtimeEvent{Type: EventProvisioning, Data: progress})
+}
@@ -0,0 +1,150 @@
+package gateway
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/akmadan/throome/pkg/adapters/kafka"
+	"github.com/gorilla/mux"
+)
+
+// maxReplayMessages caps the number of messages a single replay republishes
+const maxReplayMessages = 10000
+
+// Replay request/response types
+type QueueReplayRequest struct {
+	Partitions  []int             `json:"partitions,omitempty"`
+	StartOffset int64             `json:"start_offset,omitempty"`
+	EndOffset   int64             `json:"end_offset,omitempty"` // Exclusive
+	Since       time.Time         `json:"since,omitempty"`
+	Until       time.Time         `json:"until,omitempty"` // Exclusive
+	Limit       int               `json:"limit,omitempty"`
+	Destination ReplayDestination `json:"destination"`
+}
+
+type ReplayDestination struct {
+	Type    string `json:"type"`               // topic (default) or consumer_group
+	Topic   string `json:"topic,omitempty"`    // For type topic, defaults to the source topic
+	GroupID string `json:"group_id,omitempty"` // For type consumer_group
+}
+
+type QueueReplayResponse struct {
+	Topic       string            `json:"topic"`
+	Destination ReplayDestination `json:"destination"`
+	Replayed    int               `json:"replayed,omitempty"`
+	Truncated   bool              `json:"truncated,omitempty"` // The range held more messages than the limit
+	Offsets     map[int]int64     `json:"offsets,omitempty"`   // Committed offset per partition, for consumer_group
+}
+
+// handleReplayTopic reprocesses past messages of a topic, either by republishing them
+// to a topic or by rewinding a consumer group's offsets
+func (s *Server) handleReplayTopic(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	clusterID := vars["cluster_id"]
+	topic := vars["topic"]
+
+	var req QueueReplayRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.errorResponse(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	opts, destination, err := replayOptions(&req)
+	if err != nil {
+		s.errorResponse(w, http.StatusBadRequest, "Invalid replay request", err)
+		return
+	}
+
+	kafkaAdapter, _, adapterErr := s.kafkaAdapter(r.Context(), clusterID)
+	if adapterErr != nil {
+		s.errorResponse(w, adapterErr.status, adapterErr.message, adapterErr.err)
+		return
+	}
+
+	response := QueueReplayResponse{Topic: topic}
+
+	switch destination.Type {
+	case "topic":
+		if destination.Topic == "" {
+			destination.Topic = topic
+		}
+
+		limit := req.Limit
+		if limit <= 0 || limit > maxReplayMessages {
+			limit = maxReplayMessages
+		}
+		// Read one extra message to detect a range larger than the limit
+		opts.Limit = limit + 1
+
+		messages, err := kafkaAdapter.ReadMessages(r.Context(), topic, opts)
+		if err != nil {
+			s.errorResponse(w, http.StatusInternalServerError, "Failed to read topic", err)
+			return
+		}
+		if len(messages) > limit {
+			messages = messages[:limit]
+			response.Truncated = true
+		}
+
+		replayed, err := kafkaAdapter.Replay(r.Context(), destination.Topic, messages)
+		if err != nil {
+			s.errorResponse(w, http.StatusInternalServerError, "Failed to replay messages", err)
+			return
+		}
+		response.Replayed = replayed
+
+	case "consumer_group":
+		offsets, err := kafkaAdapter.ResetConsumerGroup(r.Context(), destination.GroupID, topic, opts)
+		if err != nil {
+			s.errorResponse(w, http.StatusConflict, "Failed to reset consumer group offsets", err)
+			return
+		}
+		response.Offsets = offsets
+	}
+
+	response.Destination = destination
+	s.jsonResponse(w, http.StatusOK, response)
+}
+
+// replayOptions validates a replay request and returns the range it reads and its
+// destination, whose type defaults to topic
+func replayOptions(req *QueueReplayRequest) (kafka.ReadOptions, ReplayDestination, error) {
+	opts := kafka.ReadOptions{
+		Partitions:  req.Partitions,
+		StartOffset: req.StartOffset,
+		EndOffset:   req.EndOffset,
+		Since:       req.Since,
+		Until:       req.Until,
+	}
+	destination := req.Destination
+
+	if req.StartOffset < 0 || req.EndOffset < 0 {
+		return opts, destination, fmt.Errorf("offsets cannot be negative")
+	}
+	if req.EndOffset > 0 && req.EndOffset <= req.StartOffset {
+		return opts, destination, fmt.Errorf("end_offset must be greater than start_offset")
+	}
+	if !req.Since.IsZero() && !req.Until.IsZero() && !req.Until.After(req.Since) {
+		return opts, destination, fmt.Errorf("until must be after since")
+	}
+	for _, partition := range req.Partitions {
+		if partition < 0 {
+			return opts, destination, fmt.Errorf("invalid partition %d", partition)
+		}
+	}
+
+	switch destination.Type {
+	case "", "topic":
+		destination.Type = "topic"
+	case "consumer_group":
+		if destination.GroupID == "" {
+			return opts, destination, fmt.Errorf("group_id is required for consumer_group destination")
+		}
+	default:
+		return opts, destination, fmt.Errorf("unknown destination type: %s", destination.Type)
+	}
+
+	return opts, destination, nil
+}
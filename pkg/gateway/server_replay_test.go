@@ -0,0 +1,93 @@
+package gateway
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/akmadan/throome/internal/config"
+	"github.com/akmadan/throome/pkg/cluster"
+)
+
+func TestReplayOptions(t *testing.T) {
+	since := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	opts, destination, err := replayOptions(&QueueReplayRequest{StartOffset: 10, EndOffset: 20, Since: since, Until: since.Add(time.Hour), Partitions: []int{0, 2}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if opts.StartOffset != 10 || opts.EndOffset != 20 || !opts.Since.Equal(since) || !opts.Until.Equal(since.Add(time.Hour)) || len(opts.Partitions) != 2 {
+		t.Errorf("Unexpected options: %+v", opts)
+	}
+	if destination.Type != "topic" {
+		t.Errorf("Expected the destination to default to a topic, got %+v", destination)
+	}
+
+	_, destination, err = replayOptions(&QueueReplayRequest{Destination: ReplayDestination{Type: "consumer_group", GroupID: "billing"}})
+	if err != nil || destination.GroupID != "billing" {
+		t.Errorf("Expected a consumer group destination, got %+v and %v", destination, err)
+	}
+
+	for _, req := range []*QueueReplayRequest{
+		{StartOffset: 20, EndOffset: 20},
+		{StartOffset: 20, EndOffset: 10},
+		{StartOffset: -1},
+		{EndOffset: -5},
+		{Since: since, Until: since},
+		{Since: since, Until: since.Add(-time.Hour)},
+		{Partitions: []int{0, -1}},
+		{Destination: ReplayDestination{Type: "consumer_group"}},
+		{Destination: ReplayDestination{Type: "webhook"}},
+	} {
+		if _, _, err := replayOptions(req); err == nil {
+			t.Errorf("Expected %+v to be rejected", req)
+		}
+	}
+}
+
+func TestReplayTopicValidation(t *testing.T) {
+	gw := newTestGateway(t)
+	clusterID, err := gw.CreateCluster(context.Background(), "replay", &cluster.Config{
+		Services: map[string]cluster.ServiceConfig{
+			"app": {Type: "test-update", Host: "localhost", Port: 9892},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create cluster: %v", err)
+	}
+
+	s := &Server{config: config.DefaultConfig(), gateway: gw, router: mux.NewRouter()}
+	s.setupRoutes()
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+	}{
+		{"invalid body", `{`, http.StatusBadRequest},
+		{"empty offset range", `{"start_offset": 5, "end_offset": 5}`, http.StatusBadRequest},
+		{"empty time range", `{"since": "2024-03-01T00:00:00Z", "until": "2024-03-01T00:00:00Z"}`, http.StatusBadRequest},
+		{"consumer group without group_id", `{"destination": {"type": "consumer_group"}}`, http.StatusBadRequest},
+		{"unknown destination", `{"destination": {"type": "webhook"}}`, http.StatusBadRequest},
+		// Valid requests get as far as looking up the cluster's Kafka service
+		{"topic replay", `{"start_offset": 5, "end_offset": 10}`, http.StatusNotFound},
+		{"consumer group reset", `{"since": "2024-03-01T00:00:00Z", "destination": {"type": "consumer_group", "group_id": "billing"}}`, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/api/v1/clusters/"+clusterID+"/queue/topics/orders/replay", strings.NewReader(tt.body))
+			s.router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Errorf("Expected status %d, got %d: %s", tt.status, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
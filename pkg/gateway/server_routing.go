@@ -0,0 +1,82 @@
+package gateway
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/akmadan/throome/pkg/cluster"
+)
+
+// RoutingRequest changes the routing settings of a cluster. Settings left out are
+// kept.
+type RoutingRequest struct {
+	Strategy        *string               `json:"strategy,omitempty"`
+	FailoverEnabled *bool                 `json:"failover_enabled,omitempty"`
+	TimeoutMS       *int                  `json:"timeout_ms,omitempty"`
+	RetryAttempts   *int                  `json:"retry_attempts,omitempty"`
+	CircuitBreaker  *cluster.CBConfig     `json:"circuit_breaker,omitempty"`
+	Sticky          *cluster.StickyConfig `json:"sticky,omitempty"`
+}
+
+// apply returns the routing settings with the changes of the request
+func (req *RoutingRequest) apply(routing cluster.RoutingConfig) cluster.RoutingConfig {
+	if req.Strategy != nil {
+		routing.Strategy = *req.Strategy
+	}
+	if req.FailoverEnabled != nil {
+		routing.FailoverEnabled = *req.FailoverEnabled
+	}
+	if req.TimeoutMS != nil {
+		routing.TimeoutMS = *req.TimeoutMS
+	}
+	if req.RetryAttempts != nil {
+		routing.RetryAttempts = *req.RetryAttempts
+	}
+	if req.CircuitBreaker != nil {
+		routing.CircuitBreaker = *req.CircuitBreaker
+	}
+	if req.Sticky != nil {
+		routing.Sticky = *req.Sticky
+	}
+	return routing
+}
+
+// handleUpdateRouting changes the routing strategy, timeouts, retries, circuit
+// breakers or sticky sessions of a running cluster, and saves them to its
+// configuration
+func (s *Server) handleUpdateRouting(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	clusterID := vars["cluster_id"]
+
+	current, err := s.gateway.GetClusterConfig(clusterID)
+	if err != nil {
+		s.errorResponse(w, http.StatusNotFound, "Cluster not found", err)
+		return
+	}
+	if current.IsArchived() {
+		s.errorResponse(w, http.StatusConflict, "Cluster is archived, restore it before updating it", nil)
+		return
+	}
+
+	var req RoutingRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.errorResponse(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	updated := current.Clone()
+	updated.Routing = req.apply(current.Routing)
+	if err := updated.Validate(); err != nil {
+		s.errorResponse(w, http.StatusBadRequest, "Invalid routing configuration", err)
+		return
+	}
+
+	if err := s.gateway.UpdateRouting(clusterID, updated.Routing); err != nil {
+		s.errorResponse(w, http.StatusInternalServerError, "Failed to update routing", err)
+		return
+	}
+
+	s.jsonResponse(w, http.StatusOK, updated.Routing)
+}
@@ -0,0 +1,95 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/akmadan/throome/pkg/adapters"
+	"github.com/gorilla/mux"
+)
+
+type SecretWriteRequest struct {
+	Data map[string]interface{} `json:"data"`
+}
+
+// handleReadSecret returns the latest version of a secret
+func (s *Server) handleReadSecret(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	secrets, adapterErr := s.secretAdapter(r.Context(), vars["cluster_id"])
+	if adapterErr != nil {
+		s.errorResponse(w, adapterErr.status, adapterErr.message, adapterErr.err)
+		return
+	}
+
+	secret, err := secrets.ReadSecret(r.Context(), vars["path"])
+	if err != nil {
+		if errors.Is(err, adapters.ErrSecretNotFound) {
+			s.errorResponse(w, http.StatusNotFound, "Secret not found", err)
+			return
+		}
+		s.errorResponse(w, http.StatusInternalServerError, "Failed to read secret", err)
+		return
+	}
+
+	s.jsonResponse(w, http.StatusOK, secret)
+}
+
+// handleWriteSecret stores a new version of a secret
+func (s *Server) handleWriteSecret(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var req SecretWriteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.errorResponse(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	if len(req.Data) == 0 {
+		s.errorResponse(w, http.StatusBadRequest, "Data is required", nil)
+		return
+	}
+
+	secrets, adapterErr := s.secretAdapter(r.Context(), vars["cluster_id"])
+	if adapterErr != nil {
+		s.errorResponse(w, adapterErr.status, adapterErr.message, adapterErr.err)
+		return
+	}
+
+	secret, err := secrets.WriteSecret(r.Context(), vars["path"], req.Data)
+	if err != nil {
+		s.errorResponse(w, http.StatusInternalServerError, "Failed to write secret", err)
+		return
+	}
+
+	s.jsonResponse(w, http.StatusOK, secret)
+}
+
+// secretAdapter resolves the secret store adapter of a cluster
+func (s *Server) secretAdapter(ctx context.Context, clusterID string) (adapters.SecretAdapter, *adapterError) {
+	config, err := s.gateway.GetClusterConfig(clusterID)
+	if err != nil {
+		return nil, &adapterError{http.StatusNotFound, "Cluster not found", err}
+	}
+
+	vaultService, adapterErr := s.selectService(ctx, clusterID, config, "vault")
+	if adapterErr != nil {
+		return nil, adapterErr
+	}
+	if vaultService == "" {
+		return nil, &adapterError{http.StatusNotFound, "No Vault service found in cluster", nil}
+	}
+
+	adapter, err := s.serviceAdapter(ctx, clusterID, vaultService)
+	if err != nil {
+		return nil, &adapterError{adapterStatus(err), "Failed to get secret adapter", err}
+	}
+
+	secrets, ok := adapter.(adapters.SecretAdapter)
+	if !ok {
+		return nil, &adapterError{http.StatusInternalServerError, "Adapter is not a SecretAdapter", nil}
+	}
+
+	return secrets, nil
+}
@@ -0,0 +1,296 @@
+package gateway
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/akmadan/throome/internal/logger"
+	"github.com/akmadan/throome/pkg/adapters/kafka"
+	"github.com/akmadan/throome/pkg/adapters/postgres"
+	"github.com/akmadan/throome/pkg/adapters/redis"
+	"github.com/gorilla/mux"
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+)
+
+// Seed request/response types
+type SeedRequest struct {
+	Tables []SeedTable      `json:"tables,omitempty"`
+	Cache  []SeedCacheEntry `json:"cache,omitempty"`
+	Topics []SeedTopic      `json:"topics,omitempty"`
+}
+
+type SeedTable struct {
+	Name     string                   `json:"name"`
+	Truncate bool                     `json:"truncate"` // Empty the table before inserting rows
+	Rows     []map[string]interface{} `json:"rows"`
+}
+
+type SeedCacheEntry struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+	TTL   int    `json:"ttl,omitempty"` // TTL in seconds
+}
+
+type SeedTopic struct {
+	Name     string   `json:"name"`
+	Messages []string `json:"messages"`
+}
+
+type SeedResponse struct {
+	RowsInserted      int64 `json:"rows_inserted"`
+	TablesTruncated   int   `json:"tables_truncated"`
+	KeysSet           int   `json:"keys_set"`
+	MessagesPublished int   `json:"messages_published"`
+}
+
+// handleSeedCluster loads a fixture payload into the cluster's services.
+// Database rows are applied in a single transaction and cache keys in a single
+// MULTI/EXEC block; topic messages are written in one batch per topic.
+func (s *Server) handleSeedCluster(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	clusterID := vars["cluster_id"]
+
+	var req SeedRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.errorResponse(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	if err := req.validate(); err != nil {
+		s.errorResponse(w, http.StatusBadRequest, "Invalid seed payload", err)
+		return
+	}
+
+	config, err := s.gateway.GetClusterConfig(clusterID)
+	if err != nil {
+		s.errorResponse(w, http.StatusNotFound, "Cluster not found", err)
+		return
+	}
+
+	// Resolve every adapter before applying anything so a missing service
+	// doesn't leave the cluster half-seeded
+	var pgAdapter *postgres.PostgresAdapter
+	if len(req.Tables) > 0 {
+		serviceName, adapterErr := s.selectService(r.Context(), clusterID, config, databaseServiceTypes...)
+		if adapterErr != nil {
+			s.errorResponse(w, adapterErr.status, adapterErr.message, adapterErr.err)
+			return
+		}
+		if serviceName == "" {
+			s.errorResponse(w, http.StatusNotFound, "No PostgreSQL service found in cluster", nil)
+			return
+		}
+		adapter, err := s.serviceAdapter(r.Context(), clusterID, serviceName)
+		if err != nil {
+			s.errorResponse(w, adapterStatus(err), "Failed to get database adapter", err)
+			return
+		}
+		var ok bool
+		if pgAdapter, ok = adapter.(*postgres.PostgresAdapter); !ok {
+			s.errorResponse(w, http.StatusInternalServerError, "Adapter is not a PostgresAdapter", nil)
+			return
+		}
+	}
+
+	var redisAdapter *redis.RedisAdapter
+	if len(req.Cache) > 0 {
+		serviceName, adapterErr := s.selectService(r.Context(), clusterID, config, "redis")
+		if adapterErr != nil {
+			s.errorResponse(w, adapterErr.status, adapterErr.message, adapterErr.err)
+			return
+		}
+		if serviceName == "" {
+			s.errorResponse(w, http.StatusNotFound, "No Redis service found in cluster", nil)
+			return
+		}
+		adapter, err := s.serviceAdapter(r.Context(), clusterID, serviceName)
+		if err != nil {
+			s.errorResponse(w, adapterStatus(err), "Failed to get cache adapter", err)
+			return
+		}
+		var ok bool
+		if redisAdapter, ok = adapter.(*redis.RedisAdapter); !ok {
+			s.errorResponse(w, http.StatusInternalServerError, "Adapter is not a RedisAdapter", nil)
+			return
+		}
+	}
+
+	var kafkaAdapter *kafka.KafkaAdapter
+	if len(req.Topics) > 0 {
+		serviceName, adapterErr := s.selectService(r.Context(), clusterID, config, "kafka")
+		if adapterErr != nil {
+			s.errorResponse(w, adapterErr.status, adapterErr.message, adapterErr.err)
+			return
+		}
+		if serviceName == "" {
+			s.errorResponse(w, http.StatusNotFound, "No Kafka service found in cluster", nil)
+			return
+		}
+		adapter, err := s.serviceAdapter(r.Context(), clusterID, serviceName)
+		if err != nil {
+			s.errorResponse(w, adapterStatus(err), "Failed to get Kafka adapter", err)
+			return
+		}
+		var ok bool
+		if kafkaAdapter, ok = adapter.(*kafka.KafkaAdapter); !ok {
+			s.errorResponse(w, http.StatusInternalServerError, "Adapter is not a KafkaAdapter", nil)
+			return
+		}
+	}
+
+	var resp SeedResponse
+
+	// Seed database tables inside one transaction
+	if pgAdapter != nil {
+		tx, err := pgAdapter.Begin(r.Context())
+		if err != nil {
+			s.errorResponse(w, http.StatusInternalServerError, "Failed to begin transaction", err)
+			return
+		}
+
+		for _, table := range req.Tables {
+			if table.Truncate {
+				query := fmt.Sprintf("TRUNCATE TABLE %s CASCADE", quoteTableName(table.Name))
+				if _, err := tx.Execute(r.Context(), query); err != nil {
+					_ = tx.Rollback()
+					s.errorResponse(w, http.StatusInternalServerError,
+						fmt.Sprintf("Failed to truncate table %s", table.Name), err)
+					return
+				}
+				resp.TablesTruncated++
+			}
+
+			for _, row := range table.Rows {
+				query, args := buildInsert(table.Name, row)
+				result, err := tx.Execute(r.Context(), query, args...)
+				if err != nil {
+					_ = tx.Rollback()
+					s.errorResponse(w, http.StatusInternalServerError,
+						fmt.Sprintf("Failed to insert row into %s", table.Name), err)
+					return
+				}
+				resp.RowsInserted += result.RowsAffected()
+			}
+		}
+
+		if err := tx.Commit(); err != nil {
+			s.errorResponse(w, http.StatusInternalServerError, "Failed to commit seed transaction", err)
+			return
+		}
+	}
+
+	// Seed cache keys atomically
+	if redisAdapter != nil {
+		items := make([]redis.KeyValue, 0, len(req.Cache))
+		for _, entry := range req.Cache {
+			items = append(items, redis.KeyValue{
+				Key:        entry.Key,
+				Value:      entry.Value,
+				Expiration: time.Duration(entry.TTL) * time.Second,
+			})
+		}
+		if err := redisAdapter.SetAll(r.Context(), items); err != nil {
+			s.errorResponse(w, http.StatusInternalServerError, "Failed to seed cache", err)
+			return
+		}
+		resp.KeysSet = len(items)
+	}
+
+	// Seed topics, one batch per topic
+	if kafkaAdapter != nil {
+		for _, topic := range req.Topics {
+			messages := make([][]byte, 0, len(topic.Messages))
+			for _, message := range topic.Messages {
+				messages = append(messages, []byte(message))
+			}
+			if err := kafkaAdapter.PublishBatch(r.Context(), topic.Name, messages); err != nil {
+				s.errorResponse(w, http.StatusInternalServerError,
+					fmt.Sprintf("Failed to seed topic %s", topic.Name), err)
+				return
+			}
+			resp.MessagesPublished += len(messages)
+		}
+	}
+
+	logger.Info("Cluster seeded",
+		zap.String("cluster_id", clusterID),
+		zap.Int64("rows_inserted", resp.RowsInserted),
+		zap.Int("keys_set", resp.KeysSet),
+		zap.Int("messages_published", resp.MessagesPublished),
+	)
+
+	s.jsonResponse(w, http.StatusOK, resp)
+}
+
+// validate checks that the seed payload is well formed
+func (req *SeedRequest) validate() error {
+	if len(req.Tables) == 0 && len(req.Cache) == 0 && len(req.Topics) == 0 {
+		return fmt.Errorf("at least one of tables, cache or topics is required")
+	}
+	for i, table := range req.Tables {
+		if table.Name == "" {
+			return fmt.Errorf("tables[%d]: name is required", i)
+		}
+		if slices.Contains(strings.Split(table.Name, "."), "") {
+			return fmt.Errorf("tables[%d]: invalid table name %q", i, table.Name)
+		}
+		for j, row := range table.Rows {
+			if len(row) == 0 {
+				return fmt.Errorf("tables[%d].rows[%d]: row has no columns", i, j)
+			}
+			if _, exists := row[""]; exists {
+				return fmt.Errorf("tables[%d].rows[%d]: column name is required", i, j)
+			}
+		}
+	}
+	for i, entry := range req.Cache {
+		if entry.Key == "" {
+			return fmt.Errorf("cache[%d]: key is required", i)
+		}
+	}
+	for i, topic := range req.Topics {
+		if topic.Name == "" {
+			return fmt.Errorf("topics[%d]: name is required", i)
+		}
+	}
+	return nil
+}
+
+// quoteTableName quotes a possibly schema-qualified table name. Each part is quoted on
+// its own, so quotes within a part are escaped rather than ending it.
+func quoteTableName(name string) string {
+	return pgx.Identifier(strings.Split(name, ".")).Sanitize()
+}
+
+// buildInsert builds a parameterized INSERT statement for a single row.
+// Columns are sorted so the generated SQL is deterministic.
+func buildInsert(table string, row map[string]interface{}) (string, []interface{}) {
+	columns := make([]string, 0, len(row))
+	for column := range row {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+
+	quoted := make([]string, len(columns))
+	placeholders := make([]string, len(columns))
+	args := make([]interface{}, len(columns))
+	for i, column := range columns {
+		quoted[i] = pgx.Identifier{column}.Sanitize()
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+		args[i] = row[column]
+	}
+
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
+		quoteTableName(table),
+		strings.Join(quoted, ", "),
+		strings.Join(placeholders, ", "),
+	)
+
+	return query, args
+}
@@ -0,0 +1,85 @@
+package gateway
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestQuoteTableName(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"users", `"users"`},
+		{"public.users", `"public"."users"`},
+		{"Mixed Case", `"Mixed Case"`},
+		{`users"; DROP TABLE users; --`, `"users""; DROP TABLE users; --"`},
+		{`my"schema.my"table`, `"my""schema"."my""table"`},
+	}
+
+	for _, tt := range tests {
+		if quoted := quoteTableName(tt.name); quoted != tt.expected {
+			t.Errorf("quoteTableName(%q) = %s, expected %s", tt.name, quoted, tt.expected)
+		}
+	}
+}
+
+func TestBuildInsert(t *testing.T) {
+	query, args := buildInsert("app.users", map[string]interface{}{
+		"name":       "Ada",
+		"id":         1,
+		`email"addr`: "ada@example.com",
+	})
+
+	// Columns are sorted, and every column has one placeholder and one argument in the
+	// same position
+	expected := `INSERT INTO "app"."users" ("email""addr", "id", "name") VALUES ($1, $2, $3)`
+	if query != expected {
+		t.Errorf("Expected %s, got %s", expected, query)
+	}
+	if len(args) != 3 || args[0] != "ada@example.com" || args[1] != 1 || args[2] != "Ada" {
+		t.Errorf("Expected the arguments in column order, got %v", args)
+	}
+
+	// Rows of a table are built on their own, so they may have different columns
+	query, args = buildInsert("users", map[string]interface{}{"id": 2})
+	if query != `INSERT INTO "users" ("id") VALUES ($1)` || len(args) != 1 {
+		t.Errorf("Unexpected insert of a single column: %s %v", query, args)
+	}
+}
+
+func TestSeedRequestValidate(t *testing.T) {
+	valid := SeedRequest{
+		Tables: []SeedTable{{Name: "public.users", Rows: []map[string]interface{}{{"id": 1}, {"id": 2, "name": "Ada"}}}},
+		Cache:  []SeedCacheEntry{{Key: "greeting", Value: "hello"}},
+		Topics: []SeedTopic{{Name: "orders", Messages: []string{"{}"}}},
+	}
+	if err := valid.validate(); err != nil {
+		t.Errorf("Expected a valid payload, got %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		req   SeedRequest
+		error string
+	}{
+		{"empty payload", SeedRequest{}, "at least one of"},
+		{"table without a name", SeedRequest{Tables: []SeedTable{{Rows: []map[string]interface{}{{"id": 1}}}}}, "tables[0]: name is required"},
+		{"empty schema", SeedRequest{Tables: []SeedTable{{Name: ".users"}}}, "invalid table name"},
+		{"empty table", SeedRequest{Tables: []SeedTable{{Name: "public."}}}, "invalid table name"},
+		{"empty part", SeedRequest{Tables: []SeedTable{{Name: "db..users"}}}, "invalid table name"},
+		{"row without columns", SeedRequest{Tables: []SeedTable{{Name: "users", Rows: []map[string]interface{}{{"id": 1}, {}}}}}, "tables[0].rows[1]: row has no columns"},
+		{"column without a name", SeedRequest{Tables: []SeedTable{{Name: "users", Rows: []map[string]interface{}{{"": 1}}}}}, "column name is required"},
+		{"cache entry without a key", SeedRequest{Cache: []SeedCacheEntry{{Key: "a"}, {Value: "b"}}}, "cache[1]: key is required"},
+		{"topic without a name", SeedRequest{Topics: []SeedTopic{{Messages: []string{"{}"}}}}, "topics[0]: name is required"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.req.validate()
+			if err == nil || !strings.Contains(err.Error(), tt.error) {
+				t.Errorf("Expected an error containing %q, got %v", tt.error, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,261 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/akmadan/throome/pkg/cluster"
+	"github.com/gorilla/mux"
+)
+
+// handleAddService adds a service to a running cluster, provisioning its container
+// when requested. The cluster's other services keep running.
+func (s *Server) handleAddService(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	clusterID := vars["cluster_id"]
+
+	current, err := s.gateway.GetClusterConfig(clusterID)
+	if err != nil {
+		s.errorResponse(w, http.StatusNotFound, "Cluster not found", err)
+		return
+	}
+	if current.IsArchived() {
+		s.errorResponse(w, http.StatusConflict, "Cluster is archived, restore it before adding services", nil)
+		return
+	}
+
+	var req struct {
+		Name   string                 `json:"name"`
+		Config map[string]interface{} `json:"config"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.errorResponse(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	if req.Name == "" {
+		s.errorResponse(w, http.StatusBadRequest, "Service name is required", nil)
+		return
+	}
+	if req.Config == nil {
+		s.errorResponse(w, http.StatusBadRequest, "Service configuration is required", nil)
+		return
+	}
+	if _, exists := current.Services[req.Name]; exists {
+		s.errorResponse(w, http.StatusConflict, "Service already exists", nil)
+		return
+	}
+
+	serviceConfig, err := s.convertJSONToServiceConfig(req.Name, req.Config)
+	if err != nil {
+		s.errorResponse(w, http.StatusBadRequest, "Invalid service configuration", err)
+		return
+	}
+	if err := serviceConfig.Validate(); err != nil {
+		s.errorResponse(w, http.StatusBadRequest, "Invalid service configuration", err)
+		return
+	}
+
+	// Provision the service with Docker if provisioner is available
+	pending := &cluster.Config{
+		ClusterID: clusterID,
+		Name:      current.Name,
+		Docker:    current.Docker,
+		Services:  map[string]cluster.ServiceConfig{req.Name: serviceConfig},
+	}
+	if err := s.gateway.provisionServices(r.Context(), pending); err != nil {
+		s.provisionErrorResponse(w, "Failed to provision service", err)
+		return
+	}
+	serviceConfig = pending.Services[req.Name]
+
+	if err := s.gateway.AddService(r.Context(), clusterID, req.Name, serviceConfig); err != nil {
+		// Cleanup the provisioned container on failure
+		s.gateway.removeProvisioned(r.Context(), pending)
+		s.errorResponse(w, http.StatusInternalServerError, "Failed to add service", err)
+		return
+	}
+
+	// Check health status
+	healthy := false
+	adapter, err := s.gateway.GetAdapter(clusterID, req.Name)
+	if err == nil {
+		status, err := adapter.HealthCheck(r.Context())
+		if err == nil && status.Healthy {
+			healthy = true
+		}
+	}
+
+	s.jsonResponse(w, http.StatusCreated, map[string]interface{}{
+		"name":         req.Name,
+		"type":         serviceConfig.Type,
+		"host":         serviceConfig.Host,
+		"port":         serviceConfig.Port,
+		"container_id": serviceConfig.ContainerID,
+		"healthy":      healthy,
+		"message":      "Service added successfully",
+	})
+}
+
+// handleRemoveService removes a service from a running cluster along with its
+// container
+func (s *Server) handleRemoveService(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	clusterID := vars["cluster_id"]
+	serviceName := vars["service_name"]
+
+	current, err := s.gateway.GetClusterConfig(clusterID)
+	if err != nil {
+		s.errorResponse(w, http.StatusNotFound, "Cluster not found", err)
+		return
+	}
+	if current.IsArchived() {
+		s.errorResponse(w, http.StatusConflict, "Cluster is archived, restore it before removing services", nil)
+		return
+	}
+	if _, exists := current.Services[serviceName]; !exists {
+		s.errorResponse(w, http.StatusNotFound, "Service not found", nil)
+		return
+	}
+	if len(current.Services) == 1 {
+		s.errorResponse(w, http.StatusConflict, "Cannot remove the last service of a cluster, delete the cluster instead", nil)
+		return
+	}
+
+	if err := s.gateway.RemoveService(r.Context(), clusterID, serviceName); err != nil {
+		s.errorResponse(w, http.StatusInternalServerError, "Failed to remove service", err)
+		return
+	}
+
+	s.jsonResponse(w, http.StatusOK, map[string]interface{}{
+		"message": "Service removed successfully",
+	})
+}
+
+// handleStartService starts a stopped service and reconnects it
+func (s *Server) handleStartService(w http.ResponseWriter, r *http.Request) {
+	s.serviceLifecycle(w, r, s.gateway.StartService, "Service started successfully")
+}
+
+// handleStopService disconnects a service and stops its container
+func (s *Server) handleStopService(w http.ResponseWriter, r *http.Request) {
+	s.serviceLifecycle(w, r, s.gateway.StopService, "Service stopped successfully")
+}
+
+// handleRestartService restarts a service and reconnects it
+func (s *Server) handleRestartService(w http.ResponseWriter, r *http.Request) {
+	s.serviceLifecycle(w, r, s.gateway.RestartService, "Service restarted successfully")
+}
+
+// serviceLifecycle checks that a service runs in a container provisioned by the
+// gateway before applying a lifecycle operation to it
+func (s *Server) serviceLifecycle(w http.ResponseWriter, r *http.Request, op func(ctx context.Context, clusterID, serviceName string) error, message string) {
+	vars := mux.Vars(r)
+	clusterID := vars["cluster_id"]
+	serviceName := vars["service_name"]
+
+	if s.gateway.Provisioner() == nil {
+		s.errorResponse(w, http.StatusServiceUnavailable, "Provisioner not available", nil)
+		return
+	}
+
+	config, err := s.gateway.GetClusterConfig(clusterID)
+	if err != nil {
+		s.errorResponse(w, http.StatusNotFound, "Cluster not found", err)
+		return
+	}
+	if config.IsArchived() {
+		s.errorResponse(w, http.StatusConflict, "Cluster is archived, restore it first", nil)
+		return
+	}
+	serviceConfig, exists := config.Services[serviceName]
+	if !exists {
+		s.errorResponse(w, http.StatusNotFound, "Service not found", nil)
+		return
+	}
+	if serviceConfig.ContainerID == "" {
+		s.errorResponse(w, http.StatusConflict, "Service is not provisioned by Throome", nil)
+		return
+	}
+
+	if err := op(r.Context(), clusterID, serviceName); err != nil {
+		s.errorResponse(w, http.StatusInternalServerError, "Service lifecycle operation failed", err)
+		return
+	}
+
+	_, err = s.gateway.GetAdapter(clusterID, serviceName)
+	s.jsonResponse(w, http.StatusOK, map[string]interface{}{
+		"connected": err == nil,
+		"message":   message,
+	})
+}
+
+// ScaleRequest is the number of replicas to scale a service to
+type ScaleRequest struct {
+	Replicas int `json:"replicas"` // Containers next to the service's own, at most 10
+}
+
+// handleScaleService provisions or removes replicas of a provisioned service until
+// it has the requested number. Requests routed to the service are spread over it and
+// its replicas.
+func (s *Server) handleScaleService(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	clusterID := vars["cluster_id"]
+	serviceName := vars["service_name"]
+
+	var req ScaleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.errorResponse(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	if req.Replicas < 0 || req.Replicas > MaxReplicas {
+		s.errorResponse(w, http.StatusBadRequest, fmt.Sprintf("Replicas must be between 0 and %d", MaxReplicas), nil)
+		return
+	}
+
+	if s.gateway.Provisioner() == nil {
+		s.errorResponse(w, http.StatusServiceUnavailable, "Provisioner not available", nil)
+		return
+	}
+	config, err := s.gateway.GetClusterConfig(clusterID)
+	if err != nil {
+		s.errorResponse(w, http.StatusNotFound, "Cluster not found", err)
+		return
+	}
+	if config.IsArchived() {
+		s.errorResponse(w, http.StatusConflict, "Cluster is archived, restore it first", nil)
+		return
+	}
+	serviceConfig, exists := config.Services[serviceName]
+	if !exists {
+		s.errorResponse(w, http.StatusNotFound, "Service not found", nil)
+		return
+	}
+	if serviceConfig.ContainerID == "" {
+		s.errorResponse(w, http.StatusConflict, "Service is not provisioned by Throome", nil)
+		return
+	}
+
+	scaled, err := s.gateway.ScaleService(r.Context(), clusterID, serviceName, req.Replicas)
+	if err != nil {
+		s.provisionErrorResponse(w, "Failed to scale service", err)
+		return
+	}
+
+	replicas := make([]map[string]interface{}, 0, len(scaled.Replicas))
+	for _, replica := range scaled.Replicas {
+		replicas = append(replicas, map[string]interface{}{
+			"host":         replica.Host,
+			"port":         replica.Port,
+			"container_id": replica.ContainerID,
+		})
+	}
+	s.jsonResponse(w, http.StatusOK, map[string]interface{}{
+		"name":     serviceName,
+		"replicas": replicas,
+		"message":  "Service scaled successfully",
+	})
+}
@@ -0,0 +1,60 @@
+package gateway
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/akmadan/throome/pkg/monitor"
+	"github.com/gorilla/mux"
+)
+
+// ServiceSLA is the availability of a service over the last week and month
+type ServiceSLA struct {
+	ServiceName string             `json:"service_name"`
+	ServiceType string             `json:"service_type"`
+	Healthy     bool               `json:"healthy"`
+	Weekly      *monitor.SLAReport `json:"weekly"`
+	Monthly     *monitor.SLAReport `json:"monthly"`
+	Incidents   []monitor.Incident `json:"incidents"` // Incidents of the month, oldest first
+}
+
+// handleClusterSLA returns the weekly and monthly uptime, MTTR and incidents of each
+// service of a cluster, computed from its health checks
+func (s *Server) handleClusterSLA(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	clusterID := vars["cluster_id"]
+
+	config, err := s.gateway.GetClusterConfig(clusterID)
+	if err != nil {
+		s.errorResponse(w, http.StatusNotFound, "Cluster not found", err)
+		return
+	}
+
+	now := time.Now()
+	checker := s.gateway.GetHealthChecker()
+	services := make([]*ServiceSLA, 0, len(config.Services))
+	for serviceName, serviceConfig := range config.Services {
+		name := clusterID + "/" + serviceName
+		history := checker.GetHealthHistory(name)
+		if history == nil {
+			history = &monitor.HealthHistory{ServiceName: name}
+		}
+
+		services = append(services, &ServiceSLA{
+			ServiceName: serviceName,
+			ServiceType: serviceConfig.Type,
+			Healthy:     checker.IsHealthy(name),
+			Weekly:      history.SLA(monitor.SLAWeek, now),
+			Monthly:     history.SLA(monitor.SLAMonth, now),
+			Incidents:   history.IncidentsSince(now.Add(-monitor.SLAMonth)),
+		})
+	}
+	sort.Slice(services, func(i, j int) bool { return services[i].ServiceName < services[j].ServiceName })
+
+	s.jsonResponse(w, http.StatusOK, map[string]interface{}{
+		"cluster_id":   clusterID,
+		"generated_at": now,
+		"services":     services,
+	})
+}
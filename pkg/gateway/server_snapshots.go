@@ -0,0 +1,461 @@
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/akmadan/throome/internal/logger"
+	"github.com/akmadan/throome/pkg/adapters"
+	"github.com/akmadan/throome/pkg/adapters/kafka"
+	"github.com/akmadan/throome/pkg/adapters/redis"
+	"github.com/akmadan/throome/pkg/cluster"
+	"github.com/akmadan/throome/pkg/provisioner"
+	"github.com/akmadan/throome/pkg/snapshot"
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+)
+
+// defaultSnapshotMessageLimit caps the messages captured per topic
+const defaultSnapshotMessageLimit = 10000
+
+// Snapshot request/response types
+type SnapshotCreateRequest struct {
+	Name         string `json:"name"`
+	TopicsOnly   bool   `json:"topics_only,omitempty"`   // Capture topic metadata without messages
+	MessageLimit int    `json:"message_limit,omitempty"` // Messages captured per topic, defaults to 10000
+}
+
+type SnapshotRestoreRequest struct {
+	Name      string         `json:"name"`                // Name of the new cluster
+	Namespace string         `json:"namespace,omitempty"` // Namespace of the new cluster, the source cluster's by default
+	Ports     map[string]int `json:"ports,omitempty"`     // Host port per service, for running next to the source cluster
+}
+
+type SnapshotServiceRestore struct {
+	Restored bool   `json:"restored"`
+	Skipped  string `json:"skipped,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// kafkaTopicSnapshot is the captured state of a single topic
+type kafkaTopicSnapshot struct {
+	Name       string              `json:"name"`
+	Partitions int                 `json:"partitions"`
+	Messages   []*adapters.Message `json:"messages,omitempty"`
+}
+
+// handleCreateSnapshot captures a cluster's configuration, container metadata and
+// data into a single named snapshot
+func (s *Server) handleCreateSnapshot(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	clusterID := vars["cluster_id"]
+
+	var req SnapshotCreateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.errorResponse(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	if err := snapshot.ValidateName(req.Name); err != nil {
+		s.errorResponse(w, http.StatusBadRequest, "Invalid snapshot name", err)
+		return
+	}
+	if s.snapshots.Exists(req.Name) {
+		s.errorResponse(w, http.StatusConflict, "Snapshot already exists", nil)
+		return
+	}
+	if req.MessageLimit <= 0 {
+		req.MessageLimit = defaultSnapshotMessageLimit
+	}
+
+	config, err := s.gateway.GetClusterConfig(clusterID)
+	if err != nil {
+		s.errorResponse(w, http.StatusNotFound, "Cluster not found", err)
+		return
+	}
+
+	manifest := &snapshot.Manifest{
+		Name:              req.Name,
+		SourceClusterID:   clusterID,
+		SourceClusterName: config.Name,
+		CreatedAt:         time.Now(),
+		Config:            snapshotConfig(config),
+		Services:          make(map[string]snapshot.ServiceSnapshot),
+	}
+	data := make(map[string][]byte)
+
+	serviceProvisioner, err := s.gateway.clusterProvisioner(config)
+	if err != nil {
+		s.errorResponse(w, http.StatusInternalServerError, "Failed to select the cluster's Docker host", err)
+		return
+	}
+
+	for serviceName, serviceConfig := range config.Services {
+		service, content, err := s.captureService(r.Context(), serviceProvisioner, clusterID, serviceName, &serviceConfig, &req)
+		if err != nil {
+			s.errorResponse(w, http.StatusInternalServerError,
+				fmt.Sprintf("Failed to capture service %s", serviceName), err)
+			return
+		}
+		if content != nil {
+			service.DataFile = fmt.Sprintf("data/%s.%s", serviceName, dataExtension(service.Format))
+			service.Size = int64(len(content))
+			data[service.DataFile] = content
+		}
+		manifest.Services[serviceName] = service
+	}
+
+	if err := s.snapshots.Save(manifest, data); err != nil {
+		s.errorResponse(w, http.StatusInternalServerError, "Failed to save snapshot", err)
+		return
+	}
+
+	logger.Info("Snapshot created",
+		zap.String("cluster_id", clusterID),
+		zap.String("snapshot", req.Name),
+	)
+
+	s.jsonResponse(w, http.StatusCreated, redactManifest(r, manifest))
+}
+
+// handleListSnapshots lists all snapshots
+func (s *Server) handleListSnapshots(w http.ResponseWriter, r *http.Request) {
+	manifests, err := s.snapshots.List()
+	if err != nil {
+		s.errorResponse(w, http.StatusInternalServerError, "Failed to list snapshots", err)
+		return
+	}
+
+	for i, manifest := range manifests {
+		manifests[i] = redactManifest(r, manifest)
+	}
+
+	s.jsonResponse(w, http.StatusOK, map[string]interface{}{
+		"snapshots": manifests,
+		"count":     len(manifests),
+	})
+}
+
+// handleGetSnapshot returns a snapshot's manifest
+func (s *Server) handleGetSnapshot(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	manifest, err := s.snapshots.Get(vars["name"])
+	if err != nil {
+		s.errorResponse(w, http.StatusNotFound, "Snapshot not found", err)
+		return
+	}
+
+	s.jsonResponse(w, http.StatusOK, redactManifest(r, manifest))
+}
+
+// redactManifest returns a copy of a snapshot manifest with the credentials of the
+// captured configuration redacted, unless the request revealed them
+func redactManifest(r *http.Request, manifest *snapshot.Manifest) *snapshot.Manifest {
+	if revealSecrets(r) || manifest.Config == nil {
+		return manifest
+	}
+	redacted := *manifest
+	redacted.Config = manifest.Config.Redacted()
+	return &redacted
+}
+
+// handleDeleteSnapshot deletes a snapshot
+func (s *Server) handleDeleteSnapshot(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	if err := s.snapshots.Delete(vars["name"]); err != nil {
+		s.errorResponse(w, http.StatusNotFound, "Snapshot not found", err)
+		return
+	}
+
+	s.jsonResponse(w, http.StatusOK, map[string]interface{}{
+		"message": "Snapshot deleted successfully",
+	})
+}
+
+// handleRestoreSnapshot creates a new cluster from a snapshot: services are
+// provisioned from the captured configuration and their data is loaded back
+func (s *Server) handleRestoreSnapshot(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	name := vars["name"]
+
+	var req SnapshotRestoreRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.errorResponse(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	if req.Name == "" {
+		s.errorResponse(w, http.StatusBadRequest, "Cluster name is required", nil)
+		return
+	}
+
+	manifest, data, err := s.snapshots.Load(name)
+	if err != nil {
+		s.errorResponse(w, http.StatusNotFound, "Snapshot not found", err)
+		return
+	}
+
+	clusterConfig := snapshotConfig(manifest.Config)
+	if req.Namespace != "" {
+		if err := cluster.ValidateNamespace(req.Namespace); err != nil {
+			s.errorResponse(w, http.StatusBadRequest, "Invalid namespace", err)
+			return
+		}
+		clusterConfig.Namespace = req.Namespace
+	}
+	for serviceName, port := range req.Ports {
+		serviceConfig, exists := clusterConfig.Services[serviceName]
+		if !exists {
+			s.errorResponse(w, http.StatusBadRequest, fmt.Sprintf("Service %s is not in the snapshot", serviceName), nil)
+			return
+		}
+		serviceConfig.Port = port
+		clusterConfig.Services[serviceName] = serviceConfig
+	}
+
+	clusterID, err := s.gateway.CreateCluster(r.Context(), req.Name, clusterConfig)
+	if err != nil {
+		s.provisionErrorResponse(w, "Failed to create cluster", err)
+		return
+	}
+
+	// The cluster exists from here on, so data errors are reported per service
+	// rather than failing the restore. Provisioning already selected the host.
+	serviceProvisioner, _ := s.gateway.clusterProvisioner(clusterConfig)
+	results := make(map[string]SnapshotServiceRestore)
+	for serviceName, serviceConfig := range clusterConfig.Services {
+		service := manifest.Services[serviceName]
+		switch {
+		case service.DataFile == "":
+			results[serviceName] = SnapshotServiceRestore{Skipped: service.Skipped}
+		case serviceConfig.ContainerID == "":
+			results[serviceName] = SnapshotServiceRestore{Skipped: "external service, data left untouched"}
+		default:
+			result := SnapshotServiceRestore{Restored: true}
+			if err := s.restoreService(r.Context(), serviceProvisioner, clusterID, serviceName, &serviceConfig, service, data[service.DataFile]); err != nil {
+				logger.Error("Failed to restore service data",
+					zap.String("cluster_id", clusterID),
+					zap.String("service", serviceName),
+					zap.Error(err),
+				)
+				result = SnapshotServiceRestore{Error: err.Error()}
+			}
+			results[serviceName] = result
+		}
+	}
+
+	logger.Info("Snapshot restored",
+		zap.String("snapshot", name),
+		zap.String("cluster_id", clusterID),
+	)
+
+	s.jsonResponse(w, http.StatusCreated, map[string]interface{}{
+		"id":        clusterID,
+		"namespace": clusterConfig.NamespaceOrDefault(),
+		"name":      req.Name,
+		"snapshot":  name,
+		"services":  results,
+	})
+}
+
+// captureService captures a service's container metadata and data
+func (s *Server) captureService(ctx context.Context, serviceProvisioner provisioner.Provisioner, clusterID, serviceName string, serviceConfig *cluster.ServiceConfig, req *SnapshotCreateRequest) (snapshot.ServiceSnapshot, []byte, error) {
+	service := snapshot.ServiceSnapshot{Type: serviceConfig.Type}
+
+	if serviceProvisioner != nil && serviceConfig.ContainerID != "" {
+		info, err := serviceProvisioner.InspectService(ctx, serviceConfig.ContainerID)
+		if err != nil {
+			return service, nil, fmt.Errorf("failed to inspect container: %w", err)
+		}
+		service.Container = info
+	}
+
+	var content []byte
+	var err error
+	switch serviceConfig.Type {
+	case "postgres":
+		if service.Container == nil {
+			service.Skipped = "logical backups need a container provisioned by Throome"
+			return service, nil, nil
+		}
+		service.Format = "sql"
+		content, err = serviceProvisioner.Exec(ctx, serviceConfig.ContainerID, []string{
+			"pg_dump",
+			"-U", postgresUser(serviceConfig),
+			"-d", postgresDatabase(serviceConfig),
+			"--no-owner", "--no-privileges",
+		}, nil)
+
+	case "redis":
+		service.Format = "redis-dump"
+		content, err = s.captureRedis(ctx, clusterID, serviceName)
+
+	case "kafka":
+		service.Format = "kafka-topics"
+		content, err = s.captureKafka(ctx, clusterID, serviceName, req)
+
+	default:
+		service.Skipped = fmt.Sprintf("data capture is not supported for %s", serviceConfig.Type)
+		return service, nil, nil
+	}
+
+	if err != nil {
+		return service, nil, err
+	}
+	return service, content, nil
+}
+
+func (s *Server) captureRedis(ctx context.Context, clusterID, serviceName string) ([]byte, error) {
+	adapter, err := s.serviceAdapter(ctx, clusterID, serviceName)
+	if err != nil {
+		return nil, err
+	}
+	redisAdapter, ok := adapter.(*redis.RedisAdapter)
+	if !ok {
+		return nil, fmt.Errorf("adapter is not a RedisAdapter")
+	}
+
+	dumps, err := redisAdapter.DumpKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(dumps)
+}
+
+func (s *Server) captureKafka(ctx context.Context, clusterID, serviceName string, req *SnapshotCreateRequest) ([]byte, error) {
+	adapter, err := s.serviceAdapter(ctx, clusterID, serviceName)
+	if err != nil {
+		return nil, err
+	}
+	kafkaAdapter, ok := adapter.(*kafka.KafkaAdapter)
+	if !ok {
+		return nil, fmt.Errorf("adapter is not a KafkaAdapter")
+	}
+
+	topics, err := kafkaAdapter.ListTopics(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots := make([]kafkaTopicSnapshot, 0, len(topics))
+	for _, topic := range topics {
+		if strings.HasPrefix(topic, "__") {
+			continue // Broker-internal topics
+		}
+
+		partitions, err := kafkaAdapter.Partitions(ctx, topic)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read partitions of topic %s: %w", topic, err)
+		}
+
+		topicSnapshot := kafkaTopicSnapshot{Name: topic, Partitions: len(partitions)}
+		if !req.TopicsOnly {
+			topicSnapshot.Messages, err = kafkaAdapter.ReadMessages(ctx, topic, kafka.ReadOptions{Limit: req.MessageLimit})
+			if err != nil {
+				return nil, fmt.Errorf("failed to read topic %s: %w", topic, err)
+			}
+		}
+		snapshots = append(snapshots, topicSnapshot)
+	}
+
+	return json.Marshal(snapshots)
+}
+
+// restoreService loads captured data into a restored service
+func (s *Server) restoreService(ctx context.Context, serviceProvisioner provisioner.Provisioner, clusterID, serviceName string, serviceConfig *cluster.ServiceConfig, service snapshot.ServiceSnapshot, content []byte) error {
+	switch service.Format {
+	case "sql":
+		_, err := serviceProvisioner.Exec(ctx, serviceConfig.ContainerID, []string{
+			"psql",
+			"-U", postgresUser(serviceConfig),
+			"-d", postgresDatabase(serviceConfig),
+			"-v", "ON_ERROR_STOP=1", "-q",
+		}, bytes.NewReader(content))
+		return err
+
+	case "redis-dump":
+		var dumps []redis.KeyDump
+		if err := json.Unmarshal(content, &dumps); err != nil {
+			return fmt.Errorf("invalid cache data: %w", err)
+		}
+
+		adapter, err := s.serviceAdapter(ctx, clusterID, serviceName)
+		if err != nil {
+			return err
+		}
+		redisAdapter, ok := adapter.(*redis.RedisAdapter)
+		if !ok {
+			return fmt.Errorf("adapter is not a RedisAdapter")
+		}
+		return redisAdapter.RestoreKeys(ctx, dumps)
+
+	case "kafka-topics":
+		var topics []kafkaTopicSnapshot
+		if err := json.Unmarshal(content, &topics); err != nil {
+			return fmt.Errorf("invalid queue data: %w", err)
+		}
+
+		adapter, err := s.serviceAdapter(ctx, clusterID, serviceName)
+		if err != nil {
+			return err
+		}
+		kafkaAdapter, ok := adapter.(*kafka.KafkaAdapter)
+		if !ok {
+			return fmt.Errorf("adapter is not a KafkaAdapter")
+		}
+
+		for _, topic := range topics {
+			if err := kafkaAdapter.CreateTopic(ctx, topic.Name, map[string]interface{}{"num_partitions": topic.Partitions}); err != nil {
+				return fmt.Errorf("failed to create topic %s: %w", topic.Name, err)
+			}
+			if _, err := kafkaAdapter.Replay(ctx, topic.Name, topic.Messages); err != nil {
+				return fmt.Errorf("failed to restore messages of topic %s: %w", topic.Name, err)
+			}
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("unsupported data format: %s", service.Format)
+	}
+}
+
+// snapshotConfig copies a cluster configuration without the identifiers and
+// containers that tie it to a specific cluster
+func snapshotConfig(config *cluster.Config) *cluster.Config {
+	copied := *config
+	copied.ClusterID = ""
+	copied.ArchivedAt = nil
+	copied.DrainedAt = nil
+	copied.Services = make(map[string]cluster.ServiceConfig, len(config.Services))
+	for serviceName, serviceConfig := range config.Services {
+		serviceConfig.ContainerID = ""
+		copied.Services[serviceName] = serviceConfig
+	}
+	return &copied
+}
+
+func dataExtension(format string) string {
+	if format == "sql" {
+		return "sql"
+	}
+	return "json"
+}
+
+func postgresUser(config *cluster.ServiceConfig) string {
+	if config.Username == "" {
+		return "postgres"
+	}
+	return config.Username
+}
+
+func postgresDatabase(config *cluster.ServiceConfig) string {
+	if config.Database == "" {
+		return "postgres"
+	}
+	return config.Database
+}
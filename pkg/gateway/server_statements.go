@@ -0,0 +1,156 @@
+package gateway
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/akmadan/throome/pkg/adapters/postgres"
+	"github.com/gorilla/mux"
+)
+
+type PrepareStatementRequest struct {
+	Name  string `json:"name"`
+	Query string `json:"query"`
+}
+
+type StatementArgsRequest struct {
+	Args []interface{} `json:"args"`
+}
+
+type ListStatementsResponse struct {
+	Statements []*postgres.PreparedStatement `json:"statements"`
+}
+
+// handlePrepareStatement prepares a named statement on the cluster's database.
+// Preparing a name again with the same query is a no-op, so clients can prepare
+// their statements on every start.
+func (s *Server) handlePrepareStatement(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var req PrepareStatementRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.errorResponse(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	if req.Query == "" {
+		s.errorResponse(w, http.StatusBadRequest, "Query is required", nil)
+		return
+	}
+
+	pgAdapter, adapterErr := s.postgresAdapter(r.Context(), vars["cluster_id"])
+	if adapterErr != nil {
+		s.errorResponse(w, adapterErr.status, adapterErr.message, adapterErr.err)
+		return
+	}
+
+	stmt, err := pgAdapter.Prepare(r.Context(), req.Name, req.Query)
+	if err != nil {
+		if errors.Is(err, postgres.ErrStatementExists) {
+			s.errorResponse(w, http.StatusConflict, "Statement already exists with another query", err)
+			return
+		}
+		s.errorResponse(w, http.StatusBadRequest, "Failed to prepare statement", err)
+		return
+	}
+
+	s.jsonResponse(w, http.StatusCreated, stmt)
+}
+
+// handleListStatements lists the prepared statements of the cluster's database
+func (s *Server) handleListStatements(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	pgAdapter, adapterErr := s.postgresAdapter(r.Context(), vars["cluster_id"])
+	if adapterErr != nil {
+		s.errorResponse(w, adapterErr.status, adapterErr.message, adapterErr.err)
+		return
+	}
+
+	s.jsonResponse(w, http.StatusOK, ListStatementsResponse{
+		Statements: pgAdapter.Statements(),
+	})
+}
+
+// handleDeallocateStatement removes a prepared statement
+func (s *Server) handleDeallocateStatement(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	pgAdapter, adapterErr := s.postgresAdapter(r.Context(), vars["cluster_id"])
+	if adapterErr != nil {
+		s.errorResponse(w, adapterErr.status, adapterErr.message, adapterErr.err)
+		return
+	}
+
+	if err := pgAdapter.Deallocate(r.Context(), vars["name"]); err != nil {
+		s.statementError(w, err)
+		return
+	}
+
+	s.jsonResponse(w, http.StatusOK, map[string]string{
+		"status": "success",
+	})
+}
+
+// handleExecuteStatement runs a prepared statement that returns no rows
+func (s *Server) handleExecuteStatement(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var req StatementArgsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.errorResponse(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	pgAdapter, adapterErr := s.postgresAdapter(r.Context(), vars["cluster_id"])
+	if adapterErr != nil {
+		s.errorResponse(w, adapterErr.status, adapterErr.message, adapterErr.err)
+		return
+	}
+
+	result, err := pgAdapter.ExecutePrepared(r.Context(), vars["name"], req.Args...)
+	if err != nil {
+		s.statementError(w, err)
+		return
+	}
+
+	s.jsonResponse(w, http.StatusOK, DBExecuteResponse{
+		RowsAffected: result.RowsAffected(),
+	})
+}
+
+// handleQueryStatement runs a prepared statement and returns its rows
+func (s *Server) handleQueryStatement(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var req StatementArgsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.errorResponse(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	pgAdapter, adapterErr := s.postgresAdapter(r.Context(), vars["cluster_id"])
+	if adapterErr != nil {
+		s.errorResponse(w, adapterErr.status, adapterErr.message, adapterErr.err)
+		return
+	}
+
+	rows, err := pgAdapter.QueryPrepared(r.Context(), vars["name"], req.Args...)
+	if err != nil {
+		s.statementError(w, err)
+		return
+	}
+
+	s.jsonResponse(w, http.StatusOK, DBQueryResponse{
+		Rows: rows,
+	})
+}
+
+// statementError responds to a failed use of a prepared statement
+func (s *Server) statementError(w http.ResponseWriter, err error) {
+	if errors.Is(err, postgres.ErrStatementNotFound) {
+		s.errorResponse(w, http.StatusNotFound, "Statement not found", err)
+		return
+	}
+	s.errorResponse(w, http.StatusInternalServerError, "Failed to execute statement", err)
+}
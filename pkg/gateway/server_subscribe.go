@@ -0,0 +1,201 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/akmadan/throome/internal/logger"
+	"github.com/akmadan/throome/pkg/adapters"
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+)
+
+// queueAckTimeout is how long a subscriber has to settle a message before it
+// counts as rejected
+const queueAckTimeout = 30 * time.Second
+
+// Frame types of queue subscriptions
+const (
+	QueueFrameSubscribed = "subscribed"
+	QueueFrameMessage    = "message"
+	QueueFrameError      = "error"
+	QueueFrameAck        = "ack"
+	QueueFrameNack       = "nack"
+)
+
+// QueueDelivery is a frame the gateway sends to a queue subscriber
+type QueueDelivery struct {
+	Type      string            `json:"type"`
+	ID        int64             `json:"id,omitempty"` // Sequence number to settle the message with
+	Topic     string            `json:"topic,omitempty"`
+	Key       []byte            `json:"key,omitempty"`
+	Value     []byte            `json:"value,omitempty"`
+	Headers   map[string]string `json:"headers,omitempty"`
+	Timestamp *time.Time        `json:"timestamp,omitempty"`
+	Partition int               `json:"partition,omitempty"`
+	Offset    int64             `json:"offset,omitempty"`
+	Error     string            `json:"error,omitempty"`
+}
+
+// QueueAck is a frame a queue subscriber sends to settle a message
+type QueueAck struct {
+	Type  string `json:"type"` // ack or nack
+	ID    int64  `json:"id"`
+	Error string `json:"error,omitempty"` // Why the message was rejected
+}
+
+// handleQueueSubscribe upgrades the connection to a WebSocket that delivers the
+// messages of the topic query parameter, as a member of the consumer group of the
+// group query parameter when set. Messages are delivered one at a time and
+// each must be settled with an ack or nack frame before the next is sent. An ack
+// commits the message; a nack, a missed ack deadline or a disconnect fails it, so it
+// is retried and dead-lettered per the queue's dead-letter settings, or delivered
+// again later.
+func (s *Server) handleQueueSubscribe(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	clusterID := vars["cluster_id"]
+	topic := r.URL.Query().Get("topic")
+	group := r.URL.Query().Get("group")
+
+	if topic == "" {
+		s.errorResponse(w, http.StatusBadRequest, "Topic is required", nil)
+		return
+	}
+
+	// Resolve the queue service first so failures are reported as plain HTTP errors
+	config, err := s.gateway.GetClusterConfig(clusterID)
+	if err != nil {
+		s.errorResponse(w, http.StatusNotFound, "Cluster not found", err)
+		return
+	}
+	queueService, adapterErr := s.selectService(r.Context(), clusterID, config, queueServiceTypes...)
+	if adapterErr != nil {
+		s.errorResponse(w, adapterErr.status, adapterErr.message, adapterErr.err)
+		return
+	}
+	if queueService == "" {
+		s.errorResponse(w, http.StatusNotFound, "No queue service found in cluster", nil)
+		return
+	}
+
+	conn, err := realtimeUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade has already written the error response
+		logger.Warn("Failed to upgrade subscribe connection", zap.Error(err))
+		return
+	}
+	defer conn.Close()
+
+	// The request context outlives hijacked connections, so stop on client disconnect
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	acks := make(chan QueueAck)
+	go func() {
+		defer cancel()
+		for {
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			var ack QueueAck
+			if json.Unmarshal(data, &ack) != nil {
+				continue
+			}
+			select {
+			case acks <- ack:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	// Held while a message is in flight, so queues calling the handler concurrently
+	// still deliver one message at a time
+	var mu sync.Mutex
+	var sequence int64
+
+	write := func(frame *QueueDelivery) error {
+		_ = conn.SetWriteDeadline(time.Now().Add(kvWriteTimeout))
+		return conn.WriteJSON(frame)
+	}
+
+	handler := func(_ context.Context, message *adapters.Message) error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		sequence++
+		id := sequence
+		if err := write(newQueueDelivery(id, message)); err != nil {
+			cancel()
+			return fmt.Errorf("failed to deliver message: %w", err)
+		}
+
+		timer := time.NewTimer(queueAckTimeout)
+		defer timer.Stop()
+		for {
+			select {
+			case ack := <-acks:
+				if ack.ID != id {
+					continue // Late settlement of an earlier message
+				}
+				if ack.Type == QueueFrameNack {
+					return fmt.Errorf("rejected by subscriber: %s", ack.Error)
+				}
+				return nil
+			case <-timer.C:
+				return fmt.Errorf("not acknowledged within %s", queueAckTimeout)
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+
+	mu.Lock()
+	unsubscribe, err := s.gateway.Subscribe(ctx, clusterID, queueService, topic, group, handler)
+	if err != nil {
+		_ = write(&QueueDelivery{Type: QueueFrameError, Topic: topic, Error: err.Error()})
+		mu.Unlock()
+		return
+	}
+	defer func() {
+		unsubscribeCtx, unsubscribeCancel := context.WithTimeout(context.Background(), queueUnsubscribeTimeout)
+		defer unsubscribeCancel()
+		if err := unsubscribe(unsubscribeCtx); err != nil {
+			logger.Warn("Failed to unsubscribe from topic",
+				zap.String("cluster_id", clusterID),
+				zap.String("topic", topic),
+				zap.Error(err),
+			)
+		}
+	}()
+	err = write(&QueueDelivery{Type: QueueFrameSubscribed, Topic: topic})
+	mu.Unlock()
+	if err != nil {
+		return
+	}
+
+	<-ctx.Done()
+}
+
+// newQueueDelivery creates the frame delivering a message
+func newQueueDelivery(id int64, message *adapters.Message) *QueueDelivery {
+	delivery := &QueueDelivery{
+		Type:      QueueFrameMessage,
+		ID:        id,
+		Topic:     message.Topic,
+		Key:       message.Key,
+		Value:     message.Value,
+		Headers:   message.Headers,
+		Partition: message.Partition,
+		Offset:    message.Offset,
+	}
+	if !message.Timestamp.IsZero() {
+		delivery.Timestamp = &message.Timestamp
+	}
+	return delivery
+}
@@ -0,0 +1,278 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+
+	"github.com/akmadan/throome/internal/config"
+	"github.com/akmadan/throome/pkg/adapters"
+	"github.com/akmadan/throome/pkg/cluster"
+)
+
+// subscribeQueue is a queue adapter handing its subscription handler to the test
+type subscribeQueue struct {
+	customAdapter
+	handlers chan adapters.MessageHandler
+	mu       sync.Mutex
+	stopped  []string
+}
+
+func (q *subscribeQueue) Publish(ctx context.Context, topic string, message []byte) error {
+	return nil
+}
+
+func (q *subscribeQueue) Subscribe(ctx context.Context, topic string, handler adapters.MessageHandler) error {
+	q.handlers <- handler
+	return nil
+}
+
+func (q *subscribeQueue) Unsubscribe(ctx context.Context, topic string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.stopped = append(q.stopped, topic)
+	return nil
+}
+
+func (q *subscribeQueue) CreateTopic(ctx context.Context, topic string, config map[string]interface{}) error {
+	return nil
+}
+func (q *subscribeQueue) DeleteTopic(ctx context.Context, topic string) error { return nil }
+func (q *subscribeQueue) ListTopics(ctx context.Context) ([]string, error)    { return nil, nil }
+
+func TestQueueSubscribe(t *testing.T) {
+	gw := newTestGateway(t)
+	queue := &subscribeQueue{handlers: make(chan adapters.MessageHandler, 1)}
+	// No other test uses mqtt services
+	gw.adapterFactory.Register("mqtt", func(config *cluster.ServiceConfig) (adapters.Adapter, error) {
+		queue.BaseAdapter = adapters.NewBaseAdapter(config)
+		return queue, nil
+	})
+
+	ctx := context.Background()
+	clusterID, err := gw.CreateCluster(ctx, "subscribe", &cluster.Config{
+		Services: map[string]cluster.ServiceConfig{
+			"events": {Type: "mqtt", Host: "localhost", Port: 9401},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create cluster: %v", err)
+	}
+	noQueueID, err := gw.CreateCluster(ctx, "subscribe-no-queue", &cluster.Config{
+		Services: map[string]cluster.ServiceConfig{
+			"app": {Type: "test-update", Host: "localhost", Port: 9402},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create cluster: %v", err)
+	}
+
+	s := &Server{config: config.DefaultConfig(), gateway: gw, router: mux.NewRouter()}
+	s.setupRoutes()
+	server := httptest.NewServer(s.router)
+	defer server.Close()
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/v1/clusters/"
+
+	// Failures before the upgrade are plain HTTP errors
+	for path, status := range map[string]int{
+		clusterID + "/queue/subscribe":              http.StatusBadRequest,
+		noQueueID + "/queue/subscribe?topic=orders": http.StatusNotFound,
+		"missing/queue/subscribe?topic=orders":      http.StatusNotFound,
+	} {
+		_, resp, err := websocket.DefaultDialer.Dial(url+path, nil)
+		if err == nil || resp == nil || resp.StatusCode != status {
+			t.Errorf("%s: expected status %d, got %v", path, status, resp)
+		}
+	}
+
+	conn, _, err := websocket.DefaultDialer.Dial(url+clusterID+"/queue/subscribe?topic=orders", nil)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer conn.Close()
+
+	read := func() *QueueDelivery {
+		_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		var frame QueueDelivery
+		if err := conn.ReadJSON(&frame); err != nil {
+			t.Fatalf("Failed to read frame: %v", err)
+		}
+		return &frame
+	}
+
+	var handler adapters.MessageHandler
+	select {
+	case handler = <-queue.handlers:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected the gateway to subscribe to the queue")
+	}
+	if frame := read(); frame.Type != QueueFrameSubscribed || frame.Topic != "orders" {
+		t.Fatalf("Expected subscription ack, got %+v", frame)
+	}
+
+	// The handler returns once the subscriber settled the message
+	deliver := func(value string) <-chan error {
+		result := make(chan error, 1)
+		go func() {
+			result <- handler(ctx, &adapters.Message{Topic: "orders", Value: []byte(value), Offset: 7})
+		}()
+		return result
+	}
+
+	result := deliver("first")
+	frame := read()
+	if frame.Type != QueueFrameMessage || string(frame.Value) != "first" || frame.Offset != 7 {
+		t.Fatalf("Expected the first message, got %+v", frame)
+	}
+	if err := conn.WriteJSON(QueueAck{Type: QueueFrameAck, ID: frame.ID}); err != nil {
+		t.Fatalf("Failed to ack: %v", err)
+	}
+	if err := <-result; err != nil {
+		t.Errorf("Expected an acked message to succeed, got %v", err)
+	}
+
+	result = deliver("second")
+	frame = read()
+	if err := conn.WriteJSON(QueueAck{Type: QueueFrameAck, ID: frame.ID - 1}); err != nil {
+		t.Fatalf("Failed to ack: %v", err)
+	}
+	if err := conn.WriteJSON(QueueAck{Type: QueueFrameNack, ID: frame.ID, Error: "invalid order"}); err != nil {
+		t.Fatalf("Failed to nack: %v", err)
+	}
+	if err := <-result; err == nil || !strings.Contains(err.Error(), "invalid order") {
+		t.Errorf("Expected a nacked message to fail, got %v", err)
+	}
+
+	// Disconnecting ends the subscription
+	conn.Close()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		queue.mu.Lock()
+		stopped := len(queue.stopped)
+		queue.mu.Unlock()
+		if stopped == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the gateway to unsubscribe after the client disconnected")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// Consumer groups are refused by queues that do not support them
+	conn, _, err = websocket.DefaultDialer.Dial(url+clusterID+"/queue/subscribe?topic=orders&group=billing", nil)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer conn.Close()
+	if frame := read(); frame.Type != QueueFrameError || !strings.Contains(frame.Error, "consumer groups") {
+		t.Errorf("Expected a consumer group to be refused, got %+v", frame)
+	}
+}
+
+// groupQueue is a queue adapter with consumer groups, recording its members
+type groupQueue struct {
+	subscribeQueue
+	members map[string]string // Groups, by subscription ID
+	ended   []string
+}
+
+func (q *groupQueue) SubscribeGroup(ctx context.Context, topic, group string, handler adapters.MessageHandler) (string, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	id := fmt.Sprintf("member-%d", len(q.members)+1)
+	q.members[id] = group
+	return id, nil
+}
+
+func (q *groupQueue) UnsubscribeGroup(ctx context.Context, id string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.ended = append(q.ended, id)
+	return nil
+}
+
+func TestQueueSubscribeGroupMembers(t *testing.T) {
+	gw := newTestGateway(t)
+	queue := &groupQueue{members: make(map[string]string)}
+	// No other test uses rabbitmq services
+	gw.adapterFactory.Register("rabbitmq", func(config *cluster.ServiceConfig) (adapters.Adapter, error) {
+		queue.BaseAdapter = adapters.NewBaseAdapter(config)
+		return queue, nil
+	})
+
+	clusterID, err := gw.CreateCluster(context.Background(), "subscribe-groups", &cluster.Config{
+		Services: map[string]cluster.ServiceConfig{
+			"events": {Type: "rabbitmq", Host: "localhost", Port: 9403},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create cluster: %v", err)
+	}
+
+	s := &Server{config: config.DefaultConfig(), gateway: gw, router: mux.NewRouter()}
+	s.setupRoutes()
+	server := httptest.NewServer(s.router)
+	defer server.Close()
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/v1/clusters/" + clusterID + "/queue/subscribe?topic=orders"
+
+	// Clients of the same group are members of their own, instead of being refused
+	var conns []*websocket.Conn
+	for _, query := range []string{"&group=billing", "&group=billing", ""} {
+		conn, _, err := websocket.DefaultDialer.Dial(url+query, nil)
+		if err != nil {
+			t.Fatalf("Failed to connect: %v", err)
+		}
+		defer conn.Close()
+		conns = append(conns, conn)
+
+		_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		var frame QueueDelivery
+		if err := conn.ReadJSON(&frame); err != nil || frame.Type != QueueFrameSubscribed {
+			t.Fatalf("Expected client %d to subscribe, got %+v (%v)", len(conns), frame, err)
+		}
+	}
+
+	queue.mu.Lock()
+	members := map[string]string{}
+	for id, group := range queue.members {
+		members[id] = group
+	}
+	queue.mu.Unlock()
+	expected := map[string]string{"member-1": "billing", "member-2": "billing", "member-3": ""}
+	if len(members) != len(expected) {
+		t.Fatalf("Expected members %v, got %v", expected, members)
+	}
+	for id, group := range expected {
+		if members[id] != group {
+			t.Errorf("Expected %s to join group %q, got %q", id, group, members[id])
+		}
+	}
+
+	// Disconnecting a client only ends its own membership
+	conns[0].Close()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		queue.mu.Lock()
+		ended := append([]string{}, queue.ended...)
+		queue.mu.Unlock()
+		if len(ended) == 1 {
+			if ended[0] != "member-1" {
+				t.Errorf("Expected member-1 to leave, got %v", ended)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the gateway to end the membership of the disconnected client")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,289 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/akmadan/throome/internal/config"
+	"github.com/akmadan/throome/pkg/adapters"
+	"github.com/akmadan/throome/pkg/cluster"
+	"github.com/akmadan/throome/pkg/monitor"
+	"github.com/akmadan/throome/pkg/requestid"
+)
+
+func TestRequestIDMiddleware(t *testing.T) {
+	s := &Server{config: config.DefaultConfig()}
+
+	var seen string
+	handler := s.requestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = requestid.FromContext(r.Context())
+		s.errorResponse(w, http.StatusNotFound, "Cluster not found", nil)
+	}))
+
+	tests := []struct {
+		name     string
+		header   string
+		expected string // "" when a new ID should be generated
+	}{
+		{"generated", "", ""},
+		{"client supplied", "sdk-call-42", "sdk-call-42"},
+		{"invalid", "has spaces", ""},
+		{"too long", strings.Repeat("a", 129), ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/api/v1/clusters/missing", nil)
+			if tt.header != "" {
+				req.Header.Set(requestid.Header, tt.header)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			id := rec.Header().Get(requestid.Header)
+			if tt.expected != "" && id != tt.expected {
+				t.Errorf("Expected request ID %q, got %q", tt.expected, id)
+			}
+			if tt.expected == "" && (id == "" || id == tt.header) {
+				t.Errorf("Expected a generated request ID, got %q", id)
+			}
+			if seen != id {
+				t.Errorf("Expected the context to carry %q, got %q", id, seen)
+			}
+
+			var body map[string]interface{}
+			_ = json.NewDecoder(rec.Body).Decode(&body)
+			if body["request_id"] != id {
+				t.Errorf("Expected the error response to carry %q, got %v", id, body["request_id"])
+			}
+		})
+	}
+}
+
+func TestClusterMetricsPoolStats(t *testing.T) {
+	gw := newTestGateway(t)
+	s := &Server{config: config.DefaultConfig(), gateway: gw}
+
+	collector := gw.GetCollector()
+	collector.RecordPoolStats("pool-metrics", "db", "postgres", &adapters.PoolStats{AcquiredConns: 3, IdleConns: 2, TotalConns: 5, MaxConns: 10})
+	defer collector.ForgetCluster("pool-metrics")
+
+	r := mux.SetURLVars(httptest.NewRequest("GET", "/api/v1/clusters/pool-metrics/metrics", nil), map[string]string{"cluster_id": "pool-metrics"})
+	rec := httptest.NewRecorder()
+	s.handleClusterMetrics(rec, r)
+
+	var metrics struct {
+		ServiceMetrics map[string]struct {
+			ActiveConnections int
+			PoolStats         *adapters.PoolStats
+		}
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&metrics); err != nil {
+		t.Fatalf("Failed to decode the metrics: %v", err)
+	}
+	db := metrics.ServiceMetrics["db"]
+	if db.PoolStats == nil || db.PoolStats.AcquiredConns != 3 || db.PoolStats.IdleConns != 2 || db.PoolStats.MaxConns != 10 {
+		t.Errorf("Expected the pool stats of the service, got %+v", db.PoolStats)
+	}
+	if db.ActiveConnections != 3 {
+		t.Errorf("Expected 3 active connections, got %d", db.ActiveConnections)
+	}
+}
+
+func TestClusterMetricsHistory(t *testing.T) {
+	gw := newTestGateway(t)
+	s := &Server{config: config.DefaultConfig(), gateway: gw}
+
+	ctx := context.Background()
+	clusterID, err := gw.CreateCluster(ctx, "history", &cluster.Config{
+		Services: map[string]cluster.ServiceConfig{
+			"store": {Type: "test-update", Host: "localhost", Port: 9884},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create cluster: %v", err)
+	}
+	defer func() { _ = gw.DeleteCluster(ctx, clusterID) }()
+
+	gw.GetCollector().RecordRequest(clusterID, "store", "test-update", time.Millisecond, true)
+	gw.GetCollector().RecordRequest(clusterID, "store", "test-update", time.Millisecond, false)
+
+	get := func(query string) *httptest.ResponseRecorder {
+		r := mux.SetURLVars(httptest.NewRequest("GET", "/api/v1/clusters/"+clusterID+"/metrics/history?"+query, nil), map[string]string{"cluster_id": clusterID})
+		rec := httptest.NewRecorder()
+		s.handleClusterMetricsHistory(rec, r)
+		return rec
+	}
+
+	rec := get("window=5m")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var history struct {
+		Window   string                             `json:"window"`
+		Services map[string][]monitor.MetricsSample `json:"services"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&history); err != nil {
+		t.Fatalf("Failed to decode the history: %v", err)
+	}
+	samples := history.Services["store"]
+	if history.Window != "5m0s" || len(samples) != 6 {
+		t.Fatalf("Expected 6 samples over 5m, got %d over %s", len(samples), history.Window)
+	}
+	var requests, failed int64
+	for _, sample := range samples {
+		requests += sample.Requests
+		failed += sample.FailedRequests
+	}
+	if requests != 2 || failed != 1 {
+		t.Errorf("Expected 2 requests and 1 failure, got %d and %d", requests, failed)
+	}
+
+	if rec := get("window=forever"); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid window, got %d", rec.Code)
+	}
+}
+
+func TestResetMetrics(t *testing.T) {
+	gw := newTestGateway(t)
+	s := &Server{config: config.DefaultConfig(), gateway: gw}
+
+	ctx := context.Background()
+	clusterID, err := gw.CreateCluster(ctx, "reset", &cluster.Config{
+		Services: map[string]cluster.ServiceConfig{
+			"store": {Type: "test-update", Host: "localhost", Port: 9891},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create cluster: %v", err)
+	}
+	defer func() { _ = gw.DeleteCluster(ctx, clusterID) }()
+
+	collector := gw.GetCollector()
+	collector.RecordRequest(clusterID, "store", "test-update", time.Millisecond, true)
+
+	reset := func(handler http.HandlerFunc, vars map[string]string) int {
+		rec := httptest.NewRecorder()
+		handler(rec, mux.SetURLVars(httptest.NewRequest("POST", "/api/v1/clusters/"+vars["cluster_id"]+"/metrics/reset", nil), vars))
+		return rec.Code
+	}
+
+	if code := reset(s.handleResetServiceMetrics, map[string]string{"cluster_id": clusterID, "service_name": "missing"}); code != http.StatusNotFound {
+		t.Errorf("Expected 404 for a missing service, got %d", code)
+	}
+	if code := reset(s.handleResetServiceMetrics, map[string]string{"cluster_id": clusterID, "service_name": "store"}); code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", code)
+	}
+	if requests := collector.GetServiceMetrics(clusterID, "store").TotalRequests; requests != 0 {
+		t.Errorf("Expected the service's requests to be cleared, got %d", requests)
+	}
+
+	if code := reset(s.handleResetClusterMetrics, map[string]string{"cluster_id": "missing"}); code != http.StatusNotFound {
+		t.Errorf("Expected 404 for a missing cluster, got %d", code)
+	}
+	collector.RecordRequest(clusterID, "store", "test-update", time.Millisecond, true)
+	if code := reset(s.handleResetClusterMetrics, map[string]string{"cluster_id": clusterID}); code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", code)
+	}
+	if requests := collector.GetServiceMetrics(clusterID, "store").TotalRequests; requests != 0 {
+		t.Errorf("Expected the cluster's requests to be cleared, got %d", requests)
+	}
+}
+
+func TestServiceHealthHistory(t *testing.T) {
+	gw := newTestGateway(t)
+	s := &Server{config: config.DefaultConfig(), gateway: gw}
+
+	ctx := context.Background()
+	clusterID, err := gw.CreateCluster(ctx, "health-history", &cluster.Config{
+		Services: map[string]cluster.ServiceConfig{
+			"store": {Type: "test-update", Host: "localhost", Port: 9885},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create cluster: %v", err)
+	}
+	defer func() { _ = gw.DeleteCluster(ctx, clusterID) }()
+
+	get := func(serviceName string) *httptest.ResponseRecorder {
+		r := mux.SetURLVars(httptest.NewRequest("GET", "/api/v1/clusters/"+clusterID+"/services/"+serviceName+"/health/history", nil),
+			map[string]string{"cluster_id": clusterID, "service_name": serviceName})
+		rec := httptest.NewRecorder()
+		s.handleServiceHealthHistory(rec, r)
+		return rec
+	}
+
+	if rec := get("missing"); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for a missing service, got %d", rec.Code)
+	}
+
+	// Before its first check a service counts as healthy with full uptime
+	rec := get("store")
+	var history struct {
+		Healthy          bool                    `json:"healthy"`
+		TotalChecks      int64                   `json:"total_checks"`
+		UptimePercentage float64                 `json:"uptime_percentage"`
+		Checks           []adapters.HealthStatus `json:"checks"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&history); err != nil {
+		t.Fatalf("Failed to decode the health history: %v", err)
+	}
+	if !history.Healthy || history.TotalChecks != 0 || history.UptimePercentage != 100 || history.Checks == nil {
+		t.Errorf("Expected an empty healthy history, got %+v", history)
+	}
+}
+
+func TestClusterSLA(t *testing.T) {
+	gw := newTestGateway(t)
+	s := &Server{config: config.DefaultConfig(), gateway: gw}
+
+	ctx := context.Background()
+	clusterID, err := gw.CreateCluster(ctx, "sla", &cluster.Config{
+		Services: map[string]cluster.ServiceConfig{
+			"store": {Type: "test-update", Host: "localhost", Port: 9889},
+			"cache": {Type: "test-update", Host: "localhost", Port: 9890},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create cluster: %v", err)
+	}
+	defer func() { _ = gw.DeleteCluster(ctx, clusterID) }()
+
+	get := func(clusterID string) *httptest.ResponseRecorder {
+		r := mux.SetURLVars(httptest.NewRequest("GET", "/api/v1/clusters/"+clusterID+"/sla", nil), map[string]string{"cluster_id": clusterID})
+		rec := httptest.NewRecorder()
+		s.handleClusterSLA(rec, r)
+		return rec
+	}
+
+	if rec := get("missing"); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for a missing cluster, got %d", rec.Code)
+	}
+
+	rec := get(clusterID)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var sla struct {
+		Services []ServiceSLA `json:"services"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&sla); err != nil {
+		t.Fatalf("Failed to decode the SLA: %v", err)
+	}
+	if len(sla.Services) != 2 || sla.Services[0].ServiceName != "cache" {
+		t.Fatalf("Expected both services ordered by name, got %+v", sla.Services)
+	}
+	// Services without checks have no downtime
+	for _, service := range sla.Services {
+		if service.Weekly.UptimePercentage != 100 || service.Monthly.UptimePercentage != 100 || service.Incidents == nil {
+			t.Errorf("Expected full uptime without incidents, got %+v", service)
+		}
+	}
+}
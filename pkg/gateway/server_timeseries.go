@@ -0,0 +1,153 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/akmadan/throome/pkg/adapters"
+	"github.com/gorilla/mux"
+)
+
+// Time series request/response types
+type TimeSeriesWriteRequest struct {
+	Points []*adapters.Point `json:"points"`
+}
+
+type TimeSeriesQueryRequest struct {
+	Measurement string            `json:"measurement"`
+	Start       time.Time         `json:"start"`
+	End         time.Time         `json:"end,omitempty"`       // Defaults to now
+	Tags        map[string]string `json:"tags,omitempty"`      // Tag values points must match
+	Fields      []string          `json:"fields,omitempty"`    // Fields to return, all by default
+	Window      string            `json:"window,omitempty"`    // Downsampling window, e.g. 1m
+	Aggregate   string            `json:"aggregate,omitempty"` // Aggregate per window, mean by default
+	Limit       int               `json:"limit,omitempty"`     // Maximum points per series
+}
+
+type TimeSeriesQueryResponse struct {
+	Points []*adapters.Point `json:"points"`
+	Count  int               `json:"count"`
+}
+
+// handleTimeSeriesWrite writes points to the cluster's time series store
+func (s *Server) handleTimeSeriesWrite(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var req TimeSeriesWriteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.errorResponse(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	if len(req.Points) == 0 {
+		s.errorResponse(w, http.StatusBadRequest, "At least one point is required", nil)
+		return
+	}
+	for _, point := range req.Points {
+		if point == nil || point.Measurement == "" || len(point.Fields) == 0 {
+			s.errorResponse(w, http.StatusBadRequest, "Every point needs a measurement and at least one field", nil)
+			return
+		}
+	}
+
+	timeSeries, adapterErr := s.timeSeriesAdapter(r.Context(), vars["cluster_id"])
+	if adapterErr != nil {
+		s.errorResponse(w, adapterErr.status, adapterErr.message, adapterErr.err)
+		return
+	}
+
+	if err := timeSeries.WritePoints(r.Context(), req.Points); err != nil {
+		s.errorResponse(w, http.StatusInternalServerError, "Failed to write points", err)
+		return
+	}
+
+	s.jsonResponse(w, http.StatusOK, map[string]interface{}{
+		"status":  "success",
+		"written": len(req.Points),
+	})
+}
+
+// handleTimeSeriesQuery returns the points of a measurement within a time range
+func (s *Server) handleTimeSeriesQuery(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var req TimeSeriesQueryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.errorResponse(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	if req.Measurement == "" || req.Start.IsZero() {
+		s.errorResponse(w, http.StatusBadRequest, "Measurement and start are required", nil)
+		return
+	}
+	if !req.End.IsZero() && !req.End.After(req.Start) {
+		s.errorResponse(w, http.StatusBadRequest, "End must be after start", nil)
+		return
+	}
+
+	var window time.Duration
+	if req.Window != "" {
+		parsed, err := time.ParseDuration(req.Window)
+		if err != nil || parsed <= 0 {
+			s.errorResponse(w, http.StatusBadRequest, "Invalid window", err)
+			return
+		}
+		window = parsed
+	}
+	if req.Aggregate != "" && window == 0 {
+		s.errorResponse(w, http.StatusBadRequest, "Aggregate requires a window", nil)
+		return
+	}
+
+	timeSeries, adapterErr := s.timeSeriesAdapter(r.Context(), vars["cluster_id"])
+	if adapterErr != nil {
+		s.errorResponse(w, adapterErr.status, adapterErr.message, adapterErr.err)
+		return
+	}
+
+	points, err := timeSeries.QueryRange(r.Context(), adapters.RangeQuery{
+		Measurement: req.Measurement,
+		Start:       req.Start,
+		End:         req.End,
+		Tags:        req.Tags,
+		Fields:      req.Fields,
+		Window:      window,
+		Aggregate:   req.Aggregate,
+		Limit:       req.Limit,
+	})
+	if err != nil {
+		s.errorResponse(w, http.StatusInternalServerError, "Failed to query points", err)
+		return
+	}
+
+	s.jsonResponse(w, http.StatusOK, TimeSeriesQueryResponse{Points: points, Count: len(points)})
+}
+
+// timeSeriesAdapter resolves the time series adapter of a cluster
+func (s *Server) timeSeriesAdapter(ctx context.Context, clusterID string) (adapters.TimeSeriesAdapter, *adapterError) {
+	config, err := s.gateway.GetClusterConfig(clusterID)
+	if err != nil {
+		return nil, &adapterError{http.StatusNotFound, "Cluster not found", err}
+	}
+
+	influxService, adapterErr := s.selectService(ctx, clusterID, config, "influxdb")
+	if adapterErr != nil {
+		return nil, adapterErr
+	}
+	if influxService == "" {
+		return nil, &adapterError{http.StatusNotFound, "No InfluxDB service found in cluster", nil}
+	}
+
+	adapter, err := s.serviceAdapter(ctx, clusterID, influxService)
+	if err != nil {
+		return nil, &adapterError{adapterStatus(err), "Failed to get time series adapter", err}
+	}
+
+	timeSeries, ok := adapter.(adapters.TimeSeriesAdapter)
+	if !ok {
+		return nil, &adapterError{http.StatusInternalServerError, "Adapter is not a TimeSeriesAdapter", nil}
+	}
+
+	return timeSeries, nil
+}
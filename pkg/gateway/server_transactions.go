@@ -0,0 +1,198 @@
+package gateway
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/akmadan/throome/pkg/adapters/postgres"
+	"github.com/gorilla/mux"
+	"github.com/jackc/pgx/v5"
+)
+
+type DBBeginRequest struct {
+	IsolationLevel string `json:"isolation_level,omitempty"` // "read committed" by default
+	ReadOnly       bool   `json:"read_only,omitempty"`
+}
+
+type DBTransactionResponse struct {
+	TransactionID string `json:"transaction_id"`
+	ServiceName   string `json:"service_name"` // Service the transaction runs on, to target with ?service=
+}
+
+// isolationLevels are the isolation levels transactions can be begun with
+var isolationLevels = []pgx.TxIsoLevel{pgx.ReadUncommitted, pgx.ReadCommitted, pgx.RepeatableRead, pgx.Serializable}
+
+// handleBeginTransaction begins a transaction whose statements are run by later
+// requests. Transactions run on the service's own connections, never on a replica,
+// and one left idle for the service's transaction_idle_seconds is rolled back.
+func (s *Server) handleBeginTransaction(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	// The options are optional, so the body may be empty
+	var req DBBeginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		s.errorResponse(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	options, err := transactionOptions(&req)
+	if err != nil {
+		s.errorResponse(w, http.StatusBadRequest, "Invalid isolation level", err)
+		return
+	}
+
+	pgAdapter, adapterErr := s.postgresAdapter(r.Context(), vars["cluster_id"])
+	if adapterErr != nil {
+		s.errorResponse(w, adapterErr.status, adapterErr.message, adapterErr.err)
+		return
+	}
+
+	token, err := pgAdapter.BeginSession(r.Context(), options)
+	if err != nil {
+		s.transactionError(w, err)
+		return
+	}
+
+	s.jsonResponse(w, http.StatusCreated, DBTransactionResponse{
+		TransactionID: token,
+		ServiceName:   pgAdapter.ServiceName(),
+	})
+}
+
+// handleTransactionExecute runs a statement that returns no rows in a transaction. A
+// failed statement leaves the transaction open, to be rolled back.
+func (s *Server) handleTransactionExecute(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var req DBExecuteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.errorResponse(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	if req.Query == "" {
+		s.errorResponse(w, http.StatusBadRequest, "Query is required", nil)
+		return
+	}
+
+	pgAdapter, adapterErr := s.postgresAdapter(r.Context(), vars["cluster_id"])
+	if adapterErr != nil {
+		s.errorResponse(w, adapterErr.status, adapterErr.message, adapterErr.err)
+		return
+	}
+
+	result, err := pgAdapter.SessionExecute(r.Context(), vars["transaction"], req.Query, req.Args...)
+	if err != nil {
+		s.transactionError(w, err)
+		return
+	}
+
+	s.jsonResponse(w, http.StatusOK, DBExecuteResponse{
+		RowsAffected: result.RowsAffected(),
+	})
+}
+
+// handleTransactionQuery runs a query in a transaction and returns its rows
+func (s *Server) handleTransactionQuery(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var req DBExecuteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.errorResponse(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	if req.Query == "" {
+		s.errorResponse(w, http.StatusBadRequest, "Query is required", nil)
+		return
+	}
+
+	pgAdapter, adapterErr := s.postgresAdapter(r.Context(), vars["cluster_id"])
+	if adapterErr != nil {
+		s.errorResponse(w, adapterErr.status, adapterErr.message, adapterErr.err)
+		return
+	}
+
+	rows, err := pgAdapter.SessionQuery(r.Context(), vars["transaction"], req.Query, req.Args...)
+	if err != nil {
+		s.transactionError(w, err)
+		return
+	}
+
+	s.jsonResponse(w, http.StatusOK, DBQueryResponse{
+		Rows: rows,
+	})
+}
+
+// handleCommitTransaction commits a transaction. The transaction is over even when
+// the commit fails.
+func (s *Server) handleCommitTransaction(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	pgAdapter, adapterErr := s.postgresAdapter(r.Context(), vars["cluster_id"])
+	if adapterErr != nil {
+		s.errorResponse(w, adapterErr.status, adapterErr.message, adapterErr.err)
+		return
+	}
+
+	if err := pgAdapter.CommitSession(r.Context(), vars["transaction"]); err != nil {
+		s.transactionError(w, err)
+		return
+	}
+
+	s.jsonResponse(w, http.StatusOK, map[string]string{
+		"status": "success",
+	})
+}
+
+// handleRollbackTransaction rolls a transaction back
+func (s *Server) handleRollbackTransaction(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	pgAdapter, adapterErr := s.postgresAdapter(r.Context(), vars["cluster_id"])
+	if adapterErr != nil {
+		s.errorResponse(w, adapterErr.status, adapterErr.message, adapterErr.err)
+		return
+	}
+
+	if err := pgAdapter.RollbackSession(r.Context(), vars["transaction"]); err != nil {
+		s.transactionError(w, err)
+		return
+	}
+
+	s.jsonResponse(w, http.StatusOK, map[string]string{
+		"status": "success",
+	})
+}
+
+// transactionOptions returns the options to begin a transaction with
+func transactionOptions(req *DBBeginRequest) (pgx.TxOptions, error) {
+	var options pgx.TxOptions
+	if req.ReadOnly {
+		options.AccessMode = pgx.ReadOnly
+	}
+	if req.IsolationLevel == "" {
+		return options, nil
+	}
+
+	for _, level := range isolationLevels {
+		if strings.EqualFold(req.IsolationLevel, string(level)) {
+			options.IsoLevel = level
+			return options, nil
+		}
+	}
+	return options, fmt.Errorf("unknown isolation level %q", req.IsolationLevel)
+}
+
+// transactionError responds to a failed use of a transaction
+func (s *Server) transactionError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, postgres.ErrTransactionNotFound):
+		s.errorResponse(w, http.StatusNotFound, "Transaction not found or expired", err)
+	case errors.Is(err, postgres.ErrTooManyTransactions):
+		s.errorResponse(w, http.StatusTooManyRequests, "Too many open transactions", err)
+	default:
+		s.errorResponse(w, http.StatusInternalServerError, "Failed to execute transaction", err)
+	}
+}
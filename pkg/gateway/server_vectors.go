@@ -0,0 +1,147 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/akmadan/throome/pkg/adapters"
+	"github.com/gorilla/mux"
+)
+
+// Vector operation request/response types
+type VectorCollectionRequest struct {
+	Dimension int    `json:"dimension"`
+	Distance  string `json:"distance,omitempty"` // cosine (default), euclid, dot or manhattan
+}
+
+type VectorUpsertRequest struct {
+	Points []*adapters.VectorPoint `json:"points"`
+}
+
+type VectorSearchResponse struct {
+	Matches []*adapters.VectorMatch `json:"matches"`
+	Count   int                     `json:"count"`
+}
+
+// handleCreateVectorCollection creates a collection of embeddings
+func (s *Server) handleCreateVectorCollection(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var req VectorCollectionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.errorResponse(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	if req.Dimension <= 0 {
+		s.errorResponse(w, http.StatusBadRequest, "Dimension must be positive", nil)
+		return
+	}
+
+	vectors, adapterErr := s.vectorAdapter(r.Context(), vars["cluster_id"])
+	if adapterErr != nil {
+		s.errorResponse(w, adapterErr.status, adapterErr.message, adapterErr.err)
+		return
+	}
+
+	if err := vectors.CreateCollection(r.Context(), vars["collection"], req.Dimension, req.Distance); err != nil {
+		s.errorResponse(w, http.StatusInternalServerError, "Failed to create collection", err)
+		return
+	}
+
+	s.jsonResponse(w, http.StatusCreated, map[string]string{
+		"message":    "Collection created successfully",
+		"collection": vars["collection"],
+	})
+}
+
+// handleVectorUpsert inserts or replaces embeddings
+func (s *Server) handleVectorUpsert(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var req VectorUpsertRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.errorResponse(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	if len(req.Points) == 0 {
+		s.errorResponse(w, http.StatusBadRequest, "At least one point is required", nil)
+		return
+	}
+
+	vectors, adapterErr := s.vectorAdapter(r.Context(), vars["cluster_id"])
+	if adapterErr != nil {
+		s.errorResponse(w, adapterErr.status, adapterErr.message, adapterErr.err)
+		return
+	}
+
+	if err := vectors.Upsert(r.Context(), vars["collection"], req.Points); err != nil {
+		s.errorResponse(w, http.StatusInternalServerError, "Failed to upsert points", err)
+		return
+	}
+
+	s.jsonResponse(w, http.StatusOK, map[string]interface{}{
+		"message": "Points upserted successfully",
+		"count":   len(req.Points),
+	})
+}
+
+// handleVectorSearch returns the nearest neighbours of a vector
+func (s *Server) handleVectorSearch(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var req adapters.VectorQuery
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.errorResponse(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	if len(req.Vector) == 0 {
+		s.errorResponse(w, http.StatusBadRequest, "Vector is required", nil)
+		return
+	}
+
+	vectors, adapterErr := s.vectorAdapter(r.Context(), vars["cluster_id"])
+	if adapterErr != nil {
+		s.errorResponse(w, adapterErr.status, adapterErr.message, adapterErr.err)
+		return
+	}
+
+	matches, err := vectors.SearchNearest(r.Context(), vars["collection"], req)
+	if err != nil {
+		s.errorResponse(w, http.StatusInternalServerError, "Failed to search vectors", err)
+		return
+	}
+
+	s.jsonResponse(w, http.StatusOK, VectorSearchResponse{
+		Matches: matches,
+		Count:   len(matches),
+	})
+}
+
+// vectorAdapter resolves the vector database adapter of a cluster
+func (s *Server) vectorAdapter(ctx context.Context, clusterID string) (adapters.VectorAdapter, *adapterError) {
+	config, err := s.gateway.GetClusterConfig(clusterID)
+	if err != nil {
+		return nil, &adapterError{http.StatusNotFound, "Cluster not found", err}
+	}
+
+	qdrantService, adapterErr := s.selectService(ctx, clusterID, config, "qdrant")
+	if adapterErr != nil {
+		return nil, adapterErr
+	}
+	if qdrantService == "" {
+		return nil, &adapterError{http.StatusNotFound, "No Qdrant service found in cluster", nil}
+	}
+
+	adapter, err := s.serviceAdapter(ctx, clusterID, qdrantService)
+	if err != nil {
+		return nil, &adapterError{adapterStatus(err), "Failed to get vector adapter", err}
+	}
+
+	vectors, ok := adapter.(adapters.VectorAdapter)
+	if !ok {
+		return nil, &adapterError{http.StatusInternalServerError, "Adapter is not a VectorAdapter", nil}
+	}
+
+	return vectors, nil
+}
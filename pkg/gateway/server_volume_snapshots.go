@@ -0,0 +1,209 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+
+	"github.com/akmadan/throome/internal/logger"
+	"github.com/akmadan/throome/pkg/provisioner"
+	"github.com/akmadan/throome/pkg/snapshot"
+)
+
+// volumeSnapshotter is implemented by provisioners that can snapshot the data of the
+// services they run
+type volumeSnapshotter interface {
+	DataPath(serviceType string) (string, bool)
+	SnapshotVolume(ctx context.Context, containerID, path string) (io.ReadCloser, error)
+	CommitService(ctx context.Context, containerID, clusterName, serviceName, tag string) (string, error)
+}
+
+// ServiceSnapshotRequest names a snapshot of a service's data and how to take it
+type ServiceSnapshotRequest struct {
+	Name string `json:"name,omitempty"` // Defaults to the time the snapshot is taken
+	Mode string `json:"mode,omitempty"` // tar (default) or commit
+}
+
+// handleSnapshotService snapshots the data of a provisioned service, either as a tar
+// archive of its data directory kept by the gateway or by committing its container
+// to an image on the Docker host
+func (s *Server) handleSnapshotService(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	clusterID := vars["cluster_id"]
+	serviceName := vars["service_name"]
+
+	var req ServiceSnapshotRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		s.errorResponse(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	if req.Name == "" {
+		req.Name = time.Now().UTC().Format("20060102-150405")
+	}
+	if err := snapshot.ValidateName(req.Name); err != nil {
+		s.errorResponse(w, http.StatusBadRequest, "Invalid snapshot name", err)
+		return
+	}
+	if req.Mode == "" {
+		req.Mode = provisioner.SnapshotTar
+	}
+	if req.Mode != provisioner.SnapshotTar && req.Mode != provisioner.SnapshotCommit {
+		s.errorResponse(w, http.StatusBadRequest, "Mode must be tar or commit", nil)
+		return
+	}
+
+	config, err := s.gateway.GetClusterConfig(clusterID)
+	if err != nil {
+		s.errorResponse(w, http.StatusNotFound, "Cluster not found", err)
+		return
+	}
+	serviceConfig, exists := config.Services[serviceName]
+	if !exists {
+		s.errorResponse(w, http.StatusNotFound, "Service not found", nil)
+		return
+	}
+	if serviceConfig.ContainerID == "" {
+		s.errorResponse(w, http.StatusConflict, "Service is not provisioned by Throome", nil)
+		return
+	}
+	if s.volumes.Exists(clusterID, serviceName, req.Name) {
+		s.errorResponse(w, http.StatusConflict, "Snapshot already exists", nil)
+		return
+	}
+
+	serviceProvisioner, err := s.gateway.clusterProvisioner(config)
+	if err != nil {
+		s.errorResponse(w, http.StatusInternalServerError, "Failed to select the cluster's Docker host", err)
+		return
+	}
+	if serviceProvisioner == nil {
+		s.errorResponse(w, http.StatusServiceUnavailable, "Provisioner not available", nil)
+		return
+	}
+	snapshotter, ok := serviceProvisioner.(volumeSnapshotter)
+	if !ok {
+		s.errorResponse(w, http.StatusNotImplemented, "The cluster's provisioner cannot snapshot services", nil)
+		return
+	}
+
+	volumeSnapshot := &snapshot.VolumeSnapshot{
+		Name:      req.Name,
+		ClusterID: clusterID,
+		Service:   serviceName,
+		Type:      serviceConfig.Type,
+		Mode:      req.Mode,
+		CreatedAt: time.Now(),
+	}
+
+	var data io.ReadCloser
+	if req.Mode == provisioner.SnapshotCommit {
+		volumeSnapshot.Image, err = snapshotter.CommitService(r.Context(), serviceConfig.ContainerID, config.Name, serviceName, req.Name)
+		if err != nil {
+			s.errorResponse(w, http.StatusInternalServerError, "Failed to commit the service's container", err)
+			return
+		}
+	} else {
+		path, ok := snapshotter.DataPath(serviceConfig.Type)
+		if !ok {
+			s.errorResponse(w, http.StatusBadRequest,
+				fmt.Sprintf("Services of type %s have no known data directory, use the commit mode", serviceConfig.Type), nil)
+			return
+		}
+		volumeSnapshot.Path = path
+		data, err = snapshotter.SnapshotVolume(r.Context(), serviceConfig.ContainerID, path)
+		if err != nil {
+			s.errorResponse(w, http.StatusInternalServerError, "Failed to copy the service's data", err)
+			return
+		}
+		defer data.Close()
+	}
+
+	if err := s.volumes.Save(volumeSnapshot, data); err != nil {
+		s.errorResponse(w, http.StatusInternalServerError, "Failed to save snapshot", err)
+		return
+	}
+
+	logger.Info("Service snapshot created",
+		zap.String("cluster_id", clusterID),
+		zap.String("service", serviceName),
+		zap.String("snapshot", req.Name),
+		zap.String("mode", req.Mode),
+		zap.Int64("size", volumeSnapshot.Size),
+	)
+
+	s.jsonResponse(w, http.StatusCreated, volumeSnapshot)
+}
+
+// handleListServiceSnapshots lists the snapshots of a service's data, newest first
+func (s *Server) handleListServiceSnapshots(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	clusterID := vars["cluster_id"]
+	serviceName := vars["service_name"]
+
+	config, err := s.gateway.GetClusterConfig(clusterID)
+	if err != nil {
+		s.errorResponse(w, http.StatusNotFound, "Cluster not found", err)
+		return
+	}
+	if _, exists := config.Services[serviceName]; !exists {
+		s.errorResponse(w, http.StatusNotFound, "Service not found", nil)
+		return
+	}
+
+	snapshots, err := s.volumes.List(clusterID, serviceName)
+	if err != nil {
+		s.errorResponse(w, http.StatusInternalServerError, "Failed to list snapshots", err)
+		return
+	}
+
+	s.jsonResponse(w, http.StatusOK, map[string]interface{}{
+		"snapshots": snapshots,
+		"count":     len(snapshots),
+	})
+}
+
+// handleDownloadServiceSnapshot streams the tar archive of a snapshot of a service's
+// data
+func (s *Server) handleDownloadServiceSnapshot(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	clusterID := vars["cluster_id"]
+	serviceName := vars["service_name"]
+
+	volumeSnapshot, err := s.volumes.Get(clusterID, serviceName, vars["name"])
+	if err != nil {
+		s.errorResponse(w, http.StatusNotFound, "Snapshot not found", err)
+		return
+	}
+	if volumeSnapshot.Mode == provisioner.SnapshotCommit {
+		s.errorResponse(w, http.StatusConflict,
+			fmt.Sprintf("Snapshot is the image %s on the cluster's Docker host, it has no archive", volumeSnapshot.Image), nil)
+		return
+	}
+
+	file, err := s.volumes.Open(clusterID, serviceName, volumeSnapshot.Name)
+	if err != nil {
+		s.errorResponse(w, http.StatusNotFound, "Snapshot not found", err)
+		return
+	}
+	defer file.Close()
+
+	w.Header().Set("Content-Type", "application/x-tar")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", serviceName+"-"+volumeSnapshot.Name+".tar"))
+	w.Header().Set("Content-Length", fmt.Sprintf("%d", volumeSnapshot.Size))
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(w, file); err != nil {
+		logger.Error("Failed to send snapshot",
+			zap.String("cluster_id", clusterID),
+			zap.String("service", serviceName),
+			zap.String("snapshot", volumeSnapshot.Name),
+			zap.Error(err),
+		)
+	}
+}
@@ -0,0 +1,95 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+
+	"github.com/akmadan/throome/internal/config"
+	"github.com/akmadan/throome/pkg/cluster"
+	"github.com/akmadan/throome/pkg/provisioner"
+	"github.com/akmadan/throome/pkg/snapshot"
+)
+
+// fakeSnapshotter archives data directories as their path
+type fakeSnapshotter struct {
+	provisioner.Provisioner
+}
+
+func (f *fakeSnapshotter) DataPath(serviceType string) (string, bool) {
+	return "/data", serviceType == "test-update"
+}
+
+func (f *fakeSnapshotter) SnapshotVolume(ctx context.Context, containerID, path string) (io.ReadCloser, error) {
+	return io.NopCloser(strings.NewReader(containerID + ":" + path)), nil
+}
+
+func (f *fakeSnapshotter) CommitService(ctx context.Context, containerID, clusterName, serviceName, tag string) (string, error) {
+	return "throome-snapshots/" + clusterName + "-" + serviceName + ":" + tag, nil
+}
+
+func TestSnapshotService(t *testing.T) {
+	gw := newTestGateway(t)
+	gw.SetProvisioner(&fakeSnapshotter{})
+	defer gw.SetProvisioner(nil)
+	s := &Server{config: config.DefaultConfig(), gateway: gw, volumes: snapshot.NewVolumeStore(t.TempDir())}
+
+	clusterID, err := gw.CreateCluster(context.Background(), "volumes", &cluster.Config{
+		Services: map[string]cluster.ServiceConfig{
+			"managed": {Type: "test-update", Host: "localhost", Port: 9871, ContainerID: "c1"},
+			"remote":  {Type: "test-update", Host: "localhost", Port: 9872},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create cluster: %v", err)
+	}
+
+	router := mux.NewRouter()
+	router.HandleFunc("/clusters/{cluster_id}/services/{service_name}/snapshot", s.handleSnapshotService).Methods("POST")
+	router.HandleFunc("/clusters/{cluster_id}/services/{service_name}/snapshots", s.handleListServiceSnapshots).Methods("GET")
+	router.HandleFunc("/clusters/{cluster_id}/services/{service_name}/snapshots/{name}/download", s.handleDownloadServiceSnapshot).Methods("GET")
+	request := func(method, path, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(method, "/clusters/"+clusterID+"/services/"+path, strings.NewReader(body)))
+		return rec
+	}
+
+	if rec := request("POST", "managed/snapshot", `{"name": "golden"}`); rec.Code != http.StatusCreated {
+		t.Fatalf("Expected the snapshot to be taken, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := request("POST", "managed/snapshot", `{"name": "golden"}`); rec.Code != http.StatusConflict {
+		t.Errorf("Expected taken names to be refused, got %d", rec.Code)
+	}
+	rec := request("POST", "managed/snapshot", `{"name": "dev", "mode": "commit"}`)
+	var committed snapshot.VolumeSnapshot
+	_ = json.NewDecoder(rec.Body).Decode(&committed)
+	if rec.Code != http.StatusCreated || committed.Image != "throome-snapshots/volumes-managed:dev" {
+		t.Errorf("Expected the container to be committed, got %d: %+v", rec.Code, committed)
+	}
+	if rec := request("POST", "remote/snapshot", ""); rec.Code != http.StatusConflict {
+		t.Errorf("Expected services without a container to be refused, got %d", rec.Code)
+	}
+
+	rec = request("GET", "managed/snapshots", "")
+	var listed struct {
+		Snapshots []snapshot.VolumeSnapshot `json:"snapshots"`
+	}
+	_ = json.NewDecoder(rec.Body).Decode(&listed)
+	if len(listed.Snapshots) != 2 {
+		t.Errorf("Expected both snapshots to be listed, got %+v", listed.Snapshots)
+	}
+
+	rec = request("GET", "managed/snapshots/golden/download", "")
+	if rec.Code != http.StatusOK || rec.Body.String() != "c1:/data" {
+		t.Errorf("Expected the archive of the data directory, got %d: %q", rec.Code, rec.Body.String())
+	}
+	if rec := request("GET", "managed/snapshots/dev/download", ""); rec.Code != http.StatusConflict {
+		t.Errorf("Expected commit snapshots to have nothing to download, got %d", rec.Code)
+	}
+}
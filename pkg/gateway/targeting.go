@@ -0,0 +1,165 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/akmadan/throome/pkg/auth"
+	"github.com/akmadan/throome/pkg/cluster"
+	"github.com/akmadan/throome/pkg/router"
+)
+
+// ServiceParam is the query parameter that runs a data operation on a named service
+// of the cluster, rather than on the one the cluster's routing strategy picks
+const ServiceParam = "service"
+
+// RouteParam is the query parameter that overrides where a read is routed: "primary"
+// runs it on the service itself, for reading what was just written, and "replica"
+// runs it on a replica even when it is not known to only read
+const RouteParam = "route"
+
+// Values of RouteParam
+const (
+	RoutePrimary = "primary"
+	RouteReplica = "replica"
+)
+
+// ClientIDHeader is the request header naming the client a request comes from, which
+// sticky routing pins to a service instance. Authenticated requests without one are
+// pinned by their API key or token subject.
+const ClientIDHeader = "X-Client-ID"
+
+// targetServiceKey is the context key of the service a request targets
+type targetServiceKey struct{}
+
+// routeOverrideKey is the context key of a request's RouteParam
+type routeOverrideKey struct{}
+
+// targetServiceMiddleware passes the service a request targets, where its reads are
+// routed and the client it comes from on to the adapter lookups of its handler
+func (s *Server) targetServiceMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if serviceName := r.URL.Query().Get(ServiceParam); serviceName != "" {
+			r = r.WithContext(withTargetService(r.Context(), serviceName))
+		}
+		if clientID := requestClientID(r.Context(), r.Header.Get(ClientIDHeader)); clientID != "" {
+			r = r.WithContext(router.WithClient(r.Context(), clientID))
+		}
+		switch route := r.URL.Query().Get(RouteParam); route {
+		case "":
+		case RoutePrimary, RouteReplica:
+			r = r.WithContext(context.WithValue(r.Context(), routeOverrideKey{}, route))
+		default:
+			s.errorResponse(w, http.StatusBadRequest, "Invalid route", fmt.Errorf("%s must be %s or %s", RouteParam, RoutePrimary, RouteReplica))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requestClientID returns the client a request comes from: the client ID it names,
+// else the API key or token subject it authenticated with, else ""
+func requestClientID(ctx context.Context, clientID string) string {
+	if clientID != "" {
+		return "client:" + clientID
+	}
+	identity := auth.IdentityFromContext(ctx)
+	switch {
+	case identity == nil:
+		return ""
+	case identity.KeyID != "":
+		return "key:" + identity.KeyID
+	default:
+		return identity.Method + ":" + identity.Subject
+	}
+}
+
+// reading wraps a data handler that only reads, so it is routed to the replicas of
+// the service it runs on
+func (s *Server) reading(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		next(w, r.WithContext(withReadAccess(r.Context(), true)))
+	}
+}
+
+// withReadAccess returns a context routing an operation to replicas when it only
+// reads, or when the request asked for a replica. Requests that asked for the primary
+// are never routed to replicas.
+func withReadAccess(ctx context.Context, reads bool) context.Context {
+	switch route, _ := ctx.Value(routeOverrideKey{}).(string); route {
+	case RoutePrimary:
+		return ctx
+	case RouteReplica:
+		reads = true
+	}
+	if !reads {
+		return ctx
+	}
+	return router.WithAccess(ctx, router.AccessRead)
+}
+
+// writingSQL matches the keywords of statements that write or lock rows
+var writingSQL = regexp.MustCompile(`(?i)\b(INSERT|UPDATE|DELETE|MERGE|UPSERT|INTO|LOCK|SHARE|ANALYZE|NEXTVAL|SETVAL|CALL|COPY)\b`)
+
+// readOnlyQuery reports whether a SQL query only reads, so it can run on a replica.
+// Queries are kept on the primary when in doubt.
+func readOnlyQuery(query string) bool {
+	fields := strings.Fields(query)
+	if len(fields) == 0 {
+		return false
+	}
+	switch strings.ToUpper(fields[0]) {
+	case "SELECT", "WITH", "VALUES", "TABLE", "SHOW", "EXPLAIN":
+		return !writingSQL.MatchString(query)
+	default:
+		return false
+	}
+}
+
+// withTargetService returns a context targeting a named service
+func withTargetService(ctx context.Context, serviceName string) context.Context {
+	return context.WithValue(ctx, targetServiceKey{}, serviceName)
+}
+
+// targetService returns the service a context targets, or "" for none
+func targetService(ctx context.Context) string {
+	serviceName, _ := ctx.Value(targetServiceKey{}).(string)
+	return serviceName
+}
+
+// selectService returns the service of a cluster an operation runs on, among the
+// services of the given types: the service the request targets, else a connected
+// one picked by the cluster's routing strategy, else the first one configured. It
+// returns "" when the cluster has no service of the types.
+func (s *Server) selectService(ctx context.Context, clusterID string, config *cluster.Config, serviceTypes ...string) (string, *adapterError) {
+	if serviceName := targetService(ctx); serviceName != "" {
+		serviceConfig, exists := config.Services[serviceName]
+		if !exists {
+			return "", &adapterError{http.StatusNotFound, "Service not found", fmt.Errorf("service not found: %s", serviceName)}
+		}
+		for _, serviceType := range serviceTypes {
+			if serviceConfig.Type == serviceType {
+				return serviceName, nil
+			}
+		}
+		err := fmt.Errorf("service %s is a %s service, not %s", serviceName, serviceConfig.Type, strings.Join(serviceTypes, " or "))
+		return "", &adapterError{http.StatusBadRequest, "Service does not support this operation", err}
+	}
+
+	for _, serviceType := range serviceTypes {
+		if serviceName, err := s.gateway.RouteService(ctx, clusterID, serviceType); err == nil {
+			return serviceName, nil
+		}
+	}
+
+	// None is connected, so the operation reports why it cannot reach the first one
+	for _, serviceType := range serviceTypes {
+		if serviceName := findServiceByType(config, serviceType); serviceName != "" {
+			return serviceName, nil
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,127 @@
+package gateway
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/akmadan/throome/pkg/auth"
+	"github.com/akmadan/throome/pkg/cluster"
+	"github.com/akmadan/throome/pkg/router"
+)
+
+func TestSelectService(t *testing.T) {
+	gw := newTestGateway(t)
+	s := &Server{gateway: gw}
+
+	ctx := context.Background()
+	clusterID, err := gw.CreateCluster(ctx, "targeting", &cluster.Config{
+		Services: map[string]cluster.ServiceConfig{
+			"primary": {Type: "test-update", Host: "localhost", Port: 9701},
+			"replica": {Type: "test-update", Host: "localhost", Port: 9702},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create cluster: %v", err)
+	}
+	config, _ := gw.GetClusterConfig(clusterID)
+
+	serviceName, adapterErr := s.selectService(ctx, clusterID, config, "test-update")
+	if adapterErr != nil || (serviceName != "primary" && serviceName != "replica") {
+		t.Errorf("Expected a routed service, got %q (%v)", serviceName, adapterErr)
+	}
+
+	serviceName, adapterErr = s.selectService(withTargetService(ctx, "replica"), clusterID, config, "test-update")
+	if adapterErr != nil || serviceName != "replica" {
+		t.Errorf("Expected the targeted service, got %q (%v)", serviceName, adapterErr)
+	}
+
+	_, adapterErr = s.selectService(withTargetService(ctx, "missing"), clusterID, config, "test-update")
+	if adapterErr == nil || adapterErr.status != http.StatusNotFound {
+		t.Errorf("Expected a missing service to be not found, got %v", adapterErr)
+	}
+
+	_, adapterErr = s.selectService(withTargetService(ctx, "replica"), clusterID, config, "redis")
+	if adapterErr == nil || adapterErr.status != http.StatusBadRequest {
+		t.Errorf("Expected a service of another type to be rejected, got %v", adapterErr)
+	}
+
+	serviceName, adapterErr = s.selectService(ctx, clusterID, config, "redis")
+	if adapterErr != nil || serviceName != "" {
+		t.Errorf("Expected no service of a missing type, got %q (%v)", serviceName, adapterErr)
+	}
+}
+
+func TestTargetServiceMiddleware(t *testing.T) {
+	s := &Server{}
+
+	var targeted string
+	handler := s.targetServiceMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		targeted = targetService(r.Context())
+	}))
+
+	req, _ := http.NewRequest("POST", "/api/v1/clusters/c1/db/query?service=analytics", nil)
+	handler.ServeHTTP(nil, req)
+	if targeted != "analytics" {
+		t.Errorf("Expected the service of the query parameter, got %q", targeted)
+	}
+
+	req, _ = http.NewRequest("POST", "/api/v1/clusters/c1/db/query", nil)
+	handler.ServeHTTP(nil, req)
+	if targeted != "" {
+		t.Errorf("Expected no targeted service, got %q", targeted)
+	}
+}
+
+func TestRequestClientID(t *testing.T) {
+	ctx := context.Background()
+	if clientID := requestClientID(ctx, ""); clientID != "" {
+		t.Errorf("Expected no client for anonymous requests, got %q", clientID)
+	}
+
+	ctx = auth.WithIdentity(ctx, &auth.Identity{Subject: "ci", Method: auth.MethodAPIKey, KeyID: "k1"})
+	if clientID := requestClientID(ctx, ""); clientID != "key:k1" {
+		t.Errorf("Expected the client of the API key, got %q", clientID)
+	}
+	if clientID := requestClientID(ctx, "session-1"); clientID != "client:session-1" {
+		t.Errorf("Expected the client of the header, got %q", clientID)
+	}
+
+	ctx = auth.WithIdentity(context.Background(), &auth.Identity{Subject: "alice", Method: auth.MethodJWT})
+	if clientID := requestClientID(ctx, ""); clientID != "jwt:alice" {
+		t.Errorf("Expected the client of the token subject, got %q", clientID)
+	}
+}
+
+func TestReadOnlyQuery(t *testing.T) {
+	tests := map[string]bool{
+		"SELECT * FROM users":                                               true,
+		"  select id from users where name = 'a'":                           true,
+		"WITH recent AS (SELECT 1) SELECT * FROM recent":                    true,
+		"EXPLAIN SELECT 1":                                                  true,
+		"SELECT * FROM users FOR UPDATE":                                    false,
+		"SELECT * INTO archive FROM users":                                  false,
+		"WITH moved AS (DELETE FROM users RETURNING *) SELECT * FROM moved": false,
+		"SELECT nextval('ids')":                                             false,
+		"INSERT INTO users VALUES (1)":                                      false,
+		"":                                                                  false,
+	}
+	for query, want := range tests {
+		if got := readOnlyQuery(query); got != want {
+			t.Errorf("readOnlyQuery(%q) = %v, want %v", query, got, want)
+		}
+	}
+
+	ctx := withReadAccess(context.Background(), true)
+	if router.AccessOf(ctx) != router.AccessRead {
+		t.Error("Expected reads to be routed to replicas")
+	}
+	ctx = context.WithValue(context.Background(), routeOverrideKey{}, RoutePrimary)
+	if router.AccessOf(withReadAccess(ctx, true)) != router.AccessWrite {
+		t.Error("Expected reads of requests asking for the primary to stay on it")
+	}
+	ctx = context.WithValue(context.Background(), routeOverrideKey{}, RouteReplica)
+	if router.AccessOf(withReadAccess(ctx, false)) != router.AccessRead {
+		t.Error("Expected requests asking for a replica to be routed to one")
+	}
+}
@@ -0,0 +1,68 @@
+package gateway
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// serverTLSConfig builds the TLS settings of the HTTPS server. The certificate
+// itself is loaded when the server starts listening.
+func (s *Server) serverTLSConfig() (*tls.Config, error) {
+	cfg := s.config.Server.TLS
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if cfg.MinVersion == "1.3" {
+		tlsConfig.MinVersion = tls.VersionTLS13
+	}
+
+	if cfg.ClientCAFile != "" {
+		pem, err := os.ReadFile(cfg.ClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read client CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in client CA file: %s", cfg.ClientCAFile)
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return tlsConfig, nil
+}
+
+// newRedirectServer creates the plain HTTP server that redirects every request to
+// the HTTPS server
+func (s *Server) newRedirectServer() *http.Server {
+	return &http.Server{
+		Addr:         fmt.Sprintf("%s:%d", s.config.Server.Host, s.config.Server.TLS.RedirectPort),
+		Handler:      httpsRedirect(s.config.Server.Port),
+		ReadTimeout:  time.Duration(s.config.Server.ReadTimeout) * time.Second,
+		WriteTimeout: time.Duration(s.config.Server.WriteTimeout) * time.Second,
+	}
+}
+
+// httpsRedirect redirects requests to the same host and path on the HTTPS port. The
+// redirect is permanent and keeps the method, so API clients resend their request.
+func httpsRedirect(port int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		host = strings.Trim(host, "[]")
+		if port != 443 {
+			host = net.JoinHostPort(host, strconv.Itoa(port))
+		} else if strings.Contains(host, ":") {
+			host = "[" + host + "]"
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusPermanentRedirect)
+	})
+}
@@ -0,0 +1,105 @@
+package gateway
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/akmadan/throome/internal/config"
+)
+
+func TestHTTPSRedirect(t *testing.T) {
+	tests := []struct {
+		port     int
+		host     string
+		target   string
+		location string
+	}{
+		{9443, "gateway.local:8080", "/api/v1/clusters?archived=all", "https://gateway.local:9443/api/v1/clusters?archived=all"},
+		{443, "gateway.local:8080", "/api/v1/health", "https://gateway.local/api/v1/health"},
+		{443, "gateway.local", "/", "https://gateway.local/"},
+		{9443, "[::1]:8080", "/", "https://[::1]:9443/"},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest("POST", tt.target, nil)
+		req.Host = tt.host
+		rec := httptest.NewRecorder()
+		httpsRedirect(tt.port).ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusPermanentRedirect {
+			t.Errorf("%s%s: expected status %d, got %d", tt.host, tt.target, http.StatusPermanentRedirect, rec.Code)
+		}
+		if location := rec.Header().Get("Location"); location != tt.location {
+			t.Errorf("%s%s: expected redirect to %s, got %s", tt.host, tt.target, tt.location, location)
+		}
+	}
+}
+
+func TestServerTLSConfig(t *testing.T) {
+	cfg := config.DefaultConfig()
+	s := &Server{config: cfg}
+
+	tlsConfig, err := s.serverTLSConfig()
+	if err != nil {
+		t.Fatalf("Failed to build TLS config: %v", err)
+	}
+	if tlsConfig.MinVersion != tls.VersionTLS12 || tlsConfig.ClientAuth != tls.NoClientCert {
+		t.Errorf("Expected TLS 1.2 without client certificates, got %+v", tlsConfig)
+	}
+
+	dir := t.TempDir()
+	cfg.Server.TLS.MinVersion = "1.3"
+	cfg.Server.TLS.ClientCAFile = filepath.Join(dir, "ca.crt")
+	if err := os.WriteFile(cfg.Server.TLS.ClientCAFile, testCACertificate(t), 0o600); err != nil {
+		t.Fatalf("Failed to write CA file: %v", err)
+	}
+
+	tlsConfig, err = s.serverTLSConfig()
+	if err != nil {
+		t.Fatalf("Failed to build TLS config: %v", err)
+	}
+	if tlsConfig.MinVersion != tls.VersionTLS13 || tlsConfig.ClientAuth != tls.RequireAndVerifyClientCert || tlsConfig.ClientCAs == nil {
+		t.Errorf("Expected TLS 1.3 with required client certificates, got %+v", tlsConfig)
+	}
+
+	if err := os.WriteFile(cfg.Server.TLS.ClientCAFile, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatalf("Failed to write CA file: %v", err)
+	}
+	if _, err := s.serverTLSConfig(); err == nil {
+		t.Error("Expected a CA file without certificates to be rejected")
+	}
+}
+
+// testCACertificate returns a PEM-encoded self-signed CA certificate
+func testCACertificate(t *testing.T) []byte {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "throome-test-ca"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
@@ -9,27 +9,41 @@ import (
 
 // ActivityLog represents a single service interaction
 type ActivityLog struct {
-	ID           string            `json:"id"`
-	Timestamp    time.Time         `json:"timestamp"`
-	ClusterID    string            `json:"cluster_id"`
-	ServiceName  string            `json:"service_name"`
-	ServiceType  string            `json:"service_type"`
-	Operation    string            `json:"operation"`               // GET, SET, SELECT, PUBLISH, etc.
-	Command      string            `json:"command"`                 // Full command/query
-	Parameters   []interface{}     `json:"parameters,omitempty"`    // Query parameters
-	Duration     int64             `json:"duration"`                // Duration in milliseconds
-	Status       string            `json:"status"`                  // success, error
-	Response     string            `json:"response,omitempty"`      // Result summary
-	Error        string            `json:"error,omitempty"`         // Error message if failed
-	RowsAffected int64             `json:"rows_affected,omitempty"` // For SQL queries
-	ClientInfo   map[string]string `json:"client_info,omitempty"`   // Additional context
+	ID            string            `json:"id"`
+	Timestamp     time.Time         `json:"timestamp"`
+	ClusterID     string            `json:"cluster_id"`
+	ServiceName   string            `json:"service_name"`
+	ServiceType   string            `json:"service_type"`
+	Operation     string            `json:"operation"`                // GET, SET, SELECT, PUBLISH, etc.
+	Command       string            `json:"command"`                  // Full command/query
+	Parameters    []interface{}     `json:"parameters,omitempty"`     // Query parameters
+	Duration      int64             `json:"duration"`                 // Duration in milliseconds
+	Status        string            `json:"status"`                   // success, error
+	Response      string            `json:"response,omitempty"`       // Result summary
+	Error         string            `json:"error,omitempty"`          // Error message if failed
+	ErrorType     string            `json:"error_type,omitempty"`     // Type of the error, such as timeout or not_found
+	RowsAffected  int64             `json:"rows_affected,omitempty"`  // For SQL queries, the rows returned by those that read
+	BytesReturned int64             `json:"bytes_returned,omitempty"` // Size of the values of the rows returned by a query
+	ClientInfo    map[string]string `json:"client_info,omitempty"`    // Additional context
 }
 
+// Client info keys of operations caused by API requests
+const (
+	ClientInfoRequestID  = "request_id"  // ID of the request
+	ClientInfoIdentity   = "identity"    // API key name or token subject of the caller
+	ClientInfoAuthMethod = "auth_method" // How the caller authenticated, api_key or jwt
+	ClientInfoKeyID      = "key_id"      // ID of the caller's API key
+)
+
+// ActivityHandler is called for every activity log added to a buffer
+type ActivityHandler func(log *ActivityLog)
+
 // ActivityBuffer is a thread-safe circular buffer for activity logs
 type ActivityBuffer struct {
 	logs     []*ActivityLog
 	maxSize  int
 	position int
+	handlers []ActivityHandler
 	mu       sync.RWMutex
 }
 
@@ -41,10 +55,17 @@ func NewActivityBuffer(maxSize int) *ActivityBuffer {
 	}
 }
 
+// OnAdd registers a handler that is called for each activity log added
+func (ab *ActivityBuffer) OnAdd(handler ActivityHandler) {
+	ab.mu.Lock()
+	defer ab.mu.Unlock()
+
+	ab.handlers = append(ab.handlers, handler)
+}
+
 // Add adds a new activity log to the buffer
 func (ab *ActivityBuffer) Add(log *ActivityLog) {
 	ab.mu.Lock()
-	defer ab.mu.Unlock()
 
 	// Ensure ID is set
 	if log.ID == "" {
@@ -59,6 +80,12 @@ func (ab *ActivityBuffer) Add(log *ActivityLog) {
 		ab.logs[ab.position] = log
 		ab.position = (ab.position + 1) % ab.maxSize
 	}
+	handlers := append([]ActivityHandler(nil), ab.handlers...)
+	ab.mu.Unlock()
+
+	for _, handler := range handlers {
+		handler(log)
+	}
 }
 
 // GetRecent returns the most recent n activity logs
@@ -224,12 +251,21 @@ type ActivityFilters struct {
 	ServiceType string
 	Operation   string
 	Status      string // success, error
+	RequestID   string // ID of the API request that caused the operations
 	Since       *time.Time
 	Limit       int
+	Offset      int // Number of matching logs to skip, newest first
 }
 
 // Filter applies filters to activity logs
 func (ab *ActivityBuffer) Filter(filters ActivityFilters) []*ActivityLog {
+	logs, _ := ab.FilterPage(filters)
+	return logs
+}
+
+// FilterPage applies filters to activity logs and returns a page of them, newest
+// first, along with the total number of matching logs
+func (ab *ActivityBuffer) FilterPage(filters ActivityFilters) ([]*ActivityLog, int) {
 	ab.mu.RLock()
 	defer ab.mu.RUnlock()
 
@@ -238,39 +274,24 @@ func (ab *ActivityBuffer) Filter(filters ActivityFilters) []*ActivityLog {
 		limit = 100
 	}
 
-	result := make([]*ActivityLog, 0, limit)
-	count := 0
+	result := make([]*ActivityLog, 0, min(limit, len(ab.logs)))
+	total := 0
 
-	// Helper function to check if log matches filters
-	matches := func(log *ActivityLog) bool {
-		if filters.ClusterID != "" && log.ClusterID != filters.ClusterID {
-			return false
+	// Every log is checked to count the matches beyond the page
+	collect := func(log *ActivityLog) {
+		if !filters.Matches(log) {
+			return
 		}
-		if filters.ServiceName != "" && log.ServiceName != filters.ServiceName {
-			return false
+		if total >= filters.Offset && len(result) < limit {
+			result = append(result, log)
 		}
-		if filters.ServiceType != "" && log.ServiceType != filters.ServiceType {
-			return false
-		}
-		if filters.Operation != "" && log.Operation != filters.Operation {
-			return false
-		}
-		if filters.Status != "" && log.Status != filters.Status {
-			return false
-		}
-		if filters.Since != nil && log.Timestamp.Before(*filters.Since) {
-			return false
-		}
-		return true
+		total++
 	}
 
 	// Iterate from newest to oldest
 	if len(ab.logs) < ab.maxSize {
-		for i := len(ab.logs) - 1; i >= 0 && count < limit; i-- {
-			if matches(ab.logs[i]) {
-				result = append(result, ab.logs[i])
-				count++
-			}
+		for i := len(ab.logs) - 1; i >= 0; i-- {
+			collect(ab.logs[i])
 		}
 	} else {
 		pos := ab.position - 1
@@ -278,19 +299,83 @@ func (ab *ActivityBuffer) Filter(filters ActivityFilters) []*ActivityLog {
 			pos = ab.maxSize - 1
 		}
 
-		checked := 0
-		for checked < len(ab.logs) && count < limit {
-			if matches(ab.logs[pos]) {
-				result = append(result, ab.logs[pos])
-				count++
-			}
+		for checked := 0; checked < len(ab.logs); checked++ {
+			collect(ab.logs[pos])
 			pos--
 			if pos < 0 {
 				pos = ab.maxSize - 1
 			}
-			checked++
+		}
+	}
+
+	return result, total
+}
+
+// Matching returns every activity log matching the filters, oldest first. The limit
+// and offset of the filters are ignored.
+func (ab *ActivityBuffer) Matching(filters ActivityFilters) []*ActivityLog {
+	ab.mu.RLock()
+	defer ab.mu.RUnlock()
+
+	result := make([]*ActivityLog, 0)
+
+	// The oldest log is at the position once the buffer has wrapped
+	start := 0
+	if len(ab.logs) == ab.maxSize {
+		start = ab.position
+	}
+	for i := 0; i < len(ab.logs); i++ {
+		if log := ab.logs[(start+i)%len(ab.logs)]; filters.Matches(log) {
+			result = append(result, log)
 		}
 	}
 
 	return result
 }
+
+// Resize changes how many activity logs the buffer keeps, dropping the oldest ones
+// that no longer fit
+func (ab *ActivityBuffer) Resize(maxSize int) {
+	ab.mu.Lock()
+	defer ab.mu.Unlock()
+
+	// Reorder the logs oldest first, so the buffer has not wrapped
+	logs := make([]*ActivityLog, 0, maxSize)
+	start := 0
+	if len(ab.logs) == ab.maxSize {
+		start = ab.position
+	}
+	for i := max(len(ab.logs)-maxSize, 0); i < len(ab.logs); i++ {
+		logs = append(logs, ab.logs[(start+i)%len(ab.logs)])
+	}
+
+	ab.logs = logs
+	ab.maxSize = maxSize
+	ab.position = 0
+}
+
+// Matches reports whether an activity log matches the filters
+func (filters ActivityFilters) Matches(log *ActivityLog) bool {
+	if filters.ClusterID != "" && log.ClusterID != filters.ClusterID {
+		return false
+	}
+	if filters.ServiceName != "" && log.ServiceName != filters.ServiceName {
+		return false
+	}
+	if filters.ServiceType != "" && log.ServiceType != filters.ServiceType {
+		return false
+	}
+	if filters.Operation != "" && log.Operation != filters.Operation {
+		return false
+	}
+	if filters.Status != "" && log.Status != filters.Status {
+		return false
+	}
+	if filters.RequestID != "" && log.ClientInfo[ClientInfoRequestID] != filters.RequestID {
+		return false
+	}
+	if filters.Since != nil && log.Timestamp.Before(*filters.Since) {
+		return false
+	}
+	return true
+}
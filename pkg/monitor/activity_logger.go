@@ -1,27 +1,68 @@
 package monitor
 
 import (
+	"context"
+	"fmt"
+	"sync"
 	"time"
+
+	"github.com/akmadan/throome/pkg/adapters"
+	"github.com/akmadan/throome/pkg/auth"
+	"github.com/akmadan/throome/pkg/cluster"
+	"github.com/akmadan/throome/pkg/requestid"
 )
 
 // ActivityLogger provides methods for logging service interactions
 type ActivityLogger interface {
 	Log(activity *ActivityLog)
-	LogOperation(clusterID, serviceName, serviceType, operation, command string, duration time.Duration, err error, response string)
+	LogOperation(ctx context.Context, clusterID, serviceName, serviceType, operation, command string, duration time.Duration, err error, response string)
 }
 
 // DefaultActivityLogger implements ActivityLogger using an ActivityBuffer
 type DefaultActivityLogger struct {
-	buffer *ActivityBuffer
+	buffer    *ActivityBuffer
+	collector *Collector
+	mu        sync.RWMutex
+	configs   map[string]cluster.ActivityConfig // clusterID -> redaction and sampling of its operations
 }
 
 // NewActivityLogger creates a new activity logger with the given buffer
 func NewActivityLogger(buffer *ActivityBuffer) ActivityLogger {
 	return &DefaultActivityLogger{
-		buffer: buffer,
+		buffer:  buffer,
+		configs: make(map[string]cluster.ActivityConfig),
 	}
 }
 
+// SetClusterConfig sets how the operations of a cluster are redacted and sampled
+func (l *DefaultActivityLogger) SetClusterConfig(clusterID string, config cluster.ActivityConfig) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.configs[clusterID] = config
+}
+
+// ForgetCluster drops the activity configuration of a deleted cluster
+func (l *DefaultActivityLogger) ForgetCluster(clusterID string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.configs, clusterID)
+}
+
+// clusterConfig returns the activity configuration of a cluster
+func (l *DefaultActivityLogger) clusterConfig(clusterID string) cluster.ActivityConfig {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return l.configs[clusterID]
+}
+
+// SetCollector makes the logger also record every operation in the metrics collector
+func (l *DefaultActivityLogger) SetCollector(collector *Collector) {
+	l.collector = collector
+}
+
 // Log adds an activity log to the buffer
 func (l *DefaultActivityLogger) Log(activity *ActivityLog) {
 	if l.buffer != nil {
@@ -29,15 +70,22 @@ func (l *DefaultActivityLogger) Log(activity *ActivityLog) {
 	}
 }
 
-// LogOperation is a convenience method for logging an operation
+// LogOperation is a convenience method for logging an operation. Operations caused by
+// an API request carry its ID and the identity of its caller in the client info.
+// Every operation is recorded in the metrics, but only those sampled by the cluster's
+// activity configuration are logged, with their command redacted as it asks.
 func (l *DefaultActivityLogger) LogOperation(
+	ctx context.Context,
 	clusterID, serviceName, serviceType, operation, command string,
 	duration time.Duration,
 	err error,
 	response string,
 ) {
-	activity := &ActivityLog{
-		Timestamp:   time.Now(),
+	if l.collector != nil {
+		l.collector.RecordOperation(clusterID, serviceName, serviceType, duration, err)
+	}
+
+	l.logSampled(ctx, &ActivityLog{
 		ClusterID:   clusterID,
 		ServiceName: serviceName,
 		ServiceType: serviceType,
@@ -45,11 +93,55 @@ func (l *DefaultActivityLogger) LogOperation(
 		Command:     command,
 		Duration:    duration.Milliseconds(),
 		Response:    response,
+	}, err)
+}
+
+// LogQuery logs a query like LogOperation, along with the rows it returned and the
+// size of their values, which are also added to the metrics of the service
+func (l *DefaultActivityLogger) LogQuery(
+	ctx context.Context,
+	clusterID, serviceName, serviceType, operation, command string,
+	duration time.Duration,
+	err error,
+	rows, bytes int64,
+) {
+	if l.collector != nil {
+		l.collector.RecordOperation(clusterID, serviceName, serviceType, duration, err)
+		l.collector.RecordRows(clusterID, serviceName, serviceType, rows, bytes)
 	}
 
+	response := ""
+	if err == nil {
+		response = fmt.Sprintf("%d rows returned", rows)
+	}
+	l.logSampled(ctx, &ActivityLog{
+		ClusterID:     clusterID,
+		ServiceName:   serviceName,
+		ServiceType:   serviceType,
+		Operation:     operation,
+		Command:       command,
+		Duration:      duration.Milliseconds(),
+		Response:      response,
+		RowsAffected:  rows,
+		BytesReturned: bytes,
+	}, err)
+}
+
+// logSampled completes and logs an operation if the activity configuration of its
+// cluster samples it, with its command redacted as the configuration asks
+func (l *DefaultActivityLogger) logSampled(ctx context.Context, activity *ActivityLog, err error) {
+	config := l.clusterConfig(activity.ClusterID)
+	if !sampled(config, err != nil) {
+		return
+	}
+
+	activity.Timestamp = time.Now()
+	activity.Command = RedactCommand(activity.Command, config)
+	activity.ClientInfo = requestClientInfo(ctx)
 	if err != nil {
 		activity.Status = "error"
 		activity.Error = err.Error()
+		activity.ErrorType = adapters.ClassifyError(err)
 	} else {
 		activity.Status = "success"
 	}
@@ -57,6 +149,27 @@ func (l *DefaultActivityLogger) LogOperation(
 	l.Log(activity)
 }
 
+// requestClientInfo returns the client info of the operations of a request: its ID and
+// the API key or token subject it authenticated with. It returns nil when the context
+// carries neither.
+func requestClientInfo(ctx context.Context) map[string]string {
+	info := make(map[string]string)
+	if id := requestid.FromContext(ctx); id != "" {
+		info[ClientInfoRequestID] = id
+	}
+	if identity := auth.IdentityFromContext(ctx); identity != nil {
+		info[ClientInfoIdentity] = identity.Subject
+		info[ClientInfoAuthMethod] = identity.Method
+		if identity.KeyID != "" {
+			info[ClientInfoKeyID] = identity.KeyID
+		}
+	}
+	if len(info) == 0 {
+		return nil
+	}
+	return info
+}
+
 // NoOpActivityLogger is a logger that does nothing (for testing or when logging is disabled)
 type NoOpActivityLogger struct{}
 
@@ -70,9 +183,20 @@ func (l *NoOpActivityLogger) Log(activity *ActivityLog) {}
 
 // LogOperation does nothing
 func (l *NoOpActivityLogger) LogOperation(
+	ctx context.Context,
 	clusterID, serviceName, serviceType, operation, command string,
 	duration time.Duration,
 	err error,
 	response string,
 ) {
 }
+
+// LogQuery does nothing
+func (l *NoOpActivityLogger) LogQuery(
+	ctx context.Context,
+	clusterID, serviceName, serviceType, operation, command string,
+	duration time.Duration,
+	err error,
+	rows, bytes int64,
+) {
+}
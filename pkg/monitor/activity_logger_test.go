@@ -0,0 +1,77 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/akmadan/throome/pkg/adapters"
+	"github.com/akmadan/throome/pkg/auth"
+	"github.com/akmadan/throome/pkg/cluster"
+	"github.com/akmadan/throome/pkg/requestid"
+)
+
+func TestLogOperationClientInfo(t *testing.T) {
+	buffer := NewActivityBuffer(10)
+	logger := NewActivityLogger(buffer)
+
+	ctx := requestid.WithID(context.Background(), "req-1")
+	ctx = auth.WithIdentity(ctx, &auth.Identity{Subject: "ci", Method: auth.MethodAPIKey, KeyID: "key-1"})
+	logger.LogOperation(ctx, "c1", "db", "postgres", "SELECT", "SELECT 1", 0, nil, "")
+	logger.LogOperation(ctx, "c1", "cache", "redis", "SET", "SET a", 0, errors.New("down"), "")
+	logger.LogOperation(context.Background(), "c1", "cache", "redis", "GET", "GET a", 0, nil, "")
+
+	logs := buffer.Filter(ActivityFilters{RequestID: "req-1"})
+	if len(logs) != 2 || logs[0].Operation != "SET" || logs[1].Operation != "SELECT" {
+		t.Fatalf("Expected the SET and SELECT of the request, got %d logs", len(logs))
+	}
+	expected := map[string]string{
+		ClientInfoRequestID:  "req-1",
+		ClientInfoIdentity:   "ci",
+		ClientInfoAuthMethod: auth.MethodAPIKey,
+		ClientInfoKeyID:      "key-1",
+	}
+	for key, value := range expected {
+		if logs[0].ClientInfo[key] != value {
+			t.Errorf("Expected %s %q, got %q", key, value, logs[0].ClientInfo[key])
+		}
+	}
+
+	// Operations outside of requests have no client info
+	if logs := buffer.Filter(ActivityFilters{Operation: "GET"}); len(logs) != 1 || logs[0].ClientInfo != nil {
+		t.Errorf("Expected the GET without client info, got %v", logs)
+	}
+}
+
+func TestLogQuery(t *testing.T) {
+	buffer := NewActivityBuffer(10)
+	adapter := adapters.NewBaseAdapter(&cluster.ServiceConfig{Type: "postgres"})
+	adapter.SetActivityLogger(NewActivityLogger(buffer), "c1", "db")
+
+	adapter.LogQuery(context.Background(), "QUERY", "SELECT * FROM users", 0, nil, 3, 120)
+	adapter.LogQuery(context.Background(), "QUERY", "SELECT pg_sleep(10)", 0, context.DeadlineExceeded, 0, 0)
+
+	logs := buffer.Filter(ActivityFilters{Status: "success"})
+	if len(logs) != 1 || logs[0].RowsAffected != 3 || logs[0].BytesReturned != 120 || logs[0].Response != "3 rows returned" {
+		t.Fatalf("Expected the query with its rows and bytes, got %+v", logs)
+	}
+	if logs := buffer.Filter(ActivityFilters{Status: "error"}); len(logs) != 1 || logs[0].Response != "" || logs[0].ErrorType != adapters.ErrorTypeTimeout {
+		t.Errorf("Expected the failed query as a timeout without a response, got %+v", logs)
+	}
+
+	// Loggers that do not record result sizes get the row count as the response
+	var response string
+	adapter.SetActivityLogger(operationLogger(func(r string) { response = r }), "c1", "db")
+	adapter.LogQuery(context.Background(), "QUERY", "SELECT 1", 0, nil, 1, 4)
+	if response != "1 rows returned" {
+		t.Errorf("Expected the row count in the response, got %q", response)
+	}
+}
+
+// operationLogger is an ActivityLogger that only logs operations
+type operationLogger func(response string)
+
+func (l operationLogger) LogOperation(ctx context.Context, clusterID, serviceName, serviceType, operation, command string, duration time.Duration, err error, response string) {
+	l(response)
+}
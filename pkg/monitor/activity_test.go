@@ -0,0 +1,70 @@
+package monitor
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestActivityBufferFilterPage(t *testing.T) {
+	buffer := NewActivityBuffer(10)
+	for i := 0; i < 15; i++ {
+		clusterID := "c1"
+		if i%3 == 0 {
+			clusterID = "c2"
+		}
+		buffer.Add(&ActivityLog{ID: fmt.Sprintf("log-%d", i), ClusterID: clusterID})
+	}
+
+	// The buffer holds logs 5 to 14, of which 5, 7, 8, 10, 11, 13 and 14 are from c1
+	logs, total := buffer.FilterPage(ActivityFilters{ClusterID: "c1", Limit: 2, Offset: 2})
+	if total != 7 {
+		t.Errorf("Expected 7 matching logs, got %d", total)
+	}
+	if len(logs) != 2 {
+		t.Fatalf("Expected a page of 2 logs, got %d", len(logs))
+	}
+	if logs[0].ID != "log-11" || logs[1].ID != "log-10" {
+		t.Errorf("Expected logs 11 and 10, got %s and %s", logs[0].ID, logs[1].ID)
+	}
+
+	if logs, total := buffer.FilterPage(ActivityFilters{ClusterID: "c1", Offset: 7}); len(logs) != 0 || total != 7 {
+		t.Errorf("Expected an empty page past the end, got %d logs of %d", len(logs), total)
+	}
+}
+
+func TestActivityBufferMatching(t *testing.T) {
+	buffer := NewActivityBuffer(4)
+	for i := 0; i < 6; i++ {
+		status := "success"
+		if i%2 == 0 {
+			status = "error"
+		}
+		buffer.Add(&ActivityLog{ID: fmt.Sprintf("log-%d", i), Status: status})
+	}
+
+	// The buffer holds logs 2 to 5, of which 2 and 4 failed
+	logs := buffer.Matching(ActivityFilters{Status: "error", Limit: 1})
+	if len(logs) != 2 || logs[0].ID != "log-2" || logs[1].ID != "log-4" {
+		t.Errorf("Expected logs 2 and 4, got %v", logIDs(logs))
+	}
+
+	// Growing the buffer keeps every log, shrinking it drops the oldest ones
+	buffer.Resize(8)
+	buffer.Add(&ActivityLog{ID: "log-6"})
+	if logs := buffer.Matching(ActivityFilters{}); len(logs) != 5 || logs[0].ID != "log-2" || logs[4].ID != "log-6" {
+		t.Errorf("Expected logs 2 to 6 after growing, got %v", logIDs(logs))
+	}
+	buffer.Resize(2)
+	if logs := buffer.Matching(ActivityFilters{}); len(logs) != 2 || logs[0].ID != "log-5" || logs[1].ID != "log-6" {
+		t.Errorf("Expected logs 5 and 6 after shrinking, got %v", logIDs(logs))
+	}
+}
+
+// logIDs returns the IDs of activity logs
+func logIDs(logs []*ActivityLog) []string {
+	ids := make([]string, 0, len(logs))
+	for _, log := range logs {
+		ids = append(ids, log.ID)
+	}
+	return ids
+}
@@ -0,0 +1,470 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/akmadan/throome/internal/logger"
+)
+
+// Metrics alert rules can watch
+const (
+	AlertErrorRate  = "error_rate"  // Percentage of failed requests since the last evaluation
+	AlertP99Latency = "p99_latency" // 99th percentile latency in milliseconds since the last evaluation
+	AlertUnhealthy  = "unhealthy"   // Whether the service failed its health checks
+	AlertAnomaly    = "anomaly"     // Standard deviations of the largest anomaly detected since the last evaluation
+)
+
+// States of an alert
+const (
+	AlertPending  = "pending"  // The condition holds, but not for the rule's duration yet
+	AlertFiring   = "firing"   // The condition held for the rule's duration, notifications were sent
+	AlertResolved = "resolved" // The condition no longer holds
+)
+
+// notifyTimeout bounds the delivery of a notification to a channel
+const notifyTimeout = 10 * time.Second
+
+// AlertRule is a condition on the metrics or health of services that fires an alert
+// once it held for a duration
+type AlertRule struct {
+	Name        string        `json:"name"`
+	Metric      string        `json:"metric"`
+	Threshold   float64       `json:"threshold"`              // Unused by unhealthy rules, any anomaly when 0 for anomaly rules
+	For         time.Duration `json:"for"`                    // How long the condition must hold before firing
+	ClusterID   string        `json:"cluster_id,omitempty"`   // All clusters when empty
+	ServiceName string        `json:"service_name,omitempty"` // All services when empty
+	Channels    []string      `json:"channels,omitempty"`     // Notification channels of the alerts
+}
+
+// Alert is a rule's condition holding for a service
+type Alert struct {
+	ID          string     `json:"id"`
+	Rule        string     `json:"rule"`
+	Metric      string     `json:"metric"`
+	ClusterID   string     `json:"cluster_id"`
+	ServiceName string     `json:"service_name"`
+	State       string     `json:"state"`
+	Value       float64    `json:"value"` // Latest value of the metric, minutes for unhealthy rules, standard deviations for anomaly rules
+	Threshold   float64    `json:"threshold"`
+	Message     string     `json:"message"`
+	ActiveSince time.Time  `json:"active_since"` // When the condition started to hold
+	FiredAt     *time.Time `json:"fired_at,omitempty"`
+	ResolvedAt  *time.Time `json:"resolved_at,omitempty"`
+}
+
+// AlertHandler is called for every alert that fires or resolves
+type AlertHandler func(alert *Alert)
+
+// Notifier delivers alerts to a notification channel
+type Notifier interface {
+	Notify(ctx context.Context, alert *Alert) error
+}
+
+// AlertEngine evaluates alert rules against the collector's metrics, the health
+// checker's results and the anomalies it is given, and notifies the rules' channels
+// as alerts fire and resolve. Error rates, latencies and anomalies are those since
+// the previous evaluation.
+type AlertEngine struct {
+	rules      []AlertRule
+	notifiers  map[string]Notifier
+	active     map[string]*Alert // rule/clusterID/serviceName -> pending or firing alert
+	resolved   []*Alert
+	maxAlerts  int
+	baselines  map[string]*alertBaseline // clusterID/serviceName -> metrics at the previous evaluation
+	anomalies  map[string]float64        // clusterID/serviceName -> largest anomaly score since the previous evaluation
+	handlers   []AlertHandler
+	mu         sync.RWMutex
+	notifyWait sync.WaitGroup
+}
+
+// alertBaseline holds the request metrics of a service at the previous evaluation
+type alertBaseline struct {
+	totalRequests  int64
+	failedRequests int64
+	latencies      latencyHistogram
+}
+
+// alertSample holds what the rules watch of a service at an evaluation
+type alertSample struct {
+	clusterID   string
+	serviceName string
+	requests    int64
+	failed      int64
+	latencies   *latencyHistogram
+	unhealthy   bool
+	since       time.Time // When the service turned unhealthy
+	anomaly     float64   // Standard deviations of the largest anomaly, 0 without anomalies
+}
+
+// NewAlertEngine creates an alert engine evaluating rules and notifying the channels
+// they name, keeping the last maxAlerts resolved alerts
+func NewAlertEngine(rules []AlertRule, notifiers map[string]Notifier, maxAlerts int) *AlertEngine {
+	return &AlertEngine{
+		rules:     rules,
+		notifiers: notifiers,
+		active:    make(map[string]*Alert),
+		resolved:  make([]*Alert, 0, maxAlerts),
+		maxAlerts: maxAlerts,
+		baselines: make(map[string]*alertBaseline),
+		anomalies: make(map[string]float64),
+	}
+}
+
+// OnAlert registers a handler that is called for every alert that fires or resolves
+func (e *AlertEngine) OnAlert(handler AlertHandler) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.handlers = append(e.handlers, handler)
+}
+
+// RecordAnomaly records an anomaly for anomaly rules to evaluate at the next
+// evaluation. It can be registered with an AnomalyDetector's OnAnomaly.
+func (e *AlertEngine) RecordAnomaly(anomaly *Anomaly) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	key := anomaly.ClusterID + "/" + anomaly.ServiceName
+	e.anomalies[key] = math.Max(e.anomalies[key], math.Abs(anomaly.Score))
+}
+
+// Rules returns the rules of the engine
+func (e *AlertEngine) Rules() []AlertRule {
+	return e.rules
+}
+
+// Evaluate evaluates the rules against the services' metrics since the previous
+// evaluation and their health, firing and resolving alerts
+func (e *AlertEngine) Evaluate(collector *Collector, health *HealthChecker, now time.Time) {
+	samples := e.samples(collector, health)
+
+	e.mu.Lock()
+	var changed []*Alert
+	seen := make(map[string]bool)
+	for _, sample := range samples {
+		for _, rule := range e.rules {
+			if (rule.ClusterID != "" && rule.ClusterID != sample.clusterID) ||
+				(rule.ServiceName != "" && rule.ServiceName != sample.serviceName) {
+				continue
+			}
+			key := rule.Name + "/" + sample.clusterID + "/" + sample.serviceName
+			seen[key] = true
+			if alert := e.evaluateRule(key, rule, sample, now); alert != nil {
+				changed = append(changed, alert)
+			}
+		}
+	}
+
+	// Alerts of services that are gone resolve
+	for key, alert := range e.active {
+		if !seen[key] {
+			if resolved := e.resolveLocked(key, alert, now); resolved != nil {
+				changed = append(changed, resolved)
+			}
+		}
+	}
+	handlers := e.handlers
+	e.mu.Unlock()
+
+	for _, alert := range changed {
+		for _, handler := range handlers {
+			handler(alert)
+		}
+		e.notify(alert)
+	}
+}
+
+// samples returns what the rules watch of every service with metrics, health checks
+// or anomalies, and moves the baselines to the current metrics
+func (e *AlertEngine) samples(collector *Collector, health *HealthChecker) []*alertSample {
+	samples := make(map[string]*alertSample)
+	sample := func(clusterID, serviceName string) *alertSample {
+		key := clusterID + "/" + serviceName
+		if _, exists := samples[key]; !exists {
+			samples[key] = &alertSample{clusterID: clusterID, serviceName: serviceName}
+		}
+		return samples[key]
+	}
+
+	if collector != nil {
+		collector.mu.RLock()
+		e.mu.Lock()
+		for clusterID, cluster := range collector.clusterMetrics {
+			for serviceName, svc := range cluster.ServiceMetrics {
+				key := clusterID + "/" + serviceName
+				baseline, exists := e.baselines[key]
+				if !exists || baseline.totalRequests > svc.TotalRequests {
+					baseline = &alertBaseline{}
+				}
+
+				current := sample(clusterID, serviceName)
+				current.requests = svc.TotalRequests - baseline.totalRequests
+				current.failed = svc.FailedRequests - baseline.failedRequests
+				current.latencies = svc.latencies.since(&baseline.latencies)
+
+				e.baselines[key] = &alertBaseline{
+					totalRequests:  svc.TotalRequests,
+					failedRequests: svc.FailedRequests,
+					latencies:      *svc.latencies,
+				}
+			}
+		}
+		e.mu.Unlock()
+		collector.mu.RUnlock()
+	}
+
+	e.mu.Lock()
+	for key, score := range e.anomalies {
+		if clusterID, serviceName, ok := strings.Cut(key, "/"); ok {
+			sample(clusterID, serviceName).anomaly = score
+		}
+	}
+	e.anomalies = make(map[string]float64)
+	e.mu.Unlock()
+
+	if health != nil {
+		for name, since := range health.UnhealthyServices() {
+			clusterID, serviceName, ok := strings.Cut(name, "/")
+			if !ok {
+				continue
+			}
+			current := sample(clusterID, serviceName)
+			current.unhealthy = true
+			current.since = since
+		}
+	}
+
+	result := make([]*alertSample, 0, len(samples))
+	for _, current := range samples {
+		result = append(result, current)
+	}
+	return result
+}
+
+// evaluateRule evaluates a rule for a service, returning a copy of its alert when it
+// fired or resolved. The caller must hold the lock.
+func (e *AlertEngine) evaluateRule(key string, rule AlertRule, sample *alertSample, now time.Time) *Alert {
+	value, holds, since := ruleCondition(rule, sample, now)
+	alert, exists := e.active[key]
+	if !holds {
+		if !exists {
+			return nil
+		}
+		alert.Value = value
+		return e.resolveLocked(key, alert, now)
+	}
+
+	if !exists {
+		alert = &Alert{
+			ID:          uuid.New().String(),
+			Rule:        rule.Name,
+			Metric:      rule.Metric,
+			ClusterID:   sample.clusterID,
+			ServiceName: sample.serviceName,
+			State:       AlertPending,
+			Threshold:   rule.Threshold,
+			ActiveSince: since,
+		}
+		e.active[key] = alert
+	}
+	alert.Value = value
+	alert.Message = alertMessage(alert)
+
+	if alert.State == AlertPending && now.Sub(alert.ActiveSince) >= rule.For {
+		alert.State = AlertFiring
+		firedAt := now
+		alert.FiredAt = &firedAt
+
+		logger.Warn("Alert firing",
+			zap.String("rule", rule.Name),
+			zap.String("cluster_id", alert.ClusterID),
+			zap.String("service", alert.ServiceName),
+			zap.String("message", alert.Message),
+		)
+		fired := *alert
+		return &fired
+	}
+	return nil
+}
+
+// resolveLocked ends an alert, returning a copy of it when it had fired. The caller
+// must hold the lock.
+func (e *AlertEngine) resolveLocked(key string, alert *Alert, now time.Time) *Alert {
+	delete(e.active, key)
+	if alert.State != AlertFiring {
+		return nil // Pending alerts were never notified
+	}
+
+	alert.State = AlertResolved
+	resolvedAt := now
+	alert.ResolvedAt = &resolvedAt
+	alert.Message = alertMessage(alert)
+
+	e.resolved = append(e.resolved, alert)
+	if len(e.resolved) > e.maxAlerts {
+		e.resolved = e.resolved[len(e.resolved)-e.maxAlerts:]
+	}
+
+	logger.Info("Alert resolved",
+		zap.String("rule", alert.Rule),
+		zap.String("cluster_id", alert.ClusterID),
+		zap.String("service", alert.ServiceName),
+	)
+	resolved := *alert
+	return &resolved
+}
+
+// ruleCondition returns the value a rule watches of a service, whether its condition
+// holds and since when
+func ruleCondition(rule AlertRule, sample *alertSample, now time.Time) (float64, bool, time.Time) {
+	switch rule.Metric {
+	case AlertErrorRate:
+		if sample.requests <= 0 {
+			return 0, false, now
+		}
+		rate := float64(sample.failed) / float64(sample.requests) * 100
+		return rate, rate > rule.Threshold, now
+	case AlertP99Latency:
+		if sample.latencies == nil || sample.latencies.count == 0 {
+			return 0, false, now
+		}
+		latency := float64(sample.latencies.percentile(99)) / float64(time.Millisecond)
+		return latency, latency > rule.Threshold, now
+	case AlertUnhealthy:
+		if !sample.unhealthy {
+			return 0, false, now
+		}
+		since := sample.since
+		if since.IsZero() || since.After(now) {
+			since = now
+		}
+		return now.Sub(since).Minutes(), true, since
+	case AlertAnomaly:
+		if sample.anomaly == 0 {
+			return 0, false, now
+		}
+		return sample.anomaly, sample.anomaly >= rule.Threshold, now
+	default:
+		return 0, false, now
+	}
+}
+
+// alertMessage describes the state of an alert
+func alertMessage(alert *Alert) string {
+	service := alert.ClusterID + "/" + alert.ServiceName
+	resolved := alert.State == AlertResolved
+
+	switch {
+	case alert.Metric == AlertErrorRate && resolved:
+		return fmt.Sprintf("%s resolved: error rate of %s is back under %.1f%%", alert.Rule, service, alert.Threshold)
+	case alert.Metric == AlertErrorRate:
+		return fmt.Sprintf("%s: error rate of %s is %.1f%%, above %.1f%%", alert.Rule, service, alert.Value, alert.Threshold)
+	case alert.Metric == AlertP99Latency && resolved:
+		return fmt.Sprintf("%s resolved: p99 latency of %s is back under %.0fms", alert.Rule, service, alert.Threshold)
+	case alert.Metric == AlertP99Latency:
+		return fmt.Sprintf("%s: p99 latency of %s is %.0fms, above %.0fms", alert.Rule, service, alert.Value, alert.Threshold)
+	case alert.Metric == AlertUnhealthy && resolved:
+		return fmt.Sprintf("%s resolved: %s is healthy again", alert.Rule, service)
+	case alert.Metric == AlertUnhealthy:
+		unhealthy := time.Duration(alert.Value * float64(time.Minute)).Round(time.Second)
+		return fmt.Sprintf("%s: %s has been unhealthy for %s", alert.Rule, service, unhealthy)
+	case alert.Metric == AlertAnomaly && resolved:
+		return fmt.Sprintf("%s resolved: no more anomalies of %s", alert.Rule, service)
+	case alert.Metric == AlertAnomaly:
+		return fmt.Sprintf("%s: anomaly of %s, %.1f standard deviations from its baseline", alert.Rule, service, alert.Value)
+	default:
+		return fmt.Sprintf("%s: %s of %s is %g", alert.Rule, alert.Metric, service, alert.Value)
+	}
+}
+
+// notify sends an alert to the channels of its rule in the background
+func (e *AlertEngine) notify(alert *Alert) {
+	var channels []string
+	for _, rule := range e.rules {
+		if rule.Name == alert.Rule {
+			channels = rule.Channels
+			break
+		}
+	}
+
+	for _, channel := range channels {
+		notifier, exists := e.notifiers[channel]
+		if !exists {
+			continue
+		}
+		e.notifyWait.Add(1)
+		go func(channel string, notifier Notifier) {
+			defer e.notifyWait.Done()
+
+			ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+			defer cancel()
+			if err := notifier.Notify(ctx, alert); err != nil {
+				logger.Error("Failed to send alert notification",
+					zap.String("rule", alert.Rule),
+					zap.String("channel", channel),
+					zap.Error(err),
+				)
+			}
+		}(channel, notifier)
+	}
+}
+
+// Wait waits for the notifications being sent
+func (e *AlertEngine) Wait() {
+	e.notifyWait.Wait()
+}
+
+// GetAlerts returns the pending and firing alerts, then the resolved ones, newest
+// first. A state only returns the alerts in it.
+func (e *AlertEngine) GetAlerts(state string) []*Alert {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	result := make([]*Alert, 0, len(e.active)+len(e.resolved))
+	if state == "" || state == AlertPending || state == AlertFiring {
+		active := make([]*Alert, 0, len(e.active))
+		for _, alert := range e.active {
+			if state == "" || alert.State == state {
+				copied := *alert
+				active = append(active, &copied)
+			}
+		}
+		sort.Slice(active, func(i, j int) bool { return active[i].ActiveSince.After(active[j].ActiveSince) })
+		result = append(result, active...)
+	}
+	if state == "" || state == AlertResolved {
+		for i := len(e.resolved) - 1; i >= 0; i-- {
+			copied := *e.resolved[i]
+			result = append(result, &copied)
+		}
+	}
+
+	return result
+}
+
+// ForgetCluster drops the baselines and anomalies of a deleted cluster. Its alerts
+// resolve at the next evaluation.
+func (e *AlertEngine) ForgetCluster(clusterID string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for key := range e.baselines {
+		if strings.HasPrefix(key, clusterID+"/") {
+			delete(e.baselines, key)
+		}
+	}
+	for key := range e.anomalies {
+		if strings.HasPrefix(key, clusterID+"/") {
+			delete(e.anomalies, key)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/akmadan/throome/pkg/adapters"
+)
+
+// recordingNotifier keeps the alerts it is sent
+type recordingNotifier struct {
+	alerts []*Alert
+	mu     sync.Mutex
+}
+
+func (n *recordingNotifier) Notify(ctx context.Context, alert *Alert) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.alerts = append(n.alerts, alert)
+	return nil
+}
+
+func (n *recordingNotifier) states() []string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	states := make([]string, 0, len(n.alerts))
+	for _, alert := range n.alerts {
+		states = append(states, alert.State)
+	}
+	return states
+}
+
+func TestAlertEngineErrorRate(t *testing.T) {
+	collector := &Collector{clusterMetrics: make(map[string]*ClusterMetrics)}
+	notifier := &recordingNotifier{}
+	rules := []AlertRule{{Name: "errors", Metric: AlertErrorRate, Threshold: 10, For: time.Minute, Channels: []string{"ops"}}}
+	engine := NewAlertEngine(rules, map[string]Notifier{"ops": notifier}, 10)
+
+	requests := func(succeeded, failed int) {
+		for i := 0; i < succeeded; i++ {
+			collector.updateServiceMetrics("c1", "db", "postgres", time.Millisecond, true)
+		}
+		for i := 0; i < failed; i++ {
+			collector.updateServiceMetrics("c1", "db", "postgres", time.Millisecond, false)
+		}
+	}
+	start := time.Now()
+	evaluate := func(after time.Duration) {
+		engine.Evaluate(collector, nil, start.Add(after))
+		engine.Wait()
+	}
+
+	// Failures before the first evaluation count, the rate is of each interval after
+	requests(5, 5)
+	evaluate(0)
+	if alerts := engine.GetAlerts(AlertPending); len(alerts) != 1 || alerts[0].Value != 50 {
+		t.Fatalf("Expected a pending alert at 50%%, got %+v", alerts)
+	}
+
+	requests(8, 2)
+	evaluate(time.Minute)
+	alerts := engine.GetAlerts(AlertFiring)
+	if len(alerts) != 1 || alerts[0].Value != 20 || alerts[0].FiredAt == nil {
+		t.Fatalf("Expected a firing alert at 20%% once the rule's duration passed, got %+v", alerts)
+	}
+	if !strings.Contains(alerts[0].Message, "error rate of c1/db is 20.0%") {
+		t.Errorf("Unexpected message %q", alerts[0].Message)
+	}
+
+	// The lifetime error rate is still above the threshold, that of the interval is not
+	requests(10, 0)
+	evaluate(2 * time.Minute)
+	if alerts := engine.GetAlerts(""); len(alerts) != 1 || alerts[0].State != AlertResolved || alerts[0].ResolvedAt == nil {
+		t.Fatalf("Expected the alert to resolve, got %+v", alerts)
+	}
+	if states := notifier.states(); strings.Join(states, ",") != "firing,resolved" {
+		t.Errorf("Expected firing and resolved notifications, got %v", states)
+	}
+}
+
+func TestAlertEngineLatencyAndHealth(t *testing.T) {
+	collector := &Collector{clusterMetrics: make(map[string]*ClusterMetrics)}
+	health := NewHealthChecker(time.Second, time.Second, 1)
+	rules := []AlertRule{
+		{Name: "slow", Metric: AlertP99Latency, Threshold: 100, ServiceName: "db"},
+		{Name: "down", Metric: AlertUnhealthy, For: 5 * time.Minute, ClusterID: "c1"},
+	}
+	engine := NewAlertEngine(rules, nil, 10)
+
+	var handled []string
+	engine.OnAlert(func(alert *Alert) {
+		handled = append(handled, alert.Rule+":"+alert.ServiceName+":"+alert.State)
+	})
+
+	for i := 0; i < 100; i++ {
+		collector.updateServiceMetrics("c1", "db", "postgres", 200*time.Millisecond, true)
+		collector.updateServiceMetrics("c1", "cache", "redis", 200*time.Millisecond, true)
+	}
+	start := time.Now()
+	health.recordHealthStatus("c1/cache", &adapters.HealthStatus{Healthy: false, LastChecked: start.Add(-6 * time.Minute)})
+
+	engine.Evaluate(collector, health, start)
+	if strings.Join(handled, ",") != "slow:db:firing,down:cache:firing" && strings.Join(handled, ",") != "down:cache:firing,slow:db:firing" {
+		t.Fatalf("Expected the slow db and the cache down for 6 minutes to fire, got %v", handled)
+	}
+
+	// Fast requests bring the p99 of the interval back down, a passing check the health
+	handled = nil
+	for i := 0; i < 100; i++ {
+		collector.updateServiceMetrics("c1", "db", "postgres", time.Millisecond, true)
+	}
+	health.recordHealthStatus("c1/cache", &adapters.HealthStatus{Healthy: true, LastChecked: start})
+	engine.Evaluate(collector, health, start.Add(time.Minute))
+	if len(handled) != 2 || !strings.HasSuffix(handled[0], ":resolved") || !strings.HasSuffix(handled[1], ":resolved") {
+		t.Errorf("Expected both alerts to resolve, got %v", handled)
+	}
+}
+
+func TestAlertEngineAnomaly(t *testing.T) {
+	rules := []AlertRule{{Name: "anomalous", Metric: AlertAnomaly, Threshold: 4}}
+	engine := NewAlertEngine(rules, nil, 10)
+
+	var handled []string
+	engine.OnAlert(func(alert *Alert) {
+		handled = append(handled, alert.ServiceName+":"+alert.State)
+	})
+
+	detector := NewAnomalyDetector(10)
+	detector.OnAnomaly(engine.RecordAnomaly)
+
+	// A latency spike after a steady baseline is flagged by the detector
+	settings := AnomalySettings{MinDataPoints: 5, Threshold: 3, Features: []string{FeatureLatency}}
+	metrics := &ClusterMetrics{ClusterID: "c1", ServiceMetrics: map[string]*ServiceMetrics{"db": {}}}
+	start := time.Now()
+	observe := func(step int, latency time.Duration) {
+		db := metrics.ServiceMetrics["db"]
+		db.AverageLatency = time.Duration((int64(db.AverageLatency)*db.TotalRequests + int64(latency)*100) / (db.TotalRequests + 100))
+		db.TotalRequests += 100
+		detector.Observe(metrics, settings, start.Add(time.Duration(step)*time.Minute))
+	}
+	for i := 0; i < 8; i++ {
+		observe(i, time.Duration(10+i%2)*time.Millisecond)
+	}
+	observe(8, time.Second)
+	if len(detector.GetAnomalies("c1", 0)) == 0 {
+		t.Fatal("Expected the detector to flag the latency spike")
+	}
+
+	// Anomalies below the rule's threshold do not alert
+	engine.RecordAnomaly(&Anomaly{ClusterID: "c1", ServiceName: "cache", Score: -3})
+
+	engine.Evaluate(nil, nil, start.Add(8*time.Minute))
+	alerts := engine.GetAlerts(AlertFiring)
+	if len(alerts) != 1 || alerts[0].ServiceName != "db" || alerts[0].Value < 4 {
+		t.Fatalf("Expected the anomaly of the db to fire, got %+v (handled %v)", alerts, handled)
+	}
+	if !strings.Contains(alerts[0].Message, "anomaly of c1/db") {
+		t.Errorf("Unexpected message %q", alerts[0].Message)
+	}
+
+	// Without anomalies since the previous evaluation, the alert resolves
+	engine.Evaluate(nil, nil, start.Add(9*time.Minute))
+	if strings.Join(handled, ",") != "db:firing,db:resolved" {
+		t.Errorf("Expected the alert to fire and resolve, got %v", handled)
+	}
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	var received Alert
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		_ = json.NewDecoder(r.Body).Decode(&received) //nolint:errcheck // Checked through the received alert
+	}))
+	defer server.Close()
+
+	notifier := &WebhookNotifier{URL: server.URL, Headers: map[string]string{"Authorization": "Bearer secret"}}
+	if err := notifier.Notify(context.Background(), &Alert{Rule: "errors", State: AlertFiring}); err != nil {
+		t.Fatalf("Failed to notify: %v", err)
+	}
+	if received.Rule != "errors" || received.State != AlertFiring || authorization != "Bearer secret" {
+		t.Errorf("Unexpected notification %+v with authorization %q", received, authorization)
+	}
+
+	rejecting := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer rejecting.Close()
+	if err := (&SlackNotifier{URL: rejecting.URL}).Notify(context.Background(), &Alert{}); err == nil {
+		t.Error("Expected an error for a rejected notification")
+	}
+}
@@ -0,0 +1,283 @@
+package monitor
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Anomaly features that can be enabled in a cluster's AI config
+const (
+	FeatureLatency    = "latency"
+	FeatureErrorRate  = "error_rate"
+	FeatureThroughput = "throughput"
+	featureLoad       = "load" // Alias for throughput used by older configs
+)
+
+// Anomaly represents a metric observation that deviates from a service's learned baseline
+type Anomaly struct {
+	ID          string    `json:"id"`
+	ClusterID   string    `json:"cluster_id"`
+	ServiceName string    `json:"service_name"`
+	ServiceType string    `json:"service_type"`
+	Metric      string    `json:"metric"`
+	Value       float64   `json:"value"`
+	Expected    float64   `json:"expected"`
+	StdDev      float64   `json:"std_dev"`
+	Score       float64   `json:"score"` // Signed number of standard deviations from the baseline
+	Explanation string    `json:"explanation"`
+	DetectedAt  time.Time `json:"detected_at"`
+}
+
+// AnomalyHandler is called for every anomaly the detector flags
+type AnomalyHandler func(anomaly *Anomaly)
+
+// AnomalySettings controls how a cluster's services are evaluated
+type AnomalySettings struct {
+	MinDataPoints int      // Samples required before a baseline is trusted
+	Threshold     float64  // Standard deviations from the mean that count as an anomaly
+	Features      []string // Metrics to evaluate
+}
+
+// AnomalyDetector learns normal per-service latency, error rate and throughput from
+// periodic metric snapshots and flags observations that deviate from them.
+// Baselines are running mean/variance estimates (Welford's algorithm) over one sample
+// per evaluation interval.
+type AnomalyDetector struct {
+	services     map[string]*serviceBaseline // clusterID/serviceName -> baseline
+	anomalies    []*Anomaly
+	maxAnomalies int
+	handlers     []AnomalyHandler
+	mu           sync.RWMutex
+}
+
+// serviceBaseline holds the previous snapshot and learned statistics for a service
+type serviceBaseline struct {
+	lastTotal      int64
+	lastFailed     int64
+	lastLatencySum float64
+	lastObserved   time.Time
+	metrics        map[string]*runningStats
+}
+
+// runningStats tracks the mean and variance of a metric
+type runningStats struct {
+	count int
+	mean  float64
+	m2    float64
+}
+
+func (rs *runningStats) add(value float64) {
+	rs.count++
+	delta := value - rs.mean
+	rs.mean += delta / float64(rs.count)
+	rs.m2 += delta * (value - rs.mean)
+}
+
+func (rs *runningStats) stdDev() float64 {
+	if rs.count < 2 {
+		return 0
+	}
+	return math.Sqrt(rs.m2 / float64(rs.count-1))
+}
+
+// NewAnomalyDetector creates a new anomaly detector that keeps the most recent maxAnomalies
+func NewAnomalyDetector(maxAnomalies int) *AnomalyDetector {
+	return &AnomalyDetector{
+		services:     make(map[string]*serviceBaseline),
+		anomalies:    make([]*Anomaly, 0, maxAnomalies),
+		maxAnomalies: maxAnomalies,
+	}
+}
+
+// OnAnomaly registers a handler that is called for each detected anomaly
+func (d *AnomalyDetector) OnAnomaly(handler AnomalyHandler) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.handlers = append(d.handlers, handler)
+}
+
+// Observe feeds a metrics snapshot for a cluster into the detector and returns the
+// anomalies found in it
+func (d *AnomalyDetector) Observe(metrics *ClusterMetrics, settings AnomalySettings, now time.Time) []*Anomaly {
+	if metrics == nil {
+		return nil
+	}
+
+	if settings.Threshold <= 0 {
+		settings.Threshold = 3.0
+	}
+	features := make(map[string]bool, len(settings.Features))
+	for _, feature := range settings.Features {
+		if feature == featureLoad {
+			feature = FeatureThroughput
+		}
+		features[feature] = true
+	}
+
+	d.mu.Lock()
+	var found []*Anomaly
+	for serviceName, svc := range metrics.ServiceMetrics {
+		key := metrics.ClusterID + "/" + serviceName
+		latencySum := float64(svc.AverageLatency) * float64(svc.TotalRequests)
+
+		baseline, exists := d.services[key]
+		if !exists {
+			d.services[key] = &serviceBaseline{
+				lastTotal:      svc.TotalRequests,
+				lastFailed:     svc.FailedRequests,
+				lastLatencySum: latencySum,
+				lastObserved:   now,
+				metrics:        make(map[string]*runningStats),
+			}
+			continue
+		}
+
+		elapsed := now.Sub(baseline.lastObserved).Seconds()
+		requests := svc.TotalRequests - baseline.lastTotal
+		failed := svc.FailedRequests - baseline.lastFailed
+		if elapsed <= 0 || requests < 0 {
+			// Metrics were reset; start over from this snapshot
+			requests = 0
+			elapsed = 0
+		}
+
+		samples := make(map[string]float64)
+		if elapsed > 0 {
+			samples[FeatureThroughput] = float64(requests) / elapsed
+		}
+		if requests > 0 {
+			samples[FeatureErrorRate] = float64(failed) / float64(requests) * 100
+			samples[FeatureLatency] = (latencySum - baseline.lastLatencySum) / float64(requests) / float64(time.Millisecond)
+		}
+
+		for metric, value := range samples {
+			if !features[metric] {
+				continue
+			}
+
+			stats, exists := baseline.metrics[metric]
+			if !exists {
+				stats = &runningStats{}
+				baseline.metrics[metric] = stats
+			}
+
+			if stats.count >= settings.MinDataPoints {
+				if anomaly := evaluateSample(metrics.ClusterID, serviceName, svc.ServiceType, metric, value, stats, settings.Threshold, now); anomaly != nil {
+					found = append(found, anomaly)
+				}
+			}
+
+			stats.add(value)
+		}
+
+		baseline.lastTotal = svc.TotalRequests
+		baseline.lastFailed = svc.FailedRequests
+		baseline.lastLatencySum = latencySum
+		baseline.lastObserved = now
+	}
+
+	for _, anomaly := range found {
+		if len(d.anomalies) >= d.maxAnomalies {
+			d.anomalies = d.anomalies[1:]
+		}
+		d.anomalies = append(d.anomalies, anomaly)
+	}
+	handlers := append([]AnomalyHandler(nil), d.handlers...)
+	d.mu.Unlock()
+
+	for _, anomaly := range found {
+		for _, handler := range handlers {
+			handler(anomaly)
+		}
+	}
+
+	return found
+}
+
+// evaluateSample compares a sample against its baseline and returns an anomaly if it
+// deviates by more than threshold standard deviations. Latency and error rate are only
+// flagged when they rise; throughput is flagged in both directions.
+func evaluateSample(clusterID, serviceName, serviceType, metric string, value float64, stats *runningStats, threshold float64, now time.Time) *Anomaly {
+	// Floor the deviation so perfectly stable baselines don't flag noise
+	stdDev := math.Max(stats.stdDev(), math.Max(math.Abs(stats.mean)*0.05, 1e-6))
+	score := (value - stats.mean) / stdDev
+
+	if math.Abs(score) < threshold {
+		return nil
+	}
+	if score < 0 && metric != FeatureThroughput {
+		return nil
+	}
+
+	direction := "above"
+	if score < 0 {
+		direction = "below"
+	}
+
+	return &Anomaly{
+		ID:          uuid.New().String(),
+		ClusterID:   clusterID,
+		ServiceName: serviceName,
+		ServiceType: serviceType,
+		Metric:      metric,
+		Value:       value,
+		Expected:    stats.mean,
+		StdDev:      stdDev,
+		Score:       score,
+		Explanation: fmt.Sprintf("%s %s is %.2f%s, %.1f standard deviations %s the learned baseline of %.2f%s (±%.2f over %d samples)",
+			serviceName, metric, value, metricUnit(metric), math.Abs(score), direction,
+			stats.mean, metricUnit(metric), stdDev, stats.count),
+		DetectedAt: now,
+	}
+}
+
+// metricUnit returns the display unit for an anomaly metric
+func metricUnit(metric string) string {
+	switch metric {
+	case FeatureLatency:
+		return "ms"
+	case FeatureErrorRate:
+		return "%"
+	case FeatureThroughput:
+		return " req/s"
+	default:
+		return ""
+	}
+}
+
+// GetAnomalies returns the most recent anomalies for a cluster, newest first.
+// An empty clusterID returns anomalies for all clusters.
+func (d *AnomalyDetector) GetAnomalies(clusterID string, limit int) []*Anomaly {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	result := make([]*Anomaly, 0)
+	for i := len(d.anomalies) - 1; i >= 0; i-- {
+		if limit > 0 && len(result) >= limit {
+			break
+		}
+		if clusterID == "" || d.anomalies[i].ClusterID == clusterID {
+			result = append(result, d.anomalies[i])
+		}
+	}
+
+	return result
+}
+
+// ForgetCluster drops all baselines learned for a cluster
+func (d *AnomalyDetector) ForgetCluster(clusterID string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	prefix := clusterID + "/"
+	for key := range d.services {
+		if len(key) > len(prefix) && key[:len(prefix)] == prefix {
+			delete(d.services, key)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package monitor
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAnomalyDetectorFlagsLatencySpike(t *testing.T) {
+	detector := NewAnomalyDetector(10)
+
+	settings := AnomalySettings{
+		MinDataPoints: 5,
+		Features:      []string{FeatureLatency, FeatureErrorRate},
+	}
+
+	var handled []*Anomaly
+	detector.OnAnomaly(func(anomaly *Anomaly) {
+		handled = append(handled, anomaly)
+	})
+
+	svc := &ServiceMetrics{ServiceName: "db", ServiceType: "postgres"}
+	metrics := &ClusterMetrics{
+		ClusterID:      "test-01",
+		ServiceMetrics: map[string]*ServiceMetrics{"db": svc},
+	}
+
+	start := time.Now()
+	latencySum := time.Duration(0)
+
+	// Record 100 requests per interval, alternating between 10ms and 12ms
+	observe := func(i int, latency time.Duration) []*Anomaly {
+		svc.TotalRequests += 100
+		latencySum += latency * 100
+		svc.AverageLatency = latencySum / time.Duration(svc.TotalRequests)
+		return detector.Observe(metrics, settings, start.Add(time.Duration(i)*time.Minute))
+	}
+
+	for i := 0; i < 10; i++ {
+		latency := 10 * time.Millisecond
+		if i%2 == 1 {
+			latency = 12 * time.Millisecond
+		}
+		if found := observe(i, latency); len(found) != 0 {
+			t.Fatalf("Expected no anomalies while learning, got %v", found[0].Explanation)
+		}
+	}
+
+	found := observe(10, 80*time.Millisecond)
+	if len(found) != 1 {
+		t.Fatalf("Expected 1 anomaly, got %d", len(found))
+	}
+	if found[0].Metric != FeatureLatency {
+		t.Errorf("Expected latency anomaly, got %s", found[0].Metric)
+	}
+	if found[0].Score <= 0 {
+		t.Errorf("Expected positive score, got %f", found[0].Score)
+	}
+	if len(handled) != 1 {
+		t.Errorf("Expected handler to be called once, got %d", len(handled))
+	}
+
+	if got := detector.GetAnomalies("test-01", 0); len(got) != 1 {
+		t.Errorf("Expected 1 stored anomaly, got %d", len(got))
+	}
+	if got := detector.GetAnomalies("other", 0); len(got) != 0 {
+		t.Errorf("Expected no anomalies for other cluster, got %d", len(got))
+	}
+}
+
+func TestAnomalyDetectorIgnoresLatencyDrop(t *testing.T) {
+	stats := &runningStats{}
+	for _, v := range []float64{50, 52, 48, 50} {
+		stats.add(v)
+	}
+
+	if anomaly := evaluateSample("c", "db", "postgres", FeatureLatency, 1, stats, 3, time.Now()); anomaly != nil {
+		t.Errorf("Expected latency drop not to be flagged, got %s", anomaly.Explanation)
+	}
+	if anomaly := evaluateSample("c", "db", "postgres", FeatureThroughput, 1, stats, 3, time.Now()); anomaly == nil {
+		t.Error("Expected throughput drop to be flagged")
+	}
+}
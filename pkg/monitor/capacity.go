@@ -0,0 +1,249 @@
+package monitor
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/akmadan/throome/pkg/adapters"
+	"github.com/akmadan/throome/pkg/cluster"
+)
+
+// Capacity planning thresholds
+const (
+	highUtilization  = 0.8           // Peak pool utilization above which the pool should grow
+	lowUtilization   = 0.2           // Peak pool utilization below which the pool can shrink
+	forecastHorizon  = 1 * time.Hour // How far ahead pool exhaustion is forecast
+	minPlanSamples   = 6             // Samples required before recommendations are made
+	maxPlanSamples   = 360           // Samples kept per service (1 hour at a 10s interval)
+	growthHeadroom   = 1.5           // Multiplier applied to peak usage when growing a pool
+	shrinkHeadroom   = 2.0           // Multiplier applied to peak usage when shrinking a pool
+	minRecommendPool = 2             // Smallest max pool size ever recommended
+)
+
+// PoolSample is a single observation of a connection pool
+type PoolSample struct {
+	Timestamp         time.Time
+	AcquiredConns     int
+	MaxConns          int
+	EmptyAcquireCount int64
+}
+
+// PoolForecast describes the projected growth of pool usage
+type PoolForecast struct {
+	GrowthPerHour float64        `json:"growth_per_hour"` // Acquired connections gained per hour
+	ExhaustionIn  *time.Duration `json:"exhaustion_in,omitempty"`
+}
+
+// Recommendation is a suggested configuration change for a service
+type Recommendation struct {
+	ServiceName     string             `json:"service_name"`
+	ServiceType     string             `json:"service_type"`
+	Kind            string             `json:"kind"` // pool_size
+	Severity        string             `json:"severity"`
+	Current         cluster.PoolConfig `json:"current"`
+	Recommended     cluster.PoolConfig `json:"recommended"`
+	Reason          string             `json:"reason"`
+	PeakUtilization float64            `json:"peak_utilization"` // Percentage
+	AvgUtilization  float64            `json:"avg_utilization"`  // Percentage
+	Forecast        PoolForecast       `json:"forecast"`
+	Samples         int                `json:"samples"`
+}
+
+// CapacityPlanner records connection pool usage over time and recommends pool sizes.
+// Growth is forecast with a least-squares linear fit of acquired connections over time.
+type CapacityPlanner struct {
+	samples map[string][]PoolSample // clusterID/serviceName -> samples
+	mu      sync.RWMutex
+}
+
+// NewCapacityPlanner creates a new capacity planner
+func NewCapacityPlanner() *CapacityPlanner {
+	return &CapacityPlanner{
+		samples: make(map[string][]PoolSample),
+	}
+}
+
+// Record stores a pool statistics sample for a service
+func (p *CapacityPlanner) Record(clusterID, serviceName string, stats *adapters.PoolStats, now time.Time) {
+	if stats == nil {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	key := clusterID + "/" + serviceName
+	samples := append(p.samples[key], PoolSample{
+		Timestamp:         now,
+		AcquiredConns:     stats.AcquiredConns,
+		MaxConns:          stats.MaxConns,
+		EmptyAcquireCount: stats.EmptyAcquireCount,
+	})
+	if len(samples) > maxPlanSamples {
+		samples = samples[len(samples)-maxPlanSamples:]
+	}
+	p.samples[key] = samples
+}
+
+// ForgetCluster drops all samples recorded for a cluster
+func (p *CapacityPlanner) ForgetCluster(clusterID string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	prefix := clusterID + "/"
+	for key := range p.samples {
+		if len(key) > len(prefix) && key[:len(prefix)] == prefix {
+			delete(p.samples, key)
+		}
+	}
+}
+
+// ForgetService drops the samples of a service removed from a cluster
+func (p *CapacityPlanner) ForgetService(clusterID, serviceName string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	delete(p.samples, clusterID+"/"+serviceName)
+}
+
+// Recommend returns pool recommendations for the services of a cluster
+func (p *CapacityPlanner) Recommend(config *cluster.Config) []*Recommendation {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	recommendations := make([]*Recommendation, 0)
+	for serviceName, serviceConfig := range config.Services {
+		samples := p.samples[config.ClusterID+"/"+serviceName]
+		if rec := recommendPool(serviceName, serviceConfig, samples); rec != nil {
+			recommendations = append(recommendations, rec)
+		}
+	}
+
+	return recommendations
+}
+
+// recommendPool analyses the samples of a single service
+func recommendPool(serviceName string, serviceConfig cluster.ServiceConfig, samples []PoolSample) *Recommendation {
+	if len(samples) < minPlanSamples {
+		return nil
+	}
+
+	latest := samples[len(samples)-1]
+	maxConns := latest.MaxConns
+	if maxConns <= 0 {
+		return nil
+	}
+
+	peak := 0
+	total := 0
+	for _, sample := range samples {
+		if sample.AcquiredConns > peak {
+			peak = sample.AcquiredConns
+		}
+		total += sample.AcquiredConns
+	}
+	peakUtil := float64(peak) / float64(maxConns)
+	avgUtil := float64(total) / float64(len(samples)) / float64(maxConns)
+	waits := latest.EmptyAcquireCount - samples[0].EmptyAcquireCount
+
+	forecast := forecastPool(samples)
+
+	current := serviceConfig.Pool
+	if current.MaxConnections == 0 {
+		current.MaxConnections = maxConns
+	}
+
+	rec := &Recommendation{
+		ServiceName:     serviceName,
+		ServiceType:     serviceConfig.Type,
+		Kind:            "pool_size",
+		Current:         current,
+		Recommended:     current,
+		PeakUtilization: peakUtil * 100,
+		AvgUtilization:  avgUtil * 100,
+		Forecast:        forecast,
+		Samples:         len(samples),
+	}
+
+	switch {
+	case forecast.ExhaustionIn != nil && *forecast.ExhaustionIn <= forecastHorizon:
+		projected := float64(latest.AcquiredConns) + forecast.GrowthPerHour*forecastHorizon.Hours()
+		rec.Severity = "critical"
+		rec.Recommended.MaxConnections = int(math.Ceil(projected * growthHeadroom))
+		rec.Reason = fmt.Sprintf("pool usage is growing by %.1f connections/hour and will exhaust %d connections in %s",
+			forecast.GrowthPerHour, maxConns, forecast.ExhaustionIn.Round(time.Minute))
+
+	case peakUtil >= highUtilization || waits > 0:
+		rec.Severity = "warning"
+		rec.Recommended.MaxConnections = int(math.Ceil(float64(peak) * growthHeadroom))
+		if waits > 0 {
+			rec.Reason = fmt.Sprintf("%d acquires waited for a free connection; peak usage was %d of %d connections",
+				waits, peak, maxConns)
+		} else {
+			rec.Reason = fmt.Sprintf("peak usage reached %.0f%% of the pool (%d of %d connections)",
+				peakUtil*100, peak, maxConns)
+		}
+
+	case peakUtil <= lowUtilization && maxConns > minRecommendPool:
+		rec.Severity = "info"
+		rec.Recommended.MaxConnections = int(math.Ceil(float64(peak) * shrinkHeadroom))
+		rec.Reason = fmt.Sprintf("peak usage was only %.0f%% of the pool (%d of %d connections); the pool can be reduced",
+			peakUtil*100, peak, maxConns)
+
+	default:
+		return nil
+	}
+
+	if rec.Recommended.MaxConnections < minRecommendPool {
+		rec.Recommended.MaxConnections = minRecommendPool
+	}
+	if rec.Recommended.MaxConnections <= maxConns && rec.Severity != "info" {
+		rec.Recommended.MaxConnections = maxConns + 1
+	}
+	if rec.Recommended.MinConnections > rec.Recommended.MaxConnections {
+		rec.Recommended.MinConnections = rec.Recommended.MaxConnections
+	}
+	if rec.Recommended.MaxConnections == current.MaxConnections {
+		return nil
+	}
+
+	return rec
+}
+
+// forecastPool fits a line through acquired connections over time and projects
+// when the pool will be exhausted
+func forecastPool(samples []PoolSample) PoolForecast {
+	origin := samples[0].Timestamp
+	n := float64(len(samples))
+
+	var sumX, sumY, sumXY, sumXX float64
+	for _, sample := range samples {
+		x := sample.Timestamp.Sub(origin).Seconds()
+		y := float64(sample.AcquiredConns)
+		sumX += x
+		sumY += y
+		sumXY += x * y
+		sumXX += x * x
+	}
+
+	denominator := n*sumXX - sumX*sumX
+	if denominator == 0 {
+		return PoolForecast{}
+	}
+
+	slope := (n*sumXY - sumX*sumY) / denominator // connections per second
+	forecast := PoolForecast{GrowthPerHour: slope * time.Hour.Seconds()}
+
+	latest := samples[len(samples)-1]
+	if slope > 0 && latest.MaxConns > 0 {
+		intercept := (sumY - slope*sumX) / n
+		fitted := intercept + slope*latest.Timestamp.Sub(origin).Seconds()
+		remaining := float64(latest.MaxConns) - fitted
+		exhaustion := time.Duration(math.Max(remaining, 0) / slope * float64(time.Second))
+		forecast.ExhaustionIn = &exhaustion
+	}
+
+	return forecast
+}
@@ -0,0 +1,76 @@
+package monitor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/akmadan/throome/pkg/adapters"
+	"github.com/akmadan/throome/pkg/cluster"
+)
+
+func TestCapacityPlannerForecastsExhaustion(t *testing.T) {
+	planner := NewCapacityPlanner()
+	config := &cluster.Config{
+		ClusterID: "test-01",
+		Services: map[string]cluster.ServiceConfig{
+			"db": {Type: "postgres", Pool: cluster.PoolConfig{MaxConnections: 20}},
+		},
+	}
+
+	// Usage grows by one connection every minute
+	start := time.Now()
+	for i := 0; i < 10; i++ {
+		planner.Record("test-01", "db", &adapters.PoolStats{
+			AcquiredConns: 5 + i,
+			MaxConns:      20,
+		}, start.Add(time.Duration(i)*time.Minute))
+	}
+
+	recs := planner.Recommend(config)
+	if len(recs) != 1 {
+		t.Fatalf("Expected 1 recommendation, got %d", len(recs))
+	}
+
+	rec := recs[0]
+	if rec.Severity != "critical" {
+		t.Errorf("Expected critical severity, got %s", rec.Severity)
+	}
+	if rec.Forecast.ExhaustionIn == nil {
+		t.Fatal("Expected an exhaustion forecast")
+	}
+	if got := rec.Forecast.ExhaustionIn.Round(time.Minute); got != 6*time.Minute {
+		t.Errorf("Expected exhaustion in 6m, got %s", got)
+	}
+	if rec.Recommended.MaxConnections <= 20 {
+		t.Errorf("Expected larger pool, got %d", rec.Recommended.MaxConnections)
+	}
+}
+
+func TestCapacityPlannerSuggestsShrinking(t *testing.T) {
+	planner := NewCapacityPlanner()
+	config := &cluster.Config{
+		ClusterID: "test-01",
+		Services: map[string]cluster.ServiceConfig{
+			"cache": {Type: "redis"},
+		},
+	}
+
+	start := time.Now()
+	for i := 0; i < minPlanSamples; i++ {
+		planner.Record("test-01", "cache", &adapters.PoolStats{
+			AcquiredConns: 1,
+			MaxConns:      50,
+		}, start.Add(time.Duration(i)*10*time.Second))
+	}
+
+	recs := planner.Recommend(config)
+	if len(recs) != 1 {
+		t.Fatalf("Expected 1 recommendation, got %d", len(recs))
+	}
+	if recs[0].Severity != "info" {
+		t.Errorf("Expected info severity, got %s", recs[0].Severity)
+	}
+	if recs[0].Recommended.MaxConnections != minRecommendPool {
+		t.Errorf("Expected pool of %d, got %d", minRecommendPool, recs[0].Recommended.MaxConnections)
+	}
+}
@@ -2,23 +2,35 @@ package monitor
 
 import (
 	"context"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/akmadan/throome/internal/logger"
 	"github.com/akmadan/throome/pkg/adapters"
+	"github.com/akmadan/throome/pkg/cluster"
 	"go.uber.org/zap"
 )
 
 // HealthChecker performs periodic health checks on adapters
 type HealthChecker struct {
-	interval  time.Duration
-	timeout   time.Duration
-	threshold int
-	running   bool
-	mu        sync.RWMutex
-	stopChan  chan struct{}
-	statuses  map[string]*HealthHistory
+	interval   time.Duration
+	timeout    time.Duration
+	threshold  int
+	running    bool
+	mu         sync.RWMutex
+	stopChan   chan struct{}
+	statuses   map[string]*HealthHistory
+	probes     map[string][]cluster.ProbeConfig // name -> application-level probes
+	watches    map[string]*healthWatch          // clusterID -> periodic checks of the cluster's services
+	thresholds map[string]int                   // clusterID -> consecutive failures of the cluster's threshold
+	handlers   []HealthTransitionHandler
+}
+
+// healthWatch is the periodic checking of the services of a cluster
+type healthWatch struct {
+	config cluster.HealthConfig
+	cancel context.CancelFunc
 }
 
 // HealthHistory tracks health check history for an adapter
@@ -28,21 +40,62 @@ type HealthHistory struct {
 	ConsecutiveSuccess int
 	LastHealthy        time.Time
 	LastUnhealthy      time.Time
+	UnhealthySince     time.Time // When the service turned unhealthy, zero while it is healthy
+	FirstChecked       time.Time
 	TotalChecks        int64
 	FailedChecks       int64
 	History            []adapters.HealthStatus
+	Incidents          []Incident // Oldest first, the last one is ongoing while the service is unhealthy
+}
+
+// HealthTransition reports a service turning unhealthy, after threshold consecutive
+// failed checks, or healthy again, on its first passing check after that
+type HealthTransition struct {
+	ServiceName      string                `json:"service_name"`
+	Healthy          bool                  `json:"healthy"`
+	ConsecutiveFails int                   `json:"consecutive_fails"`
+	Status           adapters.HealthStatus `json:"status"` // The check that caused the transition
+	Timestamp        time.Time             `json:"timestamp"`
 }
 
+// HealthTransitionHandler is called for every health transition
+type HealthTransitionHandler func(transition *HealthTransition)
+
 // NewHealthChecker creates a new health checker
 func NewHealthChecker(interval, timeout time.Duration, threshold int) *HealthChecker {
 	return &HealthChecker{
-		interval:  interval,
-		timeout:   timeout,
-		threshold: threshold,
-		running:   false,
-		stopChan:  make(chan struct{}),
-		statuses:  make(map[string]*HealthHistory),
+		interval:   interval,
+		timeout:    timeout,
+		threshold:  threshold,
+		running:    false,
+		stopChan:   make(chan struct{}),
+		statuses:   make(map[string]*HealthHistory),
+		probes:     make(map[string][]cluster.ProbeConfig),
+		watches:    make(map[string]*healthWatch),
+		thresholds: make(map[string]int),
+	}
+}
+
+// SetProbes sets the application-level probes run after the connectivity check of an
+// adapter. Passing no probes removes them.
+func (h *HealthChecker) SetProbes(name string, probes []cluster.ProbeConfig) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if len(probes) == 0 {
+		delete(h.probes, name)
+		return
 	}
+	h.probes[name] = probes
+}
+
+// OnTransition registers a handler that is called whenever a service turns
+// healthy or unhealthy
+func (h *HealthChecker) OnTransition(handler HealthTransitionHandler) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.handlers = append(h.handlers, handler)
 }
 
 // Start starts the health checker
@@ -69,16 +122,20 @@ func (h *HealthChecker) Start(ctx context.Context, adapterMap map[string]adapter
 			logger.Info("Health checker context cancelled")
 			return
 		case <-ticker.C:
-			h.performHealthChecks(ctx, adapterMap)
+			h.performHealthChecks(ctx, adapterMap, h.timeout, h.recordHealthStatus)
 		}
 	}
 }
 
-// Stop stops the health checker
+// Stop stops the health checker and the checks of every watched cluster
 func (h *HealthChecker) Stop() {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 
+	for clusterID := range h.watches {
+		h.unwatch(clusterID)
+	}
+
 	if !h.running {
 		return
 	}
@@ -87,9 +144,123 @@ func (h *HealthChecker) Stop() {
 	h.running = false
 }
 
-// performHealthChecks performs health checks on all adapters
-func (h *HealthChecker) performHealthChecks(ctx context.Context, adapterMap map[string]adapters.Adapter) {
-	checkCtx, cancel := context.WithTimeout(ctx, h.timeout)
+// Watch checks the services of a cluster in a goroutine of its own, first right away
+// and then every interval of its health settings, until Unwatch or Stop. The services
+// are listed again before every round so the checks follow changes to the cluster,
+// and their statuses are recorded as clusterID/serviceName. Watching a cluster again
+// restarts its checks only when its settings changed. Settings left at zero take the
+// checker's own.
+func (h *HealthChecker) Watch(clusterID string, config cluster.HealthConfig, services func() map[string]adapters.Adapter) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if current, exists := h.watches[clusterID]; exists {
+		if current.config == config {
+			return
+		}
+		h.unwatch(clusterID)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	watch := &healthWatch{config: config, cancel: cancel}
+	h.watches[clusterID] = watch
+	if config.Threshold > 0 {
+		h.thresholds[clusterID] = config.Threshold
+	} else {
+		delete(h.thresholds, clusterID)
+	}
+
+	interval := h.interval
+	if config.Interval > 0 {
+		interval = time.Duration(config.Interval) * time.Second
+	}
+	timeout := h.timeout
+	if config.Timeout > 0 {
+		timeout = time.Duration(config.Timeout) * time.Second
+	}
+
+	record := func(name string, status *adapters.HealthStatus) {
+		h.recordWatched(name, status, watch)
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			checks := make(map[string]adapters.Adapter)
+			for serviceName, adapter := range services() {
+				checks[clusterID+"/"+serviceName] = adapter
+			}
+			h.performHealthChecks(ctx, checks, timeout, record)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	logger.Debug("Watching cluster health",
+		zap.String("cluster_id", clusterID),
+		zap.Duration("interval", interval),
+	)
+}
+
+// Unwatch stops checking the services of a cluster. Their health and the cluster's
+// threshold are kept until ForgetCluster.
+func (h *HealthChecker) Unwatch(clusterID string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.unwatch(clusterID)
+}
+
+// unwatch stops checking the services of a cluster. The caller must hold the lock.
+func (h *HealthChecker) unwatch(clusterID string) {
+	if watch, exists := h.watches[clusterID]; exists {
+		watch.cancel()
+		delete(h.watches, clusterID)
+	}
+}
+
+// ForgetService drops the health of a service that was removed from its cluster
+func (h *HealthChecker) ForgetService(clusterID, serviceName string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	delete(h.statuses, clusterID+"/"+serviceName)
+}
+
+// ForgetCluster drops the health of every service of a cluster
+func (h *HealthChecker) ForgetCluster(clusterID string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	delete(h.thresholds, clusterID)
+	prefix := clusterID + "/"
+	for name := range h.statuses {
+		if strings.HasPrefix(name, prefix) {
+			delete(h.statuses, name)
+		}
+	}
+}
+
+// thresholdOf returns the consecutive failed checks after which a service is unhealthy:
+// that of the health settings its cluster was watched with, or the checker's. The
+// caller must hold the lock.
+func (h *HealthChecker) thresholdOf(name string) int {
+	clusterID, _, _ := strings.Cut(name, "/")
+	if threshold, exists := h.thresholds[clusterID]; exists {
+		return threshold
+	}
+	return h.threshold
+}
+
+// performHealthChecks checks all adapters concurrently and records their statuses
+func (h *HealthChecker) performHealthChecks(ctx context.Context, adapterMap map[string]adapters.Adapter, timeout time.Duration, record func(name string, status *adapters.HealthStatus)) {
+	checkCtx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
 	var wg sync.WaitGroup
@@ -98,7 +269,9 @@ func (h *HealthChecker) performHealthChecks(ctx context.Context, adapterMap map[
 		wg.Add(1)
 		go func(name string, adapter adapters.Adapter) {
 			defer wg.Done()
-			h.checkAdapter(checkCtx, name, adapter)
+			if status := h.checkAdapter(checkCtx, name, adapter, timeout); ctx.Err() == nil {
+				record(name, status)
+			}
 		}(name, adapter)
 	}
 
@@ -106,8 +279,12 @@ func (h *HealthChecker) performHealthChecks(ctx context.Context, adapterMap map[
 }
 
 // checkAdapter performs a health check on a single adapter
-func (h *HealthChecker) checkAdapter(ctx context.Context, name string, adapter adapters.Adapter) {
-	status, err := adapter.HealthCheck(ctx)
+func (h *HealthChecker) checkAdapter(ctx context.Context, name string, adapter adapters.Adapter, timeout time.Duration) *adapters.HealthStatus {
+	h.mu.RLock()
+	probes := h.probes[name]
+	h.mu.RUnlock()
+
+	status, err := adapters.CheckHealth(ctx, adapter, probes, timeout)
 	if err != nil {
 		logger.Error("Health check failed",
 			zap.String("service", name),
@@ -120,14 +297,49 @@ func (h *HealthChecker) checkAdapter(ctx context.Context, name string, adapter a
 		}
 	}
 
-	h.recordHealthStatus(name, status)
+	if status.Reachable && !status.Healthy {
+		logger.Warn("Service is reachable but failing probes",
+			zap.String("service", name),
+			zap.String("error", status.ErrorMessage),
+		)
+	}
+
+	if status.LastChecked.IsZero() {
+		status.LastChecked = time.Now()
+	}
+	return status
 }
 
-// recordHealthStatus records a health status
+// recordHealthStatus records a health status, notifying the transition handlers if
+// it changes whether the service counts as healthy
 func (h *HealthChecker) recordHealthStatus(name string, status *adapters.HealthStatus) {
+	h.recordWatched(name, status, nil)
+}
+
+// recordWatched records a health status like recordHealthStatus. When a watch is
+// given, the status is dropped unless it is still the watch of the service's cluster,
+// so checks in flight when a cluster is unwatched are not recorded.
+func (h *HealthChecker) recordWatched(name string, status *adapters.HealthStatus, watch *healthWatch) {
 	h.mu.Lock()
-	defer h.mu.Unlock()
+	if clusterID, _, _ := strings.Cut(name, "/"); watch != nil && h.watches[clusterID] != watch {
+		h.mu.Unlock()
+		return
+	}
+	transition := h.record(name, status)
+	handlers := h.handlers
+	h.mu.Unlock()
+
+	if transition == nil {
+		return
+	}
+	for _, handler := range handlers {
+		handler(transition)
+	}
+}
 
+// record records a health status and returns the transition it caused, if any.
+// The caller must hold the lock.
+func (h *HealthChecker) record(name string, status *adapters.HealthStatus) *HealthTransition {
 	history, exists := h.statuses[name]
 	if !exists {
 		history = &HealthHistory{
@@ -137,7 +349,12 @@ func (h *HealthChecker) recordHealthStatus(name string, status *adapters.HealthS
 		h.statuses[name] = history
 	}
 
+	threshold := h.thresholdOf(name)
+	wasHealthy := history.ConsecutiveFails < threshold
 	history.TotalChecks++
+	if history.FirstChecked.IsZero() {
+		history.FirstChecked = status.LastChecked
+	}
 
 	if status.Healthy {
 		history.ConsecutiveSuccess++
@@ -150,7 +367,7 @@ func (h *HealthChecker) recordHealthStatus(name string, status *adapters.HealthS
 		history.LastUnhealthy = status.LastChecked
 
 		// Log if threshold exceeded
-		if history.ConsecutiveFails >= h.threshold {
+		if history.ConsecutiveFails >= threshold {
 			logger.Warn("Service unhealthy threshold exceeded",
 				zap.String("service", name),
 				zap.Int("consecutive_fails", history.ConsecutiveFails),
@@ -163,14 +380,71 @@ func (h *HealthChecker) recordHealthStatus(name string, status *adapters.HealthS
 	if len(history.History) > 100 {
 		history.History = history.History[1:]
 	}
+
+	healthy := history.ConsecutiveFails < threshold
+	if healthy == wasHealthy {
+		return nil
+	}
+	transition := &HealthTransition{
+		ServiceName:      name,
+		Healthy:          healthy,
+		ConsecutiveFails: history.ConsecutiveFails,
+		Status:           *status,
+		Timestamp:        status.LastChecked,
+	}
+	if transition.Timestamp.IsZero() {
+		transition.Timestamp = time.Now()
+	}
+	if healthy {
+		history.UnhealthySince = time.Time{}
+		history.closeIncident(transition.Timestamp)
+	} else {
+		history.UnhealthySince = transition.Timestamp
+		history.openIncident(transition.Timestamp, status.ErrorMessage)
+	}
+	return transition
 }
 
-// GetHealthHistory returns health history for a service
+// GetHealthHistory returns a copy of the health history of a service, or nil if it
+// has not been checked yet
 func (h *HealthChecker) GetHealthHistory(name string) *HealthHistory {
 	h.mu.RLock()
 	defer h.mu.RUnlock()
 
-	return h.statuses[name]
+	history, exists := h.statuses[name]
+	if !exists {
+		return nil
+	}
+
+	historyCopy := *history
+	historyCopy.History = append([]adapters.HealthStatus(nil), history.History...)
+	historyCopy.Incidents = append([]Incident(nil), history.Incidents...)
+	return &historyCopy
+}
+
+// LastStatus returns the last check of a service with its consecutive failures, and
+// false if it has not been checked yet
+func (h *HealthChecker) LastStatus(name string) (*adapters.HealthStatus, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	history, exists := h.statuses[name]
+	if !exists || len(history.History) == 0 {
+		return nil, false
+	}
+
+	status := history.History[len(history.History)-1]
+	status.ConsecutiveFails = history.ConsecutiveFails
+	return &status, true
+}
+
+// UptimePercent returns the percentage of checks the service passed, or 100 before
+// its first check
+func (h *HealthHistory) UptimePercent() float64 {
+	if h.TotalChecks == 0 {
+		return 100
+	}
+	return float64(h.TotalChecks-h.FailedChecks) / float64(h.TotalChecks) * 100
 }
 
 // GetAllHealthHistories returns all health histories
@@ -196,5 +470,20 @@ func (h *HealthChecker) IsHealthy(name string) bool {
 		return true // Assume healthy if no history
 	}
 
-	return history.ConsecutiveFails < h.threshold
+	return history.ConsecutiveFails < h.thresholdOf(name)
+}
+
+// UnhealthyServices returns when each service that is unhealthy turned unhealthy
+func (h *HealthChecker) UnhealthyServices() map[string]time.Time {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	result := make(map[string]time.Time)
+	for name, history := range h.statuses {
+		if history.ConsecutiveFails >= h.thresholdOf(name) {
+			result[name] = history.UnhealthySince
+		}
+	}
+
+	return result
 }
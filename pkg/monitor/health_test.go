@@ -0,0 +1,124 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/akmadan/throome/pkg/adapters"
+	"github.com/akmadan/throome/pkg/cluster"
+)
+
+func TestHealthCheckerTransitions(t *testing.T) {
+	checker := NewHealthChecker(time.Second, time.Second, 3)
+
+	var transitions []*HealthTransition
+	checker.OnTransition(func(transition *HealthTransition) {
+		transitions = append(transitions, transition)
+	})
+
+	record := func(healthy bool) {
+		checker.recordHealthStatus("c1/db", &adapters.HealthStatus{Healthy: healthy, LastChecked: time.Now()})
+	}
+
+	record(true)
+	record(false)
+	record(false)
+	if len(transitions) != 0 {
+		t.Fatalf("Expected no transition below the threshold, got %d", len(transitions))
+	}
+
+	record(false)
+	if len(transitions) != 1 || transitions[0].Healthy || transitions[0].ConsecutiveFails != 3 || transitions[0].ServiceName != "c1/db" {
+		t.Fatalf("Expected an unhealthy transition at the threshold, got %+v", transitions)
+	}
+
+	record(false)
+	if len(transitions) != 1 {
+		t.Errorf("Expected no transition while staying unhealthy, got %d", len(transitions))
+	}
+
+	record(true)
+	if len(transitions) != 2 || !transitions[1].Healthy {
+		t.Fatalf("Expected a healthy transition on recovery, got %+v", transitions)
+	}
+	if !checker.IsHealthy("c1/db") {
+		t.Error("Expected the service to be healthy after the transition")
+	}
+}
+
+func TestHealthHistory(t *testing.T) {
+	checker := NewHealthChecker(time.Second, time.Second, 2)
+	if history := checker.GetHealthHistory("c1/db"); history != nil {
+		t.Fatalf("Expected no history before the first check, got %+v", history)
+	}
+
+	for _, healthy := range []bool{true, true, false, true} {
+		checker.recordHealthStatus("c1/db", &adapters.HealthStatus{Healthy: healthy, LastChecked: time.Now()})
+	}
+
+	history := checker.GetHealthHistory("c1/db")
+	if len(history.History) != 4 || history.TotalChecks != 4 || history.FailedChecks != 1 {
+		t.Fatalf("Expected 4 checks with 1 failure, got %+v", history)
+	}
+	if uptime := history.UptimePercent(); uptime != 75 {
+		t.Errorf("Expected 75%% uptime, got %v", uptime)
+	}
+
+	// The history is a copy, so later checks do not change it
+	checker.recordHealthStatus("c1/db", &adapters.HealthStatus{Healthy: false, LastChecked: time.Now()})
+	if len(history.History) != 4 || history.ConsecutiveFails != 0 {
+		t.Errorf("Expected the copy to be unchanged, got %+v", history)
+	}
+	if fails := checker.GetHealthHistory("c1/db").ConsecutiveFails; fails != 1 {
+		t.Errorf("Expected 1 consecutive failure, got %d", fails)
+	}
+}
+
+// failingAdapter is an adapter whose health checks fail
+type failingAdapter struct {
+	*adapters.BaseAdapter
+}
+
+func (f *failingAdapter) Connect(ctx context.Context) error    { return nil }
+func (f *failingAdapter) Disconnect(ctx context.Context) error { return nil }
+func (f *failingAdapter) Ping(ctx context.Context) error       { return errors.New("unreachable") }
+func (f *failingAdapter) HealthCheck(ctx context.Context) (*adapters.HealthStatus, error) {
+	return &adapters.HealthStatus{Healthy: false, ErrorMessage: "unreachable"}, nil
+}
+
+func TestHealthCheckerWatch(t *testing.T) {
+	checker := NewHealthChecker(time.Hour, time.Second, 3)
+	adapter := &failingAdapter{BaseAdapter: adapters.NewBaseAdapter(&cluster.ServiceConfig{Type: "redis"})}
+
+	transitions := make(chan *HealthTransition, 1)
+	checker.OnTransition(func(transition *HealthTransition) { transitions <- transition })
+
+	// The cluster's threshold applies, and the first round runs right away
+	checker.Watch("c1", cluster.HealthConfig{Enabled: true, Threshold: 1}, func() map[string]adapters.Adapter {
+		return map[string]adapters.Adapter{"cache": adapter}
+	})
+	defer checker.Stop()
+
+	select {
+	case transition := <-transitions:
+		if transition.ServiceName != "c1/cache" || transition.Healthy {
+			t.Fatalf("Expected c1/cache to turn unhealthy, got %+v", transition)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the first check to run when the cluster is watched")
+	}
+	if status, checked := checker.LastStatus("c1/cache"); !checked || status.ConsecutiveFails != 1 || status.LastChecked.IsZero() {
+		t.Errorf("Expected the last check with its failure, got %+v", status)
+	}
+
+	checker.Unwatch("c1")
+	if checker.IsHealthy("c1/cache") {
+		t.Error("Expected the health to be kept after the cluster is unwatched")
+	}
+	checker.ForgetCluster("c1")
+	if !checker.IsHealthy("c1/cache") || checker.GetHealthHistory("c1/cache") != nil {
+		t.Error("Expected the health of the cluster to be forgotten")
+	}
+}
@@ -0,0 +1,102 @@
+package monitor
+
+import (
+	"math"
+	"math/bits"
+	"time"
+)
+
+// Layout of latency histograms. Latencies under histogramLinear microseconds get a
+// bucket each; above, every power of two is split into 1<<histogramSubBits buckets,
+// so a bucket is never wider than 1/64th of the latencies it holds.
+const (
+	histogramSubBits = 6
+	histogramLinear  = 2 << histogramSubBits
+	histogramBuckets = histogramLinear + 30<<histogramSubBits // Up to 2^37µs, about 38 hours
+)
+
+// latencyHistogram counts latencies in log-linear buckets, like an HDR histogram, so
+// percentiles stay within 1.6% of the exact value however widely latencies spread,
+// in a fixed amount of memory
+type latencyHistogram struct {
+	counts [histogramBuckets]int64
+	count  int64
+	sum    time.Duration
+	max    time.Duration
+}
+
+// histogramIndex returns the bucket of a latency
+func histogramIndex(latency time.Duration) int {
+	micros := uint64(max(latency, 0) / time.Microsecond)
+	if micros < histogramLinear {
+		return int(micros)
+	}
+
+	shift := bits.Len64(micros) - histogramSubBits - 1
+	sub := int(micros>>shift) - histogramLinear/2
+	return min(histogramLinear+(shift-1)<<histogramSubBits+sub, histogramBuckets-1)
+}
+
+// histogramUpperBound returns the highest latency a bucket holds
+func histogramUpperBound(index int) time.Duration {
+	if index < histogramLinear {
+		return time.Duration(index+1) * time.Microsecond
+	}
+
+	shift := (index-histogramLinear)>>histogramSubBits + 1
+	sub := (index-histogramLinear)%(1<<histogramSubBits) + histogramLinear/2
+	return time.Duration((sub+1)<<shift) * time.Microsecond
+}
+
+// record adds the latency of a request
+func (h *latencyHistogram) record(latency time.Duration) {
+	h.counts[histogramIndex(latency)]++
+	h.count++
+	h.sum += latency
+	if latency > h.max {
+		h.max = latency
+	}
+}
+
+// since returns the histogram of the latencies recorded after an earlier copy of it,
+// or all of them when the copy is of another histogram. The maximum is the overall
+// one, so it only bounds the percentiles of the window.
+func (h *latencyHistogram) since(earlier *latencyHistogram) *latencyHistogram {
+	if earlier == nil || earlier.count > h.count {
+		window := *h
+		return &window
+	}
+
+	window := &latencyHistogram{count: h.count - earlier.count, sum: h.sum - earlier.sum, max: h.max}
+	for index := range h.counts {
+		window.counts[index] = h.counts[index] - earlier.counts[index]
+	}
+	return window
+}
+
+// mean returns the average latency of the recorded requests
+func (h *latencyHistogram) mean() time.Duration {
+	if h.count == 0 {
+		return 0
+	}
+	return h.sum / time.Duration(h.count)
+}
+
+// percentile returns the latency under which the given percentage of the recorded
+// requests completed, rounded up to the bound of its bucket, or 0 when none was
+// recorded
+func (h *latencyHistogram) percentile(percent float64) time.Duration {
+	if h == nil || h.count == 0 {
+		return 0
+	}
+
+	rank := max(int64(math.Ceil(float64(h.count)*percent/100)), 1)
+	var seen int64
+	for index, count := range h.counts[:] {
+		seen += count
+		if seen >= rank && index < histogramBuckets-1 {
+			return min(histogramUpperBound(index), h.max)
+		}
+	}
+	return h.max
+}
@@ -0,0 +1,62 @@
+package monitor
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLatencyHistogram(t *testing.T) {
+	histogram := &latencyHistogram{}
+	if latency := histogram.percentile(99); latency != 0 {
+		t.Errorf("Expected no latency without requests, got %v", latency)
+	}
+
+	// 1ms to 1000ms, so the nth percentile is n*10ms
+	for i := 1; i <= 1000; i++ {
+		histogram.record(time.Duration(i) * time.Millisecond)
+	}
+
+	for _, percent := range []float64{50, 95, 99} {
+		exact := time.Duration(percent*10) * time.Millisecond
+		latency := histogram.percentile(percent)
+		if latency < exact || float64(latency-exact) > float64(exact)/64 {
+			t.Errorf("p%v: expected within 1/64 of %v, got %v", percent, exact, latency)
+		}
+	}
+	if latency := histogram.percentile(100); latency != time.Second {
+		t.Errorf("Expected p100 to be the slowest request, got %v", latency)
+	}
+	if mean := histogram.mean(); mean != 500500*time.Microsecond {
+		t.Errorf("Expected the exact mean, got %v", mean)
+	}
+
+	// Latencies beyond the last bucket are clamped rather than dropped
+	histogram.record(100 * time.Hour)
+	if latency := histogram.percentile(100); latency != 100*time.Hour {
+		t.Errorf("Expected the slowest request, got %v", latency)
+	}
+}
+
+func TestSnapshotLatencyPercentiles(t *testing.T) {
+	collector := &Collector{clusterMetrics: make(map[string]*ClusterMetrics)}
+	for i := 0; i < 98; i++ {
+		collector.updateServiceMetrics("test-01", "db", "postgres", 2*time.Millisecond, true)
+	}
+	collector.updateServiceMetrics("test-01", "db", "postgres", 200*time.Millisecond, true)
+	collector.updateServiceMetrics("test-01", "db", "postgres", 400*time.Millisecond, false)
+
+	// Percentiles are rounded up to the bound of their bucket
+	svc := collector.SnapshotCluster("test-01").ServiceMetrics["db"]
+	fast := func(latency time.Duration) bool {
+		return latency >= 2*time.Millisecond && latency <= 2*time.Millisecond+2*time.Millisecond/64
+	}
+	if !fast(svc.P50Latency) || !fast(svc.P95Latency) {
+		t.Errorf("Expected p50 and p95 of the fast requests, got %v and %v", svc.P50Latency, svc.P95Latency)
+	}
+	if svc.P99Latency < 200*time.Millisecond || svc.P99Latency > 204*time.Millisecond {
+		t.Errorf("Expected p99 of the slow request, got %v", svc.P99Latency)
+	}
+	if svc.AverageLatency != 7960*time.Microsecond {
+		t.Errorf("Expected the exact average, got %v", svc.AverageLatency)
+	}
+}
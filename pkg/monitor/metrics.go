@@ -1,9 +1,12 @@
 package monitor
 
 import (
+	"strconv"
 	"sync"
 	"time"
 
+	"github.com/akmadan/throome/pkg/adapters"
+	"github.com/akmadan/throome/pkg/cluster"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 )
@@ -14,10 +17,22 @@ type Collector struct {
 	requestTotal    *prometheus.CounterVec
 	requestDuration *prometheus.HistogramVec
 	errorTotal      *prometheus.CounterVec
+	retryTotal      *prometheus.CounterVec
+	rowsReturned    *prometheus.CounterVec
+	bytesReturned   *prometheus.CounterVec
 	activeConns     *prometheus.GaugeVec
 
+	// Connection pool metrics
+	poolConns *prometheus.GaugeVec
+
+	// HTTP API metrics
+	httpRequests *prometheus.CounterVec
+	httpDuration *prometheus.HistogramVec
+	httpInFlight prometheus.Gauge
+
 	// Custom metrics storage
 	clusterMetrics map[string]*ClusterMetrics
+	namespaces     map[string]string // cluster ID -> namespace, for metric labels
 	mu             sync.RWMutex
 }
 
@@ -37,27 +52,36 @@ type ServiceMetrics struct {
 	ServiceType       string
 	TotalRequests     int64
 	FailedRequests    int64
+	Retries           int64 // Failed attempts of operations that were retried
+	RowsReturned      int64 // Rows returned by queries
+	BytesReturned     int64 // Size of the values of the returned rows, as sent by the database
 	SuccessRate       float64
 	AverageLatency    time.Duration
 	MinLatency        time.Duration
 	MaxLatency        time.Duration
+	P50Latency        time.Duration // Latency percentiles, set in snapshots
 	P95Latency        time.Duration
 	P99Latency        time.Duration
 	ActiveConnections int
 	HealthStatus      string
 	LastRequestTime   time.Time
 	Errors            []string
+	CacheStats        *adapters.CacheStats // Latest server statistics, for caches
+	PoolStats         *adapters.PoolStats  // Latest connection pool usage, for pooled services
+
+	latencies *latencyHistogram
+	history   *requestHistory
 }
 
 // NewCollector creates a new metrics collector
 func NewCollector() *Collector {
-	return &Collector{
+	collector := &Collector{
 		requestTotal: promauto.NewCounterVec(
 			prometheus.CounterOpts{
 				Name: "throome_requests_total",
 				Help: "Total number of requests",
 			},
-			[]string{"cluster_id", "service", "type"},
+			[]string{"namespace", "cluster_id", "service", "type"},
 		),
 		requestDuration: promauto.NewHistogramVec(
 			prometheus.HistogramOpts{
@@ -65,47 +89,412 @@ func NewCollector() *Collector {
 				Help:    "Request duration in seconds",
 				Buckets: prometheus.DefBuckets,
 			},
-			[]string{"cluster_id", "service", "type"},
+			[]string{"namespace", "cluster_id", "service", "type"},
 		),
 		errorTotal: promauto.NewCounterVec(
 			prometheus.CounterOpts{
 				Name: "throome_errors_total",
 				Help: "Total number of errors",
 			},
-			[]string{"cluster_id", "service", "type", "error_type"},
+			[]string{"namespace", "cluster_id", "service", "type", "error_type"},
+		),
+		retryTotal: promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "throome_retries_total",
+				Help: "Total number of failed operation attempts that were retried",
+			},
+			[]string{"namespace", "cluster_id", "service", "type"},
+		),
+		rowsReturned: promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "throome_rows_returned_total",
+				Help: "Total number of rows returned by queries",
+			},
+			[]string{"namespace", "cluster_id", "service", "type"},
+		),
+		bytesReturned: promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "throome_bytes_returned_total",
+				Help: "Total size in bytes of the rows returned by queries",
+			},
+			[]string{"namespace", "cluster_id", "service", "type"},
 		),
 		activeConns: promauto.NewGaugeVec(
 			prometheus.GaugeOpts{
 				Name: "throome_active_connections",
 				Help: "Number of active connections",
 			},
-			[]string{"cluster_id", "service", "type"},
+			[]string{"namespace", "cluster_id", "service", "type"},
+		),
+		poolConns: promauto.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "throome_pool_connections",
+				Help: "Number of pooled connections by state (acquired, idle, total, max)",
+			},
+			[]string{"namespace", "cluster_id", "service", "type", "state"},
+		),
+		httpRequests: promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "throome_http_requests_total",
+				Help: "Total number of HTTP API requests",
+			},
+			[]string{"route", "method", "status"},
+		),
+		httpDuration: promauto.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "throome_http_request_duration_seconds",
+				Help:    "HTTP API request duration in seconds",
+				Buckets: prometheus.DefBuckets,
+			},
+			[]string{"route", "method"},
+		),
+		httpInFlight: promauto.NewGauge(
+			prometheus.GaugeOpts{
+				Name: "throome_http_requests_in_flight",
+				Help: "Number of HTTP API requests being served",
+			},
 		),
 		clusterMetrics: make(map[string]*ClusterMetrics),
+		namespaces:     make(map[string]string),
 	}
+	prometheus.MustRegister(&latencyQuantiles{
+		collector: collector,
+		desc: prometheus.NewDesc(
+			"throome_request_latency_seconds",
+			"Request latency percentiles in seconds, over the requests since the gateway started",
+			[]string{"namespace", "cluster_id", "service", "type", "quantile"}, nil,
+		),
+	})
+	prometheus.MustRegister(newPoolCounters(collector))
+	return collector
 }
 
-// RecordRequest records a request metric
-func (c *Collector) RecordRequest(clusterID, service, serviceType string, duration time.Duration, success bool) {
-	c.requestTotal.WithLabelValues(clusterID, service, serviceType).Inc()
-	c.requestDuration.WithLabelValues(clusterID, service, serviceType).Observe(duration.Seconds())
+// latencyQuantiles exports the latency percentiles of every service when scraped
+type latencyQuantiles struct {
+	collector *Collector
+	desc      *prometheus.Desc
+}
+
+// exportedQuantiles are the latency quantiles exported to Prometheus
+var exportedQuantiles = []float64{0.5, 0.95, 0.99}
+
+func (q *latencyQuantiles) Describe(ch chan<- *prometheus.Desc) {
+	ch <- q.desc
+}
+
+func (q *latencyQuantiles) Collect(ch chan<- prometheus.Metric) {
+	c := q.collector
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for clusterID, metrics := range c.clusterMetrics {
+		namespace, exists := c.namespaces[clusterID]
+		if !exists {
+			namespace = cluster.DefaultNamespace
+		}
+		for service, svc := range metrics.ServiceMetrics {
+			if svc.TotalRequests == 0 {
+				continue
+			}
+			for _, quantile := range exportedQuantiles {
+				latency := svc.latencies.percentile(quantile * 100)
+				ch <- prometheus.MustNewConstMetric(q.desc, prometheus.GaugeValue, latency.Seconds(),
+					namespace, clusterID, service, svc.ServiceType, strconv.FormatFloat(quantile, 'f', -1, 64))
+			}
+		}
+	}
+}
+
+// poolCounters exports the cumulative statistics of every connection pool when
+// scraped, as counters of the latest sample
+type poolCounters struct {
+	collector    *Collector
+	acquires     *prometheus.Desc
+	emptyAcquire *prometheus.Desc
+	acquireWait  *prometheus.Desc
+	newConns     *prometheus.Desc
+	timeouts     *prometheus.Desc
+}
+
+func newPoolCounters(collector *Collector) *poolCounters {
+	labels := []string{"namespace", "cluster_id", "service", "type"}
+	return &poolCounters{
+		collector: collector,
+		acquires: prometheus.NewDesc("throome_pool_acquires_total",
+			"Total number of connections acquired from the pool", labels, nil),
+		emptyAcquire: prometheus.NewDesc("throome_pool_empty_acquires_total",
+			"Total number of acquires that had to wait for a free connection", labels, nil),
+		acquireWait: prometheus.NewDesc("throome_pool_acquire_wait_seconds_total",
+			"Total time spent waiting to acquire connections", labels, nil),
+		newConns: prometheus.NewDesc("throome_pool_new_connections_total",
+			"Total number of connections opened by the pool", labels, nil),
+		timeouts: prometheus.NewDesc("throome_pool_timeouts_total",
+			"Total number of pool acquire timeouts", labels, nil),
+	}
+}
 
+func (p *poolCounters) Describe(ch chan<- *prometheus.Desc) {
+	ch <- p.acquires
+	ch <- p.emptyAcquire
+	ch <- p.acquireWait
+	ch <- p.newConns
+	ch <- p.timeouts
+}
+
+func (p *poolCounters) Collect(ch chan<- prometheus.Metric) {
+	c := p.collector
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for clusterID, metrics := range c.clusterMetrics {
+		namespace, exists := c.namespaces[clusterID]
+		if !exists {
+			namespace = cluster.DefaultNamespace
+		}
+		for service, svc := range metrics.ServiceMetrics {
+			stats := svc.PoolStats
+			if stats == nil {
+				continue
+			}
+			labels := []string{namespace, clusterID, service, svc.ServiceType}
+			ch <- prometheus.MustNewConstMetric(p.acquires, prometheus.CounterValue, float64(stats.AcquireCount), labels...)
+			ch <- prometheus.MustNewConstMetric(p.emptyAcquire, prometheus.CounterValue, float64(stats.EmptyAcquireCount), labels...)
+			ch <- prometheus.MustNewConstMetric(p.acquireWait, prometheus.CounterValue, stats.AcquireDuration.Seconds(), labels...)
+			ch <- prometheus.MustNewConstMetric(p.newConns, prometheus.CounterValue, float64(stats.NewConnsCount), labels...)
+			ch <- prometheus.MustNewConstMetric(p.timeouts, prometheus.CounterValue, float64(stats.Timeouts), labels...)
+		}
+	}
+}
+
+// SetNamespace records the namespace of a cluster, which labels its exported metrics
+func (c *Collector) SetNamespace(clusterID, namespace string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.namespaces[clusterID] = namespace
+}
+
+// namespace returns the namespace label of a cluster's metrics
+func (c *Collector) namespace(clusterID string) string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if namespace, exists := c.namespaces[clusterID]; exists {
+		return namespace
+	}
+	return cluster.DefaultNamespace
+}
+
+// RecordRequest records a request metric, counting failures as errors of unknown type
+func (c *Collector) RecordRequest(clusterID, service, serviceType string, duration time.Duration, success bool) {
+	errorType := ""
 	if !success {
-		c.errorTotal.WithLabelValues(clusterID, service, serviceType, "unknown").Inc()
+		errorType = adapters.ErrorTypeUnknown
+	}
+	c.record(clusterID, service, serviceType, duration, errorType)
+}
+
+// RecordOperation records a request metric for an operation, counting its error by the
+// type adapters.ClassifyError gives it
+func (c *Collector) RecordOperation(clusterID, service, serviceType string, duration time.Duration, err error) {
+	c.record(clusterID, service, serviceType, duration, adapters.ClassifyError(err))
+}
+
+// record records a request that failed with an error of a type, or succeeded when the
+// type is empty
+func (c *Collector) record(clusterID, service, serviceType string, duration time.Duration, errorType string) {
+	namespace := c.namespace(clusterID)
+	c.requestTotal.WithLabelValues(namespace, clusterID, service, serviceType).Inc()
+	c.requestDuration.WithLabelValues(namespace, clusterID, service, serviceType).Observe(duration.Seconds())
+
+	if errorType != "" {
+		c.errorTotal.WithLabelValues(namespace, clusterID, service, serviceType, errorType).Inc()
 	}
 
 	// Update custom metrics
-	c.updateServiceMetrics(clusterID, service, serviceType, duration, success)
+	c.updateServiceMetrics(clusterID, service, serviceType, duration, errorType == "")
 }
 
 // RecordError records an error metric
 func (c *Collector) RecordError(clusterID, service, serviceType, errorType string) {
-	c.errorTotal.WithLabelValues(clusterID, service, serviceType, errorType).Inc()
+	namespace := c.namespace(clusterID)
+	c.errorTotal.WithLabelValues(namespace, clusterID, service, serviceType, errorType).Inc()
+}
+
+// RecordRetry records a failed attempt of an operation that is retried
+func (c *Collector) RecordRetry(clusterID, service, serviceType string) {
+	namespace := c.namespace(clusterID)
+	c.retryTotal.WithLabelValues(namespace, clusterID, service, serviceType).Inc()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cluster, svc := c.serviceMetricsLocked(clusterID, service, serviceType)
+	svc.Retries++
+	cluster.LastUpdated = time.Now()
+}
+
+// RecordRows records the rows returned by a query and the size of their values
+func (c *Collector) RecordRows(clusterID, service, serviceType string, rows, bytes int64) {
+	namespace := c.namespace(clusterID)
+	c.rowsReturned.WithLabelValues(namespace, clusterID, service, serviceType).Add(float64(rows))
+	c.bytesReturned.WithLabelValues(namespace, clusterID, service, serviceType).Add(float64(bytes))
+
+	c.addRows(clusterID, service, serviceType, rows, bytes)
+}
+
+// addRows adds returned rows and bytes to the custom metrics of a service
+func (c *Collector) addRows(clusterID, service, serviceType string, rows, bytes int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cluster, svc := c.serviceMetricsLocked(clusterID, service, serviceType)
+	svc.RowsReturned += rows
+	svc.BytesReturned += bytes
+	cluster.LastUpdated = time.Now()
 }
 
 // SetActiveConnections sets the active connections gauge
 func (c *Collector) SetActiveConnections(clusterID, service, serviceType string, count int) {
-	c.activeConns.WithLabelValues(clusterID, service, serviceType).Set(float64(count))
+	namespace := c.namespace(clusterID)
+	c.activeConns.WithLabelValues(namespace, clusterID, service, serviceType).Set(float64(count))
+}
+
+// RecordPoolStats keeps a connection pool snapshot in the service's metrics and exports
+// its connections as Prometheus gauges. Its cumulative statistics are exported as
+// counters when scraped.
+func (c *Collector) RecordPoolStats(clusterID, service, serviceType string, stats *adapters.PoolStats) {
+	if stats == nil {
+		return
+	}
+
+	c.mu.Lock()
+	cluster, svc := c.serviceMetricsLocked(clusterID, service, serviceType)
+	svc.PoolStats = stats
+	svc.ActiveConnections = stats.AcquiredConns
+	cluster.LastUpdated = time.Now()
+	c.mu.Unlock()
+
+	namespace := c.namespace(clusterID)
+	c.poolConns.WithLabelValues(namespace, clusterID, service, serviceType, "acquired").Set(float64(stats.AcquiredConns))
+	c.poolConns.WithLabelValues(namespace, clusterID, service, serviceType, "idle").Set(float64(stats.IdleConns))
+	c.poolConns.WithLabelValues(namespace, clusterID, service, serviceType, "total").Set(float64(stats.TotalConns))
+	c.poolConns.WithLabelValues(namespace, clusterID, service, serviceType, "max").Set(float64(stats.MaxConns))
+	c.SetActiveConnections(clusterID, service, serviceType, stats.AcquiredConns)
+}
+
+// HTTPRequestStarted counts an HTTP request as in flight until HTTPRequestFinished
+func (c *Collector) HTTPRequestStarted() {
+	c.httpInFlight.Inc()
+}
+
+// HTTPRequestFinished records an HTTP request served by a route, identified by its
+// path template so requests to different clusters share labels
+func (c *Collector) HTTPRequestFinished(route, method string, status int, duration time.Duration) {
+	c.httpInFlight.Dec()
+	c.httpRequests.WithLabelValues(route, method, strconv.Itoa(status)).Inc()
+	c.httpDuration.WithLabelValues(route, method).Observe(duration.Seconds())
+}
+
+// ForgetCluster drops the metrics of a deleted cluster, including its exported gauges
+func (c *Collector) ForgetCluster(clusterID string) {
+	c.mu.Lock()
+	delete(c.clusterMetrics, clusterID)
+	delete(c.namespaces, clusterID)
+	c.mu.Unlock()
+
+	labels := prometheus.Labels{"cluster_id": clusterID}
+	for _, vec := range []*prometheus.GaugeVec{c.activeConns, c.poolConns} {
+		vec.DeletePartialMatch(labels)
+	}
+}
+
+// ForgetService drops the metrics of a service removed from a cluster, including its
+// exported gauges
+func (c *Collector) ForgetService(clusterID, service string) {
+	c.mu.Lock()
+	if cluster, exists := c.clusterMetrics[clusterID]; exists {
+		delete(cluster.ServiceMetrics, service)
+		cluster.sumServices()
+	}
+	c.mu.Unlock()
+
+	labels := prometheus.Labels{"cluster_id": clusterID, "service": service}
+	for _, vec := range []*prometheus.GaugeVec{c.activeConns, c.poolConns} {
+		vec.DeletePartialMatch(labels)
+	}
+}
+
+// ResetCluster clears the request counts, latencies and history of every service of a
+// cluster, so load tests can start from a clean baseline. The latest connection and
+// cache statistics are kept, and the exported Prometheus counters are not reset.
+func (c *Collector) ResetCluster(clusterID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cluster, exists := c.clusterMetrics[clusterID]
+	if !exists {
+		return
+	}
+	for _, svc := range cluster.ServiceMetrics {
+		svc.reset()
+	}
+	cluster.sumServices()
+	cluster.LastUpdated = time.Now()
+}
+
+// ResetService clears the request counts, latencies and history of a service like
+// ResetCluster
+func (c *Collector) ResetService(clusterID, service string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cluster, exists := c.clusterMetrics[clusterID]
+	if !exists {
+		return
+	}
+	if svc, exists := cluster.ServiceMetrics[service]; exists {
+		svc.reset()
+		cluster.sumServices()
+		cluster.LastUpdated = time.Now()
+	}
+}
+
+// sumServices sets the totals of a cluster to those of its services, with the average
+// latency weighted by their requests. The caller must hold the collector's write lock.
+func (m *ClusterMetrics) sumServices() {
+	var latency time.Duration
+	m.TotalRequests, m.FailedRequests = 0, 0
+	for _, svc := range m.ServiceMetrics {
+		m.TotalRequests += svc.TotalRequests
+		m.FailedRequests += svc.FailedRequests
+		latency += svc.AverageLatency * time.Duration(svc.TotalRequests)
+	}
+	m.AverageLatency = 0
+	if m.TotalRequests > 0 {
+		m.AverageLatency = latency / time.Duration(m.TotalRequests)
+	}
+}
+
+// reset clears the metrics derived from the requests of a service. The caller must
+// hold the collector's write lock.
+func (s *ServiceMetrics) reset() {
+	s.TotalRequests = 0
+	s.FailedRequests = 0
+	s.Retries = 0
+	s.RowsReturned = 0
+	s.BytesReturned = 0
+	s.SuccessRate = 0
+	s.AverageLatency = 0
+	s.MinLatency = 0
+	s.MaxLatency = 0
+	s.P50Latency = 0
+	s.P95Latency = 0
+	s.P99Latency = 0
+	s.LastRequestTime = time.Time{}
+	s.Errors = nil
+	s.latencies = &latencyHistogram{}
+	s.history = &requestHistory{}
 }
 
 // updateServiceMetrics updates custom service metrics
@@ -113,6 +502,42 @@ func (c *Collector) updateServiceMetrics(clusterID, service, serviceType string,
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	cluster, svc := c.serviceMetricsLocked(clusterID, service, serviceType)
+	if svc.TotalRequests == 0 {
+		svc.MinLatency = duration
+		svc.MaxLatency = duration
+	}
+
+	// Update metrics
+	svc.TotalRequests++
+	if !success {
+		svc.FailedRequests++
+	}
+
+	// Update success rate
+	svc.SuccessRate = float64(svc.TotalRequests-svc.FailedRequests) / float64(svc.TotalRequests) * 100
+
+	// Update latency metrics
+	if duration < svc.MinLatency {
+		svc.MinLatency = duration
+	}
+	if duration > svc.MaxLatency {
+		svc.MaxLatency = duration
+	}
+
+	svc.latencies.record(duration)
+	svc.AverageLatency = svc.latencies.mean()
+
+	now := time.Now()
+	svc.history.record(now, duration, success)
+	svc.LastRequestTime = now
+	cluster.sumServices()
+	cluster.LastUpdated = now
+}
+
+// serviceMetricsLocked returns the metrics of a service, creating them if needed.
+// The caller must hold the write lock.
+func (c *Collector) serviceMetricsLocked(clusterID, service, serviceType string) (*ClusterMetrics, *ServiceMetrics) {
 	// Get or create cluster metrics
 	cluster, exists := c.clusterMetrics[clusterID]
 	if !exists {
@@ -130,37 +555,44 @@ func (c *Collector) updateServiceMetrics(clusterID, service, serviceType string,
 		svc = &ServiceMetrics{
 			ServiceName:  service,
 			ServiceType:  serviceType,
-			MinLatency:   duration,
-			MaxLatency:   duration,
 			HealthStatus: "healthy",
+			latencies:    &latencyHistogram{},
+			history:      &requestHistory{},
 		}
 		cluster.ServiceMetrics[service] = svc
 	}
 
-	// Update metrics
-	svc.TotalRequests++
-	if !success {
-		svc.FailedRequests++
-	}
-
-	// Update success rate
-	svc.SuccessRate = float64(svc.TotalRequests-svc.FailedRequests) / float64(svc.TotalRequests) * 100
+	return cluster, svc
+}
 
-	// Update latency metrics
-	if duration < svc.MinLatency {
-		svc.MinLatency = duration
-	}
-	if duration > svc.MaxLatency {
-		svc.MaxLatency = duration
+// RecordCacheStats stores the latest server statistics of a cache service
+func (c *Collector) RecordCacheStats(clusterID, service, serviceType string, stats *adapters.CacheStats) {
+	if stats == nil {
+		return
 	}
 
-	// Calculate rolling average
-	svc.AverageLatency = (svc.AverageLatency*time.Duration(svc.TotalRequests-1) + duration) / time.Duration(svc.TotalRequests)
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-	svc.LastRequestTime = time.Now()
+	cluster, svc := c.serviceMetricsLocked(clusterID, service, serviceType)
+	svc.CacheStats = stats
 	cluster.LastUpdated = time.Now()
 }
 
+// GetCacheStats returns the latest recorded statistics of a cache service
+func (c *Collector) GetCacheStats(clusterID, service string) *adapters.CacheStats {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if cluster, exists := c.clusterMetrics[clusterID]; exists {
+		if svc, exists := cluster.ServiceMetrics[service]; exists {
+			return svc.CacheStats
+		}
+	}
+
+	return nil
+}
+
 // GetClusterMetrics returns metrics for a cluster
 func (c *Collector) GetClusterMetrics(clusterID string) *ClusterMetrics {
 	c.mu.RLock()
@@ -169,6 +601,53 @@ func (c *Collector) GetClusterMetrics(clusterID string) *ClusterMetrics {
 	return c.clusterMetrics[clusterID]
 }
 
+// SnapshotCluster returns a deep copy of a cluster's metrics, with the latency
+// percentiles of its services, that is safe to read while requests keep being
+// recorded
+func (c *Collector) SnapshotCluster(clusterID string) *ClusterMetrics {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	cluster, exists := c.clusterMetrics[clusterID]
+	if !exists {
+		return nil
+	}
+
+	snapshot := *cluster
+	snapshot.ServiceMetrics = make(map[string]*ServiceMetrics, len(cluster.ServiceMetrics))
+	for name, svc := range cluster.ServiceMetrics {
+		svcCopy := *svc
+		svcCopy.Errors = append([]string(nil), svc.Errors...)
+		svcCopy.P50Latency = svc.latencies.percentile(50)
+		svcCopy.P95Latency = svc.latencies.percentile(95)
+		svcCopy.P99Latency = svc.latencies.percentile(99)
+		svcCopy.latencies = nil
+		svcCopy.history = nil
+		snapshot.ServiceMetrics[name] = &svcCopy
+	}
+
+	return &snapshot
+}
+
+// ClusterHistory returns the per-minute request samples of a cluster's services from
+// since to now, at most HistoryRetention back, or nil when the cluster has no metrics
+func (c *Collector) ClusterHistory(clusterID string, since, now time.Time) map[string][]MetricsSample {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	cluster, exists := c.clusterMetrics[clusterID]
+	if !exists {
+		return nil
+	}
+
+	result := make(map[string][]MetricsSample, len(cluster.ServiceMetrics))
+	for name, svc := range cluster.ServiceMetrics {
+		result[name] = svc.history.samples(since, now)
+	}
+
+	return result
+}
+
 // GetAllMetrics returns all cluster metrics
 func (c *Collector) GetAllMetrics() map[string]*ClusterMetrics {
 	c.mu.RLock()
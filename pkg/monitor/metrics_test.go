@@ -0,0 +1,101 @@
+package monitor
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/akmadan/throome/pkg/adapters"
+)
+
+func TestCollectorReset(t *testing.T) {
+	collector := &Collector{clusterMetrics: make(map[string]*ClusterMetrics)}
+	collector.updateServiceMetrics("test-01", "db", "postgres", 2*time.Millisecond, false)
+	collector.updateServiceMetrics("test-01", "cache", "redis", time.Millisecond, true)
+	collector.addRows("test-01", "db", "postgres", 5, 200)
+	collector.addRows("test-01", "db", "postgres", 2, 50)
+	if db := collector.GetServiceMetrics("test-01", "db"); db.RowsReturned != 7 || db.BytesReturned != 250 {
+		t.Errorf("Expected 7 rows and 250 bytes returned, got %d and %d", db.RowsReturned, db.BytesReturned)
+	}
+	collector.mu.Lock()
+	collector.clusterMetrics["test-01"].ServiceMetrics["db"].PoolStats = &adapters.PoolStats{MaxConns: 10}
+	collector.mu.Unlock()
+
+	if totals := collector.SnapshotCluster("test-01"); totals.TotalRequests != 2 || totals.FailedRequests != 1 {
+		t.Errorf("Expected the cluster totals to sum its services, got %d requests and %d failures", totals.TotalRequests, totals.FailedRequests)
+	}
+
+	collector.ResetService("test-01", "db")
+	if totals := collector.SnapshotCluster("test-01"); totals.TotalRequests != 1 || totals.FailedRequests != 0 || totals.AverageLatency != totals.ServiceMetrics["cache"].AverageLatency {
+		t.Errorf("Expected the cluster totals to be those of the other service after the reset, got %+v", totals)
+	}
+	db := collector.GetServiceMetrics("test-01", "db")
+	if db.TotalRequests != 0 || db.FailedRequests != 0 || db.MaxLatency != 0 || db.RowsReturned != 0 || db.PoolStats == nil {
+		t.Errorf("Expected the requests of db to be cleared and its pool stats kept, got %+v", db)
+	}
+	if cache := collector.GetServiceMetrics("test-01", "cache"); cache.TotalRequests != 1 {
+		t.Errorf("Expected the other service to be kept, got %d requests", cache.TotalRequests)
+	}
+
+	collector.ResetCluster("test-01")
+	if totals := collector.SnapshotCluster("test-01"); totals.TotalRequests != 0 || totals.AverageLatency != 0 {
+		t.Errorf("Expected the cluster totals to be cleared, got %+v", totals)
+	}
+	now := time.Now()
+	for service, samples := range collector.ClusterHistory("test-01", now.Add(-time.Hour), now) {
+		for _, sample := range samples {
+			if sample.Requests != 0 {
+				t.Errorf("Expected the history of %s to be cleared, got %+v", service, sample)
+			}
+		}
+	}
+	if snapshot := collector.SnapshotCluster("test-01"); snapshot.ServiceMetrics["cache"].P99Latency != 0 {
+		t.Errorf("Expected the latencies to be cleared, got %v", snapshot.ServiceMetrics["cache"].P99Latency)
+	}
+
+	// Resetting an unknown cluster or service is a no-op
+	collector.ResetCluster("missing")
+	collector.ResetService("test-01", "missing")
+}
+
+func TestPoolCounters(t *testing.T) {
+	collector := &Collector{clusterMetrics: make(map[string]*ClusterMetrics), namespaces: make(map[string]string)}
+	counters := newPoolCounters(collector)
+
+	collector.mu.Lock()
+	_, svc := collector.serviceMetricsLocked("test-01", "db", "postgres")
+	svc.PoolStats = &adapters.PoolStats{AcquireCount: 42, EmptyAcquireCount: 3, AcquireDuration: 1500 * time.Millisecond, NewConnsCount: 5, Timeouts: 1}
+	collector.serviceMetricsLocked("test-01", "cache", "memcached") // Not pooled
+	collector.mu.Unlock()
+
+	expected := `
+# HELP throome_pool_acquire_wait_seconds_total Total time spent waiting to acquire connections
+# TYPE throome_pool_acquire_wait_seconds_total counter
+throome_pool_acquire_wait_seconds_total{cluster_id="test-01",namespace="default",service="db",type="postgres"} 1.5
+# HELP throome_pool_acquires_total Total number of connections acquired from the pool
+# TYPE throome_pool_acquires_total counter
+throome_pool_acquires_total{cluster_id="test-01",namespace="default",service="db",type="postgres"} 42
+# HELP throome_pool_empty_acquires_total Total number of acquires that had to wait for a free connection
+# TYPE throome_pool_empty_acquires_total counter
+throome_pool_empty_acquires_total{cluster_id="test-01",namespace="default",service="db",type="postgres"} 3
+# HELP throome_pool_new_connections_total Total number of connections opened by the pool
+# TYPE throome_pool_new_connections_total counter
+throome_pool_new_connections_total{cluster_id="test-01",namespace="default",service="db",type="postgres"} 5
+# HELP throome_pool_timeouts_total Total number of pool acquire timeouts
+# TYPE throome_pool_timeouts_total counter
+throome_pool_timeouts_total{cluster_id="test-01",namespace="default",service="db",type="postgres"} 1
+`
+	if err := testutil.CollectAndCompare(counters, strings.NewReader(expected)); err != nil {
+		t.Errorf("Unexpected pool counters: %v", err)
+	}
+
+	// The pools of forgotten clusters are no longer exported
+	collector.mu.Lock()
+	delete(collector.clusterMetrics, "test-01")
+	collector.mu.Unlock()
+	if count := testutil.CollectAndCount(counters); count != 0 {
+		t.Errorf("Expected no pool counters after the cluster is forgotten, got %d", count)
+	}
+}
@@ -0,0 +1,119 @@
+package monitor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strconv"
+	"strings"
+)
+
+// Types of notification channels
+const (
+	ChannelSlack   = "slack"
+	ChannelWebhook = "webhook"
+	ChannelEmail   = "email"
+)
+
+// SlackNotifier posts alerts to a Slack incoming webhook
+type SlackNotifier struct {
+	URL    string
+	Client *http.Client // http.DefaultClient unless set
+}
+
+// Notify posts an alert's message to the webhook
+func (n *SlackNotifier) Notify(ctx context.Context, alert *Alert) error {
+	icon := ":rotating_light:"
+	if alert.State == AlertResolved {
+		icon = ":white_check_mark:"
+	}
+	return postJSON(ctx, n.Client, n.URL, nil, map[string]string{
+		"text": fmt.Sprintf("%s *%s* %s", icon, strings.ToUpper(alert.State), alert.Message),
+	})
+}
+
+// WebhookNotifier posts alerts as JSON to a URL
+type WebhookNotifier struct {
+	URL     string
+	Headers map[string]string // Sent with every notification, such as an Authorization header
+	Client  *http.Client      // http.DefaultClient unless set
+}
+
+// Notify posts an alert to the URL
+func (n *WebhookNotifier) Notify(ctx context.Context, alert *Alert) error {
+	return postJSON(ctx, n.Client, n.URL, n.Headers, alert)
+}
+
+// postJSON posts a value as JSON, failing on responses other than 2xx
+func postJSON(ctx context.Context, client *http.Client, url string, headers map[string]string, value interface{}) error {
+	body, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("notification rejected with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// EmailNotifier mails alerts through an SMTP server
+type EmailNotifier struct {
+	Host     string
+	Port     int
+	Username string // Authenticates with PLAIN when set
+	Password string
+	From     string
+	To       []string
+}
+
+// Notify mails an alert to the recipients
+func (n *EmailNotifier) Notify(ctx context.Context, alert *Alert) error {
+	var auth smtp.Auth
+	if n.Username != "" {
+		auth = smtp.PlainAuth("", n.Username, n.Password, n.Host)
+	}
+
+	subject := fmt.Sprintf("[Throome] %s: %s", strings.ToUpper(alert.State), alert.Rule)
+	var message bytes.Buffer
+	fmt.Fprintf(&message, "From: %s\r\n", n.From)
+	fmt.Fprintf(&message, "To: %s\r\n", strings.Join(n.To, ", "))
+	fmt.Fprintf(&message, "Subject: %s\r\n", subject)
+	message.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	fmt.Fprintf(&message, "%s\r\n\r\nCluster: %s\r\nService: %s\r\nActive since: %s\r\n",
+		alert.Message, alert.ClusterID, alert.ServiceName, alert.ActiveSince.Format("2006-01-02 15:04:05 MST"))
+
+	// smtp.SendMail takes no context, so deliveries that time out are abandoned
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(net.JoinHostPort(n.Host, strconv.Itoa(n.Port)), auth, n.From, n.To, message.Bytes())
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,78 @@
+package monitor
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"math/rand"
+	"regexp"
+	"unicode/utf8"
+
+	"github.com/akmadan/throome/pkg/adapters"
+	"github.com/akmadan/throome/pkg/cluster"
+)
+
+// defaultMaxCommandLength is the number of characters of a command kept by the
+// truncate redaction unless configured otherwise
+const defaultMaxCommandLength = 256
+
+// literalPattern matches the quoted strings and numbers of a SQL query, along with
+// identifiers and placeholders containing digits, which are kept
+var literalPattern = regexp.MustCompile(`'(?:[^']|'')*'|[$\w]*\d+(?:\.\d+)?\w*`)
+
+// numberPattern matches a numeric literal
+var numberPattern = regexp.MustCompile(`^\d+(?:\.\d+)?$`)
+
+// RedactCommand returns a command as the activity configuration of its cluster allows
+// it to be logged
+func RedactCommand(command string, config cluster.ActivityConfig) string {
+	switch config.Redaction {
+	case cluster.RedactionStripArgs:
+		query, _ := adapters.SplitCommandArgs(command)
+		return query
+	case cluster.RedactionHashLiterals:
+		query, args := adapters.SplitCommandArgs(command)
+		query = HashLiterals(query)
+		if args == "" {
+			return query
+		}
+		return adapters.CommandWithArgs(query, []interface{}{hashLiteral(args)})
+	case cluster.RedactionTruncate:
+		maxLength := config.MaxLength
+		if maxLength == 0 {
+			maxLength = defaultMaxCommandLength
+		}
+		if utf8.RuneCountInString(command) <= maxLength {
+			return command
+		}
+		return string([]rune(command)[:maxLength]) + "..."
+	default:
+		return command
+	}
+}
+
+// HashLiterals replaces the quoted strings and numbers of a SQL query or expression
+// with short hashes, keeping identifiers and placeholders
+func HashLiterals(query string) string {
+	return literalPattern.ReplaceAllStringFunc(query, func(literal string) string {
+		if literal[0] != '\'' && !numberPattern.MatchString(literal) {
+			return literal
+		}
+		return hashLiteral(literal)
+	})
+}
+
+// hashLiteral replaces a value with a short hash of it, so equal values can still be
+// told apart from different ones
+func hashLiteral(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return "<hash:" + hex.EncodeToString(sum[:4]) + ">"
+}
+
+// sampled reports whether an operation is logged under the sample rate of its
+// cluster. Failed operations always are.
+func sampled(config cluster.ActivityConfig, failed bool) bool {
+	if failed || config.SampleRate == 0 || config.SampleRate >= 1 {
+		return true
+	}
+	return rand.Float64() < config.SampleRate // #nosec G404 -- Not security sensitive
+}
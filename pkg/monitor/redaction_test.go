@@ -0,0 +1,64 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/akmadan/throome/pkg/adapters"
+	"github.com/akmadan/throome/pkg/cluster"
+)
+
+func TestRedactCommand(t *testing.T) {
+	command := adapters.CommandWithArgs("SELECT * FROM users_2024 WHERE email = 'a@b.c' AND age > 30 AND id = $1", []interface{}{42})
+
+	if redacted := RedactCommand(command, cluster.ActivityConfig{}); redacted != command {
+		t.Errorf("Expected the command unchanged without redaction, got %q", redacted)
+	}
+
+	stripped := RedactCommand(command, cluster.ActivityConfig{Redaction: cluster.RedactionStripArgs})
+	if stripped != "SELECT * FROM users_2024 WHERE email = 'a@b.c' AND age > 30 AND id = $1" {
+		t.Errorf("Expected the arguments to be stripped, got %q", stripped)
+	}
+
+	hashed := RedactCommand(command, cluster.ActivityConfig{Redaction: cluster.RedactionHashLiterals})
+	if strings.Contains(hashed, "a@b.c") || strings.Contains(hashed, "30") || strings.Contains(hashed, "42") {
+		t.Errorf("Expected the literals and arguments to be hashed, got %q", hashed)
+	}
+	if !strings.Contains(hashed, "users_2024") || !strings.Contains(hashed, "$1") {
+		t.Errorf("Expected identifiers and placeholders to be kept, got %q", hashed)
+	}
+	if again := RedactCommand(command, cluster.ActivityConfig{Redaction: cluster.RedactionHashLiterals}); again != hashed {
+		t.Errorf("Expected equal values to hash the same, got %q and %q", hashed, again)
+	}
+
+	truncated := RedactCommand(command, cluster.ActivityConfig{Redaction: cluster.RedactionTruncate, MaxLength: 13})
+	if truncated != "SELECT * FROM..." {
+		t.Errorf("Expected the command to be cut after 13 characters, got %q", truncated)
+	}
+}
+
+func TestLogOperationSampling(t *testing.T) {
+	buffer := NewActivityBuffer(100)
+	logger := NewActivityLogger(buffer).(*DefaultActivityLogger)
+
+	// A tiny rate keeps failures only
+	logger.SetClusterConfig("c1", cluster.ActivityConfig{SampleRate: 0.000001, Redaction: cluster.RedactionStripArgs})
+	for i := 0; i < 20; i++ {
+		logger.LogOperation(context.Background(), "c1", "db", "postgres", "QUERY", "SELECT 1", 0, nil, "")
+	}
+	logger.LogOperation(context.Background(), "c1", "db", "postgres", "QUERY", "SELECT $1 [args: [1]]", 0, errors.New("failed"), "")
+
+	logs := buffer.Filter(ActivityFilters{ClusterID: "c1"})
+	if len(logs) != 1 || logs[0].Status != "error" || logs[0].Command != "SELECT $1" {
+		t.Errorf("Expected only the redacted failure, got %+v", logs)
+	}
+
+	// Forgotten clusters log everything again
+	logger.ForgetCluster("c1")
+	logger.LogOperation(context.Background(), "c1", "db", "postgres", "QUERY", "SELECT 1", 0, nil, "")
+	if logs := buffer.Filter(ActivityFilters{ClusterID: "c1"}); len(logs) != 2 {
+		t.Errorf("Expected the operation to be logged, got %d logs", len(logs))
+	}
+}
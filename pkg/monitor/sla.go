@@ -0,0 +1,124 @@
+package monitor
+
+import (
+	"time"
+)
+
+// Periods of availability reports
+const (
+	SLAWeek  = 7 * 24 * time.Hour
+	SLAMonth = 30 * 24 * time.Hour
+)
+
+// Incidents kept per service, the last month's and at most maxIncidents
+const (
+	IncidentRetention = SLAMonth
+	maxIncidents      = 1000
+)
+
+// Incident is a period during which a service was unhealthy, from the check that
+// crossed its failure threshold to the first passing check after it
+type Incident struct {
+	Start time.Time  `json:"start"`
+	End   *time.Time `json:"end,omitempty"` // Nil while the service is still unhealthy
+	Error string     `json:"error,omitempty"`
+}
+
+// DurationAt returns how long the incident lasted, or has lasted so far
+func (i *Incident) DurationAt(now time.Time) time.Duration {
+	if i.End != nil {
+		return i.End.Sub(i.Start)
+	}
+	return now.Sub(i.Start)
+}
+
+// SLAReport is the availability of a service over a period
+type SLAReport struct {
+	Start            time.Time     `json:"start"`
+	End              time.Time     `json:"end"`
+	Observed         time.Duration `json:"observed"` // Part of the period since the first check of the service
+	Downtime         time.Duration `json:"downtime"`
+	UptimePercentage float64       `json:"uptime_percentage"` // 100 without observed time
+	Incidents        int           `json:"incidents"`         // Incidents that overlap the period
+	MTTR             time.Duration `json:"mttr"`              // Mean time to recovery of the incidents resolved within the period
+}
+
+// openIncident starts an incident. The caller must hold the checker's lock.
+func (h *HealthHistory) openIncident(at time.Time, err string) {
+	h.Incidents = append(h.Incidents, Incident{Start: at, Error: err})
+	if len(h.Incidents) > maxIncidents {
+		h.Incidents = h.Incidents[len(h.Incidents)-maxIncidents:]
+	}
+}
+
+// closeIncident ends the ongoing incident and forgets those that ended before the
+// retention. The caller must hold the checker's lock.
+func (h *HealthHistory) closeIncident(at time.Time) {
+	if n := len(h.Incidents); n > 0 && h.Incidents[n-1].End == nil {
+		h.Incidents[n-1].End = &at
+	}
+
+	cutoff := at.Add(-IncidentRetention)
+	expired := 0
+	for expired < len(h.Incidents) && h.Incidents[expired].End != nil && h.Incidents[expired].End.Before(cutoff) {
+		expired++
+	}
+	h.Incidents = h.Incidents[expired:]
+}
+
+// IncidentsSince returns the incidents that were ongoing at or started after a time,
+// oldest first
+func (h *HealthHistory) IncidentsSince(since time.Time) []Incident {
+	incidents := make([]Incident, 0)
+	for _, incident := range h.Incidents {
+		if incident.End == nil || !incident.End.Before(since) {
+			incidents = append(incidents, incident)
+		}
+	}
+	return incidents
+}
+
+// SLA returns the availability of the service over the period that ends now. Only the
+// time since the service's first check counts, so a service added during the period
+// is not reported as down before it.
+func (h *HealthHistory) SLA(period time.Duration, now time.Time) *SLAReport {
+	report := &SLAReport{Start: now.Add(-period), End: now, UptimePercentage: 100}
+
+	observedFrom := report.Start
+	if h.FirstChecked.After(observedFrom) {
+		observedFrom = h.FirstChecked
+	}
+	if now.After(observedFrom) {
+		report.Observed = now.Sub(observedFrom)
+	}
+
+	var resolved int
+	var recovery time.Duration
+	for _, incident := range h.IncidentsSince(observedFrom) {
+		end := now
+		if incident.End != nil {
+			end = *incident.End
+		}
+		start := incident.Start
+		if start.Before(observedFrom) {
+			start = observedFrom
+		}
+		if end.After(start) {
+			report.Downtime += end.Sub(start)
+		}
+		report.Incidents++
+
+		if incident.End != nil {
+			resolved++
+			recovery += incident.DurationAt(now)
+		}
+	}
+
+	if resolved > 0 {
+		report.MTTR = recovery / time.Duration(resolved)
+	}
+	if report.Observed > 0 {
+		report.UptimePercentage = float64(report.Observed-min(report.Downtime, report.Observed)) / float64(report.Observed) * 100
+	}
+	return report
+}
@@ -0,0 +1,51 @@
+package monitor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/akmadan/throome/pkg/adapters"
+)
+
+func TestSLA(t *testing.T) {
+	checker := NewHealthChecker(time.Second, time.Second, 1)
+	now := time.Date(2024, 6, 30, 12, 0, 0, 0, time.UTC)
+	check := func(at time.Time, healthy bool) {
+		checker.recordHealthStatus("c1/db", &adapters.HealthStatus{Healthy: healthy, ErrorMessage: "refused", LastChecked: at})
+	}
+
+	// Checked for the last 10 days, down for an hour 5 days ago and since 30 minutes ago
+	check(now.Add(-10*24*time.Hour), true)
+	check(now.Add(-5*24*time.Hour), false)
+	check(now.Add(-5*24*time.Hour+time.Hour), true)
+	check(now.Add(-30*time.Minute), false)
+
+	history := checker.GetHealthHistory("c1/db")
+	if len(history.Incidents) != 2 || history.Incidents[1].End != nil || history.Incidents[0].Error != "refused" {
+		t.Fatalf("Expected a resolved and an ongoing incident, got %+v", history.Incidents)
+	}
+
+	week := history.SLA(SLAWeek, now)
+	if week.Incidents != 2 || week.Downtime != 90*time.Minute || week.MTTR != time.Hour {
+		t.Errorf("Expected 2 incidents, 90m down and a 1h MTTR, got %+v", week)
+	}
+	if expected := float64(SLAWeek-90*time.Minute) / float64(SLAWeek) * 100; week.UptimePercentage != expected {
+		t.Errorf("Expected %v%% weekly uptime, got %v", expected, week.UptimePercentage)
+	}
+
+	// Only the 10 days since the first check count towards the month
+	month := history.SLA(SLAMonth, now)
+	if month.Observed != 10*24*time.Hour {
+		t.Errorf("Expected 10 days observed, got %v", month.Observed)
+	}
+
+	// The last day only has the ongoing incident
+	day := history.SLA(24*time.Hour, now)
+	if day.Incidents != 1 || day.Downtime != 30*time.Minute || day.MTTR != 0 {
+		t.Errorf("Expected the ongoing incident only, got %+v", day)
+	}
+
+	if report := (&HealthHistory{}).SLA(SLAWeek, now); report.UptimePercentage != 100 || report.Incidents != 0 {
+		t.Errorf("Expected full uptime without checks, got %+v", report)
+	}
+}
@@ -0,0 +1,116 @@
+package monitor
+
+import (
+	"time"
+)
+
+// Layout of the request history of a service
+const (
+	HistoryResolution = time.Minute                                 // Time each sample covers
+	HistoryRetention  = 24 * time.Hour                              // Age of the oldest sample kept
+	historySize       = int64(HistoryRetention / HistoryResolution) // Samples kept per service
+)
+
+// MetricsSample holds the requests of a service during one interval of its history
+type MetricsSample struct {
+	Time           time.Time     `json:"time"` // Start of the interval
+	Requests       int64         `json:"requests"`
+	FailedRequests int64         `json:"failed_requests"`
+	AverageLatency time.Duration `json:"average_latency"`
+	P50Latency     time.Duration `json:"p50_latency"`
+	P95Latency     time.Duration `json:"p95_latency"`
+	P99Latency     time.Duration `json:"p99_latency"`
+	MaxLatency     time.Duration `json:"max_latency"`
+}
+
+// historyBucket holds the requests of one interval. Its percentiles are computed
+// once the interval is over, from the histogram of the current interval.
+type historyBucket struct {
+	interval int64 // Number of the interval since the Unix epoch
+	requests int64
+	failed   int64
+	sum      time.Duration
+	max      time.Duration
+	p50      time.Duration
+	p95      time.Duration
+	p99      time.Duration
+}
+
+// requestHistory keeps per-interval samples of a service's requests in a ring, for
+// charts of request rates and latencies over the retention period. Only the current
+// interval has a latency histogram, so memory stays flat.
+type requestHistory struct {
+	buckets  [historySize]historyBucket
+	current  int64 // Interval of the latencies histogram, zero before the first request
+	latency  latencyHistogram
+	recorded bool
+}
+
+// historyInterval returns the number of the interval of a time
+func historyInterval(at time.Time) int64 {
+	return at.Unix() / int64(HistoryResolution/time.Second)
+}
+
+// record adds a request that completed at a time
+func (h *requestHistory) record(at time.Time, latency time.Duration, success bool) {
+	interval := historyInterval(at)
+	if !h.recorded || interval > h.current {
+		h.closeCurrent()
+		h.current = interval
+		h.latency = latencyHistogram{}
+		h.buckets[interval%historySize] = historyBucket{interval: interval}
+		h.recorded = true
+	}
+
+	// Requests completing out of order are counted in the current interval
+	bucket := &h.buckets[h.current%historySize]
+	bucket.requests++
+	if !success {
+		bucket.failed++
+	}
+	bucket.sum += latency
+	if latency > bucket.max {
+		bucket.max = latency
+	}
+	h.latency.record(latency)
+}
+
+// closeCurrent computes the percentiles of the current interval
+func (h *requestHistory) closeCurrent() {
+	if !h.recorded {
+		return
+	}
+	bucket := &h.buckets[h.current%historySize]
+	bucket.p50 = h.latency.percentile(50)
+	bucket.p95 = h.latency.percentile(95)
+	bucket.p99 = h.latency.percentile(99)
+}
+
+// samples returns a sample for every interval from since to now, oldest first, with
+// empty samples for intervals without requests
+func (h *requestHistory) samples(since, now time.Time) []MetricsSample {
+	first := max(historyInterval(since), historyInterval(now)-historySize+1)
+	last := historyInterval(now)
+
+	result := make([]MetricsSample, 0, max(last-first+1, 0))
+	for interval := first; interval <= last; interval++ {
+		sample := MetricsSample{Time: time.Unix(interval*int64(HistoryResolution/time.Second), 0).UTC()}
+
+		bucket := &h.buckets[interval%historySize]
+		if h.recorded && bucket.interval == interval && bucket.requests > 0 {
+			sample.Requests = bucket.requests
+			sample.FailedRequests = bucket.failed
+			sample.AverageLatency = bucket.sum / time.Duration(bucket.requests)
+			sample.MaxLatency = bucket.max
+			if interval == h.current {
+				sample.P50Latency = h.latency.percentile(50)
+				sample.P95Latency = h.latency.percentile(95)
+				sample.P99Latency = h.latency.percentile(99)
+			} else {
+				sample.P50Latency, sample.P95Latency, sample.P99Latency = bucket.p50, bucket.p95, bucket.p99
+			}
+		}
+		result = append(result, sample)
+	}
+	return result
+}
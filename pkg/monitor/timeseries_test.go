@@ -0,0 +1,84 @@
+package monitor
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRequestHistory(t *testing.T) {
+	history := &requestHistory{}
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	history.record(start.Add(10*time.Second), 10*time.Millisecond, true)
+	history.record(start.Add(20*time.Second), 30*time.Millisecond, false)
+	// Nothing in the second minute, then one request in the third
+	history.record(start.Add(2*time.Minute), 5*time.Millisecond, true)
+
+	samples := history.samples(start, start.Add(2*time.Minute+30*time.Second))
+	if len(samples) != 3 {
+		t.Fatalf("Expected a sample per minute, got %d", len(samples))
+	}
+	if !samples[0].Time.Equal(start) || !samples[2].Time.Equal(start.Add(2*time.Minute)) {
+		t.Errorf("Expected samples to start on their minute, got %v and %v", samples[0].Time, samples[2].Time)
+	}
+
+	first := samples[0]
+	if first.Requests != 2 || first.FailedRequests != 1 {
+		t.Errorf("Expected 2 requests and 1 failure, got %d and %d", first.Requests, first.FailedRequests)
+	}
+	if first.AverageLatency != 20*time.Millisecond || first.MaxLatency != 30*time.Millisecond {
+		t.Errorf("Expected 20ms average and 30ms max, got %v and %v", first.AverageLatency, first.MaxLatency)
+	}
+	if first.P99Latency != 30*time.Millisecond {
+		t.Errorf("Expected the p99 of the closed minute, got %v", first.P99Latency)
+	}
+	if samples[1].Requests != 0 || samples[1].P99Latency != 0 {
+		t.Errorf("Expected an empty sample for the gap, got %+v", samples[1])
+	}
+	if samples[2].Requests != 1 || samples[2].P50Latency != 5*time.Millisecond {
+		t.Errorf("Expected the live percentiles of the current minute, got %+v", samples[2])
+	}
+
+	// A day later the ring has wrapped, so the old minutes are gone
+	later := start.Add(HistoryRetention + time.Minute)
+	history.record(later, time.Millisecond, true)
+	samples = history.samples(start, later)
+	if len(samples) != int(historySize) {
+		t.Fatalf("Expected the samples to be capped at the retention, got %d", len(samples))
+	}
+	var requests int64
+	for _, sample := range samples {
+		requests += sample.Requests
+	}
+	if requests != 2 {
+		t.Errorf("Expected only the requests of the last day, got %d", requests)
+	}
+}
+
+func TestClusterHistory(t *testing.T) {
+	collector := &Collector{clusterMetrics: make(map[string]*ClusterMetrics)}
+	if history := collector.ClusterHistory("test-01", time.Now().Add(-time.Hour), time.Now()); history != nil {
+		t.Errorf("Expected no history for an unknown cluster, got %v", history)
+	}
+
+	collector.updateServiceMetrics("test-01", "db", "postgres", 2*time.Millisecond, true)
+	collector.updateServiceMetrics("test-01", "cache", "redis", time.Millisecond, false)
+
+	now := time.Now()
+	history := collector.ClusterHistory("test-01", now.Add(-10*time.Minute), now)
+	if len(history) != 2 {
+		t.Fatalf("Expected the history of both services, got %d", len(history))
+	}
+	samples := history["cache"]
+	if len(samples) != 11 {
+		t.Fatalf("Expected 11 samples over 10 minutes, got %d", len(samples))
+	}
+	var requests, failed int64
+	for _, sample := range samples {
+		requests += sample.Requests
+		failed += sample.FailedRequests
+	}
+	if requests != 1 || failed != 1 {
+		t.Errorf("Expected the failed request, got %d requests and %d failures", requests, failed)
+	}
+}
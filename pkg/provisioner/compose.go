@@ -0,0 +1,165 @@
+package provisioner
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/akmadan/throome/pkg/cluster"
+)
+
+// composeFile is a docker-compose.yaml
+type composeFile struct {
+	Name     string                    `yaml:"name,omitempty"`
+	Services map[string]composeService `yaml:"services"`
+	Volumes  map[string]composeVolume  `yaml:"volumes,omitempty"`
+}
+
+type composeService struct {
+	Image       string                   `yaml:"image"`
+	Entrypoint  []string                 `yaml:"entrypoint,omitempty"`
+	Command     []string                 `yaml:"command,omitempty"`
+	Environment []string                 `yaml:"environment,omitempty"`
+	Ports       []string                 `yaml:"ports"`
+	Volumes     []string                 `yaml:"volumes,omitempty"`
+	Healthcheck *composeHealthcheck      `yaml:"healthcheck,omitempty"`
+	Ulimits     map[string]composeUlimit `yaml:"ulimits,omitempty"`
+	Restart     string                   `yaml:"restart"`
+	StopGrace   string                   `yaml:"stop_grace_period,omitempty"`
+}
+
+type composeUlimit struct {
+	Soft int64 `yaml:"soft"`
+	Hard int64 `yaml:"hard"`
+}
+
+type composeHealthcheck struct {
+	Test        []string `yaml:"test"`
+	Interval    string   `yaml:"interval,omitempty"`
+	Timeout     string   `yaml:"timeout,omitempty"`
+	Retries     int      `yaml:"retries,omitempty"`
+	StartPeriod string   `yaml:"start_period,omitempty"`
+}
+
+type composeVolume struct{}
+
+// composeVariable marks where composeCredentials put a variable in a value
+var composeVariable = regexp.MustCompile("\x00([A-Z0-9_]+)\x00")
+
+// ComposeFile renders the services of a cluster into a docker-compose.yaml that runs
+// the containers the Docker provisioner would, on the same ports. Credentials
+// redacted from the configuration are rendered as variables, e.g.
+// ${ORDERS_DB_PASSWORD}, for docker compose to read from the environment or an .env
+// file.
+func ComposeFile(config *cluster.Config) ([]byte, error) {
+	compose := composeFile{
+		Name:     dnsLabel(config.Name),
+		Services: make(map[string]composeService, len(config.Services)),
+		Volumes:  make(map[string]composeVolume),
+	}
+
+	for serviceName, serviceConfig := range config.Services {
+		name := dnsLabel(serviceName)
+		serviceConfig = composeCredentials(serviceName, serviceConfig)
+
+		spec, err := newServiceSpec(&serviceConfig, "localhost")
+		if err != nil {
+			return nil, fmt.Errorf("service %s: %w", serviceName, err)
+		}
+
+		service := composeService{
+			Image:       spec.image,
+			Entrypoint:  composeValues(spec.entrypoint),
+			Command:     composeValues(spec.cmd),
+			Environment: composeValues(spec.env),
+			Ports:       []string{fmt.Sprintf("%d:%d", serviceConfig.Port, spec.internalPort)},
+			Restart:     spec.restartPolicy,
+		}
+		containerPorts := make([]int, 0, len(spec.extraPorts))
+		for containerPort := range spec.extraPorts {
+			containerPorts = append(containerPorts, containerPort)
+		}
+		sort.Ints(containerPorts)
+		for _, containerPort := range containerPorts {
+			service.Ports = append(service.Ports, fmt.Sprintf("%d:%d", spec.extraPorts[containerPort], containerPort))
+		}
+		if spec.stopTimeout > 0 {
+			service.StopGrace = fmt.Sprintf("%ds", spec.stopTimeout)
+		}
+		for _, ulimit := range spec.ulimits {
+			if service.Ulimits == nil {
+				service.Ulimits = make(map[string]composeUlimit, len(spec.ulimits))
+			}
+			service.Ulimits[ulimit.Name] = composeUlimit{Soft: ulimit.Soft, Hard: ulimit.Hard}
+		}
+
+		if volume, ok := dataVolumes[serviceConfig.Type]; ok {
+			volumeName := name + "-data"
+			compose.Volumes[volumeName] = composeVolume{}
+			service.Volumes = []string{volumeName + ":" + volume.path}
+			service.Environment = append(service.Environment, composeValues(volume.env)...)
+		}
+
+		if health := spec.healthCheck; health != nil && len(health.Test) > 0 {
+			service.Healthcheck = &composeHealthcheck{
+				Test:    composeValues(health.Test),
+				Retries: health.Retries,
+			}
+			if health.Interval > 0 {
+				service.Healthcheck.Interval = health.Interval.String()
+			}
+			if health.Timeout > 0 {
+				service.Healthcheck.Timeout = health.Timeout.String()
+			}
+			if health.StartPeriod > 0 {
+				service.Healthcheck.StartPeriod = health.StartPeriod.String()
+			}
+		}
+
+		compose.Services[name] = service
+	}
+
+	return yaml.Marshal(compose)
+}
+
+// composeCredentials marks the redacted credentials of a service, which become
+// variables named after the service
+func composeCredentials(serviceName string, config cluster.ServiceConfig) cluster.ServiceConfig {
+	prefix := strings.ToUpper(strings.ReplaceAll(dnsLabel(serviceName), "-", "_"))
+	credential := func(value, name string) string {
+		if value != cluster.RedactedSecret {
+			return value
+		}
+		return "\x00" + prefix + "_" + strings.ToUpper(name) + "\x00"
+	}
+
+	config.Password = credential(config.Password, "password")
+	if len(config.Options) > 0 {
+		options := make(map[string]interface{}, len(config.Options))
+		for key, value := range config.Options {
+			if text, ok := value.(string); ok {
+				value = credential(text, key)
+			}
+			options[key] = value
+		}
+		config.Options = options
+	}
+	return config
+}
+
+// composeValues prepares values for a compose file. Compose interpolates $ in
+// values, so literal ones are escaped, while marked credentials become variables.
+func composeValues(values []string) []string {
+	if len(values) == 0 {
+		return nil
+	}
+	prepared := make([]string, len(values))
+	for i, value := range values {
+		escaped := strings.ReplaceAll(value, "$", "$$")
+		prepared[i] = composeVariable.ReplaceAllString(escaped, "$${$1}")
+	}
+	return prepared
+}
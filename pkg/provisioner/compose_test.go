@@ -0,0 +1,67 @@
+package provisioner
+
+import (
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/akmadan/throome/pkg/cluster"
+)
+
+func TestComposeFile(t *testing.T) {
+	config := &cluster.Config{
+		Name: "Shop",
+		Services: map[string]cluster.ServiceConfig{
+			"orders_db": {Type: "postgres", Port: 5433, Password: "pa$$word", Provision: true},
+			"events":    {Type: "mqtt", Port: 1884, Provision: true},
+		},
+	}
+
+	content, err := ComposeFile(config.Redacted())
+	if err != nil {
+		t.Fatalf("Failed to render compose file: %v", err)
+	}
+	var compose composeFile
+	if err := yaml.Unmarshal(content, &compose); err != nil {
+		t.Fatalf("Failed to parse compose file: %v", err)
+	}
+
+	db, ok := compose.Services["orders-db"]
+	if !ok {
+		t.Fatalf("Expected an orders-db service, got %s", content)
+	}
+	if db.Image != "postgres:17-alpine" || db.Ports[0] != "5433:5432" {
+		t.Errorf("Unexpected image %s or ports %v", db.Image, db.Ports)
+	}
+	if !contains(db.Environment, "POSTGRES_PASSWORD=${ORDERS_DB_PASSWORD}") {
+		t.Errorf("Expected the redacted password as a variable, got %v", db.Environment)
+	}
+	if _, ok := compose.Volumes["orders-db-data"]; !ok || db.Volumes[0] != "orders-db-data:/var/lib/postgresql/data" {
+		t.Errorf("Expected a data volume, got %v", db.Volumes)
+	}
+	if db.Healthcheck == nil || db.Healthcheck.Interval != "5s" {
+		t.Errorf("Expected the provisioner's health check, got %+v", db.Healthcheck)
+	}
+
+	// Literal $ are escaped from interpolation
+	if events := compose.Services["events"]; !contains(events.Healthcheck.Test, "$$SYS/broker/uptime") {
+		t.Errorf("Expected $ to be escaped, got %v", events.Healthcheck.Test)
+	}
+	revealed, err := ComposeFile(config)
+	if err != nil {
+		t.Fatalf("Failed to render compose file: %v", err)
+	}
+	if !strings.Contains(string(revealed), "POSTGRES_PASSWORD=pa$$$$word") {
+		t.Errorf("Expected the revealed password to be escaped, got %s", revealed)
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,71 @@
+package provisioner
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+
+	"github.com/akmadan/throome/pkg/cluster"
+)
+
+// ContainerSpec describes the container that runs a custom service type
+type ContainerSpec struct {
+	Image        string
+	Env          []string
+	Cmd          []string
+	InternalPort int         // Port the service listens on inside the container
+	ExtraPorts   map[int]int // Container port -> host port, besides the service port
+	HealthCheck  []string    // Docker health check test, e.g. {"CMD", "pg_isready"}
+}
+
+// ContainerSpecFunc builds the container spec of a service from its configuration
+type ContainerSpecFunc func(config *cluster.ServiceConfig) (*ContainerSpec, error)
+
+var customSpecs = struct {
+	specs map[string]ContainerSpecFunc
+	mu    sync.RWMutex
+}{specs: make(map[string]ContainerSpecFunc)}
+
+// RegisterServiceType teaches the provisioner how to run containers for a service
+// type it does not support out of the box
+func RegisterServiceType(serviceType string, spec ContainerSpecFunc) {
+	customSpecs.mu.Lock()
+	defer customSpecs.mu.Unlock()
+
+	customSpecs.specs[serviceType] = spec
+}
+
+// customContainerSpec builds the container spec of a registered service type
+func customContainerSpec(config *cluster.ServiceConfig) (*ContainerSpec, error) {
+	customSpecs.mu.RLock()
+	spec, ok := customSpecs.specs[config.Type]
+	customSpecs.mu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unsupported service type: %s", config.Type)
+	}
+
+	containerSpec, err := spec(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build container spec for %s: %w", config.Type, err)
+	}
+	if containerSpec.Image == "" || containerSpec.InternalPort == 0 {
+		return nil, fmt.Errorf("container spec for %s needs an image and an internal port", config.Type)
+	}
+	return containerSpec, nil
+}
+
+// healthConfig converts the health check of a container spec
+func (s *ContainerSpec) healthConfig() *container.HealthConfig {
+	if len(s.HealthCheck) == 0 {
+		return nil
+	}
+	return &container.HealthConfig{
+		Test:     s.HealthCheck,
+		Interval: 5 * time.Second,
+		Timeout:  5 * time.Second,
+		Retries:  5,
+	}
+}
@@ -1,14 +1,22 @@
 package provisioner
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
 	"github.com/docker/docker/api/types/image"
 	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
 	"github.com/docker/go-connections/nat"
 
 	"github.com/akmadan/throome/internal/logger"
@@ -16,9 +24,27 @@ import (
 	"go.uber.org/zap"
 )
 
-// DockerProvisioner handles Docker container lifecycle
+// DockerProvisioner handles Docker container lifecycle, through the Docker daemon or
+// the Docker-compatible API of Podman
 type DockerProvisioner struct {
-	client *client.Client
+	client      *client.Client
+	runtime     string // docker, or podman when talking to its Docker-compatible socket
+	serviceHost string // Host services are reached at, empty for the gateway's host
+	hosts       *dockerHosts
+}
+
+// DockerHostOptions selects the Docker host a provisioner runs containers on
+type DockerHostOptions struct {
+	Host     string // e.g. tcp://builder-1:2376, empty for $DOCKER_HOST or the local daemon
+	CAFile   string // CA verifying the daemon's certificate
+	CertFile string // Client certificate, for daemons verifying their clients
+	KeyFile  string
+}
+
+// dockerHosts holds the provisioners of the other hosts clusters run on
+type dockerHosts struct {
+	mu           sync.Mutex
+	provisioners map[DockerHostOptions]*DockerProvisioner
 }
 
 // ServiceContainer represents a provisioned container
@@ -26,95 +52,137 @@ type ServiceContainer struct {
 	ContainerID string
 	Name        string
 	Type        string
+	Host        string // Host the service is reached at, empty when it is published on the gateway's host
 	Port        int
 	Status      string
 }
 
-// NewDockerProvisioner creates a new Docker provisioner
+// ManagedService is a container found on a provisioner's host, with the cluster and
+// service it was provisioned for
+type ManagedService struct {
+	ContainerID string
+	Cluster     string // Name of the cluster
+	Service     string
+	Status      string
+}
+
+// ContainerInfo holds the metadata of a provisioned container
+type ContainerInfo struct {
+	ContainerID string            `json:"container_id"`
+	Name        string            `json:"name"`
+	Image       string            `json:"image"`
+	Status      string            `json:"status"`
+	CreatedAt   string            `json:"created_at"`
+	StartedAt   string            `json:"started_at,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+}
+
+// NewDockerProvisioner creates a new Docker provisioner for the Docker host of the
+// environment
 func NewDockerProvisioner() (*DockerProvisioner, error) {
-	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	return NewDockerProvisionerForHost(DockerHostOptions{})
+}
+
+// NewDockerProvisionerForHost creates a new Docker provisioner for a Docker host.
+// Services on a remote host are reached at its hostname.
+func NewDockerProvisionerForHost(options DockerHostOptions) (*DockerProvisioner, error) {
+	cli, err := dockerClient(options)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create docker client: %w", err)
+		return nil, err
 	}
 
 	return &DockerProvisioner{
-		client: cli,
+		client:      cli,
+		runtime:     runtimeDocker,
+		serviceHost: remoteHostname(cli.DaemonHost()),
+		hosts:       &dockerHosts{provisioners: make(map[DockerHostOptions]*DockerProvisioner)},
 	}, nil
 }
 
+// dockerClient creates a Docker client for a host, with TLS when certificates are set
+func dockerClient(options DockerHostOptions) (*client.Client, error) {
+	opts := []client.Opt{client.FromEnv, client.WithAPIVersionNegotiation()}
+	if options.Host != "" {
+		opts = append(opts, client.WithHost(options.Host))
+	}
+	if options.CAFile != "" || options.CertFile != "" || options.KeyFile != "" {
+		opts = append(opts, client.WithTLSClientConfig(options.CAFile, options.CertFile, options.KeyFile))
+	}
+
+	cli, err := client.NewClientWithOpts(opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create docker client: %w", err)
+	}
+	return cli, nil
+}
+
+// ForHost returns the provisioner of another Docker host, for clusters whose
+// containers run there. Each host's provisioner is created once and shared.
+func (p *DockerProvisioner) ForHost(options DockerHostOptions) (Provisioner, error) {
+	p.hosts.mu.Lock()
+	defer p.hosts.mu.Unlock()
+
+	if provisioner, exists := p.hosts.provisioners[options]; exists {
+		return provisioner, nil
+	}
+
+	cli, err := dockerClient(options)
+	if err != nil {
+		return nil, err
+	}
+	provisioner := &DockerProvisioner{
+		client:      cli,
+		runtime:     p.runtime,
+		serviceHost: remoteHostname(cli.DaemonHost()),
+		hosts:       p.hosts,
+	}
+	p.hosts.provisioners[options] = provisioner
+
+	logger.Info("Docker host added",
+		zap.String("host", cli.DaemonHost()),
+		zap.String("runtime", p.runtime),
+	)
+	return provisioner, nil
+}
+
+// remoteHostname returns the hostname of a remote Docker host, or an empty string
+// for a daemon on the gateway's host
+func remoteHostname(daemonHost string) string {
+	parsed, err := url.Parse(daemonHost)
+	if err != nil || (parsed.Scheme != "tcp" && parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return ""
+	}
+	switch hostname := parsed.Hostname(); hostname {
+	case "", "localhost", "127.0.0.1", "::1":
+		return ""
+	default:
+		return hostname
+	}
+}
+
 // ProvisionService provisions a new service container
-func (p *DockerProvisioner) ProvisionService(ctx context.Context, serviceName string, config *cluster.ServiceConfig) (*ServiceContainer, error) {
+func (p *DockerProvisioner) ProvisionService(ctx context.Context, clusterName, serviceName string, config *cluster.ServiceConfig) (*ServiceContainer, error) {
 	logger.Info("Provisioning service",
+		zap.String("cluster", clusterName),
 		zap.String("name", serviceName),
 		zap.String("type", config.Type),
 		zap.Int("port", config.Port),
 	)
 
-	// Determine image and environment based on service type
-	var imageName string
-	var env []string
-	var healthCheck *container.HealthConfig
-
-	switch config.Type {
-	case "postgres":
-		imageName = "postgres:17-alpine"
-		env = []string{
-			fmt.Sprintf("POSTGRES_USER=%s", getOrDefault(config.Username, "postgres")),
-			fmt.Sprintf("POSTGRES_PASSWORD=%s", getOrDefault(config.Password, "password")),
-			fmt.Sprintf("POSTGRES_DB=%s", getOrDefault(config.Database, "postgres")),
-		}
-		healthCheck = &container.HealthConfig{
-			Test:     []string{"CMD-SHELL", "pg_isready -U postgres"},
-			Interval: 5 * time.Second,
-			Timeout:  3 * time.Second,
-			Retries:  3,
-		}
-
-	case "redis":
-		imageName = "redis:7-alpine"
-		env = []string{}
-		if config.Password != "" {
-			env = append(env, fmt.Sprintf("REDIS_PASSWORD=%s", config.Password))
-		}
-		healthCheck = &container.HealthConfig{
-			Test:     []string{"CMD", "redis-cli", "ping"},
-			Interval: 5 * time.Second,
-			Timeout:  3 * time.Second,
-			Retries:  3,
-		}
-
-	case "kafka":
-		// Use apache/kafka with KRaft mode (no Zookeeper needed)
-		imageName = "apache/kafka:latest"
-		env = []string{
-			"KAFKA_NODE_ID=1",
-			"KAFKA_PROCESS_ROLES=broker,controller",
-			"KAFKA_CONTROLLER_QUORUM_VOTERS=1@localhost:9093",
-			fmt.Sprintf("KAFKA_LISTENERS=PLAINTEXT://0.0.0.0:%d,CONTROLLER://0.0.0.0:9093", getInternalPort(config.Type)),
-			fmt.Sprintf("KAFKA_ADVERTISED_LISTENERS=PLAINTEXT://localhost:%d", config.Port),
-			"KAFKA_LISTENER_SECURITY_PROTOCOL_MAP=PLAINTEXT:PLAINTEXT,CONTROLLER:PLAINTEXT",
-			"KAFKA_INTER_BROKER_LISTENER_NAME=PLAINTEXT",
-			"KAFKA_CONTROLLER_LISTENER_NAMES=CONTROLLER",
-			"KAFKA_AUTO_CREATE_TOPICS_ENABLE=true",
-		}
-		// Health check - check if port is listening (wait longer for Kafka to start)
-		healthCheck = &container.HealthConfig{
-			Test:        []string{"CMD-SHELL", fmt.Sprintf("timeout 5 bash -c '</dev/tcp/localhost/%d' || exit 1", getInternalPort(config.Type))},
-			Interval:    15 * time.Second,
-			Timeout:     10 * time.Second,
-			Retries:     15,
-			StartPeriod: 60 * time.Second, // Give Kafka 60 seconds to start
-		}
-
-	default:
-		return nil, fmt.Errorf("unsupported service type: %s", config.Type)
+	spec, err := newServiceSpec(config, getOrDefault(p.serviceHost, "localhost"))
+	if err != nil {
+		return nil, err
+	}
+	imageName := spec.image
+	if p.runtime == runtimePodman {
+		imageName = qualifiedImage(imageName)
 	}
 
 	// Pull image if not present
-	logger.Info("Pulling Docker image", zap.String("image", imageName))
+	logger.Info("Pulling image", zap.String("image", imageName), zap.String("runtime", p.runtime))
 	reader, err := p.client.ImagePull(ctx, imageName, image.PullOptions{})
 	if err != nil {
-		logger.Error("Failed to pull Docker image",
+		logger.Error("Failed to pull image",
 			zap.String("image", imageName),
 			zap.Error(err))
 		return nil, fmt.Errorf("failed to pull image %s: %w", imageName, err)
@@ -123,7 +191,16 @@ func (p *DockerProvisioner) ProvisionService(ctx context.Context, serviceName st
 
 	// Wait for pull to complete
 	logger.Info("Waiting for image pull to complete", zap.String("image", imageName))
-	_, err = io.Copy(io.Discard, reader)
+	notify := pullProgressFromContext(ctx)
+	err = readPull(reader, imageName, func(progress PullProgress) {
+		logger.Debug("Pulling image",
+			zap.String("image", imageName),
+			zap.Int("percent", progress.Percent),
+			zap.String("status", progress.Status))
+		if notify != nil {
+			notify(progress)
+		}
+	})
 	if err != nil {
 		logger.Error("Failed to complete image pull",
 			zap.String("image", imageName),
@@ -140,24 +217,39 @@ func (p *DockerProvisioner) ProvisionService(ctx context.Context, serviceName st
 		nat.Port(fmt.Sprintf("%d/tcp", config.Port)): struct{}{},
 	}
 	portBindings := nat.PortMap{
-		nat.Port(fmt.Sprintf("%d/tcp", getInternalPort(config.Type))): []nat.PortBinding{
+		nat.Port(fmt.Sprintf("%d/tcp", spec.internalPort)): []nat.PortBinding{
 			{
 				HostIP:   "0.0.0.0",
 				HostPort: fmt.Sprintf("%d", config.Port),
 			},
 		},
 	}
+	for containerPort, hostPort := range spec.extraPorts {
+		port := nat.Port(fmt.Sprintf("%d/tcp", containerPort))
+		exposedPorts[port] = struct{}{}
+		portBindings[port] = []nat.PortBinding{{HostIP: "0.0.0.0", HostPort: fmt.Sprintf("%d", hostPort)}}
+	}
+
+	// Containers without a stop timeout of their own get the daemon's, 10 seconds
+	var stopTimeout *int
+	if spec.stopTimeout > 0 {
+		stopTimeout = &spec.stopTimeout
+	}
 
 	// Create container
 	logger.Info("Creating container", zap.String("name", containerName))
 	resp, err := p.client.ContainerCreate(ctx,
 		&container.Config{
 			Image:        imageName,
-			Env:          env,
+			Env:          spec.env,
+			Entrypoint:   spec.entrypoint,
+			Cmd:          spec.cmd,
 			ExposedPorts: exposedPorts,
-			Healthcheck:  healthCheck,
+			Healthcheck:  spec.healthCheck,
+			StopTimeout:  stopTimeout,
 			Labels: map[string]string{
 				"throome.managed": "true",
+				"throome.cluster": clusterName,
 				"throome.service": serviceName,
 				"throome.type":    config.Type,
 			},
@@ -165,8 +257,9 @@ func (p *DockerProvisioner) ProvisionService(ctx context.Context, serviceName st
 		&container.HostConfig{
 			PortBindings: portBindings,
 			RestartPolicy: container.RestartPolicy{
-				Name: container.RestartPolicyUnlessStopped,
+				Name: container.RestartPolicyMode(spec.restartPolicy),
 			},
+			Resources: container.Resources{Ulimits: spec.ulimits},
 		},
 		nil,
 		nil,
@@ -199,21 +292,327 @@ func (p *DockerProvisioner) ProvisionService(ctx context.Context, serviceName st
 		ContainerID: resp.ID,
 		Name:        serviceName,
 		Type:        config.Type,
+		Host:        p.serviceHost,
 		Port:        config.Port,
 		Status:      "running",
 	}, nil
 }
 
-// StopService stops a running container
+// serviceSpec describes the container that runs a service
+type serviceSpec struct {
+	image        string
+	env          []string
+	entrypoint   []string // Replaces the image's entrypoint when set
+	cmd          []string
+	internalPort int         // Port the service listens on inside the container
+	extraPorts   map[int]int // Container port -> published port, besides the service port
+	healthCheck  *container.HealthConfig
+	ulimits      []*container.Ulimit
+
+	restartPolicy string // A cluster.Restart policy
+	stopTimeout   int    // Seconds to stop before the container is killed, the runtime's default when 0
+}
+
+// newServiceSpec builds the container spec of a service. Services that tell clients
+// where to reach them advertise advertisedHost with the service's port.
+func newServiceSpec(config *cluster.ServiceConfig, advertisedHost string) (*serviceSpec, error) {
+	// Determine image and environment based on service type
+	var imageName string
+	var env []string
+	var cmd []string
+	var healthCheck *container.HealthConfig
+	extraPorts := map[int]int{} // Container port -> host port, besides the service port
+	internalPort := getInternalPort(config.Type)
+
+	switch config.Type {
+	case "postgres":
+		imageName = "postgres:17-alpine"
+		env = []string{
+			fmt.Sprintf("POSTGRES_USER=%s", getOrDefault(config.Username, "postgres")),
+			fmt.Sprintf("POSTGRES_PASSWORD=%s", getOrDefault(config.Password, "password")),
+			fmt.Sprintf("POSTGRES_DB=%s", getOrDefault(config.Database, "postgres")),
+		}
+		healthCheck = &container.HealthConfig{
+			Test:     []string{"CMD-SHELL", "pg_isready -U postgres"},
+			Interval: 5 * time.Second,
+			Timeout:  3 * time.Second,
+			Retries:  3,
+		}
+
+	case "cockroachdb":
+		// Single-node insecure mode, which accepts the root user without a password
+		imageName = "cockroachdb/cockroach:latest"
+		if config.Database != "" {
+			env = []string{fmt.Sprintf("COCKROACH_DATABASE=%s", config.Database)}
+		}
+		cmd = []string{"start-single-node", "--insecure"}
+		extraPorts[8080] = getIntOption(config.Options, "console_port", 8080)
+		healthCheck = &container.HealthConfig{
+			Test:        []string{"CMD", "cockroach", "sql", "--insecure", "-e", "SELECT 1"},
+			Interval:    5 * time.Second,
+			Timeout:     5 * time.Second,
+			Retries:     10,
+			StartPeriod: 5 * time.Second,
+		}
+
+	case "redis":
+		imageName = "redis:7-alpine"
+		env = []string{}
+		if config.Password != "" {
+			env = append(env, fmt.Sprintf("REDIS_PASSWORD=%s", config.Password))
+		}
+		healthCheck = &container.HealthConfig{
+			Test:     []string{"CMD", "redis-cli", "ping"},
+			Interval: 5 * time.Second,
+			Timeout:  3 * time.Second,
+			Retries:  3,
+		}
+
+	case "kafka":
+		// Use apache/kafka with KRaft mode (no Zookeeper needed)
+		imageName = "apache/kafka:latest"
+		env = []string{
+			"KAFKA_NODE_ID=1",
+			"KAFKA_PROCESS_ROLES=broker,controller",
+			"KAFKA_CONTROLLER_QUORUM_VOTERS=1@localhost:9093",
+			fmt.Sprintf("KAFKA_LISTENERS=PLAINTEXT://0.0.0.0:%d,CONTROLLER://0.0.0.0:9093", getInternalPort(config.Type)),
+			fmt.Sprintf("KAFKA_ADVERTISED_LISTENERS=PLAINTEXT://%s:%d", advertisedHost, config.Port),
+			"KAFKA_LISTENER_SECURITY_PROTOCOL_MAP=PLAINTEXT:PLAINTEXT,CONTROLLER:PLAINTEXT",
+			"KAFKA_INTER_BROKER_LISTENER_NAME=PLAINTEXT",
+			"KAFKA_CONTROLLER_LISTENER_NAMES=CONTROLLER",
+			"KAFKA_AUTO_CREATE_TOPICS_ENABLE=true",
+		}
+		// Health check - check if port is listening (wait longer for Kafka to start)
+		healthCheck = &container.HealthConfig{
+			Test:        []string{"CMD-SHELL", fmt.Sprintf("timeout 5 bash -c '</dev/tcp/localhost/%d' || exit 1", getInternalPort(config.Type))},
+			Interval:    15 * time.Second,
+			Timeout:     10 * time.Second,
+			Retries:     15,
+			StartPeriod: 60 * time.Second, // Give Kafka 60 seconds to start
+		}
+
+	case "mongodb":
+		imageName = "mongo:7"
+		env = []string{}
+		if config.Username != "" {
+			env = append(env,
+				fmt.Sprintf("MONGO_INITDB_ROOT_USERNAME=%s", config.Username),
+				fmt.Sprintf("MONGO_INITDB_ROOT_PASSWORD=%s", getOrDefault(config.Password, "password")),
+			)
+		}
+		if config.Database != "" {
+			env = append(env, fmt.Sprintf("MONGO_INITDB_DATABASE=%s", config.Database))
+		}
+		healthCheck = &container.HealthConfig{
+			Test:        []string{"CMD", "mongosh", "--quiet", "--eval", "db.adminCommand('ping')"},
+			Interval:    5 * time.Second,
+			Timeout:     5 * time.Second,
+			Retries:     5,
+			StartPeriod: 10 * time.Second,
+		}
+
+	case "rabbitmq":
+		imageName = "rabbitmq:3-management"
+		env = []string{
+			fmt.Sprintf("RABBITMQ_DEFAULT_USER=%s", getOrDefault(config.Username, "guest")),
+			fmt.Sprintf("RABBITMQ_DEFAULT_PASS=%s", getOrDefault(config.Password, "guest")),
+			// Allow the guest user to connect through the published port
+			"RABBITMQ_SERVER_ADDITIONAL_ERL_ARGS=-rabbit loopback_users []",
+		}
+		if config.Database != "" {
+			env = append(env, fmt.Sprintf("RABBITMQ_DEFAULT_VHOST=%s", config.Database))
+		}
+		extraPorts[15672] = getIntOption(config.Options, "management_port", 15672)
+		healthCheck = &container.HealthConfig{
+			Test:        []string{"CMD", "rabbitmq-diagnostics", "-q", "ping"},
+			Interval:    10 * time.Second,
+			Timeout:     10 * time.Second,
+			Retries:     6,
+			StartPeriod: 20 * time.Second,
+		}
+
+	case "minio", "s3":
+		// S3 services are provisioned locally as MinIO, which speaks the same API
+		imageName = "minio/minio:latest"
+		env = []string{
+			fmt.Sprintf("MINIO_ROOT_USER=%s", getOrDefault(config.Username, "minioadmin")),
+			fmt.Sprintf("MINIO_ROOT_PASSWORD=%s", getOrDefault(config.Password, "minioadmin")),
+		}
+		cmd = []string{"server", "/data", "--console-address", ":9001"}
+		extraPorts[9001] = getIntOption(config.Options, "console_port", 9001)
+		healthCheck = &container.HealthConfig{
+			Test:        []string{"CMD", "mc", "ready", "local"},
+			Interval:    5 * time.Second,
+			Timeout:     5 * time.Second,
+			Retries:     5,
+			StartPeriod: 5 * time.Second,
+		}
+
+	case "etcd":
+		imageName = "quay.io/coreos/etcd:v3.5.17"
+		env = []string{
+			"ETCD_NAME=throome",
+			"ETCD_DATA_DIR=/etcd-data",
+			fmt.Sprintf("ETCD_LISTEN_CLIENT_URLS=http://0.0.0.0:%d", getInternalPort(config.Type)),
+			fmt.Sprintf("ETCD_ADVERTISE_CLIENT_URLS=http://%s:%d", advertisedHost, config.Port),
+		}
+		// The image has no entrypoint, so the command starts the server
+		cmd = []string{"etcd"}
+		healthCheck = &container.HealthConfig{
+			Test:     []string{"CMD", "etcdctl", "endpoint", "health"},
+			Interval: 5 * time.Second,
+			Timeout:  3 * time.Second,
+			Retries:  5,
+		}
+
+	case "influxdb":
+		// Setup mode creates the organization, bucket and token on first start
+		imageName = "influxdb:2"
+		env = []string{
+			"DOCKER_INFLUXDB_INIT_MODE=setup",
+			fmt.Sprintf("DOCKER_INFLUXDB_INIT_USERNAME=%s", getOrDefault(config.Username, "admin")),
+			fmt.Sprintf("DOCKER_INFLUXDB_INIT_PASSWORD=%s", getOrDefault(config.Password, "password")),
+			fmt.Sprintf("DOCKER_INFLUXDB_INIT_ORG=%s", getOrDefault(getStringOption(config.Options, "org"), "throome")),
+			fmt.Sprintf("DOCKER_INFLUXDB_INIT_BUCKET=%s", getOrDefault(config.Database, "throome")),
+			fmt.Sprintf("DOCKER_INFLUXDB_INIT_ADMIN_TOKEN=%s", getOrDefault(getStringOption(config.Options, "token"), "throome-token")),
+		}
+		healthCheck = &container.HealthConfig{
+			Test:        []string{"CMD", "influx", "ping"},
+			Interval:    5 * time.Second,
+			Timeout:     3 * time.Second,
+			Retries:     5,
+			StartPeriod: 10 * time.Second,
+		}
+
+	case "mqtt":
+		// Mosquitto 2 only listens on localhost unless configured; the bundled
+		// no-auth config listens on all interfaces and allows anonymous clients
+		imageName = "eclipse-mosquitto:2"
+		env = []string{}
+		cmd = []string{"mosquitto", "-c", "/mosquitto-no-auth.conf"}
+		healthCheck = &container.HealthConfig{
+			Test:     []string{"CMD", "mosquitto_sub", "-t", "$SYS/broker/uptime", "-C", "1", "-W", "3"},
+			Interval: 5 * time.Second,
+			Timeout:  5 * time.Second,
+			Retries:  3,
+		}
+
+	case "vault":
+		// Dev mode keeps everything in memory and starts unsealed with a known root token
+		imageName = "hashicorp/vault:latest"
+		env = []string{
+			fmt.Sprintf("VAULT_DEV_ROOT_TOKEN_ID=%s", getOrDefault(config.Password, "root")),
+			fmt.Sprintf("VAULT_DEV_LISTEN_ADDRESS=0.0.0.0:%d", getInternalPort(config.Type)),
+			fmt.Sprintf("VAULT_ADDR=http://127.0.0.1:%d", getInternalPort(config.Type)),
+			"SKIP_SETCAP=true", // mlock needs IPC_LOCK, which dev mode does not use
+		}
+		cmd = []string{"server", "-dev"}
+		healthCheck = &container.HealthConfig{
+			Test:     []string{"CMD", "vault", "status"},
+			Interval: 5 * time.Second,
+			Timeout:  3 * time.Second,
+			Retries:  5,
+		}
+
+	case "dynamodb":
+		// DynamoDB Local accepts any credentials, so no env is needed
+		imageName = "amazon/dynamodb-local:latest"
+		cmd = []string{"-jar", "DynamoDBLocal.jar", "-sharedDb", "-inMemory"}
+		healthCheck = &container.HealthConfig{
+			// The image ships without curl, so probe the port with bash
+			Test:     []string{"CMD-SHELL", fmt.Sprintf("timeout 5 bash -c '</dev/tcp/localhost/%d' || exit 1", getInternalPort(config.Type))},
+			Interval: 5 * time.Second,
+			Timeout:  5 * time.Second,
+			Retries:  5,
+		}
+
+	case "qdrant":
+		imageName = "qdrant/qdrant:latest"
+		if config.Password != "" {
+			env = []string{fmt.Sprintf("QDRANT__SERVICE__API_KEY=%s", config.Password)}
+		}
+		healthCheck = &container.HealthConfig{
+			// The image ships without curl, so probe the port with bash
+			Test:     []string{"CMD-SHELL", fmt.Sprintf("timeout 5 bash -c '</dev/tcp/localhost/%d' || exit 1", getInternalPort(config.Type))},
+			Interval: 5 * time.Second,
+			Timeout:  5 * time.Second,
+			Retries:  5,
+		}
+
+	default:
+		// Service types registered by programs embedding the gateway
+		custom, err := customContainerSpec(config)
+		if err != nil {
+			return nil, err
+		}
+		imageName = custom.Image
+		env = custom.Env
+		cmd = custom.Cmd
+		healthCheck = custom.healthConfig()
+		internalPort = custom.InternalPort
+		for containerPort, hostPort := range custom.ExtraPorts {
+			extraPorts[containerPort] = hostPort
+		}
+	}
+
+	spec := &serviceSpec{
+		image:        imageName,
+		env:          env,
+		cmd:          cmd,
+		internalPort: internalPort,
+		extraPorts:   extraPorts,
+		healthCheck:  healthCheck,
+	}
+	if err := applyContainerOptions(spec, config.Options); err != nil {
+		return nil, err
+	}
+	if err := applyContainerConfig(spec, config.Container); err != nil {
+		return nil, err
+	}
+	return spec, nil
+}
+
+// applyContainerConfig applies the container settings of a service to its spec
+func applyContainerConfig(spec *serviceSpec, settings cluster.ContainerConfig) error {
+	if err := settings.Validate(); err != nil {
+		return err
+	}
+
+	spec.restartPolicy = getOrDefault(settings.RestartPolicy, cluster.RestartUnlessStopped)
+	spec.stopTimeout = settings.StopTimeout
+
+	// Types without a health check have nothing to tune
+	if health := spec.healthCheck; health != nil {
+		tuned := *health
+		if settings.HealthCheck.Interval > 0 {
+			tuned.Interval = time.Duration(settings.HealthCheck.Interval) * time.Second
+		}
+		if settings.HealthCheck.Retries > 0 {
+			tuned.Retries = settings.HealthCheck.Retries
+		}
+		if settings.HealthCheck.StartPeriod > 0 {
+			tuned.StartPeriod = time.Duration(settings.HealthCheck.StartPeriod) * time.Second
+		}
+		spec.healthCheck = &tuned
+	}
+	return nil
+}
+
+// StartService starts a stopped container
+func (p *DockerProvisioner) StartService(ctx context.Context, containerID string) error {
+	return p.client.ContainerStart(ctx, containerID, container.StartOptions{})
+}
+
+// StopService stops a running container, waiting for the stop timeout it was created
+// with
 func (p *DockerProvisioner) StopService(ctx context.Context, containerID string) error {
-	timeout := 10
-	return p.client.ContainerStop(ctx, containerID, container.StopOptions{Timeout: &timeout})
+	return p.client.ContainerStop(ctx, containerID, container.StopOptions{})
 }
 
-// RestartService restarts a container
+// RestartService restarts a container, waiting for the stop timeout it was created
+// with
 func (p *DockerProvisioner) RestartService(ctx context.Context, containerID string) error {
-	timeout := 10
-	return p.client.ContainerRestart(ctx, containerID, container.StopOptions{Timeout: &timeout})
+	return p.client.ContainerRestart(ctx, containerID, container.StopOptions{})
 }
 
 // RemoveService stops and removes a container
@@ -236,6 +635,160 @@ func (p *DockerProvisioner) GetContainerStatus(ctx context.Context, containerID
 	return inspect.State.Status, nil
 }
 
+// InspectService returns the metadata of a container
+func (p *DockerProvisioner) InspectService(ctx context.Context, containerID string) (*ContainerInfo, error) {
+	inspect, err := p.client.ContainerInspect(ctx, containerID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ContainerInfo{
+		ContainerID: inspect.ID,
+		Name:        strings.TrimPrefix(inspect.Name, "/"),
+		Image:       inspect.Config.Image,
+		Status:      inspect.State.Status,
+		CreatedAt:   inspect.Created,
+		StartedAt:   inspect.State.StartedAt,
+		Labels:      inspect.Config.Labels,
+	}, nil
+}
+
+// maxPortProbes is how many ports after a taken one AllocatePort tries
+const maxPortProbes = 100
+
+// AllocatePort returns a host port to publish a service on: the requested one when it
+// is free, or else the next free port after it. Ports published by the host's running
+// containers and reserved ports are taken, and so are the ports something listens on
+// when the containers run on the gateway's host.
+func (p *DockerProvisioner) AllocatePort(ctx context.Context, port int, reserved map[int]bool) (int, error) {
+	containers, err := p.client.ContainerList(ctx, container.ListOptions{})
+	if err != nil {
+		return 0, fmt.Errorf("failed to list containers: %w", err)
+	}
+	published := make(map[int]bool)
+	for _, c := range containers {
+		for _, binding := range c.Ports {
+			if binding.PublicPort != 0 {
+				published[int(binding.PublicPort)] = true
+			}
+		}
+	}
+
+	for candidate := port; candidate <= 65535 && candidate < port+maxPortProbes; candidate++ {
+		if reserved[candidate] || published[candidate] {
+			continue
+		}
+		if p.serviceHost == "" && !portFree(candidate) {
+			continue
+		}
+		return candidate, nil
+	}
+	return 0, fmt.Errorf("no free port between %d and %d", port, min(port+maxPortProbes-1, 65535))
+}
+
+// portFree reports whether nothing listens on a port of the gateway's host
+func portFree(port int) bool {
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return false
+	}
+	_ = listener.Close()
+	return true
+}
+
+// ListServices lists the containers provisioned by Throome on the Docker host,
+// including stopped ones
+func (p *DockerProvisioner) ListServices(ctx context.Context) ([]ManagedService, error) {
+	containers, err := p.client.ContainerList(ctx, container.ListOptions{
+		All:     true,
+		Filters: filters.NewArgs(filters.Arg("label", "throome.managed=true")),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list containers: %w", err)
+	}
+
+	services := make([]ManagedService, 0, len(containers))
+	for _, c := range containers {
+		services = append(services, ManagedService{
+			ContainerID: c.ID,
+			Cluster:     c.Labels["throome.cluster"],
+			Service:     c.Labels["throome.service"],
+			Status:      c.State,
+		})
+	}
+	return services, nil
+}
+
+// Logs returns the last lines a container wrote to its standard output and error
+func (p *DockerProvisioner) Logs(ctx context.Context, containerID string, tail int, timestamps bool) ([]byte, error) {
+	logs, err := p.client.ContainerLogs(ctx, containerID, container.LogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Timestamps: timestamps,
+		Tail:       strconv.Itoa(tail),
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer logs.Close()
+
+	var output bytes.Buffer
+	if _, err := stdcopy.StdCopy(&output, &output, logs); err != nil {
+		return nil, fmt.Errorf("failed to read logs: %w", err)
+	}
+	return output.Bytes(), nil
+}
+
+// Exec runs a command inside a container and returns its standard output. When stdin
+// is not nil it is streamed to the command. A non-zero exit code is returned as an
+// error carrying the command's standard error.
+func (p *DockerProvisioner) Exec(ctx context.Context, containerID string, cmd []string, stdin io.Reader) ([]byte, error) {
+	return execOutput(ctx, p, containerID, cmd, stdin)
+}
+
+// ExecStream runs a command inside a container, writing its output as it is produced,
+// and returns its exit code
+func (p *DockerProvisioner) ExecStream(ctx context.Context, containerID string, cmd []string, stdin io.Reader, stdout, stderr io.Writer) (int, error) {
+	exec, err := p.client.ContainerExecCreate(ctx, containerID, container.ExecOptions{
+		Cmd:          cmd,
+		AttachStdin:  stdin != nil,
+		AttachStdout: true,
+		AttachStderr: true,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to create exec: %w", err)
+	}
+
+	attach, err := p.client.ContainerExecAttach(ctx, exec.ID, container.ExecAttachOptions{})
+	if err != nil {
+		return 0, fmt.Errorf("failed to attach to exec: %w", err)
+	}
+	defer attach.Close()
+
+	if stdin != nil {
+		go func() {
+			_, _ = io.Copy(attach.Conn, stdin)
+			_ = attach.CloseWrite()
+		}()
+	}
+
+	// The connection outlives a cancelled context, so it is closed to stop reading
+	stop := context.AfterFunc(ctx, attach.Close)
+	defer stop()
+	if _, err := stdcopy.StdCopy(stdout, stderr, attach.Reader); err != nil {
+		if ctx.Err() != nil {
+			return 0, ctx.Err()
+		}
+		return 0, fmt.Errorf("failed to read exec output: %w", err)
+	}
+
+	inspect, err := p.client.ContainerExecInspect(ctx, exec.ID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to inspect exec: %w", err)
+	}
+	return inspect.ExitCode, nil
+}
+
 // WaitForHealthy waits for a container to become healthy
 func (p *DockerProvisioner) WaitForHealthy(ctx context.Context, containerID string, timeout time.Duration) error {
 	logger.Info("Waiting for container to be healthy",
@@ -245,6 +798,7 @@ func (p *DockerProvisioner) WaitForHealthy(ctx context.Context, containerID stri
 	deadline := time.Now().Add(timeout)
 	ticker := time.NewTicker(500 * time.Millisecond)
 	defer ticker.Stop()
+	var lastCheck time.Time
 
 	for {
 		select {
@@ -280,6 +834,17 @@ func (p *DockerProvisioner) WaitForHealthy(ctx context.Context, containerID stri
 				return nil
 			}
 
+			// Podman runs health checks from systemd timers, so on hosts without
+			// systemd they never run and the check is run here instead
+			if p.runtime == runtimePodman && len(inspect.State.Health.Log) == 0 && time.Since(lastCheck) >= 2*time.Second {
+				lastCheck = time.Now()
+				if p.runHealthCheck(ctx, containerID, inspect.Config.Healthcheck) {
+					logger.Info("Container passed its health check",
+						zap.String("container_id", containerID[:12]))
+					return nil
+				}
+			}
+
 			logger.Info("Container health check in progress",
 				zap.String("container_id", containerID[:12]),
 				zap.String("status", inspect.State.Health.Status))
@@ -287,8 +852,21 @@ func (p *DockerProvisioner) WaitForHealthy(ctx context.Context, containerID stri
 	}
 }
 
-// Close closes the Docker client
+// Ping checks that the Docker daemon, or Podman, is reachable
+func (p *DockerProvisioner) Ping(ctx context.Context) error {
+	_, err := p.client.Ping(ctx)
+	return err
+}
+
+// Close closes the Docker client, and those of the other hosts it provisioned on
 func (p *DockerProvisioner) Close() error {
+	p.hosts.mu.Lock()
+	for options, provisioner := range p.hosts.provisioners {
+		_ = provisioner.client.Close()
+		delete(p.hosts.provisioners, options)
+	}
+	p.hosts.mu.Unlock()
+
 	return p.client.Close()
 }
 
@@ -301,14 +879,53 @@ func getOrDefault(value, defaultValue string) string {
 	return value
 }
 
+// getIntOption reads an integer service option, which is a float64 when the config
+// was decoded from JSON
+func getIntOption(options map[string]interface{}, key string, defaultValue int) int {
+	switch value := options[key].(type) {
+	case int:
+		return value
+	case float64:
+		return int(value)
+	default:
+		return defaultValue
+	}
+}
+
+// getStringOption reads a string service option
+func getStringOption(options map[string]interface{}, key string) string {
+	value, _ := options[key].(string)
+	return value
+}
+
 func getInternalPort(serviceType string) int {
 	switch serviceType {
 	case "postgres":
 		return 5432
+	case "cockroachdb":
+		return 26257
 	case "redis":
 		return 6379
 	case "kafka":
 		return 9092
+	case "mongodb":
+		return 27017
+	case "rabbitmq":
+		return 5672
+	case "minio", "s3":
+		return 9000
+	case "etcd":
+		return 2379
+	case "influxdb":
+		return 8086
+	case "mqtt":
+		return 1883
+	case "vault":
+		return 8200
+	case "dynamodb":
+		return 8000
+	case "qdrant":
+		return 6333
 	default:
 		return 8080
 	}
@@ -0,0 +1,90 @@
+package provisioner
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/akmadan/throome/pkg/cluster"
+)
+
+func TestRemoteHostname(t *testing.T) {
+	cases := map[string]string{
+		"unix:///var/run/docker.sock": "",
+		"tcp://127.0.0.1:2375":        "",
+		"tcp://localhost:2376":        "",
+		"tcp://builder-1:2376":        "builder-1",
+		"tcp://10.0.4.12:2375":        "10.0.4.12",
+		"ssh://deploy@builder-2":      "",
+	}
+	for daemonHost, expected := range cases {
+		if hostname := remoteHostname(daemonHost); hostname != expected {
+			t.Errorf("Expected %s to be reached at %q, got %q", daemonHost, expected, hostname)
+		}
+	}
+}
+
+func TestDockerForHost(t *testing.T) {
+	base, err := NewDockerProvisionerForHost(DockerHostOptions{Host: "unix:///var/run/docker.sock"})
+	if err != nil {
+		t.Fatalf("Failed to create provisioner: %v", err)
+	}
+	defer base.Close()
+
+	options := DockerHostOptions{Host: "tcp://builder-1:2375"}
+	remote, err := base.ForHost(options)
+	if err != nil {
+		t.Fatalf("Failed to create provisioner for host: %v", err)
+	}
+	if remote.(*DockerProvisioner).serviceHost != "builder-1" {
+		t.Errorf("Expected services to be reached at builder-1, got %q", remote.(*DockerProvisioner).serviceHost)
+	}
+	if again, _ := base.ForHost(options); again != remote {
+		t.Error("Expected the provisioner of a host to be shared")
+	}
+}
+
+func TestPortFree(t *testing.T) {
+	listener, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+
+	if portFree(port) {
+		t.Errorf("Expected port %d to be taken while listened on", port)
+	}
+	_ = listener.Close()
+	if !portFree(port) {
+		t.Errorf("Expected port %d to be free once closed", port)
+	}
+}
+
+func TestContainerSettings(t *testing.T) {
+	spec, err := newServiceSpec(&cluster.ServiceConfig{
+		Type: "kafka",
+		Container: cluster.ContainerConfig{
+			RestartPolicy: cluster.RestartOnFailure,
+			StopTimeout:   30,
+			HealthCheck:   cluster.ContainerHealthConfig{Retries: 40, StartPeriod: 180},
+		},
+	}, "localhost")
+	if err != nil {
+		t.Fatalf("Failed to build spec: %v", err)
+	}
+	if spec.restartPolicy != cluster.RestartOnFailure || spec.stopTimeout != 30 {
+		t.Errorf("Expected the restart policy and stop timeout to be set, got %s and %d", spec.restartPolicy, spec.stopTimeout)
+	}
+	if health := spec.healthCheck; health.Retries != 40 || health.StartPeriod != 180*time.Second || health.Interval != 15*time.Second {
+		t.Errorf("Expected the retries and start period to be tuned and the interval kept, got %+v", health)
+	}
+
+	defaults, _ := newServiceSpec(&cluster.ServiceConfig{Type: "kafka"}, "localhost")
+	if defaults.restartPolicy != cluster.RestartUnlessStopped || defaults.healthCheck.StartPeriod != 60*time.Second {
+		t.Errorf("Expected the type's defaults, got %s and %+v", defaults.restartPolicy, defaults.healthCheck)
+	}
+
+	if _, err := newServiceSpec(&cluster.ServiceConfig{Type: "redis", Container: cluster.ContainerConfig{RestartPolicy: "sometimes"}}, "localhost"); err == nil {
+		t.Error("Expected an unknown restart policy to be refused")
+	}
+}
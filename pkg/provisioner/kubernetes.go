@@ -0,0 +1,737 @@
+package provisioner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/remotecommand"
+	utilexec "k8s.io/client-go/util/exec"
+
+	"github.com/akmadan/throome/internal/logger"
+	"github.com/akmadan/throome/pkg/cluster"
+	"go.uber.org/zap"
+)
+
+const (
+	kindDeployment  = "deployment"
+	kindStatefulSet = "statefulset"
+
+	// serviceContainerName is the name of the container running a service in its pod
+	serviceContainerName = "service"
+)
+
+// KubernetesOptions configures the Kubernetes provisioner
+type KubernetesOptions struct {
+	Kubeconfig      string // Path of a kubeconfig file, empty for the in-cluster configuration
+	Context         string // Kubeconfig context, empty for the current one
+	NamespacePrefix string // Prefix of the namespace created for each cluster
+	StorageClass    string // Storage class of data volumes, empty for the default one
+	StorageSize     string // Size of data volumes, e.g. "1Gi"
+}
+
+// KubernetesProvisioner runs services in a Kubernetes cluster. Each Throome cluster
+// gets a namespace, and each service a Deployment, or a StatefulSet with a data
+// volume for services that store data, behind a Service of the same name.
+type KubernetesProvisioner struct {
+	client      kubernetes.Interface
+	restConfig  *rest.Config
+	options     KubernetesOptions
+	storageSize resource.Quantity
+}
+
+// dataVolume is where a service type keeps its data, and the environment that points
+// it there
+type dataVolume struct {
+	path string
+	env  []string
+}
+
+// dataVolumes lists the service types that get a data volume, which Kubernetes runs
+// as StatefulSets
+var dataVolumes = map[string]dataVolume{
+	// Data directories must not be the mount point, which holds lost+found
+	"postgres":    {path: "/var/lib/postgresql/data", env: []string{"PGDATA=/var/lib/postgresql/data/pgdata"}},
+	"cockroachdb": {path: "/cockroach/cockroach-data"},
+	"kafka":       {path: "/var/lib/kafka/data", env: []string{"KAFKA_LOG_DIRS=/var/lib/kafka/data/logs"}},
+	"mongodb":     {path: "/data/db"},
+	"rabbitmq":    {path: "/var/lib/rabbitmq"},
+	"minio":       {path: "/data"},
+	"s3":          {path: "/data"},
+	"etcd":        {path: "/etcd-data"},
+	"influxdb":    {path: "/var/lib/influxdb2"},
+	"qdrant":      {path: "/qdrant/storage"},
+}
+
+// NewKubernetesProvisioner creates a new Kubernetes provisioner
+func NewKubernetesProvisioner(options KubernetesOptions) (*KubernetesProvisioner, error) {
+	restConfig, err := kubernetesConfig(options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load kubernetes config: %w", err)
+	}
+
+	client, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kubernetes client: %w", err)
+	}
+
+	return newKubernetesProvisioner(client, restConfig, options)
+}
+
+func newKubernetesProvisioner(client kubernetes.Interface, restConfig *rest.Config, options KubernetesOptions) (*KubernetesProvisioner, error) {
+	storageSize, err := resource.ParseQuantity(getOrDefault(options.StorageSize, "1Gi"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid storage size %q: %w", options.StorageSize, err)
+	}
+
+	return &KubernetesProvisioner{
+		client:      client,
+		restConfig:  restConfig,
+		options:     options,
+		storageSize: storageSize,
+	}, nil
+}
+
+// kubernetesConfig loads the in-cluster configuration, or a kubeconfig file when one
+// is configured or the gateway runs outside Kubernetes
+func kubernetesConfig(options KubernetesOptions) (*rest.Config, error) {
+	if options.Kubeconfig == "" && options.Context == "" {
+		if restConfig, err := rest.InClusterConfig(); err == nil {
+			return restConfig, nil
+		}
+	}
+
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	rules.ExplicitPath = options.Kubeconfig
+	overrides := &clientcmd.ConfigOverrides{CurrentContext: options.Context}
+	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, overrides).ClientConfig()
+}
+
+// ProvisionService creates the workload and Service of a service in its cluster's
+// namespace. The returned container ID is "<namespace>/<kind>/<name>".
+func (p *KubernetesProvisioner) ProvisionService(ctx context.Context, clusterName, serviceName string, config *cluster.ServiceConfig) (*ServiceContainer, error) {
+	namespace := p.namespace(clusterName)
+	name := dnsLabel(serviceName)
+	host := fmt.Sprintf("%s.%s.svc", name, namespace)
+
+	logger.Info("Provisioning service",
+		zap.String("cluster", clusterName),
+		zap.String("name", serviceName),
+		zap.String("type", config.Type),
+		zap.String("namespace", namespace),
+		zap.Int("port", config.Port),
+	)
+
+	spec, err := newServiceSpec(config, host)
+	if err != nil {
+		return nil, err
+	}
+	if len(spec.ulimits) > 0 {
+		// Pods take their limits from the node, the API has no way to set them
+		logger.Warn("Ulimits are not supported on Kubernetes, ignoring them",
+			zap.String("name", serviceName),
+		)
+	}
+	if spec.restartPolicy != cluster.RestartUnlessStopped {
+		// Workloads always restart their pods
+		logger.Warn("Restart policies are not supported on Kubernetes, ignoring it",
+			zap.String("name", serviceName),
+			zap.String("restart_policy", spec.restartPolicy),
+		)
+	}
+
+	if err := p.ensureNamespace(ctx, namespace, clusterName); err != nil {
+		return nil, err
+	}
+
+	labels := map[string]string{
+		"throome.managed": "true",
+		"throome.cluster": dnsLabel(clusterName),
+		"throome.service": name,
+		"throome.type":    dnsLabel(config.Type),
+	}
+	selector := &metav1.LabelSelector{MatchLabels: map[string]string{
+		"throome.managed": "true",
+		"throome.service": name,
+	}}
+
+	ports := []corev1.ServicePort{{
+		Name:       "service",
+		Port:       int32(config.Port),
+		TargetPort: intstr.FromInt32(int32(spec.internalPort)),
+	}}
+	containerPorts := []corev1.ContainerPort{{Name: "service", ContainerPort: int32(spec.internalPort)}}
+	for containerPort, port := range spec.extraPorts {
+		portName := fmt.Sprintf("port-%d", containerPort)
+		ports = append(ports, corev1.ServicePort{
+			Name:       portName,
+			Port:       int32(port),
+			TargetPort: intstr.FromInt32(int32(containerPort)),
+		})
+		containerPorts = append(containerPorts, corev1.ContainerPort{Name: portName, ContainerPort: int32(containerPort)})
+	}
+
+	serviceContainer := corev1.Container{
+		Name:           serviceContainerName,
+		Image:          spec.image,
+		Command:        spec.entrypoint,
+		Args:           spec.cmd,
+		Env:            envVars(spec.env),
+		Ports:          containerPorts,
+		ReadinessProbe: readinessProbe(spec),
+	}
+	template := corev1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{Labels: labels},
+		Spec:       corev1.PodSpec{Containers: []corev1.Container{serviceContainer}},
+	}
+	if spec.stopTimeout > 0 {
+		gracePeriod := int64(spec.stopTimeout)
+		template.Spec.TerminationGracePeriodSeconds = &gracePeriod
+	}
+
+	// Labels hold names as DNS labels, so the names they were provisioned under are kept
+	annotations := map[string]string{
+		"throome.cluster": clusterName,
+		"throome.service": serviceName,
+	}
+	meta := metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: labels, Annotations: annotations}
+	replicas := int32(1)
+
+	kind := kindDeployment
+	if volume, ok := dataVolumes[config.Type]; ok {
+		kind = kindStatefulSet
+		template.Spec.Containers[0].Env = append(template.Spec.Containers[0].Env, envVars(volume.env)...)
+		template.Spec.Containers[0].VolumeMounts = []corev1.VolumeMount{{Name: "data", MountPath: volume.path}}
+		// The group owns the data volume, so images running as a non-root user can write to it
+		fsGroup := int64(1000)
+		template.Spec.SecurityContext = &corev1.PodSecurityContext{FSGroup: &fsGroup}
+
+		claim := corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: "data", Labels: labels},
+			Spec: corev1.PersistentVolumeClaimSpec{
+				AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+				Resources: corev1.VolumeResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceStorage: p.storageSize},
+				},
+			},
+		}
+		if p.options.StorageClass != "" {
+			claim.Spec.StorageClassName = &p.options.StorageClass
+		}
+
+		_, err = p.client.AppsV1().StatefulSets(namespace).Create(ctx, &appsv1.StatefulSet{
+			ObjectMeta: meta,
+			Spec: appsv1.StatefulSetSpec{
+				Replicas:             &replicas,
+				Selector:             selector,
+				ServiceName:          name,
+				Template:             template,
+				VolumeClaimTemplates: []corev1.PersistentVolumeClaim{claim},
+			},
+		}, metav1.CreateOptions{})
+	} else {
+		_, err = p.client.AppsV1().Deployments(namespace).Create(ctx, &appsv1.Deployment{
+			ObjectMeta: meta,
+			Spec: appsv1.DeploymentSpec{
+				Replicas: &replicas,
+				Selector: selector,
+				Template: template,
+			},
+		}, metav1.CreateOptions{})
+	}
+	if err != nil {
+		logger.Error("Failed to create workload",
+			zap.String("namespace", namespace),
+			zap.String("name", name),
+			zap.Error(err))
+		return nil, fmt.Errorf("failed to create %s: %w", kind, err)
+	}
+	containerID := kubernetesID(namespace, kind, name)
+
+	_, err = p.client.CoreV1().Services(namespace).Create(ctx, &corev1.Service{
+		ObjectMeta: meta,
+		Spec: corev1.ServiceSpec{
+			Selector: selector.MatchLabels,
+			Ports:    ports,
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		_ = p.RemoveService(ctx, containerID)
+		return nil, fmt.Errorf("failed to create service: %w", err)
+	}
+
+	logger.Info("Service workload created",
+		zap.String("container_id", containerID),
+		zap.String("host", host),
+		zap.Int("port", config.Port),
+	)
+
+	return &ServiceContainer{
+		ContainerID: containerID,
+		Name:        serviceName,
+		Type:        config.Type,
+		Host:        host,
+		Port:        config.Port,
+		Status:      "running",
+	}, nil
+}
+
+// namespace returns the namespace of a cluster
+func (p *KubernetesProvisioner) namespace(clusterName string) string {
+	if namespace := dnsLabel(p.options.NamespacePrefix + clusterName); namespace != "" {
+		return namespace
+	}
+	return "throome"
+}
+
+// ensureNamespace creates a cluster's namespace unless it exists
+func (p *KubernetesProvisioner) ensureNamespace(ctx context.Context, namespace, clusterName string) error {
+	_, err := p.client.CoreV1().Namespaces().Create(ctx, &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: namespace,
+			Labels: map[string]string{
+				"throome.managed": "true",
+				"throome.cluster": dnsLabel(clusterName),
+			},
+		},
+	}, metav1.CreateOptions{})
+	if err != nil && !apierrors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create namespace %s: %w", namespace, err)
+	}
+	return nil
+}
+
+// StartService scales a stopped service back up
+func (p *KubernetesProvisioner) StartService(ctx context.Context, containerID string) error {
+	return p.patchWorkload(ctx, containerID, `{"spec":{"replicas":1}}`)
+}
+
+// StopService scales a service down to no pods, keeping its data volume
+func (p *KubernetesProvisioner) StopService(ctx context.Context, containerID string) error {
+	return p.patchWorkload(ctx, containerID, `{"spec":{"replicas":0}}`)
+}
+
+// RestartService replaces the pod of a service, starting it if it is stopped
+func (p *KubernetesProvisioner) RestartService(ctx context.Context, containerID string) error {
+	// The same annotation kubectl rollout restart sets
+	return p.patchWorkload(ctx, containerID, fmt.Sprintf(
+		`{"spec":{"replicas":1,"template":{"metadata":{"annotations":{"kubectl.kubernetes.io/restartedAt":%q}}}}}`,
+		time.Now().Format(time.RFC3339),
+	))
+}
+
+// patchWorkload applies a merge patch to the workload of a service
+func (p *KubernetesProvisioner) patchWorkload(ctx context.Context, containerID, patch string) error {
+	namespace, kind, name, err := parseKubernetesID(containerID)
+	if err != nil {
+		return err
+	}
+
+	if kind == kindStatefulSet {
+		_, err = p.client.AppsV1().StatefulSets(namespace).Patch(ctx, name, types.MergePatchType, []byte(patch), metav1.PatchOptions{})
+	} else {
+		_, err = p.client.AppsV1().Deployments(namespace).Patch(ctx, name, types.MergePatchType, []byte(patch), metav1.PatchOptions{})
+	}
+	return err
+}
+
+// RemoveService deletes the workload, Service and data volume of a service, and the
+// namespace once no other services are left in it
+func (p *KubernetesProvisioner) RemoveService(ctx context.Context, containerID string) error {
+	namespace, kind, name, err := parseKubernetesID(containerID)
+	if err != nil {
+		return err
+	}
+
+	propagation := metav1.DeletePropagationBackground
+	deleteOptions := metav1.DeleteOptions{PropagationPolicy: &propagation}
+	if kind == kindStatefulSet {
+		err = p.client.AppsV1().StatefulSets(namespace).Delete(ctx, name, deleteOptions)
+	} else {
+		err = p.client.AppsV1().Deployments(namespace).Delete(ctx, name, deleteOptions)
+	}
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete %s: %w", kind, err)
+	}
+
+	if err := p.client.CoreV1().Services(namespace).Delete(ctx, name, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete service: %w", err)
+	}
+
+	serviceSelector := metav1.ListOptions{LabelSelector: "throome.managed=true,throome.service=" + name}
+	if err := p.client.CoreV1().PersistentVolumeClaims(namespace).DeleteCollection(ctx, metav1.DeleteOptions{}, serviceSelector); err != nil {
+		return fmt.Errorf("failed to delete data volume: %w", err)
+	}
+
+	managed := metav1.ListOptions{LabelSelector: "throome.managed=true"}
+	deployments, err := p.client.AppsV1().Deployments(namespace).List(ctx, managed)
+	if err != nil {
+		return err
+	}
+	statefulSets, err := p.client.AppsV1().StatefulSets(namespace).List(ctx, managed)
+	if err != nil {
+		return err
+	}
+	if len(deployments.Items) == 0 && len(statefulSets.Items) == 0 {
+		if err := p.client.CoreV1().Namespaces().Delete(ctx, namespace, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete namespace %s: %w", namespace, err)
+		}
+	}
+	return nil
+}
+
+// ListServices lists the workloads provisioned by Throome in every namespace
+func (p *KubernetesProvisioner) ListServices(ctx context.Context) ([]ManagedService, error) {
+	managed := metav1.ListOptions{LabelSelector: "throome.managed=true"}
+	deployments, err := p.client.AppsV1().Deployments(metav1.NamespaceAll).List(ctx, managed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deployments: %w", err)
+	}
+	statefulSets, err := p.client.AppsV1().StatefulSets(metav1.NamespaceAll).List(ctx, managed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list statefulsets: %w", err)
+	}
+
+	var services []ManagedService
+	add := func(meta metav1.ObjectMeta, kind string, replicas *int32, ready int32) {
+		// Workloads without the names they were provisioned under cannot be matched
+		if meta.Annotations["throome.cluster"] == "" {
+			return
+		}
+		workload := &kubernetesWorkload{replicas: 1, ready: ready}
+		if replicas != nil {
+			workload.replicas = *replicas
+		}
+		services = append(services, ManagedService{
+			ContainerID: kubernetesID(meta.Namespace, kind, meta.Name),
+			Cluster:     meta.Annotations["throome.cluster"],
+			Service:     meta.Annotations["throome.service"],
+			Status:      workload.status(),
+		})
+	}
+	for _, deployment := range deployments.Items {
+		add(deployment.ObjectMeta, kindDeployment, deployment.Spec.Replicas, deployment.Status.ReadyReplicas)
+	}
+	for _, statefulSet := range statefulSets.Items {
+		add(statefulSet.ObjectMeta, kindStatefulSet, statefulSet.Spec.Replicas, statefulSet.Status.ReadyReplicas)
+	}
+	return services, nil
+}
+
+// kubernetesWorkload is the state of a service's Deployment or StatefulSet
+type kubernetesWorkload struct {
+	name      string
+	replicas  int32
+	ready     int32
+	selector  *metav1.LabelSelector
+	image     string
+	labels    map[string]string
+	createdAt metav1.Time
+}
+
+// getWorkload reads the workload of a service
+func (p *KubernetesProvisioner) getWorkload(ctx context.Context, containerID string) (*kubernetesWorkload, error) {
+	namespace, kind, name, err := parseKubernetesID(containerID)
+	if err != nil {
+		return nil, err
+	}
+
+	var meta metav1.ObjectMeta
+	var replicas *int32
+	var template corev1.PodTemplateSpec
+	workload := &kubernetesWorkload{name: namespace + "/" + name}
+	if kind == kindStatefulSet {
+		statefulSet, err := p.client.AppsV1().StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		meta, replicas, template = statefulSet.ObjectMeta, statefulSet.Spec.Replicas, statefulSet.Spec.Template
+		workload.ready = statefulSet.Status.ReadyReplicas
+		workload.selector = statefulSet.Spec.Selector
+	} else {
+		deployment, err := p.client.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		meta, replicas, template = deployment.ObjectMeta, deployment.Spec.Replicas, deployment.Spec.Template
+		workload.ready = deployment.Status.ReadyReplicas
+		workload.selector = deployment.Spec.Selector
+	}
+
+	workload.replicas = 1
+	if replicas != nil {
+		workload.replicas = *replicas
+	}
+	if len(template.Spec.Containers) > 0 {
+		workload.image = template.Spec.Containers[0].Image
+	}
+	workload.labels = meta.Labels
+	workload.createdAt = meta.CreationTimestamp
+	return workload, nil
+}
+
+// status describes a workload with the container states the Docker provisioner reports
+func (w *kubernetesWorkload) status() string {
+	switch {
+	case w.replicas == 0:
+		return "exited"
+	case w.ready > 0:
+		return "running"
+	default:
+		return "created"
+	}
+}
+
+// GetContainerStatus gets the status of a service
+func (p *KubernetesProvisioner) GetContainerStatus(ctx context.Context, containerID string) (string, error) {
+	workload, err := p.getWorkload(ctx, containerID)
+	if err != nil {
+		return "", err
+	}
+	return workload.status(), nil
+}
+
+// InspectService returns the metadata of a service's workload
+func (p *KubernetesProvisioner) InspectService(ctx context.Context, containerID string) (*ContainerInfo, error) {
+	workload, err := p.getWorkload(ctx, containerID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ContainerInfo{
+		ContainerID: containerID,
+		Name:        workload.name,
+		Image:       workload.image,
+		Status:      workload.status(),
+		CreatedAt:   workload.createdAt.UTC().Format(time.RFC3339),
+		Labels:      workload.labels,
+	}, nil
+}
+
+// Logs returns the last lines the pod of a service wrote
+func (p *KubernetesProvisioner) Logs(ctx context.Context, containerID string, tail int, timestamps bool) ([]byte, error) {
+	namespace, podName, err := p.runningPod(ctx, containerID)
+	if err != nil {
+		return nil, err
+	}
+
+	tailLines := int64(tail)
+	logs, err := p.client.CoreV1().Pods(namespace).GetLogs(podName, &corev1.PodLogOptions{
+		TailLines:  &tailLines,
+		Timestamps: timestamps,
+	}).DoRaw(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get logs: %w", err)
+	}
+	return logs, nil
+}
+
+// runningPod returns the namespace and name of a running pod of a service
+func (p *KubernetesProvisioner) runningPod(ctx context.Context, containerID string) (string, string, error) {
+	namespace, _, _, err := parseKubernetesID(containerID)
+	if err != nil {
+		return "", "", err
+	}
+	workload, err := p.getWorkload(ctx, containerID)
+	if err != nil {
+		return "", "", err
+	}
+
+	pods, err := p.client.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: metav1.FormatLabelSelector(workload.selector),
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("failed to list pods: %w", err)
+	}
+	for _, pod := range pods.Items {
+		if pod.Status.Phase == corev1.PodRunning {
+			return namespace, pod.Name, nil
+		}
+	}
+	return "", "", fmt.Errorf("no running pod for %s", workload.name)
+}
+
+// Exec runs a command in the pod of a service and returns its standard output. When
+// stdin is not nil it is streamed to the command. A non-zero exit code is returned as
+// an error carrying the command's standard error.
+func (p *KubernetesProvisioner) Exec(ctx context.Context, containerID string, cmd []string, stdin io.Reader) ([]byte, error) {
+	return execOutput(ctx, p, containerID, cmd, stdin)
+}
+
+// ExecStream runs a command in the pod of a service, writing its output as it is
+// produced, and returns its exit code
+func (p *KubernetesProvisioner) ExecStream(ctx context.Context, containerID string, cmd []string, stdin io.Reader, stdout, stderr io.Writer) (int, error) {
+	namespace, podName, err := p.runningPod(ctx, containerID)
+	if err != nil {
+		return 0, err
+	}
+
+	req := p.client.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(namespace).
+		Name(podName).
+		SubResource("exec").
+		VersionedParams(&corev1.PodExecOptions{
+			Container: serviceContainerName,
+			Command:   cmd,
+			Stdin:     stdin != nil,
+			Stdout:    true,
+			Stderr:    true,
+		}, scheme.ParameterCodec)
+	executor, err := remotecommand.NewSPDYExecutor(p.restConfig, "POST", req.URL())
+	if err != nil {
+		return 0, fmt.Errorf("failed to create exec: %w", err)
+	}
+
+	err = executor.StreamWithContext(ctx, remotecommand.StreamOptions{
+		Stdin:  stdin,
+		Stdout: stdout,
+		Stderr: stderr,
+	})
+	var exitErr utilexec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitStatus(), nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to exec: %w", err)
+	}
+	return 0, nil
+}
+
+// WaitForHealthy waits for the pod of a service to become ready
+func (p *KubernetesProvisioner) WaitForHealthy(ctx context.Context, containerID string, timeout time.Duration) error {
+	logger.Info("Waiting for service to be ready",
+		zap.String("container_id", containerID),
+		zap.Duration("timeout", timeout))
+
+	deadline := time.Now().Add(timeout)
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			if time.Now().After(deadline) {
+				return fmt.Errorf("timeout waiting for service to be ready")
+			}
+
+			workload, err := p.getWorkload(ctx, containerID)
+			if err != nil {
+				return fmt.Errorf("failed to get workload: %w", err)
+			}
+			if workload.replicas == 0 {
+				return fmt.Errorf("service is scaled down")
+			}
+			if workload.ready > 0 {
+				logger.Info("Service is ready", zap.String("container_id", containerID))
+				return nil
+			}
+		}
+	}
+}
+
+// Ping checks that the Kubernetes API server is reachable
+func (p *KubernetesProvisioner) Ping(ctx context.Context) error {
+	_, err := p.client.Discovery().ServerVersion()
+	return err
+}
+
+// Close releases the provisioner, the Kubernetes client holds no connections to close
+func (p *KubernetesProvisioner) Close() error {
+	return nil
+}
+
+// kubernetesID builds the container ID of a service's workload
+func kubernetesID(namespace, kind, name string) string {
+	return namespace + "/" + kind + "/" + name
+}
+
+// parseKubernetesID splits the container ID of a service's workload
+func parseKubernetesID(containerID string) (namespace, kind, name string, err error) {
+	parts := strings.Split(containerID, "/")
+	if len(parts) != 3 || (parts[1] != kindDeployment && parts[1] != kindStatefulSet) {
+		return "", "", "", fmt.Errorf("invalid kubernetes container ID: %s", containerID)
+	}
+	return parts[0], parts[1], parts[2], nil
+}
+
+// readinessProbe converts the health check of a service into a readiness probe,
+// falling back to checking that its port accepts connections
+func readinessProbe(spec *serviceSpec) *corev1.Probe {
+	probe := &corev1.Probe{
+		ProbeHandler: corev1.ProbeHandler{
+			TCPSocket: &corev1.TCPSocketAction{Port: intstr.FromInt32(int32(spec.internalPort))},
+		},
+		PeriodSeconds: 5,
+	}
+
+	health := spec.healthCheck
+	if health == nil {
+		return probe
+	}
+	command := healthCommand(health.Test)
+	if command == nil {
+		return probe
+	}
+	probe.ProbeHandler = corev1.ProbeHandler{Exec: &corev1.ExecAction{Command: command}}
+	probe.PeriodSeconds = probeSeconds(health.Interval)
+	probe.TimeoutSeconds = probeSeconds(health.Timeout)
+	probe.FailureThreshold = int32(health.Retries)
+	if health.StartPeriod > 0 {
+		probe.InitialDelaySeconds = probeSeconds(health.StartPeriod)
+	}
+	return probe
+}
+
+// probeSeconds converts a health check duration into probe seconds, which are at
+// least one
+func probeSeconds(d time.Duration) int32 {
+	if seconds := int32(d / time.Second); seconds > 0 {
+		return seconds
+	}
+	return 1
+}
+
+// envVars converts KEY=value pairs into container environment variables
+func envVars(env []string) []corev1.EnvVar {
+	vars := make([]corev1.EnvVar, 0, len(env))
+	for _, pair := range env {
+		name, value, _ := strings.Cut(pair, "=")
+		vars = append(vars, corev1.EnvVar{Name: name, Value: value})
+	}
+	return vars
+}
+
+// dnsLabel turns a name into a valid Kubernetes name and label value: lowercase
+// alphanumerics and dashes, at most 63 characters
+func dnsLabel(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		} else {
+			b.WriteRune('-')
+		}
+	}
+	label := b.String()
+	if len(label) > 63 {
+		label = label[:63]
+	}
+	return strings.Trim(label, "-")
+}
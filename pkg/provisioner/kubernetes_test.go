@@ -0,0 +1,104 @@
+package provisioner
+
+import (
+	"context"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+
+	"github.com/akmadan/throome/pkg/cluster"
+)
+
+func TestKubernetesProvisioner(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	p, err := newKubernetesProvisioner(client, &rest.Config{}, KubernetesOptions{NamespacePrefix: "throome-"})
+	if err != nil {
+		t.Fatalf("Failed to create provisioner: %v", err)
+	}
+
+	ctx := context.Background()
+	db, err := p.ProvisionService(ctx, "Shop", "orders_db", &cluster.ServiceConfig{Type: "postgres", Port: 5433, Provision: true})
+	if err != nil {
+		t.Fatalf("Failed to provision service: %v", err)
+	}
+	if db.ContainerID != "throome-shop/statefulset/orders-db" || db.Host != "orders-db.throome-shop.svc" {
+		t.Errorf("Unexpected container %s at %s", db.ContainerID, db.Host)
+	}
+
+	statefulSet, err := client.AppsV1().StatefulSets("throome-shop").Get(ctx, "orders-db", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Expected a StatefulSet: %v", err)
+	}
+	if claims := statefulSet.Spec.VolumeClaimTemplates; len(claims) != 1 || claims[0].Spec.Resources.Requests.Storage().String() != "1Gi" {
+		t.Errorf("Expected a 1Gi data volume, got %v", claims)
+	}
+	service, err := client.CoreV1().Services("throome-shop").Get(ctx, "orders-db", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Expected a Service: %v", err)
+	}
+	if port := service.Spec.Ports[0]; port.Port != 5433 || port.TargetPort.IntValue() != 5432 {
+		t.Errorf("Expected port 5433 to target 5432, got %d -> %s", port.Port, port.TargetPort.String())
+	}
+
+	cache, err := p.ProvisionService(ctx, "Shop", "cache", &cluster.ServiceConfig{Type: "redis", Port: 6380, Provision: true})
+	if err != nil {
+		t.Fatalf("Failed to provision service: %v", err)
+	}
+	if cache.ContainerID != "throome-shop/deployment/cache" {
+		t.Errorf("Expected redis to run as a Deployment, got %s", cache.ContainerID)
+	}
+
+	if err := p.StopService(ctx, cache.ContainerID); err != nil {
+		t.Fatalf("Failed to stop service: %v", err)
+	}
+	if status, _ := p.GetContainerStatus(ctx, cache.ContainerID); status != "exited" {
+		t.Errorf("Expected a stopped service to be exited, got %s", status)
+	}
+	if err := p.RestartService(ctx, cache.ContainerID); err != nil {
+		t.Fatalf("Failed to restart service: %v", err)
+	}
+	if status, _ := p.GetContainerStatus(ctx, cache.ContainerID); status != "created" {
+		t.Errorf("Expected a restarted service to be scaled up, got %s", status)
+	}
+
+	// Services are listed under the names they were provisioned with
+	services, err := p.ListServices(ctx)
+	if err != nil {
+		t.Fatalf("Failed to list services: %v", err)
+	}
+	found := false
+	for _, listed := range services {
+		if listed.ContainerID == db.ContainerID {
+			found = listed.Cluster == "Shop" && listed.Service == "orders_db"
+		}
+	}
+	if len(services) != 2 || !found {
+		t.Errorf("Expected both services to be listed, got %+v", services)
+	}
+
+	// The namespace is removed with its last service
+	if err := p.RemoveService(ctx, db.ContainerID); err != nil {
+		t.Fatalf("Failed to remove service: %v", err)
+	}
+	if _, err := client.CoreV1().Namespaces().Get(ctx, "throome-shop", metav1.GetOptions{}); err != nil {
+		t.Errorf("Expected the namespace to stay while it has services: %v", err)
+	}
+	if err := p.RemoveService(ctx, cache.ContainerID); err != nil {
+		t.Fatalf("Failed to remove service: %v", err)
+	}
+	if _, err := client.CoreV1().Namespaces().Get(ctx, "throome-shop", metav1.GetOptions{}); err == nil {
+		t.Error("Expected the empty namespace to be removed")
+	}
+}
+
+func TestParseKubernetesID(t *testing.T) {
+	if _, _, _, err := parseKubernetesID("3f2a1b9c8d7e"); err == nil {
+		t.Error("Expected a Docker container ID to be rejected")
+	}
+	namespace, kind, name, err := parseKubernetesID("throome-shop/deployment/cache")
+	if err != nil || namespace != "throome-shop" || kind != kindDeployment || name != "cache" {
+		t.Errorf("Unexpected parse: %s %s %s %v", namespace, kind, name, err)
+	}
+}
@@ -0,0 +1,181 @@
+package provisioner
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/docker/docker/api/types/container"
+)
+
+// Service options that tune the container of any service type
+const (
+	envOption     = "env"     // Variables added to the container's environment, replacing defaults of the same name
+	commandOption = "command" // Replaces the image's entrypoint
+	argsOption    = "args"    // Replaces the image's command, or the provisioner's for the service type
+	ulimitsOption = "ulimits" // Resource limits, a number for both limits or {soft, hard}
+)
+
+// applyContainerOptions applies the container options of a service to its spec
+func applyContainerOptions(spec *serviceSpec, options map[string]interface{}) error {
+	if raw, ok := options[envOption]; ok {
+		env, err := envOptionValues(raw)
+		if err != nil {
+			return err
+		}
+		spec.env = mergeEnv(spec.env, env)
+	}
+
+	if raw, ok := options[commandOption]; ok {
+		command, err := stringsOption(commandOption, raw)
+		if err != nil {
+			return err
+		}
+		spec.entrypoint = command
+	}
+
+	if raw, ok := options[argsOption]; ok {
+		args, err := stringsOption(argsOption, raw)
+		if err != nil {
+			return err
+		}
+		spec.cmd = args
+	}
+
+	if raw, ok := options[ulimitsOption]; ok {
+		ulimits, err := ulimitsOptionValues(raw)
+		if err != nil {
+			return err
+		}
+		spec.ulimits = ulimits
+	}
+	return nil
+}
+
+// envOptionValues reads the env option into NAME=value pairs, sorted by name
+func envOptionValues(raw interface{}) ([]string, error) {
+	values, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("option %s must map variable names to values", envOption)
+	}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		if name == "" || strings.Contains(name, "=") {
+			return nil, fmt.Errorf("option %s: invalid variable name %q", envOption, name)
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	env := make([]string, 0, len(names))
+	for _, name := range names {
+		value, err := scalarOption(values[name])
+		if err != nil {
+			return nil, fmt.Errorf("option %s: variable %s %w", envOption, name, err)
+		}
+		env = append(env, name+"="+value)
+	}
+	return env, nil
+}
+
+// mergeEnv adds variables to an environment, replacing those of the same name
+func mergeEnv(env, extra []string) []string {
+	merged := make([]string, 0, len(env)+len(extra))
+	replaced := make(map[string]bool, len(extra))
+	for _, variable := range extra {
+		name, _, _ := strings.Cut(variable, "=")
+		replaced[name] = true
+	}
+	for _, variable := range env {
+		if name, _, _ := strings.Cut(variable, "="); !replaced[name] {
+			merged = append(merged, variable)
+		}
+	}
+	return append(merged, extra...)
+}
+
+// stringsOption reads an option holding a list of strings
+func stringsOption(key string, raw interface{}) ([]string, error) {
+	items, ok := raw.([]interface{})
+	if !ok || len(items) == 0 {
+		return nil, fmt.Errorf("option %s must be a non-empty list of strings", key)
+	}
+
+	values := make([]string, 0, len(items))
+	for _, item := range items {
+		value, ok := item.(string)
+		if !ok {
+			return nil, fmt.Errorf("option %s must be a non-empty list of strings", key)
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+// ulimitsOptionValues reads the ulimits option, sorted by name
+func ulimitsOptionValues(raw interface{}) ([]*container.Ulimit, error) {
+	values, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("option %s must map limit names to values", ulimitsOption)
+	}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	ulimits := make([]*container.Ulimit, 0, len(names))
+	for _, name := range names {
+		ulimit := &container.Ulimit{Name: name}
+		if limits, ok := values[name].(map[string]interface{}); ok {
+			soft, softOK := intValue(limits["soft"])
+			hard, hardOK := intValue(limits["hard"])
+			if !softOK || !hardOK || soft > hard {
+				return nil, fmt.Errorf("option %s: limit %s needs a soft limit no larger than its hard limit", ulimitsOption, name)
+			}
+			ulimit.Soft, ulimit.Hard = soft, hard
+		} else {
+			limit, ok := intValue(values[name])
+			if !ok {
+				return nil, fmt.Errorf("option %s: limit %s must be a number or {soft, hard}", ulimitsOption, name)
+			}
+			ulimit.Soft, ulimit.Hard = limit, limit
+		}
+		ulimits = append(ulimits, ulimit)
+	}
+	return ulimits, nil
+}
+
+// scalarOption formats a string, number or boolean option value
+func scalarOption(raw interface{}) (string, error) {
+	switch value := raw.(type) {
+	case string:
+		return value, nil
+	case bool:
+		return strconv.FormatBool(value), nil
+	case int:
+		return strconv.Itoa(value), nil
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64), nil
+	default:
+		return "", fmt.Errorf("must be a string, number or boolean")
+	}
+}
+
+// intValue reads a whole number decoded from YAML or JSON
+func intValue(raw interface{}) (int64, bool) {
+	switch value := raw.(type) {
+	case int:
+		return int64(value), true
+	case float64:
+		if value != float64(int64(value)) {
+			return 0, false
+		}
+		return int64(value), true
+	default:
+		return 0, false
+	}
+}
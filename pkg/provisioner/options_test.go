@@ -0,0 +1,59 @@
+package provisioner
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/docker/docker/api/types/container"
+
+	"github.com/akmadan/throome/pkg/cluster"
+)
+
+func TestContainerOptions(t *testing.T) {
+	spec, err := newServiceSpec(&cluster.ServiceConfig{
+		Type:     "postgres",
+		Password: "secret",
+		Options: map[string]interface{}{
+			"env": map[string]interface{}{
+				"POSTGRES_INITDB_ARGS": "--data-checksums",
+				"POSTGRES_DB":          "orders",
+				"MAX_CONNECTIONS":      float64(200),
+			},
+			"command": []interface{}{"docker-entrypoint.sh"},
+			"args":    []interface{}{"postgres", "-c", "max_connections=200"},
+			"ulimits": map[string]interface{}{
+				"nofile": 65536,
+				"nproc":  map[string]interface{}{"soft": 1024, "hard": 2048},
+			},
+		},
+	}, "localhost")
+	if err != nil {
+		t.Fatalf("Failed to build spec: %v", err)
+	}
+
+	wantEnv := "[POSTGRES_USER=postgres POSTGRES_PASSWORD=secret MAX_CONNECTIONS=200 POSTGRES_DB=orders POSTGRES_INITDB_ARGS=--data-checksums]"
+	if fmt.Sprint(spec.env) != wantEnv {
+		t.Errorf("Expected env %s, got %v", wantEnv, spec.env)
+	}
+	if fmt.Sprint(spec.entrypoint) != "[docker-entrypoint.sh]" || fmt.Sprint(spec.cmd) != "[postgres -c max_connections=200]" {
+		t.Errorf("Expected the command to be overridden, got %v %v", spec.entrypoint, spec.cmd)
+	}
+	if len(spec.ulimits) != 2 ||
+		*spec.ulimits[0] != (container.Ulimit{Name: "nofile", Soft: 65536, Hard: 65536}) ||
+		*spec.ulimits[1] != (container.Ulimit{Name: "nproc", Soft: 1024, Hard: 2048}) {
+		t.Errorf("Expected nofile and nproc limits, got %v", spec.ulimits)
+	}
+
+	invalid := []map[string]interface{}{
+		{"env": []interface{}{"A=1"}},
+		{"env": map[string]interface{}{"A": []interface{}{1}}},
+		{"args": "redis-server --maxmemory 256mb"},
+		{"ulimits": map[string]interface{}{"nofile": "lots"}},
+		{"ulimits": map[string]interface{}{"nproc": map[string]interface{}{"soft": 2048, "hard": 1024}}},
+	}
+	for _, options := range invalid {
+		if _, err := newServiceSpec(&cluster.ServiceConfig{Type: "redis", Options: options}, "localhost"); err == nil {
+			t.Errorf("Expected options %v to be refused", options)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package provisioner
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/client"
+)
+
+const (
+	runtimeDocker = "docker"
+	runtimePodman = "podman"
+)
+
+// NewPodmanProvisioner creates a provisioner that runs containers with Podman through
+// its Docker-compatible API. An empty socket uses $CONTAINER_HOST, then the rootless
+// socket of the current user, then the system socket.
+func NewPodmanProvisioner(socket string) (*DockerProvisioner, error) {
+	host := podmanHost(socket)
+	cli, err := client.NewClientWithOpts(client.WithHost(host), client.WithAPIVersionNegotiation())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create podman client: %w", err)
+	}
+
+	return &DockerProvisioner{
+		client:      cli,
+		runtime:     runtimePodman,
+		serviceHost: remoteHostname(cli.DaemonHost()),
+		hosts:       &dockerHosts{provisioners: make(map[DockerHostOptions]*DockerProvisioner)},
+	}, nil
+}
+
+// podmanHost returns the address of the Podman socket
+func podmanHost(socket string) string {
+	if socket == "" {
+		socket = os.Getenv("CONTAINER_HOST")
+	}
+	if socket == "" {
+		socket = "/run/podman/podman.sock"
+		if runtimeDir := os.Getenv("XDG_RUNTIME_DIR"); runtimeDir != "" {
+			rootless := filepath.Join(runtimeDir, "podman", "podman.sock")
+			if _, err := os.Stat(rootless); err == nil {
+				socket = rootless
+			}
+		}
+	}
+	if !strings.Contains(socket, "://") {
+		socket = "unix://" + socket
+	}
+	return socket
+}
+
+// qualifiedImage prefixes short image names with Docker Hub, since Podman resolves
+// short names through its registries configuration, which refuses ambiguous names
+// without a terminal to prompt on
+func qualifiedImage(image string) string {
+	first, _, hasPath := strings.Cut(image, "/")
+	if hasPath && (strings.ContainsAny(first, ".:") || first == "localhost") {
+		return image
+	}
+	if !hasPath {
+		return "docker.io/library/" + image
+	}
+	return "docker.io/" + image
+}
+
+// runHealthCheck runs the health check of a container once and reports whether it
+// passed
+func (p *DockerProvisioner) runHealthCheck(ctx context.Context, containerID string, health *container.HealthConfig) bool {
+	if health == nil {
+		return false
+	}
+	cmd := healthCommand(health.Test)
+	if cmd == nil {
+		return false
+	}
+
+	checkCtx := ctx
+	if health.Timeout > 0 {
+		var cancel context.CancelFunc
+		checkCtx, cancel = context.WithTimeout(ctx, health.Timeout)
+		defer cancel()
+	}
+	_, err := p.Exec(checkCtx, containerID, cmd, nil)
+	return err == nil
+}
@@ -0,0 +1,35 @@
+package provisioner
+
+import "testing"
+
+func TestQualifiedImage(t *testing.T) {
+	cases := map[string]string{
+		"redis:7-alpine":              "docker.io/library/redis:7-alpine",
+		"hashicorp/vault:latest":      "docker.io/hashicorp/vault:latest",
+		"quay.io/coreos/etcd:v3.5.17": "quay.io/coreos/etcd:v3.5.17",
+		"localhost/custom:dev":        "localhost/custom:dev",
+		"registry:5000/team/app":      "registry:5000/team/app",
+	}
+	for image, expected := range cases {
+		if qualified := qualifiedImage(image); qualified != expected {
+			t.Errorf("Expected %s to be qualified as %s, got %s", image, expected, qualified)
+		}
+	}
+}
+
+func TestPodmanHost(t *testing.T) {
+	t.Setenv("CONTAINER_HOST", "")
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+
+	if host := podmanHost(""); host != "unix:///run/podman/podman.sock" {
+		t.Errorf("Expected the system socket without a rootless one, got %s", host)
+	}
+	if host := podmanHost("/tmp/podman.sock"); host != "unix:///tmp/podman.sock" {
+		t.Errorf("Expected the configured socket, got %s", host)
+	}
+
+	t.Setenv("CONTAINER_HOST", "tcp://podman.internal:8888")
+	if host := podmanHost(""); host != "tcp://podman.internal:8888" {
+		t.Errorf("Expected $CONTAINER_HOST, got %s", host)
+	}
+}
@@ -0,0 +1,66 @@
+package provisioner
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/akmadan/throome/pkg/cluster"
+)
+
+// Provisioner runs the services that clusters ask Throome to provision. Each
+// provisioned service is identified by the container ID it returns.
+type Provisioner interface {
+	ProvisionService(ctx context.Context, clusterName, serviceName string, config *cluster.ServiceConfig) (*ServiceContainer, error)
+	StartService(ctx context.Context, containerID string) error
+	StopService(ctx context.Context, containerID string) error
+	RestartService(ctx context.Context, containerID string) error
+	RemoveService(ctx context.Context, containerID string) error
+	GetContainerStatus(ctx context.Context, containerID string) (string, error)
+	InspectService(ctx context.Context, containerID string) (*ContainerInfo, error)
+	ListServices(ctx context.Context) ([]ManagedService, error)
+	Exec(ctx context.Context, containerID string, cmd []string, stdin io.Reader) ([]byte, error)
+	ExecStream(ctx context.Context, containerID string, cmd []string, stdin io.Reader, stdout, stderr io.Writer) (int, error)
+	Logs(ctx context.Context, containerID string, tail int, timestamps bool) ([]byte, error)
+	WaitForHealthy(ctx context.Context, containerID string, timeout time.Duration) error
+	Ping(ctx context.Context) error
+	Close() error
+}
+
+var (
+	_ Provisioner = (*DockerProvisioner)(nil)
+	_ Provisioner = (*KubernetesProvisioner)(nil)
+)
+
+// execOutput runs a command with ExecStream and returns its standard output, or an
+// error carrying its standard error when it exits with a non-zero code
+func execOutput(ctx context.Context, p Provisioner, containerID string, cmd []string, stdin io.Reader) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	exitCode, err := p.ExecStream(ctx, containerID, cmd, stdin, &stdout, &stderr)
+	if err != nil {
+		return nil, err
+	}
+	if exitCode != 0 {
+		return nil, fmt.Errorf("%s exited with code %d: %s", cmd[0], exitCode, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.Bytes(), nil
+}
+
+// healthCommand returns the command of a Docker health check test, or nil when the
+// test runs no command
+func healthCommand(test []string) []string {
+	if len(test) < 2 {
+		return nil
+	}
+	switch test[0] {
+	case "CMD":
+		return test[1:]
+	case "CMD-SHELL":
+		return []string{"/bin/sh", "-c", strings.Join(test[1:], " ")}
+	default:
+		return nil
+	}
+}
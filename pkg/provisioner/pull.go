@@ -0,0 +1,100 @@
+package provisioner
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/docker/docker/pkg/jsonmessage"
+)
+
+// PullProgress reports how far the pull of a service's image has got
+type PullProgress struct {
+	Image   string `json:"image"`
+	Percent int    `json:"percent"` // Of the bytes of the layers being downloaded
+	Current int64  `json:"current"`
+	Total   int64  `json:"total"`
+	Status  string `json:"status"` // Latest status reported by the daemon, such as "Downloading"
+}
+
+// PullProgressFunc is called as an image pull makes progress
+type PullProgressFunc func(progress PullProgress)
+
+type pullProgressKey struct{}
+
+// WithPullProgress returns a copy of ctx whose image pulls report their progress to fn
+func WithPullProgress(ctx context.Context, fn PullProgressFunc) context.Context {
+	return context.WithValue(ctx, pullProgressKey{}, fn)
+}
+
+// pullProgressFromContext returns the function ctx reports pull progress to, if any
+func pullProgressFromContext(ctx context.Context) PullProgressFunc {
+	fn, _ := ctx.Value(pullProgressKey{}).(PullProgressFunc)
+	return fn
+}
+
+// readPull reads the JSON messages of an image pull until it completes, reporting
+// progress whenever the downloaded share of the image grows by a percent. Errors the
+// daemon reports in the stream are returned.
+func readPull(reader io.Reader, imageName string, report PullProgressFunc) error {
+	type layer struct{ current, total int64 }
+	layers := make(map[string]*layer)
+	lastPercent := -1
+
+	decoder := json.NewDecoder(reader)
+	for {
+		var message jsonmessage.JSONMessage
+		if err := decoder.Decode(&message); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		if message.Error != nil {
+			return errors.New(message.Error.Message)
+		}
+		if message.ErrorMessage != "" {
+			return errors.New(message.ErrorMessage)
+		}
+		if message.ID == "" {
+			continue
+		}
+
+		l, exists := layers[message.ID]
+		if !exists {
+			l = &layer{}
+			layers[message.ID] = l
+		}
+		switch {
+		case message.Progress != nil && message.Progress.Total > 0 && message.Status == "Downloading":
+			l.current, l.total = message.Progress.Current, message.Progress.Total
+		case message.Status == "Download complete" || message.Status == "Pull complete":
+			l.current = l.total
+		default:
+			continue
+		}
+
+		var current, total int64
+		for _, l := range layers {
+			current += l.current
+			total += l.total
+		}
+		if total == 0 {
+			continue
+		}
+		percent := int(current * 100 / total)
+		if percent == lastPercent {
+			continue
+		}
+		lastPercent = percent
+		report(PullProgress{
+			Image:   imageName,
+			Percent: percent,
+			Current: current,
+			Total:   total,
+			Status:  fmt.Sprintf("%s %s", message.Status, message.ID),
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package provisioner
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReadPull(t *testing.T) {
+	stream := strings.Join([]string{
+		`{"status":"Pulling from library/postgres","id":"17"}`,
+		`{"status":"Pulling fs layer","id":"a"}`,
+		`{"status":"Pulling fs layer","id":"b"}`,
+		`{"status":"Downloading","progressDetail":{"current":50,"total":100},"id":"a"}`,
+		`{"status":"Downloading","progressDetail":{"current":50,"total":100},"id":"b"}`,
+		`{"status":"Downloading","progressDetail":{"current":50,"total":100},"id":"b"}`,
+		`{"status":"Download complete","id":"a"}`,
+		`{"status":"Pull complete","id":"b"}`,
+		`{"status":"Status: Downloaded newer image for postgres:17"}`,
+	}, "\n")
+
+	var percents []int
+	err := readPull(strings.NewReader(stream), "postgres:17", func(progress PullProgress) {
+		if progress.Image != "postgres:17" {
+			t.Errorf("Expected progress of postgres:17, got %s", progress.Image)
+		}
+		percents = append(percents, progress.Percent)
+	})
+	if err != nil {
+		t.Fatalf("Failed to read pull: %v", err)
+	}
+	want := []int{50, 75, 100}
+	if len(percents) != len(want) {
+		t.Fatalf("Expected progress %v, got %v", want, percents)
+	}
+	for i := range want {
+		if percents[i] != want[i] {
+			t.Fatalf("Expected progress %v, got %v", want, percents)
+		}
+	}
+
+	failed := `{"status":"Pulling fs layer","id":"a"}` + "\n" + `{"errorDetail":{"message":"toomanyrequests"},"error":"toomanyrequests"}`
+	if err := readPull(strings.NewReader(failed), "postgres:17", func(PullProgress) {}); err == nil || err.Error() != "toomanyrequests" {
+		t.Errorf("Expected the daemon's error, got %v", err)
+	}
+}
@@ -0,0 +1,58 @@
+package provisioner
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/docker/docker/api/types/container"
+)
+
+// Ways of snapshotting the data of a provisioned service
+const (
+	SnapshotTar    = "tar"    // A tar archive of the service's data directory
+	SnapshotCommit = "commit" // An image of the whole container, for development use
+)
+
+// dockerDataPaths lists where the containers the Docker provisioner runs keep their
+// data, for service types whose directory differs from their Kubernetes data volume
+// or that have none
+var dockerDataPaths = map[string]string{
+	"redis": "/data",
+	"kafka": "/tmp/kraft-combined-logs",
+}
+
+// DataPath returns the directory a service type's containers keep their data in
+func (p *DockerProvisioner) DataPath(serviceType string) (string, bool) {
+	if path, ok := dockerDataPaths[serviceType]; ok {
+		return path, true
+	}
+	volume, ok := dataVolumes[serviceType]
+	return volume.path, ok
+}
+
+// SnapshotVolume streams a tar archive of a directory of a container. The service
+// keeps running while it is copied, so stop it first for a consistent copy.
+func (p *DockerProvisioner) SnapshotVolume(ctx context.Context, containerID, path string) (io.ReadCloser, error) {
+	reader, _, err := p.client.CopyFromContainer(ctx, containerID, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy %s from container: %w", path, err)
+	}
+	return reader, nil
+}
+
+// CommitService commits the container of a cluster's service to an image tagged tag,
+// pausing it while committing, and returns the image's reference. Images do not hold
+// the contents of the container's volumes, which most images keep their data in, so
+// this suits snapshots of a development setup rather than backups.
+func (p *DockerProvisioner) CommitService(ctx context.Context, containerID, clusterName, serviceName, tag string) (string, error) {
+	reference := fmt.Sprintf("throome-snapshots/%s-%s:%s", dnsLabel(clusterName), dnsLabel(serviceName), tag)
+	if _, err := p.client.ContainerCommit(ctx, containerID, container.CommitOptions{
+		Reference: reference,
+		Comment:   "Snapshot taken by Throome",
+		Pause:     true,
+	}); err != nil {
+		return "", fmt.Errorf("failed to commit container: %w", err)
+	}
+	return reference, nil
+}
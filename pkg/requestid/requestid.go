@@ -0,0 +1,52 @@
+// Package requestid carries the ID of an API request through the contexts of the
+// operations it causes, so gateway logs, error responses and service activity of one
+// client call can be matched
+package requestid
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// Header is the request and response header carrying the request ID
+const Header = "X-Request-ID"
+
+// maxLength bounds the length of client-supplied request IDs
+const maxLength = 128
+
+type contextKey struct{}
+
+// New generates a request ID
+func New() string {
+	return uuid.New().String()
+}
+
+// Valid reports whether a client-supplied request ID may be used: it must be
+// non-empty, at most 128 characters long and consist of printable ASCII characters
+// other than spaces, so it is safe to log and echo in headers
+func Valid(id string) bool {
+	if id == "" || len(id) > maxLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// WithID returns a copy of ctx carrying the request ID
+func WithID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the request ID carried by ctx, or "" when there is none
+func FromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
@@ -0,0 +1,49 @@
+package router
+
+import (
+	"context"
+
+	"github.com/akmadan/throome/pkg/adapters"
+	"github.com/akmadan/throome/pkg/cluster"
+)
+
+// Access is what a request does with the service it is routed to
+type Access int
+
+const (
+	// AccessWrite requests are routed to services and their primary replicas. It is
+	// the access of requests that set none.
+	AccessWrite Access = iota
+	// AccessRead requests are routed to the replicas of services that take reads,
+	// or like writes while none of them is connected
+	AccessRead
+)
+
+// accessKey is the context key of a request's access
+type accessKey struct{}
+
+// WithAccess returns a context whose requests are routed for the given access
+func WithAccess(ctx context.Context, access Access) context.Context {
+	return context.WithValue(ctx, accessKey{}, access)
+}
+
+// AccessOf returns the access of a context's requests
+func AccessOf(ctx context.Context) Access {
+	access, _ := ctx.Value(accessKey{}).(Access)
+	return access
+}
+
+// Replica is the adapter of one of a service's replicas, tagged with its role
+type Replica struct {
+	Adapter adapters.Adapter
+	Role    string // A cluster.Role, replicas without one take reads only
+	Name    string // Name its health checks are recorded as, if it is checked
+}
+
+// takes reports whether the replica takes requests of the given access
+func (r Replica) takes(access Access) bool {
+	if access == AccessWrite {
+		return r.Role == cluster.RolePrimary
+	}
+	return r.Role != cluster.RolePrimary
+}
@@ -0,0 +1,123 @@
+package router
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/akmadan/throome/pkg/cluster"
+)
+
+// ErrCircuitOpen is returned when every adapter a request could be routed to has an
+// open circuit breaker
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// CircuitState is the state of a circuit breaker
+type CircuitState string
+
+const (
+	// CircuitClosed breakers let requests through
+	CircuitClosed CircuitState = "closed"
+	// CircuitOpen breakers fail requests until their reset timeout elapses
+	CircuitOpen CircuitState = "open"
+	// CircuitHalfOpen breakers let one probe request through, which closes the
+	// breaker when it succeeds and opens it again when it fails
+	CircuitHalfOpen CircuitState = "half-open"
+)
+
+// Defaults of the circuit breaker configuration
+const (
+	defaultFailureThreshold = 5
+	defaultResetTimeout     = 60 * time.Second
+)
+
+// CircuitBreaker stops routing to an adapter after consecutive failures
+type CircuitBreaker struct {
+	threshold int
+	timeout   time.Duration
+
+	state    CircuitState
+	failures int       // Consecutive failures while closed
+	openedAt time.Time // When the breaker opened, or when its probe started
+	probing  bool      // Whether the probe of a half-open breaker is in flight
+	mu       sync.Mutex
+}
+
+// NewCircuitBreaker creates a closed circuit breaker
+func NewCircuitBreaker(config cluster.CBConfig) *CircuitBreaker {
+	breaker := &CircuitBreaker{
+		threshold: config.FailureThreshold,
+		timeout:   time.Duration(config.ResetTimeout) * time.Second,
+		state:     CircuitClosed,
+	}
+	if breaker.threshold <= 0 {
+		breaker.threshold = defaultFailureThreshold
+	}
+	if breaker.timeout <= 0 {
+		breaker.timeout = defaultResetTimeout
+	}
+	return breaker
+}
+
+// State returns the state of the breaker
+func (b *CircuitBreaker) State() CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.state
+}
+
+// ready reports whether Allow would let a request through, without starting a probe
+func (b *CircuitBreaker) ready() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.state == CircuitClosed || time.Since(b.openedAt) >= b.timeout
+}
+
+// Allow reports whether a request may be sent. Once the reset timeout of an open
+// breaker elapses it turns half-open and lets one probe through; probes that never
+// report back are retried after another timeout.
+func (b *CircuitBreaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == CircuitClosed {
+		return true
+	}
+	if time.Since(b.openedAt) < b.timeout {
+		return false
+	}
+	b.state = CircuitHalfOpen
+	b.probing = true
+	b.openedAt = time.Now()
+	return true
+}
+
+// Record records the outcome of a request
+func (b *CircuitBreaker) Record(success bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch {
+	case success:
+		b.state = CircuitClosed
+		b.failures = 0
+		b.probing = false
+	case b.state == CircuitHalfOpen && b.probing:
+		b.open()
+	case b.state == CircuitClosed:
+		b.failures++
+		if b.failures >= b.threshold {
+			b.open()
+		}
+	}
+}
+
+// open opens the breaker. Caller must hold the lock.
+func (b *CircuitBreaker) open() {
+	b.state = CircuitOpen
+	b.failures = 0
+	b.probing = false
+	b.openedAt = time.Now()
+}
@@ -0,0 +1,93 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/akmadan/throome/pkg/adapters"
+	"github.com/akmadan/throome/pkg/cluster"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	breaker := NewCircuitBreaker(cluster.CBConfig{Enabled: true, FailureThreshold: 3})
+	if breaker.timeout != defaultResetTimeout {
+		t.Errorf("Expected the default reset timeout, got %v", breaker.timeout)
+	}
+
+	breaker.Record(false)
+	breaker.Record(false)
+	breaker.Record(true)
+	breaker.Record(false)
+	breaker.Record(false)
+	if breaker.State() != CircuitClosed || !breaker.Allow() {
+		t.Fatal("Expected the breaker to count consecutive failures only")
+	}
+	breaker.Record(false)
+	if breaker.State() != CircuitOpen || breaker.Allow() {
+		t.Fatal("Expected the breaker to open after three consecutive failures")
+	}
+
+	breaker.timeout = 10 * time.Millisecond
+	time.Sleep(20 * time.Millisecond)
+	if !breaker.Allow() || breaker.State() != CircuitHalfOpen {
+		t.Fatal("Expected a probe once the reset timeout elapsed")
+	}
+	if breaker.Allow() {
+		t.Error("Expected a single probe at a time")
+	}
+	breaker.Record(false)
+	if breaker.State() != CircuitOpen {
+		t.Fatal("Expected a failed probe to open the breaker again")
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	breaker.Allow()
+	breaker.Record(true)
+	if breaker.State() != CircuitClosed || !breaker.Allow() {
+		t.Error("Expected a successful probe to close the breaker")
+	}
+}
+
+func TestRouteCircuitBreaker(t *testing.T) {
+	primary := newStubAdapter("redis")
+	other := newStubAdapter("redis")
+	config := &cluster.Config{Routing: cluster.RoutingConfig{
+		CircuitBreaker: cluster.CBConfig{Enabled: true, FailureThreshold: 2},
+	}}
+	router := NewRouter(config, map[string]adapters.Adapter{"cache": primary, "backup": other})
+
+	ctx := context.Background()
+	primary.RecordRequest(time.Millisecond, false)
+	primary.RecordRequest(time.Millisecond, false)
+	if state, _ := router.Circuit(primary); state != CircuitOpen {
+		t.Fatalf("Expected the adapter's failures to open its breaker, got %q", state)
+	}
+
+	for i := 0; i < 3; i++ {
+		if name, _, err := router.RouteService(ctx, "redis"); err != nil || name != "backup" {
+			t.Fatalf("Expected requests to skip the open breaker, got %q (%v)", name, err)
+		}
+	}
+	if _, err := router.Route(ctx, "cache", "redis"); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Expected requests to the service to fail fast, got %v", err)
+	}
+
+	router.Record(other, false)
+	router.Record(other, false)
+	if _, _, err := router.RouteService(ctx, "redis"); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Expected routing to fail fast once every breaker is open, got %v", err)
+	}
+
+	router.RemoveAdapter("cache")
+	if _, exists := router.Circuit(primary); exists {
+		t.Error("Expected removed adapters to lose their breaker")
+	}
+
+	disabled := NewRouter(&cluster.Config{}, map[string]adapters.Adapter{"cache": primary})
+	primary.RecordRequest(time.Millisecond, false)
+	if _, exists := disabled.Circuit(primary); exists {
+		t.Error("Expected no breakers unless the cluster enables them")
+	}
+}
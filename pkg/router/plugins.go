@@ -0,0 +1,54 @@
+package router
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/akmadan/throome/pkg/cluster"
+)
+
+// StrategyConstructor creates a routing strategy. Every router creates its own, so
+// strategies may keep state such as counters for the cluster they route.
+type StrategyConstructor func() Strategy
+
+// customStrategies holds the constructors of routing strategies registered by
+// programs embedding the gateway
+var customStrategies = struct {
+	constructors map[string]StrategyConstructor
+	mu           sync.RWMutex
+}{constructors: make(map[string]StrategyConstructor)}
+
+// RegisterStrategy adds a routing strategy without forking the router. Clusters may
+// then name it in their routing.strategy, and their routers select adapters with the
+// strategy constructor creates.
+//
+// RegisterStrategy must be called before clusters using the strategy are loaded,
+// typically from an init function. Registering a built-in or already registered
+// strategy is an error.
+func RegisterStrategy(name string, constructor StrategyConstructor) error {
+	if constructor == nil {
+		return fmt.Errorf("strategy constructor for %s cannot be nil", name)
+	}
+
+	if err := cluster.RegisterRoutingStrategy(name); err != nil {
+		return err
+	}
+
+	customStrategies.mu.Lock()
+	customStrategies.constructors[name] = constructor
+	customStrategies.mu.Unlock()
+	return nil
+}
+
+// customStrategy creates a registered routing strategy, or returns nil when none is
+// registered under the name
+func customStrategy(name string) Strategy {
+	customStrategies.mu.RLock()
+	constructor, exists := customStrategies.constructors[name]
+	customStrategies.mu.RUnlock()
+
+	if !exists {
+		return nil
+	}
+	return constructor()
+}
@@ -0,0 +1,54 @@
+package router
+
+import (
+	"context"
+	"testing"
+
+	"github.com/akmadan/throome/pkg/adapters"
+	"github.com/akmadan/throome/pkg/cluster"
+)
+
+// lastStrategy always selects the last candidate
+type lastStrategy struct{}
+
+func (s *lastStrategy) Select(ctx context.Context, candidates []adapters.Adapter) (adapters.Adapter, error) {
+	return candidates[len(candidates)-1], nil
+}
+
+func (s *lastStrategy) Name() string { return "test-last" }
+
+func TestRegisterStrategy(t *testing.T) {
+	constructor := func() Strategy { return &lastStrategy{} }
+
+	if err := RegisterStrategy("test-last", constructor); err != nil {
+		t.Fatalf("Failed to register strategy: %v", err)
+	}
+	if err := RegisterStrategy("test-last", constructor); err == nil {
+		t.Error("Expected registering a strategy twice to fail")
+	}
+	if err := RegisterStrategy("latency", constructor); err == nil {
+		t.Error("Expected registering a built-in strategy to fail")
+	}
+	if err := RegisterStrategy("test-nil", nil); err == nil {
+		t.Error("Expected registering a nil constructor to fail")
+	}
+	if !cluster.IsRoutingStrategyRegistered("test-last") {
+		t.Error("Expected cluster configurations to accept the registered strategy")
+	}
+
+	first := newStubAdapter("postgres")
+	last := newStubAdapter("postgres")
+	router := NewRouter(&cluster.Config{Routing: cluster.RoutingConfig{Strategy: "test-last"}},
+		map[string]adapters.Adapter{"db": newStubAdapter("postgres")})
+	if _, ok := router.strategy.(*lastStrategy); !ok {
+		t.Fatalf("Expected the registered strategy, got %s", router.strategy.Name())
+	}
+
+	router.SetReplicas("db", []Replica{{Adapter: first}, {Adapter: last}})
+	reads := WithAccess(context.Background(), AccessRead)
+	for i := 0; i < 3; i++ {
+		if adapter, err := router.Route(reads, "db", "postgres"); err != nil || adapter != last {
+			t.Errorf("Expected the strategy to select the last replica, got %v (%v)", adapter, err)
+		}
+	}
+}
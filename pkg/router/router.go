@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/akmadan/throome/pkg/adapters"
 	"github.com/akmadan/throome/pkg/cluster"
@@ -13,6 +14,10 @@ import (
 type Router struct {
 	config   *cluster.Config
 	adapters map[string]adapters.Adapter
+	replicas map[string][]Replica // serviceName -> the service's replicas
+	breakers map[adapters.Adapter]*CircuitBreaker
+	sessions *sessions                     // nil unless sticky routing is enabled
+	healthy  func(serviceName string) bool // Whether a service passes its health checks, nil when unchecked
 	strategy Strategy
 	mu       sync.RWMutex
 }
@@ -22,11 +27,18 @@ func NewRouter(config *cluster.Config, adapterMap map[string]adapters.Adapter) *
 	router := &Router{
 		config:   config,
 		adapters: adapterMap,
+		replicas: make(map[string][]Replica),
+		breakers: make(map[adapters.Adapter]*CircuitBreaker),
+		sessions: newSessions(config.Routing.Sticky),
 	}
 
 	// Initialize strategy based on config
 	router.strategy = router.createStrategy(config.Routing.Strategy)
 
+	for _, adapter := range adapterMap {
+		router.observe(adapter)
+	}
+
 	return router
 }
 
@@ -43,46 +55,224 @@ func (r *Router) GetAdapter(serviceName string) (adapters.Adapter, error) {
 	return adapter, nil
 }
 
-// Route routes a request to an appropriate adapter using the configured strategy
+// Route routes a request to an appropriate adapter using the configured strategy.
+// Writes are routed to services and their primary replicas, and reads to the
+// replicas that take them, falling back to the primaries while none is connected.
+// Adapters whose circuit breaker is open are skipped, and ErrCircuitOpen is returned
+// when no other adapter is left.
 func (r *Router) Route(ctx context.Context, serviceName, serviceType string) (adapters.Adapter, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
-	// Get all adapters of the requested type
-	var candidates []adapters.Adapter
-	for _, adapter := range r.adapters {
-		if adapter.GetType() == serviceType && adapter.IsConnected() {
-			candidates = append(candidates, adapter)
-		}
+	access := AccessOf(ctx)
+	candidates, tripped := r.candidates(serviceName, serviceType, access)
+	if len(candidates) == 0 && access == AccessRead {
+		var trippedWrites bool
+		candidates, trippedWrites = r.candidates(serviceName, serviceType, AccessWrite)
+		tripped = tripped || trippedWrites
 	}
 
 	if len(candidates) == 0 {
+		if tripped {
+			return nil, circuitOpen(serviceName, serviceType)
+		}
+		if serviceName != "" {
+			return nil, fmt.Errorf("service not available: %s", serviceName)
+		}
 		return nil, fmt.Errorf("no available adapters for service type: %s", serviceType)
 	}
 
-	// If specific service name requested, return it
-	if serviceName != "" {
-		if adapter, exists := r.adapters[serviceName]; exists && adapter.IsConnected() {
-			return adapter, nil
+	// A specific service with a single candidate needs no strategy
+	selected := candidates[0]
+	if serviceName == "" || len(candidates) > 1 {
+		var err error
+		selected, err = r.selectCandidate(ctx, serviceName, serviceType, access, candidates)
+		if err != nil {
+			return nil, err
 		}
-		return nil, fmt.Errorf("service not available: %s", serviceName)
 	}
 
-	// Use strategy to select adapter
+	// Another request may have taken the probe of a half-open breaker meanwhile
+	if breaker, exists := r.breakers[selected]; exists && !breaker.Allow() {
+		return nil, circuitOpen(serviceName, serviceType)
+	}
+
+	return selected, nil
+}
+
+// selectCandidate selects the adapter of a request among candidates with the routing
+// strategy. With sticky routing, requests of clients are routed to the adapter their
+// session is pinned to while it is a candidate.
+func (r *Router) selectCandidate(ctx context.Context, serviceName, serviceType string, access Access, candidates []adapters.Adapter) (adapters.Adapter, error) {
+	clientID := ClientOf(ctx)
+	if r.sessions == nil || clientID == "" {
+		return r.selectStrategy(ctx, candidates)
+	}
+
+	key := sessionKey(clientID, serviceName, serviceType, access)
+	if pinned, ok := r.sessions.pinned(key, candidates); ok {
+		return pinned, nil
+	}
+	selected, err := r.selectStrategy(ctx, candidates)
+	if err != nil {
+		return nil, err
+	}
+	r.sessions.pin(key, selected)
+	return selected, nil
+}
+
+// selectStrategy selects an adapter among candidates with the routing strategy
+func (r *Router) selectStrategy(ctx context.Context, candidates []adapters.Adapter) (adapters.Adapter, error) {
 	selected, err := r.strategy.Select(ctx, candidates)
 	if err != nil {
 		return nil, fmt.Errorf("failed to select adapter: %w", err)
 	}
-
 	return selected, nil
 }
 
+// circuitOpen returns the error of a request whose adapters all have an open circuit
+// breaker
+func circuitOpen(serviceName, serviceType string) error {
+	if serviceName != "" {
+		return fmt.Errorf("%w: %s", ErrCircuitOpen, serviceName)
+	}
+	return fmt.Errorf("%w: every %s service", ErrCircuitOpen, serviceType)
+}
+
+// RouteService routes a request to a service of the given type like Route, and
+// returns the name of the selected service along with its adapter
+func (r *Router) RouteService(ctx context.Context, serviceType string) (string, adapters.Adapter, error) {
+	selected, err := r.Route(ctx, "", serviceType)
+	if err != nil {
+		return "", nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for name, adapter := range r.adapters {
+		if adapter == selected {
+			return name, selected, nil
+		}
+	}
+	for name, replicas := range r.replicas {
+		for _, replica := range replicas {
+			if replica.Adapter == selected {
+				return name, selected, nil
+			}
+		}
+	}
+	return "", nil, fmt.Errorf("no available adapters for service type: %s", serviceType)
+}
+
+// services returns the names of the services with an adapter or replicas. Caller
+// must hold the lock.
+func (r *Router) services() map[string]bool {
+	names := make(map[string]bool, len(r.adapters))
+	for name := range r.adapters {
+		names[name] = true
+	}
+	for name := range r.replicas {
+		names[name] = true
+	}
+	return names
+}
+
+// candidates returns the connected adapters of the given type that take requests of
+// the given access, of the named service or of every service when serviceName is
+// empty. It also reports whether adapters were left out for their open circuit
+// breaker. Caller must hold the lock.
+func (r *Router) candidates(serviceName, serviceType string, access Access) ([]adapters.Adapter, bool) {
+	var connected []adapters.Adapter
+	if serviceName != "" {
+		connected = r.appendConnected(nil, serviceName, serviceType, access)
+	} else {
+		for name := range r.services() {
+			connected = r.appendConnected(connected, name, serviceType, access)
+		}
+	}
+
+	unhealthy := r.unhealthy()
+	candidates := make([]adapters.Adapter, 0, len(connected))
+	var failing []adapters.Adapter
+	tripped := false
+	for _, adapter := range connected {
+		if breaker, exists := r.breakers[adapter]; exists && !breaker.ready() {
+			tripped = true
+			continue
+		}
+		if unhealthy[adapter] {
+			failing = append(failing, adapter)
+			continue
+		}
+		candidates = append(candidates, adapter)
+	}
+
+	// Services failing their health checks are only routed to when no other is left
+	if len(candidates) == 0 {
+		candidates = failing
+	}
+	return candidates, tripped
+}
+
+// unhealthy returns the adapters of the services and replicas failing their health
+// checks. Caller must hold the lock.
+func (r *Router) unhealthy() map[adapters.Adapter]bool {
+	if r.healthy == nil {
+		return nil
+	}
+
+	unhealthy := make(map[adapters.Adapter]bool)
+	for name, adapter := range r.adapters {
+		if !r.healthy(name) {
+			unhealthy[adapter] = true
+		}
+	}
+	for _, replicas := range r.replicas {
+		for _, replica := range replicas {
+			if replica.Name != "" && !r.healthy(replica.Name) {
+				unhealthy[replica.Adapter] = true
+			}
+		}
+	}
+	return unhealthy
+}
+
+// SetHealth sets the function reporting whether a service, or a replica by its name,
+// passes its health checks. Those that fail them are only routed to when no other
+// candidate is left.
+func (r *Router) SetHealth(healthy func(serviceName string) bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.healthy = healthy
+}
+
+// appendConnected appends the connected adapters of the given type of a service and
+// its replicas that take requests of the given access to candidates. Caller must hold
+// the lock.
+func (r *Router) appendConnected(candidates []adapters.Adapter, serviceName, serviceType string, access Access) []adapters.Adapter {
+	if adapter, exists := r.adapters[serviceName]; exists && access == AccessWrite && adapter.GetType() == serviceType && adapter.IsConnected() {
+		candidates = append(candidates, adapter)
+	}
+	for _, replica := range r.replicas[serviceName] {
+		if replica.takes(access) && replica.Adapter.GetType() == serviceType && replica.Adapter.IsConnected() {
+			candidates = append(candidates, replica.Adapter)
+		}
+	}
+	return candidates
+}
+
 // AddAdapter adds a new adapter to the router
 func (r *Router) AddAdapter(name string, adapter adapters.Adapter) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
+	if current, exists := r.adapters[name]; exists && current != adapter {
+		r.forget(current)
+	}
 	r.adapters[name] = adapter
+	r.observe(adapter)
 }
 
 // RemoveAdapter removes an adapter from the router
@@ -90,9 +280,96 @@ func (r *Router) RemoveAdapter(name string) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
+	if adapter, exists := r.adapters[name]; exists {
+		r.forget(adapter)
+	}
 	delete(r.adapters, name)
 }
 
+// SetReplicas sets the replicas of a service, which are routed to along with the
+// service itself by their role
+func (r *Router) SetReplicas(serviceName string, replicas []Replica) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Replicas that are kept keep their circuit breaker
+	kept := make(map[adapters.Adapter]bool, len(replicas))
+	for _, replica := range replicas {
+		kept[replica.Adapter] = true
+		r.observe(replica.Adapter)
+	}
+	for _, replica := range r.replicas[serviceName] {
+		if !kept[replica.Adapter] {
+			r.forget(replica.Adapter)
+		}
+	}
+
+	if len(replicas) == 0 {
+		delete(r.replicas, serviceName)
+		return
+	}
+	r.replicas[serviceName] = replicas
+}
+
+// observe gives an adapter a circuit breaker fed by the outcome of its requests, when
+// the cluster enables circuit breaking. Caller must hold the lock.
+func (r *Router) observe(adapter adapters.Adapter) {
+	observed, ok := adapters.Unwrap(adapter).(interface {
+		SetRequestObserver(observer adapters.RequestObserver)
+	})
+	if !ok {
+		return
+	}
+	if !r.config.Routing.CircuitBreaker.Enabled {
+		observed.SetRequestObserver(nil)
+		return
+	}
+	if _, exists := r.breakers[adapter]; exists {
+		return
+	}
+
+	breaker := NewCircuitBreaker(r.config.Routing.CircuitBreaker)
+	r.breakers[adapter] = breaker
+	observed.SetRequestObserver(breaker.Record)
+}
+
+// forget removes the circuit breaker of an adapter. Caller must hold the lock.
+func (r *Router) forget(adapter adapters.Adapter) {
+	if _, exists := r.breakers[adapter]; !exists {
+		return
+	}
+	delete(r.breakers, adapter)
+	if observed, ok := adapters.Unwrap(adapter).(interface {
+		SetRequestObserver(observer adapters.RequestObserver)
+	}); ok {
+		observed.SetRequestObserver(nil)
+	}
+}
+
+// Record records the outcome of a request sent to an adapter outside of its own
+// operations, such as one failed by fault injection
+func (r *Router) Record(adapter adapters.Adapter, success bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if breaker, exists := r.breakers[adapter]; exists {
+		breaker.Record(success)
+	}
+}
+
+// Circuit returns the state of an adapter's circuit breaker, and false when it has
+// none
+func (r *Router) Circuit(adapter adapters.Adapter) (CircuitState, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	breaker, exists := r.breakers[adapter]
+	if !exists {
+		return "", false
+	}
+	return breaker.State(), true
+}
+
 // GetAllAdapters returns all adapters
 func (r *Router) GetAllAdapters() map[string]adapters.Adapter {
 	r.mu.RLock()
@@ -107,6 +384,14 @@ func (r *Router) GetAllAdapters() map[string]adapters.Adapter {
 	return result
 }
 
+// GetStrategy returns the routing strategy
+func (r *Router) GetStrategy() Strategy {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.strategy
+}
+
 // UpdateStrategy updates the routing strategy
 func (r *Router) UpdateStrategy(strategyName string) {
 	r.mu.Lock()
@@ -115,6 +400,39 @@ func (r *Router) UpdateStrategy(strategyName string) {
 	r.strategy = r.createStrategy(strategyName)
 }
 
+// UpdateRouting applies new routing settings to the router. The strategy, circuit
+// breakers and sticky sessions are only replaced when their own settings change, so
+// they keep what they learned while other settings change.
+func (r *Router) UpdateRouting(routing cluster.RoutingConfig) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	previous := r.config.Routing
+	config := *r.config
+	config.Routing = routing
+	r.config = &config
+
+	if routing.Strategy != previous.Strategy {
+		r.strategy = r.createStrategy(routing.Strategy)
+	}
+	if routing.Sticky != previous.Sticky {
+		r.sessions = newSessions(routing.Sticky)
+	}
+	if routing.CircuitBreaker != previous.CircuitBreaker {
+		for adapter := range r.breakers {
+			r.forget(adapter)
+		}
+		for _, adapter := range r.adapters {
+			r.observe(adapter)
+		}
+		for _, replicas := range r.replicas {
+			for _, replica := range replicas {
+				r.observe(replica.Adapter)
+			}
+		}
+	}
+}
+
 // createStrategy creates a strategy based on the strategy name
 func (r *Router) createStrategy(strategyName string) Strategy {
 	switch strategyName {
@@ -124,11 +442,15 @@ func (r *Router) createStrategy(strategyName string) Strategy {
 		return NewLeastConnectionsStrategy()
 	case "ai":
 		return NewAIStrategy()
+	case "latency":
+		return NewLatencyStrategy()
 	case "round_robin", "":
 		return NewRoundRobinStrategy()
-	default:
-		return NewRoundRobinStrategy()
 	}
+	if strategy := customStrategy(strategyName); strategy != nil {
+		return strategy
+	}
+	return NewRoundRobinStrategy()
 }
 
 // HealthCheckAll performs health checks on all adapters
@@ -138,8 +460,9 @@ func (r *Router) HealthCheckAll(ctx context.Context) map[string]*adapters.Health
 
 	results := make(map[string]*adapters.HealthStatus)
 
+	timeout := time.Duration(r.config.Health.Timeout) * time.Second
 	for name, adapter := range r.adapters {
-		status, err := adapter.HealthCheck(ctx)
+		status, err := adapters.CheckHealth(ctx, adapter, r.config.Services[name].Probes, timeout)
 		if err != nil {
 			continue
 		}
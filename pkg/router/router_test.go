@@ -0,0 +1,220 @@
+package router
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/akmadan/throome/pkg/adapters"
+	"github.com/akmadan/throome/pkg/cluster"
+)
+
+// stubAdapter is a connected adapter of a type
+type stubAdapter struct {
+	*adapters.BaseAdapter
+}
+
+func (s *stubAdapter) Connect(ctx context.Context) error    { return nil }
+func (s *stubAdapter) Disconnect(ctx context.Context) error { return nil }
+func (s *stubAdapter) Ping(ctx context.Context) error       { return nil }
+func (s *stubAdapter) HealthCheck(ctx context.Context) (*adapters.HealthStatus, error) {
+	return &adapters.HealthStatus{Healthy: true}, nil
+}
+
+func newStubAdapter(serviceType string) *stubAdapter {
+	adapter := &stubAdapter{BaseAdapter: adapters.NewBaseAdapter(&cluster.ServiceConfig{Type: serviceType})}
+	adapter.SetConnected(true)
+	return adapter
+}
+
+func TestRouteReplicas(t *testing.T) {
+	primary := newStubAdapter("postgres")
+	replica := newStubAdapter("postgres")
+	standby := newStubAdapter("postgres")
+	router := NewRouter(&cluster.Config{}, map[string]adapters.Adapter{"db": primary})
+	router.SetReplicas("db", []Replica{
+		{Adapter: replica},
+		{Adapter: standby, Role: cluster.RolePrimary},
+	})
+
+	reads := WithAccess(context.Background(), AccessRead)
+	for i := 0; i < 4; i++ {
+		if adapter, err := router.Route(reads, "db", "postgres"); err != nil || adapter != replica {
+			t.Fatalf("Expected reads to be routed to the replica, got %v (%v)", adapter, err)
+		}
+		adapter, err := router.Route(context.Background(), "db", "postgres")
+		if err != nil || (adapter != primary && adapter != standby) {
+			t.Fatalf("Expected writes to be routed to a primary, got %v (%v)", adapter, err)
+		}
+	}
+
+	if name, adapter, err := router.RouteService(reads, "postgres"); err != nil || name != "db" || adapter != replica {
+		t.Errorf("Expected reads to be routed to the replica of db, got %q %v (%v)", name, adapter, err)
+	}
+
+	replica.SetConnected(false)
+	adapter, err := router.Route(reads, "db", "postgres")
+	if err != nil || (adapter != primary && adapter != standby) {
+		t.Errorf("Expected reads to fall back to a primary, got %v (%v)", adapter, err)
+	}
+
+	primary.SetConnected(false)
+	standby.SetConnected(false)
+	if _, err := router.Route(context.Background(), "db", "postgres"); err == nil {
+		t.Error("Expected routing to fail without a connected adapter")
+	}
+}
+
+func TestLatencyStrategy(t *testing.T) {
+	fast := newStubAdapter("postgres")
+	slow := newStubAdapter("postgres")
+	fresh := newStubAdapter("postgres")
+	strategy := NewLatencyStrategy()
+	ctx := context.Background()
+
+	for i := 0; i < 500; i++ {
+		fast.RecordRequest(20*time.Millisecond, true)
+		slow.RecordRequest(time.Millisecond, true)
+	}
+	// The slow adapter degraded recently, which its average latency hides
+	for i := 0; i < 30; i++ {
+		slow.RecordRequest(200*time.Millisecond, true)
+	}
+	if slow.GetMetrics().AverageLatency >= fast.GetMetrics().AverageLatency {
+		t.Fatal("Expected the degraded adapter to have the lower average latency")
+	}
+
+	if selected, _ := strategy.Select(ctx, []adapters.Adapter{slow, fresh, fast}); selected != fresh {
+		t.Errorf("Expected adapters without recent requests to be measured first, got %v", selected)
+	}
+	if selected, _ := strategy.Select(ctx, []adapters.Adapter{slow, fast}); selected != fast {
+		t.Errorf("Expected the adapter with the lowest p95 latency, got %v", selected)
+	}
+}
+
+func TestStickyRouting(t *testing.T) {
+	first := newStubAdapter("postgres")
+	second := newStubAdapter("postgres")
+	config := &cluster.Config{Routing: cluster.RoutingConfig{
+		Strategy: "round_robin",
+		Sticky:   cluster.StickyConfig{Enabled: true},
+	}}
+	router := NewRouter(config, map[string]adapters.Adapter{"first": first, "second": second})
+
+	alice := WithClient(context.Background(), "alice")
+	_, pinned, err := router.RouteService(alice, "postgres")
+	if err != nil {
+		t.Fatalf("Failed to route: %v", err)
+	}
+	for i := 0; i < 4; i++ {
+		if _, adapter, err := router.RouteService(alice, "postgres"); err != nil || adapter != pinned {
+			t.Fatalf("Expected the client to stay on its adapter, got %v (%v)", adapter, err)
+		}
+	}
+
+	seen := make(map[adapters.Adapter]bool)
+	for i := 0; i < 4; i++ {
+		_, adapter, err := router.RouteService(context.Background(), "postgres")
+		if err != nil {
+			t.Fatalf("Failed to route: %v", err)
+		}
+		seen[adapter] = true
+	}
+	if len(seen) != 2 {
+		t.Errorf("Expected requests without a client to be balanced, got %d adapters", len(seen))
+	}
+
+	pinned.(*stubAdapter).SetConnected(false)
+	_, moved, err := router.RouteService(alice, "postgres")
+	if err != nil || moved == pinned {
+		t.Fatalf("Expected the client to move off its disconnected adapter, got %v (%v)", moved, err)
+	}
+	pinned.(*stubAdapter).SetConnected(true)
+	if _, adapter, err := router.RouteService(alice, "postgres"); err != nil || adapter != moved {
+		t.Errorf("Expected the client to stay on the adapter it moved to, got %v (%v)", adapter, err)
+	}
+}
+
+func TestUpdateRouting(t *testing.T) {
+	adapter := newStubAdapter("redis")
+	routing := cluster.RoutingConfig{CircuitBreaker: cluster.CBConfig{Enabled: true, FailureThreshold: 1}}
+	router := NewRouter(&cluster.Config{Routing: routing}, map[string]adapters.Adapter{"cache": adapter})
+
+	adapter.RecordRequest(time.Millisecond, false)
+	if state, _ := router.Circuit(adapter); state != CircuitOpen {
+		t.Fatalf("Expected the adapter's breaker to open, got %q", state)
+	}
+
+	routing.Strategy = "latency"
+	router.UpdateRouting(routing)
+	if name := router.GetStrategy().Name(); name != "latency" {
+		t.Errorf("Expected the latency strategy, got %s", name)
+	}
+	if state, _ := router.Circuit(adapter); state != CircuitOpen {
+		t.Errorf("Expected a strategy change to keep the breaker, got %q", state)
+	}
+
+	routing.CircuitBreaker.FailureThreshold = 3
+	router.UpdateRouting(routing)
+	if state, _ := router.Circuit(adapter); state != CircuitClosed {
+		t.Errorf("Expected new breaker settings to reset the breaker, got %q", state)
+	}
+
+	routing.CircuitBreaker.Enabled = false
+	router.UpdateRouting(routing)
+	adapter.RecordRequest(time.Millisecond, false)
+	if _, exists := router.Circuit(adapter); exists {
+		t.Error("Expected disabling circuit breaking to drop the breaker")
+	}
+}
+
+func TestRouteAroundUnhealthyReplicas(t *testing.T) {
+	primary := newStubAdapter("postgres")
+	first := newStubAdapter("postgres")
+	second := newStubAdapter("postgres")
+	router := NewRouter(&cluster.Config{}, map[string]adapters.Adapter{"db": primary})
+	router.SetReplicas("db", []Replica{
+		{Adapter: first, Name: "db-replica-1"},
+		{Adapter: second, Name: "db-replica-2"},
+	})
+
+	unhealthy := map[string]bool{"db-replica-1": true}
+	router.SetHealth(func(serviceName string) bool { return !unhealthy[serviceName] })
+
+	reads := WithAccess(context.Background(), AccessRead)
+	for i := 0; i < 4; i++ {
+		if adapter, err := router.Route(reads, "db", "postgres"); err != nil || adapter != second {
+			t.Fatalf("Expected reads to avoid the unhealthy replica, got %v (%v)", adapter, err)
+		}
+	}
+
+	// With every replica unhealthy, reads still go to them rather than the primary
+	unhealthy["db-replica-2"] = true
+	if adapter, err := router.Route(reads, "db", "postgres"); err != nil || (adapter != first && adapter != second) {
+		t.Errorf("Expected reads to fall back to the unhealthy replicas, got %v (%v)", adapter, err)
+	}
+}
+
+func TestRouteAroundUnhealthy(t *testing.T) {
+	first := newStubAdapter("redis")
+	second := newStubAdapter("redis")
+	router := NewRouter(&cluster.Config{}, map[string]adapters.Adapter{"first": first, "second": second})
+
+	unhealthy := map[string]bool{"first": true}
+	router.SetHealth(func(serviceName string) bool { return !unhealthy[serviceName] })
+
+	for i := 0; i < 4; i++ {
+		if name, _, err := router.RouteService(context.Background(), "redis"); err != nil || name != "second" {
+			t.Fatalf("Expected requests to avoid the unhealthy service, got %q (%v)", name, err)
+		}
+	}
+
+	// With no healthy service left, requests still go to the unhealthy ones
+	unhealthy["second"] = true
+	if _, _, err := router.RouteService(context.Background(), "redis"); err != nil {
+		t.Errorf("Expected routing to fall back to unhealthy services, got %v", err)
+	}
+	if adapter, err := router.Route(context.Background(), "first", "redis"); err != nil || adapter != first {
+		t.Errorf("Expected the named service to be routed to, got %v (%v)", adapter, err)
+	}
+}
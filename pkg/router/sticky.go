@@ -0,0 +1,103 @@
+package router
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/akmadan/throome/pkg/adapters"
+	"github.com/akmadan/throome/pkg/cluster"
+)
+
+// Defaults of the sticky routing configuration
+const (
+	defaultStickyTTL = 5 * time.Minute
+	stickyPruneSize  = 1024 // Sessions kept before expired ones are pruned
+)
+
+// clientKey is the context key of the client a request comes from
+type clientKey struct{}
+
+// WithClient returns a context whose requests come from the given client, which
+// sticky routing pins to the adapters it was first routed to
+func WithClient(ctx context.Context, clientID string) context.Context {
+	return context.WithValue(ctx, clientKey{}, clientID)
+}
+
+// ClientOf returns the client a context's requests come from, or "" for none
+func ClientOf(ctx context.Context) string {
+	clientID, _ := ctx.Value(clientKey{}).(string)
+	return clientID
+}
+
+// session pins a client's requests to an adapter until it expires
+type session struct {
+	adapter adapters.Adapter
+	expires time.Time
+}
+
+// sessions keeps the adapters the clients of a cluster are pinned to. Sessions expire
+// once their client sent no request for the TTL.
+type sessions struct {
+	ttl      time.Duration
+	sessions map[string]*session
+	mu       sync.Mutex
+}
+
+// newSessions creates the session table of a cluster, or returns nil when sticky
+// routing is disabled
+func newSessions(config cluster.StickyConfig) *sessions {
+	if !config.Enabled {
+		return nil
+	}
+	ttl := time.Duration(config.TTL) * time.Second
+	if ttl <= 0 {
+		ttl = defaultStickyTTL
+	}
+	return &sessions{ttl: ttl, sessions: make(map[string]*session)}
+}
+
+// sessionKey returns the key of the session of a client's requests of a service, or
+// of a service type when serviceName is empty, and access
+func sessionKey(clientID, serviceName, serviceType string, access Access) string {
+	key := clientID + "\x00" + serviceName + "\x00" + serviceType
+	if access == AccessRead {
+		key += "\x00read"
+	}
+	return key
+}
+
+// pinned returns the adapter a session is pinned to when it is still a candidate,
+// extending the session
+func (s *sessions) pinned(key string, candidates []adapters.Adapter) (adapters.Adapter, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, exists := s.sessions[key]
+	if !exists || time.Now().After(current.expires) {
+		return nil, false
+	}
+	for _, candidate := range candidates {
+		if candidate == current.adapter {
+			current.expires = time.Now().Add(s.ttl)
+			return candidate, true
+		}
+	}
+	return nil, false
+}
+
+// pin pins a session to an adapter
+func (s *sessions) pin(key string, adapter adapters.Adapter) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if len(s.sessions) >= stickyPruneSize {
+		for key, current := range s.sessions {
+			if now.After(current.expires) {
+				delete(s.sessions, key)
+			}
+		}
+	}
+	s.sessions[key] = &session{adapter: adapter, expires: now.Add(s.ttl)}
+}
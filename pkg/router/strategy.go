@@ -3,7 +3,9 @@ package router
 import (
 	"context"
 	"fmt"
+	"math"
 	"sync/atomic"
+	"time"
 
 	"github.com/akmadan/throome/pkg/adapters"
 )
@@ -144,3 +146,65 @@ func (s *AIStrategy) Select(ctx context.Context, candidates []adapters.Adapter)
 func (s *AIStrategy) Name() string {
 	return "ai"
 }
+
+// latencyPercentile is the percentile of recent latencies LatencyStrategy compares
+const latencyPercentile = 95
+
+// recentLatency is implemented by adapters that keep the latencies of their recent
+// requests
+type recentLatency interface {
+	RecentLatency(percent float64) (time.Duration, bool)
+}
+
+// LatencyStrategy implements routing to the adapter with the lowest p95 latency over
+// the last minute, so adapters that slow down stop receiving traffic quickly
+type LatencyStrategy struct {
+	counter uint64
+}
+
+// NewLatencyStrategy creates a new latency strategy
+func NewLatencyStrategy() Strategy {
+	return &LatencyStrategy{counter: 0}
+}
+
+// Select selects the adapter with the lowest recent p95 latency. Adapters without
+// recent requests are tried first, in turn, so new adapters and those that were
+// avoided long enough are measured again.
+func (s *LatencyStrategy) Select(ctx context.Context, candidates []adapters.Adapter) (adapters.Adapter, error) {
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("no adapters available")
+	}
+
+	var selected adapters.Adapter
+	var unmeasured []adapters.Adapter
+	minLatency := time.Duration(math.MaxInt64)
+
+	for _, adapter := range candidates {
+		measured, ok := adapters.Unwrap(adapter).(recentLatency)
+		if !ok {
+			unmeasured = append(unmeasured, adapter)
+			continue
+		}
+		latency, ok := measured.RecentLatency(latencyPercentile)
+		if !ok {
+			unmeasured = append(unmeasured, adapter)
+			continue
+		}
+		if latency < minLatency {
+			minLatency = latency
+			selected = adapter
+		}
+	}
+
+	if len(unmeasured) > 0 {
+		index := atomic.AddUint64(&s.counter, 1) % uint64(len(unmeasured))
+		return unmeasured[index], nil
+	}
+
+	return selected, nil
+}
+
+// Name returns the strategy name
+func (s *LatencyStrategy) Name() string {
+	return "latency"
+}
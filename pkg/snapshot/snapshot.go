@@ -0,0 +1,284 @@
+package snapshot
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/akmadan/throome/pkg/cluster"
+	"github.com/akmadan/throome/pkg/provisioner"
+)
+
+// manifestFile is the name of the manifest inside a snapshot archive
+const manifestFile = "manifest.json"
+
+// namePattern restricts snapshot names to safe file names
+var namePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]*$`)
+
+// Manifest describes the contents of a snapshot
+type Manifest struct {
+	Name              string                     `json:"name"`
+	SourceClusterID   string                     `json:"source_cluster_id"`
+	SourceClusterName string                     `json:"source_cluster_name"`
+	CreatedAt         time.Time                  `json:"created_at"`
+	Config            *cluster.Config            `json:"config"`
+	Services          map[string]ServiceSnapshot `json:"services"`
+}
+
+// ServiceSnapshot describes what was captured for a single service
+type ServiceSnapshot struct {
+	Type      string                     `json:"type"`
+	Container *provisioner.ContainerInfo `json:"container,omitempty"` // Set when provisioned by Throome
+	DataFile  string                     `json:"data_file,omitempty"` // Path of the data inside the archive
+	Format    string                     `json:"format,omitempty"`    // sql, redis-dump or kafka-topics
+	Size      int64                      `json:"size"`                // Size of the data in bytes
+	Skipped   string                     `json:"skipped,omitempty"`   // Why no data was captured
+}
+
+// ValidateName validates a snapshot name
+func ValidateName(name string) error {
+	if name == "" {
+		return fmt.Errorf("snapshot name cannot be empty")
+	}
+	if len(name) > 64 {
+		return fmt.Errorf("snapshot name cannot exceed 64 characters")
+	}
+	if !namePattern.MatchString(name) {
+		return fmt.Errorf("snapshot name must contain only letters, numbers, dots, hyphens and underscores")
+	}
+	return nil
+}
+
+// Store keeps snapshots as gzipped tar archives in a directory
+type Store struct {
+	dir string
+}
+
+// NewStore creates a snapshot store
+func NewStore(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// Save writes a snapshot archive holding the manifest and the data files, keyed by
+// their path inside the archive. The archive is written to a temporary file first so
+// a failed save never leaves a partial snapshot behind.
+func (s *Store) Save(manifest *Manifest, data map[string][]byte) error {
+	if err := ValidateName(manifest.Name); err != nil {
+		return err
+	}
+	if s.Exists(manifest.Name) {
+		return fmt.Errorf("snapshot already exists: %s", manifest.Name)
+	}
+
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create snapshots directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(s.dir, manifest.Name+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot file: %w", err)
+	}
+	defer os.Remove(tmp.Name()) // No-op once renamed
+
+	if err := writeArchive(tmp, manifest, data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+
+	return os.Rename(tmp.Name(), s.path(manifest.Name))
+}
+
+// Load reads a snapshot's manifest and data files
+func (s *Store) Load(name string) (*Manifest, map[string][]byte, error) {
+	file, err := s.open(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+
+	return readArchive(file, true)
+}
+
+// Get reads a snapshot's manifest without loading its data
+func (s *Store) Get(name string) (*Manifest, error) {
+	file, err := s.open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	manifest, _, err := readArchive(file, false)
+	return manifest, err
+}
+
+// List returns the manifests of all snapshots, newest first
+func (s *Store) List() ([]*Manifest, error) {
+	entries, err := os.ReadDir(s.dir)
+	if os.IsNotExist(err) {
+		return []*Manifest{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshots directory: %w", err)
+	}
+
+	manifests := make([]*Manifest, 0)
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), ".tar.gz")
+		if entry.IsDir() || !ok {
+			continue
+		}
+
+		manifest, err := s.Get(name)
+		if err != nil {
+			continue // Skip unreadable archives
+		}
+		manifests = append(manifests, manifest)
+	}
+
+	sort.Slice(manifests, func(i, j int) bool {
+		return manifests[i].CreatedAt.After(manifests[j].CreatedAt)
+	})
+
+	return manifests, nil
+}
+
+// Delete removes a snapshot
+func (s *Store) Delete(name string) error {
+	if err := ValidateName(name); err != nil {
+		return err
+	}
+	if err := os.Remove(s.path(name)); err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("snapshot not found: %s", name)
+		}
+		return err
+	}
+	return nil
+}
+
+// Exists checks if a snapshot exists
+func (s *Store) Exists(name string) bool {
+	_, err := os.Stat(s.path(name))
+	return err == nil
+}
+
+func (s *Store) path(name string) string {
+	return filepath.Join(s.dir, name+".tar.gz")
+}
+
+func (s *Store) open(name string) (*os.File, error) {
+	if err := ValidateName(name); err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(s.path(name))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("snapshot not found: %s", name)
+	}
+	return file, err
+}
+
+func writeArchive(w io.Writer, manifest *Manifest, data map[string][]byte) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	files := map[string][]byte{manifestFile: manifestData}
+	for path, content := range data {
+		files[path] = content
+	}
+
+	// Write the manifest first so it can be read without scanning the whole archive
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		if path != manifestFile {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+	paths = append([]string{manifestFile}, paths...)
+
+	for _, path := range paths {
+		header := &tar.Header{
+			Name:    path,
+			Mode:    0o644,
+			Size:    int64(len(files[path])),
+			ModTime: manifest.CreatedAt,
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if _, err := tw.Write(files[path]); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// readArchive reads the manifest of an archive, along with its data files when
+// withData is set
+func readArchive(r io.Reader, withData bool) (*Manifest, map[string][]byte, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid snapshot archive: %w", err)
+	}
+	defer gz.Close()
+
+	var manifest *Manifest
+	data := make(map[string][]byte)
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid snapshot archive: %w", err)
+		}
+
+		if header.Name == manifestFile {
+			manifest = &Manifest{}
+			if err := json.NewDecoder(tr).Decode(manifest); err != nil {
+				return nil, nil, fmt.Errorf("invalid snapshot manifest: %w", err)
+			}
+			if !withData {
+				return manifest, nil, nil
+			}
+			continue
+		}
+
+		if withData {
+			content, err := io.ReadAll(tr)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to read %s: %w", header.Name, err)
+			}
+			data[header.Name] = content
+		}
+	}
+
+	if manifest == nil {
+		return nil, nil, fmt.Errorf("invalid snapshot archive: missing manifest")
+	}
+
+	return manifest, data, nil
+}
@@ -0,0 +1,76 @@
+package snapshot
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/akmadan/throome/pkg/cluster"
+)
+
+func TestStoreSaveLoad(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "throome-snapshots-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	store := NewStore(tmpDir)
+
+	manifest := &Manifest{
+		Name:            "golden",
+		SourceClusterID: "abc12345",
+		CreatedAt:       time.Now(),
+		Config:          cluster.DefaultConfig("abc12345", "qa"),
+		Services: map[string]ServiceSnapshot{
+			"db": {Type: "postgres", DataFile: "data/db.sql", Format: "sql", Size: 9},
+		},
+	}
+	data := map[string][]byte{"data/db.sql": []byte("SELECT 1;")}
+
+	if err := store.Save(manifest, data); err != nil {
+		t.Fatalf("Failed to save snapshot: %v", err)
+	}
+	if err := store.Save(manifest, data); err == nil {
+		t.Error("Expected error when saving a snapshot that already exists")
+	}
+
+	loaded, files, err := store.Load("golden")
+	if err != nil {
+		t.Fatalf("Failed to load snapshot: %v", err)
+	}
+	if loaded.SourceClusterID != "abc12345" || loaded.Config.Name != "qa" {
+		t.Errorf("Unexpected manifest: %+v", loaded)
+	}
+	if string(files["data/db.sql"]) != "SELECT 1;" {
+		t.Errorf("Expected data file to round trip, got %q", files["data/db.sql"])
+	}
+
+	manifests, err := store.List()
+	if err != nil {
+		t.Fatalf("Failed to list snapshots: %v", err)
+	}
+	if len(manifests) != 1 || manifests[0].Name != "golden" {
+		t.Errorf("Expected to list the saved snapshot, got %v", manifests)
+	}
+
+	if err := store.Delete("golden"); err != nil {
+		t.Fatalf("Failed to delete snapshot: %v", err)
+	}
+	if _, err := store.Get("golden"); err == nil {
+		t.Error("Expected error when getting a deleted snapshot")
+	}
+}
+
+func TestValidateName(t *testing.T) {
+	for _, name := range []string{"golden", "qa-2024.01_v2"} {
+		if err := ValidateName(name); err != nil {
+			t.Errorf("Expected %q to be valid, got %v", name, err)
+		}
+	}
+	for _, name := range []string{"", "../escape", ".hidden", "with space"} {
+		if err := ValidateName(name); err == nil {
+			t.Errorf("Expected %q to be invalid", name)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package snapshot
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// VolumeSnapshot describes a snapshot of the data of a single provisioned service
+type VolumeSnapshot struct {
+	Name      string    `json:"name"`
+	ClusterID string    `json:"cluster_id"`
+	Service   string    `json:"service"`
+	Type      string    `json:"type"`
+	Mode      string    `json:"mode"`            // tar or commit
+	Path      string    `json:"path,omitempty"`  // Directory archived from the container, for tar snapshots
+	Image     string    `json:"image,omitempty"` // Image the container was committed to, for commit snapshots
+	Size      int64     `json:"size"`            // Size of the archive in bytes
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// VolumeStore keeps snapshots of services' data in a directory per service, each
+// described by a JSON file next to its tar archive
+type VolumeStore struct {
+	dir string
+}
+
+// NewVolumeStore creates a volume snapshot store
+func NewVolumeStore(dir string) *VolumeStore {
+	return &VolumeStore{dir: dir}
+}
+
+// Save records a volume snapshot, writing the archive read from data when given. The
+// archive is written to a temporary file first so a failed save never leaves a
+// partial snapshot behind.
+func (s *VolumeStore) Save(snapshot *VolumeSnapshot, data io.Reader) error {
+	if err := ValidateName(snapshot.Name); err != nil {
+		return err
+	}
+	dir, err := s.serviceDir(snapshot.ClusterID, snapshot.Service)
+	if err != nil {
+		return err
+	}
+	if s.Exists(snapshot.ClusterID, snapshot.Service, snapshot.Name) {
+		return fmt.Errorf("snapshot already exists: %s", snapshot.Name)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create snapshots directory: %w", err)
+	}
+
+	if data != nil {
+		tmp, err := os.CreateTemp(dir, snapshot.Name+".*.tmp")
+		if err != nil {
+			return fmt.Errorf("failed to create snapshot file: %w", err)
+		}
+		defer os.Remove(tmp.Name()) // No-op once renamed
+
+		size, err := io.Copy(tmp, data)
+		if err != nil {
+			tmp.Close()
+			return fmt.Errorf("failed to write snapshot: %w", err)
+		}
+		if err := tmp.Close(); err != nil {
+			return fmt.Errorf("failed to write snapshot: %w", err)
+		}
+		if err := os.Rename(tmp.Name(), filepath.Join(dir, snapshot.Name+".tar")); err != nil {
+			return fmt.Errorf("failed to write snapshot: %w", err)
+		}
+		snapshot.Size = size
+	}
+
+	content, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return err
+	}
+	// The description is written last, snapshots without one are not listed
+	return os.WriteFile(filepath.Join(dir, snapshot.Name+".json"), content, 0o644)
+}
+
+// Get returns the description of a volume snapshot
+func (s *VolumeStore) Get(clusterID, service, name string) (*VolumeSnapshot, error) {
+	if err := ValidateName(name); err != nil {
+		return nil, err
+	}
+	dir, err := s.serviceDir(clusterID, service)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := os.ReadFile(filepath.Join(dir, name+".json"))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("snapshot not found: %s", name)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var snapshot VolumeSnapshot
+	if err := json.Unmarshal(content, &snapshot); err != nil {
+		return nil, fmt.Errorf("invalid snapshot description: %w", err)
+	}
+	return &snapshot, nil
+}
+
+// List returns the volume snapshots of a service, newest first
+func (s *VolumeStore) List(clusterID, service string) ([]*VolumeSnapshot, error) {
+	dir, err := s.serviceDir(clusterID, service)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return []*VolumeSnapshot{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshots directory: %w", err)
+	}
+
+	snapshots := make([]*VolumeSnapshot, 0)
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), ".json")
+		if entry.IsDir() || !ok {
+			continue
+		}
+
+		snapshot, err := s.Get(clusterID, service, name)
+		if err != nil {
+			continue // Skip unreadable descriptions
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].CreatedAt.After(snapshots[j].CreatedAt)
+	})
+
+	return snapshots, nil
+}
+
+// Open opens the archive of a tar volume snapshot
+func (s *VolumeStore) Open(clusterID, service, name string) (*os.File, error) {
+	if _, err := s.Get(clusterID, service, name); err != nil {
+		return nil, err
+	}
+	dir, err := s.serviceDir(clusterID, service)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(filepath.Join(dir, name+".tar"))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("snapshot has no archive: %s", name)
+	}
+	return file, err
+}
+
+// Exists checks if a volume snapshot exists
+func (s *VolumeStore) Exists(clusterID, service, name string) bool {
+	_, err := s.Get(clusterID, service, name)
+	return err == nil
+}
+
+// serviceDir returns the directory of a service's snapshots. Service names are
+// escaped so they cannot point outside of the store.
+func (s *VolumeStore) serviceDir(clusterID, service string) (string, error) {
+	for _, part := range []string{clusterID, service} {
+		if part == "" || part == "." || part == ".." {
+			return "", fmt.Errorf("invalid snapshot location: %q", part)
+		}
+	}
+	return filepath.Join(s.dir, url.PathEscape(clusterID), url.PathEscape(service)), nil
+}
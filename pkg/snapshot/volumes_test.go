@@ -0,0 +1,63 @@
+package snapshot
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVolumeStore(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "throome-volume-snapshots-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	store := NewVolumeStore(tmpDir)
+
+	older := &VolumeSnapshot{Name: "before-migration", ClusterID: "abc12345", Service: "db", Type: "postgres", Mode: "tar", Path: "/var/lib/postgresql/data", CreatedAt: time.Now().Add(-time.Hour)}
+	if err := store.Save(older, strings.NewReader("archive")); err != nil {
+		t.Fatalf("Failed to save snapshot: %v", err)
+	}
+	if older.Size != 7 {
+		t.Errorf("Expected the archive's size to be recorded, got %d", older.Size)
+	}
+	if err := store.Save(older, strings.NewReader("archive")); err == nil {
+		t.Error("Expected error when saving a snapshot that already exists")
+	}
+
+	newer := &VolumeSnapshot{Name: "dev", ClusterID: "abc12345", Service: "db", Type: "postgres", Mode: "commit", Image: "sha256:feed", CreatedAt: time.Now()}
+	if err := store.Save(newer, nil); err != nil {
+		t.Fatalf("Failed to save snapshot: %v", err)
+	}
+
+	snapshots, err := store.List("abc12345", "db")
+	if err != nil {
+		t.Fatalf("Failed to list snapshots: %v", err)
+	}
+	if len(snapshots) != 2 || snapshots[0].Name != "dev" || snapshots[1].Name != "before-migration" {
+		t.Errorf("Expected both snapshots newest first, got %+v", snapshots)
+	}
+	if others, _ := store.List("abc12345", "cache"); len(others) != 0 {
+		t.Errorf("Expected no snapshots of another service, got %+v", others)
+	}
+
+	file, err := store.Open("abc12345", "db", "before-migration")
+	if err != nil {
+		t.Fatalf("Failed to open snapshot: %v", err)
+	}
+	content, _ := io.ReadAll(file)
+	file.Close()
+	if string(content) != "archive" {
+		t.Errorf("Expected the saved archive, got %q", content)
+	}
+	if _, err := store.Open("abc12345", "db", "dev"); err == nil {
+		t.Error("Expected commit snapshots to have no archive")
+	}
+
+	if _, err := store.List("abc12345", ".."); err == nil {
+		t.Error("Expected service names pointing outside of the store to be refused")
+	}
+}
@@ -6,6 +6,13 @@ import (
 	"time"
 )
 
+// TTL values returned for keys that do not expire and keys that do not exist, as by
+// the Redis TTL command
+const (
+	NoExpiry    time.Duration = -1
+	KeyNotFound time.Duration = -2
+)
+
 // CacheClient provides cache operations
 type CacheClient struct {
 	clusterClient *ClusterClient
@@ -47,3 +54,64 @@ func (c *CacheClient) Delete(ctx context.Context, key string) error {
 	path := fmt.Sprintf("/api/v1/clusters/%s/cache/delete", c.clusterClient.clusterID)
 	return c.clusterClient.client.request(ctx, "POST", path, req, nil)
 }
+
+// Exists reports whether a key exists in cache
+func (c *CacheClient) Exists(ctx context.Context, key string) (bool, error) {
+	req := CacheGetRequest{
+		Key: key,
+	}
+
+	var resp CacheExistsResponse
+	path := fmt.Sprintf("/api/v1/clusters/%s/cache/exists", c.clusterClient.clusterID)
+	if err := c.clusterClient.client.request(ctx, "POST", path, req, &resp); err != nil {
+		return false, err
+	}
+
+	return resp.Exists, nil
+}
+
+// TTL returns the time a key has left to live, NoExpiry for a key without expiration
+// and KeyNotFound for a missing key
+func (c *CacheClient) TTL(ctx context.Context, key string) (time.Duration, error) {
+	req := CacheGetRequest{
+		Key: key,
+	}
+
+	var resp CacheTTLResponse
+	path := fmt.Sprintf("/api/v1/clusters/%s/cache/ttl", c.clusterClient.clusterID)
+	if err := c.clusterClient.client.request(ctx, "POST", path, req, &resp); err != nil {
+		return 0, err
+	}
+
+	if resp.TTL < 0 {
+		return time.Duration(resp.TTL), nil
+	}
+	return time.Duration(resp.TTL) * time.Second, nil
+}
+
+// Expire sets the expiration of an existing key, rounded down to whole seconds
+func (c *CacheClient) Expire(ctx context.Context, key string, expiration time.Duration) error {
+	req := CacheExpireRequest{
+		Key: key,
+		TTL: int(expiration.Seconds()),
+	}
+
+	path := fmt.Sprintf("/api/v1/clusters/%s/cache/expire", c.clusterClient.clusterID)
+	return c.clusterClient.client.request(ctx, "POST", path, req, nil)
+}
+
+// Incr increments the integer value of a key by one and returns the new value.
+// A missing key is set to 1.
+func (c *CacheClient) Incr(ctx context.Context, key string) (int64, error) {
+	req := CacheGetRequest{
+		Key: key,
+	}
+
+	var resp CacheIncrResponse
+	path := fmt.Sprintf("/api/v1/clusters/%s/cache/incr", c.clusterClient.clusterID)
+	if err := c.clusterClient.client.request(ctx, "POST", path, req, &resp); err != nil {
+		return 0, err
+	}
+
+	return resp.Value, nil
+}
@@ -13,6 +13,7 @@ import (
 // Client is the Throome SDK client
 type Client struct {
 	baseURL    string
+	apiKey     string
 	httpClient *http.Client
 }
 
@@ -32,6 +33,37 @@ func (c *Client) WithTimeout(timeout time.Duration) *Client {
 	return c
 }
 
+// WithAPIKey sets the API key sent with every request, for gateways with
+// authentication enabled
+func (c *Client) WithAPIKey(apiKey string) *Client {
+	c.apiKey = apiKey
+	return c
+}
+
+// RequestIDHeader is the header carrying the ID the gateway logs a request with
+const RequestIDHeader = "X-Request-ID"
+
+type requestIDKey struct{}
+
+// WithRequestID returns a copy of ctx that makes requests made with it carry the given
+// request ID, to find them in the gateway's logs and activity. Requests without one are
+// assigned an ID by the gateway.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// ServiceParam is the query parameter naming the service a data operation runs on
+const ServiceParam = "service"
+
+type serviceKey struct{}
+
+// WithService returns a copy of ctx that makes data operations made with it run on the
+// named service of the cluster, for clusters with several services of a type. Operations
+// without one run on the service the cluster's routing strategy picks.
+func WithService(ctx context.Context, serviceName string) context.Context {
+	return context.WithValue(ctx, serviceKey{}, serviceName)
+}
+
 // Cluster returns a cluster client for the specified cluster ID
 func (c *Client) Cluster(clusterID string) *ClusterClient {
 	return &ClusterClient{
@@ -61,6 +93,9 @@ func (c *Client) request(ctx context.Context, method, path string, body, result
 	if body != nil {
 		req.Header.Set("Content-Type", "application/json")
 	}
+	setService(req)
+	setRequestID(req)
+	c.authorize(req)
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
@@ -71,7 +106,14 @@ func (c *Client) request(ctx context.Context, method, path string, body, result
 	if resp.StatusCode >= 400 {
 		var errResp ErrorResponse
 		_ = json.NewDecoder(resp.Body).Decode(&errResp)
-		return fmt.Errorf("API error (status %d): %s", resp.StatusCode, errResp.Message)
+		message := errResp.Message
+		if message == "" {
+			message = errResp.Error
+		}
+		if id := resp.Header.Get(RequestIDHeader); id != "" {
+			return fmt.Errorf("API error (status %d, request %s): %s", resp.StatusCode, id, message)
+		}
+		return fmt.Errorf("API error (status %d): %s", resp.StatusCode, message)
 	}
 
 	if result != nil {
@@ -83,6 +125,29 @@ func (c *Client) request(ctx context.Context, method, path string, body, result
 	return nil
 }
 
+// authorize adds the API key to a request
+func (c *Client) authorize(req *http.Request) {
+	if c.apiKey != "" {
+		req.Header.Set("X-API-Key", c.apiKey)
+	}
+}
+
+// setService adds the service targeted by the request's context, if any
+func setService(req *http.Request) {
+	if serviceName, ok := req.Context().Value(serviceKey{}).(string); ok && serviceName != "" {
+		query := req.URL.Query()
+		query.Set(ServiceParam, serviceName)
+		req.URL.RawQuery = query.Encode()
+	}
+}
+
+// setRequestID adds the request ID of the request's context, if any
+func setRequestID(req *http.Request) {
+	if id, ok := req.Context().Value(requestIDKey{}).(string); ok && id != "" {
+		req.Header.Set(RequestIDHeader, id)
+	}
+}
+
 // Health checks the health of the gateway
 func (c *Client) Health(ctx context.Context) (*HealthResponse, error) {
 	var health HealthResponse
@@ -120,23 +185,65 @@ func (c *Client) CreateCluster(ctx context.Context, req CreateClusterRequest) (*
 	return &resp, nil
 }
 
-// DeleteCluster deletes a cluster
+// DeleteCluster archives a cluster. Its configuration and volumes are kept until it
+// is purged or the gateway's retention window passes.
 func (c *Client) DeleteCluster(ctx context.Context, clusterID string) error {
 	path := fmt.Sprintf("/api/v1/clusters/%s", clusterID)
 	return c.request(ctx, "DELETE", path, nil, nil)
 }
 
+// ListArchivedClusters lists archived clusters
+func (c *Client) ListArchivedClusters(ctx context.Context) ([]Cluster, error) {
+	var clusters []Cluster
+	if err := c.request(ctx, "GET", "/api/v1/clusters?archived=true", nil, &clusters); err != nil {
+		return nil, err
+	}
+	return clusters, nil
+}
+
+// RestoreCluster restores an archived cluster
+func (c *Client) RestoreCluster(ctx context.Context, clusterID string) error {
+	path := fmt.Sprintf("/api/v1/clusters/%s/restore", clusterID)
+	return c.request(ctx, "POST", path, nil, nil)
+}
+
+// DrainCluster makes a cluster read-only: its data writes fail until it is resumed
+func (c *Client) DrainCluster(ctx context.Context, clusterID string) error {
+	path := fmt.Sprintf("/api/v1/clusters/%s/drain", clusterID)
+	return c.request(ctx, "POST", path, nil, nil)
+}
+
+// ResumeCluster accepts writes to a drained cluster again
+func (c *Client) ResumeCluster(ctx context.Context, clusterID string) error {
+	path := fmt.Sprintf("/api/v1/clusters/%s/resume", clusterID)
+	return c.request(ctx, "POST", path, nil, nil)
+}
+
+// UpdateRouting changes the routing settings of a running cluster, which are saved to
+// its configuration, and returns its new settings
+func (c *Client) UpdateRouting(ctx context.Context, clusterID string, req UpdateRoutingRequest) (*RoutingConfig, error) {
+	var routing RoutingConfig
+	path := fmt.Sprintf("/api/v1/clusters/%s/routing", clusterID)
+	if err := c.request(ctx, "PATCH", path, req, &routing); err != nil {
+		return nil, err
+	}
+	return &routing, nil
+}
+
+// PurgeCluster permanently deletes a cluster and its containers
+func (c *Client) PurgeCluster(ctx context.Context, clusterID string) error {
+	path := fmt.Sprintf("/api/v1/clusters/%s/purge", clusterID)
+	return c.request(ctx, "POST", path, nil, nil)
+}
+
 // GetActivity gets global activity logs
 func (c *Client) GetActivity(ctx context.Context, filters ActivityFilters) ([]ActivityLog, error) {
-	var logs []ActivityLog
-	path := "/api/v1/activity"
-	if filters.Limit > 0 {
-		path = fmt.Sprintf("%s?limit=%d", path, filters.Limit)
-	}
-	if err := c.request(ctx, "GET", path, nil, &logs); err != nil {
+	var page activityPage
+	path := "/api/v1/activity" + filters.query()
+	if err := c.request(ctx, "GET", path, nil, &page); err != nil {
 		return nil, err
 	}
-	return logs, nil
+	return page.Activities, nil
 }
 
 // ClusterClient provides cluster-specific operations
@@ -165,17 +272,32 @@ func (cc *ClusterClient) Metrics(ctx context.Context) (*MetricsResponse, error)
 	return &metrics, nil
 }
 
+// MetricsHistory gets the per-minute request samples of the cluster's services over
+// a window of at most 24 hours
+func (cc *ClusterClient) MetricsHistory(ctx context.Context, window time.Duration) (*MetricsHistoryResponse, error) {
+	var history MetricsHistoryResponse
+	path := fmt.Sprintf("/api/v1/clusters/%s/metrics/history?window=%s", cc.clusterID, window)
+	if err := cc.client.request(ctx, "GET", path, nil, &history); err != nil {
+		return nil, err
+	}
+	return &history, nil
+}
+
+// ResetMetrics clears the request counts, latencies and history the gateway collected
+// for the cluster's services, such as before a benchmark
+func (cc *ClusterClient) ResetMetrics(ctx context.Context) error {
+	path := fmt.Sprintf("/api/v1/clusters/%s/metrics/reset", cc.clusterID)
+	return cc.client.request(ctx, "POST", path, nil, nil)
+}
+
 // GetActivity gets cluster-specific activity logs
 func (cc *ClusterClient) GetActivity(ctx context.Context, filters ActivityFilters) ([]ActivityLog, error) {
-	var logs []ActivityLog
-	path := fmt.Sprintf("/api/v1/clusters/%s/activity", cc.clusterID)
-	if filters.Limit > 0 {
-		path = fmt.Sprintf("%s?limit=%d", path, filters.Limit)
-	}
-	if err := cc.client.request(ctx, "GET", path, nil, &logs); err != nil {
+	var page activityPage
+	path := fmt.Sprintf("/api/v1/clusters/%s/activity", cc.clusterID) + filters.query()
+	if err := cc.client.request(ctx, "GET", path, nil, &page); err != nil {
 		return nil, err
 	}
-	return logs, nil
+	return page.Activities, nil
 }
 
 // Service returns a service client
@@ -238,6 +360,8 @@ func (sc *ServiceClient) GetLogs(ctx context.Context, options LogOptions) (strin
 	if err != nil {
 		return "", err
 	}
+	setRequestID(req)
+	sc.client.authorize(req)
 
 	resp, err := sc.client.httpClient.Do(req)
 	if err != nil {
@@ -255,13 +379,10 @@ func (sc *ServiceClient) GetLogs(ctx context.Context, options LogOptions) (strin
 
 // GetActivity gets service-specific activity logs
 func (sc *ServiceClient) GetActivity(ctx context.Context, filters ActivityFilters) ([]ActivityLog, error) {
-	var logs []ActivityLog
-	path := fmt.Sprintf("/api/v1/clusters/%s/services/%s/activity", sc.clusterID, sc.serviceName)
-	if filters.Limit > 0 {
-		path = fmt.Sprintf("%s?limit=%d", path, filters.Limit)
-	}
-	if err := sc.client.request(ctx, "GET", path, nil, &logs); err != nil {
+	var page activityPage
+	path := fmt.Sprintf("/api/v1/clusters/%s/services/%s/activity", sc.clusterID, sc.serviceName) + filters.query()
+	if err := sc.client.request(ctx, "GET", path, nil, &page); err != nil {
 		return nil, err
 	}
-	return logs, nil
+	return page.Activities, nil
 }
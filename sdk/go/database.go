@@ -37,6 +37,31 @@ func (d *DBClient) Query(ctx context.Context, query string, args ...interface{})
 	return resp.Rows, nil
 }
 
+// QueryInto executes a SQL query and stores its rows in dest, a pointer to a slice of
+// structs or struct pointers. Columns are matched to fields by their db tag, or else by
+// name ignoring case and underscores, so created_at fills CreatedAt. Timestamps fill
+// time.Time fields, numbers keep their precision, and NULL sets pointer and
+// sql.Null* fields to nil or invalid.
+func (d *DBClient) QueryInto(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	path := fmt.Sprintf("/api/v1/clusters/%s/db/query", d.clusterClient.clusterID)
+	return d.queryInto(ctx, path, dest, query, args)
+}
+
+// queryInto runs a query on an endpoint returning rows and scans them into dest
+func (d *DBClient) queryInto(ctx context.Context, path string, dest interface{}, query string, args []interface{}) error {
+	req := DBQueryRequest{
+		Query: query,
+		Args:  args,
+	}
+
+	var resp rawQueryResponse
+	if err := d.clusterClient.client.request(ctx, "POST", path, req, &resp); err != nil {
+		return err
+	}
+
+	return scanRows(resp.Rows, dest)
+}
+
 // QueryRow executes a query that returns a single row
 func (d *DBClient) QueryRow(ctx context.Context, query string, args ...interface{}) (map[string]interface{}, error) {
 	rows, err := d.Query(ctx, query, args...)
@@ -50,3 +75,83 @@ func (d *DBClient) QueryRow(ctx context.Context, query string, args ...interface
 
 	return rows[0], nil
 }
+
+// Begin begins a transaction. Its statements run on the same database connection and
+// take effect together when it is committed. A transaction left idle on the gateway
+// for too long is rolled back.
+func (d *DBClient) Begin(ctx context.Context) (*Tx, error) {
+	return d.BeginTx(ctx, TxOptions{})
+}
+
+// BeginTx begins a transaction with options
+func (d *DBClient) BeginTx(ctx context.Context, opts TxOptions) (*Tx, error) {
+	var resp DBTransactionResponse
+	path := fmt.Sprintf("/api/v1/clusters/%s/db/transactions", d.clusterClient.clusterID)
+	if err := d.clusterClient.client.request(ctx, "POST", path, opts, &resp); err != nil {
+		return nil, err
+	}
+
+	return &Tx{db: d, id: resp.TransactionID, service: resp.ServiceName}, nil
+}
+
+// Tx is a database transaction begun through the gateway. It must be ended with
+// Commit or Rollback.
+type Tx struct {
+	db      *DBClient
+	id      string
+	service string // Service the transaction runs on, which its requests target
+}
+
+// Execute executes a SQL statement in the transaction without returning results
+func (t *Tx) Execute(ctx context.Context, query string, args ...interface{}) error {
+	req := DBQueryRequest{
+		Query: query,
+		Args:  args,
+	}
+
+	return t.db.clusterClient.client.request(t.context(ctx), "POST", t.path("execute"), req, nil)
+}
+
+// Query executes a SQL query in the transaction and returns results
+func (t *Tx) Query(ctx context.Context, query string, args ...interface{}) ([]map[string]interface{}, error) {
+	req := DBQueryRequest{
+		Query: query,
+		Args:  args,
+	}
+
+	var resp DBQueryResponse
+	if err := t.db.clusterClient.client.request(t.context(ctx), "POST", t.path("query"), req, &resp); err != nil {
+		return nil, err
+	}
+
+	return resp.Rows, nil
+}
+
+// QueryInto executes a SQL query in the transaction and stores its rows in dest, as
+// DBClient.QueryInto does
+func (t *Tx) QueryInto(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	return t.db.queryInto(t.context(ctx), t.path("query"), dest, query, args)
+}
+
+// Commit commits the transaction. It is over even when the commit fails.
+func (t *Tx) Commit(ctx context.Context) error {
+	return t.db.clusterClient.client.request(t.context(ctx), "POST", t.path("commit"), nil, nil)
+}
+
+// Rollback rolls the transaction back
+func (t *Tx) Rollback(ctx context.Context) error {
+	return t.db.clusterClient.client.request(t.context(ctx), "POST", t.path("rollback"), nil, nil)
+}
+
+// context targets the service the transaction runs on
+func (t *Tx) context(ctx context.Context) context.Context {
+	if t.service == "" {
+		return ctx
+	}
+	return WithService(ctx, t.service)
+}
+
+// path returns the path of an operation on the transaction
+func (t *Tx) path(operation string) string {
+	return fmt.Sprintf("/api/v1/clusters/%s/db/transactions/%s/%s", t.db.clusterClient.clusterID, t.id, operation)
+}
@@ -0,0 +1,132 @@
+package throome
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// recordedRequest is a request received by a fake gateway
+type recordedRequest struct {
+	method  string
+	path    string
+	service string
+	body    map[string]interface{}
+}
+
+// fakeGateway answers transaction requests as the gateway does and records them
+type fakeGateway struct {
+	mu       sync.Mutex
+	requests []recordedRequest
+}
+
+func (g *fakeGateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var body map[string]interface{}
+	_ = json.NewDecoder(r.Body).Decode(&body)
+	g.mu.Lock()
+	g.requests = append(g.requests, recordedRequest{r.Method, r.URL.Path, r.URL.Query().Get(ServiceParam), body})
+	g.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case r.URL.Path == "/api/v1/clusters/c1/db/transactions":
+		_ = json.NewEncoder(w).Encode(DBTransactionResponse{TransactionID: "tx-1", ServiceName: "db-2"})
+	case strings.HasSuffix(r.URL.Path, "/query"):
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"rows": []map[string]interface{}{{"id": 7, "name": "Ada"}}})
+	case strings.HasSuffix(r.URL.Path, "/commit"):
+		w.Header().Set(RequestIDHeader, "req-42")
+		w.WriteHeader(http.StatusNotFound)
+		_ = json.NewEncoder(w).Encode(ErrorResponse{Error: "Transaction not found"})
+	default:
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"rows_affected": 1})
+	}
+}
+
+func (g *fakeGateway) recorded() []recordedRequest {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return append([]recordedRequest{}, g.requests...)
+}
+
+func TestTransaction(t *testing.T) {
+	gateway := &fakeGateway{}
+	server := httptest.NewServer(gateway)
+	defer server.Close()
+
+	db := NewClient(server.URL).Cluster("c1").DB()
+	ctx := context.Background()
+
+	tx, err := db.BeginTx(ctx, TxOptions{IsolationLevel: "serializable", ReadOnly: true})
+	if err != nil {
+		t.Fatalf("Failed to begin: %v", err)
+	}
+	if err := tx.Execute(ctx, "UPDATE users SET name = $1 WHERE id = $2", "Ada", 7); err != nil {
+		t.Fatalf("Failed to execute: %v", err)
+	}
+	rows, err := tx.Query(ctx, "SELECT id, name FROM users")
+	if err != nil {
+		t.Fatalf("Failed to query: %v", err)
+	}
+	if len(rows) != 1 || rows[0]["name"] != "Ada" {
+		t.Errorf("Expected the row of Ada, got %v", rows)
+	}
+	if err := tx.Rollback(ctx); err != nil {
+		t.Fatalf("Failed to roll back: %v", err)
+	}
+
+	requests := gateway.recorded()
+	expected := []struct{ path, service string }{
+		{"/api/v1/clusters/c1/db/transactions", ""},
+		{"/api/v1/clusters/c1/db/transactions/tx-1/execute", "db-2"},
+		{"/api/v1/clusters/c1/db/transactions/tx-1/query", "db-2"},
+		{"/api/v1/clusters/c1/db/transactions/tx-1/rollback", "db-2"},
+	}
+	if len(requests) != len(expected) {
+		t.Fatalf("Expected %d requests, got %+v", len(expected), requests)
+	}
+	for i, want := range expected {
+		// Statements target the service the transaction was begun on
+		if requests[i].method != "POST" || requests[i].path != want.path || requests[i].service != want.service {
+			t.Errorf("Expected POST %s on service %q, got %+v", want.path, want.service, requests[i])
+		}
+	}
+	if begin := requests[0].body; begin["isolation_level"] != "serializable" || begin["read_only"] != true {
+		t.Errorf("Expected the options to be sent, got %v", begin)
+	}
+	if execute := requests[1].body; execute["query"] != "UPDATE users SET name = $1 WHERE id = $2" || len(execute["args"].([]interface{})) != 2 {
+		t.Errorf("Expected the statement and its arguments to be sent, got %v", execute)
+	}
+}
+
+func TestTransactionErrors(t *testing.T) {
+	gateway := &fakeGateway{}
+	server := httptest.NewServer(gateway)
+	defer server.Close()
+
+	ctx := context.Background()
+	tx, err := NewClient(server.URL).Cluster("c1").DB().Begin(ctx)
+	if err != nil {
+		t.Fatalf("Failed to begin: %v", err)
+	}
+
+	err = tx.Commit(ctx)
+	if err == nil || err.Error() != "API error (status 404, request req-42): Transaction not found" {
+		t.Errorf("Expected the gateway's error with its request ID, got %v", err)
+	}
+
+	// An explicit service in the context is replaced by the transaction's
+	_ = tx.Rollback(WithService(ctx, "db-1"))
+	if requests := gateway.recorded(); requests[len(requests)-1].service != "db-2" {
+		t.Errorf("Expected the rollback to target db-2, got %+v", requests[len(requests)-1])
+	}
+
+	// Beginning fails on gateway errors
+	server.Close()
+	if _, err := NewClient(server.URL).Cluster("c1").DB().Begin(ctx); err == nil {
+		t.Error("Expected beginning on an unreachable gateway to fail")
+	}
+}
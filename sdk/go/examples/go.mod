@@ -4,5 +4,6 @@ go 1.24
 
 require github.com/akmadan/throome/sdk/go v0.1.0
 
-replace github.com/akmadan/throome/sdk/go => ../
+require github.com/gorilla/websocket v1.5.3 // indirect
 
+replace github.com/akmadan/throome/sdk/go => ../
@@ -2,3 +2,4 @@ module github.com/akmadan/throome/sdk/go
 
 go 1.24
 
+require github.com/gorilla/websocket v1.5.3
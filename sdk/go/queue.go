@@ -2,7 +2,21 @@ package throome
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Reconnection delays of subscriptions that set none
+const (
+	defaultMinBackoff = 500 * time.Millisecond
+	defaultMaxBackoff = 30 * time.Second
 )
 
 // QueueClient provides queue/message broker operations
@@ -10,6 +24,28 @@ type QueueClient struct {
 	clusterClient *ClusterClient
 }
 
+// MessageHandler processes a message delivered to a subscription. Returning nil
+// acknowledges the message; returning an error rejects it, so the gateway retries or
+// dead-letters it.
+type MessageHandler func(ctx context.Context, message *Message) error
+
+// SubscribeOptions configures a subscription
+type SubscribeOptions struct {
+	// Group is the consumer group to join, on queues that support them such as Kafka.
+	// Each subscription is a member of its own, so the subscriptions of a group share
+	// the topic's partitions and each message goes to one of them. Subscriptions
+	// without one join the gateway's default group.
+	Group string
+
+	// MinBackoff and MaxBackoff bound the delay between reconnection attempts, which
+	// doubles after each failed attempt. They default to 500ms and 30s.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+
+	// OnError is told why the connection was lost before each reconnection attempt
+	OnError func(err error)
+}
+
 // Publish publishes a message to a topic
 func (q *QueueClient) Publish(ctx context.Context, topic string, message []byte) error {
 	req := QueuePublishRequest{
@@ -21,7 +57,158 @@ func (q *QueueClient) Publish(ctx context.Context, topic string, message []byte)
 	return q.clusterClient.client.request(ctx, "POST", path, req, nil)
 }
 
-// Subscribe subscribes to a topic (placeholder - requires WebSocket/long-polling implementation)
-func (q *QueueClient) Subscribe(ctx context.Context, topic string, handler func([]byte) error) error {
-	return fmt.Errorf("subscribe not yet implemented in SDK - use direct Kafka consumer")
+// Subscribe consumes the messages of a topic until ctx is cancelled, passing them to
+// handler one at a time. See SubscribeWithOptions.
+func (q *QueueClient) Subscribe(ctx context.Context, topic string, handler MessageHandler) error {
+	return q.SubscribeWithOptions(ctx, topic, SubscribeOptions{}, handler)
+}
+
+// SubscribeWithOptions consumes the messages of a topic until ctx is cancelled, over a
+// WebSocket to the gateway. Each message is acknowledged or rejected once handler
+// returns, so handlers must return within the gateway's acknowledgement deadline of 30
+// seconds. A lost connection is reconnected with backoff; messages that were not
+// acknowledged are delivered again. Failures to subscribe the first time are returned,
+// as are rejected API keys. It returns ctx.Err() once ctx is cancelled.
+func (q *QueueClient) SubscribeWithOptions(ctx context.Context, topic string, opts SubscribeOptions, handler MessageHandler) error {
+	if opts.MinBackoff <= 0 {
+		opts.MinBackoff = defaultMinBackoff
+	}
+	if opts.MaxBackoff < opts.MinBackoff {
+		opts.MaxBackoff = max(defaultMaxBackoff, opts.MinBackoff)
+	}
+
+	backoff := opts.MinBackoff
+	subscribed := false
+	for {
+		err := q.consume(ctx, topic, opts.Group, handler, func() {
+			subscribed = true
+			backoff = opts.MinBackoff
+		})
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		var permanent *subscribeError
+		if errors.As(err, &permanent) || !subscribed {
+			return err
+		}
+		if opts.OnError != nil {
+			opts.OnError(err)
+		}
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		backoff = min(backoff*2, opts.MaxBackoff)
+	}
+}
+
+// subscribeError is a failure to subscribe that reconnecting does not fix
+type subscribeError struct {
+	err error
+}
+
+func (e *subscribeError) Error() string {
+	return e.err.Error()
+}
+
+func (e *subscribeError) Unwrap() error {
+	return e.err
+}
+
+// consume runs one connection of a subscription until it is lost or ctx is cancelled,
+// calling onSubscribed once the gateway has subscribed
+func (q *QueueClient) consume(ctx context.Context, topic, group string, handler MessageHandler, onSubscribed func()) error {
+	conn, err := q.dial(ctx, topic, group)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// Unblock reads when the subscription is cancelled
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = conn.Close()
+		case <-done:
+		}
+	}()
+
+	for {
+		var frame queueFrame
+		if err := conn.ReadJSON(&frame); err != nil {
+			return fmt.Errorf("subscription connection lost: %w", err)
+		}
+
+		switch frame.Type {
+		case "subscribed":
+			onSubscribed()
+		case "error":
+			return fmt.Errorf("subscription failed: %s", frame.Error)
+		case "message":
+			ack := queueAck{Type: "ack", ID: frame.ID}
+			if err := handler(ctx, &frame.Message); err != nil {
+				ack = queueAck{Type: "nack", ID: frame.ID, Error: err.Error()}
+			}
+			if err := conn.WriteJSON(ack); err != nil {
+				return fmt.Errorf("failed to acknowledge message: %w", err)
+			}
+		}
+	}
+}
+
+// dial opens the WebSocket of a subscription
+func (q *QueueClient) dial(ctx context.Context, topic, group string) (*websocket.Conn, error) {
+	client := q.clusterClient.client
+
+	endpoint, err := url.Parse(client.baseURL)
+	if err != nil {
+		return nil, &subscribeError{fmt.Errorf("invalid gateway URL: %w", err)}
+	}
+	endpoint.Scheme = strings.Replace(endpoint.Scheme, "http", "ws", 1)
+	endpoint.Path = strings.TrimSuffix(endpoint.Path, "/") + fmt.Sprintf("/api/v1/clusters/%s/queue/subscribe", q.clusterClient.clusterID)
+
+	query := url.Values{"topic": {topic}}
+	if group != "" {
+		query.Set("group", group)
+	}
+	if serviceName, ok := ctx.Value(serviceKey{}).(string); ok && serviceName != "" {
+		query.Set(ServiceParam, serviceName)
+	}
+	endpoint.RawQuery = query.Encode()
+
+	header := http.Header{}
+	if client.apiKey != "" {
+		header.Set("X-API-Key", client.apiKey)
+	}
+	if id, ok := ctx.Value(requestIDKey{}).(string); ok && id != "" {
+		header.Set(RequestIDHeader, id)
+	}
+
+	conn, resp, err := websocket.DefaultDialer.DialContext(ctx, endpoint.String(), header)
+	if err != nil {
+		if resp == nil {
+			return nil, fmt.Errorf("failed to connect subscription: %w", err)
+		}
+		defer resp.Body.Close()
+
+		var errResp ErrorResponse
+		_ = json.NewDecoder(resp.Body).Decode(&errResp)
+		message := errResp.Message
+		if message == "" {
+			message = errResp.Error
+		}
+		err = fmt.Errorf("API error (status %d): %s", resp.StatusCode, message)
+
+		// Subscriptions the gateway refuses are refused again
+		if resp.StatusCode < http.StatusInternalServerError && resp.StatusCode != http.StatusTooManyRequests {
+			return nil, &subscribeError{err}
+		}
+		return nil, err
+	}
+	return conn, nil
 }
@@ -0,0 +1,204 @@
+package throome
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// subscribeServer is a fake gateway whose subscription endpoint serves each
+// connection attempt with serve, numbered from 1
+type subscribeServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	attempts []time.Time
+}
+
+func newSubscribeServer(t *testing.T, serve func(attempt int, w http.ResponseWriter, r *http.Request)) *subscribeServer {
+	s := &subscribeServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/clusters/c1/queue/subscribe" || r.URL.Query().Get("topic") != "orders" {
+			t.Errorf("Unexpected subscription request %s", r.URL)
+		}
+		s.mu.Lock()
+		s.attempts = append(s.attempts, time.Now())
+		attempt := len(s.attempts)
+		s.mu.Unlock()
+		serve(attempt, w, r)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *subscribeServer) attemptTimes() []time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]time.Time{}, s.attempts...)
+}
+
+// refuse answers a subscription attempt with a gateway error
+func refuse(w http.ResponseWriter, status int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(ErrorResponse{Error: http.StatusText(status)})
+}
+
+var upgrader = websocket.Upgrader{}
+
+// subscribeThenDrop accepts a subscription, delivers a message and drops the
+// connection once the message is settled, returning the settlement. A message left
+// unsettled returns a zero settlement.
+func subscribeThenDrop(t *testing.T, w http.ResponseWriter, r *http.Request, id int64) queueAck {
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		t.Errorf("Failed to upgrade: %v", err)
+		return queueAck{}
+	}
+	defer conn.Close()
+
+	_ = conn.WriteJSON(queueFrame{Type: "subscribed"})
+	_ = conn.WriteJSON(queueFrame{Type: "message", ID: id, Message: Message{Topic: "orders", Offset: id}})
+	var ack queueAck
+	_ = conn.ReadJSON(&ack)
+	return ack
+}
+
+func TestSubscribeReconnects(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	acks := make(chan queueAck, 2)
+	server := newSubscribeServer(t, func(attempt int, w http.ResponseWriter, r *http.Request) {
+		acks <- subscribeThenDrop(t, w, r, int64(attempt))
+	})
+
+	var mu sync.Mutex
+	var offsets []int64
+	var lost []error
+	opts := SubscribeOptions{
+		MinBackoff: time.Millisecond,
+		OnError: func(err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			lost = append(lost, err)
+		},
+	}
+	handler := func(ctx context.Context, message *Message) error {
+		mu.Lock()
+		defer mu.Unlock()
+		offsets = append(offsets, message.Offset)
+		if message.Offset == 2 {
+			cancel()
+			return errors.New("rejected by handler")
+		}
+		return nil
+	}
+
+	err := NewClient(server.URL).Cluster("c1").Queue().SubscribeWithOptions(ctx, "orders", opts, handler)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected the subscription to end with its context, got %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(offsets) != 2 || offsets[0] != 1 || offsets[1] != 2 {
+		t.Errorf("Expected a message from each connection, got offsets %v", offsets)
+	}
+	if len(lost) == 0 {
+		t.Error("Expected OnError to be told the connection was lost")
+	}
+	if first := <-acks; first.Type != "ack" || first.ID != 1 {
+		t.Errorf("Expected message 1 to be acknowledged, got %+v", first)
+	}
+	select {
+	case second := <-acks:
+		if second.Type != "nack" || second.ID != 2 || second.Error != "rejected by handler" {
+			t.Errorf("Expected message 2 to be rejected, got %+v", second)
+		}
+	case <-time.After(time.Second):
+		// The settlement may not reach the server once the subscription is cancelled
+	}
+}
+
+func TestSubscribeDoesNotRetryRefusals(t *testing.T) {
+	for _, status := range []int{http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound} {
+		t.Run(http.StatusText(status), func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			// The refusal comes on reconnecting, after the first connection was lost
+			server := newSubscribeServer(t, func(attempt int, w http.ResponseWriter, r *http.Request) {
+				if attempt == 1 {
+					subscribeThenDrop(t, w, r, 1)
+					return
+				}
+				refuse(w, status)
+			})
+
+			handler := func(ctx context.Context, message *Message) error { return nil }
+			err := NewClient(server.URL).Cluster("c1").Queue().SubscribeWithOptions(ctx, "orders", SubscribeOptions{MinBackoff: time.Millisecond}, handler)
+
+			var permanent *subscribeError
+			if !errors.As(err, &permanent) {
+				t.Errorf("Expected the refusal to be returned, got %v", err)
+			}
+			if attempts := len(server.attemptTimes()); attempts != 2 {
+				t.Errorf("Expected no attempt after the refusal, got %d attempts", attempts)
+			}
+		})
+	}
+}
+
+func TestSubscribeBackoffIsCapped(t *testing.T) {
+	const (
+		minBackoff = 20 * time.Millisecond
+		maxBackoff = 50 * time.Millisecond
+		attempts   = 7
+	)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// After the first connection is lost, the gateway is unavailable
+	server := newSubscribeServer(t, func(attempt int, w http.ResponseWriter, r *http.Request) {
+		switch {
+		case attempt == 1:
+			subscribeThenDrop(t, w, r, 1)
+		case attempt >= attempts:
+			cancel()
+			refuse(w, http.StatusServiceUnavailable)
+		default:
+			refuse(w, http.StatusServiceUnavailable)
+		}
+	})
+
+	handler := func(ctx context.Context, message *Message) error { return nil }
+	opts := SubscribeOptions{MinBackoff: minBackoff, MaxBackoff: maxBackoff}
+	if err := NewClient(server.URL).Cluster("c1").Queue().SubscribeWithOptions(ctx, "orders", opts, handler); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected unavailable gateways to be retried until cancelled, got %v", err)
+	}
+
+	times := server.attemptTimes()
+	if len(times) != attempts {
+		t.Fatalf("Expected %d attempts, got %d", attempts, len(times))
+	}
+
+	// The delay doubles from the minimum and stays at the maximum
+	expected := minBackoff
+	for i := 1; i < len(times); i++ {
+		delay := times[i].Sub(times[i-1])
+		if delay < expected {
+			t.Errorf("Expected attempt %d to wait at least %v, waited %v", i+1, expected, delay)
+		}
+		if delay > maxBackoff+100*time.Millisecond {
+			t.Errorf("Expected attempt %d to wait at most about %v, waited %v", i+1, maxBackoff, delay)
+		}
+		expected = min(expected*2, maxBackoff)
+	}
+}
@@ -0,0 +1,297 @@
+package throome
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// rawQueryResponse is a query response whose values are kept as the gateway encoded
+// them, so numbers keep their precision until they are scanned
+type rawQueryResponse struct {
+	Rows []map[string]json.RawMessage `json:"rows"`
+}
+
+var (
+	timeType    = reflect.TypeOf(time.Time{})
+	scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+)
+
+// timeLayouts are the layouts timestamps are parsed with, tried in order
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02",
+}
+
+// scanRows maps rows onto dest, a pointer to a slice of structs or struct pointers. A
+// column is stored in the field whose db tag names it, else in the field whose name
+// matches it ignoring case and underscores. Columns without a field are skipped, and
+// fields tagged db:"-" are never set.
+func scanRows(rows []map[string]json.RawMessage, dest interface{}) error {
+	slice := reflect.ValueOf(dest)
+	if slice.Kind() != reflect.Ptr || slice.IsNil() || slice.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("destination must be a pointer to a slice, got %T", dest)
+	}
+	slice = slice.Elem()
+
+	elemType := slice.Type().Elem()
+	structType := elemType
+	if elemType.Kind() == reflect.Ptr {
+		structType = elemType.Elem()
+	}
+	if structType.Kind() != reflect.Struct {
+		return fmt.Errorf("destination must be a slice of structs, got %T", dest)
+	}
+	fields := structFields(structType)
+
+	result := reflect.MakeSlice(slice.Type(), 0, len(rows))
+	for i, row := range rows {
+		item := reflect.New(structType)
+		for column, raw := range row {
+			index, ok := fields.lookup(column)
+			if !ok {
+				continue
+			}
+			field := item.Elem().FieldByIndex(index)
+			if err := assign(field, raw); err != nil {
+				return fmt.Errorf("row %d: cannot scan column %s into field %s: %w", i, column, structType.FieldByIndex(index).Name, err)
+			}
+		}
+
+		if elemType.Kind() == reflect.Ptr {
+			result = reflect.Append(result, item)
+		} else {
+			result = reflect.Append(result, item.Elem())
+		}
+	}
+
+	slice.Set(result)
+	return nil
+}
+
+// fieldIndex locates the fields of a struct by the columns they are set from
+type fieldIndex struct {
+	tagged map[string][]int // By db tag
+	named  map[string][]int // By normalized field name
+}
+
+// lookup returns the index of the field a column is stored in
+func (f fieldIndex) lookup(column string) ([]int, bool) {
+	if index, ok := f.tagged[column]; ok {
+		return index, true
+	}
+	index, ok := f.named[normalizeColumn(column)]
+	return index, ok
+}
+
+// structFields indexes the settable fields of a struct, those of embedded structs
+// included
+func structFields(t reflect.Type) fieldIndex {
+	fields := fieldIndex{tagged: make(map[string][]int), named: make(map[string][]int)}
+	addStructFields(fields, t, nil)
+	return fields
+}
+
+func addStructFields(fields fieldIndex, t reflect.Type, prefix []int) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		index := append(append([]int{}, prefix...), i)
+		tag := field.Tag.Get("db")
+		if tag == "-" {
+			continue
+		}
+
+		// Fields of embedded structs are matched as the struct's own. Those of embedded
+		// pointers are not, as the pointers would have to be allocated.
+		if field.Anonymous && tag == "" && field.Type.Kind() == reflect.Struct && field.Type != timeType {
+			addStructFields(fields, field.Type, index)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+
+		// Outer fields win over those of embedded structs, as in Go
+		if tag != "" {
+			if _, exists := fields.tagged[tag]; !exists {
+				fields.tagged[tag] = index
+			}
+			continue
+		}
+		name := normalizeColumn(field.Name)
+		if _, exists := fields.named[name]; !exists {
+			fields.named[name] = index
+		}
+	}
+}
+
+// normalizeColumn folds a column or field name for matching, so created_at matches
+// CreatedAt
+func normalizeColumn(name string) string {
+	return strings.ToLower(strings.ReplaceAll(name, "_", ""))
+}
+
+// assign stores a JSON-encoded column value in a field. NULL sets pointers to nil and
+// other fields to their zero value, and types implementing sql.Scanner, such as
+// sql.NullString, scan the value themselves.
+func assign(field reflect.Value, raw json.RawMessage) error {
+	null := bytes.Equal(bytes.TrimSpace(raw), []byte("null"))
+
+	if field.Addr().Type().Implements(scannerType) {
+		return scan(field.Addr().Interface().(sql.Scanner), raw, null)
+	}
+	if null {
+		field.Set(reflect.Zero(field.Type()))
+		return nil
+	}
+
+	if field.Kind() == reflect.Ptr {
+		value := reflect.New(field.Type().Elem())
+		if err := assign(value.Elem(), raw); err != nil {
+			return err
+		}
+		field.Set(value)
+		return nil
+	}
+
+	if field.Type() == timeType {
+		text, err := stringValue(raw)
+		if err != nil {
+			return err
+		}
+		t, err := parseTime(text)
+		if err != nil {
+			return err
+		}
+		field.Set(reflect.ValueOf(t))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := parseInt(numberText(raw))
+		if err != nil {
+			return err
+		}
+		if field.OverflowInt(n) {
+			return fmt.Errorf("%d overflows %s", n, field.Type())
+		}
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(numberText(raw), 10, 64)
+		if err != nil {
+			return err
+		}
+		if field.OverflowUint(n) {
+			return fmt.Errorf("%d overflows %s", n, field.Type())
+		}
+		field.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(numberText(raw), field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	case reflect.String:
+		// Numbers and JSON documents are kept as their text
+		if text, err := stringValue(raw); err == nil {
+			field.SetString(text)
+		} else {
+			field.SetString(string(raw))
+		}
+	default:
+		// Booleans, bytes (base64 encoded), and JSON columns into maps, slices and structs
+		return json.Unmarshal(raw, field.Addr().Interface())
+	}
+	return nil
+}
+
+// scan passes a column value to a sql.Scanner as database/sql would: nil for NULL,
+// int64 or float64 for numbers, and bool or string otherwise. Strings the scanner
+// refuses are passed again as a time if they parse as one, for sql.NullTime.
+func scan(scanner sql.Scanner, raw json.RawMessage, null bool) error {
+	if null {
+		return scanner.Scan(nil)
+	}
+
+	var value interface{}
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		return err
+	}
+
+	switch v := value.(type) {
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return scanner.Scan(n)
+		}
+		f, err := v.Float64()
+		if err != nil {
+			return err
+		}
+		return scanner.Scan(f)
+	case string:
+		err := scanner.Scan(v)
+		if err != nil {
+			if t, timeErr := parseTime(v); timeErr == nil {
+				return scanner.Scan(t)
+			}
+		}
+		return err
+	case bool:
+		return scanner.Scan(v)
+	default:
+		return scanner.Scan([]byte(raw))
+	}
+}
+
+// stringValue decodes a JSON string
+func stringValue(raw json.RawMessage) (string, error) {
+	var text string
+	err := json.Unmarshal(raw, &text)
+	return text, err
+}
+
+// numberText returns the text of a JSON number, or of a number encoded as a string as
+// the gateway does for some numeric types
+func numberText(raw json.RawMessage) string {
+	if text, err := stringValue(raw); err == nil {
+		return strings.TrimSpace(text)
+	}
+	return string(bytes.TrimSpace(raw))
+}
+
+// parseInt parses an integer, accepting numbers with an exponent or a zero fraction
+// such as 1e3 and 42.0
+func parseInt(text string) (int64, error) {
+	if n, err := strconv.ParseInt(text, 10, 64); err == nil {
+		return n, nil
+	}
+	f, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return 0, err
+	}
+	if f != math.Trunc(f) || f < math.MinInt64 || f >= math.MaxInt64 {
+		return 0, fmt.Errorf("%s is not an integer", text)
+	}
+	return int64(f), nil
+}
+
+// parseTime parses a timestamp or date
+func parseTime(text string) (time.Time, error) {
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, text); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("cannot parse %q as a time", text)
+}
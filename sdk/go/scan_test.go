@@ -0,0 +1,188 @@
+package throome
+
+import (
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+type scanBase struct {
+	ID        int64
+	CreatedAt time.Time
+}
+
+type scanUser struct {
+	scanBase
+	Name     string  `db:"full_name"`
+	Email    *string // NULL leaves it nil
+	Age      int
+	Score    float64
+	Active   bool
+	Nickname sql.NullString
+	Visits   sql.NullInt64
+	LastSeen sql.NullTime
+	Secret   string `db:"-"`
+	Tags     []string
+}
+
+// decodeRows decodes rows as the gateway encodes them
+func decodeRows(t *testing.T, text string) []map[string]json.RawMessage {
+	t.Helper()
+	var rows []map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(text), &rows); err != nil {
+		t.Fatalf("Invalid rows %s: %v", text, err)
+	}
+	return rows
+}
+
+func TestScanRows(t *testing.T) {
+	email := "ada@example.com"
+	tests := []struct {
+		name     string
+		rows     string
+		expected scanUser
+	}{
+		{
+			name: "columns by tag and by name",
+			rows: `[{"id": 7, "full_name": "Ada", "email": "ada@example.com", "age": 36, "score": 9.5, "active": true, "tags": ["admin"]}]`,
+			expected: scanUser{
+				scanBase: scanBase{ID: 7},
+				Name:     "Ada", Email: &email, Age: 36, Score: 9.5, Active: true, Tags: []string{"admin"},
+			},
+		},
+		{
+			name:     "NULL into pointer and non-pointer fields",
+			rows:     `[{"email": null, "age": null, "full_name": null, "nickname": null, "visits": null, "last_seen": null}]`,
+			expected: scanUser{},
+		},
+		{
+			name: "sql.Scanner fields",
+			rows: `[{"nickname": "ada", "visits": 12, "last_seen": "2024-03-01T10:00:00Z"}]`,
+			expected: scanUser{
+				Nickname: sql.NullString{String: "ada", Valid: true},
+				Visits:   sql.NullInt64{Int64: 12, Valid: true},
+				LastSeen: sql.NullTime{Time: time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC), Valid: true},
+			},
+		},
+		{
+			name:     "created_at matches CreatedAt of the embedded struct",
+			rows:     `[{"created_at": "2024-03-01T10:00:00.5Z"}]`,
+			expected: scanUser{scanBase: scanBase{CreatedAt: time.Date(2024, 3, 1, 10, 0, 0, 5e8, time.UTC)}},
+		},
+		{
+			name:     "timestamp without a zone",
+			rows:     `[{"created_at": "2024-03-01 10:00:00"}]`,
+			expected: scanUser{scanBase: scanBase{CreatedAt: time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)}},
+		},
+		{
+			name:     "date",
+			rows:     `[{"created_at": "2024-03-01"}]`,
+			expected: scanUser{scanBase: scanBase{CreatedAt: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)}},
+		},
+		{
+			name:     "numbers encoded as text or with an exponent",
+			rows:     `[{"id": "9007199254740993", "age": 4.2e1, "score": "0.25"}]`,
+			expected: scanUser{scanBase: scanBase{ID: 9007199254740993}, Age: 42, Score: 0.25},
+		},
+		{
+			name:     "numbers into strings keep their text",
+			rows:     `[{"full_name": 12.50}]`,
+			expected: scanUser{Name: "12.50"},
+		},
+		{
+			name:     "unknown and excluded columns are skipped",
+			rows:     `[{"id": 1, "unknown": "x", "secret": "hidden", "-": "hidden"}]`,
+			expected: scanUser{scanBase: scanBase{ID: 1}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var users []scanUser
+			if err := scanRows(decodeRows(t, tt.rows), &users); err != nil {
+				t.Fatalf("Failed to scan: %v", err)
+			}
+			if len(users) != 1 {
+				t.Fatalf("Expected 1 row, got %d", len(users))
+			}
+			assertUser(t, users[0], tt.expected)
+		})
+	}
+}
+
+// assertUser compares the fields of scanned users
+func assertUser(t *testing.T, got, expected scanUser) {
+	t.Helper()
+	if got.ID != expected.ID || !got.CreatedAt.Equal(expected.CreatedAt) {
+		t.Errorf("Expected base %+v, got %+v", expected.scanBase, got.scanBase)
+	}
+	if got.Name != expected.Name || got.Age != expected.Age || got.Score != expected.Score || got.Active != expected.Active || got.Secret != "" {
+		t.Errorf("Expected %+v, got %+v", expected, got)
+	}
+	if (got.Email == nil) != (expected.Email == nil) || (got.Email != nil && *got.Email != *expected.Email) {
+		t.Errorf("Expected email %v, got %v", expected.Email, got.Email)
+	}
+	if got.Nickname != expected.Nickname || got.Visits != expected.Visits {
+		t.Errorf("Expected %+v and %+v, got %+v and %+v", expected.Nickname, expected.Visits, got.Nickname, got.Visits)
+	}
+	if got.LastSeen.Valid != expected.LastSeen.Valid || !got.LastSeen.Time.Equal(expected.LastSeen.Time) {
+		t.Errorf("Expected last seen %+v, got %+v", expected.LastSeen, got.LastSeen)
+	}
+	if strings.Join(got.Tags, ",") != strings.Join(expected.Tags, ",") {
+		t.Errorf("Expected tags %v, got %v", expected.Tags, got.Tags)
+	}
+}
+
+func TestScanRowsErrors(t *testing.T) {
+	type small struct {
+		Count int8
+		Total uint8
+	}
+
+	tests := []struct {
+		name  string
+		rows  string
+		dest  interface{}
+		error string
+	}{
+		{"non-pointer destination", `[]`, []scanUser{}, "must be a pointer to a slice"},
+		{"pointer to a non-slice", `[]`, &scanUser{}, "must be a pointer to a slice"},
+		{"nil pointer", `[]`, (*[]scanUser)(nil), "must be a pointer to a slice"},
+		{"slice of non-structs", `[]`, &[]string{}, "must be a slice of structs"},
+		{"integer overflow", `[{"count": 300}]`, &[]small{}, "300 overflows int8"},
+		{"unsigned overflow", `[{"total": 256}]`, &[]small{}, "256 overflows uint8"},
+		{"fraction into an integer", `[{"count": 4.5}]`, &[]small{}, "4.5 is not an integer"},
+		{"text into an integer", `[{"count": "many"}]`, &[]small{}, "cannot scan column count into field Count"},
+		{"invalid time", `[{"created_at": "yesterday"}]`, &[]scanUser{}, `cannot parse "yesterday" as a time`},
+		{"error names the row", `[{"count": 1}, {"count": 1000}]`, &[]small{}, "row 1:"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := scanRows(decodeRows(t, tt.rows), tt.dest)
+			if err == nil || !strings.Contains(err.Error(), tt.error) {
+				t.Errorf("Expected an error containing %q, got %v", tt.error, err)
+			}
+		})
+	}
+}
+
+func TestScanRowsIntoPointers(t *testing.T) {
+	var users []*scanUser
+	if err := scanRows(decodeRows(t, `[{"id": 1}, {"id": 2}]`), &users); err != nil {
+		t.Fatalf("Failed to scan: %v", err)
+	}
+	if len(users) != 2 || users[0].ID != 1 || users[1].ID != 2 {
+		t.Errorf("Expected users 1 and 2, got %+v", users)
+	}
+
+	// Scanning replaces the slice's previous rows
+	if err := scanRows(decodeRows(t, `[]`), &users); err != nil {
+		t.Fatalf("Failed to scan: %v", err)
+	}
+	if len(users) != 0 {
+		t.Errorf("Expected no users, got %d", len(users))
+	}
+}
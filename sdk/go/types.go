@@ -1,11 +1,26 @@
 package throome
 
-import "time"
+import (
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// RedactedSecret is what the gateway returns in place of passwords and other
+// credentials unless they are revealed. Sending it back in a configuration keeps
+// the stored value.
+const RedactedSecret = "[REDACTED]"
+
+// IsRedacted reports whether a value read from the gateway is a redacted credential
+func IsRedacted(value string) bool {
+	return value == RedactedSecret
+}
 
 // ErrorResponse represents an API error response
 type ErrorResponse struct {
-	Message string `json:"message"`
-	Error   string `json:"error,omitempty"`
+	Message   string `json:"message"`
+	Error     string `json:"error,omitempty"`
+	RequestID string `json:"request_id,omitempty"` // ID of the failed request in the gateway's logs
 }
 
 // HealthResponse represents a health check response
@@ -16,10 +31,12 @@ type HealthResponse struct {
 
 // Cluster represents a Throome cluster
 type Cluster struct {
-	ID        string    `json:"id"`
-	Name      string    `json:"name"`
-	Services  []Service `json:"services,omitempty"`
-	CreatedAt string    `json:"created_at"`
+	ID         string    `json:"id"`
+	Name       string    `json:"name"`
+	Services   []Service `json:"services,omitempty"`
+	CreatedAt  string    `json:"created_at"`
+	ArchivedAt string    `json:"archived_at,omitempty"`
+	DrainedAt  string    `json:"drained_at,omitempty"`
 }
 
 // Service represents a service in a cluster
@@ -47,7 +64,7 @@ type ServiceConfig struct {
 	Host      string `json:"host,omitempty"`     // Required when Provision is false
 	Port      int    `json:"port"`               // Required when Provision is false
 	Username  string `json:"username,omitempty"` // Required for databases when Provision is false
-	Password  string `json:"password,omitempty"` // Required for databases when Provision is false
+	Password  string `json:"password,omitempty"` // Required for databases when Provision is false, RedactedSecret when read back
 	Database  string `json:"database,omitempty"` // Required for databases when Provision is false
 }
 
@@ -57,6 +74,40 @@ type CreateClusterResponse struct {
 	Message   string `json:"message"`
 }
 
+// RoutingConfig represents the routing settings of a cluster
+type RoutingConfig struct {
+	Strategy        string               `json:"strategy"` // round_robin, weighted, least_connections, ai, latency, or one registered with the gateway
+	FailoverEnabled bool                 `json:"failover_enabled"`
+	TimeoutMS       int                  `json:"timeout_ms,omitempty"`
+	RetryAttempts   int                  `json:"retry_attempts,omitempty"`
+	CircuitBreaker  CircuitBreakerConfig `json:"circuit_breaker,omitempty"`
+	Sticky          StickyConfig         `json:"sticky,omitempty"`
+}
+
+// CircuitBreakerConfig represents the circuit breaker settings of a cluster
+type CircuitBreakerConfig struct {
+	Enabled          bool `json:"enabled"`
+	FailureThreshold int  `json:"failure_threshold,omitempty"`
+	ResetTimeout     int  `json:"reset_timeout,omitempty"` // seconds
+}
+
+// StickyConfig represents the sticky routing settings of a cluster
+type StickyConfig struct {
+	Enabled bool `json:"enabled"`
+	TTL     int  `json:"ttl,omitempty"` // seconds
+}
+
+// UpdateRoutingRequest changes the routing settings of a cluster. Settings left nil
+// are kept.
+type UpdateRoutingRequest struct {
+	Strategy        *string               `json:"strategy,omitempty"`
+	FailoverEnabled *bool                 `json:"failover_enabled,omitempty"`
+	TimeoutMS       *int                  `json:"timeout_ms,omitempty"`
+	RetryAttempts   *int                  `json:"retry_attempts,omitempty"`
+	CircuitBreaker  *CircuitBreakerConfig `json:"circuit_breaker,omitempty"`
+	Sticky          *StickyConfig         `json:"sticky,omitempty"`
+}
+
 // ClusterHealthResponse represents cluster health status
 type ClusterHealthResponse struct {
 	ClusterID string                   `json:"cluster_id"`
@@ -72,11 +123,66 @@ type ServiceHealth struct {
 
 // MetricsResponse represents cluster metrics
 type MetricsResponse struct {
-	Requests       int64   `json:"requests"`
-	Errors         int64   `json:"errors"`
-	AvgResponseMs  float64 `json:"avg_response_ms"`
-	P95ResponseMs  float64 `json:"p95_response_ms"`
-	ActiveServices int     `json:"active_services"`
+	ClusterID      string                    `json:"ClusterID"`
+	ServiceMetrics map[string]ServiceMetrics `json:"ServiceMetrics"`
+	LastUpdated    time.Time                 `json:"LastUpdated"`
+}
+
+// ServiceMetrics represents the request metrics of a service since the gateway
+// started
+type ServiceMetrics struct {
+	ServiceName       string        `json:"ServiceName"`
+	ServiceType       string        `json:"ServiceType"`
+	TotalRequests     int64         `json:"TotalRequests"`
+	FailedRequests    int64         `json:"FailedRequests"`
+	Retries           int64         `json:"Retries"`
+	RowsReturned      int64         `json:"RowsReturned"`  // Rows returned by queries
+	BytesReturned     int64         `json:"BytesReturned"` // Size of the values of the returned rows
+	SuccessRate       float64       `json:"SuccessRate"`   // Percentage
+	AverageLatency    time.Duration `json:"AverageLatency"`
+	MinLatency        time.Duration `json:"MinLatency"`
+	MaxLatency        time.Duration `json:"MaxLatency"`
+	P50Latency        time.Duration `json:"P50Latency"`
+	P95Latency        time.Duration `json:"P95Latency"`
+	P99Latency        time.Duration `json:"P99Latency"`
+	ActiveConnections int           `json:"ActiveConnections"`
+	LastRequestTime   time.Time     `json:"LastRequestTime"`
+	PoolStats         *PoolStats    `json:"PoolStats,omitempty"` // Latest connection pool usage, for pooled services
+}
+
+// PoolStats represents the connection pool usage of a service, collected periodically
+type PoolStats struct {
+	AcquiredConns        int           `json:"acquired_conns"`
+	IdleConns            int           `json:"idle_conns"`
+	TotalConns           int           `json:"total_conns"`
+	MaxConns             int           `json:"max_conns"`
+	AcquireCount         int64         `json:"acquire_count"`
+	EmptyAcquireCount    int64         `json:"empty_acquire_count"` // Acquires that had to wait for a connection
+	CanceledAcquireCount int64         `json:"canceled_acquire_count"`
+	AcquireDuration      time.Duration `json:"acquire_duration"` // Total time spent waiting to acquire
+	NewConnsCount        int64         `json:"new_conns_count"`
+	Timeouts             int64         `json:"timeouts"`
+}
+
+// MetricsHistoryResponse represents the per-minute request samples of a cluster's
+// services
+type MetricsHistoryResponse struct {
+	ClusterID  string                     `json:"cluster_id"`
+	Window     string                     `json:"window"`
+	Resolution string                     `json:"resolution"`
+	Services   map[string][]MetricsSample `json:"services"` // Oldest first
+}
+
+// MetricsSample represents the requests of a service during one minute
+type MetricsSample struct {
+	Time           time.Time     `json:"time"` // Start of the minute
+	Requests       int64         `json:"requests"`
+	FailedRequests int64         `json:"failed_requests"`
+	AverageLatency time.Duration `json:"average_latency"`
+	P50Latency     time.Duration `json:"p50_latency"`
+	P95Latency     time.Duration `json:"p95_latency"`
+	P99Latency     time.Duration `json:"p99_latency"`
+	MaxLatency     time.Duration `json:"max_latency"`
 }
 
 // ServiceInfo represents detailed service information
@@ -109,7 +215,32 @@ type ActivityLog struct {
 
 // ActivityFilters represents filters for activity logs
 type ActivityFilters struct {
-	Limit int
+	Limit     int
+	Offset    int    // Number of matching logs to skip, newest first
+	RequestID string // Only logs of the operations caused by the request with this ID
+}
+
+// query returns the query string of the filters
+func (f ActivityFilters) query() string {
+	values := url.Values{}
+	if f.Limit > 0 {
+		values.Set("limit", strconv.Itoa(f.Limit))
+	}
+	if f.Offset > 0 {
+		values.Set("offset", strconv.Itoa(f.Offset))
+	}
+	if f.RequestID != "" {
+		values.Set("request_id", f.RequestID)
+	}
+	if len(values) == 0 {
+		return ""
+	}
+	return "?" + values.Encode()
+}
+
+// activityPage is the response of the activity endpoints
+type activityPage struct {
+	Activities []ActivityLog `json:"activities"`
 }
 
 // LogOptions represents options for fetching service logs
@@ -129,6 +260,18 @@ type DBQueryResponse struct {
 	Rows []map[string]interface{} `json:"rows"`
 }
 
+// TxOptions are the options a transaction is begun with
+type TxOptions struct {
+	IsolationLevel string `json:"isolation_level,omitempty"` // e.g. "serializable", "read committed" by default
+	ReadOnly       bool   `json:"read_only,omitempty"`
+}
+
+// DBTransactionResponse represents a begun transaction
+type DBTransactionResponse struct {
+	TransactionID string `json:"transaction_id"`
+	ServiceName   string `json:"service_name"`
+}
+
 // CacheGetRequest represents a cache get request
 type CacheGetRequest struct {
 	Key string `json:"key"`
@@ -151,8 +294,55 @@ type CacheDeleteRequest struct {
 	Key string `json:"key"`
 }
 
+// CacheExpireRequest represents a cache expire request
+type CacheExpireRequest struct {
+	Key string `json:"key"`
+	TTL int    `json:"ttl"` // Seconds
+}
+
+// CacheExistsResponse represents a cache exists response
+type CacheExistsResponse struct {
+	Exists bool `json:"exists"`
+}
+
+// CacheTTLResponse represents a cache TTL response
+type CacheTTLResponse struct {
+	TTL int64 `json:"ttl"` // Seconds, -1 when the key does not expire and -2 when it does not exist
+}
+
+// CacheIncrResponse represents a cache increment response
+type CacheIncrResponse struct {
+	Value int64 `json:"value"`
+}
+
 // QueuePublishRequest represents a queue publish request
 type QueuePublishRequest struct {
 	Topic   string `json:"topic"`
 	Message []byte `json:"message"`
 }
+
+// Message is a message delivered to a queue subscriber
+type Message struct {
+	Topic     string            `json:"topic"`
+	Key       []byte            `json:"key,omitempty"`
+	Value     []byte            `json:"value,omitempty"`
+	Headers   map[string]string `json:"headers,omitempty"`
+	Timestamp *time.Time        `json:"timestamp,omitempty"`
+	Partition int               `json:"partition,omitempty"`
+	Offset    int64             `json:"offset,omitempty"`
+}
+
+// queueFrame is a frame the gateway sends to a queue subscriber
+type queueFrame struct {
+	Message
+	Type  string `json:"type"` // subscribed, message or error
+	ID    int64  `json:"id,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// queueAck is a frame a queue subscriber sends to settle a message
+type queueAck struct {
+	Type  string `json:"type"` // ack or nack
+	ID    int64  `json:"id"`
+	Error string `json:"error,omitempty"`
+}
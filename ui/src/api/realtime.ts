@@ -0,0 +1,87 @@
+import { useEffect, useRef, useState } from 'react'
+
+export type RealtimeEventType =
+  | 'health'
+  | 'metrics'
+  | 'activity'
+  | 'provisioning'
+  | 'subscribed'
+  | 'unsubscribed'
+  | 'error'
+
+export interface RealtimeEvent<T = any> {
+  type: RealtimeEventType
+  cluster_id?: string
+  timestamp: string
+  data?: T
+}
+
+export interface RealtimeSubscription {
+  cluster_id: string // Cluster ID, or '*' for all clusters
+  events?: RealtimeEventType[]
+}
+
+export type RealtimeStatus = 'connecting' | 'connected' | 'disconnected'
+
+const realtimeURL = () => {
+  const protocol = window.location.protocol === 'https:' ? 'wss:' : 'ws:'
+  return `${protocol}//${window.location.host}/api/v1/realtime`
+}
+
+// useRealtime keeps a WebSocket to the gateway open, subscribes to the given clusters
+// and reconnects with backoff when the connection drops
+export function useRealtime(
+  subscriptions: RealtimeSubscription[],
+  onEvent?: (event: RealtimeEvent) => void
+): RealtimeStatus {
+  const [status, setStatus] = useState<RealtimeStatus>('connecting')
+  const handlerRef = useRef(onEvent)
+  handlerRef.current = onEvent
+
+  const key = JSON.stringify(subscriptions)
+
+  useEffect(() => {
+    let socket: WebSocket | null = null
+    let retryTimer: ReturnType<typeof setTimeout> | undefined
+    let retryDelay = 1000
+    let closed = false
+
+    const connect = () => {
+      setStatus('connecting')
+      socket = new WebSocket(realtimeURL())
+
+      socket.onopen = () => {
+        retryDelay = 1000
+        setStatus('connected')
+        for (const subscription of subscriptions) {
+          socket?.send(JSON.stringify({ action: 'subscribe', ...subscription }))
+        }
+      }
+
+      socket.onmessage = (message) => {
+        try {
+          handlerRef.current?.(JSON.parse(message.data) as RealtimeEvent)
+        } catch {
+          // Ignore malformed messages
+        }
+      }
+
+      socket.onclose = () => {
+        if (closed) return
+        setStatus('disconnected')
+        retryTimer = setTimeout(connect, retryDelay)
+        retryDelay = Math.min(retryDelay * 2, 30000)
+      }
+    }
+
+    connect()
+
+    return () => {
+      closed = true
+      clearTimeout(retryTimer)
+      socket?.close()
+    }
+  }, [key])
+
+  return status
+}
@@ -1,31 +1,19 @@
 import { useEffect, useState } from 'react'
 import { Activity, AlertCircle } from 'lucide-react'
-import { checkHealth } from '@/api/client'
+import { useRealtime } from '@/api/realtime'
 
 export default function ConnectionStatus() {
-  const [isConnected, setIsConnected] = useState<boolean | null>(null)
+  // The realtime socket doubles as the connection indicator, so no polling is needed
+  const status = useRealtime([])
   const [lastCheck, setLastCheck] = useState<Date | null>(null)
 
-  const checkConnection = async () => {
-    try {
-      await checkHealth()
-      setIsConnected(true)
-      setLastCheck(new Date())
-    } catch (error) {
-      setIsConnected(false)
+  useEffect(() => {
+    if (status !== 'connecting') {
       setLastCheck(new Date())
     }
-  }
-
-  useEffect(() => {
-    // Initial check
-    checkConnection()
-
-    // Check every 10 seconds
-    const interval = setInterval(checkConnection, 10000)
+  }, [status])
 
-    return () => clearInterval(interval)
-  }, [])
+  const isConnected = status === 'connecting' ? null : status === 'connected'
 
   if (isConnected === null) {
     return (
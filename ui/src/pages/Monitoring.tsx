@@ -1,5 +1,6 @@
 import { useState, useEffect } from 'react'
 import { getActivity, Activity, ActivityFilters as Filters } from '@/api/client'
+import { useRealtime, RealtimeEvent } from '@/api/realtime'
 import { toast } from 'sonner'
 import { RefreshCw, X, Clock, CheckCircle2, XCircle, Code, Copy, Check } from 'lucide-react'
 import ActivityFilters from '@/components/ActivityFilters'
@@ -8,8 +9,7 @@ export default function Monitoring() {
   const [activities, setActivities] = useState<Activity[]>([])
   const [loading, setLoading] = useState(true)
   const [filters, setFilters] = useState<Filters>({ limit: 100 })
-  const [autoRefresh, setAutoRefresh] = useState(false)
-  const [refreshInterval] = useState(5000) // 5 seconds
+  const [live, setLive] = useState(true)
   const [selectedActivity, setSelectedActivity] = useState<Activity | null>(null)
   const [copied, setCopied] = useState(false)
 
@@ -31,15 +31,23 @@ export default function Monitoring() {
     fetchActivities()
   }, [filters])
 
-  useEffect(() => {
-    if (autoRefresh) {
-      const interval = setInterval(() => {
-        fetchActivities()
-      }, refreshInterval)
+  // Stream new entries over the realtime socket instead of polling
+  const matchesFilters = (activity: Activity) =>
+    (!filters.service_name || activity.service_name === filters.service_name) &&
+    (!filters.service_type || activity.service_type === filters.service_type) &&
+    (!filters.operation || activity.operation === filters.operation) &&
+    (!filters.status || activity.status === filters.status)
 
-      return () => clearInterval(interval)
-    }
-  }, [autoRefresh, refreshInterval, filters])
+  const handleRealtimeEvent = (event: RealtimeEvent<Activity>) => {
+    if (event.type !== 'activity' || !event.data || !matchesFilters(event.data)) return
+    const activity = event.data
+    setActivities((current) => [activity, ...current].slice(0, filters.limit || 100))
+  }
+
+  const realtimeStatus = useRealtime(
+    live ? [{ cluster_id: filters.cluster_id || '*', events: ['activity'] }] : [],
+    handleRealtimeEvent
+  )
 
   const handleRefresh = () => {
     fetchActivities()
@@ -116,19 +124,19 @@ export default function Monitoring() {
               </p>
             </div>
             <div className="flex items-center space-x-3">
-              {/* Auto-refresh toggle */}
+              {/* Live toggle */}
               <div className="flex items-center space-x-2">
                 <label className="relative inline-flex items-center cursor-pointer">
                   <input
                     type="checkbox"
-                    checked={autoRefresh}
-                    onChange={(e) => setAutoRefresh(e.target.checked)}
+                    checked={live}
+                    onChange={(e) => setLive(e.target.checked)}
                     className="sr-only peer"
                   />
                   <div className="w-11 h-6 bg-border peer-focus:outline-none peer-focus:ring-4 peer-focus:ring-primary/20 rounded-full peer peer-checked:after:translate-x-full peer-checked:after:border-white after:content-[''] after:absolute after:top-[2px] after:left-[2px] after:bg-white after:border-gray-300 after:border after:rounded-full after:h-5 after:w-5 after:transition-all peer-checked:bg-primary"></div>
                 </label>
                 <span className="text-xs text-muted-foreground">
-                  Auto-refresh ({refreshInterval / 1000}s)
+                  Live{live && realtimeStatus !== 'connected' ? ' (reconnecting)' : ''}
                 </span>
               </div>
 